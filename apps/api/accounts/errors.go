@@ -0,0 +1,17 @@
+package accounts
+
+import "errors"
+
+// Sentinel errors returned by the accounts service
+var (
+	ErrDatabaseNotConfigured = errors.New("database not configured - please add DATABASE_URL_POOLED in Environment Settings")
+	ErrSignupDisabled        = errors.New("signup is disabled")
+	ErrInvalidEmail          = errors.New("invalid email address")
+	ErrWeakPassword          = errors.New("password must be between 8 and 256 characters")
+	ErrEmailTaken            = errors.New("an account with this email already exists")
+	ErrUserNotFound          = errors.New("user not found")
+	ErrInvalidCredentials    = errors.New("invalid email or password")
+	ErrUserDisabled          = errors.New("account is disabled")
+	ErrInvalidRefreshToken   = errors.New("invalid or expired refresh token")
+	ErrRefreshTokenReused    = errors.New("refresh token was already used; sign in again")
+)
@@ -0,0 +1,226 @@
+// Package accounts manages user accounts: signup and login with argon2id
+// password hashes, short-lived JWT access tokens, and rotating refresh
+// tokens.
+package accounts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"sync"
+	"time"
+
+	"agentic-template/api/auth"
+	"agentic-template/api/db"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Password length limits
+const (
+	MinPasswordLength = 8
+	MaxPasswordLength = 256
+)
+
+// Config configures token issuance and signup
+type Config struct {
+	Issuer        *auth.TokenIssuer // Signs access tokens
+	RefreshTTL    time.Duration     // Lifetime of a refresh token
+	SignupEnabled bool              // Allow anyone to create an account
+}
+
+// TokenPair is the credentials returned by signup, login, and refresh
+type TokenPair struct {
+	AccessToken      string    `json:"access_token"`
+	TokenType        string    `json:"token_type"` // Always "Bearer"
+	ExpiresIn        int64     `json:"expires_in"` // Seconds until the access token expires
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+	User             *User     `json:"user"`
+}
+
+// Service signs users up and in and rotates their tokens
+type Service struct {
+	pool   *pgxpool.Pool
+	config Config
+}
+
+// NewService creates a new accounts service
+func NewService(pool *pgxpool.Pool, cfg Config) *Service {
+	return &Service{
+		pool:   pool,
+		config: cfg,
+	}
+}
+
+// Signup creates an account and signs it in
+func (s *Service) Signup(ctx context.Context, email, password string) (*TokenPair, error) {
+	if !s.config.SignupEnabled {
+		return nil, ErrSignupDisabled
+	}
+	email = normalizeEmail(email)
+	if address, err := mail.ParseAddress(email); err != nil || address.Address != email {
+		return nil, ErrInvalidEmail
+	}
+	if len(password) < MinPasswordLength || len(password) > MaxPasswordLength {
+		return nil, ErrWeakPassword
+	}
+
+	passwordHash, err := HashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+
+	var tokens *TokenPair
+	err = s.inTx(ctx, func(tx pgx.Tx) error {
+		user, err := insertUser(ctx, tx, email, passwordHash)
+		if err != nil {
+			return err
+		}
+		tokens, err = s.issue(ctx, tx, user, uuid.New())
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// Login checks a user's password and signs them in
+func (s *Service) Login(ctx context.Context, email, password string) (*TokenPair, error) {
+	var tokens *TokenPair
+	err := s.inTx(ctx, func(tx pgx.Tx) error {
+		user, err := userByEmail(ctx, tx, email)
+		if errors.Is(err, ErrUserNotFound) {
+			// Hash anyway so response times don't reveal which emails exist
+			_, _ = VerifyPassword(password, dummyHash())
+			return ErrInvalidCredentials
+		}
+		if err != nil {
+			return err
+		}
+
+		ok, err := VerifyPassword(password, user.passwordHash)
+		if err != nil {
+			return fmt.Errorf("failed to verify password: %w", err)
+		}
+		if !ok {
+			return ErrInvalidCredentials
+		}
+		if user.Disabled {
+			return ErrUserDisabled
+		}
+
+		if _, err := tx.Exec(ctx, `UPDATE users SET last_login_at = NOW() WHERE id = $1`, user.ID); err != nil {
+			return fmt.Errorf("failed to record login: %w", err)
+		}
+		tokens, err = s.issue(ctx, tx, user, uuid.New())
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// Refresh exchanges a refresh token for new tokens. The user's roles are
+// read again, so role changes apply from the next refresh.
+func (s *Service) Refresh(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	var tokens *TokenPair
+	err := s.inTx(ctx, func(tx pgx.Tx) error {
+		userID, family, err := consumeRefreshToken(ctx, tx, refreshToken)
+		if err != nil {
+			return err
+		}
+
+		user, err := userByID(ctx, tx, userID)
+		if err != nil {
+			return err
+		}
+		if user.Disabled {
+			return ErrUserDisabled
+		}
+		tokens, err = s.issue(ctx, tx, user, family)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// Logout revokes a refresh token and every token rotated from the same
+// login. Access tokens stay valid until they expire.
+func (s *Service) Logout(ctx context.Context, refreshToken string) error {
+	return s.inTx(ctx, func(tx pgx.Tx) error {
+		_, family, err := consumeRefreshToken(ctx, tx, refreshToken)
+		if errors.Is(err, ErrRefreshTokenReused) {
+			// The family was revoked already
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return revokeFamily(ctx, tx, family)
+	})
+}
+
+// issue creates an access token and a refresh token in the given family
+func (s *Service) issue(ctx context.Context, tx pgx.Tx, user *User, family uuid.UUID) (*TokenPair, error) {
+	accessToken, expiresAt, err := s.config.Issuer.Issue(user.Subject(), user.Roles)
+	if err != nil {
+		return nil, err
+	}
+	refreshToken, refreshExpiresAt, err := insertRefreshToken(ctx, tx, user.ID, family, s.config.RefreshTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:      accessToken,
+		TokenType:        "Bearer",
+		ExpiresIn:        int64(time.Until(expiresAt).Seconds()),
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: refreshExpiresAt,
+		User:             user,
+	}, nil
+}
+
+// inTx runs fn in a transaction, committing unless it fails. Refresh token
+// reuse is committed too, so the family stays revoked.
+func (s *Service) inTx(ctx context.Context, fn func(tx pgx.Tx) error) error {
+	if s.pool == nil {
+		return ErrDatabaseNotConfigured
+	}
+
+	var reused error
+	err := db.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
+		reused = nil
+		err := fn(tx)
+		if errors.Is(err, ErrRefreshTokenReused) {
+			reused = err
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return reused
+}
+
+var (
+	dummyHashOnce  sync.Once
+	dummyHashValue string
+)
+
+// dummyHash returns a hash to verify against when a login's email is unknown
+func dummyHash() string {
+	dummyHashOnce.Do(func() {
+		dummyHashValue, _ = HashPassword(uuid.NewString())
+	})
+	return dummyHashValue
+}
@@ -0,0 +1,92 @@
+package accounts
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// refreshTokenBytes is the entropy of a refresh token
+const refreshTokenBytes = 32
+
+// hashRefreshToken returns the stored form of a refresh token. Tokens are
+// random, so an unsalted fast hash is enough.
+func hashRefreshToken(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
+
+// insertRefreshToken issues a refresh token in a token family
+func insertRefreshToken(ctx context.Context, tx pgx.Tx, userID int, family uuid.UUID, ttl time.Duration) (string, time.Time, error) {
+	raw := make([]byte, refreshTokenBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+	expiresAt := time.Now().Add(ttl)
+
+	query := `
+		INSERT INTO refresh_tokens (user_id, family, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4)
+	`
+	if _, err := tx.Exec(ctx, query, userID, family, hashRefreshToken(token), expiresAt); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to store refresh token: %w", err)
+	}
+	return token, expiresAt, nil
+}
+
+// consumeRefreshToken revokes a live refresh token and returns its user and
+// family. Presenting an already revoked token means it leaked, so its
+// whole family is revoked; the caller must commit for that to stick.
+func consumeRefreshToken(ctx context.Context, tx pgx.Tx, token string) (int, uuid.UUID, error) {
+	var (
+		id        int
+		userID    int
+		family    uuid.UUID
+		expiresAt time.Time
+		revokedAt *time.Time
+	)
+	query := `
+		SELECT id, user_id, family, expires_at, revoked_at
+		FROM refresh_tokens
+		WHERE token_hash = $1
+		FOR UPDATE
+	`
+	err := tx.QueryRow(ctx, query, hashRefreshToken(token)).Scan(&id, &userID, &family, &expiresAt, &revokedAt)
+	if err == pgx.ErrNoRows {
+		return 0, uuid.Nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return 0, uuid.Nil, fmt.Errorf("failed to query refresh token: %w", err)
+	}
+
+	if revokedAt != nil {
+		if err := revokeFamily(ctx, tx, family); err != nil {
+			return 0, uuid.Nil, err
+		}
+		return 0, uuid.Nil, ErrRefreshTokenReused
+	}
+	if time.Now().After(expiresAt) {
+		return 0, uuid.Nil, ErrInvalidRefreshToken
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE refresh_tokens SET revoked_at = NOW() WHERE id = $1`, id); err != nil {
+		return 0, uuid.Nil, fmt.Errorf("failed to revoke refresh token: %w", err)
+	}
+	return userID, family, nil
+}
+
+// revokeFamily revokes every live token descended from the same login
+func revokeFamily(ctx context.Context, tx pgx.Tx, family uuid.UUID) error {
+	query := `UPDATE refresh_tokens SET revoked_at = NOW() WHERE family = $1 AND revoked_at IS NULL`
+	if _, err := tx.Exec(ctx, query, family); err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package accounts
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// SubjectPrefix starts the principal ID of every user, e.g. "user:42"
+const SubjectPrefix = "user:"
+
+// User is an account signing in with an email and password
+type User struct {
+	ID          int        `json:"id"`
+	Email       string     `json:"email"`
+	Roles       []string   `json:"roles"`
+	Disabled    bool       `json:"disabled"`
+	LastLoginAt *time.Time `json:"last_login_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+
+	passwordHash string
+}
+
+// Subject returns the user's principal ID, recorded as the actor of the
+// changes they make
+func (u *User) Subject() string {
+	return SubjectPrefix + strconv.Itoa(u.ID)
+}
+
+// userColumns is the column list shared by user queries
+const userColumns = `id, email, password_hash, roles, disabled, last_login_at, created_at, updated_at`
+
+// normalizeEmail makes emails compare case-insensitively
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// insertUser creates a user. The first user of a new deployment becomes an
+// admin so someone can manage the rest; the table lock keeps two first
+// signups from both qualifying.
+func insertUser(ctx context.Context, tx pgx.Tx, email, passwordHash string) (*User, error) {
+	if _, err := tx.Exec(ctx, `LOCK TABLE users IN SHARE ROW EXCLUSIVE MODE`); err != nil {
+		return nil, fmt.Errorf("failed to lock users: %w", err)
+	}
+
+	query := `
+		INSERT INTO users (email, password_hash, roles)
+		VALUES ($1, $2, CASE WHEN EXISTS (SELECT 1 FROM users) THEN '{}'::TEXT[] ELSE '{admin}'::TEXT[] END)
+		RETURNING ` + userColumns
+	user, err := scanUser(tx.QueryRow(ctx, query, email, passwordHash))
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return nil, ErrEmailTaken
+		}
+		return nil, fmt.Errorf("failed to insert user: %w", err)
+	}
+	return user, nil
+}
+
+// userByEmail retrieves a user by email
+func userByEmail(ctx context.Context, q pgx.Tx, email string) (*User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE lower(email) = $1`
+	user, err := scanUser(q.QueryRow(ctx, query, normalizeEmail(email)))
+	if err == pgx.ErrNoRows {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query user: %w", err)
+	}
+	return user, nil
+}
+
+// userByID retrieves a user by ID
+func userByID(ctx context.Context, q pgx.Tx, id int) (*User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE id = $1`
+	user, err := scanUser(q.QueryRow(ctx, query, id))
+	if err == pgx.ErrNoRows {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query user: %w", err)
+	}
+	return user, nil
+}
+
+// scanUser scans a row of userColumns
+func scanUser(row pgx.Row) (*User, error) {
+	var user User
+	err := row.Scan(
+		&user.ID,
+		&user.Email,
+		&user.passwordHash,
+		&user.Roles,
+		&user.Disabled,
+		&user.LastLoginAt,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
@@ -0,0 +1,81 @@
+// Package adminui serves the admin frontend bundled into the API binary.
+// The frontend is a single-page app built as static files into dist/.
+package adminui
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+//go:embed all:dist
+var distFS embed.FS
+
+// indexFile is served for app routes the browser loads directly
+const indexFile = "index.html"
+
+// Files returns the frontend's files: those under dir when it is set,
+// otherwise the embedded build
+func Files(dir string) (fs.FS, error) {
+	if dir != "" {
+		files := os.DirFS(dir)
+		if _, err := fs.Stat(files, indexFile); err != nil {
+			return nil, err
+		}
+		return files, nil
+	}
+	return fs.Sub(distFS, "dist")
+}
+
+// Handler serves the frontend's files from a route with a *path parameter.
+// Paths without a file extension that match no file get index.html so the
+// app's client-side router can handle them; missing assets are 404s.
+func Handler(files fs.FS) gin.HandlerFunc {
+	fileServer := http.FS(files)
+
+	return func(c *gin.Context) {
+		name := strings.TrimPrefix(path.Clean("/"+c.Param("path")), "/")
+		if name == "" {
+			name = indexFile
+		}
+
+		info, err := fs.Stat(files, name)
+		if err == nil && info.IsDir() {
+			name = path.Join(name, indexFile)
+			info, err = fs.Stat(files, name)
+		}
+		if err != nil {
+			if path.Ext(name) != "" {
+				c.Status(http.StatusNotFound)
+				return
+			}
+			name = indexFile
+		}
+
+		if path.Base(name) == indexFile {
+			serveIndex(c, files, name)
+			return
+		}
+
+		// Build tools fingerprint asset names, so assets can be cached
+		c.Header("Cache-Control", "public, max-age=3600")
+		c.FileFromFS(name, fileServer)
+	}
+}
+
+// serveIndex writes an index.html uncached, so deploys take effect on the
+// next page load. http.FileServer would redirect it to its directory.
+func serveIndex(c *gin.Context, files fs.FS, name string) {
+	data, err := fs.ReadFile(files, name)
+	if err != nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	c.Header("Cache-Control", "no-cache")
+	c.Data(http.StatusOK, "text/html; charset=utf-8", data)
+}
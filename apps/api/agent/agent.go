@@ -5,7 +5,10 @@ import (
 	"fmt"
 	"strings"
 
+	"agentic-template/api/agent/mockllm"
+	"agentic-template/api/agent/tooluse"
 	"github.com/tmc/langchaingo/agents"
+	"github.com/tmc/langchaingo/callbacks"
 	"github.com/tmc/langchaingo/chains"
 	"github.com/tmc/langchaingo/llms"
 	"github.com/tmc/langchaingo/llms/anthropic"
@@ -14,50 +17,134 @@ import (
 	"github.com/tmc/langchaingo/memory"
 	"github.com/tmc/langchaingo/schema"
 	"github.com/tmc/langchaingo/tools"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Agent represents an AI agent with tools and memory
 type Agent struct {
-	llm      llms.Model
-	memory   schema.Memory
-	tools    []tools.Tool
-	executor *agents.Executor
-	provider string
+	llm           llms.Model
+	memory        schema.Memory
+	tools         []tools.Tool
+	executor      *agents.Executor
+	provider      string
+	model         string
+	systemPrompt  string
+	name          string
+	maxIterations int
+	callbacks     callbacks.Handler
+	toolModel     tooluse.Model // Native tool use; nil falls back to the conversational agent
+	temperature   float64
+	maxTokens     int
+	stream        *streamHandler // Forwards streamed output to the current run
+	approveTool   ToolApprover
+	cite          CitationHandler
+	screenTool    ToolScreen
+	images        []Image // Sent to the model with the query
+	route         *Route  // How the model was picked; nil when not routed
 }
 
 // Config holds agent configuration
 type Config struct {
-	Provider     string
-	APIKey       string
-	Model        string
-	Temperature  float64
-	MaxTokens    int
+	Provider      string
+	APIKey        string
+	Model         string
+	Temperature   float64
+	MaxTokens     int
+	SystemPrompt  string            // Optional persona/instructions from an agent profile
+	Name          string            // Identifies the agent in multi-agent streams
+	MaxIterations int               // Max reasoning/tool iterations (0 uses the default)
+	Callbacks     callbacks.Handler // Optional hooks for LLM, agent, and tool events
+	// Memory builds the conversation memory from the agent's LLM.
+	// nil uses an in-process conversation buffer.
+	Memory        func(llm llms.Model) (schema.Memory, error)
 	StreamingFunc func(ctx context.Context, chunk []byte) error
+	MockFixture   string          // Fixture file replayed by the "mock" provider
+	ApproveTool   ToolApprover    // Optional check run before every tool call
+	Style         ResponseStyle   // Optional language and tone hints added to the system prompt
+	Cite          CitationHandler // Optional receiver of the sources tool calls drew on
+	SchemaContext string          // Optional summary of the database schema added to the system prompt
+	Images        []Image         // Optional images sent with the query; routes to a vision model if needed
+	Route         *Route          // Optional routing decision that picked Model, counted in the routing metrics
+	ScreenTool    ToolScreen      // Optional check of tool traffic for injected instructions
 }
 
+// ToolApprover decides whether the agent may call a tool with the given
+// input. A rejection is returned to the agent as the tool's output, with the
+// reason, so it can change course.
+type ToolApprover func(ctx context.Context, agentName, toolName, input string) (approved bool, reason string, err error)
+
+// ToolScreen checks tool traffic for instructions injected through table
+// rows and documents: the output of a tool that returned them (output true)
+// before the agent reads it, and the input of every tool call before it
+// runs. It returns the text to use, or a rejection passed to the agent in
+// place of the output or the call.
+type ToolScreen func(ctx context.Context, agentName, toolName, text string, output bool) (screened, rejection string, err error)
+
+// DefaultMaxIterations is the iteration limit used when none is configured
+const DefaultMaxIterations = 10
+
+// DefaultAgentName is the name of an agent created without one
+const DefaultAgentName = "assistant"
+
 // NewAgent creates a new AI agent with the specified configuration
 func NewAgent(cfg Config) (*Agent, error) {
+	// Route queries with images to a model that can see them
+	if len(cfg.Images) > 0 {
+		provider := strings.ToLower(cfg.Provider)
+		if !acceptsImages(provider, getModelName(provider, cfg.Model)) {
+			vision, ok := visionModels[provider]
+			if !ok {
+				return nil, fmt.Errorf("%w: %s", ErrImagesUnsupported, cfg.Provider)
+			}
+			cfg.Model = vision
+		}
+	}
+
 	// Create LLM based on provider
 	var llm llms.Model
+	var toolModel tooluse.Model
 	var err error
 
 	switch strings.ToLower(cfg.Provider) {
 	case "openai":
-		llm, err = openai.New(
+		opts := []openai.Option{
 			openai.WithToken(cfg.APIKey),
 			openai.WithModel(getModelName(cfg.Provider, cfg.Model)),
-		)
+		}
+		if cfg.Callbacks != nil {
+			opts = append(opts, openai.WithCallback(cfg.Callbacks))
+		}
+		llm, err = openai.New(opts...)
 	case "anthropic":
-		llm, err = anthropic.New(
+		var anthropicLLM *anthropic.LLM
+		anthropicLLM, err = anthropic.New(
 			anthropic.WithToken(cfg.APIKey),
 			anthropic.WithModel(getModelName(cfg.Provider, cfg.Model)),
 		)
+		if err == nil {
+			anthropicLLM.CallbacksHandler = cfg.Callbacks
+			toolModel, err = tooluse.NewAnthropic(cfg.APIKey, getModelName(cfg.Provider, cfg.Model))
+		}
+		llm = anthropicLLM
 	case "google":
-		llm, err = googleai.New(
+		var googleLLM *googleai.GoogleAI
+		googleLLM, err = googleai.New(
 			context.Background(),
 			googleai.WithAPIKey(cfg.APIKey),
 			googleai.WithDefaultModel(getModelName(cfg.Provider, cfg.Model)),
 		)
+		if err == nil {
+			googleLLM.CallbacksHandler = cfg.Callbacks
+			toolModel, err = tooluse.NewGemini(cfg.APIKey, getModelName(cfg.Provider, cfg.Model))
+		}
+		llm = googleLLM
+	case "mock":
+		var mockLLM *mockllm.LLM
+		mockLLM, err = mockllm.NewFromFile(cfg.MockFixture)
+		if err == nil {
+			mockLLM.CallbacksHandler = cfg.Callbacks
+		}
+		llm = mockLLM
 	default:
 		return nil, fmt.Errorf("unsupported provider: %s", cfg.Provider)
 	}
@@ -66,20 +153,82 @@ func NewAgent(cfg Config) (*Agent, error) {
 		return nil, fmt.Errorf("failed to create LLM: %w", err)
 	}
 
+	// Trace every model call, retrying transient provider errors behind the
+	// provider's circuit breaker
+	modelName := getModelName(cfg.Provider, cfg.Model)
+	provider := strings.ToLower(cfg.Provider)
+	llm = &resilientModel{Model: &tracedModel{Model: llm, provider: cfg.Provider, model: modelName}, provider: provider}
+	if toolModel != nil {
+		toolModel = &resilientToolModel{Model: &tracedToolModel{Model: toolModel, provider: cfg.Provider, model: modelName}, provider: provider}
+	}
+
 	// Create conversation memory
-	mem := memory.NewConversationBuffer()
+	var mem schema.Memory = memory.NewConversationBuffer()
+	if cfg.Memory != nil {
+		mem, err = cfg.Memory(llm)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create memory: %w", err)
+		}
+	}
 
 	// Create agent
 	agent := &Agent{
-		llm:      llm,
-		memory:   mem,
-		tools:    []tools.Tool{},
-		provider: cfg.Provider,
+		llm:           llm,
+		memory:        mem,
+		tools:         []tools.Tool{},
+		provider:      cfg.Provider,
+		model:         modelName,
+		systemPrompt:  composeSystemPrompt(cfg.SystemPrompt, cfg.SchemaContext, cfg.Style.Instructions()),
+		name:          cfg.Name,
+		maxIterations: cfg.MaxIterations,
+		callbacks:     cfg.Callbacks,
+		toolModel:     toolModel,
+		temperature:   cfg.Temperature,
+		maxTokens:     cfg.MaxTokens,
+		approveTool:   cfg.ApproveTool,
+		cite:          cfg.Cite,
+		screenTool:    cfg.ScreenTool,
+		images:        cfg.Images,
+		route:         cfg.Route,
+	}
+
+	if agent.name == "" {
+		agent.name = DefaultAgentName
+	}
+	if agent.maxIterations <= 0 {
+		agent.maxIterations = DefaultMaxIterations
 	}
 
 	return agent, nil
 }
 
+// defaultPersona leads the system prompt when sections are added to an
+// agent without one, since they replace the provider's default prompt
+const defaultPersona = "You are a helpful AI assistant."
+
+// composeSystemPrompt appends the non-empty sections to a system prompt
+func composeSystemPrompt(systemPrompt string, sections ...string) string {
+	for _, section := range sections {
+		if section == "" {
+			continue
+		}
+		if systemPrompt == "" {
+			systemPrompt = defaultPersona
+		}
+		systemPrompt += "\n\n" + section
+	}
+	return systemPrompt
+}
+
+// conversationalToolsPrefix keeps the tool listing of the conversational
+// agent's default prompt when a custom system prompt replaces its persona
+const conversationalToolsPrefix = `TOOLS:
+------
+
+Assistant has access to the following tools:
+
+{{.tool_descriptions}}`
+
 // getModelName returns the appropriate model name for each provider
 func getModelName(provider, model string) string {
 	if model != "" {
@@ -110,33 +259,89 @@ func (a *Agent) Initialize() error {
 		return fmt.Errorf("no tools added to agent")
 	}
 
+	// Trace tool calls
+	agentTools := make([]tools.Tool, len(a.tools))
+	for i, tool := range a.tools {
+		agentTools[i] = &tracedTool{Tool: tool, handler: a.callbacks, agentName: a.name, approve: a.approveTool, cite: a.cite, screen: a.screenTool}
+	}
+
+	// Stream output through the callbacks handler alongside any configured
+	// callbacks. Agents other than function and tool-use agents write their
+	// plan as text, so only their final answer is streamed.
+	a.stream = &streamHandler{}
+	var handler callbacks.Handler = a.stream
+	switch a.provider {
+	case "openai", "anthropic", "google":
+	default:
+		a.stream.finalOnly = true
+	}
+	if a.callbacks != nil {
+		handler = callbacks.CombiningHandler{Callbacks: []callbacks.Handler{a.callbacks, a.stream}}
+	}
+
+	executorOpts := []agents.CreationOption{
+		agents.WithMemory(a.memory),
+		agents.WithMaxIterations(a.maxIterations),
+		agents.WithCallbacksHandler(handler),
+	}
+
+	// Agents that prompt with text get the query's images added by the model
+	var llm llms.Model = a.llm
+	if len(a.images) > 0 {
+		llm = &imageModel{Model: a.llm, images: a.images}
+	}
+
 	// Create the agent executor based on provider
-	var executor *agents.Executor
+	var executor agents.Executor
 	var err error
 
 	switch a.provider {
 	case "openai":
 		// Use OpenAI Functions agent for OpenAI models
+		opts := []agents.CreationOption{
+			agents.WithMaxIterations(a.maxIterations),
+			agents.WithCallbacksHandler(handler),
+		}
+		if a.systemPrompt != "" {
+			opts = append(opts, agents.NewOpenAIOption().WithSystemMessage(a.systemPrompt))
+		}
 		agentInstance := agents.NewOpenAIFunctionsAgent(
-			a.llm,
-			a.tools,
-			agents.WithMaxIterations(10),
+			llm,
+			agentTools,
+			opts...,
+		)
+		executor = agents.NewExecutor(
+			agentInstance,
+			agentTools,
+			executorOpts...,
 		)
+	case "anthropic", "google":
+		// Use native tool use for Anthropic and Gemini models
+		agentInstance := tooluse.NewAgent(a.toolModel, agentTools, a.systemPrompt)
+		agentInstance.Temperature = a.temperature
+		agentInstance.MaxTokens = a.maxTokens
+		agentInstance.CallbacksHandler = handler
+		agentInstance.Images = a.images
 		executor = agents.NewExecutor(
 			agentInstance,
-			a.tools,
-			agents.WithMemory(a.memory),
+			agentTools,
+			executorOpts...,
 		)
 	default:
 		// Use conversational agent for other providers
+		opts := []agents.CreationOption{agents.WithCallbacksHandler(handler)}
+		if a.systemPrompt != "" {
+			opts = append(opts, agents.WithPromptPrefix(a.systemPrompt+"\n\n"+conversationalToolsPrefix))
+		}
 		agentInstance := agents.NewConversationalAgent(
-			a.llm,
-			a.tools,
+			llm,
+			agentTools,
+			opts...,
 		)
 		executor = agents.NewExecutor(
 			agentInstance,
-			a.tools,
-			agents.WithMemory(a.memory),
+			agentTools,
+			executorOpts...,
 		)
 	}
 
@@ -144,7 +349,7 @@ func (a *Agent) Initialize() error {
 		return fmt.Errorf("failed to create agent executor: %w", err)
 	}
 
-	a.executor = executor
+	a.executor = &executor
 	return nil
 }
 
@@ -154,7 +359,9 @@ func (a *Agent) Run(ctx context.Context, input string) (string, error) {
 		return "", fmt.Errorf("agent not initialized")
 	}
 
+	ctx, span := a.startRunSpan(ctx)
 	result, err := chains.Run(ctx, a.executor, input)
+	endSpan(span, err)
 	if err != nil {
 		return "", fmt.Errorf("agent execution failed: %w", err)
 	}
@@ -172,16 +379,19 @@ func (a *Agent) RunWithCallback(
 		return fmt.Errorf("agent not initialized")
 	}
 
-	// Create a custom chain with callback
-	chain := chains.NewChain(a.executor)
-	
-	// Run the chain with streaming
-	_, err := chain.Call(ctx, map[string]any{
+	// Run the executor with streaming
+	a.stream.setCallback(callback)
+	defer a.stream.setCallback(nil)
+
+	ctx, span := a.startRunSpan(ctx)
+	_, err := chains.Call(ctx, a.executor, map[string]any{
 		"input": input,
-	}, chains.WithStreamingFunc(func(ctx context.Context, chunk []byte) error {
-		return callback(string(chunk))
-	}))
+	})
+	if streamErr := a.stream.callbackErr(); streamErr != nil {
+		err = streamErr
+	}
 
+	endSpan(span, err)
 	return err
 }
 
@@ -191,11 +401,120 @@ func (a *Agent) GetMemory() schema.Memory {
 }
 
 // ClearMemory clears the agent's conversation memory
-func (a *Agent) ClearMemory() {
-	a.memory.Clear()
+func (a *Agent) ClearMemory(ctx context.Context) error {
+	return a.memory.Clear(ctx)
+}
+
+// Name returns the agent's name
+func (a *Agent) Name() string {
+	return a.name
+}
+
+// LLM returns the agent's model, for prompts outside its runs
+func (a *Agent) LLM() llms.Model {
+	return a.llm
+}
+
+// Model returns the name of the model the agent calls
+func (a *Agent) Model() string {
+	return a.model
+}
+
+// Route returns how the agent's model was picked, or nil if it wasn't routed
+func (a *Agent) Route() *Route {
+	return a.route
 }
 
 // GetTools returns the agent's tools
 func (a *Agent) GetTools() []tools.Tool {
 	return a.tools
-}
\ No newline at end of file
+}
+
+// tracedTool records a span per tool call, asks the optional approver before
+// running the tool, reports the tool's input, output, and errors to an
+// optional callbacks handler, and the sources of citing tools to an optional
+// citation handler. An optional screen checks the input and the output of
+// citing tools for injected instructions.
+type tracedTool struct {
+	tools.Tool
+	handler   callbacks.Handler
+	agentName string
+	approve   ToolApprover
+	cite      CitationHandler
+	screen    ToolScreen
+}
+
+// Call runs the wrapped tool and reports the result
+func (t *tracedTool) Call(ctx context.Context, input string) (string, error) {
+	ctx, span := tracer.Start(ctx, "tool.call "+t.Name(), trace.WithAttributes(attrToolName.String(t.Name())))
+
+	if t.handler != nil {
+		t.handler.HandleToolStart(ctx, input)
+	}
+
+	output, err := t.call(ctx, input)
+	endSpan(span, err)
+	if err != nil {
+		if t.handler != nil {
+			t.handler.HandleToolError(ctx, err)
+		}
+		return "", err
+	}
+	if t.handler != nil {
+		t.handler.HandleToolEnd(ctx, output)
+	}
+	return output, nil
+}
+
+// call runs the wrapped tool once the screen and the approver, if any,
+// allow it
+func (t *tracedTool) call(ctx context.Context, input string) (string, error) {
+	if t.screen != nil {
+		screened, rejection, err := t.screen(ctx, t.agentName, t.Name(), input, false)
+		if err != nil {
+			return "", fmt.Errorf("tool screening failed: %w", err)
+		}
+		if rejection != "" {
+			return rejection, nil
+		}
+		input = screened
+	}
+
+	if t.approve != nil {
+		approved, reason, err := t.approve(ctx, t.agentName, t.Name(), input)
+		if err != nil {
+			return "", fmt.Errorf("tool approval failed: %w", err)
+		}
+		if !approved {
+			if reason == "" {
+				reason = "no reason given"
+			}
+			return fmt.Sprintf("The user rejected this call to %s: %s", t.Name(), reason), nil
+		}
+	}
+
+	citer, ok := t.Tool.(CitingTool)
+	if !ok || (t.cite == nil && t.screen == nil) {
+		return t.Tool.Call(ctx, input)
+	}
+	output, citations, err := citer.CallWithCitations(ctx, input)
+	if err != nil {
+		return "", err
+	}
+	if len(citations) > 0 && t.cite != nil {
+		t.cite(t.agentName, citations)
+	}
+
+	// Rows and documents may carry instructions aimed at the agent
+	if t.screen != nil {
+		screened, rejection, err := t.screen(ctx, t.agentName, t.Name(), output, true)
+		if err != nil {
+			return "", fmt.Errorf("tool screening failed: %w", err)
+		}
+		if rejection != "" {
+			return rejection, nil
+		}
+		output = screened
+	}
+	return output, nil
+}
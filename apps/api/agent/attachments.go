@@ -0,0 +1,82 @@
+package agent
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"slices"
+	"strings"
+
+	"agentic-template/api/agent/tooluse"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/schema"
+)
+
+// Image is a picture sent with a query to a multimodal model
+type Image = tooluse.Image
+
+// ImageTypes lists the image formats multimodal models accept
+var ImageTypes = []string{"image/png", "image/jpeg", "image/gif", "image/webp"}
+
+// ErrImagesUnsupported is returned when a query has images and the provider
+// has no model that can see them
+var ErrImagesUnsupported = errors.New("provider does not accept images")
+
+// visionModels is the model a query with images is routed to when the
+// provider's configured model can't see them
+var visionModels = map[string]string{
+	"openai":    "gpt-4o",
+	"anthropic": "claude-3-5-sonnet-20241022",
+	"google":    "gemini-1.5-pro",
+}
+
+// IsImageType reports whether a MIME type is an image format models accept
+func IsImageType(mimeType string) bool {
+	return slices.Contains(ImageTypes, mimeType)
+}
+
+// acceptsImages reports whether a provider's model can see images
+func acceptsImages(provider, model string) bool {
+	switch provider {
+	case "openai":
+		for _, prefix := range []string{"gpt-4o", "chatgpt-4o", "gpt-4.1", "gpt-4.5", "gpt-5", "o1", "o3", "o4", "gpt-4-turbo-20"} {
+			if strings.HasPrefix(model, prefix) {
+				return true
+			}
+		}
+		return model == "gpt-4-turbo" || strings.Contains(model, "vision")
+	case "anthropic":
+		return !strings.HasPrefix(model, "claude-2") && !strings.HasPrefix(model, "claude-instant")
+	case "google":
+		return model != "gemini-pro" && !strings.HasPrefix(model, "gemini-1.0")
+	case "mock":
+		return true
+	default:
+		return false
+	}
+}
+
+// imageModel adds the query's images to the last user message of each call,
+// for agents that build their messages from text prompts
+type imageModel struct {
+	llms.Model
+	images []Image
+}
+
+// GenerateContent sends the messages with the images as data URLs
+func (m *imageModel) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role != schema.ChatMessageTypeHuman {
+			continue
+		}
+		parts := append([]llms.ContentPart(nil), messages[i].Parts...)
+		for _, image := range m.images {
+			parts = append(parts, llms.ImageURLPart("data:"+image.MIMEType+";base64,"+base64.StdEncoding.EncodeToString(image.Data)))
+		}
+		messages = append([]llms.MessageContent(nil), messages...)
+		messages[i].Parts = parts
+		break
+	}
+	return m.Model.GenerateContent(ctx, messages, options...)
+}
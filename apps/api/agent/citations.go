@@ -0,0 +1,47 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+)
+
+// Kinds of cited sources
+const (
+	CitationRow      = "row"      // A record of a database table
+	CitationDocument = "document" // A chunk of an ingested document
+)
+
+// Citation points at a source a tool's output drew on, so clients can link
+// an answer to the rows and documents behind it
+type Citation struct {
+	Kind       string  `json:"kind"`                  // row or document
+	Tool       string  `json:"tool"`                  // Tool that returned the source
+	Table      string  `json:"table,omitempty"`       // Cited table (rows)
+	RowID      *int64  `json:"row_id,omitempty"`      // Cited record; nil for a result computed over the table
+	Column     string  `json:"column,omitempty"`      // Column the answer used, when known
+	DocumentID int     `json:"document_id,omitempty"` // Cited document (documents)
+	Title      string  `json:"title,omitempty"`       // Document title
+	ChunkIndex int     `json:"chunk_index,omitempty"` // Position of the chunk in the document
+	Similarity float64 `json:"similarity,omitempty"`  // Cosine similarity to the query, 1.0 is identical
+}
+
+// String describes the cited source in run traces
+func (c Citation) String() string {
+	switch {
+	case c.Kind == CitationDocument:
+		return fmt.Sprintf("document %d %q chunk %d (similarity %.3f)", c.DocumentID, c.Title, c.ChunkIndex, c.Similarity)
+	case c.RowID != nil:
+		return fmt.Sprintf("%s row %d", c.Table, *c.RowID)
+	default:
+		return fmt.Sprintf("table %s", c.Table)
+	}
+}
+
+// CitingTool is a tool that reports the sources of its output
+type CitingTool interface {
+	CallWithCitations(ctx context.Context, input string) (string, []Citation, error)
+}
+
+// CitationHandler receives the sources a tool call of the named agent drew
+// on, before the agent sees its output
+type CitationHandler func(agentName string, citations []Citation)
@@ -0,0 +1,199 @@
+package conversation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// Session is the overview of a conversation shown in history lists
+type Session struct {
+	ConversationID string
+	Title          string // Short LLM-generated title; empty until the first turn is titled
+	Recap          string // Rolling LLM summary of the whole conversation
+	Turns          int    // Completed turns folded into the recap
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// SessionListOptions pages ListSessions
+type SessionListOptions struct {
+	Limit  int
+	Offset int
+}
+
+// Paging limits for ListSessions
+const (
+	DefaultSessionListLimit = 50
+	MaxSessionListLimit     = 500
+)
+
+// titlePrompt asks the LLM for a history list title from the first turn
+const titlePrompt = `Write a short title (at most 6 words) for a conversation that starts with the exchange below. Reply with the title only, without quotes or trailing punctuation.
+
+User: %s
+Assistant: %s
+
+Title:`
+
+// recapPrompt asks the LLM to fold a turn into the conversation's recap
+const recapPrompt = `Update the summary of a conversation with its latest exchange. Keep it to two or three sentences covering what the user asked for and what was found or decided.
+
+Current summary:
+%s
+
+Latest exchange:
+User: %s
+Assistant: %s
+
+New summary:`
+
+// maxTitleLength caps, in characters, a generated title that ignored the
+// prompt's length
+const maxTitleLength = 80
+
+// sessionColumns lists the session columns in scanSession order
+const sessionColumns = `conversation_id, title, recap, turns, created_at, updated_at`
+
+// GetSession returns the overview of a conversation, or nil if it has none
+func (s *Store) GetSession(ctx context.Context, conversationID string) (*Session, error) {
+	if s.pool == nil {
+		return nil, fmt.Errorf("database not configured - please add DATABASE_URL_POOLED in Environment Settings")
+	}
+
+	session, err := scanSession(s.pool.QueryRow(ctx,
+		`SELECT `+sessionColumns+` FROM conversation_memory WHERE conversation_id = $1`,
+		conversationID,
+	))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to query conversation session: %w", err)
+	}
+	return session, nil
+}
+
+// ListSessions returns the conversations that completed a turn, most
+// recently active first
+func (s *Store) ListSessions(ctx context.Context, opts SessionListOptions) ([]Session, error) {
+	if s.pool == nil {
+		return nil, fmt.Errorf("database not configured - please add DATABASE_URL_POOLED in Environment Settings")
+	}
+
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = DefaultSessionListLimit
+	}
+	if limit > MaxSessionListLimit {
+		limit = MaxSessionListLimit
+	}
+	offset := opts.Offset
+	if offset < 0 {
+		offset = 0
+	}
+
+	rows, err := s.pool.Query(ctx,
+		`SELECT `+sessionColumns+` FROM conversation_memory
+		WHERE turns > 0
+		ORDER BY updated_at DESC, conversation_id LIMIT $1 OFFSET $2`,
+		limit, offset,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query conversation sessions: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := []Session{}
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan conversation session: %w", err)
+		}
+		sessions = append(sessions, *session)
+	}
+
+	return sessions, rows.Err()
+}
+
+// SaveSession replaces the title, recap, and turn count of a conversation,
+// leaving its memory as it is
+func (s *Store) SaveSession(ctx context.Context, session *Session) error {
+	if s.pool == nil {
+		return fmt.Errorf("database not configured - please add DATABASE_URL_POOLED in Environment Settings")
+	}
+
+	query := `
+		INSERT INTO conversation_memory (conversation_id, title, recap, turns)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (conversation_id) DO UPDATE SET title = EXCLUDED.title, recap = EXCLUDED.recap, turns = EXCLUDED.turns`
+	if _, err := s.pool.Exec(ctx, query, session.ConversationID, session.Title, session.Recap, session.Turns); err != nil {
+		return fmt.Errorf("failed to save conversation session: %w", err)
+	}
+
+	return nil
+}
+
+// RecordTurn folds a completed turn into the conversation's session: the
+// first turn gets a title and every turn updates the rolling recap, both
+// generated by the LLM
+func (s *Store) RecordTurn(ctx context.Context, llm llms.Model, conversationID, input, output string) (*Session, error) {
+	session, err := s.GetSession(ctx, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		session = &Session{ConversationID: conversationID}
+	}
+
+	if session.Title == "" {
+		title, err := llms.GenerateFromSinglePrompt(ctx, llm, fmt.Sprintf(titlePrompt, input, output))
+		if err != nil {
+			return nil, fmt.Errorf("failed to title conversation: %w", err)
+		}
+		session.Title = cleanTitle(title)
+	}
+
+	recap, err := llms.GenerateFromSinglePrompt(ctx, llm, fmt.Sprintf(recapPrompt, session.Recap, input, output))
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize conversation: %w", err)
+	}
+	session.Recap = strings.TrimSpace(recap)
+	session.Turns++
+
+	if err := s.SaveSession(ctx, session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// cleanTitle trims the quotes, punctuation, and extra lines a model may
+// wrap a title in
+func cleanTitle(title string) string {
+	title, _, _ = strings.Cut(strings.TrimSpace(title), "\n")
+	title = strings.Trim(strings.TrimSpace(title), "\"'`*.")
+	if runes := []rune(title); len(runes) > maxTitleLength {
+		title = strings.TrimSpace(string(runes[:maxTitleLength])) + "..."
+	}
+	return title
+}
+
+// scanSession scans a session row in sessionColumns order
+func scanSession(row pgx.Row) (*Session, error) {
+	var session Session
+	if err := row.Scan(
+		&session.ConversationID,
+		&session.Title,
+		&session.Recap,
+		&session.Turns,
+		&session.CreatedAt,
+		&session.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
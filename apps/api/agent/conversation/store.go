@@ -0,0 +1,171 @@
+package conversation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"agentic-template/api/agent"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/tmc/langchaingo/schema"
+)
+
+// State is the persisted memory of a conversation
+type State struct {
+	Summary  string
+	Messages []schema.ChatMessage
+}
+
+// storedMessage is the JSON form of a chat message
+type storedMessage struct {
+	Role    schema.ChatMessageType `json:"role"`
+	Content string                 `json:"content"`
+}
+
+// Store persists conversation memory per conversation ID
+type Store struct {
+	pool *pgxpool.Pool
+}
+
+// NewStore creates a new conversation memory store
+func NewStore(pool *pgxpool.Pool) *Store {
+	return &Store{
+		pool: pool,
+	}
+}
+
+// Load returns the memory of a conversation, or an empty state if none exists
+func (s *Store) Load(ctx context.Context, conversationID string) (*State, error) {
+	if s.pool == nil {
+		return nil, fmt.Errorf("database not configured - please add DATABASE_URL_POOLED in Environment Settings")
+	}
+
+	var summary string
+	var messagesJSON []byte
+	err := s.pool.QueryRow(ctx,
+		`SELECT summary, messages FROM conversation_memory WHERE conversation_id = $1`,
+		conversationID,
+	).Scan(&summary, &messagesJSON)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return &State{}, nil
+		}
+		return nil, fmt.Errorf("failed to query conversation memory: %w", err)
+	}
+
+	var stored []storedMessage
+	if err := json.Unmarshal(messagesJSON, &stored); err != nil {
+		return nil, fmt.Errorf("failed to decode conversation messages: %w", err)
+	}
+
+	state := &State{Summary: summary}
+	for _, msg := range stored {
+		state.Messages = append(state.Messages, toChatMessage(msg))
+	}
+
+	return state, nil
+}
+
+// Save replaces the memory of a conversation
+func (s *Store) Save(ctx context.Context, conversationID string, state *State) error {
+	if s.pool == nil {
+		return fmt.Errorf("database not configured - please add DATABASE_URL_POOLED in Environment Settings")
+	}
+
+	stored := make([]storedMessage, 0, len(state.Messages))
+	for _, msg := range state.Messages {
+		stored = append(stored, storedMessage{Role: msg.GetType(), Content: msg.GetContent()})
+	}
+	messagesJSON, err := json.Marshal(stored)
+	if err != nil {
+		return fmt.Errorf("failed to encode conversation messages: %w", err)
+	}
+
+	query := `
+		INSERT INTO conversation_memory (conversation_id, summary, messages)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (conversation_id) DO UPDATE SET summary = EXCLUDED.summary, messages = EXCLUDED.messages`
+	if _, err := s.pool.Exec(ctx, query, conversationID, state.Summary, messagesJSON); err != nil {
+		return fmt.Errorf("failed to save conversation memory: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes the memory of a conversation
+func (s *Store) Delete(ctx context.Context, conversationID string) error {
+	if s.pool == nil {
+		return fmt.Errorf("database not configured - please add DATABASE_URL_POOLED in Environment Settings")
+	}
+
+	if _, err := s.pool.Exec(ctx, `DELETE FROM conversation_memory WHERE conversation_id = $1`, conversationID); err != nil {
+		return fmt.Errorf("failed to delete conversation memory: %w", err)
+	}
+
+	return nil
+}
+
+// LoadStyle returns the response style saved for a conversation, or an
+// empty style if none was
+func (s *Store) LoadStyle(ctx context.Context, conversationID string) (agent.ResponseStyle, error) {
+	var style agent.ResponseStyle
+	if s.pool == nil {
+		return style, fmt.Errorf("database not configured - please add DATABASE_URL_POOLED in Environment Settings")
+	}
+
+	var styleJSON []byte
+	err := s.pool.QueryRow(ctx,
+		`SELECT response_style FROM conversation_memory WHERE conversation_id = $1`,
+		conversationID,
+	).Scan(&styleJSON)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return style, nil
+		}
+		return style, fmt.Errorf("failed to query conversation style: %w", err)
+	}
+
+	if err := json.Unmarshal(styleJSON, &style); err != nil {
+		return style, fmt.Errorf("failed to decode conversation style: %w", err)
+	}
+	return style, nil
+}
+
+// SaveStyle replaces the response style of a conversation, leaving its
+// memory as it is
+func (s *Store) SaveStyle(ctx context.Context, conversationID string, style agent.ResponseStyle) error {
+	if s.pool == nil {
+		return fmt.Errorf("database not configured - please add DATABASE_URL_POOLED in Environment Settings")
+	}
+
+	styleJSON, err := json.Marshal(style)
+	if err != nil {
+		return fmt.Errorf("failed to encode conversation style: %w", err)
+	}
+
+	query := `
+		INSERT INTO conversation_memory (conversation_id, response_style)
+		VALUES ($1, $2)
+		ON CONFLICT (conversation_id) DO UPDATE SET response_style = EXCLUDED.response_style`
+	if _, err := s.pool.Exec(ctx, query, conversationID, styleJSON); err != nil {
+		return fmt.Errorf("failed to save conversation style: %w", err)
+	}
+
+	return nil
+}
+
+// toChatMessage converts a stored message back to its chat message type
+func toChatMessage(msg storedMessage) schema.ChatMessage {
+	switch msg.Role {
+	case schema.ChatMessageTypeAI:
+		return schema.AIChatMessage{Content: msg.Content}
+	case schema.ChatMessageTypeSystem:
+		return schema.SystemChatMessage{Content: msg.Content}
+	case schema.ChatMessageTypeHuman:
+		return schema.HumanChatMessage{Content: msg.Content}
+	default:
+		return schema.GenericChatMessage{Role: string(msg.Role), Content: msg.Content}
+	}
+}
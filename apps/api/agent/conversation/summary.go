@@ -0,0 +1,249 @@
+package conversation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/memory"
+	"github.com/tmc/langchaingo/schema"
+)
+
+// Memory strategies selectable per agent profile
+const (
+	StrategyBuffer  = "buffer"  // Keep every turn in process
+	StrategySummary = "summary" // Token-window buffer with LLM summarization of older turns
+)
+
+// DefaultMaxTokens is the buffer size before older turns are summarized
+const DefaultMaxTokens = 2000
+
+// summaryPrompt asks the LLM to fold pruned turns into the running summary
+const summaryPrompt = `Progressively summarize the lines of conversation provided, adding onto the previous summary and returning a new summary. Keep names, numbers, decisions, and open questions.
+
+Current summary:
+%s
+
+New lines of conversation:
+%s
+
+New summary:`
+
+// SummaryMemory keeps recent turns verbatim within a token budget and
+// summarizes older turns with the LLM. When a store and conversation ID are
+// set the memory is loaded from and persisted to the database.
+type SummaryMemory struct {
+	mu             sync.Mutex
+	llm            llms.Model
+	store          *Store
+	conversationID string
+	maxTokens      int
+	history        *memory.ChatMessageHistory
+	summary        string
+	memoryKey      string
+	humanPrefix    string
+	aiPrefix       string
+}
+
+// Statically assert that SummaryMemory implements the memory interface
+var _ schema.Memory = &SummaryMemory{}
+
+// SummaryConfig configures a summarizing memory
+type SummaryConfig struct {
+	LLM            llms.Model
+	Store          *Store // Optional; nil keeps memory in process
+	ConversationID string // Required for persistence
+	MaxTokens      int    // 0 uses DefaultMaxTokens
+}
+
+// NewSummaryMemory creates a summarizing memory, loading any persisted state
+func NewSummaryMemory(ctx context.Context, cfg SummaryConfig) (*SummaryMemory, error) {
+	if cfg.LLM == nil {
+		return nil, fmt.Errorf("summary memory requires an LLM")
+	}
+
+	m := &SummaryMemory{
+		llm:            cfg.LLM,
+		store:          cfg.Store,
+		conversationID: cfg.ConversationID,
+		maxTokens:      cfg.MaxTokens,
+		history:        memory.NewChatMessageHistory(),
+		memoryKey:      "history",
+		humanPrefix:    "Human",
+		aiPrefix:       "AI",
+	}
+	if m.maxTokens <= 0 {
+		m.maxTokens = DefaultMaxTokens
+	}
+
+	if m.persistent() {
+		state, err := m.store.Load(ctx, m.conversationID)
+		if err != nil {
+			return nil, err
+		}
+		m.summary = state.Summary
+		if err := m.history.SetMessages(ctx, state.Messages); err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+// persistent reports whether the memory is backed by the store
+func (m *SummaryMemory) persistent() bool {
+	return m.store != nil && m.conversationID != ""
+}
+
+// GetMemoryKey returns the prompt variable the memory fills
+func (m *SummaryMemory) GetMemoryKey(context.Context) string {
+	return m.memoryKey
+}
+
+// MemoryVariables returns the prompt variables the memory fills
+func (m *SummaryMemory) MemoryVariables(context.Context) []string {
+	return []string{m.memoryKey}
+}
+
+// LoadMemoryVariables returns the summary followed by the recent turns
+func (m *SummaryMemory) LoadMemoryVariables(ctx context.Context, _ map[string]any) (map[string]any, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	messages, err := m.history.Messages(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.summary != "" {
+		summary := schema.SystemChatMessage{Content: "Summary of the earlier conversation: " + m.summary}
+		messages = append([]schema.ChatMessage{summary}, messages...)
+	}
+
+	buffer, err := schema.GetBufferString(messages, m.humanPrefix, m.aiPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]any{m.memoryKey: buffer}, nil
+}
+
+// SaveContext records a turn, summarizes turns that no longer fit in the
+// token budget, and persists the result
+func (m *SummaryMemory) SaveContext(ctx context.Context, inputs map[string]any, outputs map[string]any) error {
+	input, err := singleValue(inputs, "input")
+	if err != nil {
+		return err
+	}
+	output, err := singleValue(outputs, "output")
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.history.AddUserMessage(ctx, input); err != nil {
+		return err
+	}
+	if err := m.history.AddAIMessage(ctx, output); err != nil {
+		return err
+	}
+
+	if err := m.prune(ctx); err != nil {
+		return err
+	}
+
+	return m.save(ctx)
+}
+
+// prune moves the oldest turns into the summary until the buffer fits
+func (m *SummaryMemory) prune(ctx context.Context) error {
+	messages, err := m.history.Messages(ctx)
+	if err != nil {
+		return err
+	}
+
+	var pruned []schema.ChatMessage
+	for len(messages) > 2 && m.countTokens(messages) > m.maxTokens {
+		pruned = append(pruned, messages[0])
+		messages = messages[1:]
+	}
+	if len(pruned) == 0 {
+		return nil
+	}
+
+	lines, err := schema.GetBufferString(pruned, m.humanPrefix, m.aiPrefix)
+	if err != nil {
+		return err
+	}
+
+	summary, err := llms.GenerateFromSinglePrompt(ctx, m.llm, fmt.Sprintf(summaryPrompt, m.summary, lines))
+	if err != nil {
+		return fmt.Errorf("failed to summarize conversation: %w", err)
+	}
+
+	m.summary = strings.TrimSpace(summary)
+	return m.history.SetMessages(ctx, append([]schema.ChatMessage(nil), messages...))
+}
+
+// countTokens estimates the tokens used by the buffered messages
+func (m *SummaryMemory) countTokens(messages []schema.ChatMessage) int {
+	buffer, err := schema.GetBufferString(messages, m.humanPrefix, m.aiPrefix)
+	if err != nil {
+		return 0
+	}
+	return llms.CountTokens("", buffer)
+}
+
+// save persists the current state when the memory is backed by the store
+func (m *SummaryMemory) save(ctx context.Context) error {
+	if !m.persistent() {
+		return nil
+	}
+
+	messages, err := m.history.Messages(ctx)
+	if err != nil {
+		return err
+	}
+
+	return m.store.Save(ctx, m.conversationID, &State{Summary: m.summary, Messages: messages})
+}
+
+// Clear forgets the summary and all turns
+func (m *SummaryMemory) Clear(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.summary = ""
+	if err := m.history.Clear(ctx); err != nil {
+		return err
+	}
+
+	if m.persistent() {
+		return m.store.Delete(ctx, m.conversationID)
+	}
+	return nil
+}
+
+// singleValue returns the string under key, or the only value in the map
+func singleValue(values map[string]any, key string) (string, error) {
+	value, ok := values[key]
+	if !ok && len(values) == 1 {
+		for _, v := range values {
+			value = v
+		}
+		ok = true
+	}
+	if !ok {
+		return "", fmt.Errorf("memory values do not contain key %s", key)
+	}
+
+	text, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("memory value for %s is not a string", key)
+	}
+	return text, nil
+}
@@ -0,0 +1,182 @@
+package guardrails
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Action is what a policy does when it triggers
+type Action string
+
+const (
+	ActionLog    Action = "log"    // Report the violation and pass the text through
+	ActionRedact Action = "redact" // Replace the offending text
+	ActionBlock  Action = "block"  // Stop the text from reaching the agent or client
+)
+
+// Policy names reported in violations
+const (
+	PolicyPII             = "pii"
+	PolicyPromptInjection = "prompt_injection"
+	PolicyBlockedTopic    = "blocked_topic"
+)
+
+// Stages at which text is screened
+const (
+	StageInput  = "input"
+	StageOutput = "output"
+)
+
+// Config selects the policies to apply and their actions.
+// An empty action disables the policy.
+type Config struct {
+	PIIAction       Action
+	InjectionAction Action
+	BlockedTopics   []string
+	TopicAction     Action
+	// ToolInjectionAction screens tool content for injected instructions:
+	// log, sanitize, approve, or block
+	ToolInjectionAction Action
+}
+
+// Violation describes a triggered policy
+type Violation struct {
+	Policy string
+	Action Action
+	Detail string
+}
+
+// Result is the outcome of screening a piece of text
+type Result struct {
+	Text       string // Possibly redacted text
+	Blocked    bool
+	Violations []Violation
+}
+
+// Triggered reports whether any policy fired
+func (r *Result) Triggered() bool {
+	return len(r.Violations) > 0
+}
+
+// rule is a named pattern within a policy
+type rule struct {
+	name        string
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// piiRules detect common personal data in free text
+var piiRules = []rule{
+	{name: "email", pattern: regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`), replacement: "[REDACTED EMAIL]"},
+	{name: "ssn", pattern: regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`), replacement: "[REDACTED SSN]"},
+	{name: "credit_card", pattern: regexp.MustCompile(`\b(?:\d[ -]?){13,16}\b`), replacement: "[REDACTED CARD]"},
+	{name: "phone", pattern: regexp.MustCompile(`(?:\+?\d{1,2}[ .\-]?)?\(?\b\d{3}\)?[ .\-]?\d{3}[ .\-]?\d{4}\b`), replacement: "[REDACTED PHONE]"},
+}
+
+// injectionRules are heuristics for attempts to override the agent's instructions
+var injectionRules = []rule{
+	{name: "ignore_instructions", pattern: regexp.MustCompile(`(?i)\b(ignore|disregard|forget)\b.{0,30}\b(previous|prior|above|earlier|all)\b.{0,30}\b(instructions|prompts?|rules|directions)\b`)},
+	{name: "reveal_prompt", pattern: regexp.MustCompile(`(?i)\b(reveal|print|show|repeat|output)\b.{0,30}\b(system prompt|hidden instructions|initial instructions)\b`)},
+	{name: "role_override", pattern: regexp.MustCompile(`(?i)\byou are (now|no longer)\b|\bact as (an? )?(unrestricted|jailbroken)\b|\bdeveloper mode\b`)},
+	{name: "fake_system_message", pattern: regexp.MustCompile(`(?im)^\s*(system|assistant)\s*:`)},
+}
+
+// Guard screens agent inputs and outputs against configured policies
+type Guard struct {
+	piiAction       Action
+	injectionAction Action
+	topicAction     Action
+	topicRules      []rule
+	toolAction      Action
+}
+
+// New creates a guard from the given configuration
+func New(cfg Config) (*Guard, error) {
+	for _, action := range []Action{cfg.PIIAction, cfg.InjectionAction, cfg.TopicAction} {
+		if err := validateAction(action); err != nil {
+			return nil, err
+		}
+	}
+	if err := validateToolAction(cfg.ToolInjectionAction); err != nil {
+		return nil, err
+	}
+
+	g := &Guard{
+		piiAction:       cfg.PIIAction,
+		injectionAction: cfg.InjectionAction,
+		topicAction:     cfg.TopicAction,
+		toolAction:      cfg.ToolInjectionAction,
+	}
+
+	for _, topic := range cfg.BlockedTopics {
+		topic = strings.TrimSpace(topic)
+		if topic == "" {
+			continue
+		}
+		g.topicRules = append(g.topicRules, rule{
+			name:        topic,
+			pattern:     regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(topic) + `\b`),
+			replacement: "[REDACTED]",
+		})
+	}
+
+	return g, nil
+}
+
+// validateAction checks that an action is known (empty disables a policy)
+func validateAction(action Action) error {
+	switch action {
+	case "", ActionLog, ActionRedact, ActionBlock:
+		return nil
+	default:
+		return fmt.Errorf("invalid guardrail action: %s", action)
+	}
+}
+
+// CheckInput screens a user query before it reaches the agent
+func (g *Guard) CheckInput(text string) Result {
+	result := Result{Text: text}
+	g.apply(&result, PolicyPromptInjection, g.injectionAction, injectionRules)
+	g.apply(&result, PolicyBlockedTopic, g.topicAction, g.topicRules)
+	g.apply(&result, PolicyPII, g.piiAction, piiRules)
+	return result
+}
+
+// CheckOutput screens agent output before it reaches the client
+func (g *Guard) CheckOutput(text string) Result {
+	result := Result{Text: text}
+	g.apply(&result, PolicyBlockedTopic, g.topicAction, g.topicRules)
+	g.apply(&result, PolicyPII, g.piiAction, piiRules)
+	return result
+}
+
+// apply runs one policy's rules over the result text
+func (g *Guard) apply(result *Result, policy string, action Action, rules []rule) {
+	if action == "" {
+		return
+	}
+
+	for _, r := range rules {
+		if !r.pattern.MatchString(result.Text) {
+			continue
+		}
+
+		result.Violations = append(result.Violations, Violation{
+			Policy: policy,
+			Action: action,
+			Detail: r.name,
+		})
+
+		switch action {
+		case ActionBlock:
+			result.Blocked = true
+		case ActionRedact:
+			replacement := r.replacement
+			if replacement == "" {
+				replacement = "[REDACTED]"
+			}
+			result.Text = r.pattern.ReplaceAllString(result.Text, replacement)
+		}
+	}
+}
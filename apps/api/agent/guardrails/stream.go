@@ -0,0 +1,89 @@
+package guardrails
+
+import (
+	"strings"
+)
+
+// maxBufferedOutput bounds how much streamed output is held back while
+// waiting for a segment boundary
+const maxBufferedOutput = 2000
+
+// StreamFilter screens streamed output. Chunks are buffered until a line or
+// sentence boundary so that patterns split across chunks are still caught.
+type StreamFilter struct {
+	guard   *Guard
+	buffer  strings.Builder
+	blocked bool
+}
+
+// NewStreamFilter creates a stream filter for the guard
+func NewStreamFilter(guard *Guard) *StreamFilter {
+	return &StreamFilter{
+		guard: guard,
+	}
+}
+
+// Write adds a chunk and returns the screened text that is ready to send.
+// ok is false when nothing is ready yet or the stream has been blocked.
+func (f *StreamFilter) Write(chunk string) (result Result, ok bool) {
+	if f.blocked {
+		return Result{}, false
+	}
+
+	f.buffer.WriteString(chunk)
+	buffered := f.buffer.String()
+
+	cut := lastBoundary(buffered)
+	if cut <= 0 && len(buffered) < maxBufferedOutput {
+		return Result{}, false
+	}
+	if cut <= 0 {
+		cut = len(buffered)
+	}
+
+	f.buffer.Reset()
+	f.buffer.WriteString(buffered[cut:])
+	return f.screen(buffered[:cut])
+}
+
+// Flush screens and returns any remaining buffered output
+func (f *StreamFilter) Flush() (Result, bool) {
+	if f.blocked || f.buffer.Len() == 0 {
+		return Result{}, false
+	}
+
+	remaining := f.buffer.String()
+	f.buffer.Reset()
+	return f.screen(remaining)
+}
+
+// Blocked reports whether a block policy has stopped the stream
+func (f *StreamFilter) Blocked() bool {
+	return f.blocked
+}
+
+// screen checks a segment and latches the blocked state
+func (f *StreamFilter) screen(segment string) (Result, bool) {
+	result := f.guard.CheckOutput(segment)
+	if result.Blocked {
+		f.blocked = true
+		result.Text = ""
+	}
+	return result, true
+}
+
+// lastBoundary returns the index just past the last line or sentence break
+func lastBoundary(text string) int {
+	if i := strings.LastIndex(text, "\n"); i >= 0 {
+		return i + 1
+	}
+	for i := len(text) - 2; i >= 0; i-- {
+		switch text[i] {
+		case '.', '!', '?':
+			if text[i+1] == ' ' {
+				return i + 2
+			}
+		}
+	}
+	return 0
+}
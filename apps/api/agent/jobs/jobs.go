@@ -0,0 +1,248 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"agentic-template/api/agent"
+	"agentic-template/api/agent/runs"
+	"agentic-template/api/requestctx"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrJobNotFound is returned when an agent job does not exist
+var ErrJobNotFound = errors.New("agent job not found")
+
+// Job statuses
+const (
+	StatusPending    = "PENDING"
+	StatusProcessing = "PROCESSING"
+	StatusCompleted  = "COMPLETED"
+	StatusFailed     = "FAILED"
+)
+
+// Request is the agent run a job performs
+type Request struct {
+	Query                   string               `json:"query"`
+	ConversationID          string               `json:"conversation_id,omitempty"`
+	Metadata                map[string]string    `json:"metadata,omitempty"`
+	ProfileID               *int                 `json:"profile_id,omitempty"`
+	DelegateProfileIDs      []int                `json:"delegate_profile_ids,omitempty"`
+	MaxIterations           int                  `json:"max_iterations,omitempty"`
+	IterationTimeoutSeconds int                  `json:"iteration_timeout_seconds,omitempty"`
+	TimeoutSeconds          int                  `json:"timeout_seconds,omitempty"`
+	ResponseStyle           *agent.ResponseStyle `json:"response_style,omitempty"`
+}
+
+// Job is a queued asynchronous agent run
+type Job struct {
+	ID           int          `json:"id"`
+	Request      Request      `json:"request"`
+	Status       string       `json:"status"` // PENDING, PROCESSING, COMPLETED, FAILED
+	Output       string       `json:"output"`
+	Events       []runs.Event `json:"events"`
+	ErrorMessage *string      `json:"error_message,omitempty"`
+	CreatedAt    time.Time    `json:"created_at"`
+	UpdatedAt    time.Time    `json:"updated_at"`
+	StartedAt    *time.Time   `json:"started_at,omitempty"`
+	CompletedAt  *time.Time   `json:"completed_at,omitempty"`
+	CreatedBy    *string      `json:"created_by,omitempty"` // Principal that submitted the job
+}
+
+// Store persists the agent job queue
+type Store struct {
+	pool *pgxpool.Pool
+}
+
+// NewStore creates a new job store
+func NewStore(pool *pgxpool.Pool) *Store {
+	return &Store{
+		pool: pool,
+	}
+}
+
+// jobColumns is the column list shared by job queries
+const jobColumns = `id, request, status, output, events, error_message, created_at, updated_at, started_at, completed_at, created_by`
+
+// Enqueue inserts a pending job submitted by the actor of ctx and returns it
+func (s *Store) Enqueue(ctx context.Context, req Request) (*Job, error) {
+	if s.pool == nil {
+		return nil, fmt.Errorf("database not configured - please add DATABASE_URL_POOLED in Environment Settings")
+	}
+	if req.Query == "" {
+		return nil, fmt.Errorf("query is required")
+	}
+
+	requestJSON, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode job request: %w", err)
+	}
+
+	query := `
+		INSERT INTO agent_jobs (request, status, created_by)
+		VALUES ($1, $2, $3)
+		RETURNING ` + jobColumns
+	job, err := scanJob(s.pool.QueryRow(ctx, query, requestJSON, StatusPending, requestctx.Actor(ctx)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create agent job: %w", err)
+	}
+
+	return job, nil
+}
+
+// Claim marks the oldest pending job as processing and returns it, or nil
+// when the queue is empty. Concurrent workers never claim the same job.
+func (s *Store) Claim(ctx context.Context) (*Job, error) {
+	if s.pool == nil {
+		return nil, fmt.Errorf("database not configured - please add DATABASE_URL_POOLED in Environment Settings")
+	}
+
+	query := `
+		UPDATE agent_jobs
+		SET status = $1, started_at = NOW()
+		WHERE id = (
+			SELECT id FROM agent_jobs
+			WHERE status = $2
+			ORDER BY created_at, id
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + jobColumns
+	job, err := scanJob(s.pool.QueryRow(ctx, query, StatusProcessing, StatusPending))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to claim agent job: %w", err)
+	}
+
+	return job, nil
+}
+
+// AppendProgress appends streamed output and events to a processing job
+func (s *Store) AppendProgress(ctx context.Context, id int, output string, events []runs.Event) error {
+	if s.pool == nil {
+		return fmt.Errorf("database not configured - please add DATABASE_URL_POOLED in Environment Settings")
+	}
+
+	if events == nil {
+		events = []runs.Event{}
+	}
+	eventsJSON, err := json.Marshal(events)
+	if err != nil {
+		return fmt.Errorf("failed to encode job events: %w", err)
+	}
+
+	query := `UPDATE agent_jobs SET output = output || $2, events = events || $3::jsonb WHERE id = $1`
+	tag, err := s.pool.Exec(ctx, query, id, output, eventsJSON)
+	if err != nil {
+		return fmt.Errorf("failed to update agent job: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrJobNotFound
+	}
+
+	return nil
+}
+
+// Complete marks a job as completed
+func (s *Store) Complete(ctx context.Context, id int) error {
+	return s.finish(ctx, id, StatusCompleted, nil)
+}
+
+// Fail marks a job as failed with its error message
+func (s *Store) Fail(ctx context.Context, id int, cause error) error {
+	message := cause.Error()
+	return s.finish(ctx, id, StatusFailed, &message)
+}
+
+// finish records the final status of a job
+func (s *Store) finish(ctx context.Context, id int, status string, errorMessage *string) error {
+	if s.pool == nil {
+		return fmt.Errorf("database not configured - please add DATABASE_URL_POOLED in Environment Settings")
+	}
+
+	query := `UPDATE agent_jobs SET status = $2, error_message = $3, completed_at = NOW() WHERE id = $1`
+	tag, err := s.pool.Exec(ctx, query, id, status, errorMessage)
+	if err != nil {
+		return fmt.Errorf("failed to update agent job: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrJobNotFound
+	}
+
+	return nil
+}
+
+// FailStale fails jobs left processing by a worker that stopped before
+// finishing them, returning how many were failed
+func (s *Store) FailStale(ctx context.Context, startedBefore time.Time) (int64, error) {
+	if s.pool == nil {
+		return 0, fmt.Errorf("database not configured - please add DATABASE_URL_POOLED in Environment Settings")
+	}
+
+	query := `
+		UPDATE agent_jobs
+		SET status = $1, error_message = 'worker stopped before the job finished', completed_at = NOW()
+		WHERE status = $2 AND started_at < $3`
+	tag, err := s.pool.Exec(ctx, query, StatusFailed, StatusProcessing, startedBefore)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fail stale agent jobs: %w", err)
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// Get retrieves an agent job by ID
+func (s *Store) Get(ctx context.Context, id int) (*Job, error) {
+	if s.pool == nil {
+		return nil, fmt.Errorf("database not configured - please add DATABASE_URL_POOLED in Environment Settings")
+	}
+
+	query := `SELECT ` + jobColumns + ` FROM agent_jobs WHERE id = $1`
+	job, err := scanJob(s.pool.QueryRow(ctx, query, id))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrJobNotFound
+		}
+		return nil, fmt.Errorf("failed to query agent job: %w", err)
+	}
+
+	return job, nil
+}
+
+// scanJob scans a job row in jobColumns order
+func scanJob(row pgx.Row) (*Job, error) {
+	var job Job
+	var requestJSON, eventsJSON []byte
+	err := row.Scan(
+		&job.ID,
+		&requestJSON,
+		&job.Status,
+		&job.Output,
+		&eventsJSON,
+		&job.ErrorMessage,
+		&job.CreatedAt,
+		&job.UpdatedAt,
+		&job.StartedAt,
+		&job.CompletedAt,
+		&job.CreatedBy,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(requestJSON, &job.Request); err != nil {
+		return nil, fmt.Errorf("failed to decode job request: %w", err)
+	}
+	if err := json.Unmarshal(eventsJSON, &job.Events); err != nil {
+		return nil, fmt.Errorf("failed to decode job events: %w", err)
+	}
+
+	return &job, nil
+}
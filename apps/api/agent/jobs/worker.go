@@ -0,0 +1,169 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"agentic-template/api/logging"
+)
+
+// DefaultPollInterval is how often idle workers check for pending jobs
+const DefaultPollInterval = 2 * time.Second
+
+// Handler runs a claimed job. A returned error marks the job as failed.
+type Handler func(ctx context.Context, job *Job) error
+
+// Pool is a fixed set of workers that process queued jobs
+type Pool struct {
+	storeMu      sync.RWMutex
+	store        *Store
+	handler      Handler
+	workers      int
+	pollInterval time.Duration
+	staleAfter   time.Duration
+	wake         chan struct{}
+	cancel       context.CancelFunc
+	wg           sync.WaitGroup
+}
+
+// NewPool creates a worker pool; call Start to begin processing. Jobs still
+// processing after staleAfter are assumed to belong to a worker that stopped.
+func NewPool(store *Store, handler Handler, workers int, staleAfter time.Duration) *Pool {
+	if workers <= 0 {
+		workers = 1
+	}
+	return &Pool{
+		store:        store,
+		handler:      handler,
+		workers:      workers,
+		pollInterval: DefaultPollInterval,
+		staleAfter:   staleAfter,
+		wake:         make(chan struct{}, 1),
+	}
+}
+
+// Start launches the workers. Stale jobs left processing by a stopped
+// worker are failed first since their progress cannot be resumed.
+func (p *Pool) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+
+	if failed, err := p.currentStore().FailStale(ctx, time.Now().Add(-p.staleAfter)); err != nil {
+		log.Printf("Warning: failed to clean up stale agent jobs: %v", err)
+	} else if failed > 0 {
+		log.Printf("Failed %d stale agent job(s)", failed)
+	}
+
+	for i := 0; i < p.workers; i++ {
+		p.wg.Add(1)
+		go p.work(ctx)
+	}
+	log.Printf("Agent job pool started with %d worker(s)", p.workers)
+}
+
+// SetStore switches the pool to a new store, such as one bound to a
+// reloaded database. Running jobs record their outcome in the new store.
+func (p *Pool) SetStore(store *Store) {
+	p.storeMu.Lock()
+	defer p.storeMu.Unlock()
+	p.store = store
+}
+
+// currentStore returns the store new work uses
+func (p *Pool) currentStore() *Store {
+	p.storeMu.RLock()
+	defer p.storeMu.RUnlock()
+	return p.store
+}
+
+// Notify wakes an idle worker after a job is enqueued
+func (p *Pool) Notify() {
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Stop cancels running jobs and waits for the workers to exit or ctx to
+// expire
+func (p *Pool) Stop(ctx context.Context) error {
+	if p.cancel == nil {
+		return nil
+	}
+	p.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("agent job workers still running: %w", ctx.Err())
+	}
+}
+
+// work claims and runs jobs until the pool is stopped
+func (p *Pool) work(ctx context.Context) {
+	defer p.wg.Done()
+
+	for {
+		job, err := p.currentStore().Claim(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Warning: failed to claim agent job: %v", err)
+		}
+
+		if job != nil {
+			p.run(ctx, job)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-p.wake:
+		case <-time.After(p.pollInterval):
+		}
+	}
+}
+
+// handle runs the handler, failing the job instead of the worker when the
+// handler panics
+func (p *Pool) handle(ctx context.Context, job *Job) (err error) {
+	defer func() {
+		if value := recover(); value != nil {
+			err = logging.RecoverPanic(ctx, value)
+		}
+	}()
+	return p.handler(ctx, job)
+}
+
+// run executes a job and records its outcome
+func (p *Pool) run(ctx context.Context, job *Job) {
+	runErr := p.handle(ctx, job)
+	store := p.currentStore()
+
+	// The pool context may be cancelled during shutdown
+	finishCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if runErr != nil {
+		log.Printf("Agent job %d failed: %v", job.ID, runErr)
+		if err := store.Fail(finishCtx, job.ID, runErr); err != nil {
+			log.Printf("Warning: failed to mark agent job %d as failed: %v", job.ID, err)
+		}
+		return
+	}
+
+	if err := store.Complete(finishCtx, job.ID); err != nil {
+		log.Printf("Warning: failed to mark agent job %d as completed: %v", job.ID, err)
+		return
+	}
+	log.Printf("Agent job %d completed", job.ID)
+}
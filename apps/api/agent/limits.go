@@ -0,0 +1,109 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tmc/langchaingo/agents"
+)
+
+// Limits bound how far and how long an agent run may go
+type Limits struct {
+	MaxIterations    int           // Reasoning/tool iterations per run
+	IterationTimeout time.Duration // Deadline for a single iteration
+	RunTimeout       time.Duration // Wall-clock budget for the whole run
+}
+
+// Reasons a run can be stopped by its limits
+const (
+	TimeoutIteration     = "iteration"
+	TimeoutRun           = "run"
+	TimeoutMaxIterations = "max_iterations"
+)
+
+// TimeoutError reports that a run exceeded one of its limits
+type TimeoutError struct {
+	Reason     string
+	Iterations int
+	Elapsed    time.Duration
+	Limits     Limits
+}
+
+func (e *TimeoutError) Error() string {
+	switch e.Reason {
+	case TimeoutIteration:
+		return fmt.Sprintf("agent iteration exceeded %s timeout", e.Limits.IterationTimeout)
+	case TimeoutRun:
+		return fmt.Sprintf("agent run exceeded %s time budget", e.Limits.RunTimeout)
+	default:
+		return fmt.Sprintf("agent did not finish within %d iterations", e.Limits.MaxIterations)
+	}
+}
+
+// Override returns the limits with any positive request values applied.
+// Zero values keep the configured limit.
+func (l Limits) Override(maxIterations int, iterationTimeout, runTimeout time.Duration) (Limits, error) {
+	if maxIterations < 0 || iterationTimeout < 0 || runTimeout < 0 {
+		return l, fmt.Errorf("limits must not be negative")
+	}
+
+	if maxIterations > 0 {
+		l.MaxIterations = maxIterations
+	}
+	if iterationTimeout > 0 {
+		l.IterationTimeout = iterationTimeout
+	}
+	if runTimeout > 0 {
+		l.RunTimeout = runTimeout
+	}
+	return l, nil
+}
+
+// CheckTimeout classifies an iteration error as a limit being exceeded.
+// parent is the caller's context and runCtx the run's budgeted context;
+// cancellation by the caller is not a timeout. It returns nil when err is not
+// caused by a limit.
+func (l Limits) CheckTimeout(parent, runCtx context.Context, err error, iterations int, started time.Time) *TimeoutError {
+	if err == nil || parent.Err() != nil {
+		return nil
+	}
+
+	timeout := &TimeoutError{
+		Iterations: iterations,
+		Elapsed:    time.Since(started),
+		Limits:     l,
+	}
+
+	switch {
+	case errors.Is(runCtx.Err(), context.DeadlineExceeded):
+		timeout.Reason = TimeoutRun
+	case errors.Is(err, context.DeadlineExceeded):
+		timeout.Reason = TimeoutIteration
+	case errors.Is(err, agents.ErrNotFinished):
+		timeout.Reason = TimeoutMaxIterations
+	default:
+		return nil
+	}
+
+	return timeout
+}
+
+// RunContext returns a context bounded by the run's time budget
+func (l Limits) RunContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withTimeout(ctx, l.RunTimeout)
+}
+
+// IterationContext returns a context bounded by the per-iteration timeout
+func (l Limits) IterationContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withTimeout(ctx, l.IterationTimeout)
+}
+
+// withTimeout applies a timeout when one is set
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
@@ -0,0 +1,208 @@
+package mockllm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/tmc/langchaingo/callbacks"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// Step is one scripted model turn. A step with a Tool asks the agent to call
+// that tool with ToolInput; otherwise Response is returned as the final answer.
+type Step struct {
+	Tool      string   `json:"tool,omitempty"`
+	ToolInput string   `json:"tool_input,omitempty"`
+	Response  string   `json:"response,omitempty"`
+	Chunks    []string `json:"chunks,omitempty"` // Streamed pieces; defaults to one chunk per word
+}
+
+// Fixture is a script of model turns replayed in order
+type Fixture struct {
+	Steps []Step `json:"steps"`
+	// Loop restarts the script when it is exhausted instead of failing
+	Loop bool `json:"loop,omitempty"`
+}
+
+// DefaultFixture answers every prompt with a fixed final answer
+var DefaultFixture = Fixture{
+	Steps: []Step{{Response: "This is a mock response."}},
+	Loop:  true,
+}
+
+// LoadFixture reads a JSON fixture from disk
+func LoadFixture(path string) (*Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mock fixture: %w", err)
+	}
+
+	var fixture Fixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("failed to parse mock fixture: %w", err)
+	}
+	if len(fixture.Steps) == 0 {
+		return nil, fmt.Errorf("mock fixture %s has no steps", path)
+	}
+
+	return &fixture, nil
+}
+
+// LLM is a deterministic llms.Model that replays a fixture without network
+// calls. Responses are formatted for the conversational agent's parser.
+type LLM struct {
+	mu               sync.Mutex
+	fixture          Fixture
+	next             int
+	CallbacksHandler callbacks.Handler
+}
+
+// Statically assert that LLM implements the model interface
+var _ llms.Model = &LLM{}
+
+// New creates a mock model replaying the given fixture
+func New(fixture Fixture) (*LLM, error) {
+	if len(fixture.Steps) == 0 {
+		return nil, fmt.Errorf("mock fixture has no steps")
+	}
+	return &LLM{fixture: fixture}, nil
+}
+
+// NewFromFile creates a mock model from a JSON fixture file. An empty path
+// uses DefaultFixture.
+func NewFromFile(path string) (*LLM, error) {
+	if path == "" {
+		return New(DefaultFixture)
+	}
+
+	fixture, err := LoadFixture(path)
+	if err != nil {
+		return nil, err
+	}
+	return New(*fixture)
+}
+
+// Reset rewinds the script to its first step
+func (m *LLM) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.next = 0
+}
+
+// Call implements the deprecated single-prompt interface
+func (m *LLM) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
+
+// GenerateContent returns the next scripted step, streaming it through the
+// call's streaming function when one is set
+func (m *LLM) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	if m.CallbacksHandler != nil {
+		m.CallbacksHandler.HandleLLMGenerateContentStart(ctx, messages)
+	}
+
+	opts := llms.CallOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+
+	step, err := m.nextStep()
+	if err != nil {
+		if m.CallbacksHandler != nil {
+			m.CallbacksHandler.HandleLLMError(ctx, err)
+		}
+		return nil, err
+	}
+
+	content := formatStep(step)
+	if opts.StreamingFunc != nil {
+		for _, chunk := range chunksFor(step, content) {
+			if err := opts.StreamingFunc(ctx, []byte(chunk)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	promptTokens := llms.CountTokens("", promptText(messages))
+	completionTokens := llms.CountTokens("", content)
+	resp := &llms.ContentResponse{
+		Choices: []*llms.ContentChoice{{
+			Content:    content,
+			StopReason: "stop",
+			GenerationInfo: map[string]any{
+				"PromptTokens":     promptTokens,
+				"CompletionTokens": completionTokens,
+				"TotalTokens":      promptTokens + completionTokens,
+			},
+		}},
+	}
+
+	if m.CallbacksHandler != nil {
+		m.CallbacksHandler.HandleLLMGenerateContentEnd(ctx, resp)
+	}
+	return resp, nil
+}
+
+// nextStep advances the script
+func (m *LLM) nextStep() (Step, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.next >= len(m.fixture.Steps) {
+		if !m.fixture.Loop {
+			return Step{}, fmt.Errorf("mock fixture exhausted after %d steps", len(m.fixture.Steps))
+		}
+		m.next = 0
+	}
+
+	step := m.fixture.Steps[m.next]
+	m.next++
+	if step.Response == "" && len(step.Chunks) > 0 {
+		step.Response = strings.Join(step.Chunks, "")
+	}
+	return step, nil
+}
+
+// formatStep renders a step in the conversational agent's output format
+func formatStep(step Step) string {
+	if step.Tool != "" {
+		return fmt.Sprintf("Thought: Do I need to use a tool? Yes\nAction: %s\nAction Input: %s", step.Tool, step.ToolInput)
+	}
+	return "Thought: Do I need to use a tool? No\nAI: " + step.Response
+}
+
+// chunksFor returns the pieces streamed for a step. Scripted chunks replace
+// the final answer text; the agent prefix is always streamed first.
+func chunksFor(step Step, content string) []string {
+	if step.Tool == "" && len(step.Chunks) > 0 {
+		prefix := strings.TrimSuffix(content, step.Response)
+		return append([]string{prefix}, step.Chunks...)
+	}
+
+	words := strings.SplitAfter(content, " ")
+	chunks := make([]string, 0, len(words))
+	for _, word := range words {
+		if word != "" {
+			chunks = append(chunks, word)
+		}
+	}
+	return chunks
+}
+
+// promptText flattens the text parts of the messages for token counting
+func promptText(messages []llms.MessageContent) string {
+	var sb strings.Builder
+	for _, msg := range messages {
+		for _, part := range msg.Parts {
+			if text, ok := part.(llms.TextContent); ok {
+				sb.WriteString(text.Text)
+				sb.WriteString("\n")
+			}
+		}
+	}
+	return sb.String()
+}
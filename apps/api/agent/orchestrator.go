@@ -0,0 +1,117 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ChunkHandler receives streamed output tagged with the producing agent
+type ChunkHandler func(agentName, chunk string) error
+
+// nonToolNamePattern matches characters not allowed in tool names
+var nonToolNamePattern = regexp.MustCompile(`[^a-z0-9_]+`)
+
+// DelegateTool exposes another agent as a tool so that a coordinating agent
+// (e.g. a planner) can hand subtasks to a specialist (e.g. a SQL agent)
+type DelegateTool struct {
+	agent       *Agent
+	description string
+	onChunk     ChunkHandler
+}
+
+// NewDelegateTool wraps a sub-agent as a tool. The description tells the
+// coordinating agent when to delegate; onChunk (optional) receives the
+// sub-agent's streamed output.
+func NewDelegateTool(sub *Agent, description string, onChunk ChunkHandler) *DelegateTool {
+	if description == "" {
+		description = fmt.Sprintf("Delegate a subtask to the %s agent.", sub.Name())
+	}
+	return &DelegateTool{
+		agent:       sub,
+		description: description,
+		onChunk:     onChunk,
+	}
+}
+
+// Name returns the name of the tool
+func (t *DelegateTool) Name() string {
+	name := nonToolNamePattern.ReplaceAllString(strings.ToLower(t.agent.Name()), "_")
+	return "delegate_to_" + strings.Trim(name, "_")
+}
+
+// Description returns the description of the tool
+func (t *DelegateTool) Description() string {
+	return t.description + " Input should be a complete, self-contained description of the subtask."
+}
+
+// Call runs the sub-agent on the subtask and returns its answer
+func (t *DelegateTool) Call(ctx context.Context, input string) (string, error) {
+	var output strings.Builder
+
+	err := t.agent.RunWithCallback(ctx, input, func(chunk string) error {
+		output.WriteString(chunk)
+		if t.onChunk != nil {
+			return t.onChunk(t.agent.Name(), chunk)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("agent '%s' failed: %w", t.agent.Name(), err)
+	}
+
+	if output.Len() == 0 {
+		return fmt.Sprintf("Agent '%s' completed without output", t.agent.Name()), nil
+	}
+
+	return output.String(), nil
+}
+
+// Orchestrator composes a coordinating agent with specialist sub-agents
+type Orchestrator struct {
+	coordinator *Agent
+	delegates   []*DelegateTool
+}
+
+// NewOrchestrator creates an orchestrator around the coordinating agent
+func NewOrchestrator(coordinator *Agent) *Orchestrator {
+	return &Orchestrator{
+		coordinator: coordinator,
+	}
+}
+
+// AddDelegate registers a specialist sub-agent the coordinator can call.
+// The sub-agent must already have its own tools added and be initialized.
+func (o *Orchestrator) AddDelegate(sub *Agent, description string, onChunk ChunkHandler) error {
+	if sub == o.coordinator {
+		return fmt.Errorf("an agent cannot delegate to itself")
+	}
+	if sub.executor == nil {
+		return fmt.Errorf("delegate agent '%s' is not initialized", sub.Name())
+	}
+
+	delegate := NewDelegateTool(sub, description, onChunk)
+	for _, existing := range o.delegates {
+		if existing.Name() == delegate.Name() {
+			return fmt.Errorf("duplicate delegate agent name: %s", sub.Name())
+		}
+	}
+
+	o.delegates = append(o.delegates, delegate)
+	o.coordinator.AddTool(delegate)
+	return nil
+}
+
+// Initialize initializes the coordinating agent with all delegate tools
+func (o *Orchestrator) Initialize() error {
+	return o.coordinator.Initialize()
+}
+
+// RunWithCallback runs the coordinator, tagging its chunks with its name.
+// Chunks from delegates are reported through their own handlers.
+func (o *Orchestrator) RunWithCallback(ctx context.Context, input string, onChunk ChunkHandler) error {
+	return o.coordinator.RunWithCallback(ctx, input, func(chunk string) error {
+		return onChunk(o.coordinator.Name(), chunk)
+	})
+}
@@ -0,0 +1,287 @@
+package profiles
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"agentic-template/api/agent"
+	"agentic-template/api/agent/conversation"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrProfileNotFound is returned when an agent profile does not exist
+var ErrProfileNotFound = errors.New("agent profile not found")
+
+// Supported providers for agent profiles
+var validProviders = map[string]bool{
+	"openai":    true,
+	"anthropic": true,
+	"google":    true,
+	"mock":      true, // Scripted responses for testing
+}
+
+// Profile is a named agent configuration (persona, tools, and model)
+type Profile struct {
+	ID              int      `json:"id,omitempty"`
+	Name            string   `json:"name"`
+	Description     *string  `json:"description,omitempty"`
+	SystemPrompt    string   `json:"system_prompt"`
+	Provider        string   `json:"provider"`
+	Model           *string  `json:"model,omitempty"` // nil uses the provider default
+	Temperature     float64  `json:"temperature"`
+	MaxTokens       int      `json:"max_tokens"`
+	AllowedTools    []string `json:"allowed_tools,omitempty"` // nil allows every tool
+	MemoryStrategy  string   `json:"memory_strategy"`         // "buffer" or "summary" (summarized and persisted per conversation)
+	MemoryMaxTokens int      `json:"memory_max_tokens"`       // Buffer size before older turns are summarized
+	// RoutingRules maps task complexities to the model used for them; nil
+	// routes only when model routing is enabled and Model is not set
+	RoutingRules map[string]string `json:"routing_rules,omitempty"`
+	// MaxIterations limits reasoning/tool iterations per run, also when the
+	// profile runs as a delegate; nil uses AGENT_MAX_ITERATIONS
+	MaxIterations *int      `json:"max_iterations,omitempty"`
+	CreatedAt     time.Time `json:"created_at,omitempty"`
+	UpdatedAt     time.Time `json:"updated_at,omitempty"`
+}
+
+// AllowsTool reports whether the profile permits the named tool
+func (p *Profile) AllowsTool(name string) bool {
+	if p.AllowedTools == nil {
+		return true
+	}
+	for _, allowed := range p.AllowedTools {
+		if allowed == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Store persists agent profiles
+type Store struct {
+	pool *pgxpool.Pool
+}
+
+// NewStore creates a new profile store
+func NewStore(pool *pgxpool.Pool) *Store {
+	return &Store{
+		pool: pool,
+	}
+}
+
+// profileColumns is the column list shared by profile queries
+const profileColumns = `id, name, description, system_prompt, provider, model, temperature,
+	max_tokens, allowed_tools, memory_strategy, memory_max_tokens, routing_rules, max_iterations, created_at, updated_at`
+
+// Create inserts a new agent profile
+func (s *Store) Create(ctx context.Context, profile Profile) (*Profile, error) {
+	if s.pool == nil {
+		return nil, fmt.Errorf("database not configured - please add DATABASE_URL_POOLED in Environment Settings")
+	}
+
+	if err := Validate(&profile); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	var routingRules []byte
+	if profile.RoutingRules != nil {
+		var err error
+		if routingRules, err = json.Marshal(profile.RoutingRules); err != nil {
+			return nil, fmt.Errorf("failed to marshal routing rules: %w", err)
+		}
+	}
+
+	query := `
+		INSERT INTO agent_profiles (name, description, system_prompt, provider, model, temperature, max_tokens,
+			allowed_tools, memory_strategy, memory_max_tokens, routing_rules, max_iterations)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING ` + profileColumns
+	row := s.pool.QueryRow(ctx, query,
+		profile.Name,
+		profile.Description,
+		profile.SystemPrompt,
+		profile.Provider,
+		profile.Model,
+		profile.Temperature,
+		profile.MaxTokens,
+		profile.AllowedTools,
+		profile.MemoryStrategy,
+		profile.MemoryMaxTokens,
+		routingRules,
+		profile.MaxIterations,
+	)
+
+	created, err := scanProfile(row)
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return nil, fmt.Errorf("agent profile '%s' already exists", profile.Name)
+		}
+		return nil, fmt.Errorf("failed to insert agent profile: %w", err)
+	}
+
+	return created, nil
+}
+
+// Get retrieves an agent profile by ID
+func (s *Store) Get(ctx context.Context, id int) (*Profile, error) {
+	if s.pool == nil {
+		return nil, fmt.Errorf("database not configured - please add DATABASE_URL_POOLED in Environment Settings")
+	}
+
+	query := `SELECT ` + profileColumns + ` FROM agent_profiles WHERE id = $1`
+	profile, err := scanProfile(s.pool.QueryRow(ctx, query, id))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrProfileNotFound
+		}
+		return nil, fmt.Errorf("failed to query agent profile: %w", err)
+	}
+
+	return profile, nil
+}
+
+// List returns all agent profiles ordered by name
+func (s *Store) List(ctx context.Context) ([]Profile, error) {
+	if s.pool == nil {
+		return nil, fmt.Errorf("database not configured - please add DATABASE_URL_POOLED in Environment Settings")
+	}
+
+	query := `SELECT ` + profileColumns + ` FROM agent_profiles ORDER BY name`
+	rows, err := s.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query agent profiles: %w", err)
+	}
+	defer rows.Close()
+
+	profiles := []Profile{}
+	for rows.Next() {
+		profile, err := scanProfile(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan agent profile: %w", err)
+		}
+		profiles = append(profiles, *profile)
+	}
+
+	return profiles, rows.Err()
+}
+
+// SetAllowedTools replaces a profile's tool allow-list (nil allows every tool)
+func (s *Store) SetAllowedTools(ctx context.Context, id int, allowedTools []string) (*Profile, error) {
+	if s.pool == nil {
+		return nil, fmt.Errorf("database not configured - please add DATABASE_URL_POOLED in Environment Settings")
+	}
+
+	query := `UPDATE agent_profiles SET allowed_tools = $1 WHERE id = $2 RETURNING ` + profileColumns
+	profile, err := scanProfile(s.pool.QueryRow(ctx, query, allowedTools, id))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrProfileNotFound
+		}
+		return nil, fmt.Errorf("failed to update agent profile tools: %w", err)
+	}
+
+	return profile, nil
+}
+
+// Delete removes an agent profile
+func (s *Store) Delete(ctx context.Context, id int) error {
+	if s.pool == nil {
+		return fmt.Errorf("database not configured - please add DATABASE_URL_POOLED in Environment Settings")
+	}
+
+	tag, err := s.pool.Exec(ctx, `DELETE FROM agent_profiles WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete agent profile: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrProfileNotFound
+	}
+
+	return nil
+}
+
+// Validate checks a profile and fills in defaults
+func Validate(profile *Profile) error {
+	if strings.TrimSpace(profile.Name) == "" {
+		return fmt.Errorf("profile name is required")
+	}
+
+	if profile.Provider == "" {
+		profile.Provider = "openai"
+	}
+	profile.Provider = strings.ToLower(profile.Provider)
+	if !validProviders[profile.Provider] {
+		return fmt.Errorf("unsupported provider: %s", profile.Provider)
+	}
+
+	if profile.Temperature < 0 || profile.Temperature > 2 {
+		return fmt.Errorf("temperature must be between 0 and 2")
+	}
+
+	if profile.MaxTokens == 0 {
+		profile.MaxTokens = 2000
+	}
+	if profile.MaxTokens < 0 {
+		return fmt.Errorf("max_tokens must be positive")
+	}
+
+	if profile.MemoryStrategy == "" {
+		profile.MemoryStrategy = conversation.StrategyBuffer
+	}
+	if profile.MemoryStrategy != conversation.StrategyBuffer && profile.MemoryStrategy != conversation.StrategySummary {
+		return fmt.Errorf("unsupported memory strategy: %s", profile.MemoryStrategy)
+	}
+	if profile.MemoryMaxTokens == 0 {
+		profile.MemoryMaxTokens = conversation.DefaultMaxTokens
+	}
+	if profile.MemoryMaxTokens < 0 {
+		return fmt.Errorf("memory_max_tokens must be positive")
+	}
+
+	if profile.MaxIterations != nil && *profile.MaxIterations <= 0 {
+		return fmt.Errorf("max_iterations must be positive")
+	}
+
+	if err := agent.ValidateRoutingRules(profile.RoutingRules); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// scanProfile scans a profile row in profileColumns order
+func scanProfile(row pgx.Row) (*Profile, error) {
+	var profile Profile
+	var routingRules []byte
+	err := row.Scan(
+		&profile.ID,
+		&profile.Name,
+		&profile.Description,
+		&profile.SystemPrompt,
+		&profile.Provider,
+		&profile.Model,
+		&profile.Temperature,
+		&profile.MaxTokens,
+		&profile.AllowedTools,
+		&profile.MemoryStrategy,
+		&profile.MemoryMaxTokens,
+		&routingRules,
+		&profile.MaxIterations,
+		&profile.CreatedAt,
+		&profile.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if routingRules != nil {
+		if err := json.Unmarshal(routingRules, &profile.RoutingRules); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal routing rules: %w", err)
+		}
+	}
+	return &profile, nil
+}
@@ -0,0 +1,201 @@
+package agent
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"agentic-template/api/db"
+	"agentic-template/api/embeddings"
+	"github.com/tmc/langchaingo/tools"
+)
+
+// Plugin is a tool that describes its input and the permissions it needs
+type Plugin interface {
+	tools.Tool
+
+	// InputSchema returns a JSON schema describing the tool's input
+	InputSchema() map[string]any
+
+	// Permissions returns the permissions required to use the tool
+	Permissions() []string
+}
+
+// ToolDeps holds the dependencies available to tool factories
+type ToolDeps struct {
+	DB        *db.DB // For read-only queries; may be a read replica
+	Retriever *embeddings.Pipeline
+}
+
+// ToolFactory builds a plugin from its dependencies. It returns nil when a
+// required dependency is not configured.
+type ToolFactory func(deps ToolDeps) Plugin
+
+// ToolInfo describes a registered tool
+type ToolInfo struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	InputSchema map[string]any `json:"input_schema"`
+	Permissions []string       `json:"permissions"`
+	Enabled     bool           `json:"enabled"`
+	Available   bool           `json:"available"` // false when its dependencies are not configured
+}
+
+// registration is a registered tool factory
+type registration struct {
+	factory ToolFactory
+	enabled bool
+}
+
+// Registry holds the tools agents can be given
+type Registry struct {
+	mu      sync.RWMutex
+	entries map[string]*registration
+	order   []string
+}
+
+// NewRegistry creates an empty tool registry
+func NewRegistry() *Registry {
+	return &Registry{
+		entries: make(map[string]*registration),
+	}
+}
+
+// DefaultRegistry holds the built-in tools and any registered at startup
+var DefaultRegistry = NewRegistry()
+
+func init() {
+	DefaultRegistry.MustRegister("database_query", func(deps ToolDeps) Plugin {
+		if deps.DB == nil || deps.DB.Pool == nil {
+			return nil
+		}
+		return NewDatabaseQueryTool(deps.DB)
+	})
+	DefaultRegistry.MustRegister("knowledge_base", func(deps ToolDeps) Plugin {
+		if deps.Retriever == nil {
+			return nil
+		}
+		return NewKnowledgeBaseTool(deps.Retriever)
+	})
+	DefaultRegistry.MustRegister("calculator", func(deps ToolDeps) Plugin {
+		return NewCalculatorTool()
+	})
+	DefaultRegistry.MustRegister("web_search", func(deps ToolDeps) Plugin {
+		return NewWebSearchTool()
+	})
+}
+
+// Register adds an enabled tool under the given name
+func (r *Registry) Register(name string, factory ToolFactory) error {
+	if name == "" {
+		return fmt.Errorf("tool name is required")
+	}
+	if factory == nil {
+		return fmt.Errorf("tool factory is required for '%s'", name)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.entries[name]; exists {
+		return fmt.Errorf("tool '%s' is already registered", name)
+	}
+
+	r.entries[name] = &registration{factory: factory, enabled: true}
+	r.order = append(r.order, name)
+	return nil
+}
+
+// MustRegister is like Register but panics on error
+func (r *Registry) MustRegister(name string, factory ToolFactory) {
+	if err := r.Register(name, factory); err != nil {
+		panic(err)
+	}
+}
+
+// SetEnabled enables or disables a tool for every agent
+func (r *Registry) SetEnabled(name string, enabled bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, exists := r.entries[name]
+	if !exists {
+		return fmt.Errorf("unknown tool: %s", name)
+	}
+
+	entry.enabled = enabled
+	return nil
+}
+
+// Has reports whether a tool is registered
+func (r *Registry) Has(name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, exists := r.entries[name]
+	return exists
+}
+
+// List describes every registered tool in registration order
+func (r *Registry) List(deps ToolDeps) []ToolInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	infos := make([]ToolInfo, 0, len(r.order))
+	for _, name := range r.order {
+		entry := r.entries[name]
+		info := ToolInfo{
+			Name:    name,
+			Enabled: entry.enabled,
+		}
+
+		if plugin := entry.factory(deps); plugin != nil {
+			info.Description = plugin.Description()
+			info.InputSchema = plugin.InputSchema()
+			info.Permissions = append([]string(nil), plugin.Permissions()...)
+			sort.Strings(info.Permissions)
+			info.Available = true
+		}
+
+		infos = append(infos, info)
+	}
+
+	return infos
+}
+
+// Build creates the enabled, available tools accepted by allow (nil allows
+// every tool), in registration order
+func (r *Registry) Build(deps ToolDeps, allow func(name string) bool) []tools.Tool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var toolSet []tools.Tool
+	for _, name := range r.order {
+		entry := r.entries[name]
+		if !entry.enabled || (allow != nil && !allow(name)) {
+			continue
+		}
+
+		plugin := entry.factory(deps)
+		if plugin == nil {
+			continue
+		}
+		toolSet = append(toolSet, plugin)
+	}
+
+	return toolSet
+}
+
+// textInputSchema is the schema for tools that take a single text input
+func textInputSchema(description string) map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"input": map[string]any{
+				"type":        "string",
+				"description": description,
+			},
+		},
+		"required": []string{"input"},
+	}
+}
@@ -0,0 +1,386 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"agentic-template/api/agent/tooluse"
+	"agentic-template/api/metrics"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// Circuit breaker states
+const (
+	BreakerClosed   = "closed"    // Calls go through
+	BreakerOpen     = "open"      // Calls fail fast until the cooldown has passed
+	BreakerHalfOpen = "half_open" // One trial call decides whether to close or reopen
+)
+
+// breakerStateValues encodes the states for the breaker state gauge
+var breakerStateValues = map[string]float64{BreakerClosed: 0, BreakerHalfOpen: 1, BreakerOpen: 2}
+
+var (
+	llmRetries = metrics.NewCounter("llm_retries_total",
+		"LLM provider calls retried after a transient error", "provider")
+	llmShortCircuits = metrics.NewCounter("llm_circuit_breaker_rejections_total",
+		"LLM provider calls failed fast by an open circuit breaker", "provider")
+	llmBreakerState = metrics.NewGauge("llm_circuit_breaker_state",
+		"Circuit breaker state per LLM provider: 0 closed, 1 half open, 2 open", "provider")
+)
+
+// ErrCircuitOpen is returned for calls to a provider whose circuit breaker
+// is open
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// RetryPolicy retries transient provider errors with exponential backoff
+// and jitter
+type RetryPolicy struct {
+	Attempts       int // Calls per request including the first; 1 disables retries
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// BreakerSettings configures the circuit breaker of each provider
+type BreakerSettings struct {
+	FailureThreshold int           // Consecutive transient failures that open the breaker; 0 disables it
+	Cooldown         time.Duration // How long an open breaker fails calls before a trial call
+}
+
+// Resilience configures how LLM provider calls are retried and
+// short-circuited
+type Resilience struct {
+	Retry             RetryPolicy
+	Breaker           BreakerSettings
+	FallbackProviders []string // Providers used, in order, while a provider's breaker is open
+}
+
+// DefaultResilience applies until SetResilience is called
+var DefaultResilience = Resilience{
+	Retry:   RetryPolicy{Attempts: 3, InitialBackoff: 500 * time.Millisecond, MaxBackoff: 10 * time.Second},
+	Breaker: BreakerSettings{FailureThreshold: 5, Cooldown: 30 * time.Second},
+}
+
+var (
+	resilienceMu sync.RWMutex
+	resilience   = DefaultResilience
+)
+
+// SetResilience replaces the retry, breaker, and fallback settings
+func SetResilience(r Resilience) {
+	resilienceMu.Lock()
+	defer resilienceMu.Unlock()
+	resilience = r
+}
+
+// currentResilience returns the settings in effect
+func currentResilience() Resilience {
+	resilienceMu.RLock()
+	defer resilienceMu.RUnlock()
+	return resilience
+}
+
+// backoff returns the wait before the given retry (1 for the first): the
+// doubled initial backoff capped at the maximum, with the upper half
+// jittered so concurrent retries spread out
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	delay := p.InitialBackoff
+	for i := 1; i < retry && delay < p.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if p.MaxBackoff > 0 && delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+	if delay <= 0 {
+		return 0
+	}
+	half := delay / 2
+	return half + rand.N(delay-half+1)
+}
+
+// BreakerState reports a provider's circuit breaker
+type BreakerState struct {
+	Provider  string
+	State     string
+	Failures  int       // Consecutive transient failures
+	RetryAt   time.Time // When an open breaker lets a trial call through
+	LastError string
+}
+
+// breaker tracks the consecutive failures of one provider
+type breaker struct {
+	mu        sync.Mutex
+	state     string
+	failures  int
+	openedAt  time.Time
+	trial     bool // A half-open trial call is in flight
+	lastError string
+}
+
+var (
+	breakersMu sync.Mutex
+	breakers   = map[string]*breaker{}
+)
+
+// breakerFor returns the breaker of a provider, creating it closed
+func breakerFor(provider string) *breaker {
+	breakersMu.Lock()
+	defer breakersMu.Unlock()
+	b, ok := breakers[provider]
+	if !ok {
+		b = &breaker{state: BreakerClosed}
+		breakers[provider] = b
+		llmBreakerState.Set(breakerStateValues[BreakerClosed], provider)
+	}
+	return b
+}
+
+// BreakerStates reports the breaker of every provider called so far, sorted
+// by provider
+func BreakerStates() []BreakerState {
+	breakersMu.Lock()
+	providers := make([]string, 0, len(breakers))
+	for provider := range breakers {
+		providers = append(providers, provider)
+	}
+	breakersMu.Unlock()
+
+	sort.Strings(providers)
+	settings := currentResilience().Breaker
+	states := make([]BreakerState, 0, len(providers))
+	for _, provider := range providers {
+		states = append(states, breakerFor(provider).snapshot(provider, settings))
+	}
+	return states
+}
+
+// snapshot reports the breaker's state
+func (b *breaker) snapshot(provider string, settings BreakerSettings) BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	state := BreakerState{Provider: provider, State: b.state, Failures: b.failures, LastError: b.lastError}
+	if b.state == BreakerOpen {
+		state.RetryAt = b.openedAt.Add(settings.Cooldown)
+	}
+	return state
+}
+
+// available reports whether the breaker would let a call through
+func (b *breaker) available(settings BreakerSettings) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return settings.FailureThreshold <= 0 || b.state == BreakerClosed ||
+		(b.state == BreakerOpen && time.Since(b.openedAt) >= settings.Cooldown)
+}
+
+// allow admits a call, moving an open breaker whose cooldown has passed to
+// half open for one trial call
+func (b *breaker) allow(provider string, settings BreakerSettings) error {
+	if settings.FailureThreshold <= 0 {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case BreakerOpen:
+		if time.Since(b.openedAt) < settings.Cooldown {
+			break
+		}
+		b.setState(provider, BreakerHalfOpen)
+		b.trial = true
+		return nil
+	case BreakerHalfOpen:
+		if b.trial {
+			break
+		}
+		b.trial = true
+		return nil
+	default:
+		return nil
+	}
+
+	llmShortCircuits.Inc(provider)
+	return fmt.Errorf("%w for provider %s after %d failures (last: %s); retry after %s",
+		ErrCircuitOpen, provider, b.failures, b.lastError, b.openedAt.Add(settings.Cooldown).Format(time.RFC3339))
+}
+
+// record counts a call's outcome. Only transient errors count as failures;
+// a rejected request still shows the provider is up.
+func (b *breaker) record(provider string, transient bool, err error, settings BreakerSettings) {
+	if settings.FailureThreshold <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+	if !transient {
+		b.failures = 0
+		b.setState(provider, BreakerClosed)
+		return
+	}
+
+	b.failures++
+	b.lastError = err.Error()
+	if b.state == BreakerHalfOpen || b.failures >= settings.FailureThreshold {
+		b.openedAt = time.Now()
+		b.setState(provider, BreakerOpen)
+	}
+}
+
+// setState changes the state and its gauge; the caller holds b.mu
+func (b *breaker) setState(provider, state string) {
+	b.state = state
+	llmBreakerState.Set(breakerStateValues[state], provider)
+}
+
+// Fallback returns the provider to call: the given one unless its breaker
+// is open, otherwise the first configured fallback provider that hasKey
+// accepts and whose breaker is closed. It returns an ErrCircuitOpen error
+// when none is available.
+func Fallback(provider string, hasKey func(provider string) bool) (string, error) {
+	settings := currentResilience()
+	if breakerFor(provider).available(settings.Breaker) {
+		return provider, nil
+	}
+	for _, fallback := range settings.FallbackProviders {
+		if fallback != provider && hasKey(fallback) && breakerFor(fallback).available(settings.Breaker) {
+			return fallback, nil
+		}
+	}
+	state := breakerFor(provider).snapshot(provider, settings.Breaker)
+	return "", fmt.Errorf("%w for provider %s and no fallback provider is available; retry after %s",
+		ErrCircuitOpen, provider, state.RetryAt.Format(time.RFC3339))
+}
+
+// callProvider makes a provider call through its breaker, retrying
+// transient errors while retryable allows it
+func callProvider(ctx context.Context, provider string, call func(ctx context.Context) error, retryable func() bool) error {
+	settings := currentResilience()
+	b := breakerFor(provider)
+	for attempt := 1; ; attempt++ {
+		if err := b.allow(provider, settings.Breaker); err != nil {
+			return err
+		}
+		err := call(ctx)
+		transient := err != nil && isTransient(ctx, err)
+		b.record(provider, transient, err, settings.Breaker)
+		if !transient || attempt >= settings.Retry.Attempts || !retryable() {
+			return err
+		}
+
+		llmRetries.Inc(provider)
+		timer := time.NewTimer(settings.Retry.backoff(attempt))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		}
+	}
+}
+
+// transientStatusPattern matches the rate limit and server error statuses
+// providers report, e.g. "API returned status 529" or "Error 503"
+var transientStatusPattern = regexp.MustCompile(`(?i)(status(?: code:)?|error) (429|5\d\d)\b`)
+
+// transientMarkers appear in provider errors worth retrying: rate limits,
+// overload, and dropped connections
+var transientMarkers = []string{
+	"rate limit", "too many requests", "overloaded", "service unavailable", "temporarily unavailable",
+	"timeout", "timed out", "connection reset", "connection refused", "unexpected eof",
+}
+
+// isTransient reports whether a failed call may succeed if retried. Errors
+// from the caller's own cancellation or deadline are not.
+func isTransient(ctx context.Context, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	if transientStatusPattern.MatchString(err.Error()) {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	for _, marker := range transientMarkers {
+		if strings.Contains(msg, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// resilientModel retries an LLM's transient errors and short-circuits it
+// while its provider's breaker is open
+type resilientModel struct {
+	llms.Model
+	provider string
+}
+
+// GenerateContent calls the model, retrying until output has been streamed
+func (m *resilientModel) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	var opts llms.CallOptions
+	for _, option := range options {
+		option(&opts)
+	}
+
+	// A retry after streamed output would repeat it
+	streamed := false
+	if stream := opts.StreamingFunc; stream != nil {
+		options = append(options[:len(options):len(options)], llms.WithStreamingFunc(func(ctx context.Context, chunk []byte) error {
+			streamed = true
+			return stream(ctx, chunk)
+		}))
+	}
+
+	var resp *llms.ContentResponse
+	err := callProvider(ctx, m.provider, func(ctx context.Context) error {
+		var err error
+		resp, err = m.Model.GenerateContent(ctx, messages, options...)
+		return err
+	}, func() bool { return !streamed })
+	return resp, err
+}
+
+// Call sends the deprecated single-prompt interface through GenerateContent
+func (m *resilientModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
+
+// resilientToolModel retries a tool-use model's transient errors and
+// short-circuits it while its provider's breaker is open
+type resilientToolModel struct {
+	tooluse.Model
+	provider string
+}
+
+// Chat calls the model, retrying until output has been streamed
+func (m *resilientToolModel) Chat(ctx context.Context, req tooluse.Request) (*tooluse.Response, error) {
+	streamed := false
+	if stream := req.StreamingFunc; stream != nil {
+		req.StreamingFunc = func(ctx context.Context, chunk []byte) error {
+			streamed = true
+			return stream(ctx, chunk)
+		}
+	}
+
+	var resp *tooluse.Response
+	err := callProvider(ctx, m.provider, func(ctx context.Context) error {
+		var err error
+		resp, err = m.Model.Chat(ctx, req)
+		return err
+	}, func() bool { return !streamed })
+	return resp, err
+}
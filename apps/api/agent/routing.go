@@ -0,0 +1,171 @@
+package agent
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+
+	"agentic-template/api/metrics"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// Task complexities a request is classified into for model routing
+const (
+	ComplexitySimple   = "simple"   // Lookups and natural-language-to-SQL questions
+	ComplexityStandard = "standard" // General questions
+	ComplexityComplex  = "complex"  // Long reasoning, analysis, delegation, or images
+)
+
+// Complexities lists the task complexities in increasing order
+var Complexities = []string{ComplexitySimple, ComplexityStandard, ComplexityComplex}
+
+var (
+	routedRequests = metrics.NewCounter("llm_routed_requests_total",
+		"Agent requests routed to a model by task complexity", "provider", "complexity", "model")
+	routedCost = metrics.NewCounter("llm_routed_cost_usd_total",
+		"Estimated cost of routed requests on the model they were routed to", "provider")
+	flagshipCost = metrics.NewCounter("llm_flagship_cost_usd_total",
+		"Estimated cost routed requests would have had on the provider's flagship model; the difference to llm_routed_cost_usd_total is the saving", "provider")
+)
+
+// ModelPrice is a model's price in USD per million tokens
+type ModelPrice struct {
+	Input  float64
+	Output float64
+}
+
+// modelPrices lists the prices of the models routing picks between
+var modelPrices = map[string]ModelPrice{
+	"gpt-4o-mini":                {Input: 0.15, Output: 0.60},
+	"gpt-4o":                     {Input: 2.50, Output: 10},
+	"gpt-4-turbo":                {Input: 10, Output: 30},
+	"gpt-4-turbo-preview":        {Input: 10, Output: 30},
+	"claude-3-haiku-20240307":    {Input: 0.25, Output: 1.25},
+	"claude-3-5-sonnet-20241022": {Input: 3, Output: 15},
+	"claude-3-opus-20240229":     {Input: 15, Output: 75},
+	"gemini-1.5-flash":           {Input: 0.075, Output: 0.30},
+	"gemini-1.5-pro":             {Input: 1.25, Output: 5},
+	"gemini-pro":                 {Input: 0.50, Output: 1.50},
+}
+
+// routingModels is the cheapest capable model of each provider per
+// complexity; the complex model is the provider's flagship
+var routingModels = map[string]map[string]string{
+	"openai": {
+		ComplexitySimple:   "gpt-4o-mini",
+		ComplexityStandard: "gpt-4o",
+		ComplexityComplex:  "gpt-4-turbo",
+	},
+	"anthropic": {
+		ComplexitySimple:   "claude-3-haiku-20240307",
+		ComplexityStandard: "claude-3-5-sonnet-20241022",
+		ComplexityComplex:  "claude-3-opus-20240229",
+	},
+	"google": {
+		ComplexitySimple:   "gemini-1.5-flash",
+		ComplexityStandard: "gemini-1.5-pro",
+		ComplexityComplex:  "gemini-1.5-pro",
+	},
+}
+
+// Wording that marks a query as reasoning-heavy or as a simple lookup
+var (
+	reasoningPattern = regexp.MustCompile(`(?i)\b(why|explain|analy[sz]e|analysis|compare|comparison|step by step|reason|plan|strategy|recommend|trade-?offs?|evaluate|design|prove|root cause|forecast)\b`)
+	lookupPattern    = regexp.MustCompile(`(?i)\b(how many|count|list|show|find|look ?up|total|sum|average|avg|max|min|latest|top \d+|which|select|sql)\b`)
+)
+
+// Query sizes, in tokens, that bound the simple and complex classes
+const (
+	maxSimpleQueryTokens  = 60
+	minComplexQueryTokens = 400
+)
+
+// ClassifyComplexity estimates how demanding a request is from its query's
+// length and wording, its images, and whether it delegates to other agents
+func ClassifyComplexity(query string, hasImages bool, delegates int) string {
+	if hasImages || delegates > 0 {
+		return ComplexityComplex
+	}
+	tokens := llms.CountTokens("", query)
+	switch {
+	case tokens >= minComplexQueryTokens || reasoningPattern.MatchString(query):
+		return ComplexityComplex
+	case tokens <= maxSimpleQueryTokens && lookupPattern.MatchString(query):
+		return ComplexitySimple
+	default:
+		return ComplexityStandard
+	}
+}
+
+// ValidateRoutingRules checks routing rules map known complexities to models
+func ValidateRoutingRules(rules map[string]string) error {
+	for complexity, model := range rules {
+		if !slices.Contains(Complexities, complexity) {
+			return fmt.Errorf("invalid routing complexity: %s (expected one of %v)", complexity, Complexities)
+		}
+		if model == "" {
+			return fmt.Errorf("routing rule for %s has no model", complexity)
+		}
+	}
+	return nil
+}
+
+// Route is the model picked for a request by its complexity
+type Route struct {
+	Provider   string
+	Complexity string
+	Model      string
+	Flagship   string // Model the cost is compared against
+}
+
+// RouteModel picks the model for a request of the given complexity: the
+// rule for it, else the provider's cheapest capable model. It reports false
+// when neither is known.
+func RouteModel(provider, complexity string, rules map[string]string) (Route, bool) {
+	route := Route{
+		Provider:   provider,
+		Complexity: complexity,
+		Model:      rules[complexity],
+		Flagship:   routingModels[provider][ComplexityComplex],
+	}
+	if route.Model == "" {
+		route.Model = routingModels[provider][complexity]
+	}
+	return route, route.Model != ""
+}
+
+// String describes the route in run traces
+func (r Route) String() string {
+	return fmt.Sprintf("%s request routed to %s (flagship %s)", r.Complexity, r.Model, r.Flagship)
+}
+
+// Record counts the routed request and its estimated cost against the
+// flagship's for the tokens it used. Costs are skipped for models without a
+// known price.
+func (r Route) Record(promptTokens, completionTokens int) {
+	routedRequests.Inc(r.Provider, r.Complexity, r.Model)
+
+	routed, ok := modelPrices[r.Model]
+	flagship, flagshipOK := modelPrices[r.Flagship]
+	if !ok || !flagshipOK {
+		return
+	}
+	routedCost.Add(routed.cost(promptTokens, completionTokens), r.Provider)
+	flagshipCost.Add(flagship.cost(promptTokens, completionTokens), r.Provider)
+}
+
+// EstimateCost returns the price of the tokens on a model in USD; ok is
+// false when the model's price is unknown
+func EstimateCost(model string, promptTokens, completionTokens int) (cost float64, ok bool) {
+	price, ok := modelPrices[model]
+	if !ok {
+		return 0, false
+	}
+	return price.cost(promptTokens, completionTokens), true
+}
+
+// cost returns the price of the tokens in USD
+func (p ModelPrice) cost(promptTokens, completionTokens int) float64 {
+	return (float64(promptTokens)*p.Input + float64(completionTokens)*p.Output) / 1e6
+}
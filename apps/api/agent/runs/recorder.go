@@ -0,0 +1,202 @@
+package runs
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tmc/langchaingo/callbacks"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/schema"
+)
+
+// Recorder collects the trace of a run while it executes. It is safe for
+// concurrent use by the coordinating agent and its delegates.
+type Recorder struct {
+	mu               sync.Mutex
+	startedAt        time.Time
+	events           []Event
+	output           strings.Builder
+	promptTokens     int
+	completionTokens int
+	totalTokens      int
+	modelCalls       int
+}
+
+// Progress is what a run has consumed so far
+type Progress struct {
+	PromptTokens     int
+	CompletionTokens int
+	TotalTokens      int
+	ModelCalls       int // Completed LLM calls of the agent and its delegates
+	Elapsed          time.Duration
+}
+
+// NewRecorder creates a recorder and starts its clock
+func NewRecorder() *Recorder {
+	return &Recorder{
+		startedAt: time.Now(),
+	}
+}
+
+// ForAgent returns a langchaingo callbacks handler that records events
+// under the given agent name
+func (r *Recorder) ForAgent(agentName string) callbacks.Handler {
+	return &agentHandler{
+		recorder:  r,
+		agentName: agentName,
+	}
+}
+
+// RecordChunk appends streamed output produced by the named agent. Only the
+// top-level agent's chunks form the run output; delegate output is traced
+// through its tool calls.
+func (r *Recorder) RecordChunk(agentName, coordinatorName, chunk string) {
+	if agentName != coordinatorName {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.output.WriteString(chunk)
+}
+
+// Output returns the top-level agent's output streamed so far
+func (r *Recorder) Output() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.output.String()
+}
+
+// Usage returns the token usage reported so far
+func (r *Recorder) Usage() (promptTokens, completionTokens int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.promptTokens, r.completionTokens
+}
+
+// Progress returns the usage and elapsed time of the run so far
+func (r *Recorder) Progress() Progress {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return Progress{
+		PromptTokens:     r.promptTokens,
+		CompletionTokens: r.completionTokens,
+		TotalTokens:      r.totalTokens,
+		ModelCalls:       r.modelCalls,
+		Elapsed:          time.Since(r.startedAt),
+	}
+}
+
+// Record appends an event to the trace
+func (r *Recorder) Record(event Event) {
+	r.addEvent(event)
+}
+
+// Complete fills in the run's outcome, trace, usage, and duration.
+// A nil runErr marks the run as completed.
+func (r *Recorder) Complete(run *Run, runErr error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	run.Output = r.output.String()
+	run.Events = append([]Event(nil), r.events...)
+	run.PromptTokens = r.promptTokens
+	run.CompletionTokens = r.completionTokens
+	run.TotalTokens = r.totalTokens
+	run.DurationMs = time.Since(r.startedAt).Milliseconds()
+
+	if runErr != nil {
+		message := runErr.Error()
+		run.Status = StatusFailed
+		run.ErrorMessage = &message
+	} else {
+		run.Status = StatusCompleted
+	}
+}
+
+// addEvent appends an event to the trace
+func (r *Recorder) addEvent(event Event) {
+	event.Timestamp = time.Now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+// addUsage accumulates token usage reported by the provider
+func (r *Recorder) addUsage(info map[string]any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.promptTokens += intValue(info["PromptTokens"])
+	r.completionTokens += intValue(info["CompletionTokens"])
+	r.totalTokens += intValue(info["TotalTokens"])
+}
+
+// addCall counts a completed model call
+func (r *Recorder) addCall() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.modelCalls++
+}
+
+// intValue converts a numeric generation info value to an int
+func intValue(v any) int {
+	switch n := v.(type) {
+	case int:
+		return n
+	case int32:
+		return int(n)
+	case int64:
+		return int(n)
+	case float64:
+		return int(n)
+	default:
+		return 0
+	}
+}
+
+// agentHandler adapts the recorder to langchaingo callbacks for one agent
+type agentHandler struct {
+	callbacks.SimpleHandler
+	recorder  *Recorder
+	agentName string
+}
+
+func (h *agentHandler) HandleLLMGenerateContentEnd(_ context.Context, res *llms.ContentResponse) {
+	if res == nil {
+		return
+	}
+	h.recorder.addCall()
+	for _, choice := range res.Choices {
+		if choice != nil && choice.GenerationInfo != nil {
+			h.recorder.addUsage(choice.GenerationInfo)
+		}
+	}
+}
+
+func (h *agentHandler) HandleLLMError(_ context.Context, err error) {
+	h.recorder.addEvent(Event{Type: EventLLMError, AgentName: h.agentName, Content: err.Error()})
+}
+
+func (h *agentHandler) HandleAgentAction(_ context.Context, action schema.AgentAction) {
+	if action.Log != "" {
+		h.recorder.addEvent(Event{Type: EventThought, AgentName: h.agentName, Tool: action.Tool, Content: action.Log})
+	}
+	h.recorder.addEvent(Event{Type: EventToolStart, AgentName: h.agentName, Tool: action.Tool, Content: action.ToolInput})
+}
+
+func (h *agentHandler) HandleToolEnd(_ context.Context, output string) {
+	h.recorder.addEvent(Event{Type: EventToolEnd, AgentName: h.agentName, Content: output})
+}
+
+func (h *agentHandler) HandleToolError(_ context.Context, err error) {
+	h.recorder.addEvent(Event{Type: EventToolError, AgentName: h.agentName, Content: err.Error()})
+}
+
+func (h *agentHandler) HandleChainError(_ context.Context, err error) {
+	h.recorder.addEvent(Event{Type: EventAgentError, AgentName: h.agentName, Content: err.Error()})
+}
+
+func (h *agentHandler) HandleAgentFinish(_ context.Context, finish schema.AgentFinish) {
+	h.recorder.addEvent(Event{Type: EventFinish, AgentName: h.agentName, Content: finish.Log})
+}
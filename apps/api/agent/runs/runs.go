@@ -0,0 +1,292 @@
+package runs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"agentic-template/api/requestctx"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrRunNotFound is returned when an agent run does not exist
+var ErrRunNotFound = errors.New("agent run not found")
+
+// Run statuses
+const (
+	StatusRunning   = "RUNNING"
+	StatusCompleted = "COMPLETED"
+	StatusFailed    = "FAILED"
+)
+
+// Event types recorded in a run trace
+const (
+	EventThought    = "thought"
+	EventToolStart  = "tool_start"
+	EventToolEnd    = "tool_end"
+	EventToolError  = "tool_error"
+	EventLLMError   = "llm_error"
+	EventAgentError = "agent_error"
+	EventFinish     = "finish"
+	EventGuardrail  = "guardrail"
+	EventTimeout    = "timeout"
+	EventApproval   = "tool_approval"
+	EventCitation   = "citation"
+	EventRouting    = "model_routing"
+)
+
+// Event is a single step of an agent run
+type Event struct {
+	Type      string    `json:"type"`
+	AgentName string    `json:"agent_name"`
+	Tool      string    `json:"tool,omitempty"`
+	Content   string    `json:"content"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Run is the persisted trace of one agent invocation
+type Run struct {
+	ID                 int               `json:"id,omitempty"`
+	ConversationID     *string           `json:"conversation_id,omitempty"`
+	ProfileID          *int              `json:"profile_id,omitempty"`
+	DelegateProfileIDs []int             `json:"delegate_profile_ids,omitempty"`
+	Input              string            `json:"input"`
+	Metadata           map[string]string `json:"metadata,omitempty"`
+	Output             string            `json:"output"`
+	Status             string            `json:"status"`
+	ErrorMessage       *string           `json:"error_message,omitempty"`
+	Events             []Event           `json:"events"`
+	PromptTokens       int               `json:"prompt_tokens"`
+	CompletionTokens   int               `json:"completion_tokens"`
+	TotalTokens        int               `json:"total_tokens"`
+	DurationMs         int64             `json:"duration_ms"`
+	CreatedAt          time.Time         `json:"created_at,omitempty"`
+	UpdatedAt          time.Time         `json:"updated_at,omitempty"`
+	CompletedAt        *time.Time        `json:"completed_at,omitempty"`
+	CreatedBy          *string           `json:"created_by,omitempty"` // Principal that started the run
+}
+
+// ListOptions filters and pages ListRuns
+type ListOptions struct {
+	ConversationID string
+	ProfileID      *int
+	Status         string
+	Limit          int
+	Offset         int
+}
+
+// Paging limits for List
+const (
+	DefaultListLimit = 50
+	MaxListLimit     = 500
+)
+
+// Store persists agent run traces
+type Store struct {
+	pool *pgxpool.Pool
+}
+
+// NewStore creates a new run store
+func NewStore(pool *pgxpool.Pool) *Store {
+	return &Store{
+		pool: pool,
+	}
+}
+
+// runColumns is the column list shared by run queries
+const runColumns = `id, conversation_id, profile_id, delegate_profile_ids, input, metadata, output,
+	status, error_message, events, prompt_tokens, completion_tokens, total_tokens, duration_ms,
+	created_at, updated_at, completed_at, created_by`
+
+// Start inserts a run in the RUNNING state, created by the actor of ctx,
+// and fills in its ID
+func (s *Store) Start(ctx context.Context, run *Run) error {
+	if s.pool == nil {
+		return fmt.Errorf("database not configured - please add DATABASE_URL_POOLED in Environment Settings")
+	}
+
+	metadataJSON, err := json.Marshal(run.Metadata)
+	if err != nil {
+		return fmt.Errorf("failed to encode run metadata: %w", err)
+	}
+	if run.Metadata == nil {
+		metadataJSON = []byte("{}")
+	}
+
+	run.Status = StatusRunning
+	createdBy := requestctx.Actor(ctx)
+	run.CreatedBy = &createdBy
+	query := `
+		INSERT INTO agent_runs (conversation_id, profile_id, delegate_profile_ids, input, metadata, status, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at, updated_at`
+	err = s.pool.QueryRow(ctx, query,
+		run.ConversationID,
+		run.ProfileID,
+		run.DelegateProfileIDs,
+		run.Input,
+		metadataJSON,
+		run.Status,
+		run.CreatedBy,
+	).Scan(&run.ID, &run.CreatedAt, &run.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert agent run: %w", err)
+	}
+
+	return nil
+}
+
+// Finish records the outcome, trace, and usage of a started run
+func (s *Store) Finish(ctx context.Context, run *Run) error {
+	if s.pool == nil {
+		return fmt.Errorf("database not configured - please add DATABASE_URL_POOLED in Environment Settings")
+	}
+
+	events := run.Events
+	if events == nil {
+		events = []Event{}
+	}
+	eventsJSON, err := json.Marshal(events)
+	if err != nil {
+		return fmt.Errorf("failed to encode run events: %w", err)
+	}
+
+	query := `
+		UPDATE agent_runs
+		SET output = $1, status = $2, error_message = $3, events = $4, prompt_tokens = $5,
+			completion_tokens = $6, total_tokens = $7, duration_ms = $8, completed_at = NOW()
+		WHERE id = $9
+		RETURNING updated_at, completed_at`
+	err = s.pool.QueryRow(ctx, query,
+		run.Output,
+		run.Status,
+		run.ErrorMessage,
+		eventsJSON,
+		run.PromptTokens,
+		run.CompletionTokens,
+		run.TotalTokens,
+		run.DurationMs,
+		run.ID,
+	).Scan(&run.UpdatedAt, &run.CompletedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return ErrRunNotFound
+		}
+		return fmt.Errorf("failed to update agent run: %w", err)
+	}
+
+	return nil
+}
+
+// Get retrieves an agent run by ID
+func (s *Store) Get(ctx context.Context, id int) (*Run, error) {
+	if s.pool == nil {
+		return nil, fmt.Errorf("database not configured - please add DATABASE_URL_POOLED in Environment Settings")
+	}
+
+	query := `SELECT ` + runColumns + ` FROM agent_runs WHERE id = $1`
+	run, err := scanRun(s.pool.QueryRow(ctx, query, id))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrRunNotFound
+		}
+		return nil, fmt.Errorf("failed to query agent run: %w", err)
+	}
+
+	return run, nil
+}
+
+// List returns agent runs, newest first
+func (s *Store) List(ctx context.Context, opts ListOptions) ([]Run, error) {
+	if s.pool == nil {
+		return nil, fmt.Errorf("database not configured - please add DATABASE_URL_POOLED in Environment Settings")
+	}
+
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = DefaultListLimit
+	}
+	if limit > MaxListLimit {
+		limit = MaxListLimit
+	}
+	offset := opts.Offset
+	if offset < 0 {
+		offset = 0
+	}
+
+	query := `SELECT ` + runColumns + ` FROM agent_runs WHERE 1=1`
+	args := []interface{}{}
+	if opts.ConversationID != "" {
+		args = append(args, opts.ConversationID)
+		query += fmt.Sprintf(" AND conversation_id = $%d", len(args))
+	}
+	if opts.ProfileID != nil {
+		args = append(args, *opts.ProfileID)
+		query += fmt.Sprintf(" AND profile_id = $%d", len(args))
+	}
+	if opts.Status != "" {
+		args = append(args, opts.Status)
+		query += fmt.Sprintf(" AND status = $%d", len(args))
+	}
+	args = append(args, limit, offset)
+	query += fmt.Sprintf(" ORDER BY created_at DESC, id DESC LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	rows, err := s.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query agent runs: %w", err)
+	}
+	defer rows.Close()
+
+	runs := []Run{}
+	for rows.Next() {
+		run, err := scanRun(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan agent run: %w", err)
+		}
+		runs = append(runs, *run)
+	}
+
+	return runs, rows.Err()
+}
+
+// scanRun scans a run row in runColumns order
+func scanRun(row pgx.Row) (*Run, error) {
+	var run Run
+	var metadataJSON, eventsJSON []byte
+	err := row.Scan(
+		&run.ID,
+		&run.ConversationID,
+		&run.ProfileID,
+		&run.DelegateProfileIDs,
+		&run.Input,
+		&metadataJSON,
+		&run.Output,
+		&run.Status,
+		&run.ErrorMessage,
+		&eventsJSON,
+		&run.PromptTokens,
+		&run.CompletionTokens,
+		&run.TotalTokens,
+		&run.DurationMs,
+		&run.CreatedAt,
+		&run.UpdatedAt,
+		&run.CompletedAt,
+		&run.CreatedBy,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(metadataJSON, &run.Metadata); err != nil {
+		return nil, fmt.Errorf("failed to decode run metadata: %w", err)
+	}
+	if err := json.Unmarshal(eventsJSON, &run.Events); err != nil {
+		return nil, fmt.Errorf("failed to decode run events: %w", err)
+	}
+
+	return &run, nil
+}
@@ -0,0 +1,113 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+
+	"agentic-template/api/schema_manager"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// schemaContextHeader introduces the schema summary in the system prompt
+const schemaContextHeader = "Database schema (user-defined tables; refer to them by their machine names):"
+
+// SchemaContext summarizes the user-defined tables for the system prompt:
+// their names, descriptions, column types, and relations. It stays within
+// maxTokens: tables that don't fit are listed by column names only, and
+// those that don't fit at all are counted in a closing line. It returns ""
+// when there are no tables or the budget can't hold the header.
+func SchemaContext(tables []schema_manager.TableDefinition, maxTokens int) string {
+	if len(tables) == 0 || maxTokens <= 0 {
+		return ""
+	}
+
+	tableNames := make(map[int]string, len(tables))
+	for _, table := range tables {
+		tableNames[table.ID] = table.TableName
+	}
+
+	var sb strings.Builder
+	sb.WriteString(schemaContextHeader)
+	used := llms.CountTokens("", schemaContextHeader)
+	if used > maxTokens {
+		return ""
+	}
+
+	for i, table := range tables {
+		// Leave room for the line counting the tables left out
+		omitted := fmt.Sprintf("\n- ... %d more table(s); inspect them with your tools", len(tables)-i)
+		budget := maxTokens - llms.CountTokens("", omitted)
+		if i == len(tables)-1 {
+			budget = maxTokens
+		}
+
+		entry := describeTable(table, tableNames, true)
+		tokens := llms.CountTokens("", entry)
+		if used+tokens > budget {
+			entry = describeTable(table, tableNames, false)
+			tokens = llms.CountTokens("", entry)
+		}
+		if used+tokens > budget {
+			if used+llms.CountTokens("", omitted) <= maxTokens {
+				sb.WriteString(omitted)
+			}
+			break
+		}
+		sb.WriteString(entry)
+		used += tokens
+	}
+	return sb.String()
+}
+
+// describeTable renders a table's entry in the schema summary. The full
+// entry has the description and each column's type and constraints; the
+// short one lists column names only.
+func describeTable(table schema_manager.TableDefinition, tableNames map[int]string, full bool) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("\n- %s", table.TableName))
+	if table.Name != table.TableName {
+		sb.WriteString(fmt.Sprintf(" (%q)", table.Name))
+	}
+	if full && table.Description != nil && *table.Description != "" {
+		sb.WriteString(": " + *table.Description)
+	}
+
+	columns := []string{"id"}
+	for _, col := range table.Columns {
+		if !full {
+			columns = append(columns, col.ColumnName)
+			continue
+		}
+		columns = append(columns, fmt.Sprintf("%s %s", col.ColumnName, describeColumn(col, tableNames)))
+	}
+	if full {
+		columns = append(columns, "created_at", "updated_at")
+	}
+	sb.WriteString("\n  columns: " + strings.Join(columns, ", "))
+	return sb.String()
+}
+
+// describeColumn renders a column's type and constraints
+func describeColumn(col schema_manager.ColumnDefinition, tableNames map[int]string) string {
+	var details []string
+	switch {
+	case col.DataType == schema_manager.DataTypeRelation && col.ForeignKeyToTableID != nil:
+		target, ok := tableNames[*col.ForeignKeyToTableID]
+		if !ok {
+			target = fmt.Sprintf("table %d", *col.ForeignKeyToTableID)
+		}
+		details = append(details, "relation -> "+target+".id")
+	case col.DataType == schema_manager.DataTypeFormula && col.Formula != nil:
+		details = append(details, "formula = "+*col.Formula)
+	default:
+		details = append(details, string(col.DataType))
+	}
+	if !col.IsNullable {
+		details = append(details, "required")
+	}
+	if col.IsUnique {
+		details = append(details, "unique")
+	}
+	return "(" + strings.Join(details, ", ") + ")"
+}
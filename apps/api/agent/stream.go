@@ -0,0 +1,85 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/tmc/langchaingo/callbacks"
+	"github.com/tmc/langchaingo/schema"
+)
+
+// finalAnswerMarker precedes the final answer in the conversational agent's
+// output
+const finalAnswerMarker = "AI:"
+
+// streamHandler forwards streamed LLM output to the callback of the current
+// run. langchaingo agents stream through their callbacks handler rather than
+// the chain's streaming option.
+type streamHandler struct {
+	callbacks.SimpleHandler
+
+	mu       sync.Mutex
+	callback func(string) error
+	// finalOnly holds back reasoning and tool calls until the final answer
+	// marker, for agents that write their plan as text
+	finalOnly bool
+	buffer    strings.Builder
+	answering bool
+	err       error // First error returned by the callback
+}
+
+// setCallback sets the callback of the current run; nil stops forwarding
+func (h *streamHandler) setCallback(callback func(string) error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.callback = callback
+	h.buffer.Reset()
+	h.answering = false
+	h.err = nil
+}
+
+// callbackErr returns the first error returned by the current run's callback
+func (h *streamHandler) callbackErr() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.err
+}
+
+// HandleAgentAction resets the final answer filter; the next generation
+// plans again after the tool call
+func (h *streamHandler) HandleAgentAction(_ context.Context, _ schema.AgentAction) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.buffer.Reset()
+	h.answering = false
+}
+
+// HandleStreamingFunc forwards a chunk to the current run's callback
+func (h *streamHandler) HandleStreamingFunc(_ context.Context, chunk []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.callback == nil || h.err != nil {
+		return
+	}
+
+	text := string(chunk)
+	if h.finalOnly && !h.answering {
+		h.buffer.WriteString(text)
+		buffered := h.buffer.String()
+		idx := strings.LastIndex(buffered, finalAnswerMarker)
+		if idx < 0 {
+			return
+		}
+		h.answering = true
+		text = strings.TrimLeft(buffered[idx+len(finalAnswerMarker):], " ")
+		if text == "" {
+			return
+		}
+	}
+
+	// The executor can't be stopped from here; the error stops forwarding
+	// and is returned when the run ends
+	h.err = h.callback(text)
+}
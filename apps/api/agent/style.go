@@ -0,0 +1,97 @@
+package agent
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Verbosity levels of a response
+const (
+	VerbosityConcise  = "concise"
+	VerbosityBalanced = "balanced"
+	VerbosityDetailed = "detailed"
+)
+
+// Formality levels of a response
+const (
+	FormalityCasual  = "casual"
+	FormalityNeutral = "neutral"
+	FormalityFormal  = "formal"
+)
+
+// Verbosities and Formalities list the accepted style values
+var (
+	Verbosities = []string{VerbosityConcise, VerbosityBalanced, VerbosityDetailed}
+	Formalities = []string{FormalityCasual, FormalityNeutral, FormalityFormal}
+)
+
+// languagePattern accepts BCP 47 language tags such as "fr" or "pt-BR"
+var languagePattern = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{1,8}){0,3}$`)
+
+// ResponseStyle holds hints on how the agent should answer. Empty fields
+// leave the choice to the agent.
+type ResponseStyle struct {
+	Language  string `json:"language,omitempty"`  // BCP 47 tag, e.g. "de" or "pt-BR"
+	Verbosity string `json:"verbosity,omitempty"` // concise, balanced, detailed
+	Formality string `json:"formality,omitempty"` // casual, neutral, formal
+}
+
+// IsZero reports whether no hint is set
+func (s ResponseStyle) IsZero() bool {
+	return s == ResponseStyle{}
+}
+
+// Validate checks the hints hold accepted values
+func (s ResponseStyle) Validate() error {
+	if s.Language != "" && !languagePattern.MatchString(s.Language) {
+		return fmt.Errorf("invalid language tag: %q", s.Language)
+	}
+	if s.Verbosity != "" && !slices.Contains(Verbosities, s.Verbosity) {
+		return fmt.Errorf("invalid verbosity: %s (expected one of %v)", s.Verbosity, Verbosities)
+	}
+	if s.Formality != "" && !slices.Contains(Formalities, s.Formality) {
+		return fmt.Errorf("invalid formality: %s (expected one of %v)", s.Formality, Formalities)
+	}
+	return nil
+}
+
+// Merge returns the style with the hints set in override replacing its own
+func (s ResponseStyle) Merge(override ResponseStyle) ResponseStyle {
+	if override.Language != "" {
+		s.Language = override.Language
+	}
+	if override.Verbosity != "" {
+		s.Verbosity = override.Verbosity
+	}
+	if override.Formality != "" {
+		s.Formality = override.Formality
+	}
+	return s
+}
+
+// Instructions renders the hints as system prompt instructions, or "" when
+// none is set
+func (s ResponseStyle) Instructions() string {
+	var lines []string
+	if s.Language != "" {
+		lines = append(lines, fmt.Sprintf("- Answer in the language with BCP 47 tag %q, whatever language the question or the data is in. Keep table names, column names, and quoted values as they are.", s.Language))
+	}
+	switch s.Verbosity {
+	case VerbosityConcise:
+		lines = append(lines, "- Be concise: give the answer in a few sentences without preamble.")
+	case VerbosityDetailed:
+		lines = append(lines, "- Be thorough: explain your reasoning and include relevant details.")
+	}
+	switch s.Formality {
+	case FormalityCasual:
+		lines = append(lines, "- Use a casual, friendly tone.")
+	case FormalityFormal:
+		lines = append(lines, "- Use a formal, professional tone.")
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return "Response style:\n" + strings.Join(lines, "\n")
+}
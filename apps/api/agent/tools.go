@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"agentic-template/api/db"
+	"agentic-template/api/embeddings"
 	"github.com/tmc/langchaingo/tools"
 )
 
@@ -19,7 +20,7 @@ type DatabaseQueryTool struct {
 // NewDatabaseQueryTool creates a new database query tool
 func NewDatabaseQueryTool(database *db.DB) *DatabaseQueryTool {
 	return &DatabaseQueryTool{
-		db: database,
+		db:          database,
 		description: "Query the database to retrieve information. Input should be a natural language question about the data.",
 	}
 }
@@ -34,20 +35,37 @@ func (t *DatabaseQueryTool) Description() string {
 	return t.description
 }
 
+// InputSchema returns the JSON schema of the tool's input
+func (t *DatabaseQueryTool) InputSchema() map[string]any {
+	return textInputSchema("Natural language question about the data")
+}
+
+// Permissions returns the permissions required to use the tool
+func (t *DatabaseQueryTool) Permissions() []string {
+	return []string{"database:read"}
+}
+
 // Call executes the database query based on natural language input
 func (t *DatabaseQueryTool) Call(ctx context.Context, input string) (string, error) {
+	output, _, err := t.CallWithCitations(ctx, input)
+	return output, err
+}
+
+// CallWithCitations executes the query and cites the rows it returned, or
+// the queried table when the result is computed over it
+func (t *DatabaseQueryTool) CallWithCitations(ctx context.Context, input string) (string, []Citation, error) {
 	// For demo purposes, we'll handle some basic query patterns
 	// In production, you might want to use an LLM to convert natural language to SQL
-	
-	query := t.parseNaturalLanguageToSQL(input)
+
+	query, table := t.parseNaturalLanguageToSQL(input)
 	if query == "" {
-		return "", fmt.Errorf("could not understand the query: %s", input)
+		return "", nil, fmt.Errorf("could not understand the query: %s", input)
 	}
 
 	// Execute the query
 	rows, err := t.db.Pool.Query(ctx, query)
 	if err != nil {
-		return "", fmt.Errorf("database query failed: %w", err)
+		return "", nil, fmt.Errorf("database query failed: %w", err)
 	}
 	defer rows.Close()
 
@@ -56,7 +74,7 @@ func (t *DatabaseQueryTool) Call(ctx context.Context, input string) (string, err
 	for rows.Next() {
 		values, err := rows.Values()
 		if err != nil {
-			return "", fmt.Errorf("failed to get row values: %w", err)
+			return "", nil, fmt.Errorf("failed to get row values: %w", err)
 		}
 
 		row := make(map[string]interface{})
@@ -69,34 +87,63 @@ func (t *DatabaseQueryTool) Call(ctx context.Context, input string) (string, err
 	// Convert results to JSON for easy reading
 	jsonResult, err := json.MarshalIndent(results, "", "  ")
 	if err != nil {
-		return "", fmt.Errorf("failed to format results: %w", err)
+		return "", nil, fmt.Errorf("failed to format results: %w", err)
 	}
 
 	if len(results) == 0 {
-		return "No results found", nil
+		return "No results found", nil, nil
+	}
+
+	return fmt.Sprintf("Query results (%d rows):\n%s", len(results), string(jsonResult)), rowCitations(t.Name(), table, results), nil
+}
+
+// rowCitations cites the rows of a query result by their id column, or the
+// table itself when no row has one. Results not read from a table cite
+// nothing.
+func rowCitations(toolName, table string, results []map[string]interface{}) []Citation {
+	if table == "" {
+		return nil
+	}
+
+	var citations []Citation
+	for _, row := range results {
+		var id int64
+		switch v := row["id"].(type) {
+		case int32:
+			id = int64(v)
+		case int64:
+			id = v
+		default:
+			continue
+		}
+		citations = append(citations, Citation{Kind: CitationRow, Tool: toolName, Table: table, RowID: &id})
 	}
 
-	return fmt.Sprintf("Query results (%d rows):\n%s", len(results), string(jsonResult)), nil
+	if len(citations) == 0 {
+		citations = append(citations, Citation{Kind: CitationRow, Tool: toolName, Table: table})
+	}
+	return citations
 }
 
-// parseNaturalLanguageToSQL converts natural language to SQL
+// parseNaturalLanguageToSQL converts natural language to SQL, returning
+// the table it reads ("" for none)
 // This is a simplified version - in production, use an LLM for this
-func (t *DatabaseQueryTool) parseNaturalLanguageToSQL(input string) string {
+func (t *DatabaseQueryTool) parseNaturalLanguageToSQL(input string) (query, table string) {
 	input = strings.ToLower(input)
 
 	// Basic pattern matching for common queries
 	switch {
 	case strings.Contains(input, "count") && strings.Contains(input, "users"):
-		return "SELECT COUNT(*) as count FROM users"
+		return "SELECT COUNT(*) as count FROM users", "users"
 	case strings.Contains(input, "list") && strings.Contains(input, "users"):
-		return "SELECT * FROM users LIMIT 10"
+		return "SELECT * FROM users LIMIT 10", "users"
 	case strings.Contains(input, "recent") && strings.Contains(input, "orders"):
-		return "SELECT * FROM orders ORDER BY created_at DESC LIMIT 10"
+		return "SELECT * FROM orders ORDER BY created_at DESC LIMIT 10", "orders"
 	case strings.Contains(input, "total") && strings.Contains(input, "revenue"):
-		return "SELECT SUM(amount) as total_revenue FROM orders"
+		return "SELECT SUM(amount) as total_revenue FROM orders", "orders"
 	default:
 		// For demo, return a safe default query
-		return "SELECT 'Please be more specific with your query' as message"
+		return "SELECT 'Please be more specific with your query' as message", ""
 	}
 }
 
@@ -118,11 +165,21 @@ func (t *CalculatorTool) Description() string {
 	return "Useful for performing mathematical calculations. Input should be a mathematical expression."
 }
 
+// InputSchema returns the JSON schema of the tool's input
+func (t *CalculatorTool) InputSchema() map[string]any {
+	return textInputSchema("Mathematical expression to evaluate")
+}
+
+// Permissions returns the permissions required to use the tool
+func (t *CalculatorTool) Permissions() []string {
+	return nil
+}
+
 // Call performs the calculation
 func (t *CalculatorTool) Call(ctx context.Context, input string) (string, error) {
 	// For demo purposes, we'll just handle basic operations
 	// In production, use a proper expression evaluator
-	
+
 	// This is a placeholder - implement proper math evaluation
 	return fmt.Sprintf("Calculated result for '%s': [calculation would be performed here]", input), nil
 }
@@ -145,24 +202,91 @@ func (t *WebSearchTool) Description() string {
 	return "Search the web for current information. Input should be a search query."
 }
 
+// InputSchema returns the JSON schema of the tool's input
+func (t *WebSearchTool) InputSchema() map[string]any {
+	return textInputSchema("Search query")
+}
+
+// Permissions returns the permissions required to use the tool
+func (t *WebSearchTool) Permissions() []string {
+	return []string{"network"}
+}
+
 // Call performs the web search
 func (t *WebSearchTool) Call(ctx context.Context, input string) (string, error) {
 	// This is a placeholder - in production, integrate with a search API
 	return fmt.Sprintf("Web search results for '%s': [search results would appear here]", input), nil
 }
 
-// CreateToolSet creates a standard set of tools for the agent
-func CreateToolSet(database *db.DB) []tools.Tool {
-	var toolSet []tools.Tool
+// KnowledgeBaseTool retrieves relevant document chunks from the RAG store
+type KnowledgeBaseTool struct {
+	pipeline *embeddings.Pipeline
+}
 
-	// Add database tool if database is available
-	if database != nil && database.Pool != nil {
-		toolSet = append(toolSet, NewDatabaseQueryTool(database))
+// NewKnowledgeBaseTool creates a new knowledge base retrieval tool
+func NewKnowledgeBaseTool(pipeline *embeddings.Pipeline) *KnowledgeBaseTool {
+	return &KnowledgeBaseTool{
+		pipeline: pipeline,
 	}
+}
+
+// Name returns the name of the tool
+func (t *KnowledgeBaseTool) Name() string {
+	return "knowledge_base"
+}
+
+// Description returns the description of the tool
+func (t *KnowledgeBaseTool) Description() string {
+	return "Search the knowledge base of ingested documents for passages relevant to a question. Input should be a natural language question or topic."
+}
+
+// InputSchema returns the JSON schema of the tool's input
+func (t *KnowledgeBaseTool) InputSchema() map[string]any {
+	return textInputSchema("Natural language question or topic")
+}
 
-	// Add other tools
-	toolSet = append(toolSet, NewCalculatorTool())
-	toolSet = append(toolSet, NewWebSearchTool())
+// Permissions returns the permissions required to use the tool
+func (t *KnowledgeBaseTool) Permissions() []string {
+	return []string{"knowledge:read"}
+}
 
-	return toolSet
-}
\ No newline at end of file
+// Call retrieves the top-k chunks and formats them as context for the agent
+func (t *KnowledgeBaseTool) Call(ctx context.Context, input string) (string, error) {
+	output, _, err := t.CallWithCitations(ctx, input)
+	return output, err
+}
+
+// CallWithCitations retrieves the top-k chunks and cites each of them
+func (t *KnowledgeBaseTool) CallWithCitations(ctx context.Context, input string) (string, []Citation, error) {
+	chunks, err := t.pipeline.Retrieve(ctx, input, 0)
+	if err != nil {
+		return "", nil, fmt.Errorf("knowledge base search failed: %w", err)
+	}
+
+	if len(chunks) == 0 {
+		return "No relevant documents found", nil, nil
+	}
+
+	var sb strings.Builder
+	citations := make([]Citation, 0, len(chunks))
+	sb.WriteString(fmt.Sprintf("Found %d relevant passage(s):\n", len(chunks)))
+	for i, chunk := range chunks {
+		sb.WriteString(fmt.Sprintf("\n[%d] %s (chunk %d, similarity %.3f)\n%s\n",
+			i+1, chunk.DocumentTitle, chunk.ChunkIndex, chunk.Similarity, chunk.Content))
+		citations = append(citations, Citation{
+			Kind:       CitationDocument,
+			Tool:       t.Name(),
+			DocumentID: chunk.DocumentID,
+			Title:      chunk.DocumentTitle,
+			ChunkIndex: chunk.ChunkIndex,
+			Similarity: chunk.Similarity,
+		})
+	}
+
+	return sb.String(), citations, nil
+}
+
+// CreateToolSet creates the enabled tools from the default registry
+func CreateToolSet(database *db.DB, retriever *embeddings.Pipeline) []tools.Tool {
+	return DefaultRegistry.Build(ToolDeps{DB: database, Retriever: retriever}, nil)
+}
@@ -0,0 +1,238 @@
+package tooluse
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// DefaultAnthropicBaseURL is the Anthropic API endpoint
+const DefaultAnthropicBaseURL = "https://api.anthropic.com/v1"
+
+// AnthropicVersion is the API version sent with every request
+const AnthropicVersion = "2023-06-01"
+
+// Anthropic calls the Anthropic Messages API with native tool use
+type Anthropic struct {
+	APIKey     string
+	Model      string
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+// Statically assert that Anthropic implements the model interface
+var _ Model = &Anthropic{}
+
+// NewAnthropic creates an Anthropic tool-use model
+func NewAnthropic(apiKey, model string) (*Anthropic, error) {
+	if apiKey == "" {
+		return nil, fmt.Errorf("anthropic API key is required")
+	}
+	if model == "" {
+		return nil, fmt.Errorf("anthropic model is required")
+	}
+	return &Anthropic{
+		APIKey:     apiKey,
+		Model:      model,
+		BaseURL:    DefaultAnthropicBaseURL,
+		HTTPClient: http.DefaultClient,
+	}, nil
+}
+
+// anthropicBlock is a content block of a message
+type anthropicBlock struct {
+	Type      string           `json:"type"`
+	Text      string           `json:"text,omitempty"`
+	ID        string           `json:"id,omitempty"`
+	Name      string           `json:"name,omitempty"`
+	Input     map[string]any   `json:"input,omitempty"`
+	ToolUseID string           `json:"tool_use_id,omitempty"`
+	Content   string           `json:"content,omitempty"`
+	Source    *anthropicSource `json:"source,omitempty"`
+}
+
+// anthropicSource is the base64 data of an image block
+type anthropicSource struct {
+	Type      string `json:"type"`
+	MediaType string `json:"media_type"`
+	Data      string `json:"data"`
+}
+
+type anthropicMessage struct {
+	Role    string           `json:"role"`
+	Content []anthropicBlock `json:"content"`
+}
+
+type anthropicTool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	InputSchema map[string]any `json:"input_schema"`
+}
+
+type anthropicRequest struct {
+	Model       string             `json:"model"`
+	System      string             `json:"system,omitempty"`
+	Messages    []anthropicMessage `json:"messages"`
+	Tools       []anthropicTool    `json:"tools,omitempty"`
+	ToolChoice  map[string]any     `json:"tool_choice,omitempty"`
+	MaxTokens   int                `json:"max_tokens"`
+	Temperature float64            `json:"temperature"`
+	Stream      bool               `json:"stream"`
+}
+
+// anthropicEvent is a streamed Messages API event
+type anthropicEvent struct {
+	Type         string `json:"type"`
+	Index        int    `json:"index"`
+	ContentBlock struct {
+		Type string `json:"type"`
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"content_block"`
+	Delta struct {
+		Type        string `json:"type"`
+		Text        string `json:"text"`
+		PartialJSON string `json:"partial_json"`
+	} `json:"delta"`
+	Message struct {
+		Usage struct {
+			InputTokens int `json:"input_tokens"`
+		} `json:"usage"`
+	} `json:"message"`
+	Usage struct {
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+	Error struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Chat sends the conversation and streams the reply
+func (a *Anthropic) Chat(ctx context.Context, req Request) (*Response, error) {
+	body := anthropicRequest{
+		Model:       a.Model,
+		System:      req.System,
+		Messages:    toAnthropicMessages(req.Messages),
+		MaxTokens:   req.MaxTokens,
+		Temperature: req.Temperature,
+		Stream:      true,
+	}
+	if body.MaxTokens <= 0 {
+		body.MaxTokens = 2000
+	}
+	for _, tool := range req.Tools {
+		body.Tools = append(body.Tools, anthropicTool{
+			Name:        tool.Name,
+			Description: tool.Description,
+			InputSchema: tool.InputSchema,
+		})
+	}
+	if len(body.Tools) > 0 {
+		// The agent runs one tool per step
+		body.ToolChoice = map[string]any{"type": "auto", "disable_parallel_tool_use": true}
+	}
+
+	httpResp, err := postJSON(ctx, a.HTTPClient, strings.TrimSuffix(a.BaseURL, "/")+"/messages", map[string]string{
+		"x-api-key":         a.APIKey,
+		"anthropic-version": AnthropicVersion,
+	}, body)
+	if err != nil {
+		return nil, fmt.Errorf("anthropic: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	resp := &Response{}
+	var text strings.Builder
+	var toolJSON strings.Builder
+	err = readSSE(httpResp.Body, func(data []byte) error {
+		var event anthropicEvent
+		if err := json.Unmarshal(data, &event); err != nil {
+			return fmt.Errorf("failed to decode event: %w", err)
+		}
+
+		switch event.Type {
+		case "message_start":
+			resp.PromptTokens = event.Message.Usage.InputTokens
+		case "content_block_start":
+			if event.ContentBlock.Type == "tool_use" && resp.ToolCall == nil {
+				resp.ToolCall = &ToolCall{ID: event.ContentBlock.ID, Name: event.ContentBlock.Name}
+			}
+		case "content_block_delta":
+			switch event.Delta.Type {
+			case "text_delta":
+				text.WriteString(event.Delta.Text)
+				if req.StreamingFunc != nil {
+					return req.StreamingFunc(ctx, []byte(event.Delta.Text))
+				}
+			case "input_json_delta":
+				toolJSON.WriteString(event.Delta.PartialJSON)
+			}
+		case "message_delta":
+			resp.CompletionTokens = event.Usage.OutputTokens
+		case "error":
+			return fmt.Errorf("%s", event.Error.Message)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("anthropic: %w", err)
+	}
+
+	resp.Text = text.String()
+	if resp.ToolCall != nil {
+		resp.ToolCall.Input = map[string]any{}
+		if toolJSON.Len() > 0 {
+			if err := json.Unmarshal([]byte(toolJSON.String()), &resp.ToolCall.Input); err != nil {
+				return nil, fmt.Errorf("anthropic: failed to decode tool input: %w", err)
+			}
+		}
+	}
+
+	return resp, nil
+}
+
+// toAnthropicMessages converts the conversation, sending tool results as
+// user turns as the Messages API requires
+func toAnthropicMessages(messages []Message) []anthropicMessage {
+	result := make([]anthropicMessage, 0, len(messages))
+	for _, msg := range messages {
+		switch msg.Role {
+		case RoleAssistant:
+			var blocks []anthropicBlock
+			if msg.Text != "" {
+				blocks = append(blocks, anthropicBlock{Type: "text", Text: msg.Text})
+			}
+			if msg.ToolCall != nil {
+				blocks = append(blocks, anthropicBlock{
+					Type:  "tool_use",
+					ID:    msg.ToolCall.ID,
+					Name:  msg.ToolCall.Name,
+					Input: msg.ToolCall.Input,
+				})
+			}
+			result = append(result, anthropicMessage{Role: "assistant", Content: blocks})
+		case RoleTool:
+			result = append(result, anthropicMessage{Role: "user", Content: []anthropicBlock{{
+				Type:      "tool_result",
+				ToolUseID: msg.Result.CallID,
+				Content:   msg.Result.Content,
+			}}})
+		default:
+			// Images go before the text, as the Messages API recommends
+			blocks := make([]anthropicBlock, 0, len(msg.Images)+1)
+			for _, image := range msg.Images {
+				blocks = append(blocks, anthropicBlock{Type: "image", Source: &anthropicSource{
+					Type:      "base64",
+					MediaType: image.MIMEType,
+					Data:      base64.StdEncoding.EncodeToString(image.Data),
+				}})
+			}
+			blocks = append(blocks, anthropicBlock{Type: "text", Text: msg.Text})
+			result = append(result, anthropicMessage{Role: "user", Content: blocks})
+		}
+	}
+	return result
+}
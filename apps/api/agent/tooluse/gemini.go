@@ -0,0 +1,228 @@
+package tooluse
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultGeminiBaseURL is the Gemini API endpoint
+const DefaultGeminiBaseURL = "https://generativelanguage.googleapis.com/v1beta"
+
+// Gemini calls the Gemini API with native function calling
+type Gemini struct {
+	APIKey     string
+	Model      string
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+// Statically assert that Gemini implements the model interface
+var _ Model = &Gemini{}
+
+// NewGemini creates a Gemini function-calling model
+func NewGemini(apiKey, model string) (*Gemini, error) {
+	if apiKey == "" {
+		return nil, fmt.Errorf("google API key is required")
+	}
+	if model == "" {
+		return nil, fmt.Errorf("gemini model is required")
+	}
+	return &Gemini{
+		APIKey:     apiKey,
+		Model:      model,
+		BaseURL:    DefaultGeminiBaseURL,
+		HTTPClient: http.DefaultClient,
+	}, nil
+}
+
+type geminiFunctionCall struct {
+	Name string         `json:"name"`
+	Args map[string]any `json:"args"`
+}
+
+type geminiFunctionResponse struct {
+	Name     string         `json:"name"`
+	Response map[string]any `json:"response"`
+}
+
+// geminiBlob is inline base64 data of a part
+type geminiBlob struct {
+	MIMEType string `json:"mimeType"`
+	Data     string `json:"data"`
+}
+
+type geminiPart struct {
+	Text             string                  `json:"text,omitempty"`
+	InlineData       *geminiBlob             `json:"inlineData,omitempty"`
+	FunctionCall     *geminiFunctionCall     `json:"functionCall,omitempty"`
+	FunctionResponse *geminiFunctionResponse `json:"functionResponse,omitempty"`
+}
+
+type geminiContent struct {
+	Role  string       `json:"role,omitempty"`
+	Parts []geminiPart `json:"parts"`
+}
+
+type geminiFunctionDeclaration struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Parameters  map[string]any `json:"parameters"`
+}
+
+type geminiTool struct {
+	FunctionDeclarations []geminiFunctionDeclaration `json:"functionDeclarations"`
+}
+
+type geminiRequest struct {
+	SystemInstruction *geminiContent  `json:"systemInstruction,omitempty"`
+	Contents          []geminiContent `json:"contents"`
+	Tools             []geminiTool    `json:"tools,omitempty"`
+	GenerationConfig  map[string]any  `json:"generationConfig,omitempty"`
+}
+
+// geminiChunk is a streamed generateContent response
+type geminiChunk struct {
+	Candidates []struct {
+		Content geminiContent `json:"content"`
+	} `json:"candidates"`
+	UsageMetadata struct {
+		PromptTokenCount     int `json:"promptTokenCount"`
+		CandidatesTokenCount int `json:"candidatesTokenCount"`
+	} `json:"usageMetadata"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Chat sends the conversation and streams the reply
+func (g *Gemini) Chat(ctx context.Context, req Request) (*Response, error) {
+	body := geminiRequest{
+		Contents: toGeminiContents(req.Messages),
+		GenerationConfig: map[string]any{
+			"temperature": req.Temperature,
+		},
+	}
+	if req.MaxTokens > 0 {
+		body.GenerationConfig["maxOutputTokens"] = req.MaxTokens
+	}
+	if req.System != "" {
+		body.SystemInstruction = &geminiContent{Parts: []geminiPart{{Text: req.System}}}
+	}
+	if len(req.Tools) > 0 {
+		declarations := make([]geminiFunctionDeclaration, 0, len(req.Tools))
+		for _, tool := range req.Tools {
+			declarations = append(declarations, geminiFunctionDeclaration{
+				Name:        tool.Name,
+				Description: tool.Description,
+				Parameters:  tool.InputSchema,
+			})
+		}
+		body.Tools = []geminiTool{{FunctionDeclarations: declarations}}
+	}
+
+	endpoint := fmt.Sprintf("%s/models/%s:streamGenerateContent?alt=sse",
+		strings.TrimSuffix(g.BaseURL, "/"), url.PathEscape(g.Model))
+	httpResp, err := postJSON(ctx, g.HTTPClient, endpoint, map[string]string{
+		"x-goog-api-key": g.APIKey,
+	}, body)
+	if err != nil {
+		return nil, fmt.Errorf("gemini: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	resp := &Response{}
+	var text strings.Builder
+	err = readSSE(httpResp.Body, func(data []byte) error {
+		var chunk geminiChunk
+		if err := json.Unmarshal(data, &chunk); err != nil {
+			return fmt.Errorf("failed to decode event: %w", err)
+		}
+		if chunk.Error != nil {
+			return fmt.Errorf("%s", chunk.Error.Message)
+		}
+
+		// Usage is cumulative; the last chunk has the totals
+		if chunk.UsageMetadata.PromptTokenCount > 0 {
+			resp.PromptTokens = chunk.UsageMetadata.PromptTokenCount
+			resp.CompletionTokens = chunk.UsageMetadata.CandidatesTokenCount
+		}
+
+		if len(chunk.Candidates) == 0 {
+			return nil
+		}
+		for _, part := range chunk.Candidates[0].Content.Parts {
+			if part.FunctionCall != nil {
+				// The agent runs one tool per step
+				if resp.ToolCall == nil {
+					resp.ToolCall = &ToolCall{Name: part.FunctionCall.Name, Input: part.FunctionCall.Args}
+				}
+				continue
+			}
+			if part.Text == "" {
+				continue
+			}
+			text.WriteString(part.Text)
+			if req.StreamingFunc != nil {
+				if err := req.StreamingFunc(ctx, []byte(part.Text)); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("gemini: %w", err)
+	}
+
+	resp.Text = text.String()
+	if resp.ToolCall != nil && resp.ToolCall.Input == nil {
+		resp.ToolCall.Input = map[string]any{}
+	}
+
+	return resp, nil
+}
+
+// toGeminiContents converts the conversation; Gemini calls the assistant
+// role "model" and matches function responses to calls by name
+func toGeminiContents(messages []Message) []geminiContent {
+	contents := make([]geminiContent, 0, len(messages))
+	for _, msg := range messages {
+		switch msg.Role {
+		case RoleAssistant:
+			var parts []geminiPart
+			if msg.Text != "" {
+				parts = append(parts, geminiPart{Text: msg.Text})
+			}
+			if msg.ToolCall != nil {
+				parts = append(parts, geminiPart{FunctionCall: &geminiFunctionCall{
+					Name: msg.ToolCall.Name,
+					Args: msg.ToolCall.Input,
+				}})
+			}
+			contents = append(contents, geminiContent{Role: "model", Parts: parts})
+		case RoleTool:
+			contents = append(contents, geminiContent{Role: "user", Parts: []geminiPart{{
+				FunctionResponse: &geminiFunctionResponse{
+					Name:     msg.Result.Name,
+					Response: map[string]any{"content": msg.Result.Content},
+				},
+			}}})
+		default:
+			parts := make([]geminiPart, 0, len(msg.Images)+1)
+			for _, image := range msg.Images {
+				parts = append(parts, geminiPart{InlineData: &geminiBlob{
+					MIMEType: image.MIMEType,
+					Data:     base64.StdEncoding.EncodeToString(image.Data),
+				}})
+			}
+			parts = append(parts, geminiPart{Text: msg.Text})
+			contents = append(contents, geminiContent{Role: "user", Parts: parts})
+		}
+	}
+	return contents
+}
@@ -0,0 +1,255 @@
+package tooluse
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/tmc/langchaingo/agents"
+	"github.com/tmc/langchaingo/callbacks"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/schema"
+	"github.com/tmc/langchaingo/tools"
+)
+
+// Message roles in a tool-use conversation
+const (
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+	RoleTool      = "tool"
+)
+
+// DefaultSystemPrompt is used when the agent has no persona
+const DefaultSystemPrompt = "You are a helpful assistant. Use the provided tools when they help answer the user's request, and answer directly when they don't."
+
+// ToolSpec describes a tool offered to the model
+type ToolSpec struct {
+	Name        string
+	Description string
+	InputSchema map[string]any
+}
+
+// ToolCall is a tool invocation requested by the model
+type ToolCall struct {
+	ID    string
+	Name  string
+	Input map[string]any
+}
+
+// ToolResult is the output of a tool call returned to the model
+type ToolResult struct {
+	CallID  string
+	Name    string
+	Content string
+}
+
+// Image is a picture sent to the model with a user turn
+type Image struct {
+	MIMEType string // image/png, image/jpeg, image/gif, or image/webp
+	Data     []byte
+}
+
+// Message is one turn of a tool-use conversation. User turns may carry
+// images; assistant turns may carry a tool call; tool turns carry its result.
+type Message struct {
+	Role     string
+	Text     string
+	Images   []Image
+	ToolCall *ToolCall
+	Result   *ToolResult
+}
+
+// Request is a single model call
+type Request struct {
+	System        string
+	Messages      []Message
+	Tools         []ToolSpec
+	Temperature   float64
+	MaxTokens     int
+	StreamingFunc func(ctx context.Context, chunk []byte) error // Receives text as it is generated
+}
+
+// Response is the model's reply: text, and at most one tool call
+type Response struct {
+	Text             string
+	ToolCall         *ToolCall
+	PromptTokens     int
+	CompletionTokens int
+}
+
+// Model is a chat model with native tool use
+type Model interface {
+	Chat(ctx context.Context, req Request) (*Response, error)
+}
+
+// Agent plans with a model's native tool use instead of parsing tool calls
+// out of text. It implements the langchaingo agent interface.
+type Agent struct {
+	Model            Model
+	Tools            []tools.Tool
+	SystemPrompt     string
+	Images           []Image // Sent with the user input
+	Temperature      float64
+	MaxTokens        int
+	CallbacksHandler callbacks.Handler
+	OutputKey        string
+}
+
+// Statically assert that Agent implements the agent interface
+var _ agents.Agent = &Agent{}
+
+// NewAgent creates a tool-use agent
+func NewAgent(model Model, agentTools []tools.Tool, systemPrompt string) *Agent {
+	if systemPrompt == "" {
+		systemPrompt = DefaultSystemPrompt
+	}
+	return &Agent{
+		Model:        model,
+		Tools:        agentTools,
+		SystemPrompt: systemPrompt,
+		OutputKey:    "output",
+	}
+}
+
+// GetInputKeys returns the inputs the agent expects
+func (a *Agent) GetInputKeys() []string {
+	return []string{"input"}
+}
+
+// GetOutputKeys returns the outputs the agent produces
+func (a *Agent) GetOutputKeys() []string {
+	return []string{a.OutputKey}
+}
+
+// Plan asks the model for the next tool call or the final answer
+func (a *Agent) Plan(
+	ctx context.Context,
+	intermediateSteps []schema.AgentStep,
+	inputs map[string]string,
+) ([]schema.AgentAction, *schema.AgentFinish, error) {
+	req := Request{
+		System:      a.systemPrompt(inputs["history"]),
+		Messages:    buildMessages(inputs["input"], a.Images, intermediateSteps),
+		Tools:       a.toolSpecs(),
+		Temperature: a.Temperature,
+		MaxTokens:   a.MaxTokens,
+	}
+	if a.CallbacksHandler != nil {
+		req.StreamingFunc = func(ctx context.Context, chunk []byte) error {
+			a.CallbacksHandler.HandleStreamingFunc(ctx, chunk)
+			return nil
+		}
+		a.CallbacksHandler.HandleLLMGenerateContentStart(ctx, []llms.MessageContent{
+			llms.TextParts(schema.ChatMessageTypeHuman, inputs["input"]),
+		})
+	}
+
+	resp, err := a.Model.Chat(ctx, req)
+	if err != nil {
+		if a.CallbacksHandler != nil {
+			a.CallbacksHandler.HandleLLMError(ctx, err)
+		}
+		return nil, nil, err
+	}
+	if a.CallbacksHandler != nil {
+		a.CallbacksHandler.HandleLLMGenerateContentEnd(ctx, contentResponse(resp))
+	}
+
+	if resp.ToolCall == nil {
+		return nil, &schema.AgentFinish{
+			ReturnValues: map[string]any{a.OutputKey: resp.Text},
+			Log:          resp.Text,
+		}, nil
+	}
+
+	input := toolInput(resp.ToolCall.Input)
+	log := fmt.Sprintf("Invoking: %s with %s", resp.ToolCall.Name, input)
+	if resp.Text != "" {
+		log = resp.Text + "\n" + log
+	}
+
+	return []schema.AgentAction{{
+		Tool:      resp.ToolCall.Name,
+		ToolInput: input,
+		Log:       log,
+	}}, nil, nil
+}
+
+// systemPrompt adds the conversation history to the persona
+func (a *Agent) systemPrompt(history string) string {
+	if strings.TrimSpace(history) == "" {
+		return a.SystemPrompt
+	}
+	return a.SystemPrompt + "\n\nConversation so far:\n" + history
+}
+
+// toolSpecs describes the agent's tools. Tools take a single text input.
+func (a *Agent) toolSpecs() []ToolSpec {
+	specs := make([]ToolSpec, 0, len(a.Tools))
+	for _, tool := range a.Tools {
+		specs = append(specs, ToolSpec{
+			Name:        tool.Name(),
+			Description: tool.Description(),
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"input": map[string]any{
+						"type":        "string",
+						"description": "Input for the tool",
+					},
+				},
+				"required": []string{"input"},
+			},
+		})
+	}
+	return specs
+}
+
+// buildMessages replays the user input with its images and the tool calls
+// made so far. Each step becomes an assistant tool call followed by its
+// result.
+func buildMessages(input string, images []Image, steps []schema.AgentStep) []Message {
+	messages := []Message{{Role: RoleUser, Text: input, Images: images}}
+	for i, step := range steps {
+		call := &ToolCall{
+			ID:    fmt.Sprintf("call_%d", i),
+			Name:  step.Action.Tool,
+			Input: map[string]any{"input": step.Action.ToolInput},
+		}
+		messages = append(messages,
+			Message{Role: RoleAssistant, ToolCall: call},
+			Message{Role: RoleTool, Result: &ToolResult{CallID: call.ID, Name: call.Name, Content: step.Observation}},
+		)
+	}
+	return messages
+}
+
+// toolInput extracts the text input of a tool call, falling back to the
+// raw JSON arguments
+func toolInput(args map[string]any) string {
+	if text, ok := args["input"].(string); ok && len(args) == 1 {
+		return text
+	}
+	raw, err := json.Marshal(args)
+	if err != nil {
+		return ""
+	}
+	return string(raw)
+}
+
+// contentResponse reports a response in the shape callbacks handlers expect
+func contentResponse(resp *Response) *llms.ContentResponse {
+	choice := &llms.ContentChoice{
+		Content: resp.Text,
+		GenerationInfo: map[string]any{
+			"PromptTokens":     resp.PromptTokens,
+			"CompletionTokens": resp.CompletionTokens,
+			"TotalTokens":      resp.PromptTokens + resp.CompletionTokens,
+		},
+	}
+	if resp.ToolCall != nil {
+		choice.FuncCall = &schema.FunctionCall{Name: resp.ToolCall.Name, Arguments: toolInput(resp.ToolCall.Input)}
+	}
+	return &llms.ContentResponse{Choices: []*llms.ContentChoice{choice}}
+}
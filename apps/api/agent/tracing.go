@@ -0,0 +1,125 @@
+package agent
+
+import (
+	"context"
+
+	"agentic-template/api/agent/tooluse"
+
+	"github.com/tmc/langchaingo/llms"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer creates the spans of agent runs, LLM calls, and tool calls. Spans
+// are no-ops until a tracer provider is installed.
+var tracer = otel.Tracer("agentic-template/api/agent")
+
+// Span attributes, following the OpenTelemetry GenAI conventions
+const (
+	attrSystem       = attribute.Key("gen_ai.system")
+	attrModel        = attribute.Key("gen_ai.request.model")
+	attrAgentName    = attribute.Key("gen_ai.agent.name")
+	attrToolName     = attribute.Key("gen_ai.tool.name")
+	attrInputTokens  = attribute.Key("gen_ai.usage.input_tokens")
+	attrOutputTokens = attribute.Key("gen_ai.usage.output_tokens")
+	attrIterations   = attribute.Key("agent.max_iterations")
+)
+
+// startRunSpan starts the span covering one agent run
+func (a *Agent) startRunSpan(ctx context.Context) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "agent.run "+a.name, trace.WithAttributes(
+		attrAgentName.String(a.name),
+		attrSystem.String(a.provider),
+		attrModel.String(a.model),
+		attrIterations.Int(a.maxIterations),
+	))
+}
+
+// endSpan records the outcome of a span and ends it
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// tracedModel wraps an LLM with a span per generation
+type tracedModel struct {
+	llms.Model
+	provider string
+	model    string
+}
+
+// GenerateContent traces a generation with its token usage
+func (m *tracedModel) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	ctx, span := tracer.Start(ctx, "llm.generate "+m.model, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attrSystem.String(m.provider),
+		attrModel.String(m.model),
+	))
+
+	resp, err := m.Model.GenerateContent(ctx, messages, options...)
+	if err == nil && resp != nil {
+		var input, output int
+		for _, choice := range resp.Choices {
+			if choice == nil {
+				continue
+			}
+			input += tokenCount(choice.GenerationInfo["PromptTokens"])
+			output += tokenCount(choice.GenerationInfo["CompletionTokens"])
+		}
+		span.SetAttributes(attrInputTokens.Int(input), attrOutputTokens.Int(output))
+	}
+
+	endSpan(span, err)
+	return resp, err
+}
+
+// Call traces the deprecated single-prompt interface through GenerateContent
+func (m *tracedModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
+
+// tracedToolModel wraps a native tool-use model with a span per call
+type tracedToolModel struct {
+	tooluse.Model
+	provider string
+	model    string
+}
+
+// Chat traces a tool-use call with its token usage and requested tool
+func (m *tracedToolModel) Chat(ctx context.Context, req tooluse.Request) (*tooluse.Response, error) {
+	ctx, span := tracer.Start(ctx, "llm.chat "+m.model, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attrSystem.String(m.provider),
+		attrModel.String(m.model),
+	))
+
+	resp, err := m.Model.Chat(ctx, req)
+	if err == nil {
+		span.SetAttributes(attrInputTokens.Int(resp.PromptTokens), attrOutputTokens.Int(resp.CompletionTokens))
+		if resp.ToolCall != nil {
+			span.SetAttributes(attrToolName.String(resp.ToolCall.Name))
+		}
+	}
+
+	endSpan(span, err)
+	return resp, err
+}
+
+// tokenCount converts a numeric generation info value to an int
+func tokenCount(v any) int {
+	switch n := v.(type) {
+	case int:
+		return n
+	case int32:
+		return int(n)
+	case int64:
+		return int(n)
+	case float64:
+		return int(n)
+	default:
+		return 0
+	}
+}
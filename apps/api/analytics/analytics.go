@@ -0,0 +1,268 @@
+// Package analytics tracks API usage of user-defined tables: requests per
+// table split into reads and writes, failures, and a latency histogram.
+// Requests are counted in memory and added to hourly rows of the
+// table_usage table in the background, so tracking never slows down or
+// fails a request.
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"agentic-template/api/db"
+	"agentic-template/api/requestctx"
+)
+
+// Tracker tuning
+const (
+	bucketSize    = time.Hour        // Requests are counted per table and hour
+	flushInterval = 30 * time.Second // How often counts are added to table_usage
+	writeTimeout  = 5 * time.Second  // Bound on each flush
+	purgeInterval = time.Hour        // How often expired rows are deleted
+	purgeTimeout  = 30 * time.Second // Bound on each purge
+	maxPending    = 10000            // Table buckets held between flushes before new ones are dropped
+)
+
+// LatencyBoundsMS are the upper bounds of the latency histogram buckets in
+// milliseconds. A last bucket counts slower requests.
+var LatencyBoundsMS = []int64{5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000}
+
+// Request is one API request naming a user table
+type Request struct {
+	TableID int
+	Schema  string // Tenant schema from db.TenantSchema; empty for the shared schema
+	Write   bool   // Changes the table rather than reading it
+	Failed  bool
+	Latency time.Duration
+	At      time.Time
+}
+
+// Counts are the requests of a table in some period
+type Counts struct {
+	Reads         int64
+	Writes        int64
+	Errors        int64   // Failed requests, also counted as reads or writes
+	LatencyCounts []int64 // Requests per bucket of LatencyBoundsMS
+}
+
+// add counts a request
+func (c *Counts) add(req Request) {
+	if req.Write {
+		c.Writes++
+	} else {
+		c.Reads++
+	}
+	if req.Failed {
+		c.Errors++
+	}
+	if c.LatencyCounts == nil {
+		c.LatencyCounts = make([]int64, len(LatencyBoundsMS)+1)
+	}
+	ms := req.Latency.Milliseconds()
+	i, _ := slices.BinarySearch(LatencyBoundsMS, ms)
+	c.LatencyCounts[i]++
+}
+
+// merge adds the requests of other
+func (c *Counts) merge(other Counts) {
+	c.Reads += other.Reads
+	c.Writes += other.Writes
+	c.Errors += other.Errors
+	for len(c.LatencyCounts) < len(other.LatencyCounts) {
+		c.LatencyCounts = append(c.LatencyCounts, 0)
+	}
+	for i, n := range other.LatencyCounts {
+		c.LatencyCounts[i] += n
+	}
+}
+
+// Config configures the tracker
+type Config struct {
+	RetentionDays int // Hourly rows older than this are deleted; 0 keeps them forever
+}
+
+// bucketKey identifies the counts of a table in one hour
+type bucketKey struct {
+	schema  string
+	tableID int
+	bucket  time.Time
+}
+
+// Tracker counts table requests and adds them to table_usage
+type Tracker struct {
+	dbManager *db.Manager
+	retention time.Duration
+	stop      chan struct{}
+	wg        sync.WaitGroup
+	dropped   atomic.Int64
+
+	mu      sync.Mutex
+	pending map[bucketKey]*Counts
+	schemas map[string]struct{} // Schemas written to, purged in turn
+}
+
+// New creates a tracker and starts its flush and retention workers
+func New(dbManager *db.Manager, cfg Config) *Tracker {
+	t := &Tracker{
+		dbManager: dbManager,
+		retention: time.Duration(cfg.RetentionDays) * 24 * time.Hour,
+		stop:      make(chan struct{}),
+		pending:   map[bucketKey]*Counts{},
+		schemas:   map[string]struct{}{},
+	}
+
+	t.wg.Add(1)
+	go t.flushLoop()
+	if t.retention > 0 {
+		t.wg.Add(1)
+		go t.purgeLoop()
+	}
+	return t
+}
+
+// Record counts a request. When too many table buckets are pending the
+// request is dropped rather than growing memory without bound.
+func (t *Tracker) Record(req Request) {
+	if req.At.IsZero() {
+		req.At = time.Now()
+	}
+	key := bucketKey{schema: req.Schema, tableID: req.TableID, bucket: req.At.UTC().Truncate(bucketSize)}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	counts, ok := t.pending[key]
+	if !ok {
+		if len(t.pending) >= maxPending {
+			if dropped := t.dropped.Add(1); dropped == 1 || dropped%1000 == 0 {
+				log.Printf("Warning: table analytics backlog full, %d requests dropped so far", dropped)
+			}
+			return
+		}
+		counts = &Counts{}
+		t.pending[key] = counts
+	}
+	counts.add(req)
+}
+
+// Close stops the workers after flushing the pending counts, or when ctx
+// is done
+func (t *Tracker) Close(ctx context.Context) error {
+	close(t.stop)
+
+	done := make(chan struct{})
+	go func() {
+		t.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("table analytics unflushed: %w", ctx.Err())
+	}
+}
+
+// flushLoop flushes the pending counts every flushInterval until the
+// tracker is closed
+func (t *Tracker) flushLoop() {
+	defer t.wg.Done()
+
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			t.flush()
+		case <-t.stop:
+			t.flush()
+			return
+		}
+	}
+}
+
+// flush adds the pending counts to table_usage, one batch per schema.
+// Counts are lost when the database is unavailable.
+func (t *Tracker) flush() {
+	t.mu.Lock()
+	pending := t.pending
+	t.pending = map[bucketKey]*Counts{}
+	t.mu.Unlock()
+	if len(pending) == 0 {
+		return
+	}
+
+	pool := t.dbManager.GetPool()
+	if pool == nil {
+		return
+	}
+
+	bySchema := map[string][]Row{}
+	for key, counts := range pending {
+		bySchema[key.schema] = append(bySchema[key.schema], Row{TableID: key.tableID, Bucket: key.bucket, Counts: *counts})
+	}
+
+	for schema, rows := range bySchema {
+		ctx, cancel := context.WithTimeout(requestctx.WithTenant(context.Background(), "", schema), writeTimeout)
+		err := NewStore(pool).Add(ctx, rows)
+		cancel()
+		if err != nil {
+			log.Printf("Warning: failed to write table analytics for %d table bucket(s): %v", len(rows), err)
+			continue
+		}
+		t.mu.Lock()
+		t.schemas[schema] = struct{}{}
+		t.mu.Unlock()
+	}
+}
+
+// purgeLoop deletes expired rows now and every purgeInterval
+func (t *Tracker) purgeLoop() {
+	defer t.wg.Done()
+
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+
+	for {
+		t.purge()
+		select {
+		case <-ticker.C:
+		case <-t.stop:
+			return
+		}
+	}
+}
+
+// purge deletes rows older than the retention period, and those of deleted
+// tables, in the shared schema and every tenant schema written to
+func (t *Tracker) purge() {
+	pool := t.dbManager.GetPool()
+	if pool == nil {
+		return
+	}
+
+	t.mu.Lock()
+	t.schemas[""] = struct{}{}
+	schemas := slices.Collect(maps.Keys(t.schemas))
+	t.mu.Unlock()
+
+	before := time.Now().Add(-t.retention)
+	for _, schema := range schemas {
+		ctx, cancel := context.WithTimeout(requestctx.WithTenant(context.Background(), "", schema), purgeTimeout)
+		deleted, err := NewStore(pool).Purge(ctx, before)
+		cancel()
+		if err != nil {
+			log.Printf("Warning: failed to purge table analytics: %v", err)
+			continue
+		}
+		if deleted > 0 {
+			log.Printf("Purged %d table analytics row(s)", deleted)
+		}
+	}
+}
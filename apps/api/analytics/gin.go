@@ -0,0 +1,44 @@
+package analytics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"agentic-template/api/requestctx"
+
+	"github.com/gin-gonic/gin"
+)
+
+// tableRoutes are the tracked HTTP routes naming a table in their :id
+// parameter, and whether they change the table
+var tableRoutes = map[string]bool{
+	http.MethodGet + " /api/schema/tables/:id":    false,
+	http.MethodDelete + " /api/schema/tables/:id": true,
+}
+
+// GinMiddleware counts requests to table routes. It must run after tenancy
+// scoping so counts land in the tenant's schema.
+func (t *Tracker) GinMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		started := time.Now()
+		c.Next()
+
+		write, tracked := tableRoutes[c.Request.Method+" "+c.FullPath()]
+		if !tracked {
+			return
+		}
+		tableID, err := strconv.Atoi(c.Param("id"))
+		if err != nil || tableID <= 0 {
+			return
+		}
+		t.Record(Request{
+			TableID: tableID,
+			Schema:  requestctx.TenantSchema(c.Request.Context()),
+			Write:   write,
+			Failed:  c.Writer.Status() >= http.StatusBadRequest,
+			Latency: time.Since(started),
+			At:      started,
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package analytics
+
+import (
+	"context"
+	"time"
+
+	"agentic-template/api/auth"
+	"agentic-template/api/requestctx"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+// tableMethods are the tracked RPCs naming a table in their table_id
+// field, keyed by auth.ServiceMethod, and whether they change the table.
+// Creating a table isn't counted: its usage starts with the first request
+// by ID.
+var tableMethods = map[string]bool{
+	"SchemaService/GetTable":            false,
+	"SchemaService/DeleteTable":         true,
+	"SchemaService/RestoreTable":        true,
+	"SchemaService/PatchJSONValue":      true,
+	"SchemaService/CheckTableIntegrity": true,
+	"KnowledgeService/SemanticSearch":   false,
+}
+
+// UnaryServerInterceptor counts calls of table RPCs. It must run after
+// tenancy scoping so counts land in the tenant's schema.
+func (t *Tracker) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		write, tracked := tableMethods[auth.ServiceMethod(info.FullMethod)]
+		if !tracked {
+			return handler(ctx, req)
+		}
+		tableID := requestTableID(req)
+		if tableID <= 0 {
+			return handler(ctx, req)
+		}
+
+		started := time.Now()
+		resp, err := handler(ctx, req)
+		t.Record(Request{
+			TableID: tableID,
+			Schema:  requestctx.TenantSchema(ctx),
+			Write:   write,
+			Failed:  err != nil,
+			Latency: time.Since(started),
+			At:      started,
+		})
+		return resp, err
+	}
+}
+
+// requestTableID returns the table_id field of a request, 0 when unset
+func requestTableID(req any) int {
+	msg, ok := req.(proto.Message)
+	if !ok {
+		return 0
+	}
+	m := msg.ProtoReflect()
+	field := m.Descriptor().Fields().ByName("table_id")
+	if field == nil || !m.Has(field) {
+		return 0
+	}
+	return int(m.Get(field).Int())
+}
@@ -0,0 +1,216 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Periods of Usage in days
+const (
+	DefaultDays = 7
+	MaxDays     = 366
+)
+
+// Errors returned by the store
+var (
+	ErrDatabaseNotConfigured = errors.New("database not configured - please add DATABASE_URL_POOLED in Environment Settings")
+	ErrTableNotFound         = errors.New("table not found")
+)
+
+// Row is the requests of one table in one hour
+type Row struct {
+	TableID int
+	Bucket  time.Time // Start of the hour
+	Counts
+}
+
+// Query selects the tables and period of Usage
+type Query struct {
+	TableID *int      // Only this table; nil reports every table
+	Since   time.Time // Hours starting before this aren't counted
+}
+
+// TableUsage is the requests of a table over a period
+type TableUsage struct {
+	TableID    int
+	Name       string
+	LastUsedAt *time.Time // Start of the last hour with requests; nil when unused
+	Counts
+}
+
+// Requests returns the number of requests
+func (u TableUsage) Requests() int64 {
+	return u.Reads + u.Writes
+}
+
+// ReadRatio returns the share of requests that were reads, 0 when unused
+func (u TableUsage) ReadRatio() float64 {
+	if u.Requests() == 0 {
+		return 0
+	}
+	return float64(u.Reads) / float64(u.Requests())
+}
+
+// LatencyPercentileMS estimates the latency under which a share p (0-1) of
+// requests finished, interpolating within the histogram bucket. Requests
+// slower than the last bound count as the last bound; 0 when unused.
+func (u TableUsage) LatencyPercentileMS(p float64) float64 {
+	var total int64
+	for _, n := range u.LatencyCounts {
+		total += n
+	}
+	if total == 0 {
+		return 0
+	}
+
+	rank := p * float64(total)
+	var seen int64
+	for i, n := range u.LatencyCounts {
+		if n == 0 || float64(seen+n) < rank {
+			seen += n
+			continue
+		}
+		if i >= len(LatencyBoundsMS) {
+			break
+		}
+		lower := 0.0
+		if i > 0 {
+			lower = float64(LatencyBoundsMS[i-1])
+		}
+		upper := float64(LatencyBoundsMS[i])
+		return lower + (upper-lower)*(rank-float64(seen))/float64(n)
+	}
+	return float64(LatencyBoundsMS[len(LatencyBoundsMS)-1])
+}
+
+// Store reads and writes table_usage
+type Store struct {
+	pool *pgxpool.Pool
+}
+
+// NewStore creates a new analytics store
+func NewStore(pool *pgxpool.Pool) *Store {
+	return &Store{
+		pool: pool,
+	}
+}
+
+// Add adds counts to the hourly rows of their tables in one batch.
+// Histograms are added element-wise.
+func (s *Store) Add(ctx context.Context, rows []Row) error {
+	if s.pool == nil {
+		return ErrDatabaseNotConfigured
+	}
+
+	query := `
+		INSERT INTO table_usage (table_id, bucket, reads, writes, errors, latency_counts)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (table_id, bucket) DO UPDATE SET
+			reads = table_usage.reads + EXCLUDED.reads,
+			writes = table_usage.writes + EXCLUDED.writes,
+			errors = table_usage.errors + EXCLUDED.errors,
+			latency_counts = ARRAY(
+				SELECT COALESCE(a, 0) + COALESCE(b, 0)
+				FROM unnest(table_usage.latency_counts, EXCLUDED.latency_counts) WITH ORDINALITY AS t(a, b, i)
+				ORDER BY i
+			)
+	`
+	batch := &pgx.Batch{}
+	for _, row := range rows {
+		batch.Queue(query, row.TableID, row.Bucket, row.Reads, row.Writes, row.Errors, row.LatencyCounts)
+	}
+	return s.pool.SendBatch(ctx, batch).Close()
+}
+
+// Usage returns the usage of existing tables since query.Since, most
+// requested first; unused tables are included with zero counts. A table
+// filter naming no table returns ErrTableNotFound.
+func (s *Store) Usage(ctx context.Context, query Query) ([]TableUsage, error) {
+	if s.pool == nil {
+		return nil, ErrDatabaseNotConfigured
+	}
+
+	sql := `
+		SELECT t.id, t.name, u.bucket, u.reads, u.writes, u.errors, u.latency_counts
+		FROM configurable_tables t
+		LEFT JOIN table_usage u ON u.table_id = t.id AND u.bucket >= $1`
+	args := []any{query.Since.UTC().Truncate(bucketSize)}
+	if query.TableID != nil {
+		args = append(args, *query.TableID)
+		sql += " WHERE t.id = $2"
+	}
+
+	rows, err := s.pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query table usage: %w", err)
+	}
+	defer rows.Close()
+
+	byTable := map[int]*TableUsage{}
+	for rows.Next() {
+		var (
+			tableID               int
+			name                  string
+			bucket                *time.Time
+			reads, writes, failed *int64
+			latencyCounts         []int64
+		)
+		if err := rows.Scan(&tableID, &name, &bucket, &reads, &writes, &failed, &latencyCounts); err != nil {
+			return nil, fmt.Errorf("failed to scan table usage: %w", err)
+		}
+
+		usage, ok := byTable[tableID]
+		if !ok {
+			usage = &TableUsage{TableID: tableID, Name: name}
+			byTable[tableID] = usage
+		}
+		if bucket == nil {
+			continue
+		}
+		usage.merge(Counts{Reads: *reads, Writes: *writes, Errors: *failed, LatencyCounts: latencyCounts})
+		if usage.LastUsedAt == nil || bucket.After(*usage.LastUsedAt) {
+			usage.LastUsedAt = bucket
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read table usage: %w", err)
+	}
+	if query.TableID != nil && len(byTable) == 0 {
+		return nil, ErrTableNotFound
+	}
+
+	usages := make([]TableUsage, 0, len(byTable))
+	for _, usage := range byTable {
+		usages = append(usages, *usage)
+	}
+	sort.Slice(usages, func(i, j int) bool {
+		if usages[i].Requests() != usages[j].Requests() {
+			return usages[i].Requests() > usages[j].Requests()
+		}
+		return usages[i].TableID < usages[j].TableID
+	})
+	return usages, nil
+}
+
+// Purge deletes rows of hours before a time and rows of deleted tables,
+// and returns how many were deleted
+func (s *Store) Purge(ctx context.Context, before time.Time) (int64, error) {
+	if s.pool == nil {
+		return 0, ErrDatabaseNotConfigured
+	}
+
+	tag, err := s.pool.Exec(ctx, `
+		DELETE FROM table_usage u
+		WHERE u.bucket < $1 OR NOT EXISTS (SELECT 1 FROM configurable_tables t WHERE t.id = u.table_id)
+	`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge table usage: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,210 @@
+// Package audit records mutating API calls with their actor, request, and
+// outcome in the api_audit_log table. Entries are written in the
+// background so auditing never slows down or fails a request.
+package audit
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"agentic-template/api/db"
+)
+
+// Transports
+const (
+	TransportGRPC = "grpc"
+	TransportHTTP = "http"
+)
+
+// Writer tuning
+const (
+	defaultBufferSize = 1024             // Entries queued before new ones are dropped
+	maxBatchSize      = 100              // Entries written per INSERT batch
+	flushInterval     = time.Second      // Longest an entry waits in a partial batch
+	writeTimeout      = 5 * time.Second  // Bound on each batch write
+	purgeInterval     = time.Hour        // How often expired entries are deleted
+	purgeTimeout      = 30 * time.Second // Bound on each purge
+)
+
+// Entry is one audited API call
+type Entry struct {
+	ID             int64     `json:"id"`
+	OccurredAt     time.Time `json:"occurred_at"`
+	Actor          string    `json:"actor"`
+	Transport      string    `json:"transport"` // grpc or http
+	Method         string    `json:"method"`
+	Resource       string    `json:"resource"`
+	RequestSummary string    `json:"request_summary,omitempty"` // JSON
+	Status         string    `json:"status"`
+	LatencyMS      int64     `json:"latency_ms"`
+	RequestID      string    `json:"request_id"`
+	ClientIP       string    `json:"client_ip"`
+}
+
+// Config configures the recorder
+type Config struct {
+	RetentionDays int // Entries older than this are deleted; 0 keeps them forever
+	BufferSize    int // Defaults to 1024
+}
+
+// Recorder queues audit entries and writes them in batches
+type Recorder struct {
+	dbManager *db.Manager
+	retention time.Duration
+	entries   chan Entry
+	stop      chan struct{}
+	wg        sync.WaitGroup
+	dropped   atomic.Int64
+}
+
+// New creates a recorder and starts its writer and retention workers
+func New(dbManager *db.Manager, cfg Config) *Recorder {
+	bufferSize := cfg.BufferSize
+	if bufferSize <= 0 {
+		bufferSize = defaultBufferSize
+	}
+
+	r := &Recorder{
+		dbManager: dbManager,
+		retention: time.Duration(cfg.RetentionDays) * 24 * time.Hour,
+		entries:   make(chan Entry, bufferSize),
+		stop:      make(chan struct{}),
+	}
+
+	r.wg.Add(1)
+	go r.writeLoop()
+	if r.retention > 0 {
+		r.wg.Add(1)
+		go r.purgeLoop()
+	}
+	return r
+}
+
+// Record queues an entry. When the queue is full the entry is dropped
+// rather than blocking the request.
+func (r *Recorder) Record(entry Entry) {
+	select {
+	case r.entries <- entry:
+	default:
+		if dropped := r.dropped.Add(1); dropped == 1 || dropped%1000 == 0 {
+			log.Printf("Warning: audit log queue full, %d entries dropped so far", dropped)
+		}
+	}
+}
+
+// Close stops the workers after writing the queued entries, or when ctx
+// is done
+func (r *Recorder) Close(ctx context.Context) error {
+	close(r.stop)
+
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%d audit log entries unwritten: %w", len(r.entries), ctx.Err())
+	}
+}
+
+// writeLoop writes queued entries in batches until the recorder is closed
+func (r *Recorder) writeLoop() {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]Entry, 0, maxBatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		r.write(batch)
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case entry := <-r.entries:
+			batch = append(batch, entry)
+			if len(batch) == maxBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-r.stop:
+			for {
+				select {
+				case entry := <-r.entries:
+					batch = append(batch, entry)
+					if len(batch) == maxBatchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// write inserts a batch, logging failures. Entries are lost when the
+// database is unavailable.
+func (r *Recorder) write(batch []Entry) {
+	pool := r.dbManager.GetPool()
+	if pool == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), writeTimeout)
+	defer cancel()
+
+	if err := NewStore(pool).Insert(ctx, batch); err != nil {
+		log.Printf("Warning: failed to write %d audit log entries: %v", len(batch), err)
+	}
+}
+
+// purgeLoop deletes expired entries now and every purgeInterval
+func (r *Recorder) purgeLoop() {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+
+	for {
+		r.purge()
+		select {
+		case <-ticker.C:
+		case <-r.stop:
+			return
+		}
+	}
+}
+
+// purge deletes entries older than the retention period
+func (r *Recorder) purge() {
+	pool := r.dbManager.GetPool()
+	if pool == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), purgeTimeout)
+	defer cancel()
+
+	deleted, err := NewStore(pool).Purge(ctx, time.Now().Add(-r.retention))
+	if err != nil {
+		log.Printf("Warning: failed to purge audit log: %v", err)
+		return
+	}
+	if deleted > 0 {
+		log.Printf("Purged %d audit log entries older than %s", deleted, r.retention)
+	}
+}
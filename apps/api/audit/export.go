@@ -0,0 +1,383 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"agentic-template/api/db"
+	"agentic-template/api/metrics"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Export sources
+const (
+	SourceAPI    = "api"    // Mutating API calls from api_audit_log
+	SourceSchema = "schema" // DDL from schema_change_log
+)
+
+// Exporter tuning
+const (
+	defaultExportInterval  = time.Minute
+	defaultExportBatchSize = 500
+	maxExportBackoff       = time.Hour        // Longest wait after repeated sink failures
+	maxBatchesPerRun       = 20               // Batches per source before yielding to the next run
+	exportTimeout          = 30 * time.Second // Bound on reading, sending, and recording one batch
+)
+
+var exportedEvents = metrics.NewCounter("audit_export_events_total",
+	"Audit events handled by the exporter, by sink and outcome (sent, filtered, or failed).", "sink", "outcome")
+
+// Event is an audit record from any source in the form sent to sinks
+type Event struct {
+	Source     string          `json:"source"` // api or schema
+	ID         int64           `json:"id"`     // Unique within the source
+	OccurredAt time.Time       `json:"occurred_at"`
+	Actor      string          `json:"actor"`
+	Action     string          `json:"action"` // RPC or HTTP route, or schema change type
+	Resource   string          `json:"resource,omitempty"`
+	Status     string          `json:"status"`
+	Details    json.RawMessage `json:"details,omitempty"` // Source-specific fields
+}
+
+// exportQueries read the events of each source with IDs in ($1, $2],
+// oldest first, at most $3
+var exportQueries = map[string]string{
+	SourceAPI: `
+		SELECT id, occurred_at, actor, method, resource, status,
+			jsonb_strip_nulls(jsonb_build_object(
+				'transport', transport, 'request_summary', request_summary, 'latency_ms', latency_ms,
+				'request_id', request_id, 'client_ip', client_ip))
+		FROM api_audit_log
+		WHERE id > $1 AND id <= $2
+		ORDER BY id
+		LIMIT $3`,
+	SourceSchema: `
+		SELECT id, created_at, COALESCE(created_by, 'system'), change_type,
+			COALESCE('table_id=' || table_id, ''), status,
+			jsonb_strip_nulls(jsonb_build_object(
+				'change_details', change_details, 'executed_sql', executed_sql, 'error_message', error_message))
+		FROM schema_change_log
+		WHERE id > $1 AND id <= $2
+		ORDER BY id
+		LIMIT $3`,
+}
+
+// latestIDQueries return the newest ID of each source
+var latestIDQueries = map[string]string{
+	SourceAPI:    `SELECT COALESCE(MAX(id), 0) FROM api_audit_log`,
+	SourceSchema: `SELECT COALESCE(MAX(id), 0) FROM schema_change_log`,
+}
+
+// ExportSources lists the sources the exporter can read
+func ExportSources() []string {
+	return []string{SourceAPI, SourceSchema}
+}
+
+// ExportConfig configures the exporter
+type ExportConfig struct {
+	Sinks     []Sink
+	Sources   []string      // Defaults to every source
+	Actions   []string      // Exported action prefixes, such as "SchemaService/"; empty exports every action
+	Interval  time.Duration // Time between runs; defaults to a minute
+	BatchSize int           // Events per sink write; defaults to 500
+}
+
+// SinkStatus is the export progress of one sink
+type SinkStatus struct {
+	Sink        string     `json:"sink"`
+	Exported    int64      `json:"exported"` // Events sent since startup
+	LastSuccess *time.Time `json:"last_success,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+	Failures    int        `json:"failures"` // Consecutive failed runs
+	NextRun     time.Time  `json:"next_run"`
+}
+
+// Exporter ships audit events to external sinks on a schedule. Each sink
+// keeps a cursor per source in audit_export_cursors, so events are sent at
+// least once across restarts and instances. A failing sink backs off
+// without holding up the others; its events wait in the database, so keep
+// the retention period well above the longest outage to tolerate.
+type Exporter struct {
+	dbManager *db.Manager
+	config    ExportConfig
+	stop      chan struct{}
+	wg        sync.WaitGroup
+
+	mu     sync.Mutex
+	status map[string]*SinkStatus
+}
+
+// NewExporter creates an exporter and starts a worker per sink
+func NewExporter(dbManager *db.Manager, cfg ExportConfig) *Exporter {
+	if len(cfg.Sources) == 0 {
+		cfg.Sources = ExportSources()
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultExportInterval
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultExportBatchSize
+	}
+
+	e := &Exporter{
+		dbManager: dbManager,
+		config:    cfg,
+		stop:      make(chan struct{}),
+		status:    map[string]*SinkStatus{},
+	}
+	for _, sink := range cfg.Sinks {
+		e.status[sink.Name()] = &SinkStatus{Sink: sink.Name(), NextRun: time.Now().Add(cfg.Interval)}
+		e.wg.Add(1)
+		go e.exportLoop(sink)
+	}
+	return e
+}
+
+// Status returns the progress of every sink, sorted by name
+func (e *Exporter) Status() []SinkStatus {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	statuses := make([]SinkStatus, 0, len(e.status))
+	for _, status := range e.status {
+		statuses = append(statuses, *status)
+	}
+	slices.SortFunc(statuses, func(a, b SinkStatus) int { return strings.Compare(a.Sink, b.Sink) })
+	return statuses
+}
+
+// Close stops the workers, waiting for running exports until ctx is done
+func (e *Exporter) Close(ctx context.Context) error {
+	close(e.stop)
+
+	done := make(chan struct{})
+	go func() {
+		e.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("audit export still running: %w", ctx.Err())
+	}
+}
+
+// exportLoop runs the exports of one sink every interval, backing off
+// exponentially while it fails
+func (e *Exporter) exportLoop(sink Sink) {
+	defer e.wg.Done()
+
+	// Export only IDs seen by the previous run: IDs are allocated before
+	// their transaction commits, so a newer ID can become visible first
+	ceilings := map[string]int64{}
+	e.refreshCeilings(ceilings)
+
+	wait := e.config.Interval
+	for {
+		select {
+		case <-time.After(wait):
+		case <-e.stop:
+			return
+		}
+
+		exported, err := e.export(sink, ceilings)
+		e.refreshCeilings(ceilings)
+
+		e.mu.Lock()
+		status := e.status[sink.Name()]
+		status.Exported += exported
+		if err != nil {
+			status.Failures++
+			status.LastError = err.Error()
+			wait = min(e.config.Interval<<min(status.Failures, 16), maxExportBackoff)
+			log.Printf("Warning: audit export to %s failed %d time(s), retrying in %s: %v", sink.Name(), status.Failures, wait, err)
+		} else {
+			now := time.Now()
+			status.Failures, status.LastError, status.LastSuccess = 0, "", &now
+			wait = e.config.Interval
+		}
+		status.NextRun = time.Now().Add(wait)
+		e.mu.Unlock()
+	}
+}
+
+// refreshCeilings records the newest ID of each source
+func (e *Exporter) refreshCeilings(ceilings map[string]int64) {
+	pool := e.dbManager.GetPool()
+	if pool == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+	defer cancel()
+
+	for _, source := range e.config.Sources {
+		var latest int64
+		if err := pool.QueryRow(ctx, latestIDQueries[source]).Scan(&latest); err != nil {
+			log.Printf("Warning: failed to read the newest %s audit event: %v", source, err)
+			continue
+		}
+		ceilings[source] = latest
+	}
+}
+
+// export sends the pending events of every source to a sink and returns
+// how many were sent
+func (e *Exporter) export(sink Sink, ceilings map[string]int64) (int64, error) {
+	pool := e.dbManager.GetPool()
+	if pool == nil {
+		return 0, nil
+	}
+
+	var exported int64
+	for _, source := range e.config.Sources {
+		for range maxBatchesPerRun {
+			sent, more, err := e.exportBatch(pool, sink, source, ceilings[source])
+			exported += int64(sent)
+			if err != nil {
+				e.recordFailure(pool, sink, source, err)
+				return exported, fmt.Errorf("%s events: %w", source, err)
+			}
+			if !more {
+				break
+			}
+		}
+	}
+	return exported, nil
+}
+
+// exportBatch sends the next batch of a source's events up to ceiling and
+// advances the sink's cursor. It reports how many events were sent and
+// whether more are pending. A cursor locked by another instance's export
+// is skipped.
+func (e *Exporter) exportBatch(pool *pgxpool.Pool, sink Sink, source string, ceiling int64) (int, bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+	defer cancel()
+
+	if _, err := pool.Exec(ctx, `
+		INSERT INTO audit_export_cursors (sink, source) VALUES ($1, $2)
+		ON CONFLICT (sink, source) DO NOTHING`, sink.Name(), source); err != nil {
+		return 0, false, fmt.Errorf("failed to create export cursor: %w", err)
+	}
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to begin export: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var lastID int64
+	err = tx.QueryRow(ctx, `
+		SELECT last_id FROM audit_export_cursors
+		WHERE sink = $1 AND source = $2
+		FOR UPDATE SKIP LOCKED`, sink.Name(), source).Scan(&lastID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to lock export cursor: %w", err)
+	}
+	if lastID >= ceiling {
+		return 0, false, nil
+	}
+
+	events, err := readEvents(ctx, tx, source, lastID, ceiling, e.config.BatchSize)
+	if err != nil {
+		return 0, false, err
+	}
+	if len(events) == 0 {
+		return 0, false, nil
+	}
+	newLastID := events[len(events)-1].ID
+	more := len(events) == e.config.BatchSize
+
+	selected := e.filter(events)
+	exportedEvents.Add(float64(len(events)-len(selected)), sink.Name(), "filtered")
+	if len(selected) > 0 {
+		if err := sink.Write(ctx, selected); err != nil {
+			exportedEvents.Add(float64(len(selected)), sink.Name(), "failed")
+			return 0, false, err
+		}
+		exportedEvents.Add(float64(len(selected)), sink.Name(), "sent")
+	}
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE audit_export_cursors SET last_id = $3, last_error = NULL, updated_at = NOW()
+		WHERE sink = $1 AND source = $2`, sink.Name(), source, newLastID); err != nil {
+		return 0, false, fmt.Errorf("failed to advance export cursor: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, false, fmt.Errorf("failed to advance export cursor: %w", err)
+	}
+	return len(selected), more, nil
+}
+
+// recordFailure stores why an export failed on the sink's cursor
+func (e *Exporter) recordFailure(pool *pgxpool.Pool, sink Sink, source string, cause error) {
+	ctx, cancel := context.WithTimeout(context.Background(), writeTimeout)
+	defer cancel()
+
+	if _, err := pool.Exec(ctx, `
+		UPDATE audit_export_cursors SET last_error = $3, updated_at = NOW()
+		WHERE sink = $1 AND source = $2`, sink.Name(), source, cause.Error()); err != nil {
+		log.Printf("Warning: failed to record audit export failure: %v", err)
+	}
+}
+
+// filter returns the events whose action matches a configured prefix
+func (e *Exporter) filter(events []Event) []Event {
+	if len(e.config.Actions) == 0 {
+		return events
+	}
+	selected := make([]Event, 0, len(events))
+	for _, event := range events {
+		for _, prefix := range e.config.Actions {
+			if strings.HasPrefix(event.Action, prefix) {
+				selected = append(selected, event)
+				break
+			}
+		}
+	}
+	return selected
+}
+
+// readEvents reads a source's events with IDs in (afterID, ceiling]
+func readEvents(ctx context.Context, tx pgx.Tx, source string, afterID, ceiling int64, limit int) ([]Event, error) {
+	rows, err := tx.Query(ctx, exportQueries[source], afterID, ceiling, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s audit events: %w", source, err)
+	}
+	defer rows.Close()
+
+	var events []Event
+	for rows.Next() {
+		event := Event{Source: source}
+		err := rows.Scan(
+			&event.ID,
+			&event.OccurredAt,
+			&event.Actor,
+			&event.Action,
+			&event.Resource,
+			&event.Status,
+			&event.Details,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan %s audit event: %w", source, err)
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s audit events: %w", source, err)
+	}
+	return events, nil
+}
@@ -0,0 +1,71 @@
+package audit
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"agentic-template/api/requestctx"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GinMiddleware records mutating HTTP requests (any method but GET, HEAD,
+// and OPTIONS). Bodies aren't recorded; the summary holds the query
+// string. It must run after authentication to know the actor.
+func (r *Recorder) GinMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+
+		started := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = c.Request.URL.Path
+		}
+		params := make([]string, 0, len(c.Params))
+		for _, param := range c.Params {
+			params = append(params, param.Key+"="+param.Value)
+		}
+
+		ctx := c.Request.Context()
+		entry := Entry{
+			OccurredAt: started,
+			Actor:      requestctx.Actor(ctx),
+			Transport:  TransportHTTP,
+			Method:     c.Request.Method + " " + route,
+			Resource:   strings.Join(params, ","),
+			Status:     strconv.Itoa(c.Writer.Status()),
+			LatencyMS:  time.Since(started).Milliseconds(),
+			RequestID:  requestctx.RequestID(ctx),
+			ClientIP:   c.ClientIP(),
+		}
+		if c.Request.URL.RawQuery != "" {
+			entry.RequestSummary = queryJSON(c)
+		}
+		r.Record(entry)
+	}
+}
+
+// queryJSON renders the query string as JSON with sensitive parameters
+// redacted
+func queryJSON(c *gin.Context) string {
+	query := c.Request.URL.Query()
+	for key := range query {
+		if isSensitive(key) {
+			query[key] = []string{"[REDACTED]"}
+		}
+	}
+	data, err := json.Marshal(query)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
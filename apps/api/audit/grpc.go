@@ -0,0 +1,190 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"agentic-template/api/auth"
+	"agentic-template/api/logging"
+	"agentic-template/api/requestctx"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Request summary limits
+const (
+	maxSummaryValueLen = 256  // Longer string fields are truncated
+	maxSummaryLen      = 4096 // Longer summaries are replaced by a note
+)
+
+// mutatingMethods are the audited RPCs, keyed by auth.ServiceMethod so
+// they cover every API version. Agent runs are included because their
+// tools can change data.
+var mutatingMethods = map[string]bool{
+	"AgentService/StreamAgentResponse":         true,
+	"AgentService/Chat":                        true,
+	"AgentService/SubmitAgentJob":              true,
+	"AgentProfileService/CreateAgentProfile":   true,
+	"AgentProfileService/DeleteAgentProfile":   true,
+	"AgentProfileService/SetAgentProfileTools": true,
+	"AgentProfileService/SetToolEnabled":       true,
+	"SchemaService/CreateTable":                true,
+	"SchemaService/DeleteTable":                true,
+	"SchemaService/RestoreTable":               true,
+	"SchemaService/PurgeTable":                 true,
+	"SchemaService/ReloadDatabase":             true,
+	"SchemaService/PatchJSONValue":             true,
+	"SchemaService/CheckTableIntegrity":        true,
+	"SchemaService/ApproveSchemaChangeRequest": true,
+	"SchemaService/RejectSchemaChangeRequest":  true,
+	"SchemaService/ResetSchemaSandbox":         true,
+	"SchemaService/PromoteSchemaSandbox":       true,
+	"SchemaService/AnonymizeTable":             true,
+	"SchemaService/StartImport":                true,
+	"SchemaService/StopImportSync":             true,
+	"SchemaService/CreateWarehouseSync":        true,
+	"SchemaService/PauseWarehouseSync":         true,
+	"SchemaService/ResumeWarehouseSync":        true,
+	"SchemaService/DeleteWarehouseSync":        true,
+	"KnowledgeService/IngestDocument":          true,
+}
+
+// sensitiveFieldParts mark request fields whose values are never recorded
+var sensitiveFieldParts = []string{"password", "secret", "token", "api_key", "credential", "url"}
+
+// recordRPC records the outcome of an audited RPC
+func (r *Recorder) recordRPC(ctx context.Context, fullMethod string, req any, started time.Time, err error) {
+	entry := Entry{
+		OccurredAt: started,
+		Actor:      requestctx.Actor(ctx),
+		Transport:  TransportGRPC,
+		Method:     fullMethod,
+		Status:     status.Code(err).String(),
+		LatencyMS:  time.Since(started).Milliseconds(),
+		RequestID:  requestctx.RequestID(ctx),
+		ClientIP:   logging.ClientIP(ctx),
+	}
+	if msg, ok := req.(proto.Message); ok {
+		entry.Resource = resourceIDs(msg)
+		entry.RequestSummary = summarize(msg)
+	}
+	r.Record(entry)
+}
+
+// UnaryServerInterceptor records mutating unary calls. It must run after
+// authentication to know the actor, and before authorization and rate
+// limiting so rejected calls are recorded too.
+func (r *Recorder) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if !mutatingMethods[auth.ServiceMethod(info.FullMethod)] {
+			return handler(ctx, req)
+		}
+
+		started := time.Now()
+		resp, err := handler(ctx, req)
+		r.recordRPC(ctx, info.FullMethod, req, started, err)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor records mutating streams when they end, with
+// the first request message as the request
+func (r *Recorder) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if !mutatingMethods[auth.ServiceMethod(info.FullMethod)] {
+			return handler(srv, stream)
+		}
+
+		started := time.Now()
+		recording := &recordingStream{ServerStream: stream}
+		err := handler(srv, recording)
+		r.recordRPC(stream.Context(), info.FullMethod, recording.first, started, err)
+		return err
+	}
+}
+
+// recordingStream keeps the first message received on a stream
+type recordingStream struct {
+	grpc.ServerStream
+	first any
+}
+
+// RecvMsg receives a message, keeping it if it is the first
+func (s *recordingStream) RecvMsg(m any) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil && s.first == nil {
+		s.first = m
+	}
+	return err
+}
+
+// resourceIDs lists the non-zero top-level ID fields of a request, e.g.
+// "table_id=3"
+func resourceIDs(msg proto.Message) string {
+	var ids []string
+	msg.ProtoReflect().Range(func(field protoreflect.FieldDescriptor, value protoreflect.Value) bool {
+		name := string(field.Name())
+		if (name == "id" || strings.HasSuffix(name, "_id")) && !field.IsList() && !field.IsMap() &&
+			field.Kind() != protoreflect.MessageKind {
+			ids = append(ids, fmt.Sprintf("%s=%v", name, value.Interface()))
+		}
+		return true
+	})
+	return strings.Join(ids, ",")
+}
+
+// summarize renders a request as JSON with sensitive fields redacted and
+// long strings truncated
+func summarize(msg proto.Message) string {
+	clone := proto.Clone(msg)
+	redact(clone.ProtoReflect())
+
+	data, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(clone)
+	if err != nil {
+		return ""
+	}
+	if len(data) > maxSummaryLen {
+		return fmt.Sprintf(`{"truncated":true,"size":%d}`, len(data))
+	}
+	return string(data)
+}
+
+// redact blanks sensitive fields and truncates long strings, recursing
+// into nested messages
+func redact(msg protoreflect.Message) {
+	msg.Range(func(field protoreflect.FieldDescriptor, value protoreflect.Value) bool {
+		switch {
+		case field.Kind() == protoreflect.StringKind && !field.IsList() && !field.IsMap():
+			if isSensitive(string(field.Name())) {
+				msg.Set(field, protoreflect.ValueOfString("[REDACTED]"))
+			} else if s := value.String(); len(s) > maxSummaryValueLen {
+				msg.Set(field, protoreflect.ValueOfString(strings.ToValidUTF8(s[:maxSummaryValueLen], "")+"..."))
+			}
+		case field.Kind() == protoreflect.MessageKind && field.IsList():
+			list := value.List()
+			for i := 0; i < list.Len(); i++ {
+				redact(list.Get(i).Message())
+			}
+		case field.Kind() == protoreflect.MessageKind && !field.IsMap():
+			redact(value.Message())
+		}
+		return true
+	})
+}
+
+// isSensitive reports whether a field name suggests a secret
+func isSensitive(name string) bool {
+	name = strings.ToLower(name)
+	for _, part := range sensitiveFieldParts {
+		if strings.Contains(name, part) {
+			return true
+		}
+	}
+	return false
+}
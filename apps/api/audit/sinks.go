@@ -0,0 +1,291 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"agentic-template/api/awsauth"
+)
+
+// sinkTimeout bounds each network call of a sink
+const sinkTimeout = 20 * time.Second
+
+// Sink receives exported audit events. Write gets events of one source in
+// ID order and must fail unless it stored them all; events are retried
+// after a failure, so a sink may receive an event more than once.
+type Sink interface {
+	// Name identifies the sink's export cursors, so it must stay stable
+	// across restarts and hold no secrets
+	Name() string
+	Write(ctx context.Context, events []Event) error
+}
+
+// SinkOptions holds the credentials sinks may need
+type SinkOptions struct {
+	WebhookSecret string              // Signs webhook bodies; empty sends them unsigned
+	AWS           awsauth.Credentials // Used by S3 sinks
+	S3Endpoint    string              // Overrides the S3 endpoint, e.g. for MinIO or LocalStack
+}
+
+// ParseSink creates a sink from its URL:
+//
+//	file:///var/log/audit           NDJSON files, one per day
+//	s3://bucket/prefix              An NDJSON object per batch
+//	syslog://host:514               RFC 5424 over UDP; syslog+tcp:// for TCP
+//	https://example.com/audit       NDJSON webhook POSTs
+func ParseSink(spec string, opts SinkOptions) (Sink, error) {
+	u, err := url.Parse(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid audit sink %q: %w", spec, err)
+	}
+
+	switch u.Scheme {
+	case "file":
+		if u.Path == "" {
+			return nil, fmt.Errorf("file audit sink %q needs a directory", spec)
+		}
+		return &FileSink{Dir: u.Path}, nil
+	case "s3":
+		if u.Host == "" {
+			return nil, fmt.Errorf("s3 audit sink %q needs a bucket", spec)
+		}
+		if !opts.AWS.Complete() {
+			return nil, fmt.Errorf("s3 audit sink needs AWS_REGION, AWS_ACCESS_KEY_ID, and AWS_SECRET_ACCESS_KEY")
+		}
+		return &S3Sink{
+			Bucket:      u.Host,
+			Prefix:      strings.Trim(u.Path, "/"),
+			Endpoint:    opts.S3Endpoint,
+			Credentials: opts.AWS,
+			HTTPClient:  &http.Client{Timeout: sinkTimeout},
+		}, nil
+	case "syslog", "syslog+udp", "syslog+tcp":
+		if u.Port() == "" {
+			return nil, fmt.Errorf("syslog audit sink %q needs host:port", spec)
+		}
+		network := "udp"
+		if u.Scheme == "syslog+tcp" {
+			network = "tcp"
+		}
+		return &SyslogSink{Network: network, Address: u.Host, AppName: "agentic-template-api"}, nil
+	case "http", "https":
+		if u.Host == "" {
+			return nil, fmt.Errorf("webhook audit sink %q needs a host", spec)
+		}
+		return &WebhookSink{URL: spec, Secret: opts.WebhookSecret, HTTPClient: &http.Client{Timeout: sinkTimeout}}, nil
+	default:
+		return nil, fmt.Errorf("unsupported audit sink %q, use file://, s3://, syslog://, syslog+tcp://, or https://", spec)
+	}
+}
+
+// encodeNDJSON encodes events as newline-delimited JSON
+func encodeNDJSON(events []Event) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, event := range events {
+		if err := encoder.Encode(event); err != nil {
+			return nil, fmt.Errorf("failed to encode audit event %s/%d: %w", event.Source, event.ID, err)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// FileSink appends events as NDJSON to a file per UTC day in Dir
+type FileSink struct {
+	Dir string
+}
+
+// Name implements Sink
+func (s *FileSink) Name() string {
+	return "file://" + s.Dir
+}
+
+// Write implements Sink
+func (s *FileSink) Write(ctx context.Context, events []Event) error {
+	data, err := encodeNDJSON(events)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.Dir, 0o750); err != nil {
+		return fmt.Errorf("failed to create audit export directory: %w", err)
+	}
+
+	path := filepath.Join(s.Dir, "audit-"+time.Now().UTC().Format("2006-01-02")+".ndjson")
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o640)
+	if err != nil {
+		return fmt.Errorf("failed to open audit export file: %w", err)
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write audit export file: %w", err)
+	}
+	// Events count as exported once they're durable
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to sync audit export file: %w", err)
+	}
+	return file.Close()
+}
+
+// S3Sink uploads each batch as an NDJSON object named after the day and
+// the batch's first and last IDs, so a retried batch overwrites its object
+type S3Sink struct {
+	Bucket      string
+	Prefix      string
+	Endpoint    string // Path-style endpoint; empty uses the bucket's AWS endpoint
+	Credentials awsauth.Credentials
+	HTTPClient  *http.Client
+}
+
+// Name implements Sink
+func (s *S3Sink) Name() string {
+	return strings.TrimSuffix("s3://"+s.Bucket+"/"+s.Prefix, "/")
+}
+
+// Write implements Sink
+func (s *S3Sink) Write(ctx context.Context, events []Event) error {
+	data, err := encodeNDJSON(events)
+	if err != nil {
+		return err
+	}
+
+	first, last := events[0], events[len(events)-1]
+	key := fmt.Sprintf("%s/%s-%d-%d.ndjson", first.OccurredAt.UTC().Format("2006/01/02"), first.Source, first.ID, last.ID)
+	if s.Prefix != "" {
+		key = s.Prefix + "/" + key
+	}
+	objectURL := "https://" + s.Bucket + ".s3." + s.Credentials.Region + ".amazonaws.com/" + key
+	if s.Endpoint != "" {
+		objectURL = strings.TrimSuffix(s.Endpoint, "/") + "/" + s.Bucket + "/" + key
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, objectURL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	req.Header.Set("X-Amz-Content-Sha256", awsauth.SHA256Hex(data))
+	awsauth.Sign(req, data, "s3", s.Credentials, time.Now())
+
+	resp, err := s.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to upload audit events to S3: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("S3 returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// SyslogSink sends each event as an RFC 5424 message with the JSON event
+// as its body, under the log audit facility
+type SyslogSink struct {
+	Network string // udp or tcp
+	Address string
+	AppName string
+}
+
+// syslogPriority is the log audit facility (13) at informational severity
+const syslogPriority = 13*8 + 6
+
+// Name implements Sink
+func (s *SyslogSink) Name() string {
+	return "syslog+" + s.Network + "://" + s.Address
+}
+
+// Write implements Sink
+func (s *SyslogSink) Write(ctx context.Context, events []Event) error {
+	dialer := net.Dialer{Timeout: sinkTimeout}
+	conn, err := dialer.DialContext(ctx, s.Network, s.Address)
+	if err != nil {
+		return fmt.Errorf("failed to connect to syslog: %w", err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	hostname, _ := os.Hostname()
+	if hostname == "" {
+		hostname = "-"
+	}
+	for _, event := range events {
+		body, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("failed to encode audit event %s/%d: %w", event.Source, event.ID, err)
+		}
+		msgID := fmt.Sprintf("%s-%d", event.Source, event.ID)
+		message := fmt.Sprintf("<%d>1 %s %s %s - %s - %s", syslogPriority,
+			event.OccurredAt.UTC().Format(time.RFC3339Nano), hostname, s.AppName, msgID, body)
+		// TCP streams frame messages by octet counting (RFC 6587)
+		if s.Network == "tcp" {
+			message = fmt.Sprintf("%d %s", len(message), message)
+		}
+		if _, err := io.WriteString(conn, message); err != nil {
+			return fmt.Errorf("failed to send audit event to syslog: %w", err)
+		}
+	}
+	return nil
+}
+
+// WebhookSink POSTs each batch as NDJSON. With a secret, the body's
+// HMAC-SHA256 is sent hex-encoded as X-Audit-Signature: sha256=<hex>.
+type WebhookSink struct {
+	URL        string
+	Secret     string
+	HTTPClient *http.Client
+}
+
+// Name implements Sink, leaving out credentials in the URL
+func (s *WebhookSink) Name() string {
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		return "webhook"
+	}
+	return u.Scheme + "://" + u.Host + u.Path
+}
+
+// Write implements Sink
+func (s *WebhookSink) Write(ctx context.Context, events []Event) error {
+	data, err := encodeNDJSON(events)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	if s.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(s.Secret))
+		mac.Write(data)
+		req.Header.Set("X-Audit-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := s.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send audit events to webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("webhook returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+package audit
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Page sizes for List
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 500
+)
+
+// Errors returned by the store
+var (
+	ErrDatabaseNotConfigured = errors.New("database not configured - please add DATABASE_URL_POOLED in Environment Settings")
+	ErrInvalidPageToken      = errors.New("invalid page token")
+)
+
+// Filter selects audit entries; empty fields match everything
+type Filter struct {
+	Actor     string
+	Method    string
+	Since     time.Time // Inclusive
+	Until     time.Time // Exclusive
+	PageSize  int       // Defaults to DefaultPageSize, max MaxPageSize
+	PageToken string    // NextPageToken of the previous page
+}
+
+// Page is one page of audit entries, newest first
+type Page struct {
+	Entries       []Entry `json:"entries"`
+	NextPageToken string  `json:"next_page_token,omitempty"` // Empty on the last page
+}
+
+// Store reads and writes the audit log
+type Store struct {
+	pool *pgxpool.Pool
+}
+
+// NewStore creates a new audit store
+func NewStore(pool *pgxpool.Pool) *Store {
+	return &Store{
+		pool: pool,
+	}
+}
+
+// Insert writes entries in one batch
+func (s *Store) Insert(ctx context.Context, entries []Entry) error {
+	if s.pool == nil {
+		return ErrDatabaseNotConfigured
+	}
+
+	query := `
+		INSERT INTO api_audit_log (occurred_at, actor, transport, method, resource, request_summary,
+			status, latency_ms, request_id, client_ip)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+	batch := &pgx.Batch{}
+	for _, entry := range entries {
+		var summary *string
+		if entry.RequestSummary != "" {
+			summary = &entry.RequestSummary
+		}
+		batch.Queue(query,
+			entry.OccurredAt,
+			entry.Actor,
+			entry.Transport,
+			entry.Method,
+			entry.Resource,
+			summary,
+			entry.Status,
+			entry.LatencyMS,
+			entry.RequestID,
+			entry.ClientIP,
+		)
+	}
+	return s.pool.SendBatch(ctx, batch).Close()
+}
+
+// List returns a page of entries matching the filter, newest first
+func (s *Store) List(ctx context.Context, filter Filter) (*Page, error) {
+	if s.pool == nil {
+		return nil, ErrDatabaseNotConfigured
+	}
+
+	pageSize := filter.PageSize
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	if pageSize > MaxPageSize {
+		pageSize = MaxPageSize
+	}
+
+	var conditions []string
+	var args []any
+	addCondition := func(condition string, value any) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if filter.PageToken != "" {
+		afterID, err := decodePageToken(filter.PageToken)
+		if err != nil {
+			return nil, err
+		}
+		addCondition("id < $%d", afterID)
+	}
+	if filter.Actor != "" {
+		addCondition("actor = $%d", filter.Actor)
+	}
+	if filter.Method != "" {
+		addCondition("method = $%d", filter.Method)
+	}
+	if !filter.Since.IsZero() {
+		addCondition("occurred_at >= $%d", filter.Since)
+	}
+	if !filter.Until.IsZero() {
+		addCondition("occurred_at < $%d", filter.Until)
+	}
+
+	query := `
+		SELECT id, occurred_at, actor, transport, method, resource, COALESCE(request_summary::TEXT, ''),
+			status, latency_ms, request_id, client_ip
+		FROM api_audit_log`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	// Fetch one extra row to learn whether another page follows
+	args = append(args, pageSize+1)
+	query += fmt.Sprintf(" ORDER BY id DESC LIMIT $%d", len(args))
+
+	rows, err := s.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit log: %w", err)
+	}
+	defer rows.Close()
+
+	page := &Page{Entries: []Entry{}}
+	for rows.Next() {
+		var entry Entry
+		err := rows.Scan(
+			&entry.ID,
+			&entry.OccurredAt,
+			&entry.Actor,
+			&entry.Transport,
+			&entry.Method,
+			&entry.Resource,
+			&entry.RequestSummary,
+			&entry.Status,
+			&entry.LatencyMS,
+			&entry.RequestID,
+			&entry.ClientIP,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan audit entry: %w", err)
+		}
+		page.Entries = append(page.Entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+
+	if len(page.Entries) > pageSize {
+		page.Entries = page.Entries[:pageSize]
+		page.NextPageToken = encodePageToken(page.Entries[pageSize-1].ID)
+	}
+	return page, nil
+}
+
+// Purge deletes entries that occurred before a time and returns how many
+// were deleted
+func (s *Store) Purge(ctx context.Context, before time.Time) (int64, error) {
+	if s.pool == nil {
+		return 0, ErrDatabaseNotConfigured
+	}
+
+	tag, err := s.pool.Exec(ctx, `DELETE FROM api_audit_log WHERE occurred_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge audit log: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// encodePageToken encodes the ID of the last entry of a page
+func encodePageToken(id int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(id, 10)))
+}
+
+// decodePageToken decodes a page token from encodePageToken
+func decodePageToken(token string) (int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, ErrInvalidPageToken
+	}
+	id, err := strconv.ParseInt(string(raw), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, ErrInvalidPageToken
+	}
+	return id, nil
+}
@@ -0,0 +1,143 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+
+	"agentic-template/api/requestctx"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrUnauthenticated is returned when credentials are missing or invalid
+var ErrUnauthenticated = errors.New("unauthenticated")
+
+// Principal types
+const (
+	PrincipalAPIKey = "api_key"
+	PrincipalUser   = "user"
+)
+
+// Principal is the authenticated caller of a request, attached to its
+// context with requestctx.WithPrincipal
+type Principal = requestctx.Principal
+
+// Config configures the accepted credentials
+type Config struct {
+	APIKeys       map[string]string   // API key -> principal ID
+	APIKeyRoles   map[string][]string // Principal ID -> roles of its API key
+	APIKeyTenants map[string]string   // Principal ID -> tenant of its API key
+	JWTSecret     string              // HS256 signing secret; empty disables JWTs
+	JWTIssuer     string              // Required issuer, if set
+	Optional      bool                // Let requests without credentials through anonymously
+}
+
+// Claims are the JWT claims the authenticator reads
+type Claims struct {
+	jwt.RegisteredClaims
+	Roles  []string `json:"roles,omitempty"`
+	Tenant string   `json:"tenant,omitempty"`
+}
+
+// Authenticator validates API keys and JWTs
+type Authenticator struct {
+	apiKeys       map[string]string
+	apiKeyRoles   map[string][]string
+	apiKeyTenants map[string]string
+	jwtSecret     []byte
+	jwtIssuer     string
+	optional      bool
+}
+
+// New creates an authenticator
+func New(cfg Config) *Authenticator {
+	a := &Authenticator{
+		apiKeys:       make(map[string]string, len(cfg.APIKeys)),
+		apiKeyRoles:   make(map[string][]string, len(cfg.APIKeyRoles)),
+		apiKeyTenants: make(map[string]string, len(cfg.APIKeyTenants)),
+		jwtIssuer:     cfg.JWTIssuer,
+		optional:      cfg.Optional,
+	}
+	for key, id := range cfg.APIKeys {
+		a.apiKeys[key] = id
+	}
+	for id, roles := range cfg.APIKeyRoles {
+		a.apiKeyRoles[id] = append([]string(nil), roles...)
+	}
+	for id, tenant := range cfg.APIKeyTenants {
+		a.apiKeyTenants[id] = tenant
+	}
+	if cfg.JWTSecret != "" {
+		a.jwtSecret = []byte(cfg.JWTSecret)
+	}
+	return a
+}
+
+// Configured reports whether any credential is accepted
+func (a *Authenticator) Configured() bool {
+	return len(a.apiKeys) > 0 || a.jwtSecret != nil
+}
+
+// AuthenticateAPIKey returns the principal of an API key
+func (a *Authenticator) AuthenticateAPIKey(key string) (*Principal, error) {
+	if key == "" {
+		return nil, ErrUnauthenticated
+	}
+
+	// Compare every key in constant time so timing doesn't reveal prefixes
+	var principalID string
+	for candidate, id := range a.apiKeys {
+		if subtle.ConstantTimeCompare([]byte(candidate), []byte(key)) == 1 {
+			principalID = id
+		}
+	}
+	if principalID == "" {
+		return nil, fmt.Errorf("%w: invalid API key", ErrUnauthenticated)
+	}
+
+	return &Principal{
+		ID:     principalID,
+		Type:   PrincipalAPIKey,
+		Roles:  a.apiKeyRoles[principalID],
+		Tenant: a.apiKeyTenants[principalID],
+	}, nil
+}
+
+// AuthenticateJWT validates an HS256 JWT and returns its subject
+func (a *Authenticator) AuthenticateJWT(token string) (*Principal, error) {
+	if a.jwtSecret == nil {
+		return nil, fmt.Errorf("%w: JWT authentication is not configured", ErrUnauthenticated)
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithExpirationRequired(),
+	}
+	if a.jwtIssuer != "" {
+		opts = append(opts, jwt.WithIssuer(a.jwtIssuer))
+	}
+
+	var claims Claims
+	_, err := jwt.ParseWithClaims(token, &claims, func(*jwt.Token) (any, error) {
+		return a.jwtSecret, nil
+	}, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid token: %v", ErrUnauthenticated, err)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: token has no subject", ErrUnauthenticated)
+	}
+
+	return &Principal{ID: claims.Subject, Type: PrincipalUser, Roles: claims.Roles, Tenant: claims.Tenant}, nil
+}
+
+// AuthenticateBearer validates a bearer credential, which may be a JWT or
+// an API key
+func (a *Authenticator) AuthenticateBearer(token string) (*Principal, error) {
+	if strings.Count(token, ".") == 2 && a.jwtSecret != nil {
+		return a.AuthenticateJWT(token)
+	}
+	return a.AuthenticateAPIKey(token)
+}
@@ -0,0 +1,237 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"agentic-template/api/requestctx"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Role grants access to a set of methods. Each role includes the access
+// of the roles below it: admin > editor > viewer.
+type Role string
+
+// Roles
+const (
+	RoleViewer Role = "viewer" // Reads metadata, records, runs, and jobs
+	RoleEditor Role = "editor" // Runs agents, ingests documents, and manages profiles
+	RoleAdmin  Role = "admin"  // Changes schema and server-wide settings
+)
+
+// roleRanks orders the roles; unknown roles rank zero and grant nothing
+var roleRanks = map[Role]int{
+	RoleViewer: 1,
+	RoleEditor: 2,
+	RoleAdmin:  3,
+}
+
+// ParseRole validates a role name
+func ParseRole(name string) (Role, error) {
+	role := Role(name)
+	if _, ok := roleRanks[role]; !ok {
+		return "", fmt.Errorf("unknown role %q (want admin, editor, or viewer)", name)
+	}
+	return role, nil
+}
+
+// includes reports whether the role grants the access of another role
+func (r Role) includes(required Role) bool {
+	return roleRanks[r] > 0 && roleRanks[r] >= roleRanks[required]
+}
+
+// methodRoles is the minimum role of each RPC, keyed by ServiceMethod so it
+// covers every API version. RPCs missing from the map require admin, so new
+// RPCs are closed until they are classified.
+var methodRoles = map[string]Role{
+	"AgentService/StreamAgentResponse": RoleEditor,
+	"AgentService/Chat":                RoleEditor,
+	"AgentService/SubmitAgentJob":      RoleEditor,
+	"AgentService/GetAgentJob":         RoleViewer,
+
+	"AgentProfileService/CreateAgentProfile":   RoleEditor,
+	"AgentProfileService/GetAgentProfile":      RoleViewer,
+	"AgentProfileService/ListAgentProfiles":    RoleViewer,
+	"AgentProfileService/DeleteAgentProfile":   RoleEditor,
+	"AgentProfileService/SetAgentProfileTools": RoleEditor,
+	"AgentProfileService/ListTools":            RoleViewer,
+	"AgentProfileService/SetToolEnabled":       RoleAdmin,
+
+	"AgentRunService/GetAgentRun":   RoleViewer,
+	"AgentRunService/ListAgentRuns": RoleViewer,
+	"AgentRunService/ListSessions":  RoleViewer,
+
+	"SchemaService/CreateTable":         RoleAdmin,
+	"SchemaService/GetTable":            RoleViewer,
+	"SchemaService/ListTables":          RoleViewer,
+	"SchemaService/SearchSchema":        RoleViewer,
+	"SchemaService/GetDataTypes":        RoleViewer,
+	"SchemaService/DeleteTable":         RoleAdmin,
+	"SchemaService/ListTrash":           RoleAdmin,
+	"SchemaService/RestoreTable":        RoleAdmin,
+	"SchemaService/PurgeTable":          RoleAdmin,
+	"SchemaService/ReloadDatabase":      RoleAdmin,
+	"SchemaService/ListSchemaChanges":   RoleAdmin,
+	"SchemaService/GetTableAnalytics":   RoleViewer,
+	"SchemaService/PatchJSONValue":      RoleEditor,
+	"SchemaService/StreamJSONValue":     RoleViewer,
+	"SchemaService/CheckTableIntegrity": RoleEditor,
+
+	"SchemaService/ListSchemaChangeRequests":   RoleAdmin,
+	"SchemaService/GetSchemaChangeRequest":     RoleEditor,
+	"SchemaService/ApproveSchemaChangeRequest": RoleAdmin,
+	"SchemaService/RejectSchemaChangeRequest":  RoleAdmin,
+	"SchemaService/ResetSchemaSandbox":         RoleAdmin,
+	"SchemaService/DiffSchemaSandbox":          RoleAdmin,
+	"SchemaService/PromoteSchemaSandbox":       RoleAdmin,
+	"SchemaService/AnonymizeTable":             RoleAdmin,
+	"SchemaService/GetAnonymizationJob":        RoleAdmin,
+	"SchemaService/ListImportSources":          RoleAdmin,
+	"SchemaService/PreviewImport":              RoleAdmin,
+	"SchemaService/StartImport":                RoleAdmin,
+	"SchemaService/StopImportSync":             RoleAdmin,
+	"SchemaService/GetImport":                  RoleAdmin,
+	"SchemaService/ListImports":                RoleAdmin,
+	"SchemaService/ListSyncDestinations":       RoleAdmin,
+	"SchemaService/CreateWarehouseSync":        RoleAdmin,
+	"SchemaService/GetWarehouseSync":           RoleAdmin,
+	"SchemaService/ListWarehouseSyncs":         RoleAdmin,
+	"SchemaService/PauseWarehouseSync":         RoleAdmin,
+	"SchemaService/ResumeWarehouseSync":        RoleAdmin,
+	"SchemaService/DeleteWarehouseSync":        RoleAdmin,
+
+	"KnowledgeService/IngestDocument":  RoleEditor,
+	"KnowledgeService/GetIngestionJob": RoleViewer,
+	"KnowledgeService/SemanticSearch":  RoleViewer,
+
+	"AuditService/ListAuditEntries": RoleAdmin,
+}
+
+// changeRequestRoles lower the role of admin RPCs whose calls by
+// non-admins become schema change requests, when the approval workflow is
+// on
+var changeRequestRoles = map[string]Role{
+	"SchemaService/CreateTable": RoleEditor,
+	"SchemaService/DeleteTable": RoleEditor,
+}
+
+// ServiceMethod strips the proto package from a full RPC method name, so
+// "/proto.v2.SchemaService/CreateTable" becomes "SchemaService/CreateTable"
+func ServiceMethod(fullMethod string) string {
+	service, method, found := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	if !found {
+		return fullMethod
+	}
+	if i := strings.LastIndex(service, "."); i >= 0 {
+		service = service[i+1:]
+	}
+	return service + "/" + method
+}
+
+// Policy authorizes principals by role
+type Policy struct {
+	defaultRole    Role // Role of principals without any known role
+	changeRequests bool // Editors may request schema changes for approval
+}
+
+// NewPolicy creates a policy giving principals without roles the default
+// role. An empty default role grants them nothing.
+func NewPolicy(defaultRole Role) *Policy {
+	return &Policy{defaultRole: defaultRole}
+}
+
+// AllowChangeRequests lets editors call the schema RPCs whose calls become
+// change requests an admin reviews (see schema_manager.NeedsApproval)
+func (p *Policy) AllowChangeRequests() {
+	p.changeRequests = true
+}
+
+// roleOf returns the highest known role of a principal
+func (p *Policy) roleOf(principal *Principal) Role {
+	var best Role
+	for _, name := range principal.Roles {
+		if role := Role(name); roleRanks[role] > roleRanks[best] {
+			best = role
+		}
+	}
+	if best == "" {
+		return p.defaultRole
+	}
+	return best
+}
+
+// Allows reports whether a principal has the required role
+func (p *Policy) Allows(principal *Principal, required Role) bool {
+	return p.roleOf(principal).includes(required)
+}
+
+// methodRole returns the minimum role of an RPC, keyed by ServiceMethod,
+// lowered for change requests when the approval workflow is on
+func (p *Policy) methodRole(method string) Role {
+	required, ok := methodRoles[method]
+	if !ok {
+		required = RoleAdmin
+	}
+	if role, ok := changeRequestRoles[method]; ok && p.changeRequests {
+		required = role
+	}
+	return required
+}
+
+// authorizeRPC checks the principal of a call against the method's role and
+// returns the context carrying its resolved role, for the column
+// permissions of the record layer. Calls without a principal (public
+// methods, or authentication disabled) are not restricted.
+func (p *Policy) authorizeRPC(ctx context.Context, fullMethod string) (context.Context, error) {
+	principal, ok := requestctx.PrincipalFrom(ctx)
+	if !ok {
+		return ctx, nil
+	}
+
+	required := p.methodRole(ServiceMethod(fullMethod))
+	if p.Allows(principal, required) {
+		return requestctx.WithRole(ctx, string(p.roleOf(principal))), nil
+	}
+
+	st := status.New(codes.PermissionDenied, fmt.Sprintf("%s requires the %s role", fullMethod, required))
+	if detailed, err := st.WithDetails(&errdetails.ErrorInfo{
+		Reason: "INSUFFICIENT_ROLE",
+		Domain: "agentic-template",
+		Metadata: map[string]string{
+			"required_role": string(required),
+			"role":          string(p.roleOf(principal)),
+		},
+	}); err == nil {
+		st = detailed
+	}
+	return ctx, st.Err()
+}
+
+// UnaryServerInterceptor rejects unary calls the principal's role does not
+// allow. It must run after authentication.
+func (p *Policy) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := p.authorizeRPC(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor rejects streams the principal's role does not
+// allow. It must run after authentication.
+func (p *Policy) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := p.authorizeRPC(stream.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &contextStream{ServerStream: stream, ctx: ctx})
+	}
+}
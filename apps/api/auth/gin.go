@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+
+	"agentic-template/api/requestctx"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GinMiddleware rejects HTTP requests without valid credentials (or, when
+// optional, with invalid ones) and attaches the principal to the request
+// context. It reads the same "Authorization" and "X-API-Key" headers as the
+// gRPC interceptors.
+func (a *Authenticator) GinMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, err := a.authenticateHeaders(c.GetHeader("Authorization"), c.GetHeader("X-API-Key"))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+
+		if principal != nil {
+			c.Request = c.Request.WithContext(requestctx.WithPrincipal(c.Request.Context(), principal))
+		}
+		c.Next()
+	}
+}
+
+// Require returns middleware rejecting HTTP requests whose principal lacks
+// the role and attaching the principal's resolved role to allowed ones.
+// Requests without a principal (authentication disabled) pass.
+func (p *Policy) Require(role Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p.require(c, role)
+	}
+}
+
+// RequireMethod returns middleware like Require for routes mirroring an
+// RPC, keyed by ServiceMethod, requiring the role the RPC does. Editors
+// pass the schema routes whose calls become change requests when the
+// approval workflow is on.
+func (p *Policy) RequireMethod(method string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p.require(c, p.methodRole(method))
+	}
+}
+
+// require rejects a request whose principal lacks the role, or attaches
+// the principal's resolved role and continues
+func (p *Policy) require(c *gin.Context, role Role) {
+	principal, ok := requestctx.PrincipalFrom(c.Request.Context())
+	if !ok {
+		c.Next()
+		return
+	}
+	if !p.Allows(principal, role) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"error": fmt.Sprintf("%s %s requires the %s role", c.Request.Method, c.FullPath(), role),
+		})
+		return
+	}
+	c.Request = c.Request.WithContext(requestctx.WithRole(c.Request.Context(), string(p.roleOf(principal))))
+	c.Next()
+}
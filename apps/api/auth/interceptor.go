@@ -0,0 +1,117 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"agentic-template/api/requestctx"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// publicMethodPrefixes are RPCs callable without credentials
+var publicMethodPrefixes = []string{
+	"/grpc.reflection.",
+	"/grpc.health.v1.",
+}
+
+// isPublic reports whether a full RPC method name needs no credentials
+func isPublic(fullMethod string) bool {
+	for _, prefix := range publicMethodPrefixes {
+		if strings.HasPrefix(fullMethod, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// authenticateContext reads the credentials from the request metadata and
+// returns a context carrying the principal. The "authorization" header
+// takes a bearer JWT or API key; "x-api-key" takes an API key.
+func (a *Authenticator) authenticateContext(ctx context.Context) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	var authorization, apiKey string
+	if values := md.Get("authorization"); len(values) > 0 {
+		authorization = values[0]
+	}
+	if values := md.Get("x-api-key"); len(values) > 0 {
+		apiKey = values[0]
+	}
+
+	principal, err := a.authenticateHeaders(authorization, apiKey)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	if principal == nil {
+		return ctx, nil
+	}
+	return requestctx.WithPrincipal(ctx, principal), nil
+}
+
+// authenticateHeaders authenticates the credentials of an "authorization"
+// or "x-api-key" header, shared by gRPC metadata and HTTP requests. An
+// optional authenticator returns no principal when neither is sent.
+func (a *Authenticator) authenticateHeaders(authorization, apiKey string) (*Principal, error) {
+	switch {
+	case authorization != "":
+		token, found := strings.CutPrefix(authorization, "Bearer ")
+		if !found {
+			return nil, errors.New("authorization must use the Bearer scheme")
+		}
+		return a.AuthenticateBearer(strings.TrimSpace(token))
+	case apiKey != "":
+		return a.AuthenticateAPIKey(apiKey)
+	case a.optional:
+		return nil, nil
+	default:
+		return nil, errors.New("missing credentials: send an API key or bearer token")
+	}
+}
+
+// UnaryServerInterceptor rejects unary calls without valid credentials and
+// attaches the principal to the handler's context
+func (a *Authenticator) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if isPublic(info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		ctx, err := a.authenticateContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor rejects streams without valid credentials and
+// attaches the principal to the stream's context
+func (a *Authenticator) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if isPublic(info.FullMethod) {
+			return handler(srv, stream)
+		}
+
+		ctx, err := a.authenticateContext(stream.Context())
+		if err != nil {
+			return err
+		}
+		return handler(srv, &contextStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+// contextStream overrides the context of a server stream
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns the stream's context with the principal attached
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,48 @@
+package auth
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// TokenIssuer signs HS256 access tokens that an Authenticator with the
+// same secret and issuer accepts
+type TokenIssuer struct {
+	secret []byte
+	issuer string
+	ttl    time.Duration
+}
+
+// NewTokenIssuer creates a token issuer whose tokens expire after ttl
+func NewTokenIssuer(secret, issuer string, ttl time.Duration) *TokenIssuer {
+	return &TokenIssuer{
+		secret: []byte(secret),
+		issuer: issuer,
+		ttl:    ttl,
+	}
+}
+
+// Issue signs an access token for a subject and its roles
+func (i *TokenIssuer) Issue(subject string, roles []string) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(i.ttl)
+
+	claims := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Subject:   subject,
+			Issuer:    i.issuer,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+		Roles: roles,
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(i.secret)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to sign access token: %w", err)
+	}
+	return token, expiresAt, nil
+}
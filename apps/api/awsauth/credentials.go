@@ -0,0 +1,322 @@
+package awsauth
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// containerCredentialsHost serves the credentials of ECS task roles
+	// under AWS_CONTAINER_CREDENTIALS_RELATIVE_URI
+	containerCredentialsHost = "http://169.254.170.2"
+
+	// defaultIMDSEndpoint is the EC2 instance metadata service
+	defaultIMDSEndpoint = "http://169.254.169.254"
+
+	// imdsTokenTimeout bounds the first metadata call, so hosts outside
+	// EC2 fail fast
+	imdsTokenTimeout = time.Second
+
+	// credentialsRefreshWindow renews temporary credentials this long
+	// before they expire
+	credentialsRefreshWindow = 5 * time.Minute
+
+	// defaultRoleSessionName names web identity sessions when
+	// AWS_ROLE_SESSION_NAME is unset
+	defaultRoleSessionName = "agentic-template-api"
+)
+
+// errNoCredentials is returned when no source in the chain is configured
+var errNoCredentials = errors.New("no AWS credentials: set AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY, or run with a web identity, container, or instance role")
+
+// Provider resolves credentials the way the AWS SDKs' default chain does,
+// trying in order:
+//
+//  1. AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY, with AWS_SESSION_TOKEN
+//  2. A web identity token exchanged with STS, from
+//     AWS_WEB_IDENTITY_TOKEN_FILE and AWS_ROLE_ARN (EKS IRSA)
+//  3. The container credentials endpoint, from
+//     AWS_CONTAINER_CREDENTIALS_RELATIVE_URI or _FULL_URI (ECS task
+//     roles, EKS Pod Identity)
+//  4. The EC2 instance profile through IMDSv2, unless
+//     AWS_EC2_METADATA_DISABLED is true
+//
+// Temporary credentials are cached until shortly before they expire.
+type Provider struct {
+	Region     string
+	HTTPClient *http.Client
+
+	static             Credentials // Keys from the environment
+	webIdentityFile    string
+	roleARN            string
+	roleSessionName    string
+	stsEndpoint        string // Overrides the regional STS endpoint
+	containerURL       string
+	containerToken     string // Authorization header of container requests
+	containerTokenFile string // Read for each request, as it is rotated
+	imdsEndpoint       string // Empty when the metadata service is disabled
+
+	mu      sync.Mutex
+	cached  Credentials
+	expires time.Time
+}
+
+// NewProvider creates a provider configured from the standard AWS
+// environment variables
+func NewProvider(client *http.Client) *Provider {
+	static := FromEnv()
+	p := &Provider{
+		Region:             static.Region,
+		HTTPClient:         client,
+		static:             static,
+		webIdentityFile:    os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE"),
+		roleARN:            os.Getenv("AWS_ROLE_ARN"),
+		roleSessionName:    os.Getenv("AWS_ROLE_SESSION_NAME"),
+		stsEndpoint:        os.Getenv("AWS_ENDPOINT_URL_STS"),
+		containerToken:     os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN"),
+		containerTokenFile: os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE"),
+	}
+	if uri := os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"); uri != "" {
+		p.containerURL = containerCredentialsHost + uri
+	} else {
+		p.containerURL = os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI")
+	}
+	if !strings.EqualFold(os.Getenv("AWS_EC2_METADATA_DISABLED"), "true") {
+		p.imdsEndpoint = defaultIMDSEndpoint
+		if endpoint := os.Getenv("AWS_EC2_METADATA_SERVICE_ENDPOINT"); endpoint != "" {
+			p.imdsEndpoint = strings.TrimSuffix(endpoint, "/")
+		}
+	}
+	return p
+}
+
+// Retrieve returns credentials for the provider's region from the first
+// configured source of the chain
+func (p *Provider) Retrieve(ctx context.Context) (Credentials, error) {
+	if p.static.AccessKeyID != "" && p.static.SecretAccessKey != "" {
+		creds := p.static
+		creds.Region = p.Region
+		return creds, nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cached.AccessKeyID != "" && time.Now().Before(p.expires) {
+		return p.cached, nil
+	}
+
+	var creds temporaryCredentials
+	var err error
+	switch {
+	case p.webIdentityFile != "" && p.roleARN != "":
+		creds, err = p.assumeRoleWithWebIdentity(ctx)
+	case p.containerURL != "":
+		creds, err = p.containerCredentials(ctx)
+	case p.imdsEndpoint != "":
+		creds, err = p.instanceCredentials(ctx)
+	default:
+		return Credentials{}, errNoCredentials
+	}
+	if err != nil {
+		return Credentials{}, err
+	}
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return Credentials{}, fmt.Errorf("AWS credentials response holds no keys")
+	}
+
+	p.cached = Credentials{
+		Region:          p.Region,
+		AccessKeyID:     creds.AccessKeyID,
+		SecretAccessKey: creds.SecretAccessKey,
+		SessionToken:    creds.SessionToken,
+	}
+	p.expires = creds.Expiration.Add(-credentialsRefreshWindow)
+	return p.cached, nil
+}
+
+// temporaryCredentials are the keys STS and the metadata endpoints return
+type temporaryCredentials struct {
+	AccessKeyID     string    `json:"AccessKeyId" xml:"AccessKeyId"`
+	SecretAccessKey string    `json:"SecretAccessKey" xml:"SecretAccessKey"`
+	SessionToken    string    `json:"Token" xml:"SessionToken"`
+	Expiration      time.Time `json:"Expiration" xml:"Expiration"`
+}
+
+// assumeRoleWithWebIdentity exchanges the web identity token for the
+// role's credentials. The call is authenticated by the token, not signed.
+func (p *Provider) assumeRoleWithWebIdentity(ctx context.Context) (temporaryCredentials, error) {
+	token, err := os.ReadFile(p.webIdentityFile)
+	if err != nil {
+		return temporaryCredentials{}, fmt.Errorf("failed to read web identity token: %w", err)
+	}
+	sessionName := p.roleSessionName
+	if sessionName == "" {
+		sessionName = defaultRoleSessionName
+	}
+	endpoint := p.stsEndpoint
+	switch {
+	case endpoint != "":
+	case p.Region != "":
+		endpoint = "https://sts." + p.Region + ".amazonaws.com"
+	default:
+		endpoint = "https://sts.amazonaws.com"
+	}
+
+	form := url.Values{
+		"Action":           {"AssumeRoleWithWebIdentity"},
+		"Version":          {"2011-06-15"},
+		"RoleArn":          {p.roleARN},
+		"RoleSessionName":  {sessionName},
+		"WebIdentityToken": {strings.TrimSpace(string(token))},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(endpoint, "/")+"/", strings.NewReader(form.Encode()))
+	if err != nil {
+		return temporaryCredentials{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	body, err := p.do(req)
+	if err != nil {
+		return temporaryCredentials{}, fmt.Errorf("failed to assume role %s with web identity: %w", p.roleARN, err)
+	}
+	var result struct {
+		Credentials temporaryCredentials `xml:"AssumeRoleWithWebIdentityResult>Credentials"`
+	}
+	if err := xml.Unmarshal(body, &result); err != nil {
+		return temporaryCredentials{}, fmt.Errorf("failed to decode STS response: %w", err)
+	}
+	return result.Credentials, nil
+}
+
+// containerCredentials fetches the task or pod role's credentials from the
+// container credentials endpoint
+func (p *Provider) containerCredentials(ctx context.Context) (temporaryCredentials, error) {
+	if err := checkContainerURL(p.containerURL); err != nil {
+		return temporaryCredentials{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.containerURL, nil)
+	if err != nil {
+		return temporaryCredentials{}, err
+	}
+	token := p.containerToken
+	if p.containerTokenFile != "" {
+		data, err := os.ReadFile(p.containerTokenFile)
+		if err != nil {
+			return temporaryCredentials{}, fmt.Errorf("failed to read container authorization token: %w", err)
+		}
+		token = strings.TrimSpace(string(data))
+	}
+	if token != "" {
+		req.Header.Set("Authorization", token)
+	}
+
+	body, err := p.do(req)
+	if err != nil {
+		return temporaryCredentials{}, fmt.Errorf("failed to get container credentials: %w", err)
+	}
+	var creds temporaryCredentials
+	if err := json.Unmarshal(body, &creds); err != nil {
+		return temporaryCredentials{}, fmt.Errorf("failed to decode container credentials: %w", err)
+	}
+	return creds, nil
+}
+
+// checkContainerURL allows plain HTTP only to loopback and the ECS and EKS
+// credential addresses, as the SDKs do, so credentials aren't requested
+// from an arbitrary host in the clear
+func checkContainerURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid container credentials URL: %w", err)
+	}
+	switch u.Scheme {
+	case "https":
+		return nil
+	case "http":
+		host := u.Hostname()
+		if host == "localhost" || host == "169.254.170.2" || host == "169.254.170.23" || host == "fd00:ec2::23" {
+			return nil
+		}
+		if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+			return nil
+		}
+		return fmt.Errorf("container credentials URL %s must use https or a loopback or container host", rawURL)
+	default:
+		return fmt.Errorf("container credentials URL %s must use http or https", rawURL)
+	}
+}
+
+// instanceCredentials fetches the instance profile's credentials from the
+// metadata service, with an IMDSv2 session token
+func (p *Provider) instanceCredentials(ctx context.Context) (temporaryCredentials, error) {
+	tokenCtx, cancel := context.WithTimeout(ctx, imdsTokenTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(tokenCtx, http.MethodPut, p.imdsEndpoint+"/latest/api/token", nil)
+	if err != nil {
+		return temporaryCredentials{}, err
+	}
+	req.Header.Set("X-Aws-Ec2-Metadata-Token-Ttl-Seconds", "21600")
+	token, err := p.do(req)
+	if err != nil {
+		return temporaryCredentials{}, fmt.Errorf("%w (instance metadata: %v)", errNoCredentials, err)
+	}
+
+	get := func(path string) ([]byte, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.imdsEndpoint+path, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("X-Aws-Ec2-Metadata-Token", string(token))
+		return p.do(req)
+	}
+	const rolesPath = "/latest/meta-data/iam/security-credentials/"
+	roles, err := get(rolesPath)
+	if err != nil {
+		return temporaryCredentials{}, fmt.Errorf("failed to find the instance profile role: %w", err)
+	}
+	role, _, _ := strings.Cut(strings.TrimSpace(string(roles)), "\n")
+	if role == "" {
+		return temporaryCredentials{}, fmt.Errorf("the instance has no profile role")
+	}
+	body, err := get(rolesPath + role)
+	if err != nil {
+		return temporaryCredentials{}, fmt.Errorf("failed to get instance profile credentials: %w", err)
+	}
+	var creds temporaryCredentials
+	if err := json.Unmarshal(body, &creds); err != nil {
+		return temporaryCredentials{}, fmt.Errorf("failed to decode instance profile credentials: %w", err)
+	}
+	return creds, nil
+}
+
+// do sends a request and returns the body of a successful response
+func (p *Provider) do(req *http.Request) ([]byte, error) {
+	client := p.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %s: %s", req.URL.Host, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
@@ -0,0 +1,220 @@
+package awsauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// writeFile writes a token file in a test directory and returns its path
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// expiration formats a time the way STS and the metadata endpoints do
+func expiration(d time.Duration) string {
+	return time.Now().Add(d).UTC().Format(time.RFC3339)
+}
+
+func TestProviderStaticKeys(t *testing.T) {
+	p := &Provider{
+		Region:       "eu-west-1",
+		static:       Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret", SessionToken: "token"},
+		imdsEndpoint: "http://127.0.0.1:1", // Never reached
+	}
+	creds, err := p.Retrieve(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Credentials{Region: "eu-west-1", AccessKeyID: "AKID", SecretAccessKey: "secret", SessionToken: "token"}
+	if creds != want {
+		t.Errorf("Retrieve() = %+v, want %+v", creds, want)
+	}
+}
+
+func TestProviderWebIdentity(t *testing.T) {
+	var calls atomic.Int32
+	sts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if err := r.ParseForm(); err != nil {
+			t.Error(err)
+		}
+		for name, want := range map[string]string{
+			"Action":           "AssumeRoleWithWebIdentity",
+			"RoleArn":          "arn:aws:iam::123456789012:role/api",
+			"RoleSessionName":  defaultRoleSessionName,
+			"WebIdentityToken": "jwt",
+		} {
+			if got := r.PostForm.Get(name); got != want {
+				t.Errorf("%s = %q, want %q", name, got, want)
+			}
+		}
+		fmt.Fprintf(w, `<AssumeRoleWithWebIdentityResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <AssumeRoleWithWebIdentityResult>
+    <Credentials>
+      <AccessKeyId>ASIAWEB</AccessKeyId>
+      <SecretAccessKey>web-secret</SecretAccessKey>
+      <SessionToken>web-token</SessionToken>
+      <Expiration>%s</Expiration>
+    </Credentials>
+  </AssumeRoleWithWebIdentityResult>
+</AssumeRoleWithWebIdentityResponse>`, expiration(time.Hour))
+	}))
+	defer sts.Close()
+
+	p := &Provider{
+		Region:          "us-east-1",
+		HTTPClient:      sts.Client(),
+		webIdentityFile: writeFile(t, "jwt\n"),
+		roleARN:         "arn:aws:iam::123456789012:role/api",
+		stsEndpoint:     sts.URL,
+	}
+	for range 2 {
+		creds, err := p.Retrieve(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := Credentials{Region: "us-east-1", AccessKeyID: "ASIAWEB", SecretAccessKey: "web-secret", SessionToken: "web-token"}
+		if creds != want {
+			t.Errorf("Retrieve() = %+v, want %+v", creds, want)
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("STS called %d times, want the credentials cached", n)
+	}
+}
+
+func TestProviderContainer(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if got := r.Header.Get("Authorization"); got != "pod-token" {
+			t.Errorf("Authorization = %q, want the token file's", got)
+		}
+		// Credentials about to expire are fetched again on the next call
+		fmt.Fprintf(w, `{"AccessKeyId":"ASIACONTAINER","SecretAccessKey":"container-secret","Token":"container-token","Expiration":%q}`,
+			expiration(time.Minute))
+	}))
+	defer server.Close()
+
+	p := &Provider{
+		Region:             "us-west-2",
+		HTTPClient:         server.Client(),
+		containerURL:       server.URL + "/v2/credentials",
+		containerToken:     "ignored",
+		containerTokenFile: writeFile(t, "pod-token"),
+	}
+	for range 2 {
+		creds, err := p.Retrieve(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if creds.AccessKeyID != "ASIACONTAINER" || creds.SessionToken != "container-token" || creds.Region != "us-west-2" {
+			t.Errorf("Retrieve() = %+v", creds)
+		}
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("endpoint called %d times, want expiring credentials refreshed", n)
+	}
+}
+
+func TestCheckContainerURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		wantErr bool
+	}{
+		{"http://169.254.170.2/v2/credentials/id", false},
+		{"http://169.254.170.23/v1/credentials", false},
+		{"http://[fd00:ec2::23]/v1/credentials", false},
+		{"http://127.0.0.1:8080/creds", false},
+		{"http://localhost/creds", false},
+		{"https://creds.example.com/", false},
+		{"http://creds.example.com/", true},
+		{"http://10.0.0.5/creds", true},
+		{"file:///etc/passwd", true},
+	}
+	for _, tt := range tests {
+		if err := checkContainerURL(tt.url); (err != nil) != tt.wantErr {
+			t.Errorf("checkContainerURL(%q) error = %v, want error %v", tt.url, err, tt.wantErr)
+		}
+	}
+}
+
+func TestProviderInstanceProfile(t *testing.T) {
+	const token = "imds-token"
+	mux := http.NewServeMux()
+	mux.HandleFunc("PUT /latest/api/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Aws-Ec2-Metadata-Token-Ttl-Seconds") == "" {
+			t.Error("token request has no TTL")
+		}
+		fmt.Fprint(w, token)
+	})
+	metadata := func(body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("X-Aws-Ec2-Metadata-Token") != token {
+				http.Error(w, "missing token", http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprint(w, body)
+		}
+	}
+	mux.HandleFunc("GET /latest/meta-data/iam/security-credentials/", metadata("api-role\n"))
+	mux.HandleFunc("GET /latest/meta-data/iam/security-credentials/api-role", metadata(fmt.Sprintf(
+		`{"Code":"Success","AccessKeyId":"ASIAEC2","SecretAccessKey":"ec2-secret","Token":"ec2-token","Expiration":%q}`,
+		expiration(6*time.Hour))))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	p := &Provider{Region: "ap-south-1", HTTPClient: server.Client(), imdsEndpoint: server.URL}
+	creds, err := p.Retrieve(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Credentials{Region: "ap-south-1", AccessKeyID: "ASIAEC2", SecretAccessKey: "ec2-secret", SessionToken: "ec2-token"}
+	if creds != want {
+		t.Errorf("Retrieve() = %+v, want %+v", creds, want)
+	}
+}
+
+func TestProviderNoCredentials(t *testing.T) {
+	p := &Provider{Region: "us-east-1"}
+	if _, err := p.Retrieve(context.Background()); !errors.Is(err, errNoCredentials) {
+		t.Errorf("Retrieve() error = %v, want errNoCredentials", err)
+	}
+}
+
+func TestNewProvider(t *testing.T) {
+	for _, name := range []string{
+		"AWS_REGION", "AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN",
+		"AWS_WEB_IDENTITY_TOKEN_FILE", "AWS_ROLE_ARN", "AWS_CONTAINER_CREDENTIALS_FULL_URI",
+		"AWS_EC2_METADATA_SERVICE_ENDPOINT",
+	} {
+		t.Setenv(name, "")
+	}
+	t.Setenv("AWS_DEFAULT_REGION", "eu-central-1")
+	t.Setenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI", "/v2/credentials/task")
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+
+	p := NewProvider(nil)
+	if p.Region != "eu-central-1" {
+		t.Errorf("Region = %q, want AWS_DEFAULT_REGION", p.Region)
+	}
+	if p.containerURL != "http://169.254.170.2/v2/credentials/task" {
+		t.Errorf("containerURL = %q", p.containerURL)
+	}
+	if p.imdsEndpoint != "" {
+		t.Errorf("imdsEndpoint = %q, want the metadata service disabled", p.imdsEndpoint)
+	}
+}
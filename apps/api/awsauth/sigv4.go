@@ -0,0 +1,144 @@
+// Package awsauth signs requests to AWS APIs with Signature Version 4 and
+// resolves credentials like the SDKs' default chain, so the few AWS calls
+// the API makes need no SDK.
+package awsauth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Credentials are static AWS credentials for one region
+type Credentials struct {
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string // Set for temporary credentials
+}
+
+// FromEnv reads credentials from the standard AWS environment variables
+func FromEnv() Credentials {
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	return Credentials{
+		Region:          region,
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+}
+
+// Complete reports whether the region and key pair are set
+func (c Credentials) Complete() bool {
+	return c.Region != "" && c.AccessKeyID != "" && c.SecretAccessKey != ""
+}
+
+// Sign adds Signature Version 4 headers for service to a request whose
+// body is body. Every header already set is signed.
+func Sign(req *http.Request, body []byte, service string, creds Credentials, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method, path, canonicalQuery(req.URL),
+		canonicalHeaders.String(), signedHeaders, SHA256Hex(body),
+	}, "\n")
+
+	scope := date + "/" + creds.Region + "/" + service + "/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256", amzDate, scope, SHA256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), date)
+	key = hmacSHA256(key, creds.Region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		creds.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// canonicalQuery returns the query string sorted by name, then value,
+// with both encoded as SigV4 requires
+func canonicalQuery(u *url.URL) string {
+	var pairs [][2]string
+	for name, values := range u.Query() {
+		for _, value := range values {
+			pairs = append(pairs, [2]string{uriEncode(name), uriEncode(value)})
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i][0] != pairs[j][0] {
+			return pairs[i][0] < pairs[j][0]
+		}
+		return pairs[i][1] < pairs[j][1]
+	})
+	encoded := make([]string, len(pairs))
+	for i, pair := range pairs {
+		encoded[i] = pair[0] + "=" + pair[1]
+	}
+	return strings.Join(encoded, "&")
+}
+
+// uriEncode percent-encodes every byte but the unreserved characters
+func uriEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// SHA256Hex returns the hex-encoded SHA-256 of data, the payload hash
+// services such as S3 also expect in X-Amz-Content-Sha256
+func SHA256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of data under key
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,125 @@
+package awsauth
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestSign checks signatures against vectors of the AWS Signature Version 4
+// test suite, which signs for the example keys at 2015-08-30T12:36:00Z
+func TestSign(t *testing.T) {
+	creds := Credentials{
+		Region:          "us-east-1",
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+	}
+	now := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+	const scope = "AKIDEXAMPLE/20150830/us-east-1/service/aws4_request"
+
+	tests := []struct {
+		name          string
+		method        string
+		url           string
+		body          string
+		contentType   string
+		sessionToken  string
+		signedHeaders string
+		signature     string
+	}{
+		{
+			name:          "get-vanilla",
+			method:        http.MethodGet,
+			url:           "https://example.amazonaws.com/",
+			signedHeaders: "host;x-amz-date",
+			signature:     "5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		},
+		{
+			name:          "get-vanilla-query-order-key-case",
+			method:        http.MethodGet,
+			url:           "https://example.amazonaws.com/?Param2=value2&Param1=value1",
+			signedHeaders: "host;x-amz-date",
+			signature:     "b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500",
+		},
+		{
+			name:          "get-query-encoding",
+			method:        http.MethodGet,
+			url:           "https://example.amazonaws.com/?Param=value%20with%20spaces&a=b&A=c",
+			signedHeaders: "host;x-amz-date",
+			signature:     "db3d98daf5ff1e95b228c9f38a1b6ecdcda6299ff66a6f3fc40c2796042ba9d1",
+		},
+		{
+			name:          "post-vanilla",
+			method:        http.MethodPost,
+			url:           "https://example.amazonaws.com/",
+			signedHeaders: "host;x-amz-date",
+			signature:     "5da7c1a2acd57cee7505fc6676e4e544621c30862966e37dddb68e92efbe5d6b",
+		},
+		{
+			name:          "post-x-www-form-urlencoded",
+			method:        http.MethodPost,
+			url:           "https://example.amazonaws.com/",
+			body:          "Param1=value1",
+			contentType:   "application/x-www-form-urlencoded",
+			signedHeaders: "content-type;host;x-amz-date",
+			signature:     "ff11897932ad3f4e8b18135d722051e5ac45fc38421b1da7b9d196a0fe09473a",
+		},
+		{
+			name:          "get-session-token",
+			method:        http.MethodGet,
+			url:           "https://example.amazonaws.com/",
+			sessionToken:  "AQoDYXdzEPT//////////wEXAMPLE",
+			signedHeaders: "host;x-amz-date;x-amz-security-token",
+			signature:     "e10798a7d4e6903cdea527f4ce90552d0984c47cedb232694699be85918af680",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			creds := creds
+			creds.SessionToken = tt.sessionToken
+
+			Sign(req, []byte(tt.body), "service", creds, now)
+
+			want := "AWS4-HMAC-SHA256 Credential=" + scope + ", SignedHeaders=" + tt.signedHeaders + ", Signature=" + tt.signature
+			if got := req.Header.Get("Authorization"); got != want {
+				t.Errorf("Authorization:\n got %s\nwant %s", got, want)
+			}
+			if got := req.Header.Get("X-Amz-Date"); got != "20150830T123600Z" {
+				t.Errorf("X-Amz-Date = %q", got)
+			}
+			if got := req.Header.Get("X-Amz-Security-Token"); got != tt.sessionToken {
+				t.Errorf("X-Amz-Security-Token = %q, want %q", got, tt.sessionToken)
+			}
+		})
+	}
+}
+
+func TestCanonicalQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"", ""},
+		{"b=2&a=1", "a=1&b=2"},
+		{"a=2&a=1", "a=1&a=2"},
+		{"a-b=1&a=2", "a=2&a-b=1"},
+		{"key=a+b&path=%2Fx%2Fy&tilde=~", "key=a%20b&path=%2Fx%2Fy&tilde=~"},
+	}
+	for _, tt := range tests {
+		req, err := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/?"+tt.query, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := canonicalQuery(req.URL); got != tt.want {
+			t.Errorf("canonicalQuery(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
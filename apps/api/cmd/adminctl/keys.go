@@ -0,0 +1,41 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// keys creates API keys. Keys are configured through the API_KEYS and
+// API_KEY_ROLES settings, so this prints the entries to add there, or to
+// the secret they reference; rotated secrets are picked up without a
+// restart.
+func keys(args []string) error {
+	_, args = subcommand(args, "keys", "generate")
+	flags := flag.NewFlagSet("keys generate", flag.ExitOnError)
+	role := flags.String("role", "", "role of the key: viewer, editor, or admin (default AUTHZ_DEFAULT_ROLE)")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: adminctl keys generate [-role role] <name>")
+	}
+	name := flags.Arg(0)
+	if strings.ContainsAny(name, ":,") || strings.TrimSpace(name) != name || name == "" {
+		return fmt.Errorf("key name %q must not be empty or contain ':', ',', or surrounding spaces", name)
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return fmt.Errorf("failed to generate key: %w", err)
+	}
+	key := "ak_" + base64.RawURLEncoding.EncodeToString(secret)
+
+	fmt.Printf("API key for %s (shown once):\n\n  %s\n\n", name, key)
+	fmt.Printf("Append to API_KEYS:\n\n  %s:%s\n\n", name, key)
+	if *role != "" {
+		fmt.Printf("Append to API_KEY_ROLES:\n\n  %s:%s\n\n", name, *role)
+	}
+	fmt.Println("Revoke the key by removing its entries.")
+	return nil
+}
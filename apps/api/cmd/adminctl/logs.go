@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	pb "agentic-template/api/pb/v1"
+
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// audit exports audit entries
+func (c *client) audit(args []string) error {
+	_, args = subcommand(args, "audit", "export")
+	flags := flag.NewFlagSet("audit export", flag.ExitOnError)
+	since := flags.String("since", "", "only entries at or after this RFC 3339 time")
+	until := flags.String("until", "", "only entries before this RFC 3339 time")
+	actor := flags.String("actor", "", "only entries of this principal")
+	method := flags.String("method", "", "only entries of this method, e.g. /proto.SchemaService/CreateTable")
+	flags.Parse(args)
+
+	req := &pb.ListAuditEntriesRequest{
+		PageSize: 500,
+		Since:    optional(*since),
+		Until:    optional(*until),
+		Actor:    optional(*actor),
+		Method:   optional(*method),
+	}
+
+	// Pages come newest first; collect them so the export reads in order
+	auditClient := pb.NewAuditServiceClient(c.conn)
+	var entries []*pb.AuditEntry
+	for {
+		ctx, cancel := c.context()
+		resp, err := auditClient.ListAuditEntries(ctx, req)
+		cancel()
+		if err != nil {
+			return err
+		}
+		entries = append(entries, resp.Entries...)
+		if resp.NextPageToken == "" {
+			break
+		}
+		req.PageToken = resp.NextPageToken
+	}
+	slices.Reverse(entries)
+
+	w := bufio.NewWriter(os.Stdout)
+	for _, entry := range entries {
+		line, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		w.Write(line)
+		w.WriteByte('\n')
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Exported %d audit entries\n", len(entries))
+	return nil
+}
+
+// schemaChanges prints the latest schema changes and optionally follows
+// new ones
+func (c *client) schemaChanges(args []string) error {
+	_, args = subcommand(args, "schema-changes", "tail")
+	flags := flag.NewFlagSet("schema-changes tail", flag.ExitOnError)
+	count := flags.Int("n", 20, "number of recent changes to show")
+	table := flags.Int("table", 0, "only changes to this table ID")
+	follow := flags.Bool("follow", false, "keep printing new changes")
+	interval := flags.Duration("interval", 2*time.Second, "how often to poll when following")
+	flags.Parse(args)
+
+	schema := pb.NewSchemaServiceClient(c.conn)
+	req := &pb.ListSchemaChangesRequest{PageSize: int32(*count)}
+	if *table > 0 {
+		tableID := int32(*table)
+		req.TableId = &tableID
+	}
+
+	ctx, cancel := c.context()
+	resp, err := schema.ListSchemaChanges(ctx, req)
+	cancel()
+	if err != nil {
+		return err
+	}
+	changes := resp.Changes
+	slices.Reverse(changes) // Newest first, printed oldest first
+	lastID := c.printChanges(changes, 0)
+	if !*follow {
+		return nil
+	}
+
+	req.PageSize = 500
+	for {
+		time.Sleep(*interval)
+		req.AfterId = lastID
+		ctx, cancel := c.context()
+		resp, err := schema.ListSchemaChanges(ctx, req)
+		cancel()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			continue
+		}
+		lastID = c.printChanges(resp.Changes, lastID)
+	}
+}
+
+// printChanges prints schema changes in order and returns the highest ID
+// printed, or lastID when there are none
+func (c *client) printChanges(changes []*pb.SchemaChange, lastID int64) int64 {
+	for _, change := range changes {
+		if c.json {
+			line, _ := protojson.MarshalOptions{UseProtoNames: true}.Marshal(change)
+			fmt.Println(string(line))
+		} else {
+			table := "-"
+			if change.TableId != nil {
+				table = fmt.Sprint(*change.TableId)
+			}
+			fmt.Printf("%s  #%d  %-13s  table=%s  %s  by=%s  %s\n", change.CreatedAt, change.Id, change.ChangeType,
+				table, change.Status, change.GetCreatedBy(), change.ChangeDetails)
+			if change.ErrorMessage != nil {
+				fmt.Printf("    error: %s\n", *change.ErrorMessage)
+			}
+		}
+		lastID = max(lastID, change.Id)
+	}
+	return lastID
+}
+
+// database triggers a reload of the server's database connection
+func (c *client) database(args []string) error {
+	subcommand(args, "db", "reload")
+
+	ctx, cancel := c.context()
+	defer cancel()
+	resp, err := pb.NewSchemaServiceClient(c.conn).ReloadDatabase(ctx, &pb.ReloadDatabaseRequest{})
+	if err != nil {
+		return err
+	}
+	fmt.Println(resp.Message)
+	if resp.DatabaseInfo != nil {
+		fmt.Println(*resp.DatabaseInfo)
+	}
+	return nil
+}
+
+// optional returns a pointer to value, or nil when it is empty
+func optional(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
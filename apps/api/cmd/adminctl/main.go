@@ -0,0 +1,172 @@
+// Command adminctl runs common operations against a running API server
+// over gRPC, authenticating with an API key or bearer token.
+//
+//	adminctl [flags] tables list [-prefix name]
+//	adminctl tables get <id>
+//	adminctl tables search <words>
+//	adminctl tables create <spec.json | ->
+//	adminctl tables delete <id>
+//	adminctl audit export [-since t] [-until t] [-actor id] [-method m] > audit.ndjson
+//	adminctl schema-changes tail [-n 20] [-table id] [-follow]
+//	adminctl db reload
+//	adminctl keys generate [-role role] <name>
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// usage lists the commands
+const usage = `Usage: adminctl [flags] <command> [args]
+
+Commands:
+  tables list [-prefix name]     List tables
+  tables get <id>                Show a table and its columns
+  tables search <words>          Find tables and columns by name or description
+  tables create <spec.json | ->  Create a table from a CreateTableRequest in JSON
+  tables delete <id>             Delete a table
+  audit export [filters]         Write audit entries as NDJSON, oldest first
+  schema-changes tail [-follow]  Show recent schema changes, then follow new ones
+  db reload                      Reconnect the server's database pool
+  keys generate <name>           Create an API key and print its configuration
+
+Flags:
+`
+
+// client holds the connection and call settings shared by commands
+type client struct {
+	conn    *grpc.ClientConn
+	apiKey  string
+	token   string
+	timeout time.Duration
+	json    bool
+}
+
+func main() {
+	log.SetFlags(0)
+	addr := flag.String("addr", envOr("ADMINCTL_ADDR", "localhost:50051"), "gRPC address of the API server (ADMINCTL_ADDR)")
+	apiKey := flag.String("api-key", os.Getenv("ADMINCTL_API_KEY"), "API key sent as x-api-key (ADMINCTL_API_KEY)")
+	token := flag.String("token", os.Getenv("ADMINCTL_TOKEN"), "bearer token, instead of an API key (ADMINCTL_TOKEN)")
+	useTLS := flag.Bool("tls", false, "connect with TLS")
+	caFile := flag.String("ca-file", "", "PEM certificates to verify the server with, instead of the system roots; implies -tls")
+	timeout := flag.Duration("timeout", 30*time.Second, "deadline of each call")
+	jsonOutput := flag.Bool("json", false, "print responses as JSON")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// Generating keys needs no server
+	if flag.Arg(0) == "keys" {
+		if err := keys(flag.Args()[1:]); err != nil {
+			log.Fatalf("adminctl keys: %v", err)
+		}
+		return
+	}
+
+	creds, err := transportCredentials(*useTLS, *caFile)
+	if err != nil {
+		log.Fatalf("adminctl: %v", err)
+	}
+	conn, err := grpc.NewClient(*addr, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		log.Fatalf("adminctl: failed to connect to %s: %v", *addr, err)
+	}
+	defer conn.Close()
+	c := &client{conn: conn, apiKey: *apiKey, token: *token, timeout: *timeout, json: *jsonOutput}
+
+	args := flag.Args()[1:]
+	switch command := flag.Arg(0); command {
+	case "tables":
+		err = c.tables(args)
+	case "audit":
+		err = c.audit(args)
+	case "schema-changes":
+		err = c.schemaChanges(args)
+	case "db":
+		err = c.database(args)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		conn.Close()
+		if st, ok := status.FromError(err); ok {
+			log.Fatalf("adminctl %s: %s: %s", flag.Arg(0), st.Code(), st.Message())
+		}
+		log.Fatalf("adminctl %s: %v", flag.Arg(0), err)
+	}
+}
+
+// context returns a call context with the deadline and credentials
+func (c *client) context() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	switch {
+	case c.token != "":
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+c.token)
+	case c.apiKey != "":
+		ctx = metadata.AppendToOutgoingContext(ctx, "x-api-key", c.apiKey)
+	}
+	return ctx, cancel
+}
+
+// transportCredentials returns TLS credentials, verified against caFile
+// when set, or plaintext ones
+func transportCredentials(useTLS bool, caFile string) (credentials.TransportCredentials, error) {
+	if caFile == "" {
+		if !useTLS {
+			return insecure.NewCredentials(), nil
+		}
+		return credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12}), nil
+	}
+
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA file: %w", err)
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates in %s", caFile)
+	}
+	return credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12, RootCAs: roots}), nil
+}
+
+// subcommand parses a subcommand's flags, failing with its usage when the
+// subcommand is unknown
+func subcommand(args []string, name string, commands ...string) (string, []string) {
+	if len(args) > 0 {
+		for _, command := range commands {
+			if args[0] == command {
+				return command, args[1:]
+			}
+		}
+	}
+	log.Fatalf("Usage: adminctl %s %v", name, commands)
+	return "", nil
+}
+
+// envOr returns an environment variable, or fallback when it is unset
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	pb "agentic-template/api/pb/v1"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// tables lists, shows, searches, creates, and deletes tables
+func (c *client) tables(args []string) error {
+	command, args := subcommand(args, "tables", "list", "get", "search", "create", "delete")
+	schema := pb.NewSchemaServiceClient(c.conn)
+
+	switch command {
+	case "list":
+		flags := flag.NewFlagSet("tables list", flag.ExitOnError)
+		prefix := flags.String("prefix", "", "only tables whose name starts with this")
+		flags.Parse(args)
+		return c.listTables(schema, *prefix)
+	case "get":
+		id, err := tableID(args)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := c.context()
+		defer cancel()
+		resp, err := schema.GetTable(ctx, &pb.GetTableRequest{TableId: id})
+		if err != nil {
+			return err
+		}
+		if c.json {
+			return printJSON(resp.Table)
+		}
+		printTable(resp.Table)
+		return nil
+	case "search":
+		if len(args) == 0 {
+			return fmt.Errorf("usage: adminctl tables search <words>")
+		}
+		return c.searchSchema(schema, strings.Join(args, " "))
+	case "create":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: adminctl tables create <spec.json | ->")
+		}
+		req, err := readCreateTableRequest(args[0])
+		if err != nil {
+			return err
+		}
+		ctx, cancel := c.context()
+		defer cancel()
+		resp, err := schema.CreateTable(ctx, req)
+		if err != nil {
+			return err
+		}
+		if c.json {
+			return printJSON(resp.Table)
+		}
+		fmt.Println(resp.Message)
+		printTable(resp.Table)
+		return nil
+	default: // delete
+		id, err := tableID(args)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := c.context()
+		defer cancel()
+		resp, err := schema.DeleteTable(ctx, &pb.DeleteTableRequest{TableId: id})
+		if err != nil {
+			return err
+		}
+		fmt.Println(resp.Message)
+		return nil
+	}
+}
+
+// listTables prints every table, following the pages
+func (c *client) listTables(schema pb.SchemaServiceClient, prefix string) error {
+	req := &pb.ListTablesRequest{PageSize: 500, Sort: "name_asc"}
+	if prefix != "" {
+		req.NamePrefix = &prefix
+	}
+
+	var tables []*pb.TableDefinition
+	for {
+		ctx, cancel := c.context()
+		resp, err := schema.ListTables(ctx, req)
+		cancel()
+		if err != nil {
+			return err
+		}
+		tables = append(tables, resp.Tables...)
+		if resp.NextPageToken == "" {
+			break
+		}
+		req.PageToken = resp.NextPageToken
+	}
+
+	if c.json {
+		return printJSON(&pb.ListTablesResponse{Tables: tables, TotalSize: int32(len(tables))})
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tTABLE\tCREATED\tCREATED BY")
+	for _, table := range tables {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", table.Id, table.Name, table.TableName, table.CreatedAt, table.GetCreatedBy())
+	}
+	return w.Flush()
+}
+
+// searchSchema prints the tables and columns matching a query, best first
+func (c *client) searchSchema(schema pb.SchemaServiceClient, query string) error {
+	ctx, cancel := c.context()
+	defer cancel()
+	resp, err := schema.SearchSchema(ctx, &pb.SearchSchemaRequest{Query: query})
+	if err != nil {
+		return err
+	}
+	if c.json {
+		return printJSON(resp)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "TABLE ID\tTABLE\tCOLUMN\tDESCRIPTION")
+	for _, match := range resp.Matches {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", match.TableId, match.TableName, match.GetColumnName(), match.GetDescription())
+	}
+	return w.Flush()
+}
+
+// printTable prints a table's columns
+func printTable(table *pb.TableDefinition) {
+	fmt.Printf("Table %d: %s (%s)\n", table.Id, table.Name, table.TableName)
+	if table.Description != nil {
+		fmt.Println(*table.Description)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "COLUMN\tTYPE\tPOSTGRES TYPE\tNULLABLE\tUNIQUE\tREFERENCES")
+	for _, col := range table.Columns {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%t\t%t\t%s\n", col.ColumnName, col.DataType, col.PostgresType,
+			col.IsNullable, col.IsUnique, col.GetForeignKeyToTableName())
+	}
+	w.Flush()
+}
+
+// readCreateTableRequest reads a CreateTableRequest in JSON from a file,
+// or from stdin when path is "-"
+func readCreateTableRequest(path string) (*pb.CreateTableRequest, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read table spec: %w", err)
+	}
+
+	var req pb.CreateTableRequest
+	if err := protojson.Unmarshal(data, &req); err != nil {
+		return nil, fmt.Errorf("invalid table spec: %w", err)
+	}
+	return &req, nil
+}
+
+// tableID parses the single table ID argument
+func tableID(args []string) (int32, error) {
+	if len(args) != 1 {
+		return 0, fmt.Errorf("expected a table ID")
+	}
+	id, err := strconv.ParseInt(args[0], 10, 32)
+	if err != nil || id < 1 {
+		return 0, fmt.Errorf("invalid table ID %q", args[0])
+	}
+	return int32(id), nil
+}
+
+// printJSON prints a message as indented JSON
+func printJSON(message proto.Message) error {
+	data, err := protojson.MarshalOptions{Multiline: true, Indent: "  "}.Marshal(message)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Println(string(data))
+	return err
+}
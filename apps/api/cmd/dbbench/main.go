@@ -0,0 +1,210 @@
+// Command dbbench measures bulk record reads and writes against the
+// configured database with and without prepared statement caching, to size
+// DB_STATEMENT_CACHE_CAPACITY. It creates a scratch table and drops it
+// when done.
+//
+//	dbbench [-rows 5000] [-workers 8] [-columns 8] [-capacity 512]
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"agentic-template/api/config"
+	"agentic-template/api/db"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// tableName is the scratch table, suffixed with the process ID so
+// concurrent runs don't collide
+var tableName = fmt.Sprintf("dbbench_records_%d", os.Getpid())
+
+// workload is a benchmarked operation, run once per row
+type workload struct {
+	name string
+	run  func(ctx context.Context, pool *pgxpool.Pool, row int) error
+}
+
+// result is the outcome of a workload in one mode
+type result struct {
+	mode     string
+	workload string
+	ops      int
+	elapsed  time.Duration
+}
+
+func main() {
+	rows := flag.Int("rows", 5000, "rows written, then read back, per mode")
+	workers := flag.Int("workers", 8, "concurrent connections")
+	columns := flag.Int("columns", 8, "text columns of the scratch table")
+	capacity := flag.Int("capacity", db.DefaultStatementCacheCapacity, "statements cached per connection in the cached mode")
+	flag.Parse()
+	if *rows < 1 || *workers < 1 || *columns < 1 || *capacity < 1 {
+		log.Fatal("-rows, -workers, -columns, and -capacity must be at least 1")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	if cfg.DatabaseURLPooled == "" {
+		log.Fatal("DATABASE_URL_POOLED is required")
+	}
+
+	ctx := context.Background()
+	workloads := recordWorkloads(*columns)
+	var results []result
+	for _, mode := range []struct {
+		name     string
+		capacity int
+	}{{"uncached", 0}, {"cached", *capacity}} {
+		poolConfig := db.DefaultPoolConfig()
+		poolConfig.MinConns = int32(*workers)
+		poolConfig.MaxConns = int32(*workers)
+		poolConfig.SlowQueryThreshold = 0
+		poolConfig.StatementCacheCapacity = mode.capacity
+		conn, err := db.NewConnection(cfg.DatabaseURLPooled, poolConfig)
+		if err != nil {
+			log.Fatalf("Failed to connect to database: %v", err)
+		}
+
+		modeResults, err := runMode(ctx, conn.Pool, mode.name, *columns, *rows, *workers, workloads)
+		conn.Close()
+		if err != nil {
+			log.Fatalf("%s: %v", mode.name, err)
+		}
+		results = append(results, modeResults...)
+	}
+	report(results)
+}
+
+// runMode recreates the scratch table and runs each workload over it
+func runMode(ctx context.Context, pool *pgxpool.Pool, mode string, columns, rows, workers int, workloads []workload) ([]result, error) {
+	defs := make([]string, columns)
+	for i := range defs {
+		defs[i] = fmt.Sprintf("c%d TEXT", i)
+	}
+	if _, err := pool.Exec(ctx, "DROP TABLE IF EXISTS "+tableName); err != nil {
+		return nil, fmt.Errorf("failed to drop %s: %w", tableName, err)
+	}
+	if _, err := pool.Exec(ctx, fmt.Sprintf("CREATE TABLE %s (id INTEGER PRIMARY KEY, %s)",
+		tableName, strings.Join(defs, ", "))); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", tableName, err)
+	}
+	defer pool.Exec(context.Background(), "DROP TABLE IF EXISTS "+tableName)
+
+	var results []result
+	for _, w := range workloads {
+		elapsed, err := runWorkload(ctx, pool, w, rows, workers)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", w.name, err)
+		}
+		results = append(results, result{mode: mode, workload: w.name, ops: rows, elapsed: elapsed})
+	}
+	return results, nil
+}
+
+// runWorkload runs a workload once per row, spread over the workers
+func runWorkload(ctx context.Context, pool *pgxpool.Pool, w workload, rows, workers int) (time.Duration, error) {
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+
+	started := time.Now()
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				row := int(next.Add(1)) - 1
+				if row >= rows {
+					return
+				}
+				if err := w.run(ctx, pool, row); err != nil {
+					once.Do(func() { firstErr = err })
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return time.Since(started), firstErr
+}
+
+// recordWorkloads returns the operations of the record layer: inserts,
+// point reads, filtered reads, and updates over every column
+func recordWorkloads(columns int) []workload {
+	names := make([]string, columns)
+	placeholders := make([]string, columns)
+	sets := make([]string, columns)
+	for i := range names {
+		names[i] = fmt.Sprintf("c%d", i)
+		placeholders[i] = fmt.Sprintf("$%d", i+2)
+		sets[i] = fmt.Sprintf("c%d = $%d", i, i+2)
+	}
+	values := func(row int) []any {
+		args := []any{row}
+		for i := range columns {
+			args = append(args, fmt.Sprintf("row %d column %d", row, i))
+		}
+		return args
+	}
+
+	insertSQL := fmt.Sprintf("INSERT INTO %s (id, %s) VALUES ($1, %s)",
+		tableName, strings.Join(names, ", "), strings.Join(placeholders, ", "))
+	selectSQL := fmt.Sprintf("SELECT id, %s FROM %s WHERE id = $1", strings.Join(names, ", "), tableName)
+	filterSQL := fmt.Sprintf("SELECT id FROM %s WHERE c0 = $1 LIMIT 10", tableName)
+	updateSQL := fmt.Sprintf("UPDATE %s SET %s WHERE id = $1", tableName, strings.Join(sets, ", "))
+
+	return []workload{
+		{"insert", func(ctx context.Context, pool *pgxpool.Pool, row int) error {
+			_, err := pool.Exec(ctx, insertSQL, values(row)...)
+			return err
+		}},
+		{"read by id", func(ctx context.Context, pool *pgxpool.Pool, row int) error {
+			dest := []any{new(int)}
+			for range columns {
+				dest = append(dest, new(string))
+			}
+			return pool.QueryRow(ctx, selectSQL, row).Scan(dest...)
+		}},
+		{"read by filter", func(ctx context.Context, pool *pgxpool.Pool, row int) error {
+			rows, err := pool.Query(ctx, filterSQL, fmt.Sprintf("row %d column 0", row))
+			if err != nil {
+				return err
+			}
+			rows.Close()
+			return rows.Err()
+		}},
+		{"update", func(ctx context.Context, pool *pgxpool.Pool, row int) error {
+			_, err := pool.Exec(ctx, updateSQL, values(row)...)
+			return err
+		}},
+	}
+}
+
+// report prints the throughput of each workload per mode and the speedup
+// of caching
+func report(results []result) {
+	uncached := map[string]float64{}
+	fmt.Printf("%-10s %-16s %10s %12s %8s\n", "MODE", "WORKLOAD", "OPS", "OPS/SEC", "SPEEDUP")
+	for _, r := range results {
+		rate := float64(r.ops) / r.elapsed.Seconds()
+		speedup := ""
+		if r.mode == "uncached" {
+			uncached[r.workload] = rate
+		} else if base := uncached[r.workload]; base > 0 {
+			speedup = fmt.Sprintf("%.2fx", rate/base)
+		}
+		fmt.Printf("%-10s %-16s %10d %12.0f %8s\n", r.mode, r.workload, r.ops, rate, speedup)
+	}
+}
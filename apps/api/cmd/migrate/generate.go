@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"agentic-template/api/db"
+	"agentic-template/api/db/migrations"
+	"agentic-template/api/schema_manager"
+)
+
+// export writes the user-defined tables to stdout as a schema bundle
+func export(ctx context.Context, dbManager *db.Manager) error {
+	bundle, err := schema_manager.NewSchemaManager(dbManager.GetPool()).ExportBundle(ctx)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(bundle)
+}
+
+// generate writes a migration creating the tables of a schema bundle, read
+// from a file or exported from the database, numbered after the latest
+// embedded or external migration
+func generate(ctx context.Context, dbManager *db.Manager, from, dir, name string) error {
+	var bundle *schema_manager.SchemaBundle
+	if from != "" {
+		data, err := os.ReadFile(from)
+		if err != nil {
+			return err
+		}
+		bundle = &schema_manager.SchemaBundle{}
+		if err := json.Unmarshal(data, bundle); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", from, err)
+		}
+	} else {
+		var err error
+		if bundle, err = schema_manager.NewSchemaManager(dbManager.GetPool()).ExportBundle(ctx); err != nil {
+			return err
+		}
+	}
+	if len(bundle.Tables) == 0 {
+		return fmt.Errorf("the schema has no user-defined tables")
+	}
+
+	body, err := schema_manager.GenerateMigrationSQL(bundle)
+	if err != nil {
+		return err
+	}
+	version, err := migrations.NextVersion()
+	if err != nil {
+		return err
+	}
+
+	name, err = schema_manager.SanitizeIdentifier(name)
+	if err != nil {
+		return fmt.Errorf("invalid migration name: %w", err)
+	}
+	title := strings.ReplaceAll(name, "_", " ")
+	header := fmt.Sprintf("-- Migration %03d: %s\n-- Generated from %d user-defined table(s) exported at %s\n-- Created: %s\n\n",
+		version, strings.ToUpper(title[:1])+title[1:], len(bundle.Tables),
+		bundle.ExportedAt.Format("2006-01-02 15:04:05 MST"), bundle.ExportedAt.Format("2006-01-02"))
+
+	path := filepath.Join(dir, fmt.Sprintf("%03d_%s.sql", version, name))
+	if err := os.WriteFile(path, []byte(header+body), 0o644); err != nil {
+		return err
+	}
+	log.Printf("Wrote %s; review it, then apply it with migrate up (or baseline it on the source database)", path)
+	return nil
+}
@@ -0,0 +1,135 @@
+// Command migrate applies and inspects database migrations outside the API
+// server, using the same configuration.
+//
+//	migrate [-allow-out-of-order] [-timeout 10m] up
+//	migrate status
+//	migrate baseline <version>
+//	migrate export > schema.json
+//	migrate [-from schema.json] [-dir migrations] [-name user_tables] generate
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"agentic-template/api/config"
+	"agentic-template/api/db"
+	"agentic-template/api/db/migrations"
+)
+
+func main() {
+	allowOutOfOrder := flag.Bool("allow-out-of-order", false, "apply pending migrations older than the latest applied one")
+	timeout := flag.Duration("timeout", 10*time.Minute, "give up after this long, waiting for other runners included")
+	from := flag.String("from", "", "generate: read the schema bundle from this file instead of the database")
+	dir := flag.String("dir", "", "generate: write the migration here (default MIGRATIONS_DIR or the current directory)")
+	name := flag.String("name", "user_tables", "generate: name of the migration file after its version")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: migrate [flags] up | status | baseline <version> | export | generate\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	migrations.SetExternalDir(cfg.MigrationsDir)
+	migrations.SetAllowOutOfOrder(*allowOutOfOrder || cfg.MigrationsAllowOutOfOrder)
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	dbManager := db.GetManager()
+	defer dbManager.Close()
+
+	switch command := flag.Arg(0); command {
+	case "up":
+		connect(cfg, dbManager)
+		err = migrations.RunMigrations(ctx, dbManager.GetPool())
+	case "status":
+		connect(cfg, dbManager)
+		err = status(ctx, dbManager)
+	case "baseline":
+		if flag.NArg() != 2 {
+			log.Fatal("Usage: migrate baseline <version>")
+		}
+		version, convErr := strconv.Atoi(flag.Arg(1))
+		if convErr != nil || version < 1 {
+			log.Fatalf("Invalid version %q", flag.Arg(1))
+		}
+		connect(cfg, dbManager)
+		err = migrations.Baseline(ctx, dbManager.GetPool(), version)
+	case "export":
+		connect(cfg, dbManager)
+		err = export(ctx, dbManager)
+	case "generate":
+		outDir := *dir
+		if outDir == "" {
+			outDir = cfg.MigrationsDir
+		}
+		if *from == "" {
+			connect(cfg, dbManager)
+		}
+		err = generate(ctx, dbManager, *from, outDir, *name)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		dbManager.Close()
+		log.Fatalf("migrate %s: %v", flag.Arg(0), err)
+	}
+}
+
+// connect opens a small pool, preferring the direct URL since the
+// migration lock is a session lock
+func connect(cfg *config.Config, dbManager *db.Manager) {
+	url := cfg.DatabaseURLDirect
+	if url == "" {
+		url = cfg.DatabaseURLPooled
+	}
+	poolConfig := db.DefaultPoolConfig()
+	poolConfig.MinConns = 0
+	poolConfig.MaxConns = 2
+	poolConfig.StatementTimeout = 0 // Migrations may legitimately run long
+	dbManager.SetPoolConfig(poolConfig)
+	if err := dbManager.Initialize(url, ""); err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+}
+
+// status prints the current version and the pending migrations
+func status(ctx context.Context, dbManager *db.Manager) error {
+	pool := dbManager.GetPool()
+	version, err := migrations.GetCurrentVersion(ctx, pool)
+	if err != nil {
+		return err
+	}
+	pending, err := migrations.Pending(ctx, pool)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Current version: %03d\n", version)
+	if len(pending) == 0 {
+		fmt.Println("No pending migrations")
+		return nil
+	}
+	fmt.Printf("Pending migrations:\n")
+	for _, migration := range pending {
+		note := ""
+		if migration.Version < version {
+			note = " (out of order)"
+		}
+		fmt.Printf("  %03d %s [%s]%s\n", migration.Version, migration.Name, migration.Source, note)
+	}
+	return nil
+}
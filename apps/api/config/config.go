@@ -1,46 +1,674 @@
 package config
 
 import (
-	"os"
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 // Config holds all configuration values for the application
 type Config struct {
-	HTTPPort           string
-	GRPCPort           string
-	DatabaseURLPooled  string // Pooled connection for runtime queries
-	DatabaseURLDirect  string // Direct connection for migrations
-	Environment        string
-	OpenAIAPIKey       string
-	LogLevel           string
-	EnableCORS         bool
+	HTTPPort          string
+	GRPCPort          string
+	DatabaseURLPooled string // Pooled connection for runtime queries; a comma-separated list fails over in order
+	DatabaseURLDirect string // Direct connection for migrations
+	DBDriver          string // "postgres", or "sqlite" to run locally on SQLitePath without PostgreSQL
+	SQLitePath        string // File database of DB_DRIVER=sqlite, created when missing
+	Environment       string
+	OpenAIAPIKey      string
+	AnthropicAPIKey   string
+	GoogleAPIKey      string
+	LogLevel          string
+	EnableCORS        bool
+	GRPCReflection    bool   // Expose gRPC server reflection for grpcurl/Postman
+	GinMode           string // "debug" or "release"; defaults by environment profile
+
+	// TLS, required in production unless terminated by a load balancer or mesh
+	TLSCertFile             string // PEM certificate served by the HTTP and gRPC servers
+	TLSKeyFile              string // PEM private key of the certificate
+	TLSTerminatedUpstream   bool   // Traffic arrives over TLS terminated before this process
+	AllowInsecureProduction bool   // Start in production without TLS or authentication
+
+	// CORS for browser frontends on other origins (when EnableCORS is set)
+	CORSAllowedOrigins   []string // Origins allowed to call the HTTP API; "*" allows any
+	CORSAllowedMethods   []string
+	CORSAllowedHeaders   []string
+	CORSAllowCredentials bool // Allow cookies and auth headers on cross-origin requests
+	CORSMaxAgeSeconds    int  // How long browsers cache preflight results
+
+	// HTTP server limits (0 disables a limit)
+	HTTPReadHeaderTimeoutSeconds int   // Time to read request headers, against slowloris clients
+	HTTPReadTimeoutSeconds       int   // Time to read a whole request, body included
+	HTTPWriteTimeoutSeconds      int   // Time to write a response; agent streams and profiles are exempt
+	HTTPIdleTimeoutSeconds       int   // How long keep-alive connections wait for the next request
+	HTTPMaxBodySize              int64 // Largest request body accepted, in bytes
+
+	// Reverse proxies whose X-Forwarded-For header is trusted for the HTTP
+	// client IP; empty trusts none, so the rate limiter and audit log see
+	// the connecting address
+	TrustedProxies []string // IPs or CIDRs
+
+	// Admin UI
+	AdminUIEnabled bool   // Serve the admin frontend under /admin
+	AdminUIDir     string // Serve the frontend from this directory instead of the embedded build
+
+	// Database
+	DBStatementTimeout         time.Duration // Longest a single query may run, also set as the session statement_timeout; 0 leaves only request deadlines
+	DBMaxConns                 int           // Largest size of the runtime connection pool
+	DBMinConns                 int           // Idle connections kept open
+	DBMaxConnLifetimeMinutes   int           // Connections are replaced after this long
+	DBMaxConnIdleMinutes       int           // Idle connections above the minimum close after this long
+	DBHealthCheckPeriodSeconds int           // How often idle connections are checked
+	DBConnectTimeout           time.Duration // Time to establish a new connection
+	DBConnectAttempts          int           // Connection attempts at startup, with exponential backoff
+	DBConnectMaxBackoffSeconds int           // Longest wait between connection attempts
+	DBConnectInBackground      bool          // Keep retrying after startup, serving without a database meanwhile
+	DBSlowQueryMS              int           // Queries running this long are logged as warnings; 0 disables
+	DBReloadGraceSeconds       int           // How long a pool replaced by a reload drains in-flight queries
+	DBPoolStatsIntervalSeconds int           // How often pool statistics are exported as metrics
+	DBFailoverCheckSeconds     int           // How often the primary is checked when DATABASE_URL_POOLED lists several DSNs
+	DBFailoverThreshold        int           // Consecutive failed checks before failing over to the next DSN
+	DBStatementCacheCapacity   int           // Prepared statements kept per connection; 0 for transaction-mode poolers
+
+	// Migrations
+	MigrationsDir             string // Directory of deployment-specific migrations applied with the embedded ones
+	MigrationsAllowOutOfOrder bool   // Apply pending migrations older than the latest applied one instead of failing
+
+	// Read replicas
+	DatabaseURLReplicas []string // Lag-tolerant reads rotate across these; DDL and writes use the primary
+
+	// gRPC server limits (0 keeps the library default)
+	GRPCMaxRecvMsgSizeMB        int // Largest request message accepted
+	GRPCMaxSendMsgSizeMB        int // Largest response message sent
+	GRPCMaxConcurrentStreams    int // Concurrent streams per client connection
+	GRPCKeepaliveTimeSeconds    int // Idle time before the server pings a client
+	GRPCKeepaliveTimeoutSeconds int // Wait for a ping ack before closing the connection
+	GRPCKeepaliveMinTimeSeconds int // Shortest client ping interval tolerated
+
+	// gRPC compression
+	GRPCCompression []string // Response compressors offered to clients, in order of preference
+
+	// Authentication and authorization
+	AuthEnabled bool                // Require credentials on gRPC calls and /api routes
+	APIKeys     map[string]string   // API key -> principal ID
+	APIKeyRoles map[string][]string // API key name -> roles ("name:role" entries)
+	DefaultRole string              // Role of principals without roles; empty grants nothing
+	JWTSecret   string              // HS256 secret for bearer JWTs; empty disables JWTs
+	JWTIssuer   string              // Required JWT issuer, if set
+
+	// User accounts (enabled when JWTSecret is set)
+	SignupEnabled       bool // Allow anyone to sign up; the first user becomes an admin
+	JWTAccessTTLMinutes int  // Lifetime of issued access tokens
+	JWTRefreshTTLHours  int  // Lifetime of refresh tokens
+
+	// Multi-tenancy: each tenant's user tables and their metadata live in a
+	// tenant_<name> schema. Requires session-level pooling, since each
+	// connection's search_path is set per request.
+	TenancyEnabled bool              // Scope schema management to the principal's tenant
+	APIKeyTenants  map[string]string // API key name -> tenant ("name:tenant" entries); JWTs use the tenant claim
+
+	// Identifier naming: how the database names of new user tables and
+	// columns are derived from their display names
+	IdentifierTablePrefix string // Prefix of table names, e.g. user_table_
+	IdentifierCase        string // "lower", or "snake" to split camelCase words
+	IdentifierTruncation  string // "cut" long names, or "hash" to end them with a hash of the whole name
+	IdentifierMaxLength   int    // Longest name, at most 63
+
+	// Table trash: deleted tables are renamed to trash_<name> and purged
+	// after the retention period unless restored
+	TableTrashEnabled   bool          // Move deleted tables to the trash instead of dropping them
+	TableTrashRetention time.Duration // How long tables stay in the trash; 0 keeps them until purged
+
+	// Schema change approval: CreateTable and DeleteTable calls by editors
+	// become change requests that an admin approves or rejects
+	SchemaChangeApproval bool
+
+	// Schema sandbox: requests with an x-schema-environment: sandbox header
+	// or metadata use a shadow copy of their schema, whose new tables an
+	// admin promotes to production
+	SchemaSandboxEnabled bool
+
+	// Data imports: admins copy tables of these external databases, Google
+	// Sheets, and Airtable bases into new user tables; callers name a
+	// source, never its URL
+	ImportSources map[string]string // Source name -> postgres://, mysql://, gsheets://, or airtable:// URL ("name=url" entries)
+
+	// Warehouse syncs: admins replicate the record changes of user tables
+	// to these warehouses on a schedule; callers name a destination, never
+	// its URL
+	SyncDestinations  map[string]string // Destination name -> bigquery://, snowflake://, or s3:// URL ("name=url" entries)
+	SyncAlertWebhook  string            // Receives a JSON alert when a sync keeps failing, and when it recovers
+	SyncAlertFailures int               // Failed runs in a row that raise an alert
+
+	// Soft quotas per tenant (0 disables a limit); admins not bound to a
+	// tenant may override them per request
+	QuotaMaxTables          int // User tables
+	QuotaMaxColumnsPerTable int // Columns of a new user table
+	QuotaMaxRowsPerTable    int // Records of a user table
+
+	// API audit log of mutating calls
+	AuditLogEnabled    bool // Record mutating gRPC and HTTP calls in api_audit_log
+	AuditRetentionDays int  // Days entries are kept; 0 keeps them forever
+
+	// API usage analytics of user-defined tables
+	TableAnalyticsEnabled       bool // Count table requests in table_usage for GetTableAnalytics
+	TableAnalyticsRetentionDays int  // Days hourly counts are kept; 0 keeps them forever
+
+	// Audit export to external sinks for archiving
+	AuditExportSinks         []string      // file:///dir, s3://bucket/prefix, syslog[+tcp]://host:port, or https:// webhook URLs
+	AuditExportSources       []string      // "api" and/or "schema"
+	AuditExportActions       []string      // Exported action prefixes, such as "SchemaService/"; empty exports all
+	AuditExportInterval      time.Duration // Time between exports
+	AuditExportBatchSize     int           // Events per sink write
+	AuditExportWebhookSecret string        // Signs webhook bodies in X-Audit-Signature
+	AuditExportS3Endpoint    string        // Overrides the S3 endpoint, e.g. for MinIO
+
+	// Rate limiting per caller and method class (0 per minute disables a class)
+	RateLimitEnabled        bool
+	RateLimitReadPerMinute  int // Sustained reads per minute
+	RateLimitReadBurst      int // Reads allowed at once
+	RateLimitWritePerMinute int // Sustained schema and configuration changes per minute
+	RateLimitWriteBurst     int // Changes allowed at once
+
+	// RAG / embeddings
+	EmbeddingProvider string // "openai" or "ollama"
+	EmbeddingModel    string
+	EmbeddingBaseURL  string
+	RAGTopK           int
+
+	// Vector size of the embedding model; rag_chunks.embedding is resized to
+	// match while the knowledge base is empty. Defaults to the size of the
+	// provider's default model.
+	EmbeddingDimensions int
+
+	// Embedding batches: texts from concurrent callers are grouped into
+	// provider requests, throttled, and retried with backoff when the
+	// provider rate limits
+	EmbeddingBatchSize         int // Texts per provider request
+	EmbeddingRequestsPerMinute int // Provider requests per minute; 0 only backs off when rate limited
+	EmbeddingMaxRetries        int // Retries of a rate-limited request
+
+	// Guardrails
+	GuardrailsEnabled        bool
+	GuardrailPIIAction       string   // "redact", "block", or "log"
+	GuardrailInjectionAction string   // "redact", "block", or "log"
+	GuardrailBlockedTopics   []string // Keywords/phrases the agent must not discuss
+	GuardrailTopicAction     string   // "redact", "block", or "log"
+	// Instructions injected through rows and documents tools read
+	GuardrailToolInjectionAction     string // "sanitize", "approve", "block", or "log"
+	GuardrailToolInjectionClassifier bool   // Also ask the agent's model when the heuristics find nothing
+
+	// Agent run limits (overridable per request)
+	AgentMaxIterations    int
+	AgentIterationTimeout time.Duration
+	AgentRunTimeout       time.Duration
+	AgentUsageInterval    time.Duration // How often usage events are streamed during a run; 0 sends only the final one
+
+	// Agent prompts
+	AgentSchemaContextTokens int // Token budget of the schema summary added to system prompts; 0 disables it
+
+	// Conversation history
+	ConversationSessionsEnabled bool // Title and summarize conversations with the LLM after each turn for ListSessions
+
+	// Asynchronous agent jobs
+	AgentJobWorkers int // Workers processing queued agent jobs
+
+	// Background jobs
+	QueueWorkers      int           // Workers running jobs from the background_jobs queue
+	QueuePollInterval time.Duration // How often idle workers look for due jobs
+	QueueJobTimeout   time.Duration // Longest a background job may run before it is retried
+
+	// Agent tools
+	DisabledTools []string // Registered tools to disable at startup
+
+	// LLM provider resilience
+	LLMRetryAttempts       int           // Calls per model request including the first; 1 disables retries
+	LLMRetryInitialBackoff time.Duration // Wait before the first retry, doubled for each further one
+	LLMRetryMaxBackoff     time.Duration // Longest wait between retries
+	LLMBreakerFailures     int           // Consecutive transient failures that open a provider's circuit breaker; 0 disables it
+	LLMBreakerCooldown     time.Duration // How long an open breaker fails calls before trying the provider again
+	LLMFallbackProviders   []string      // Providers used, in order, while a provider's breaker is open
+
+	// Model routing
+	ModelRoutingEnabled bool // Route requests without a profile model to the cheapest model capable of their complexity
+
+	// Mock LLM provider
+	MockLLMFixture string // JSON script replayed by the "mock" provider; empty uses a fixed answer
+
+	// Profiling
+	DebugEndpoints       bool // Serve pprof profiles and runtime stats under /debug (admin only)
+	MutexProfileFraction int  // Sample 1/n mutex contention events; 0 disables
+	BlockProfileRate     int  // Sample blocking events lasting this many ns; 0 disables
+
+	// gRPC payload logging, to debug production traffic. Fields marked
+	// debug_redact in the proto are redacted.
+	PayloadLogSampleRate float64 // Share of RPCs whose request and response messages are logged, from 0 to 1
+	PayloadLogMaxSize    int64   // Logged payloads are truncated past this many bytes; 0 logs them whole
+
+	// Shutdown
+	ShutdownTimeout  time.Duration // How long in-flight requests and workers get to finish on shutdown
+	MigrationTimeout time.Duration // Longest the startup migrations may run
+
+	// Tracing, exported over OTLP when an endpoint is set
+	OTLPEndpoint       string  // Collector URL, such as http://otel-collector:4317
+	OTLPProtocol       string  // "grpc" or "http/protobuf"
+	TracingSampleRatio float64 // Share of new traces recorded, from 0 to 1
+	ServiceName        string  // service.name of exported spans
+
+	// Metrics
+	MetricsEnabled bool // Serve Prometheus metrics at /metrics, unauthenticated for scrapers
+
+	// Health
+	HealthLLMPing bool // /health calls each configured LLM provider rather than only checking its key
+
+	// Secrets managers: DATABASE_URL_* and LLM API keys may reference a
+	// secret (awssm://, gcpsm://, or vault://) instead of holding it
+	SecretsRefreshMinutes int // How often referenced secrets are fetched again; 0 disables
+
+	secrets    *Secrets
+	secretRefs map[string][]string // Env var -> secret references it held
+	malformed  []string            // Variables that did not parse, reported by Validate
+	settings   map[string]Setting  // Env var -> value and source, for introspection
 }
 
 // Load loads configuration from environment variables
 func Load() (*Config, error) {
 	// Load .env file if it exists
+	envFileKeys = envFileOnlyKeys()
 	_ = godotenv.Load()
+	malformedEnv = nil
+	loadedSettings = map[string]Setting{}
+
+	// Defaults such as authentication and rate limits follow the environment
+	environment := getEnv("ENVIRONMENT", "development")
+	profile := profileFor(environment)
 
 	config := &Config{
 		HTTPPort:          getEnv("HTTP_PORT", ":8080"),
 		GRPCPort:          getEnv("GO_API_PORT", ":50051"),
 		DatabaseURLPooled: getEnv("DATABASE_URL_POOLED", ""),
 		DatabaseURLDirect: getEnv("DATABASE_URL_DIRECT", ""),
-		Environment:       getEnv("ENVIRONMENT", "development"),
+		DBDriver:          getEnv("DB_DRIVER", "postgres"),
+		SQLitePath:        getEnv("SQLITE_PATH", "data/local.db"),
+		Environment:       environment,
 		OpenAIAPIKey:      getEnv("OPENAI_API_KEY", ""),
+		AnthropicAPIKey:   getEnv("ANTHROPIC_API_KEY", ""),
+		GoogleAPIKey:      getEnv("GOOGLE_API_KEY", ""),
 		LogLevel:          getEnv("LOG_LEVEL", "info"),
 		EnableCORS:        getEnv("ENABLE_CORS", "false") == "true",
+		EmbeddingProvider: getEnv("EMBEDDING_PROVIDER", "openai"),
+		EmbeddingModel:    getEnv("EMBEDDING_MODEL", ""),
+		EmbeddingBaseURL:  getEnv("EMBEDDING_BASE_URL", ""),
+		RAGTopK:           getEnvInt("RAG_TOP_K", 4),
+
+		EmbeddingBatchSize:         getEnvInt("EMBEDDING_BATCH_SIZE", 96),
+		EmbeddingRequestsPerMinute: getEnvInt("EMBEDDING_REQUESTS_PER_MINUTE", 0),
+		EmbeddingMaxRetries:        getEnvInt("EMBEDDING_MAX_RETRIES", 5),
+
+		HTTPReadHeaderTimeoutSeconds: getEnvInt("HTTP_READ_HEADER_TIMEOUT_SECONDS", 10),
+		HTTPReadTimeoutSeconds:       getEnvInt("HTTP_READ_TIMEOUT_SECONDS", 60),
+		HTTPWriteTimeoutSeconds:      getEnvInt("HTTP_WRITE_TIMEOUT_SECONDS", 120),
+		HTTPIdleTimeoutSeconds:       getEnvInt("HTTP_IDLE_TIMEOUT_SECONDS", 120),
+		HTTPMaxBodySize:              getEnvSize("HTTP_MAX_BODY_SIZE", int64(getEnvInt("HTTP_MAX_BODY_MB", 16))<<20),
+
+		TrustedProxies: getEnvList("TRUSTED_PROXIES"),
+
+		AdminUIEnabled: getEnv("ADMIN_UI_ENABLED", "false") == "true",
+		AdminUIDir:     getEnv("ADMIN_UI_DIR", ""),
+
+		DBStatementTimeout:         getEnvDuration("DB_STATEMENT_TIMEOUT", getEnvSeconds("DB_STATEMENT_TIMEOUT_SECONDS", 30)),
+		DBMaxConns:                 getEnvInt("DB_MAX_CONNS", 20),
+		DBMinConns:                 getEnvInt("DB_MIN_CONNS", 2),
+		DBMaxConnLifetimeMinutes:   getEnvInt("DB_MAX_CONN_LIFETIME_MINUTES", 60),
+		DBMaxConnIdleMinutes:       getEnvInt("DB_MAX_CONN_IDLE_MINUTES", 30),
+		DBHealthCheckPeriodSeconds: getEnvInt("DB_HEALTH_CHECK_PERIOD_SECONDS", 60),
+		DBConnectTimeout:           getEnvDuration("DB_CONNECT_TIMEOUT", getEnvSeconds("DB_CONNECT_TIMEOUT_SECONDS", 5)),
+		DBConnectAttempts:          getEnvInt("DB_CONNECT_ATTEMPTS", 5),
+		DBConnectMaxBackoffSeconds: getEnvInt("DB_CONNECT_MAX_BACKOFF_SECONDS", 30),
+		DBConnectInBackground:      getEnv("DB_CONNECT_IN_BACKGROUND", "true") == "true",
+		DBSlowQueryMS:              getEnvInt("DB_SLOW_QUERY_MS", 500),
+		DBReloadGraceSeconds:       getEnvInt("DB_RELOAD_GRACE_SECONDS", 30),
+		DBPoolStatsIntervalSeconds: getEnvInt("DB_POOL_STATS_INTERVAL_SECONDS", 15),
+		DBFailoverCheckSeconds:     getEnvInt("DB_FAILOVER_CHECK_SECONDS", 10),
+		DBFailoverThreshold:        getEnvInt("DB_FAILOVER_THRESHOLD", 3),
+		DBStatementCacheCapacity:   getEnvInt("DB_STATEMENT_CACHE_CAPACITY", 512),
+
+		DatabaseURLReplicas: getEnvList("DATABASE_URL_REPLICAS"),
+
+		GRPCMaxRecvMsgSizeMB:        getEnvInt("GRPC_MAX_RECV_MSG_SIZE_MB", 16),
+		GRPCMaxSendMsgSizeMB:        getEnvInt("GRPC_MAX_SEND_MSG_SIZE_MB", 16),
+		GRPCMaxConcurrentStreams:    getEnvInt("GRPC_MAX_CONCURRENT_STREAMS", 0),
+		GRPCKeepaliveTimeSeconds:    getEnvInt("GRPC_KEEPALIVE_TIME_SECONDS", 60),
+		GRPCKeepaliveTimeoutSeconds: getEnvInt("GRPC_KEEPALIVE_TIMEOUT_SECONDS", 20),
+		GRPCKeepaliveMinTimeSeconds: getEnvInt("GRPC_KEEPALIVE_MIN_TIME_SECONDS", 10),
+
+		AuditLogEnabled:    getEnv("AUDIT_LOG_ENABLED", "true") == "true",
+		AuditRetentionDays: getEnvInt("AUDIT_RETENTION_DAYS", 90),
+
+		TableAnalyticsEnabled:       getEnv("TABLE_ANALYTICS_ENABLED", "true") == "true",
+		TableAnalyticsRetentionDays: getEnvInt("TABLE_ANALYTICS_RETENTION_DAYS", 90),
+
+		AuditExportSinks:         getEnvList("AUDIT_EXPORT_SINKS"),
+		AuditExportSources:       getEnvListOr("AUDIT_EXPORT_SOURCES", "api", "schema"),
+		AuditExportActions:       getEnvList("AUDIT_EXPORT_ACTIONS"),
+		AuditExportInterval:      getEnvDuration("AUDIT_EXPORT_INTERVAL", time.Minute),
+		AuditExportBatchSize:     getEnvInt("AUDIT_EXPORT_BATCH_SIZE", 500),
+		AuditExportWebhookSecret: getEnv("AUDIT_EXPORT_WEBHOOK_SECRET", ""),
+		AuditExportS3Endpoint:    getEnv("AUDIT_EXPORT_S3_ENDPOINT", ""),
+
+		RateLimitEnabled:        getEnv("RATE_LIMIT_ENABLED", "true") == "true",
+		RateLimitReadPerMinute:  getEnvInt("RATE_LIMIT_READ_PER_MINUTE", profile.RateLimitReadPerMinute),
+		RateLimitReadBurst:      getEnvInt("RATE_LIMIT_READ_BURST", profile.RateLimitReadBurst),
+		RateLimitWritePerMinute: getEnvInt("RATE_LIMIT_WRITE_PER_MINUTE", profile.RateLimitWritePerMinute),
+		RateLimitWriteBurst:     getEnvInt("RATE_LIMIT_WRITE_BURST", profile.RateLimitWriteBurst),
+
+		GuardrailsEnabled:        getEnv("GUARDRAILS_ENABLED", "true") == "true",
+		GuardrailPIIAction:       getEnv("GUARDRAIL_PII_ACTION", "redact"),
+		GuardrailInjectionAction: getEnv("GUARDRAIL_INJECTION_ACTION", "block"),
+		GuardrailBlockedTopics:   getEnvList("GUARDRAIL_BLOCKED_TOPICS"),
+		GuardrailTopicAction:     getEnv("GUARDRAIL_TOPIC_ACTION", "block"),
+
+		GuardrailToolInjectionAction:     getEnv("GUARDRAIL_TOOL_INJECTION_ACTION", "sanitize"),
+		GuardrailToolInjectionClassifier: getEnv("GUARDRAIL_TOOL_INJECTION_CLASSIFIER", "false") == "true",
+
+		AgentMaxIterations:    getEnvInt("AGENT_MAX_ITERATIONS", 5),
+		AgentIterationTimeout: getEnvDuration("AGENT_ITERATION_TIMEOUT", getEnvSeconds("AGENT_ITERATION_TIMEOUT_SECONDS", 120)),
+		AgentRunTimeout:       getEnvDuration("AGENT_RUN_TIMEOUT", getEnvSeconds("AGENT_RUN_TIMEOUT_SECONDS", 300)),
+		AgentUsageInterval:    getEnvDuration("AGENT_USAGE_INTERVAL", 2*time.Second),
+
+		AgentSchemaContextTokens: getEnvInt("AGENT_SCHEMA_CONTEXT_TOKENS", 1500),
+
+		ConversationSessionsEnabled: getEnv("CONVERSATION_SESSIONS_ENABLED", "true") == "true",
+
+		AgentJobWorkers: getEnvInt("AGENT_JOB_WORKERS", 2),
+
+		QueueWorkers:      getEnvInt("QUEUE_WORKERS", 4),
+		QueuePollInterval: getEnvDuration("QUEUE_POLL_INTERVAL", 2*time.Second),
+		QueueJobTimeout:   getEnvDuration("QUEUE_JOB_TIMEOUT", 10*time.Minute),
+
+		DisabledTools: getEnvList("DISABLED_TOOLS"),
+
+		LLMRetryAttempts:       getEnvInt("LLM_RETRY_ATTEMPTS", 3),
+		LLMRetryInitialBackoff: getEnvDuration("LLM_RETRY_INITIAL_BACKOFF", 500*time.Millisecond),
+		LLMRetryMaxBackoff:     getEnvDuration("LLM_RETRY_MAX_BACKOFF", 10*time.Second),
+		LLMBreakerFailures:     getEnvInt("LLM_BREAKER_FAILURES", 5),
+		LLMBreakerCooldown:     getEnvDuration("LLM_BREAKER_COOLDOWN", 30*time.Second),
+		LLMFallbackProviders:   getEnvList("LLM_FALLBACK_PROVIDERS"),
+
+		ModelRoutingEnabled: getEnv("MODEL_ROUTING_ENABLED", "false") == "true",
+
+		MockLLMFixture: getEnv("MOCK_LLM_FIXTURE", ""),
+
+		DebugEndpoints:       getEnv("DEBUG_ENDPOINTS_ENABLED", boolString(profile.DebugEndpoints)) == "true",
+		MutexProfileFraction: getEnvInt("DEBUG_MUTEX_PROFILE_FRACTION", 0),
+		BlockProfileRate:     getEnvInt("DEBUG_BLOCK_PROFILE_RATE", 0),
+
+		PayloadLogSampleRate: getEnvFloat("GRPC_PAYLOAD_LOG_SAMPLE_RATE", 0),
+		PayloadLogMaxSize:    getEnvSize("GRPC_PAYLOAD_LOG_MAX_SIZE", 4<<10),
+
+		ShutdownTimeout:  getEnvDuration("SHUTDOWN_TIMEOUT", 30*time.Second),
+		MigrationTimeout: getEnvDuration("MIGRATION_TIMEOUT", 30*time.Second),
+
+		OTLPEndpoint:       getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", ""),
+		OTLPProtocol:       getEnv("OTEL_EXPORTER_OTLP_PROTOCOL", "grpc"),
+		TracingSampleRatio: getEnvFloat("OTEL_TRACES_SAMPLER_ARG", 1),
+		ServiceName:        getEnv("OTEL_SERVICE_NAME", "agentic-template-api"),
+
+		MetricsEnabled: getEnv("METRICS_ENABLED", "false") == "true",
+
+		HealthLLMPing: getEnv("HEALTH_LLM_PING", "false") == "true",
+	}
+
+	config.GRPCReflection = getEnv("GRPC_REFLECTION", boolString(profile.GRPCReflection)) == "true"
+	config.GinMode = getEnv("GIN_MODE", profile.GinMode)
+	config.TLSCertFile = getEnv("TLS_CERT_FILE", "")
+	config.TLSKeyFile = getEnv("TLS_KEY_FILE", "")
+	config.TLSTerminatedUpstream = getEnv("TLS_TERMINATED_UPSTREAM", "false") == "true"
+	config.AllowInsecureProduction = getEnv("ALLOW_INSECURE_PRODUCTION", "false") == "true"
+
+	config.AuthEnabled = getEnv("AUTH_ENABLED", boolString(profile.AuthEnabled)) == "true"
+	config.GRPCCompression = getEnvListOr("GRPC_COMPRESSION", "zstd", "gzip")
+	config.CORSAllowedOrigins = getEnvListOr("CORS_ALLOWED_ORIGINS", "http://localhost:3000")
+	config.CORSAllowedMethods = getEnvListOr("CORS_ALLOWED_METHODS", "GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS")
+	config.CORSAllowedHeaders = getEnvListOr("CORS_ALLOWED_HEADERS", "Authorization", "Content-Type", "X-API-Key", "X-Request-ID")
+	config.CORSAllowCredentials = getEnv("CORS_ALLOW_CREDENTIALS", "false") == "true"
+	config.CORSMaxAgeSeconds = getEnvInt("CORS_MAX_AGE_SECONDS", 600)
+	config.APIKeys = parseAPIKeys(getEnvList("API_KEYS"))
+	config.APIKeyRoles = parseAPIKeyRoles(getEnvList("API_KEY_ROLES"))
+	config.DefaultRole = getEnv("AUTHZ_DEFAULT_ROLE", "viewer")
+	config.JWTSecret = getEnv("JWT_SECRET", "")
+	config.JWTIssuer = getEnv("JWT_ISSUER", "")
+
+	config.SignupEnabled = getEnv("AUTH_SIGNUP_ENABLED", boolString(profile.SignupEnabled)) == "true"
+	config.JWTAccessTTLMinutes = getEnvInt("JWT_ACCESS_TTL_MINUTES", 15)
+	config.JWTRefreshTTLHours = getEnvInt("JWT_REFRESH_TTL_HOURS", 720)
+
+	config.TenancyEnabled = getEnv("TENANCY_ENABLED", "false") == "true"
+	config.APIKeyTenants = parseAPIKeyTenants(getEnvList("API_KEY_TENANTS"))
+	config.IdentifierTablePrefix = getEnv("IDENTIFIER_TABLE_PREFIX", "user_table_")
+	config.IdentifierCase = getEnv("IDENTIFIER_CASE", "lower")
+	config.IdentifierTruncation = getEnv("IDENTIFIER_TRUNCATION", "cut")
+	config.IdentifierMaxLength = getEnvInt("IDENTIFIER_MAX_LENGTH", 63)
+	config.TableTrashEnabled = getEnv("TABLE_TRASH_ENABLED", "true") == "true"
+	config.TableTrashRetention = getEnvDuration("TABLE_TRASH_RETENTION", 7*24*time.Hour)
+	config.SchemaChangeApproval = getEnv("SCHEMA_CHANGE_APPROVAL", "false") == "true"
+	config.SchemaSandboxEnabled = getEnv("SCHEMA_SANDBOX_ENABLED", "false") == "true"
+	config.ImportSources = parseNamedURLs(getEnvList("IMPORT_SOURCES"))
+	config.SyncDestinations = parseNamedURLs(getEnvList("SYNC_DESTINATIONS"))
+	config.SyncAlertWebhook = getEnv("SYNC_ALERT_WEBHOOK", "")
+	config.SyncAlertFailures = getEnvInt("SYNC_ALERT_FAILURES", 3)
+	config.QuotaMaxTables = getEnvInt("QUOTA_MAX_TABLES", 0)
+	config.QuotaMaxColumnsPerTable = getEnvInt("QUOTA_MAX_COLUMNS_PER_TABLE", 0)
+	config.QuotaMaxRowsPerTable = getEnvInt("QUOTA_MAX_ROWS_PER_TABLE", 0)
+	config.SecretsRefreshMinutes = getEnvInt("SECRETS_REFRESH_MINUTES", 15)
+	config.MigrationsDir = getEnv("MIGRATIONS_DIR", "")
+	config.MigrationsAllowOutOfOrder = getEnv("MIGRATIONS_ALLOW_OUT_OF_ORDER", "false") == "true"
+	config.EmbeddingDimensions = getEnvInt("EMBEDDING_DIMENSIONS", defaultEmbeddingDimensions(config.EmbeddingProvider, config.EmbeddingModel))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if err := config.resolveSecrets(ctx); err != nil {
+		return nil, fmt.Errorf("failed to resolve secrets: %w", err)
 	}
+	config.malformed = malformedEnv
+	config.settings = loadedSettings
 
 	return config, nil
 }
 
+// defaultEmbeddingDimensions returns the vector size of the provider's
+// default embedding model. Other models need EMBEDDING_DIMENSIONS.
+func defaultEmbeddingDimensions(provider, model string) int {
+	if model == "" && strings.EqualFold(provider, "ollama") {
+		return 768 // nomic-embed-text
+	}
+	return 1536 // text-embedding-3-small
+}
+
+// parseAPIKeys parses "name:key" entries into a key -> name map. A bare key
+// is named after its position.
+func parseAPIKeys(entries []string) map[string]string {
+	keys := make(map[string]string, len(entries))
+	for i, entry := range entries {
+		name, key, found := strings.Cut(entry, ":")
+		if !found {
+			name, key = "api-key-"+strconv.Itoa(i+1), entry
+		}
+		if key = strings.TrimSpace(key); key != "" {
+			keys[key] = strings.TrimSpace(name)
+		}
+	}
+	return keys
+}
+
+// parseAPIKeyRoles parses "name:role" entries into a name -> roles map.
+// A key name may be listed once per role.
+func parseAPIKeyRoles(entries []string) map[string][]string {
+	roles := make(map[string][]string, len(entries))
+	for _, entry := range entries {
+		name, role, found := strings.Cut(entry, ":")
+		if !found {
+			continue
+		}
+		name, role = strings.TrimSpace(name), strings.TrimSpace(role)
+		if name != "" && role != "" {
+			roles[name] = append(roles[name], role)
+		}
+	}
+	return roles
+}
+
+// parseAPIKeyTenants parses "name:tenant" entries into a name -> tenant map
+func parseAPIKeyTenants(entries []string) map[string]string {
+	tenants := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		name, tenant, found := strings.Cut(entry, ":")
+		if !found {
+			continue
+		}
+		name, tenant = strings.TrimSpace(name), strings.TrimSpace(tenant)
+		if name != "" && tenant != "" {
+			tenants[name] = tenant
+		}
+	}
+	return tenants
+}
+
+// parseNamedURLs parses "name=url" entries into a name -> URL map
+func parseNamedURLs(entries []string) map[string]string {
+	urls := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		name, rawURL, found := strings.Cut(entry, "=")
+		if !found {
+			continue
+		}
+		name, rawURL = strings.TrimSpace(name), strings.TrimSpace(rawURL)
+		if name != "" && rawURL != "" {
+			urls[name] = rawURL
+		}
+	}
+	return urls
+}
+
 // getEnv gets an environment variable with a fallback value
 func getEnv(key, fallback string) string {
-	if value := os.Getenv(key); value != "" {
+	if value := lookupEnv(key, fallback); value != "" {
 		return value
 	}
 	return fallback
-}
\ No newline at end of file
+}
+
+// getEnvInt gets an integer environment variable with a fallback value
+func getEnvInt(key string, fallback int) int {
+	if value := lookupEnv(key, strconv.Itoa(fallback)); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err == nil {
+			return parsed
+		}
+		malformedEnv = append(malformedEnv, fmt.Sprintf("%s %q is not an integer", key, value))
+	}
+	return fallback
+}
+
+// getEnvFloat gets a floating-point environment variable with a fallback
+// value
+func getEnvFloat(key string, fallback float64) float64 {
+	if value := lookupEnv(key, strconv.FormatFloat(fallback, 'g', -1, 64)); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err == nil {
+			return parsed
+		}
+		malformedEnv = append(malformedEnv, fmt.Sprintf("%s %q is not a number", key, value))
+	}
+	return fallback
+}
+
+// getEnvSeconds gets an integer environment variable counting seconds. It
+// reads the *_SECONDS variables that predate duration values.
+func getEnvSeconds(key string, fallback int) time.Duration {
+	return time.Duration(getEnvInt(key, fallback)) * time.Second
+}
+
+// getEnvDuration gets a duration environment variable such as "30s",
+// "1m30s", or "500ms" with a fallback value
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	if value := lookupEnv(key, fallback.String()); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err == nil {
+			return parsed
+		}
+		malformedEnv = append(malformedEnv, fmt.Sprintf("%s %q is not a duration such as 30s or 5m", key, value))
+	}
+	return fallback
+}
+
+// getEnvSize gets a size environment variable such as "10MB" or "512KB" in
+// bytes with a fallback value
+func getEnvSize(key string, fallback int64) int64 {
+	if value := lookupEnv(key, strconv.FormatInt(fallback, 10)); value != "" {
+		parsed, err := ParseSize(value)
+		if err == nil {
+			return parsed
+		}
+		malformedEnv = append(malformedEnv, fmt.Sprintf("%s %q is not a size such as 10MB or 512KB", key, value))
+	}
+	return fallback
+}
+
+// sizeUnits are the size suffixes ParseSize accepts, longest first
+var sizeUnits = []struct {
+	suffix string
+	bytes  int64
+}{
+	{"KIB", 1 << 10}, {"MIB", 1 << 20}, {"GIB", 1 << 30},
+	{"KB", 1 << 10}, {"MB", 1 << 20}, {"GB", 1 << 30},
+	{"K", 1 << 10}, {"M", 1 << 20}, {"G", 1 << 30},
+	{"B", 1},
+}
+
+// ParseSize parses a byte size such as "10MB", "512KiB", or "1048576".
+// Units are case-insensitive powers of 1024, so 1MB and 1MiB are the same.
+func ParseSize(value string) (int64, error) {
+	number, multiplier := strings.ToUpper(strings.TrimSpace(value)), int64(1)
+	for _, unit := range sizeUnits {
+		if trimmed, found := strings.CutSuffix(number, unit.suffix); found {
+			number, multiplier = strings.TrimSpace(trimmed), unit.bytes
+			break
+		}
+	}
+	n, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || n < 0 || n > math.MaxInt64/multiplier {
+		return 0, fmt.Errorf("invalid size %q", value)
+	}
+	return n * multiplier, nil
+}
+
+// getEnvList gets a comma-separated environment variable as a list
+func getEnvList(key string) []string {
+	return splitList(lookupEnv(key, ""))
+}
+
+// getEnvListOr gets a comma-separated environment variable as a list, or
+// the fallback values when it is unset or empty
+func getEnvListOr(key string, fallback ...string) []string {
+	if values := splitList(lookupEnv(key, strings.Join(fallback, ","))); len(values) > 0 {
+		return values
+	}
+	return fallback
+}
+
+// splitList splits a comma-separated value, dropping empty entries
+func splitList(value string) []string {
+	var values []string
+	for _, entry := range strings.Split(value, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			values = append(values, entry)
+		}
+	}
+	return values
+}
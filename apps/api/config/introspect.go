@@ -0,0 +1,131 @@
+package config
+
+import (
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/joho/godotenv"
+)
+
+// Source tells where a setting's value came from
+type Source string
+
+const (
+	SourceEnv     Source = "env"     // The process environment
+	SourceFile    Source = "file"    // The .env file
+	SourceDefault Source = "default" // Unset, so the built-in default applies
+)
+
+// redactedValue replaces secrets in introspection output
+const redactedValue = "[redacted]"
+
+// Setting is the effective value of one environment variable
+type Setting struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Source   Source `json:"source"`
+	Redacted bool   `json:"redacted,omitempty"`
+}
+
+var (
+	// envFileKeys are the variables set by the .env file rather than the
+	// process environment, which godotenv.Load does not override
+	envFileKeys map[string]bool
+	// loadedSettings records every variable read while Load runs
+	loadedSettings map[string]Setting
+)
+
+// envFileOnlyKeys returns the variables the .env file will set
+func envFileOnlyKeys() map[string]bool {
+	keys := map[string]bool{}
+	values, err := godotenv.Read()
+	if err != nil {
+		return keys
+	}
+	for key := range values {
+		if _, set := os.LookupEnv(key); !set {
+			keys[key] = true
+		}
+	}
+	return keys
+}
+
+// lookupEnv reads an environment variable, recording its value and source
+// for Settings. fallback is what an unset variable is reported as.
+func lookupEnv(key, fallback string) string {
+	value := os.Getenv(key)
+	if loadedSettings != nil {
+		setting := Setting{Name: key, Value: value, Source: SourceEnv}
+		switch {
+		case value == "":
+			setting.Value, setting.Source = fallback, SourceDefault
+		case envFileKeys[key]:
+			setting.Source = SourceFile
+		}
+		loadedSettings[key] = setting
+	}
+	return value
+}
+
+// Settings returns the environment variables the configuration was loaded
+// from, sorted by name, with their effective values and sources. API keys,
+// tokens, and passwords are redacted, as are the passwords of database
+// URLs; secret references are shown as they are.
+func (c *Config) Settings() []Setting {
+	settings := make([]Setting, 0, len(c.settings))
+	for _, setting := range c.settings {
+		settings = append(settings, c.redact(setting))
+	}
+	sort.Slice(settings, func(i, j int) bool { return settings[i].Name < settings[j].Name })
+	return settings
+}
+
+// redact hides the secret parts of a setting
+func (c *Config) redact(setting Setting) Setting {
+	if setting.Value == "" || (c.secrets != nil && c.secrets.IsReference(setting.Value)) {
+		return setting
+	}
+	switch {
+	case isSecretName(setting.Name):
+		setting.Value, setting.Redacted = redactedValue, true
+	case strings.HasPrefix(setting.Name, "DATABASE_URL_") || strings.HasSuffix(setting.Name, "_URL") || strings.HasSuffix(setting.Name, "_SINKS") || strings.HasSuffix(setting.Name, "_SOURCES") || strings.HasSuffix(setting.Name, "_DESTINATIONS"):
+		redacted := redactURLs(setting.Value)
+		setting.Redacted = redacted != setting.Value
+		setting.Value = redacted
+	}
+	return setting
+}
+
+// isSecretName reports whether a variable holds a credential by its name
+func isSecretName(name string) bool {
+	for _, suffix := range []string{"_KEY", "_KEYS", "_SECRET", "_TOKEN", "_PASSWORD", "_WEBHOOK"} {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// dsnPassword matches the password of a keyword/value DSN
+var dsnPassword = regexp.MustCompile(`password=('[^']*'|\S+)`)
+
+// redactURLs hides the passwords of a comma-separated list of URLs or
+// keyword/value DSNs. URLs may be named by a "name=" prefix.
+func redactURLs(value string) string {
+	entries := strings.Split(value, ",")
+	for i, entry := range entries {
+		name, rawURL := "", strings.TrimSpace(entry)
+		if before, after, found := strings.Cut(rawURL, "="); found && !strings.Contains(before, "://") && strings.Contains(after, "://") {
+			name, rawURL = before+"=", after
+		}
+		if u, err := url.Parse(rawURL); err == nil && u.Scheme != "" {
+			entries[i] = name + u.Redacted()
+			continue
+		}
+		entries[i] = dsnPassword.ReplaceAllString(entry, "password="+redactedValue)
+	}
+	return strings.Join(entries, ",")
+}
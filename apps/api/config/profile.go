@@ -0,0 +1,87 @@
+package config
+
+import (
+	"slices"
+	"strconv"
+)
+
+// Profile holds the defaults of one environment. Every value can still be
+// overridden by its environment variable.
+type Profile struct {
+	Name           string
+	GinMode        string // "debug" logs routes and warnings; "release" is quiet
+	GRPCReflection bool
+	AuthEnabled    bool
+	SignupEnabled  bool
+	DebugEndpoints bool
+
+	RateLimitReadPerMinute  int
+	RateLimitReadBurst      int
+	RateLimitWritePerMinute int
+	RateLimitWriteBurst     int
+
+	// RequireSecureTransport refuses to start without authentication and
+	// TLS, served or terminated upstream, unless ALLOW_INSECURE_PRODUCTION
+	// is set
+	RequireSecureTransport bool
+}
+
+// profiles are the supported environments. Staging runs like production
+// but keeps reflection for debugging and may start without TLS.
+var profiles = map[string]Profile{
+	"development": {
+		Name:                    "development",
+		GinMode:                 "debug",
+		GRPCReflection:          true,
+		SignupEnabled:           true,
+		DebugEndpoints:          true,
+		RateLimitReadPerMinute:  600,
+		RateLimitReadBurst:      100,
+		RateLimitWritePerMinute: 30,
+		RateLimitWriteBurst:     10,
+	},
+	"staging": {
+		Name:                    "staging",
+		GinMode:                 "release",
+		GRPCReflection:          true,
+		AuthEnabled:             true,
+		RateLimitReadPerMinute:  600,
+		RateLimitReadBurst:      100,
+		RateLimitWritePerMinute: 30,
+		RateLimitWriteBurst:     10,
+	},
+	"production": {
+		Name:                    "production",
+		GinMode:                 "release",
+		AuthEnabled:             true,
+		RateLimitReadPerMinute:  300,
+		RateLimitReadBurst:      50,
+		RateLimitWritePerMinute: 20,
+		RateLimitWriteBurst:     5,
+		RequireSecureTransport:  true,
+	},
+}
+
+// ProfileNames lists the supported environments
+func ProfileNames() []string {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// profileFor returns the profile of an environment. Unknown environments
+// get the development profile; Validate reports them.
+func profileFor(environment string) Profile {
+	if profile, ok := profiles[environment]; ok {
+		return profile
+	}
+	return profiles["development"]
+}
+
+// boolString formats a profile default for getEnv
+func boolString(value bool) string {
+	return strconv.FormatBool(value)
+}
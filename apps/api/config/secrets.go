@@ -0,0 +1,283 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"agentic-template/api/awsauth"
+)
+
+// secretFetchTimeout bounds each call to a secrets manager
+const secretFetchTimeout = 10 * time.Second
+
+// SecretProvider fetches secrets from a secrets manager by name
+type SecretProvider interface {
+	Fetch(ctx context.Context, name string) (string, error)
+}
+
+// Secrets resolves configuration values that reference a secrets manager
+// instead of holding the secret itself. A reference looks like
+//
+//	awssm://<secret id>[#<json key>]
+//	gcpsm://[projects/<project>/secrets/]<secret>[/versions/<version>][#<json key>]
+//	vault://<path>#<json key>
+//
+// where the optional key picks a field of a secret holding a JSON object.
+// Values without a known scheme are used as they are. Resolved values are
+// cached until Refresh fetches them again.
+type Secrets struct {
+	providers map[string]SecretProvider // scheme -> provider
+
+	mu     sync.RWMutex
+	values map[string]string // reference -> resolved value
+}
+
+// NewSecrets creates a resolver for the given scheme -> provider map
+func NewSecrets(providers map[string]SecretProvider) *Secrets {
+	return &Secrets{providers: providers, values: map[string]string{}}
+}
+
+// newSecretsFromEnv creates a resolver for AWS Secrets Manager, GCP Secret
+// Manager, and Vault, each configured from its usual environment variables
+func newSecretsFromEnv() *Secrets {
+	client := &http.Client{Timeout: secretFetchTimeout}
+	return NewSecrets(map[string]SecretProvider{
+		"awssm": &AWSSecretsManager{
+			Credentials: awsauth.NewProvider(client),
+			HTTPClient:  client,
+		},
+		"gcpsm": &GCPSecretManager{
+			Project:     getEnv("GOOGLE_CLOUD_PROJECT", os.Getenv("GCP_PROJECT")),
+			AccessToken: os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"),
+			HTTPClient:  client,
+		},
+		"vault": &Vault{
+			Address:    os.Getenv("VAULT_ADDR"),
+			Token:      os.Getenv("VAULT_TOKEN"),
+			Namespace:  os.Getenv("VAULT_NAMESPACE"),
+			HTTPClient: client,
+		},
+	})
+}
+
+// IsReference reports whether a value references a secret of a known
+// provider
+func (s *Secrets) IsReference(value string) bool {
+	scheme, _, found := strings.Cut(value, "://")
+	if !found {
+		return false
+	}
+	_, known := s.providers[scheme]
+	return known
+}
+
+// Resolve returns the secret a value references, or the value itself when
+// it is not a reference
+func (s *Secrets) Resolve(ctx context.Context, value string) (string, error) {
+	if !s.IsReference(value) {
+		return value, nil
+	}
+
+	s.mu.RLock()
+	resolved, cached := s.values[value]
+	s.mu.RUnlock()
+	if cached {
+		return resolved, nil
+	}
+
+	resolved, err := s.fetch(ctx, value)
+	if err != nil {
+		return "", err
+	}
+	s.mu.Lock()
+	s.values[value] = resolved
+	s.mu.Unlock()
+	return resolved, nil
+}
+
+// Current returns the latest resolved value of a reference, or the value
+// itself when it is not a reference or has not been resolved
+func (s *Secrets) Current(value string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if resolved, cached := s.values[value]; cached {
+		return resolved
+	}
+	return value
+}
+
+// Refresh fetches every resolved reference again and returns those whose
+// value changed. References that fail to fetch keep their last value.
+func (s *Secrets) Refresh(ctx context.Context) []string {
+	s.mu.RLock()
+	refs := make([]string, 0, len(s.values))
+	for ref := range s.values {
+		refs = append(refs, ref)
+	}
+	s.mu.RUnlock()
+
+	var changed []string
+	for _, ref := range refs {
+		value, err := s.fetch(ctx, ref)
+		if err != nil {
+			log.Printf("Warning: failed to refresh secret %s: %v", ref, err)
+			continue
+		}
+		s.mu.Lock()
+		if s.values[ref] != value {
+			s.values[ref] = value
+			changed = append(changed, ref)
+		}
+		s.mu.Unlock()
+	}
+	return changed
+}
+
+// fetch reads a reference from its provider, picking the JSON key if the
+// reference names one
+func (s *Secrets) fetch(ctx context.Context, ref string) (string, error) {
+	scheme, rest, _ := strings.Cut(ref, "://")
+	name, key, hasKey := strings.Cut(rest, "#")
+	if name == "" {
+		return "", fmt.Errorf("secret reference %s names no secret", ref)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, secretFetchTimeout)
+	defer cancel()
+	value, err := s.providers[scheme].Fetch(ctx, name)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch secret %s: %w", ref, err)
+	}
+	if !hasKey {
+		return value, nil
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(value), &fields); err != nil {
+		return "", fmt.Errorf("secret %s is not a JSON object: %w", ref, err)
+	}
+	field, ok := fields[key]
+	if !ok {
+		return "", fmt.Errorf("secret %s has no key %q", ref, key)
+	}
+	if str, ok := field.(string); ok {
+		return str, nil
+	}
+	return fmt.Sprint(field), nil
+}
+
+// resolveSecrets replaces secret references in the secret-bearing fields
+// with their values, remembering which environment variable held which
+// reference so WatchSecrets can report rotations
+func (c *Config) resolveSecrets(ctx context.Context) error {
+	c.secrets = newSecretsFromEnv()
+	c.secretRefs = map[string][]string{}
+
+	fields := map[string]*string{
+		"DATABASE_URL_POOLED": &c.DatabaseURLPooled,
+		"DATABASE_URL_DIRECT": &c.DatabaseURLDirect,
+		"OPENAI_API_KEY":      &c.OpenAIAPIKey,
+		"ANTHROPIC_API_KEY":   &c.AnthropicAPIKey,
+		"GOOGLE_API_KEY":      &c.GoogleAPIKey,
+	}
+	for envVar, field := range fields {
+		if err := c.resolveSecret(ctx, envVar, field); err != nil {
+			return err
+		}
+	}
+	for i := range c.DatabaseURLReplicas {
+		if err := c.resolveSecret(ctx, "DATABASE_URL_REPLICAS", &c.DatabaseURLReplicas[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolveSecret resolves one field in place
+func (c *Config) resolveSecret(ctx context.Context, envVar string, field *string) error {
+	if !c.secrets.IsReference(*field) {
+		return nil
+	}
+	ref := *field
+	value, err := c.secrets.Resolve(ctx, ref)
+	if err != nil {
+		return fmt.Errorf("%s: %w", envVar, err)
+	}
+	*field = value
+	c.secretRefs[envVar] = append(c.secretRefs[envVar], ref)
+	return nil
+}
+
+// ResolveSecret resolves a value that may reference a secret, such as a
+// database URL re-read from the environment on reload
+func (c *Config) ResolveSecret(ctx context.Context, value string) (string, error) {
+	if c.secrets == nil {
+		return value, nil
+	}
+	return c.secrets.Resolve(ctx, value)
+}
+
+// APIKey returns the current API key of an LLM provider, following
+// rotations of keys held in a secrets manager
+func (c *Config) APIKey(provider string) string {
+	var envVar, key string
+	switch strings.ToLower(provider) {
+	case "openai":
+		envVar, key = "OPENAI_API_KEY", c.OpenAIAPIKey
+	case "anthropic":
+		envVar, key = "ANTHROPIC_API_KEY", c.AnthropicAPIKey
+	case "google":
+		envVar, key = "GOOGLE_API_KEY", c.GoogleAPIKey
+	default:
+		return ""
+	}
+	if refs := c.secretRefs[envVar]; len(refs) > 0 {
+		return c.secrets.Current(refs[0])
+	}
+	return key
+}
+
+// WatchSecrets refreshes referenced secrets every SecretsRefreshMinutes
+// until ctx is done, calling onChange with the environment variables whose
+// secret changed. It returns at once when no secrets are referenced or
+// refreshing is disabled.
+func (c *Config) WatchSecrets(ctx context.Context, onChange func(envVars []string)) {
+	if len(c.secretRefs) == 0 || c.SecretsRefreshMinutes <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(time.Duration(c.SecretsRefreshMinutes) * time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		changed := map[string]bool{}
+		for _, ref := range c.secrets.Refresh(ctx) {
+			changed[ref] = true
+		}
+		var envVars []string
+		for envVar, refs := range c.secretRefs {
+			for _, ref := range refs {
+				if changed[ref] {
+					envVars = append(envVars, envVar)
+					break
+				}
+			}
+		}
+		if len(envVars) > 0 {
+			log.Printf("Rotated secrets: %s", strings.Join(envVars, ", "))
+			onChange(envVars)
+		}
+	}
+}
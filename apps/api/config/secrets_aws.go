@@ -0,0 +1,77 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"agentic-template/api/awsauth"
+)
+
+// AWSSecretsManager fetches secrets from AWS Secrets Manager, signing
+// requests with Signature Version 4 and credentials from the default chain
+// (static keys, web identity, container, or instance profile)
+type AWSSecretsManager struct {
+	Credentials *awsauth.Provider
+	Endpoint    string // Overrides the regional endpoint, e.g. for LocalStack
+	HTTPClient  *http.Client
+}
+
+// Statically assert that AWSSecretsManager implements the provider interface
+var _ SecretProvider = &AWSSecretsManager{}
+
+// Fetch returns the SecretString of the current version of a secret
+func (a *AWSSecretsManager) Fetch(ctx context.Context, name string) (string, error) {
+	if a.Credentials.Region == "" {
+		return "", fmt.Errorf("AWS secrets need AWS_REGION")
+	}
+	creds, err := a.Credentials.Retrieve(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	endpoint := a.Endpoint
+	if endpoint == "" {
+		endpoint = "https://secretsmanager." + creds.Region + ".amazonaws.com"
+	}
+	body, err := json.Marshal(map[string]string{"SecretId": name})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint+"/", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	awsauth.Sign(req, body, "secretsmanager", creds, time.Now())
+
+	resp, err := a.HTTPClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("secrets manager returned %s: %s", resp.Status, strings.TrimSpace(string(respBody)))
+	}
+
+	var result struct {
+		SecretString *string `json:"SecretString"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return "", fmt.Errorf("failed to decode secret: %w", err)
+	}
+	if result.SecretString == nil {
+		return "", fmt.Errorf("secret holds binary data, not a string")
+	}
+	return *result.SecretString, nil
+}
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// gcpMetadataTokenURL serves access tokens of the attached service account
+// on GCE, GKE, Cloud Run, and App Engine
+const gcpMetadataTokenURL = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+
+// GCPSecretManager fetches secrets from Google Cloud Secret Manager. It
+// authenticates with AccessToken when set, otherwise with the metadata
+// server's service account token.
+type GCPSecretManager struct {
+	Project     string // Project of secrets named without one
+	AccessToken string
+	HTTPClient  *http.Client
+
+	mu          sync.Mutex
+	token       string
+	tokenExpiry time.Time
+}
+
+// Statically assert that GCPSecretManager implements the provider interface
+var _ SecretProvider = &GCPSecretManager{}
+
+// Fetch returns a secret version, the latest unless the name gives one
+func (g *GCPSecretManager) Fetch(ctx context.Context, name string) (string, error) {
+	if !strings.HasPrefix(name, "projects/") {
+		if g.Project == "" {
+			return "", fmt.Errorf("GCP secret %q names no project and GOOGLE_CLOUD_PROJECT is not set", name)
+		}
+		name = "projects/" + g.Project + "/secrets/" + name
+	}
+	if !strings.Contains(name, "/versions/") {
+		name += "/versions/latest"
+	}
+
+	token, err := g.accessToken(ctx)
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://secretmanager.googleapis.com/v1/"+name+":access", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	var result struct {
+		Payload struct {
+			Data string `json:"data"`
+		} `json:"payload"`
+	}
+	if err := getJSON(g.HTTPClient, req, &result); err != nil {
+		return "", err
+	}
+	data, err := base64.StdEncoding.DecodeString(result.Payload.Data)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode secret payload: %w", err)
+	}
+	return string(data), nil
+}
+
+// accessToken returns the configured token or a cached metadata server
+// token, renewing it a minute before it expires
+func (g *GCPSecretManager) accessToken(ctx context.Context) (string, error) {
+	if g.AccessToken != "" {
+		return g.AccessToken, nil
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.token != "" && time.Now().Before(g.tokenExpiry) {
+		return g.token, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, gcpMetadataTokenURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	var result struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := getJSON(g.HTTPClient, req, &result); err != nil {
+		return "", fmt.Errorf("failed to get a GCP access token (set GOOGLE_OAUTH_ACCESS_TOKEN outside GCP): %w", err)
+	}
+	g.token = result.AccessToken
+	g.tokenExpiry = time.Now().Add(time.Duration(result.ExpiresIn)*time.Second - time.Minute)
+	return g.token, nil
+}
+
+// getJSON sends a request and decodes a successful JSON response
+func getJSON(client *http.Client, req *http.Request, result any) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %s: %s", req.URL.Host, resp.Status, strings.TrimSpace(string(body)))
+	}
+	if err := json.Unmarshal(body, result); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
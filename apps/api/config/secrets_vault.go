@@ -0,0 +1,56 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Vault reads secrets from HashiCorp Vault with a token. Paths are API
+// paths, so KV version 2 secrets include the data segment, e.g.
+// vault://secret/data/api#database_url.
+type Vault struct {
+	Address    string
+	Token      string
+	Namespace  string // Vault Enterprise namespace, if any
+	HTTPClient *http.Client
+}
+
+// Statically assert that Vault implements the provider interface
+var _ SecretProvider = &Vault{}
+
+// Fetch returns the secret's fields as a JSON object, unwrapping the
+// metadata envelope of KV version 2
+func (v *Vault) Fetch(ctx context.Context, path string) (string, error) {
+	if v.Address == "" || v.Token == "" {
+		return "", fmt.Errorf("vault secrets need VAULT_ADDR and VAULT_TOKEN")
+	}
+
+	url := strings.TrimSuffix(v.Address, "/") + "/v1/" + strings.TrimPrefix(path, "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", v.Token)
+	if v.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.Namespace)
+	}
+
+	var result struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	if err := getJSON(v.HTTPClient, req, &result); err != nil {
+		return "", err
+	}
+	data := result.Data
+	if nested, ok := data["data"]; ok && data["metadata"] != nil {
+		return string(nested), nil
+	}
+	fields, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+	return string(fields), nil
+}
@@ -0,0 +1,310 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ValidationError lists every problem found in a configuration
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%d configuration problem(s): %s", len(e.Problems), strings.Join(e.Problems, "; "))
+}
+
+// malformedEnv collects the integer, duration, and size variables that did
+// not parse while Load runs, so Validate can report them instead of their
+// fallbacks
+var malformedEnv []string
+
+// validator accumulates problems
+type validator struct {
+	problems []string
+}
+
+func (v *validator) addf(format string, args ...any) {
+	v.problems = append(v.problems, fmt.Sprintf(format, args...))
+}
+
+// Validate checks required settings, listen addresses, URLs, numeric ranges,
+// and enumerated values, returning a ValidationError with every problem
+// found so they can all be fixed at once
+func (c *Config) Validate() error {
+	v := &validator{}
+	for _, problem := range c.malformed {
+		v.addf("%s", problem)
+	}
+
+	if _, ok := profiles[c.Environment]; !ok {
+		v.addf("ENVIRONMENT %q must be one of %s", c.Environment, strings.Join(ProfileNames(), ", "))
+	}
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		v.addf("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+	if profileFor(c.Environment).RequireSecureTransport && !c.AllowInsecureProduction {
+		if !c.AuthEnabled {
+			v.addf("AUTH_ENABLED must be true in %s (set ALLOW_INSECURE_PRODUCTION=true to override)", c.Environment)
+		} else if len(c.APIKeys) == 0 && c.JWTSecret == "" {
+			v.addf("API_KEYS or JWT_SECRET is required in %s (set ALLOW_INSECURE_PRODUCTION=true to override)", c.Environment)
+		}
+		if c.TLSCertFile == "" && !c.TLSTerminatedUpstream {
+			v.addf("TLS_CERT_FILE and TLS_KEY_FILE, or TLS_TERMINATED_UPSTREAM=true, are required in %s (set ALLOW_INSECURE_PRODUCTION=true to override)", c.Environment)
+		}
+	}
+	// The SQLite file database is for running locally without PostgreSQL
+	v.oneOf("DB_DRIVER", c.DBDriver, "postgres", "sqlite")
+	if c.DBDriver == "sqlite" {
+		if c.Environment == "production" {
+			v.addf("DB_DRIVER=sqlite is for local development and can't be used in production")
+		}
+		if c.SQLitePath == "" {
+			v.addf("SQLITE_PATH is required when DB_DRIVER=sqlite")
+		}
+	}
+
+	// A database is only optional outside production, where it can be
+	// configured later through the environment settings
+	if c.DatabaseURLPooled == "" {
+		if c.Environment == "production" {
+			v.addf("DATABASE_URL_POOLED is required in production")
+		}
+	} else {
+		for i, dsn := range strings.Split(c.DatabaseURLPooled, ",") {
+			v.databaseURL(fmt.Sprintf("DATABASE_URL_POOLED entry %d", i+1), strings.TrimSpace(dsn))
+		}
+	}
+	if c.DatabaseURLDirect != "" {
+		v.databaseURL("DATABASE_URL_DIRECT", c.DatabaseURLDirect)
+	}
+	for i, dsn := range c.DatabaseURLReplicas {
+		v.databaseURL(fmt.Sprintf("DATABASE_URL_REPLICAS entry %d", i+1), dsn)
+	}
+
+	v.listenAddress("HTTP_PORT", c.HTTPPort)
+	v.listenAddress("GO_API_PORT", c.GRPCPort)
+
+	if c.EmbeddingBaseURL != "" {
+		v.httpURL("EMBEDDING_BASE_URL", c.EmbeddingBaseURL)
+	}
+	if c.EnableCORS {
+		for _, origin := range c.CORSAllowedOrigins {
+			if origin != "*" {
+				v.httpURL("CORS_ALLOWED_ORIGINS", origin)
+			}
+		}
+	}
+
+	for _, proxy := range c.TrustedProxies {
+		v.ipOrCIDR("TRUSTED_PROXIES", proxy)
+	}
+
+	v.atLeast("DB_MAX_CONNS", c.DBMaxConns, 1)
+	if c.DBMinConns < 0 || c.DBMinConns > c.DBMaxConns {
+		v.addf("DB_MIN_CONNS must be between 0 and DB_MAX_CONNS (%d), got %d", c.DBMaxConns, c.DBMinConns)
+	}
+	v.atLeast("DB_CONNECT_ATTEMPTS", c.DBConnectAttempts, 1)
+	v.atLeast("DB_FAILOVER_THRESHOLD", c.DBFailoverThreshold, 1)
+	v.atLeast("RAG_TOP_K", c.RAGTopK, 1)
+	v.atLeast("EMBEDDING_BATCH_SIZE", c.EmbeddingBatchSize, 1)
+	if c.EmbeddingDimensions < 1 || c.EmbeddingDimensions > 2000 {
+		// pgvector's HNSW index covers at most 2000 dimensions
+		v.addf("EMBEDDING_DIMENSIONS must be between 1 and 2000, got %d", c.EmbeddingDimensions)
+	}
+	v.atLeast("AGENT_MAX_ITERATIONS", c.AgentMaxIterations, 1)
+	v.atLeast("AGENT_SCHEMA_CONTEXT_TOKENS", c.AgentSchemaContextTokens, 0)
+	v.atLeast("AGENT_JOB_WORKERS", c.AgentJobWorkers, 1)
+	v.atLeast("LLM_RETRY_ATTEMPTS", c.LLMRetryAttempts, 1)
+	v.atLeast("LLM_BREAKER_FAILURES", c.LLMBreakerFailures, 0)
+	v.atLeast("QUEUE_WORKERS", c.QueueWorkers, 1)
+	v.atLeast("AUDIT_EXPORT_BATCH_SIZE", c.AuditExportBatchSize, 1)
+	v.atLeast("JWT_ACCESS_TTL_MINUTES", c.JWTAccessTTLMinutes, 1)
+	v.atLeast("JWT_REFRESH_TTL_HOURS", c.JWTRefreshTTLHours, 1)
+	for name, value := range map[string]int{
+		"HTTP_READ_HEADER_TIMEOUT_SECONDS": c.HTTPReadHeaderTimeoutSeconds,
+		"HTTP_READ_TIMEOUT_SECONDS":        c.HTTPReadTimeoutSeconds,
+		"HTTP_WRITE_TIMEOUT_SECONDS":       c.HTTPWriteTimeoutSeconds,
+		"HTTP_IDLE_TIMEOUT_SECONDS":        c.HTTPIdleTimeoutSeconds,
+		"CORS_MAX_AGE_SECONDS":             c.CORSMaxAgeSeconds,
+		"DB_MAX_CONN_LIFETIME_MINUTES":     c.DBMaxConnLifetimeMinutes,
+		"DB_MAX_CONN_IDLE_MINUTES":         c.DBMaxConnIdleMinutes,
+		"DB_HEALTH_CHECK_PERIOD_SECONDS":   c.DBHealthCheckPeriodSeconds,
+		"DB_CONNECT_MAX_BACKOFF_SECONDS":   c.DBConnectMaxBackoffSeconds,
+		"DB_SLOW_QUERY_MS":                 c.DBSlowQueryMS,
+		"DB_RELOAD_GRACE_SECONDS":          c.DBReloadGraceSeconds,
+		"DB_POOL_STATS_INTERVAL_SECONDS":   c.DBPoolStatsIntervalSeconds,
+		"DB_FAILOVER_CHECK_SECONDS":        c.DBFailoverCheckSeconds,
+		"DB_STATEMENT_CACHE_CAPACITY":      c.DBStatementCacheCapacity,
+		"GRPC_MAX_RECV_MSG_SIZE_MB":        c.GRPCMaxRecvMsgSizeMB,
+		"GRPC_MAX_SEND_MSG_SIZE_MB":        c.GRPCMaxSendMsgSizeMB,
+		"GRPC_MAX_CONCURRENT_STREAMS":      c.GRPCMaxConcurrentStreams,
+		"GRPC_KEEPALIVE_TIME_SECONDS":      c.GRPCKeepaliveTimeSeconds,
+		"GRPC_KEEPALIVE_TIMEOUT_SECONDS":   c.GRPCKeepaliveTimeoutSeconds,
+		"GRPC_KEEPALIVE_MIN_TIME_SECONDS":  c.GRPCKeepaliveMinTimeSeconds,
+		"AUDIT_RETENTION_DAYS":             c.AuditRetentionDays,
+		"TABLE_ANALYTICS_RETENTION_DAYS":   c.TableAnalyticsRetentionDays,
+		"RATE_LIMIT_READ_PER_MINUTE":       c.RateLimitReadPerMinute,
+		"RATE_LIMIT_READ_BURST":            c.RateLimitReadBurst,
+		"RATE_LIMIT_WRITE_PER_MINUTE":      c.RateLimitWritePerMinute,
+		"RATE_LIMIT_WRITE_BURST":           c.RateLimitWriteBurst,
+		"DEBUG_MUTEX_PROFILE_FRACTION":     c.MutexProfileFraction,
+		"DEBUG_BLOCK_PROFILE_RATE":         c.BlockProfileRate,
+		"SECRETS_REFRESH_MINUTES":          c.SecretsRefreshMinutes,
+		"EMBEDDING_REQUESTS_PER_MINUTE":    c.EmbeddingRequestsPerMinute,
+		"EMBEDDING_MAX_RETRIES":            c.EmbeddingMaxRetries,
+		"QUOTA_MAX_TABLES":                 c.QuotaMaxTables,
+		"QUOTA_MAX_COLUMNS_PER_TABLE":      c.QuotaMaxColumnsPerTable,
+		"QUOTA_MAX_ROWS_PER_TABLE":         c.QuotaMaxRowsPerTable,
+	} {
+		v.atLeast(name, value, 0)
+	}
+	if c.HTTPMaxBodySize < 0 {
+		v.addf("HTTP_MAX_BODY_SIZE must not be negative, got %d", c.HTTPMaxBodySize)
+	}
+	for name, value := range map[string]time.Duration{
+		"DB_STATEMENT_TIMEOUT":      c.DBStatementTimeout,
+		"DB_CONNECT_TIMEOUT":        c.DBConnectTimeout,
+		"AGENT_ITERATION_TIMEOUT":   c.AgentIterationTimeout,
+		"AGENT_RUN_TIMEOUT":         c.AgentRunTimeout,
+		"AGENT_USAGE_INTERVAL":      c.AgentUsageInterval,
+		"SHUTDOWN_TIMEOUT":          c.ShutdownTimeout,
+		"MIGRATION_TIMEOUT":         c.MigrationTimeout,
+		"QUEUE_POLL_INTERVAL":       c.QueuePollInterval,
+		"QUEUE_JOB_TIMEOUT":         c.QueueJobTimeout,
+		"AUDIT_EXPORT_INTERVAL":     c.AuditExportInterval,
+		"TABLE_TRASH_RETENTION":     c.TableTrashRetention,
+		"LLM_RETRY_INITIAL_BACKOFF": c.LLMRetryInitialBackoff,
+		"LLM_RETRY_MAX_BACKOFF":     c.LLMRetryMaxBackoff,
+		"LLM_BREAKER_COOLDOWN":      c.LLMBreakerCooldown,
+	} {
+		if value < 0 {
+			v.addf("%s must not be negative, got %s", name, value)
+		}
+	}
+
+	if c.TracingSampleRatio < 0 || c.TracingSampleRatio > 1 {
+		v.addf("OTEL_TRACES_SAMPLER_ARG must be between 0 and 1, got %g", c.TracingSampleRatio)
+	}
+	if c.PayloadLogSampleRate < 0 || c.PayloadLogSampleRate > 1 {
+		v.addf("GRPC_PAYLOAD_LOG_SAMPLE_RATE must be between 0 and 1, got %g", c.PayloadLogSampleRate)
+	}
+	if c.OTLPEndpoint != "" {
+		v.httpURL("OTEL_EXPORTER_OTLP_ENDPOINT", c.OTLPEndpoint)
+	}
+	v.oneOf("OTEL_EXPORTER_OTLP_PROTOCOL", c.OTLPProtocol, "grpc", "http/protobuf")
+
+	v.oneOf("IDENTIFIER_CASE", c.IdentifierCase, "lower", "snake")
+	v.oneOf("IDENTIFIER_TRUNCATION", c.IdentifierTruncation, "cut", "hash")
+	if c.IdentifierMaxLength < 16 || c.IdentifierMaxLength > 63 {
+		v.addf("IDENTIFIER_MAX_LENGTH must be between 16 and 63, got %d", c.IdentifierMaxLength)
+	}
+	v.oneOf("GIN_MODE", c.GinMode, "debug", "release", "test")
+	for _, sink := range c.AuditExportSinks {
+		v.auditSink("AUDIT_EXPORT_SINKS", sink)
+	}
+	for _, source := range c.AuditExportSources {
+		v.oneOf("AUDIT_EXPORT_SOURCES", source, "api", "schema")
+	}
+	v.oneOf("LOG_LEVEL", strings.ToLower(c.LogLevel), "debug", "info", "warn", "warning", "error")
+	v.oneOf("EMBEDDING_PROVIDER", c.EmbeddingProvider, "openai", "ollama")
+	for _, provider := range c.LLMFallbackProviders {
+		v.oneOf("LLM_FALLBACK_PROVIDERS", provider, "openai", "anthropic", "google")
+	}
+	v.oneOf("GUARDRAIL_PII_ACTION", c.GuardrailPIIAction, "log", "redact", "block")
+	v.oneOf("GUARDRAIL_INJECTION_ACTION", c.GuardrailInjectionAction, "log", "redact", "block")
+	v.oneOf("GUARDRAIL_TOPIC_ACTION", c.GuardrailTopicAction, "log", "redact", "block")
+	v.oneOf("GUARDRAIL_TOOL_INJECTION_ACTION", c.GuardrailToolInjectionAction, "log", "sanitize", "approve", "block")
+
+	if len(v.problems) == 0 {
+		return nil
+	}
+	// Ranges are checked in map order; keep the report stable
+	slices.Sort(v.problems)
+	return &ValidationError{Problems: v.problems}
+}
+
+// databaseURL checks that a URL or keyword/value DSN parses
+func (v *validator) databaseURL(name, dsn string) {
+	if dsn == "" {
+		v.addf("%s is empty", name)
+		return
+	}
+	if _, err := pgx.ParseConfig(dsn); err != nil {
+		v.addf("%s is not a valid database URL: %v", name, err)
+	}
+}
+
+// listenAddress checks a [host]:port address with a port from 1 to 65535
+func (v *validator) listenAddress(name, addr string) {
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		v.addf("%s %q must look like :8080 or host:8080", name, addr)
+		return
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+		v.addf("%s %q must have a port from 1 to 65535", name, addr)
+	}
+}
+
+// ipOrCIDR checks an IP address or CIDR range
+func (v *validator) ipOrCIDR(name, value string) {
+	if net.ParseIP(value) != nil {
+		return
+	}
+	if _, _, err := net.ParseCIDR(value); err != nil {
+		v.addf("%s entry %q must be an IP address or CIDR range", name, value)
+	}
+}
+
+// httpURL checks an absolute http or https URL
+func (v *validator) httpURL(name, value string) {
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		v.addf("%s %q must be an http or https URL", name, value)
+	}
+}
+
+// auditSink checks an audit export sink URL's scheme and required parts
+func (v *validator) auditSink(name, spec string) {
+	u, err := url.Parse(spec)
+	if err != nil {
+		v.addf("%s entry %q is not a URL", name, redactURLs(spec))
+		return
+	}
+	switch u.Scheme {
+	case "file":
+		if u.Path == "" {
+			v.addf("%s entry %q needs a directory path", name, u.Redacted())
+		}
+	case "s3", "http", "https":
+		if u.Host == "" {
+			v.addf("%s entry %q needs a host or bucket", name, u.Redacted())
+		}
+	case "syslog", "syslog+udp", "syslog+tcp":
+		if u.Port() == "" {
+			v.addf("%s entry %q needs host:port", name, u.Redacted())
+		}
+	default:
+		v.addf("%s entry %q must use file, s3, syslog, syslog+tcp, http, or https", name, u.Redacted())
+	}
+}
+
+func (v *validator) atLeast(name string, value, minimum int) {
+	if value < minimum {
+		v.addf("%s must be at least %d, got %d", name, minimum, value)
+	}
+}
+
+func (v *validator) oneOf(name, value string, allowed ...string) {
+	if !slices.Contains(allowed, value) {
+		v.addf("%s %q must be one of %s", name, value, strings.Join(allowed, ", "))
+	}
+}
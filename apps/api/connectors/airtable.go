@@ -0,0 +1,211 @@
+package connectors
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Airtable API tuning
+const (
+	airtableAPI        = "https://api.airtable.com/v0/"
+	airtablePageSize   = 100              // The API's maximum
+	airtableTimeout    = 30 * time.Second // Bound on each API call
+	airtableRetryAfter = 30 * time.Second // The API's penalty once rate limited
+	airtableRetries    = 3                // Rate-limited calls retried before giving up
+)
+
+// airtableConnector reads the tables of an Airtable base
+type airtableConnector struct {
+	client *http.Client
+	token  string
+	baseID string
+}
+
+// openAirtable reads the base of an airtable://:token@base-id URL, with a
+// personal access token granted the data.records:read and
+// schema.bases:read scopes
+func openAirtable(u *url.URL) (Connector, error) {
+	token, ok := u.User.Password()
+	if !ok || u.Host == "" {
+		return nil, fmt.Errorf("%w: the Airtable URL must hold a token and a base ID, e.g. airtable://:token@appXXXXXXXX", ErrUnsupportedSource)
+	}
+	return &airtableConnector{client: &http.Client{Timeout: airtableTimeout}, token: token, baseID: u.Host}, nil
+}
+
+// Kind returns KindAirtable
+func (c *airtableConnector) Kind() string {
+	return KindAirtable
+}
+
+// airtableField is a field of the base schema API
+type airtableField struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// ListTables reads the base's schema. Columns are named by field and typed
+// by the field's Airtable type, e.g. singleLineText or checkbox; the API
+// reports no row counts.
+func (c *airtableConnector) ListTables(ctx context.Context) ([]Table, error) {
+	var schema struct {
+		Tables []struct {
+			Name   string          `json:"name"`
+			Fields []airtableField `json:"fields"`
+		} `json:"tables"`
+	}
+	if err := c.get(ctx, "meta/bases/"+url.PathEscape(c.baseID)+"/tables", nil, &schema); err != nil {
+		return nil, fmt.Errorf("failed to read base schema: %w", err)
+	}
+
+	tables := make([]Table, 0, len(schema.Tables))
+	for _, t := range schema.Tables {
+		table := Table{Name: t.Name}
+		for _, field := range t.Fields {
+			table.Columns = append(table.Columns, Column{Name: field.Name, NativeType: field.Type, Nullable: true})
+		}
+		tables = append(tables, table)
+	}
+	return tables, nil
+}
+
+// ReadRows pages through the table's records. Lists, such as linked
+// records or attachments, are passed on as JSON.
+func (c *airtableConnector) ReadRows(ctx context.Context, table Table, columns []string, batchSize int, fn func(rows [][]*string) error) error {
+	path := url.PathEscape(c.baseID) + "/" + url.PathEscape(table.Name)
+	query := url.Values{"pageSize": {strconv.Itoa(airtablePageSize)}}
+	for _, name := range columns {
+		query.Add("fields[]", name)
+	}
+
+	// The API leaves out unchecked checkboxes along with empty fields
+	checkboxes := make([]bool, len(columns))
+	for i, name := range columns {
+		for _, col := range table.Columns {
+			checkboxes[i] = checkboxes[i] || (col.Name == name && col.NativeType == "checkbox")
+		}
+	}
+	unchecked := "false"
+
+	batch := make([][]*string, 0, batchSize)
+	for {
+		var page struct {
+			Records []struct {
+				Fields map[string]json.RawMessage `json:"fields"`
+			} `json:"records"`
+			Offset string `json:"offset"`
+		}
+		if err := c.get(ctx, path, query, &page); err != nil {
+			return fmt.Errorf("failed to read %s: %w", table.Name, err)
+		}
+
+		for _, record := range page.Records {
+			row := make([]*string, len(columns))
+			for i, name := range columns {
+				row[i] = fieldText(record.Fields[name])
+				if row[i] == nil && checkboxes[i] {
+					row[i] = &unchecked
+				}
+			}
+			batch = append(batch, row)
+			if len(batch) == batchSize {
+				if err := fn(batch); err != nil {
+					return err
+				}
+				batch = make([][]*string, 0, batchSize)
+			}
+		}
+		if page.Offset == "" {
+			break
+		}
+		query.Set("offset", page.Offset)
+	}
+	if len(batch) > 0 {
+		return fn(batch)
+	}
+	return nil
+}
+
+// Close releases idle connections
+func (c *airtableConnector) Close() error {
+	c.client.CloseIdleConnections()
+	return nil
+}
+
+// get calls the API and decodes its response into v, waiting out rate
+// limits
+func (c *airtableConnector) get(ctx context.Context, path string, query url.Values, v any) error {
+	endpoint := airtableAPI + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+c.token)
+
+		resp, err := c.client.Do(req)
+		if err != nil {
+			return err
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case resp.StatusCode == http.StatusTooManyRequests && attempt < airtableRetries:
+			select {
+			case <-time.After(airtableRetryAfter):
+				continue
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		case resp.StatusCode == http.StatusNotFound:
+			return fmt.Errorf("%w: %s", ErrTableNotFound, airtableError(body, resp.Status))
+		case resp.StatusCode != http.StatusOK:
+			return errors.New(airtableError(body, resp.Status))
+		}
+		return json.Unmarshal(body, v)
+	}
+}
+
+// airtableError returns the message of an API error response
+func airtableError(body []byte, status string) string {
+	var resp struct {
+		Error json.RawMessage `json:"error"`
+	}
+	if json.Unmarshal(body, &resp) != nil || len(resp.Error) == 0 {
+		return "Airtable returned " + status
+	}
+	var detail struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(resp.Error, &detail) == nil && detail.Type != "" {
+		return fmt.Sprintf("Airtable returned %s: %s %s", status, detail.Type, detail.Message)
+	}
+	return fmt.Sprintf("Airtable returned %s: %s", status, resp.Error)
+}
+
+// fieldText returns the text of a field value, nil when missing; strings
+// are unquoted and lists and objects stay JSON
+func fieldText(raw json.RawMessage) *string {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+	var text string
+	if json.Unmarshal(raw, &text) != nil {
+		text = string(raw)
+	}
+	return &text
+}
@@ -0,0 +1,133 @@
+// Package connectors reads the tables of external databases, Google
+// Sheets, and Airtable bases so they can be imported into user tables. A
+// connector introspects the remote tables and streams their rows as text,
+// which the schema manager casts to the types of the columns it maps them
+// to.
+package connectors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+// Kinds of external databases
+const (
+	KindPostgres     = "postgres"
+	KindMySQL        = "mysql"
+	KindGoogleSheets = "google_sheets"
+	KindAirtable     = "airtable"
+)
+
+// ErrUnsupportedSource is returned for URLs of databases without a
+// connector
+var ErrUnsupportedSource = errors.New("unsupported source database")
+
+// ErrTableNotFound is returned for tables a source doesn't have
+var ErrTableNotFound = errors.New("source table not found")
+
+// Column is a column of a remote table
+type Column struct {
+	Name       string `json:"name"`
+	NativeType string `json:"native_type"` // The remote type, e.g. "character varying" or "tinyint(1)"
+	Nullable   bool   `json:"nullable"`
+	MaxLength  int    `json:"max_length,omitempty"` // Of character types; 0 when unbounded
+}
+
+// Table is a table of a remote database
+type Table struct {
+	Schema      string   `json:"schema,omitempty"` // The PostgreSQL schema or MySQL database; empty for spreadsheets
+	Name        string   `json:"name"`
+	Columns     []Column `json:"columns"`
+	RowEstimate int64    `json:"row_estimate"` // From the source's statistics; may be stale
+}
+
+// QualifiedName returns the table's name qualified by its schema, if it
+// has one, as FindTable accepts it
+func (t *Table) QualifiedName() string {
+	if t.Schema == "" {
+		return t.Name
+	}
+	return t.Schema + "." + t.Name
+}
+
+// Connector reads one external database
+type Connector interface {
+	// Kind returns the kind of database, e.g. KindPostgres
+	Kind() string
+	// ListTables returns the source's tables with their columns, leaving
+	// out system schemas
+	ListTables(ctx context.Context) ([]Table, error)
+	// ReadRows streams the values of the named columns of a table as text,
+	// nil for NULL, passing batchSize rows at a time to fn
+	ReadRows(ctx context.Context, table Table, columns []string, batchSize int, fn func(rows [][]*string) error) error
+	// Close disconnects from the source
+	Close() error
+}
+
+// SourceKind returns the kind of database a URL connects to, or
+// ErrUnsupportedSource
+func SourceKind(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		// Don't echo the URL, which may hold a password
+		return "", fmt.Errorf("%w: invalid URL", ErrUnsupportedSource)
+	}
+	switch u.Scheme {
+	case "postgres", "postgresql":
+		return KindPostgres, nil
+	case "mysql":
+		return KindMySQL, nil
+	case "gsheets":
+		return KindGoogleSheets, nil
+	case "airtable":
+		return KindAirtable, nil
+	default:
+		return "", fmt.Errorf("%w: %q (expected postgres, mysql, gsheets, or airtable)", ErrUnsupportedSource, u.Scheme)
+	}
+}
+
+// Open connects to the source of a postgres://, postgresql://, mysql://,
+// gsheets://, or airtable:// URL
+func Open(ctx context.Context, rawURL string) (Connector, error) {
+	kind, err := SourceKind(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	u, _ := url.Parse(rawURL)
+	switch kind {
+	case KindMySQL:
+		return openMySQL(ctx, u)
+	case KindGoogleSheets:
+		return openSheets(ctx, u)
+	case KindAirtable:
+		return openAirtable(u)
+	default:
+		return openPostgres(ctx, rawURL)
+	}
+}
+
+// FindTable returns the table of a connector with a name, qualified by
+// its schema ("sales.orders") or not when only one schema has it. Names
+// are matched whole, since sheet and Airtable names may contain dots.
+func FindTable(ctx context.Context, conn Connector, name string) (*Table, error) {
+	tables, err := conn.ListTables(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var match *Table
+	for i := range tables {
+		if tables[i].QualifiedName() != name && tables[i].Name != name {
+			continue
+		}
+		if match != nil {
+			return nil, fmt.Errorf("%w: %q is in several schemas; qualify it, e.g. %s", ErrTableNotFound, name, tables[i].QualifiedName())
+		}
+		match = &tables[i]
+	}
+	if match == nil {
+		return nil, fmt.Errorf("%w: %q", ErrTableNotFound, name)
+	}
+	return match, nil
+}
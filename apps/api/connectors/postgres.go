@@ -0,0 +1,115 @@
+package connectors
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// postgresConnector reads a PostgreSQL database over a single connection
+type postgresConnector struct {
+	conn *pgx.Conn
+}
+
+// openPostgres connects to a PostgreSQL database
+func openPostgres(ctx context.Context, rawURL string) (Connector, error) {
+	conn, err := pgx.Connect(ctx, rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to source database: %w", err)
+	}
+	return &postgresConnector{conn: conn}, nil
+}
+
+// Kind returns KindPostgres
+func (c *postgresConnector) Kind() string {
+	return KindPostgres
+}
+
+// ListTables reads information_schema, with row estimates from pg_class
+func (c *postgresConnector) ListTables(ctx context.Context) ([]Table, error) {
+	query := `
+		SELECT col.table_schema, col.table_name, col.column_name, col.data_type,
+			col.is_nullable = 'YES', COALESCE(col.character_maximum_length, 0),
+			COALESCE(GREATEST(cls.reltuples, 0)::bigint, 0)
+		FROM information_schema.columns col
+		JOIN information_schema.tables tbl
+			ON tbl.table_schema = col.table_schema AND tbl.table_name = col.table_name
+		LEFT JOIN pg_namespace ns ON ns.nspname = col.table_schema
+		LEFT JOIN pg_class cls ON cls.relnamespace = ns.oid AND cls.relname = col.table_name
+		WHERE tbl.table_type = 'BASE TABLE'
+			AND col.table_schema NOT IN ('pg_catalog', 'information_schema')
+			AND col.table_schema NOT LIKE 'pg_toast%'
+		ORDER BY col.table_schema, col.table_name, col.ordinal_position`
+	rows, err := c.conn.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list source tables: %w", err)
+	}
+	defer rows.Close()
+
+	var tables []Table
+	for rows.Next() {
+		var schema, name string
+		var col Column
+		var estimate int64
+		if err := rows.Scan(&schema, &name, &col.Name, &col.NativeType, &col.Nullable, &col.MaxLength, &estimate); err != nil {
+			return nil, fmt.Errorf("failed to list source tables: %w", err)
+		}
+		if n := len(tables); n == 0 || tables[n-1].Schema != schema || tables[n-1].Name != name {
+			tables = append(tables, Table{Schema: schema, Name: name, RowEstimate: estimate})
+		}
+		tables[len(tables)-1].Columns = append(tables[len(tables)-1].Columns, col)
+	}
+	return tables, rows.Err()
+}
+
+// ReadRows casts every value to text in one streamed query
+func (c *postgresConnector) ReadRows(ctx context.Context, table Table, columns []string, batchSize int, fn func(rows [][]*string) error) error {
+	selects := make([]string, len(columns))
+	for i, name := range columns {
+		selects[i] = pgx.Identifier{name}.Sanitize() + "::text"
+	}
+	query := fmt.Sprintf("SELECT %s FROM %s", strings.Join(selects, ", "), pgx.Identifier{table.Schema, table.Name}.Sanitize())
+	rows, err := c.conn.Query(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", table.QualifiedName(), err)
+	}
+	defer rows.Close()
+
+	return readBatches(len(columns), batchSize, rows.Next, rows.Scan, rows.Err, fn)
+}
+
+// Close closes the connection
+func (c *postgresConnector) Close() error {
+	return c.conn.Close(context.Background())
+}
+
+// readBatches scans rows of text values into batches
+func readBatches(columnCount, batchSize int, next func() bool, scan func(dest ...any) error, rowsErr func() error, fn func(rows [][]*string) error) error {
+	batch := make([][]*string, 0, batchSize)
+	for next() {
+		row := make([]*string, columnCount)
+		dest := make([]any, columnCount)
+		for i := range row {
+			dest[i] = &row[i]
+		}
+		if err := scan(dest...); err != nil {
+			return fmt.Errorf("failed to read source row: %w", err)
+		}
+		batch = append(batch, row)
+		if len(batch) == batchSize {
+			if err := fn(batch); err != nil {
+				return err
+			}
+			batch = make([][]*string, 0, batchSize)
+		}
+	}
+	if err := rowsErr(); err != nil {
+		return fmt.Errorf("failed to read source rows: %w", err)
+	}
+	if len(batch) > 0 {
+		return fn(batch)
+	}
+	return nil
+}
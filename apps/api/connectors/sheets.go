@@ -0,0 +1,287 @@
+package connectors
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/api/option"
+	"google.golang.org/api/sheets/v4"
+)
+
+// sheetsSampleRows is how many rows of a sheet column types are inferred
+// from
+const sheetsSampleRows = 1000
+
+// sheetsConnector reads the tabs of a Google Sheet as tables, taking
+// column names from their first row
+type sheetsConnector struct {
+	service       *sheets.Service
+	spreadsheetID string
+}
+
+// openSheets connects to the spreadsheet of a
+// gsheets://[:api-key@]spreadsheet-id[?credentials_file=path] URL. An API
+// key reads sheets shared by link; a credentials file holds a service
+// account key or an OAuth refresh token; without either, the application
+// default credentials are used.
+func openSheets(ctx context.Context, u *url.URL) (Connector, error) {
+	if u.Host == "" {
+		return nil, fmt.Errorf("%w: the Google Sheets URL must name a spreadsheet ID", ErrUnsupportedSource)
+	}
+	opts := []option.ClientOption{option.WithScopes(sheets.SpreadsheetsReadonlyScope)}
+	if key, ok := u.User.Password(); ok {
+		opts = append(opts, option.WithAPIKey(key))
+	} else if file := u.Query().Get("credentials_file"); file != "" {
+		opts = append(opts, option.WithCredentialsFile(file))
+	}
+
+	service, err := sheets.NewService(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Google Sheets: %w", err)
+	}
+	return &sheetsConnector{service: service, spreadsheetID: u.Host}, nil
+}
+
+// Kind returns KindGoogleSheets
+func (c *sheetsConnector) Kind() string {
+	return KindGoogleSheets
+}
+
+// ListTables returns a table per tab, inferring column types from a
+// sample of its rows
+func (c *sheetsConnector) ListTables(ctx context.Context) ([]Table, error) {
+	spreadsheet, err := c.service.Spreadsheets.Get(c.spreadsheetID).Fields("sheets.properties").Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spreadsheet: %w", err)
+	}
+
+	tables := make([]Table, 0, len(spreadsheet.Sheets))
+	for _, sheet := range spreadsheet.Sheets {
+		props := sheet.Properties
+		if props == nil || props.SheetType != "GRID" {
+			continue
+		}
+		rows, err := c.values(ctx, fmt.Sprintf("%s!1:%d", sheetRange(props.Title), sheetsSampleRows+1))
+		if err != nil {
+			return nil, err
+		}
+		if len(rows) == 0 {
+			continue
+		}
+		table := Table{Name: props.Title, Columns: inferColumns(sheetHeaders(rows[0]), rows[1:])}
+		if props.GridProperties != nil && props.GridProperties.RowCount > 1 {
+			table.RowEstimate = props.GridProperties.RowCount - 1
+		}
+		tables = append(tables, table)
+	}
+	return tables, nil
+}
+
+// ReadRows reads the whole tab, since the API pages by range rather than
+// by row
+func (c *sheetsConnector) ReadRows(ctx context.Context, table Table, columns []string, batchSize int, fn func(rows [][]*string) error) error {
+	rows, err := c.values(ctx, sheetRange(table.Name))
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	indexes, err := columnIndexes(sheetHeaders(rows[0]), columns, table.Name)
+	if err != nil {
+		return err
+	}
+
+	batch := make([][]*string, 0, batchSize)
+	for _, cells := range rows[1:] {
+		row := make([]*string, len(indexes))
+		empty := true
+		for i, index := range indexes {
+			if index < len(cells) {
+				row[i] = cells[index]
+				empty = empty && row[i] == nil
+			}
+		}
+		if empty {
+			continue
+		}
+		batch = append(batch, row)
+		if len(batch) == batchSize {
+			if err := fn(batch); err != nil {
+				return err
+			}
+			batch = make([][]*string, 0, batchSize)
+		}
+	}
+	if len(batch) > 0 {
+		return fn(batch)
+	}
+	return nil
+}
+
+// Close does nothing; the service holds no connection
+func (c *sheetsConnector) Close() error {
+	return nil
+}
+
+// values reads a range as text, nil for empty cells. Numbers and booleans
+// are read unformatted so currency signs and separators don't hide them;
+// dates keep their cell format.
+func (c *sheetsConnector) values(ctx context.Context, valueRange string) ([][]*string, error) {
+	resp, err := c.service.Spreadsheets.Values.Get(c.spreadsheetID, valueRange).
+		ValueRenderOption("UNFORMATTED_VALUE").
+		DateTimeRenderOption("FORMATTED_STRING").
+		Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", valueRange, err)
+	}
+	rows := make([][]*string, len(resp.Values))
+	for i, cells := range resp.Values {
+		rows[i] = make([]*string, len(cells))
+		for j, cell := range cells {
+			rows[i][j] = cellText(cell)
+		}
+	}
+	return rows, nil
+}
+
+// cellText returns the text of a cell value, nil when empty
+func cellText(cell any) *string {
+	var text string
+	switch v := cell.(type) {
+	case nil:
+		return nil
+	case string:
+		text = v
+	case float64:
+		text = strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		text = strconv.FormatBool(v)
+	default:
+		text = fmt.Sprint(v)
+	}
+	if text == "" {
+		return nil
+	}
+	return &text
+}
+
+// sheetRange quotes a tab title for A1 notation
+func sheetRange(title string) string {
+	return "'" + strings.ReplaceAll(title, "'", "''") + "'"
+}
+
+// sheetHeaders returns the column names of a header row, naming empty
+// cells after their position and suffixing repeated names
+func sheetHeaders(cells []*string) []string {
+	headers := make([]string, len(cells))
+	seen := map[string]bool{}
+	for i, cell := range cells {
+		name := fmt.Sprintf("column_%d", i+1)
+		if cell != nil && strings.TrimSpace(*cell) != "" {
+			name = strings.TrimSpace(*cell)
+		}
+		for base, n := name, 2; seen[name]; n++ {
+			name = fmt.Sprintf("%s_%d", base, n)
+		}
+		seen[name] = true
+		headers[i] = name
+	}
+	return headers
+}
+
+// columnIndexes returns the position of each named column among headers
+func columnIndexes(headers, columns []string, table string) ([]int, error) {
+	indexes := make([]int, len(columns))
+	for i, name := range columns {
+		indexes[i] = -1
+		for j, header := range headers {
+			if header == name {
+				indexes[i] = j
+				break
+			}
+		}
+		if indexes[i] < 0 {
+			return nil, fmt.Errorf("%w: column %q is no longer in %s", ErrTableNotFound, name, table)
+		}
+	}
+	return indexes, nil
+}
+
+// Layouts of the dates inferColumns recognizes
+var inferDateLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02"}
+
+// inferShortText is the longest sampled text of columns inferred as
+// short text
+const inferShortText = 100
+
+// inferColumns infers the type of each column from sample rows, naming it
+// like a PostgreSQL type: integer, bigint, numeric, boolean, timestamp,
+// or character varying, bounded to 255 when the sampled text is short
+func inferColumns(headers []string, rows [][]*string) []Column {
+	columns := make([]Column, len(headers))
+	for i, header := range headers {
+		var values []string
+		for _, row := range rows {
+			if i < len(row) && row[i] != nil {
+				values = append(values, *row[i])
+			}
+		}
+		columns[i] = Column{Name: header, NativeType: inferType(values), Nullable: true}
+		if columns[i].NativeType == "character varying" {
+			longest := 0
+			for _, value := range values {
+				longest = max(longest, len(value))
+			}
+			// Leave room for longer values past the sample
+			if longest <= inferShortText {
+				columns[i].MaxLength = 255
+			}
+		}
+	}
+	return columns
+}
+
+// inferType returns the narrowest type every value parses as
+func inferType(values []string) string {
+	if len(values) == 0 {
+		return "character varying"
+	}
+	matches := func(parse func(string) bool) bool {
+		for _, value := range values {
+			if !parse(value) {
+				return false
+			}
+		}
+		return true
+	}
+	switch {
+	case matches(func(v string) bool { _, err := strconv.ParseInt(v, 10, 32); return err == nil }):
+		return "integer"
+	case matches(func(v string) bool { _, err := strconv.ParseInt(v, 10, 64); return err == nil }):
+		return "bigint"
+	case matches(func(v string) bool {
+		f, err := strconv.ParseFloat(v, 64)
+		return err == nil && !math.IsInf(f, 0) && !math.IsNaN(f)
+	}):
+		return "numeric"
+	case matches(func(v string) bool { return v == "true" || v == "false" }):
+		return "boolean"
+	case matches(func(v string) bool {
+		for _, layout := range inferDateLayouts {
+			if _, err := time.Parse(layout, v); err == nil {
+				return true
+			}
+		}
+		return false
+	}):
+		return "timestamp"
+	default:
+		return "character varying"
+	}
+}
@@ -2,12 +2,20 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// ErrNotConfigured is returned when no database URL is set
+var ErrNotConfigured = errors.New("database URL is required")
+
+// ErrInvalidConfig is returned for connection settings that retrying
+// cannot fix, such as a malformed URL
+var ErrInvalidConfig = errors.New("invalid database configuration")
+
 // DB wraps the database connection pool
 type DB struct {
 	Pool *pgxpool.Pool
@@ -15,27 +23,24 @@ type DB struct {
 
 // NewConnection creates a new database connection pool
 // Uses the pooled connection string for runtime queries
-func NewConnection(databaseURL string) (*DB, error) {
+func NewConnection(databaseURL string, poolConfig PoolConfig) (*DB, error) {
 	if databaseURL == "" {
-		return nil, fmt.Errorf("database URL is required")
+		return nil, ErrNotConfigured
 	}
 
 	// Parse the connection string and create a config
 	config, err := pgxpool.ParseConfig(databaseURL)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse database URL: %w", err)
+		return nil, fmt.Errorf("%w: failed to parse database URL: %w", ErrInvalidConfig, err)
 	}
 
 	// Configure connection pool settings
-	config.MaxConns = 20
-	config.MinConns = 2
-	config.MaxConnLifetime = time.Hour
-	config.MaxConnIdleTime = time.Minute * 30
-	config.HealthCheckPeriod = time.Minute
-	config.ConnConfig.ConnectTimeout = time.Second * 5
+	if err := poolConfig.apply(config); err != nil {
+		return nil, fmt.Errorf("%w: invalid pool settings: %w", ErrInvalidConfig, err)
+	}
 
 	// Create the connection pool
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout(config))
 	defer cancel()
 
 	pool, err := pgxpool.NewWithConfig(ctx, config)
@@ -70,7 +75,7 @@ func NewDirectConnection(databaseURL string) (*DB, error) {
 	config.MinConns = 1
 
 	// Create the connection pool
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout(config))
 	defer cancel()
 
 	pool, err := pgxpool.NewWithConfig(ctx, config)
@@ -121,10 +126,19 @@ func (db *DB) Health(ctx context.Context) error {
 	return nil
 }
 
+// connectTimeout bounds creating a pool and pinging it by the time allowed
+// to establish a connection, or the default when the pool config has none
+func connectTimeout(config *pgxpool.Config) time.Duration {
+	if config.ConnConfig.ConnectTimeout > 0 {
+		return config.ConnConfig.ConnectTimeout
+	}
+	return DefaultPoolConfig().ConnectTimeout
+}
+
 // Stats returns the current pool statistics
 func (db *DB) Stats() *pgxpool.Stat {
 	if db.Pool == nil {
 		return nil
 	}
 	return db.Pool.Stat()
-}
\ No newline at end of file
+}
@@ -0,0 +1,167 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"agentic-template/api/metrics"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// failoverCheckTimeout bounds each health check of the primary
+const failoverCheckTimeout = 5 * time.Second
+
+var (
+	failoversTotal = metrics.NewCounter("db_failovers_total",
+		"Failovers of the primary database to another DSN, by outcome (ok or failed).", "outcome")
+	primaryDSNIndex = metrics.NewGauge("db_primary_dsn_index",
+		"Position of the connected primary in DATABASE_URL_POOLED, from 0.")
+)
+
+// FailoverEvent describes a failover of the primary database
+type FailoverEvent struct {
+	From  int    // Position of the abandoned DSN
+	To    int    // Position of the new DSN, or -1 when none was reachable
+	Host  string // Host of the new primary
+	Cause error  // Why the previous primary was abandoned
+	At    time.Time
+}
+
+// connectFirst connects to the first reachable DSN, trying them in order
+// from start and wrapping around. Invalid DSNs are reported at once since
+// retrying cannot fix them.
+func connectFirst(dsns []string, start int, poolConfig PoolConfig) (*DB, int, error) {
+	var errs []error
+	for step := range dsns {
+		i := (start + step) % len(dsns)
+		db, err := NewConnection(dsns[i], poolConfig)
+		if err == nil {
+			return db, i, nil
+		}
+		if errors.Is(err, ErrInvalidConfig) {
+			return nil, -1, err
+		}
+		if len(dsns) > 1 {
+			err = fmt.Errorf("DSN %d: %w", i+1, err)
+		}
+		errs = append(errs, err)
+	}
+	return nil, -1, errors.Join(errs...)
+}
+
+// dsnHost returns the host of a DSN for logs, without its credentials
+func dsnHost(dsn string) string {
+	config, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		return "unknown"
+	}
+	return config.Host
+}
+
+// OnFailover registers fn to be called after each failover attempt, with
+// To set to -1 when every DSN was unreachable. Subscribers of OnReload are
+// also called when the primary changes.
+func (m *Manager) OnFailover(fn func(FailoverEvent)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.failoverSubscribers = append(m.failoverSubscribers, fn)
+}
+
+// WatchFailover health-checks the primary every interval until ctx is done
+// and fails over to the next reachable DSN of DATABASE_URL_POOLED after
+// threshold consecutive failures. It does nothing while a single DSN is
+// configured.
+func (m *Manager) WatchFailover(ctx context.Context, interval time.Duration, threshold int) {
+	threshold = max(threshold, 1)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	failures := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		m.mu.RLock()
+		candidates := len(m.dsns)
+		database := m.database
+		m.mu.RUnlock()
+		if candidates < 2 || database == nil {
+			failures = 0
+			continue
+		}
+
+		checkCtx, cancel := context.WithTimeout(ctx, failoverCheckTimeout)
+		err := database.Health(checkCtx)
+		cancel()
+		if err == nil || ctx.Err() != nil {
+			failures = 0
+			continue
+		}
+		failures++
+		log.Printf("Warning: primary database health check failed (%d/%d): %v", failures, threshold, err)
+		if failures >= threshold {
+			failures = 0
+			m.failover(err)
+		}
+	}
+}
+
+// failover connects to the DSNs after the current one in order and swaps
+// in the first reachable one. The old pool is retired like on Reload.
+func (m *Manager) failover(cause error) {
+	m.reloadMu.Lock()
+	defer m.reloadMu.Unlock()
+
+	m.mu.RLock()
+	dsns, from, poolConfig := m.dsns, m.dsnIndex, m.poolConfig
+	m.mu.RUnlock()
+
+	event := FailoverEvent{From: from, To: -1, Cause: cause, At: time.Now()}
+	var db *DB
+	if len(dsns) > 1 {
+		// The current DSN comes last, in case it recovered meanwhile
+		var err error
+		db, event.To, err = connectFirst(dsns, from+1, poolConfig)
+		if err != nil {
+			log.Printf("Warning: database failover found no reachable DSN: %v", err)
+		}
+	}
+
+	m.mu.Lock()
+	var retired []*DB
+	if db != nil {
+		if m.database != nil {
+			retired = append(retired, m.database)
+		}
+		m.database = db
+		m.dsnIndex = event.To
+		m.lastErr = nil
+	}
+	subscribers := slices.Clone(m.subscribers)
+	failoverSubscribers := slices.Clone(m.failoverSubscribers)
+	m.mu.Unlock()
+
+	if db == nil {
+		failoversTotal.Inc("failed")
+	} else {
+		event.Host = dsnHost(dsns[event.To])
+		failoversTotal.Inc("ok")
+		primaryDSNIndex.Set(float64(event.To))
+		log.Printf("Failed over primary database from DSN %d to DSN %d (%s): %v", from+1, event.To+1, event.Host, cause)
+		for _, fn := range subscribers {
+			fn(db)
+		}
+		retire(retired, poolConfig.ReloadGracePeriod)
+	}
+	for _, fn := range failoverSubscribers {
+		fn(event)
+	}
+}
@@ -0,0 +1,23 @@
+package db
+
+import (
+	"database/sql"
+)
+
+// SetLocal makes the manager serve a local file database, opened with
+// db/sqlite, instead of PostgreSQL. Pools stay nil, so features that need
+// PostgreSQL report the database as not configured, while Health checks
+// and Close closes the local database.
+func (m *Manager) SetLocal(local *sql.DB) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.local = local
+	m.markConnected()
+}
+
+// Local returns the local file database, or nil when running on PostgreSQL
+func (m *Manager) Local() *sql.DB {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.local
+}
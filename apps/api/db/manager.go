@@ -2,9 +2,15 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"slices"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/joho/godotenv"
@@ -12,12 +18,36 @@ import (
 
 // Manager handles the database connection and provides hot-reload functionality
 type Manager struct {
-	mu       sync.RWMutex
-	database *DB
-	pooledURL string
-	directURL string
+	mu         sync.RWMutex
+	database   *DB
+	pooledURL  string
+	directURL  string
+	poolConfig PoolConfig
+	lastErr    error         // Why the last connection attempt failed
+	connected  chan struct{} // Closed once a connection is first established
+	markOnce   sync.Once
+
+	replicaURLs []string
+	replicas    []*DB         // Connected read replicas
+	nextReplica atomic.Uint64 // Round-robin position for GetReadPool
+
+	reloadMu    sync.Mutex // Serializes reloads, which connect without holding mu
+	subscribers []func(*DB)
+	resolveURL  func(context.Context, string) (string, error) // Resolves env URLs on reload
+
+	dsns                []string // Primary DSNs from the pooled URL list, in failover order
+	dsnIndex            int      // Position of the connected primary in dsns
+	failoverSubscribers []func(FailoverEvent)
+
+	local *sql.DB // Local file database of DB_DRIVER=sqlite; nil on PostgreSQL
 }
 
+// reloadHealthCheckTimeout bounds the health check of a reloaded connection
+const reloadHealthCheckTimeout = 5 * time.Second
+
+// retirePollInterval is how often a replaced pool is checked for idleness
+const retirePollInterval = 100 * time.Millisecond
+
 // Global database manager instance
 var globalManager *Manager
 var once sync.Once
@@ -25,65 +55,192 @@ var once sync.Once
 // GetManager returns the singleton database manager
 func GetManager() *Manager {
 	once.Do(func() {
-		globalManager = &Manager{}
+		globalManager = NewManager()
 	})
 	return globalManager
 }
 
-// Initialize sets up the initial database connection
-func (m *Manager) Initialize(pooledURL, directURL string) error {
+// NewManager creates a manager separate from the singleton, for tools and
+// tests that connect to more than one database
+func NewManager() *Manager {
+	return &Manager{
+		poolConfig: DefaultPoolConfig(),
+		connected:  make(chan struct{}),
+		resolveURL: func(_ context.Context, value string) (string, error) { return value, nil },
+	}
+}
+
+// SetPoolConfig sets the pool settings used by Initialize and Reload
+func (m *Manager) SetPoolConfig(poolConfig PoolConfig) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	m.poolConfig = poolConfig
+}
 
+// Initialize sets up the initial database connection. The pooled URL may
+// be a comma-separated list of DSNs, of which the first reachable one is
+// used. The manager is not locked while connecting, so callers see no pool
+// until it succeeds.
+func (m *Manager) Initialize(pooledURL, directURL string) error {
+	m.mu.Lock()
 	m.pooledURL = pooledURL
 	m.directURL = directURL
+	poolConfig := m.poolConfig
+	replicaURLs := m.replicaURLs
+	m.mu.Unlock()
 
-	if pooledURL == "" {
-		return fmt.Errorf("database URL is required")
+	dsns := splitURLs(pooledURL)
+	if len(dsns) == 0 {
+		return ErrNotConfigured
 	}
 
-	db, err := NewConnection(pooledURL)
+	db, index, err := connectFirst(dsns, 0, poolConfig)
+	var replicas []*DB
+	if err == nil {
+		replicas = connectReplicas(replicaURLs, poolConfig)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	if err != nil {
+		m.lastErr = err
 		return err
 	}
 
 	m.database = db
+	m.replicas = replicas
+	m.dsns = dsns
+	m.dsnIndex = index
+	m.lastErr = nil
+	m.markConnected()
+	primaryDSNIndex.Set(float64(index))
 	return nil
 }
 
-// Reload reloads the database connection by reading the latest env vars
+// Connected returns a channel closed once the database is first connected
+func (m *Manager) Connected() <-chan struct{} {
+	return m.connected
+}
+
+// markConnected closes the connected channel; the caller holds m.mu
+func (m *Manager) markConnected() {
+	m.markOnce.Do(func() {
+		close(m.connected)
+	})
+}
+
+// Reload reloads the database connection by reading the latest env vars.
+// The new pool is connected and health-checked before it replaces the
+// current one, so a bad URL leaves the current connection in place. The
+// replaced pools keep serving in-flight queries for the grace period
+// before they are closed.
 func (m *Manager) Reload() error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	m.reloadMu.Lock()
+	defer m.reloadMu.Unlock()
 
-	// Reload environment variables from .env file
-	if err := godotenv.Load(); err != nil {
+	// Reload environment variables from .env file, if there is one
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
 		return fmt.Errorf("failed to reload .env file: %w", err)
 	}
 
-	pooledURL := os.Getenv("DATABASE_URL_POOLED")
-	if pooledURL == "" {
+	if os.Getenv("DATABASE_URL_POOLED") == "" {
 		return fmt.Errorf("DATABASE_URL_POOLED not found in environment")
 	}
 
-	// Close existing connection if any
-	if m.database != nil && m.database.Pool != nil {
-		m.database.Close()
+	m.mu.RLock()
+	poolConfig := m.poolConfig
+	resolveURL := m.resolveURL
+	m.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), reloadHealthCheckTimeout)
+	defer cancel()
+	pooledURL, err := resolveURL(ctx, os.Getenv("DATABASE_URL_POOLED"))
+	if err != nil {
+		return fmt.Errorf("failed to resolve DATABASE_URL_POOLED: %w", err)
+	}
+	directURL, err := resolveURL(ctx, os.Getenv("DATABASE_URL_DIRECT"))
+	if err != nil {
+		return fmt.Errorf("failed to resolve DATABASE_URL_DIRECT: %w", err)
+	}
+	replicaURLs := splitURLs(os.Getenv("DATABASE_URL_REPLICAS"))
+	for i, url := range replicaURLs {
+		if replicaURLs[i], err = resolveURL(ctx, url); err != nil {
+			return fmt.Errorf("failed to resolve DATABASE_URL_REPLICAS: %w", err)
+		}
 	}
 
-	// Create new connection
-	db, err := NewConnection(pooledURL)
+	// Create and check the new connection while the current one serves
+	dsns := splitURLs(pooledURL)
+	db, index, err := connectFirst(dsns, 0, poolConfig)
 	if err != nil {
 		return fmt.Errorf("failed to create new database connection: %w", err)
 	}
+	if err := db.Health(ctx); err != nil {
+		db.Close()
+		return fmt.Errorf("new database connection is unhealthy: %w", err)
+	}
+	replicas := connectReplicas(replicaURLs, poolConfig)
 
+	// Swap the connections
+	m.mu.Lock()
+	retired := m.replicas
+	if m.database != nil {
+		retired = append(retired, m.database)
+	}
 	m.database = db
+	m.replicas = replicas
+	m.lastErr = nil
+	m.markConnected()
 	m.pooledURL = pooledURL
-	m.directURL = os.Getenv("DATABASE_URL_DIRECT")
+	m.directURL = directURL
+	m.replicaURLs = replicaURLs
+	m.dsns = dsns
+	m.dsnIndex = index
+	subscribers := slices.Clone(m.subscribers)
+	m.mu.Unlock()
 
+	// Let dependents re-bind before the replaced pools start closing
+	for _, fn := range subscribers {
+		fn(db)
+	}
+	primaryDSNIndex.Set(float64(index))
+	retire(retired, poolConfig.ReloadGracePeriod)
 	return nil
 }
 
+// SetURLResolver sets how Reload turns the URLs read from the environment
+// into connection strings, such as by fetching referenced secrets
+func (m *Manager) SetURLResolver(resolve func(ctx context.Context, value string) (string, error)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.resolveURL = resolve
+}
+
+// OnReload registers fn to be called with the new primary connection after
+// each successful Reload or failover. Components holding a pool, prepared statements,
+// or cached state tied to the old database use it to re-bind; the old pool
+// keeps serving for the grace period. Callbacks run in registration order
+// on the reloading goroutine and must not call Reload.
+func (m *Manager) OnReload(fn func(*DB)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.subscribers = append(m.subscribers, fn)
+}
+
+// retire closes replaced pools once their in-flight queries finish or the
+// grace period ends. Closing still waits for connections in use.
+func retire(pools []*DB, grace time.Duration) {
+	for _, old := range pools {
+		go func(old *DB) {
+			deadline := time.Now().Add(grace)
+			for old.Pool.Stat().AcquiredConns() > 0 && time.Now().Before(deadline) {
+				time.Sleep(retirePollInterval)
+			}
+			old.Close()
+		}(old)
+	}
+}
+
 // GetDB returns the current database connection
 func (m *Manager) GetDB() *DB {
 	m.mu.RLock()
@@ -125,7 +282,13 @@ func (m *Manager) Health(ctx context.Context) error {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
+	if m.local != nil {
+		return m.local.PingContext(ctx)
+	}
 	if m.database == nil {
+		if m.lastErr != nil {
+			return fmt.Errorf("database not connected: %w", m.lastErr)
+		}
 		return fmt.Errorf("database not initialized")
 	}
 
@@ -140,4 +303,8 @@ func (m *Manager) Close() {
 	if m.database != nil {
 		m.database.Close()
 	}
+	if m.local != nil {
+		m.local.Close()
+	}
+	m.closeReplicas()
 }
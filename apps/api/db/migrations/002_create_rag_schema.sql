@@ -0,0 +1,52 @@
+-- Migration 002: Create RAG Schema (pgvector document and chunk store)
+-- Stores ingested documents, their chunks, and chunk embeddings for retrieval
+-- Created: 2026-10-16
+
+-- ============================================================
+-- PART 1: Extensions
+-- ============================================================
+
+-- pgvector provides the VECTOR type and similarity operators
+CREATE EXTENSION IF NOT EXISTS vector;
+
+-- ============================================================
+-- PART 2: Document and Chunk Store
+-- ============================================================
+
+-- Source documents that have been ingested into the knowledge base
+CREATE TABLE IF NOT EXISTS rag_documents (
+    id SERIAL PRIMARY KEY,
+    title TEXT NOT NULL,
+    source TEXT, -- Optional origin, e.g. a URL or file name
+    content_type TEXT NOT NULL DEFAULT 'text/plain',
+    metadata JSONB NOT NULL DEFAULT '{}'::JSONB,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Chunks of a document together with their embedding vectors
+-- The dimension matches OpenAI text-embedding-3-small / ada-002 (1536)
+CREATE TABLE IF NOT EXISTS rag_chunks (
+    id SERIAL PRIMARY KEY,
+    document_id INTEGER NOT NULL REFERENCES rag_documents(id) ON DELETE CASCADE,
+    chunk_index INTEGER NOT NULL,
+    content TEXT NOT NULL,
+    metadata JSONB NOT NULL DEFAULT '{}'::JSONB,
+    embedding VECTOR(1536) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    UNIQUE (document_id, chunk_index)
+);
+
+-- Indexes for lookups and nearest-neighbor search (cosine distance)
+CREATE INDEX IF NOT EXISTS idx_rag_chunks_document_id ON rag_chunks(document_id);
+CREATE INDEX IF NOT EXISTS idx_rag_chunks_embedding ON rag_chunks USING hnsw (embedding vector_cosine_ops);
+
+-- ============================================================
+-- PART 3: Triggers
+-- ============================================================
+
+CREATE TRIGGER update_rag_documents_updated_at
+    BEFORE UPDATE ON rag_documents
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,25 @@
+-- Migration 003: Create Ingestion Jobs
+-- Tracks document ingestion into the RAG store (status and error reporting)
+-- Created: 2026-10-16
+
+CREATE TABLE IF NOT EXISTS ingestion_jobs (
+    id SERIAL PRIMARY KEY,
+    title TEXT NOT NULL,
+    source_type TEXT NOT NULL, -- 'text', 'markdown', 'pdf', 'url'
+    source TEXT, -- URL or file name, if any
+    status TEXT NOT NULL DEFAULT 'PENDING', -- 'PENDING', 'PROCESSING', 'COMPLETED', 'FAILED'
+    document_id INTEGER REFERENCES rag_documents(id) ON DELETE SET NULL,
+    chunk_count INTEGER NOT NULL DEFAULT 0,
+    error_message TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_ingestion_jobs_status ON ingestion_jobs(status);
+CREATE INDEX IF NOT EXISTS idx_ingestion_jobs_created_at ON ingestion_jobs(created_at DESC);
+
+CREATE TRIGGER update_ingestion_jobs_updated_at
+    BEFORE UPDATE ON ingestion_jobs
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
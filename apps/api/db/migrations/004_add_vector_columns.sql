@@ -0,0 +1,10 @@
+-- Migration 004: Vector Column Support
+-- Adds metadata for pgvector-backed 'vector' columns in user-defined tables
+-- Created: 2026-10-16
+
+-- Ensure pgvector is available for user tables (also enabled by 002)
+CREATE EXTENSION IF NOT EXISTS vector;
+
+ALTER TABLE configurable_columns
+    ADD COLUMN IF NOT EXISTS vector_dimensions INTEGER, -- Dimension n for VECTOR(n) columns
+    ADD COLUMN IF NOT EXISTS vector_index_type TEXT; -- 'hnsw', 'ivfflat', or NULL for no index
@@ -0,0 +1,22 @@
+-- Migration 005: Create Agent Profiles
+-- Named agent configurations (persona, tools, model) selectable per request
+-- Created: 2026-10-16
+
+CREATE TABLE IF NOT EXISTS agent_profiles (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE, -- e.g. "Support Assistant"
+    description TEXT,
+    system_prompt TEXT NOT NULL DEFAULT '',
+    provider TEXT NOT NULL DEFAULT 'openai', -- 'openai', 'anthropic', 'google'
+    model TEXT, -- NULL uses the provider default
+    temperature DOUBLE PRECISION NOT NULL DEFAULT 0.7,
+    max_tokens INTEGER NOT NULL DEFAULT 2000,
+    allowed_tools TEXT[], -- NULL allows every registered tool
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TRIGGER update_agent_profiles_updated_at
+    BEFORE UPDATE ON agent_profiles
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
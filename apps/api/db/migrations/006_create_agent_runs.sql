@@ -0,0 +1,31 @@
+-- Migration 006: Create Agent Runs
+-- Persisted traces of agent runs for debugging and replay
+-- Created: 2026-10-16
+
+CREATE TABLE IF NOT EXISTS agent_runs (
+    id SERIAL PRIMARY KEY,
+    conversation_id TEXT,
+    profile_id INTEGER REFERENCES agent_profiles(id) ON DELETE SET NULL,
+    delegate_profile_ids INTEGER[], -- Specialist agents available to the run
+    input TEXT NOT NULL,
+    metadata JSONB NOT NULL DEFAULT '{}', -- Request metadata, kept for replay
+    output TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT 'RUNNING', -- 'RUNNING', 'COMPLETED', 'FAILED'
+    error_message TEXT,
+    events JSONB NOT NULL DEFAULT '[]', -- Thoughts, tool calls, and tool outputs in order
+    prompt_tokens INTEGER NOT NULL DEFAULT 0,
+    completion_tokens INTEGER NOT NULL DEFAULT 0,
+    total_tokens INTEGER NOT NULL DEFAULT 0,
+    duration_ms BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_agent_runs_created_at ON agent_runs(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_agent_runs_conversation_id ON agent_runs(conversation_id);
+
+CREATE TRIGGER update_agent_runs_updated_at
+    BEFORE UPDATE ON agent_runs
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
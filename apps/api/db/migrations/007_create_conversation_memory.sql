@@ -0,0 +1,20 @@
+-- Migration 007: Create Conversation Memory
+-- Per-conversation memory (rolling summary + recent turns) and profile memory strategy
+-- Created: 2026-10-16
+
+CREATE TABLE IF NOT EXISTS conversation_memory (
+    conversation_id TEXT PRIMARY KEY,
+    summary TEXT NOT NULL DEFAULT '', -- LLM summary of turns pruned from the buffer
+    messages JSONB NOT NULL DEFAULT '[]', -- Recent turns kept verbatim: [{"role": "human", "content": "..."}]
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TRIGGER update_conversation_memory_updated_at
+    BEFORE UPDATE ON conversation_memory
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+ALTER TABLE agent_profiles
+    ADD COLUMN IF NOT EXISTS memory_strategy TEXT NOT NULL DEFAULT 'buffer', -- 'buffer' or 'summary'
+    ADD COLUMN IF NOT EXISTS memory_max_tokens INTEGER NOT NULL DEFAULT 2000; -- Buffer size before summarizing
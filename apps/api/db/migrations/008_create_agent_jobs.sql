@@ -0,0 +1,23 @@
+-- Migration 008: Create Agent Jobs
+-- Queue of asynchronous agent runs processed by a worker pool and polled by clients
+-- Created: 2026-10-16
+
+CREATE TABLE IF NOT EXISTS agent_jobs (
+    id SERIAL PRIMARY KEY,
+    request JSONB NOT NULL, -- Query, profile, delegates, and limits of the run
+    status TEXT NOT NULL DEFAULT 'PENDING', -- 'PENDING', 'PROCESSING', 'COMPLETED', 'FAILED'
+    output TEXT NOT NULL DEFAULT '',
+    events JSONB NOT NULL DEFAULT '[]', -- Thoughts, tool calls, and guardrail events streamed so far
+    error_message TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    started_at TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_agent_jobs_status_created_at ON agent_jobs(status, created_at);
+
+CREATE TRIGGER update_agent_jobs_updated_at
+    BEFORE UPDATE ON agent_jobs
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
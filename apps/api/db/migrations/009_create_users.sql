@@ -0,0 +1,36 @@
+-- Migration 009: Create Users
+-- User accounts signing in with a password, and the refresh tokens issued to them
+-- Created: 2026-10-16
+
+CREATE TABLE IF NOT EXISTS users (
+    id SERIAL PRIMARY KEY,
+    email TEXT NOT NULL, -- Stored lowercased
+    password_hash TEXT NOT NULL, -- argon2id in PHC string format
+    roles TEXT[] NOT NULL DEFAULT '{}', -- Empty gets AUTHZ_DEFAULT_ROLE
+    disabled BOOLEAN NOT NULL DEFAULT FALSE,
+    last_login_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users(lower(email));
+
+CREATE TRIGGER update_users_updated_at
+    BEFORE UPDATE ON users
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Refresh tokens are single use: refreshing revokes the token and issues a
+-- successor in the same family. Presenting a revoked token revokes its family.
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    family UUID NOT NULL, -- Tokens descended from the same login
+    token_hash BYTEA NOT NULL UNIQUE, -- SHA-256 of the token; the token itself is never stored
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family ON refresh_tokens(family);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user_id ON refresh_tokens(user_id);
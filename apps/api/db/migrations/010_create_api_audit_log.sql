@@ -0,0 +1,21 @@
+-- Migration 010: Create API Audit Log
+-- Every mutating gRPC and HTTP call, with its actor and outcome, for compliance review
+-- Created: 2026-10-16
+
+CREATE TABLE IF NOT EXISTS api_audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    occurred_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    actor TEXT NOT NULL, -- Principal ID, or 'system' when unauthenticated
+    transport TEXT NOT NULL, -- 'grpc' or 'http'
+    method TEXT NOT NULL, -- Full RPC method, or HTTP method and route
+    resource TEXT NOT NULL DEFAULT '', -- IDs named by the request, e.g. 'table_id=3'
+    request_summary JSONB, -- Request with secrets redacted and long values truncated
+    status TEXT NOT NULL, -- gRPC code name or HTTP status
+    latency_ms BIGINT NOT NULL,
+    request_id TEXT NOT NULL DEFAULT '',
+    client_ip TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_audit_log_occurred_at ON api_audit_log(occurred_at);
+CREATE INDEX IF NOT EXISTS idx_api_audit_log_actor_id ON api_audit_log(actor, id DESC);
+CREATE INDEX IF NOT EXISTS idx_api_audit_log_method_id ON api_audit_log(method, id DESC);
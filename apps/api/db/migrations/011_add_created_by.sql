@@ -0,0 +1,7 @@
+-- Migration 011: Add Created By
+-- Record the principal that created tables, agent runs, and agent jobs
+-- Created: 2026-10-16
+
+ALTER TABLE configurable_tables ADD COLUMN IF NOT EXISTS created_by TEXT; -- Principal ID, or 'system'
+ALTER TABLE agent_runs ADD COLUMN IF NOT EXISTS created_by TEXT;
+ALTER TABLE agent_jobs ADD COLUMN IF NOT EXISTS created_by TEXT;
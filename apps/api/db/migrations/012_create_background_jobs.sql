@@ -0,0 +1,28 @@
+-- Migration 012: Create Background Jobs
+-- Durable queue of background work such as imports, webhooks, and exports, retried with backoff
+-- Created: 2026-10-16
+
+CREATE TABLE IF NOT EXISTS background_jobs (
+    id BIGSERIAL PRIMARY KEY,
+    kind TEXT NOT NULL, -- Selects the handler that runs the job
+    payload JSONB NOT NULL DEFAULT '{}',
+    status TEXT NOT NULL DEFAULT 'PENDING', -- 'PENDING', 'RUNNING', 'SUCCEEDED', 'DEAD'
+    attempts INTEGER NOT NULL DEFAULT 0, -- Runs started so far
+    max_attempts INTEGER NOT NULL DEFAULT 5, -- Runs before the job is dead-lettered
+    run_at TIMESTAMPTZ NOT NULL DEFAULT NOW(), -- Not claimed before this time, for delays and backoff
+    last_error TEXT,
+    locked_at TIMESTAMPTZ, -- When the running attempt was claimed
+    created_by TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMPTZ
+);
+
+-- Claims scan pending jobs that are due
+CREATE INDEX IF NOT EXISTS idx_background_jobs_pending ON background_jobs(run_at, id) WHERE status = 'PENDING';
+CREATE INDEX IF NOT EXISTS idx_background_jobs_status_kind ON background_jobs(status, kind);
+
+CREATE TRIGGER update_background_jobs_updated_at
+    BEFORE UPDATE ON background_jobs
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,12 @@
+-- Migration 013: Create Audit Export Cursors
+-- How far each external audit sink has been sent each audit source, so exports resume after restarts
+-- Created: 2026-10-16
+
+CREATE TABLE IF NOT EXISTS audit_export_cursors (
+    sink TEXT NOT NULL, -- Sink name, e.g. 'webhook:audit.example.com/ingest'
+    source TEXT NOT NULL, -- 'api' (api_audit_log) or 'schema' (schema_change_log)
+    last_id BIGINT NOT NULL DEFAULT 0, -- ID of the last event sent; later events are pending
+    last_error TEXT, -- Why the last export attempt failed, cleared on success
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (sink, source)
+);
@@ -0,0 +1,15 @@
+-- Migration 014: Create Table Usage
+-- API requests per user-defined table and hour, with a latency histogram, for usage analytics
+-- Created: 2026-10-16
+
+CREATE TABLE IF NOT EXISTS table_usage (
+    table_id INTEGER NOT NULL, -- configurable_tables.id; rows of deleted tables are purged
+    bucket TIMESTAMPTZ NOT NULL, -- Start of the hour the requests arrived in
+    reads BIGINT NOT NULL DEFAULT 0,
+    writes BIGINT NOT NULL DEFAULT 0,
+    errors BIGINT NOT NULL DEFAULT 0, -- Failed requests, also counted as reads or writes
+    latency_counts BIGINT[] NOT NULL, -- Requests per latency bucket, bounded by analytics.LatencyBoundsMS
+    PRIMARY KEY (table_id, bucket)
+);
+
+CREATE INDEX IF NOT EXISTS idx_table_usage_bucket ON table_usage(bucket);
@@ -0,0 +1,10 @@
+-- Migration 015: Column Format Metadata
+-- Adds optional presentation settings to columns so every client renders and exports values the same way
+-- Created: 2026-10-16
+
+ALTER TABLE configurable_columns
+    ADD COLUMN IF NOT EXISTS format_timezone TEXT, -- IANA zone dates are shown in, e.g. 'Europe/Paris' (date columns)
+    ADD COLUMN IF NOT EXISTS format_date TEXT, -- Date format preset: 'iso', 'date', 'datetime', 'us', 'eu', or 'long' (date columns)
+    ADD COLUMN IF NOT EXISTS format_number_locale TEXT, -- BCP 47 tag for separators, e.g. 'de-DE' (number and decimal columns)
+    ADD COLUMN IF NOT EXISTS format_currency TEXT, -- ISO 4217 code shown with the value, e.g. 'EUR' (number and decimal columns)
+    ADD COLUMN IF NOT EXISTS format_currency_display TEXT; -- 'symbol', 'code', or 'name'; requires format_currency
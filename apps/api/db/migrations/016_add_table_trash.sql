@@ -0,0 +1,9 @@
+-- Migration 016: Table Trash
+-- Deleted tables are moved to the trash (renamed to trash_<name>) until they are restored or purged
+-- Created: 2026-10-16
+
+ALTER TABLE configurable_tables
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ, -- When the table was moved to the trash; NULL for live tables
+    ADD COLUMN IF NOT EXISTS deleted_by TEXT; -- Principal that deleted it
+
+CREATE INDEX IF NOT EXISTS idx_configurable_tables_deleted_at ON configurable_tables(deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,6 @@
+-- Migration 017: Formula Columns
+-- Formula columns have no database column; their expression is evaluated from sibling columns when records are read
+-- Created: 2026-10-16
+
+ALTER TABLE configurable_columns
+    ADD COLUMN IF NOT EXISTS formula TEXT; -- Expression of 'formula' columns, e.g. 'price * quantity'; NULL for stored columns
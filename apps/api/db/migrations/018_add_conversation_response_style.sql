@@ -0,0 +1,6 @@
+-- Migration 018: Conversation Response Style
+-- Remembers the language and tone a conversation's answers are given in, so later turns needn't repeat them
+-- Created: 2026-10-16
+
+ALTER TABLE conversation_memory
+    ADD COLUMN IF NOT EXISTS response_style JSONB NOT NULL DEFAULT '{}'; -- {"language": "de", "verbosity": "concise", "formality": "formal"}
@@ -0,0 +1,10 @@
+-- Migration 019: Conversation Sessions
+-- Stores an LLM-generated title and rolling recap per conversation for chat history lists
+-- Created: 2026-10-16
+
+ALTER TABLE conversation_memory
+    ADD COLUMN IF NOT EXISTS title TEXT NOT NULL DEFAULT '', -- Generated from the first turn
+    ADD COLUMN IF NOT EXISTS recap TEXT NOT NULL DEFAULT '', -- Rolling summary of the whole conversation, updated every turn
+    ADD COLUMN IF NOT EXISTS turns INTEGER NOT NULL DEFAULT 0; -- Completed turns folded into the recap
+
+CREATE INDEX IF NOT EXISTS idx_conversation_memory_updated_at ON conversation_memory (updated_at DESC);
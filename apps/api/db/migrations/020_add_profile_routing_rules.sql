@@ -0,0 +1,6 @@
+-- Migration 020: Profile Routing Rules
+-- Lets agent profiles pick the model per classified task complexity for cost-aware routing
+-- Created: 2026-10-16
+
+ALTER TABLE agent_profiles
+    ADD COLUMN IF NOT EXISTS routing_rules JSONB; -- e.g. {"simple": "gpt-4o-mini"}; NULL routes only when MODEL_ROUTING_ENABLED is set
@@ -0,0 +1,7 @@
+-- Migration 021: Column Permissions
+-- Lets roles be denied reading or writing sensitive columns of configurable tables
+-- Created: 2026-10-16
+
+ALTER TABLE configurable_columns
+    ADD COLUMN IF NOT EXISTS deny_read_roles TEXT, -- Comma-separated roles whose reads omit the column, e.g. 'viewer,editor'; NULL denies none
+    ADD COLUMN IF NOT EXISTS deny_write_roles TEXT; -- Comma-separated roles whose writes to the column are rejected; NULL denies none
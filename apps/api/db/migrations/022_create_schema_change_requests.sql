@@ -0,0 +1,19 @@
+-- Migration 022: Schema Change Requests
+-- Schema changes by non-admins wait here for an admin's approval when SCHEMA_CHANGE_APPROVAL is set
+-- Created: 2026-10-16
+
+CREATE TABLE IF NOT EXISTS schema_change_requests (
+    id SERIAL PRIMARY KEY,
+    change_type TEXT NOT NULL, -- 'CREATE_TABLE', 'DROP_TABLE'
+    table_id INTEGER REFERENCES configurable_tables(id) ON DELETE SET NULL, -- Table deleted, or created once approved
+    change_details JSONB NOT NULL, -- The stored request, replayed on approval
+    status TEXT NOT NULL DEFAULT 'pending', -- 'pending', 'approved', 'rejected', 'failed'
+    requested_by TEXT,
+    reviewed_by TEXT,
+    review_comment TEXT,
+    error_message TEXT, -- Why replaying an approved request failed
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    reviewed_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_schema_change_requests_status ON schema_change_requests(status, id);
@@ -0,0 +1,27 @@
+-- Migration 023: Data Imports
+-- Tracks copies of tables from external databases into user tables, run as background jobs
+-- Created: 2026-10-16
+
+CREATE TABLE IF NOT EXISTS data_imports (
+    id SERIAL PRIMARY KEY,
+    source TEXT NOT NULL, -- Name of the source in IMPORT_SOURCES; its URL is never stored
+    source_table TEXT NOT NULL, -- Schema-qualified table of the source
+    table_id INTEGER REFERENCES configurable_tables(id) ON DELETE SET NULL, -- User table receiving the rows
+    status TEXT NOT NULL DEFAULT 'pending', -- 'pending', 'running', 'succeeded', 'failed'
+    job_id BIGINT, -- background_jobs row copying the data
+    rows_total BIGINT NOT NULL DEFAULT 0, -- Source's row estimate
+    rows_copied BIGINT NOT NULL DEFAULT 0,
+    rows_failed BIGINT NOT NULL DEFAULT 0, -- Rows whose values didn't fit their column
+    error_message TEXT,
+    created_by TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_data_imports_status ON data_imports(status, id);
+
+CREATE TRIGGER update_data_imports_updated_at
+    BEFORE UPDATE ON data_imports
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,6 @@
+-- Migration 024: Import Sync
+-- Imports with a sync interval copy their source again periodically, replacing the table's records
+-- Created: 2026-10-16
+
+ALTER TABLE data_imports
+    ADD COLUMN IF NOT EXISTS sync_interval_minutes INTEGER NOT NULL DEFAULT 0; -- 0 imports once
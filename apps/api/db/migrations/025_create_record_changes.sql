@@ -0,0 +1,68 @@
+-- Migration 025: Record Change Log and Warehouse Syncs
+-- Logs each insert, update, and delete of a user table's records, which
+-- warehouse syncs replicate to external warehouses on a schedule
+-- Created: 2026-10-16
+
+CREATE TABLE IF NOT EXISTS record_changes (
+    id BIGSERIAL PRIMARY KEY,
+    table_name TEXT NOT NULL, -- Physical name of the user table
+    record_id INTEGER NOT NULL,
+    operation TEXT NOT NULL, -- 'insert', 'update', 'delete'
+    xact_id BIGINT NOT NULL, -- Writing transaction, so readers know which changes are committed
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_record_changes_xact_id ON record_changes(xact_id);
+
+-- Logs a change into the record_changes table of the user table's own
+-- schema, so tenant and sandbox tables log into their schema
+CREATE OR REPLACE FUNCTION log_record_change()
+RETURNS TRIGGER AS $$
+DECLARE
+    changed_id INTEGER;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        changed_id := OLD.id;
+    ELSE
+        changed_id := NEW.id;
+    END IF;
+    EXECUTE format('INSERT INTO %I.record_changes (table_name, record_id, operation, xact_id) VALUES ($1, $2, $3, pg_current_xact_id()::text::bigint)', TG_TABLE_SCHEMA)
+        USING TG_TABLE_NAME, changed_id, lower(TG_OP);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Existing user tables; tables created later get the trigger with their table
+DO $$
+DECLARE
+    user_table TEXT;
+BEGIN
+    FOR user_table IN SELECT table_name FROM configurable_tables WHERE to_regclass(table_name) IS NOT NULL LOOP
+        EXECUTE format('CREATE OR REPLACE TRIGGER %I AFTER INSERT OR UPDATE OR DELETE ON %I FOR EACH ROW EXECUTE FUNCTION log_record_change()',
+            'log_' || user_table || '_changes', user_table);
+    END LOOP;
+END $$;
+
+CREATE TABLE IF NOT EXISTS warehouse_syncs (
+    id SERIAL PRIMARY KEY,
+    destination TEXT NOT NULL, -- Name of the destination in SYNC_DESTINATIONS; its URL is never stored
+    table_ids INTEGER[] NOT NULL DEFAULT '{}', -- User tables replicated; empty replicates all
+    interval_minutes INTEGER NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT true,
+    job_id BIGINT, -- background_jobs row of the next run
+    cursor_xact_id BIGINT NOT NULL DEFAULT 0, -- Changes of transactions from this one on are not replicated yet
+    snapshot_done BOOLEAN NOT NULL DEFAULT false, -- Whether the records present when the sync started were copied
+    rows_synced BIGINT NOT NULL DEFAULT 0,
+    last_synced_at TIMESTAMPTZ,
+    last_error TEXT,
+    consecutive_failures INTEGER NOT NULL DEFAULT 0,
+    alerted_at TIMESTAMPTZ, -- When the failure alert of the current run of failures was sent
+    created_by TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TRIGGER update_warehouse_syncs_updated_at
+    BEFORE UPDATE ON warehouse_syncs
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
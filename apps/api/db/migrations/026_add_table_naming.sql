@@ -0,0 +1,6 @@
+-- Migration 026: Table Naming Strategies
+-- Records the naming strategy each configurable table's database names were derived with
+-- Created: 2026-10-16
+
+ALTER TABLE configurable_tables
+    ADD COLUMN IF NOT EXISTS naming JSONB; -- {"table_prefix": "user_table_", "case": "lower", "truncation": "cut", "max_length": 63}; NULL for tables named before strategies were configurable, which used those defaults
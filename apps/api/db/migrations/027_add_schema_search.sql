@@ -0,0 +1,15 @@
+-- Migration 027: Schema Search
+-- Adds column descriptions and full-text indexes over the names and descriptions of tables and columns, searched by SearchSchema
+-- Created: 2026-10-17
+
+ALTER TABLE configurable_columns
+    ADD COLUMN IF NOT EXISTS description TEXT;
+
+-- Names weigh more than descriptions. The expressions must match the ones
+-- schema_manager searches with, or the indexes go unused.
+CREATE INDEX IF NOT EXISTS idx_configurable_tables_search ON configurable_tables USING GIN (
+    (setweight(to_tsvector('simple', name), 'A') || setweight(to_tsvector('simple', COALESCE(description, '')), 'B'))
+);
+CREATE INDEX IF NOT EXISTS idx_configurable_columns_search ON configurable_columns USING GIN (
+    (setweight(to_tsvector('simple', name || ' ' || column_name), 'A') || setweight(to_tsvector('simple', COALESCE(description, '')), 'B'))
+);
@@ -0,0 +1,6 @@
+-- Migration 028: Profile Max Iterations
+-- Lets agent profiles set their reasoning/tool iteration limit, which also bounds them as delegates
+-- Created: 2026-10-17
+
+ALTER TABLE agent_profiles
+    ADD COLUMN IF NOT EXISTS max_iterations INTEGER CHECK (max_iterations > 0); -- NULL uses AGENT_MAX_ITERATIONS
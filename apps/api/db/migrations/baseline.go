@@ -0,0 +1,105 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrOutOfOrder is returned when a pending migration is older than the
+// latest applied one, as when a branch adding it merges late
+var ErrOutOfOrder = errors.New("out-of-order migrations")
+
+// allowOutOfOrder applies out-of-order migrations instead of failing
+var allowOutOfOrder bool
+
+// SetAllowOutOfOrder sets whether pending migrations older than the latest
+// applied one are applied. Off by default, since such a migration may
+// assume a schema that later migrations have changed.
+func SetAllowOutOfOrder(allow bool) {
+	allowOutOfOrder = allow
+}
+
+// checkOrder fails when a pending migration is older than the latest
+// applied one, unless out-of-order migrations are allowed
+func checkOrder(migrations []Migration, applied map[int]bool) error {
+	latest := 0
+	for version := range applied {
+		latest = max(latest, version)
+	}
+
+	var late []string
+	for _, migration := range migrations {
+		if !applied[migration.Version] && migration.Version < latest {
+			late = append(late, fmt.Sprintf("%03d (%s)", migration.Version, migration.Name))
+		}
+	}
+	if len(late) == 0 {
+		return nil
+	}
+	if allowOutOfOrder {
+		log.Printf("Warning: applying migrations older than the latest applied %03d: %s", latest, strings.Join(late, ", "))
+		return nil
+	}
+	return fmt.Errorf("%w: %s older than the latest applied migration %03d; renumber them or set MIGRATIONS_ALLOW_OUT_OF_ORDER=true",
+		ErrOutOfOrder, strings.Join(late, ", "), latest)
+}
+
+// Baseline adopts an existing database whose schema already matches the
+// migrations up to version: they are recorded as applied, marked as a
+// baseline, without running. Later migrations apply as usual.
+func Baseline(ctx context.Context, pool *pgxpool.Pool, version int) error {
+	return withLock(ctx, pool, func() error {
+		if err := createMigrationsTable(ctx, pool); err != nil {
+			return fmt.Errorf("failed to create migrations table: %w", err)
+		}
+		applied, err := getAppliedMigrations(ctx, pool)
+		if err != nil {
+			return fmt.Errorf("failed to get applied migrations: %w", err)
+		}
+		migrations, err := loadMigrations()
+		if err != nil {
+			return fmt.Errorf("failed to load migrations: %w", err)
+		}
+
+		known := false
+		batch := &pgx.Batch{}
+		for _, migration := range migrations {
+			if migration.Version == version {
+				known = true
+			}
+			if migration.Version > version || applied[migration.Version] {
+				continue
+			}
+			batch.Queue(`INSERT INTO schema_migrations (version, name, baseline) VALUES ($1, $2, TRUE)`,
+				migration.Version, migration.Name)
+		}
+		if !known {
+			return fmt.Errorf("no migration has version %03d", version)
+		}
+
+		if err := pool.SendBatch(ctx, batch).Close(); err != nil {
+			return fmt.Errorf("failed to record baseline: %w", err)
+		}
+		log.Printf("Baselined %d migration(s) up to %03d", batch.Len(), version)
+		return nil
+	})
+}
+
+// NextVersion returns the version following the latest embedded or
+// external migration
+func NextVersion() (int, error) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return 0, err
+	}
+	if len(migrations) == 0 {
+		return 1, nil
+	}
+	return migrations[len(migrations)-1].Version + 1, nil
+}
@@ -0,0 +1,62 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// lockName names the session advisory lock held while migrations run
+const lockName = "schema_migrations"
+
+// lockPollInterval is how often a waiting runner retries the lock. Polling
+// rather than blocking in pg_advisory_lock keeps the wait clear of the
+// session statement_timeout.
+const lockPollInterval = time.Second
+
+// withLock runs fn while holding the migration lock on a dedicated
+// connection. The lock is a session lock, so it needs session pooling: a
+// transaction-mode pooler could hand the lock to another client.
+func withLock(ctx context.Context, pool *pgxpool.Pool, fn func() error) error {
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire a connection for the migration lock: %w", err)
+	}
+	defer conn.Release()
+
+	waiting := false
+	for {
+		var locked bool
+		if err := conn.QueryRow(ctx, "SELECT pg_try_advisory_lock(hashtext($1))", lockName).Scan(&locked); err != nil {
+			return fmt.Errorf("failed to take the migration lock: %w", err)
+		}
+		if locked {
+			break
+		}
+		if !waiting {
+			log.Println("Waiting for another instance to finish migrations...")
+			waiting = true
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("gave up waiting for the migration lock: %w", ctx.Err())
+		case <-time.After(lockPollInterval):
+		}
+	}
+
+	defer func() {
+		// Unlock even when ctx expired; closing the session releases the
+		// lock if unlocking fails
+		unlockCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if _, err := conn.Exec(unlockCtx, "SELECT pg_advisory_unlock(hashtext($1))", lockName); err != nil {
+			log.Printf("Warning: failed to release the migration lock, closing its connection: %v", err)
+			conn.Conn().Close(unlockCtx)
+		}
+	}()
+
+	return fn()
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"embed"
 	"fmt"
+	"io/fs"
 	"log"
+	"os"
 	"path/filepath"
 	"sort"
 	"strings"
@@ -15,17 +17,41 @@ import (
 //go:embed *.sql
 var migrationsFS embed.FS
 
+// embeddedSource is the Source of migrations built into the binary
+const embeddedSource = "embedded"
+
+// externalDir holds deployment-specific migrations applied along with the
+// embedded ones; empty disables it
+var externalDir string
+
 // Migration represents a single database migration
 type Migration struct {
 	Version int
 	Name    string
 	SQL     string
+	Source  string // "embedded" or the external directory holding the file
+}
+
+// SetExternalDir sets a directory of additional migrations, named like the
+// embedded ones. Their versions must not collide with embedded versions.
+func SetExternalDir(dir string) {
+	externalDir = dir
 }
 
-// RunMigrations executes all pending migrations
+// RunMigrations executes all pending migrations. Instances starting at the
+// same time take turns through an advisory lock; later ones find the
+// migrations applied and only verify the version.
 func RunMigrations(ctx context.Context, pool *pgxpool.Pool) error {
 	log.Println("Starting database migrations...")
 
+	return withLock(ctx, pool, func() error {
+		return applyPending(ctx, pool)
+	})
+}
+
+// applyPending applies the migrations not yet recorded, then checks the
+// database reached the latest version; the caller holds the lock
+func applyPending(ctx context.Context, pool *pgxpool.Pool) error {
 	// Create migrations tracking table if it doesn't exist
 	if err := createMigrationsTable(ctx, pool); err != nil {
 		return fmt.Errorf("failed to create migrations table: %w", err)
@@ -43,10 +69,10 @@ func RunMigrations(ctx context.Context, pool *pgxpool.Pool) error {
 		return fmt.Errorf("failed to load migrations: %w", err)
 	}
 
-	// Sort migrations by version
-	sort.Slice(migrations, func(i, j int) bool {
-		return migrations[i].Version < migrations[j].Version
-	})
+	// Refuse migrations older than the latest applied one unless allowed
+	if err := checkOrder(migrations, appliedMigrations); err != nil {
+		return err
+	}
 
 	// Execute pending migrations
 	executed := 0
@@ -56,7 +82,7 @@ func RunMigrations(ctx context.Context, pool *pgxpool.Pool) error {
 			continue
 		}
 
-		log.Printf("Applying migration %03d: %s", migration.Version, migration.Name)
+		log.Printf("Applying migration %03d: %s (%s)", migration.Version, migration.Name, migration.Source)
 		if err := applyMigration(ctx, pool, migration); err != nil {
 			return fmt.Errorf("failed to apply migration %03d: %w", migration.Version, err)
 		}
@@ -69,6 +95,16 @@ func RunMigrations(ctx context.Context, pool *pgxpool.Pool) error {
 		log.Printf("Successfully applied %d migration(s)", executed)
 	}
 
+	// Verify the version, which another instance may have advanced
+	version, err := GetCurrentVersion(ctx, pool)
+	if err != nil {
+		return fmt.Errorf("failed to verify migration version: %w", err)
+	}
+	if len(migrations) > 0 && version < migrations[len(migrations)-1].Version {
+		return fmt.Errorf("database is at migration %03d, expected %03d", version, migrations[len(migrations)-1].Version)
+	}
+	log.Printf("Database schema at migration %03d", version)
+
 	return nil
 }
 
@@ -79,7 +115,8 @@ func createMigrationsTable(ctx context.Context, pool *pgxpool.Pool) error {
 			version INTEGER PRIMARY KEY,
 			name TEXT NOT NULL,
 			applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
-		)
+		);
+		ALTER TABLE schema_migrations ADD COLUMN IF NOT EXISTS baseline BOOLEAN NOT NULL DEFAULT FALSE
 	`
 	_, err := pool.Exec(ctx, query)
 	return err
@@ -106,9 +143,36 @@ func getAppliedMigrations(ctx context.Context, pool *pgxpool.Pool) (map[int]bool
 	return applied, rows.Err()
 }
 
-// loadMigrations reads all migration files from the embedded filesystem
+// loadMigrations reads the embedded and external migrations, ordered by
+// version. A version defined twice is an error.
 func loadMigrations() ([]Migration, error) {
-	entries, err := migrationsFS.ReadDir(".")
+	migrations, err := loadFrom(migrationsFS, embeddedSource)
+	if err != nil {
+		return nil, err
+	}
+	if externalDir != "" {
+		external, err := loadFrom(os.DirFS(externalDir), externalDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load migrations from %s: %w", externalDir, err)
+		}
+		migrations = append(migrations, external...)
+	}
+
+	sort.SliceStable(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	for i := 1; i < len(migrations); i++ {
+		if prev, cur := migrations[i-1], migrations[i]; prev.Version == cur.Version {
+			return nil, fmt.Errorf("migration version %03d is defined by both %s (%s) and %s (%s)",
+				cur.Version, prev.Name, prev.Source, cur.Name, cur.Source)
+		}
+	}
+	return migrations, nil
+}
+
+// loadFrom reads the migration files at the root of a filesystem
+func loadFrom(fsys fs.FS, source string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
 	if err != nil {
 		return nil, err
 	}
@@ -134,7 +198,7 @@ func loadMigrations() ([]Migration, error) {
 		}
 
 		// Read the SQL content
-		content, err := migrationsFS.ReadFile(entry.Name())
+		content, err := fs.ReadFile(fsys, entry.Name())
 		if err != nil {
 			return nil, fmt.Errorf("failed to read %s: %w", entry.Name(), err)
 		}
@@ -146,6 +210,7 @@ func loadMigrations() ([]Migration, error) {
 			Version: version,
 			Name:    name,
 			SQL:     string(content),
+			Source:  source,
 		})
 	}
 
@@ -183,6 +248,28 @@ func applyMigration(ctx context.Context, pool *pgxpool.Pool, migration Migration
 	return nil
 }
 
+// Pending returns the embedded and external migrations not yet applied to the database,
+// oldest first
+func Pending(ctx context.Context, pool *pgxpool.Pool) ([]Migration, error) {
+	appliedMigrations, err := getAppliedMigrations(ctx, pool)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get applied migrations: %w", err)
+	}
+
+	migrations, err := loadMigrations()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load migrations: %w", err)
+	}
+
+	var pending []Migration
+	for _, migration := range migrations {
+		if !appliedMigrations[migration.Version] {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
 // GetCurrentVersion returns the latest applied migration version
 func GetCurrentVersion(ctx context.Context, pool *pgxpool.Pool) (int, error) {
 	query := `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`
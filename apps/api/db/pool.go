@@ -0,0 +1,92 @@
+package db
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PoolConfig tunes the runtime connection pool
+type PoolConfig struct {
+	MaxConns          int32
+	MinConns          int32
+	MaxConnLifetime   time.Duration
+	MaxConnIdleTime   time.Duration
+	HealthCheckPeriod time.Duration
+	ConnectTimeout    time.Duration
+	// StatementTimeout is set as the session statement_timeout so the server
+	// cancels runaway queries even when the client is gone; 0 leaves the
+	// server default
+	StatementTimeout time.Duration
+	// SlowQueryThreshold logs queries running at least this long as
+	// warnings; 0 disables it. Every query is logged at debug level.
+	SlowQueryThreshold time.Duration
+	// ScopeSearchPath sets each connection's search_path to the tenant
+	// schema of the context acquiring it (see requestctx.WithTenant)
+	ScopeSearchPath bool
+	// ReloadGracePeriod is how long a pool replaced by Manager.Reload keeps
+	// serving in-flight queries before it is closed
+	ReloadGracePeriod time.Duration
+	// StatementCacheCapacity is how many prepared statements each
+	// connection keeps, keyed by SQL text, so repeated queries skip parsing
+	// and planning. 0 prepares nothing and describes each query instead,
+	// for transaction-mode poolers that can't keep prepared statements.
+	StatementCacheCapacity int
+}
+
+// DefaultStatementCacheCapacity is pgx's default number of prepared
+// statements per connection
+const DefaultStatementCacheCapacity = 512
+
+// DefaultPoolConfig returns the pool settings used when none are configured
+func DefaultPoolConfig() PoolConfig {
+	return PoolConfig{
+		MaxConns:               20,
+		MinConns:               2,
+		MaxConnLifetime:        time.Hour,
+		MaxConnIdleTime:        30 * time.Minute,
+		HealthCheckPeriod:      time.Minute,
+		ConnectTimeout:         5 * time.Second,
+		StatementTimeout:       DefaultStatementTimeout,
+		SlowQueryThreshold:     DefaultSlowQueryThreshold,
+		ReloadGracePeriod:      30 * time.Second,
+		StatementCacheCapacity: DefaultStatementCacheCapacity,
+	}
+}
+
+// apply copies the settings onto a parsed pool config
+func (c PoolConfig) apply(config *pgxpool.Config) error {
+	if c.MaxConns < 1 {
+		return fmt.Errorf("max connections must be at least 1, got %d", c.MaxConns)
+	}
+	if c.MinConns < 0 || c.MinConns > c.MaxConns {
+		return fmt.Errorf("min connections must be between 0 and %d, got %d", c.MaxConns, c.MinConns)
+	}
+	if c.StatementCacheCapacity < 0 {
+		return fmt.Errorf("statement cache capacity must be at least 0, got %d", c.StatementCacheCapacity)
+	}
+
+	config.MaxConns = c.MaxConns
+	config.MinConns = c.MinConns
+	config.MaxConnLifetime = c.MaxConnLifetime
+	config.MaxConnIdleTime = c.MaxConnIdleTime
+	config.HealthCheckPeriod = c.HealthCheckPeriod
+	config.ConnConfig.ConnectTimeout = c.ConnectTimeout
+	config.ConnConfig.Tracer = &queryTracer{slowThreshold: c.SlowQueryThreshold}
+	config.ConnConfig.StatementCacheCapacity = c.StatementCacheCapacity
+	if c.StatementCacheCapacity == 0 && config.ConnConfig.DefaultQueryExecMode == pgx.QueryExecModeCacheStatement {
+		config.ConnConfig.DefaultQueryExecMode = pgx.QueryExecModeDescribeExec
+	}
+	if c.StatementTimeout > 0 {
+		config.ConnConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(c.StatementTimeout.Milliseconds(), 10)
+	}
+	if c.ScopeSearchPath {
+		scoper := newSearchPathScoper()
+		config.BeforeAcquire = scoper.beforeAcquire
+		config.BeforeClose = scoper.beforeClose
+	}
+	return nil
+}
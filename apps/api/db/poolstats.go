@@ -0,0 +1,155 @@
+package db
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"agentic-template/api/metrics"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// DefaultPoolStatsInterval is how often pool statistics are exported when
+// no interval is configured
+const DefaultPoolStatsInterval = 15 * time.Second
+
+var (
+	poolAcquiredConns = metrics.NewGauge("db_pool_acquired_conns",
+		"Connections currently in use, by pool.", "pool")
+	poolIdleConns = metrics.NewGauge("db_pool_idle_conns",
+		"Idle connections, by pool.", "pool")
+	poolTotalConns = metrics.NewGauge("db_pool_total_conns",
+		"Open and constructing connections, by pool.", "pool")
+	poolMaxConns = metrics.NewGauge("db_pool_max_conns",
+		"Largest size of the pool, by pool.", "pool")
+	poolConstructingConns = metrics.NewGauge("db_pool_constructing_conns",
+		"Connections being established, by pool.", "pool")
+	poolAcquiresTotal = metrics.NewCounter("db_pool_acquires_total",
+		"Connections acquired from the pool, by pool.", "pool")
+	poolEmptyAcquiresTotal = metrics.NewCounter("db_pool_empty_acquires_total",
+		"Acquires that waited because no connection was idle, by pool.", "pool")
+	poolCanceledAcquiresTotal = metrics.NewCounter("db_pool_canceled_acquires_total",
+		"Acquires cancelled before a connection was available, by pool.", "pool")
+	poolConnsCreatedTotal = metrics.NewCounter("db_pool_conns_created_total",
+		"Connections established by the pool, by pool.", "pool")
+	poolAcquireDuration = metrics.NewHistogram("db_pool_acquire_duration_seconds",
+		"Average time to acquire a connection over each sampling interval, by pool.",
+		metrics.ExponentialBuckets(0.0001, 4, 10), "pool")
+)
+
+// PoolStats is a snapshot of one connection pool
+type PoolStats struct {
+	Pool                 string  `json:"pool"` // primary or replica-<n>
+	AcquiredConns        int32   `json:"acquired_conns"`
+	IdleConns            int32   `json:"idle_conns"`
+	TotalConns           int32   `json:"total_conns"`
+	MaxConns             int32   `json:"max_conns"`
+	ConstructingConns    int32   `json:"constructing_conns"`
+	AcquireCount         int64   `json:"acquire_count"`
+	EmptyAcquireCount    int64   `json:"empty_acquire_count"`
+	CanceledAcquireCount int64   `json:"canceled_acquire_count"`
+	NewConnsCount        int64   `json:"new_conns_count"`
+	AcquireDurationMS    float64 `json:"acquire_duration_ms"` // Total time spent acquiring
+	Utilization          float64 `json:"utilization"`         // Acquired / max connections
+
+	source *pgxpool.Pool
+}
+
+// statsOf snapshots a pool's statistics
+func statsOf(name string, pool *pgxpool.Pool) PoolStats {
+	stat := pool.Stat()
+	stats := PoolStats{
+		Pool:                 name,
+		AcquiredConns:        stat.AcquiredConns(),
+		IdleConns:            stat.IdleConns(),
+		TotalConns:           stat.TotalConns(),
+		MaxConns:             stat.MaxConns(),
+		ConstructingConns:    stat.ConstructingConns(),
+		AcquireCount:         stat.AcquireCount(),
+		EmptyAcquireCount:    stat.EmptyAcquireCount(),
+		CanceledAcquireCount: stat.CanceledAcquireCount(),
+		NewConnsCount:        stat.NewConnsCount(),
+		AcquireDurationMS:    float64(stat.AcquireDuration().Microseconds()) / 1000,
+		source:               pool,
+	}
+	if stats.MaxConns > 0 {
+		stats.Utilization = float64(stats.AcquiredConns) / float64(stats.MaxConns)
+	}
+	return stats
+}
+
+// PoolStats snapshots the primary pool and each read replica pool
+func (m *Manager) PoolStats() []PoolStats {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	stats := []PoolStats{}
+	if m.database != nil && m.database.Pool != nil {
+		stats = append(stats, statsOf("primary", m.database.Pool))
+	}
+	for i, replica := range m.replicas {
+		stats = append(stats, statsOf("replica-"+strconv.Itoa(i+1), replica.Pool))
+	}
+	return stats
+}
+
+// ExportPoolStats exports pool statistics as metrics every interval until
+// ctx is done. Cumulative pool counters are exported as the growth since
+// the last sample, so counters carry on across reloads.
+func (m *Manager) ExportPoolStats(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultPoolStatsInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	previous := map[string]PoolStats{}
+	for {
+		current := map[string]PoolStats{}
+		for _, stats := range m.PoolStats() {
+			exportPoolStats(stats, previous[stats.Pool])
+			current[stats.Pool] = stats
+		}
+		// Drop the gauges of pools that are gone, such as lost replicas
+		for name := range previous {
+			if _, ok := current[name]; !ok {
+				for _, gauge := range []*metrics.Gauge{poolAcquiredConns, poolIdleConns, poolTotalConns, poolMaxConns, poolConstructingConns} {
+					gauge.Delete(name)
+				}
+			}
+		}
+		previous = current
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// exportPoolStats sets the gauges of a pool and adds the growth of its
+// counters since the previous sample
+func exportPoolStats(stats, previous PoolStats) {
+	name := stats.Pool
+	poolAcquiredConns.Set(float64(stats.AcquiredConns), name)
+	poolIdleConns.Set(float64(stats.IdleConns), name)
+	poolTotalConns.Set(float64(stats.TotalConns), name)
+	poolMaxConns.Set(float64(stats.MaxConns), name)
+	poolConstructingConns.Set(float64(stats.ConstructingConns), name)
+
+	if previous.source != stats.source {
+		// A reload replaced the pool; its counters started from zero
+		previous = PoolStats{}
+	}
+	acquires := stats.AcquireCount - previous.AcquireCount
+	poolAcquiresTotal.Add(float64(acquires), name)
+	poolEmptyAcquiresTotal.Add(float64(stats.EmptyAcquireCount-previous.EmptyAcquireCount), name)
+	poolCanceledAcquiresTotal.Add(float64(stats.CanceledAcquireCount-previous.CanceledAcquireCount), name)
+	poolConnsCreatedTotal.Add(float64(stats.NewConnsCount-previous.NewConnsCount), name)
+	if acquires > 0 {
+		waitedMS := stats.AcquireDurationMS - previous.AcquireDurationMS
+		poolAcquireDuration.Observe(waitedMS/1000/float64(acquires), name)
+	}
+}
@@ -0,0 +1,83 @@
+package db
+
+import (
+	"log"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// SetReplicaURLs sets the read replicas connected by Initialize. Reads
+// routed to replicas may lag writes on the primary by the replication
+// delay.
+func (m *Manager) SetReplicaURLs(urls []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.replicaURLs = urls
+}
+
+// connectReplicas opens a pool per replica URL. Unreachable replicas are
+// skipped so reads fall back to the other replicas or the primary.
+func connectReplicas(urls []string, poolConfig PoolConfig) []*DB {
+	var replicas []*DB
+	for i, url := range urls {
+		replica, err := NewConnection(url, poolConfig)
+		if err != nil {
+			log.Printf("Warning: skipping read replica %d: %v", i+1, err)
+			continue
+		}
+		replicas = append(replicas, replica)
+	}
+	if len(urls) > 0 {
+		log.Printf("Connected to %d of %d read replica(s)", len(replicas), len(urls))
+	}
+	return replicas
+}
+
+// closeReplicas closes the replica pools; the caller holds m.mu
+func (m *Manager) closeReplicas() {
+	for _, replica := range m.replicas {
+		replica.Close()
+	}
+	m.replicas = nil
+}
+
+// GetWritePool returns the primary pool, for writes, DDL, and reads that
+// must see the latest writes
+func (m *Manager) GetWritePool() *pgxpool.Pool {
+	return m.GetPool()
+}
+
+// GetReadPool returns a pool for reads that tolerate replication lag,
+// rotating across the read replicas. It is the primary pool when no
+// replica is connected.
+func (m *Manager) GetReadPool() *pgxpool.Pool {
+	if replica := m.GetReadDB(); replica != nil {
+		return replica.Pool
+	}
+	return nil
+}
+
+// GetReadDB returns the connection for reads that tolerate replication lag,
+// like GetReadPool
+func (m *Manager) GetReadDB() *DB {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if len(m.replicas) == 0 {
+		return m.database
+	}
+	next := m.nextReplica.Add(1)
+	return m.replicas[next%uint64(len(m.replicas))]
+}
+
+// splitURLs parses a comma-separated URL list
+func splitURLs(value string) []string {
+	var urls []string
+	for _, url := range strings.Split(value, ",") {
+		if url = strings.TrimSpace(url); url != "" {
+			urls = append(urls, url)
+		}
+	}
+	return urls
+}
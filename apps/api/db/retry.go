@@ -0,0 +1,85 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+)
+
+// DefaultInitialBackoff is the wait before the first connection retry
+const DefaultInitialBackoff = time.Second
+
+// RetryPolicy controls how connecting to the database is retried
+type RetryPolicy struct {
+	Attempts       int           // Connection attempts before giving up; 1 or less tries once
+	InitialBackoff time.Duration // Wait before the first retry, doubled after each failure
+	MaxBackoff     time.Duration // Longest wait between attempts
+}
+
+// backoff returns the wait after the given failed attempt (1-based)
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	wait := p.InitialBackoff
+	if wait <= 0 {
+		wait = DefaultInitialBackoff
+	}
+	for i := 1; i < attempt && (p.MaxBackoff <= 0 || wait < p.MaxBackoff); i++ {
+		wait *= 2
+	}
+	if p.MaxBackoff > 0 && wait > p.MaxBackoff {
+		return p.MaxBackoff
+	}
+	return wait
+}
+
+// Connect initializes the connection, retrying with exponential backoff.
+// Missing or invalid settings are not retried. It returns the last error
+// when every attempt fails or ctx is done.
+func (m *Manager) Connect(ctx context.Context, pooledURL, directURL string, policy RetryPolicy) error {
+	attempts := max(policy.Attempts, 1)
+	for attempt := 1; ; attempt++ {
+		err := m.Initialize(pooledURL, directURL)
+		if err == nil || errors.Is(err, ErrNotConfigured) || errors.Is(err, ErrInvalidConfig) || attempt >= attempts {
+			return err
+		}
+
+		wait := policy.backoff(attempt)
+		log.Printf("Warning: database connection attempt %d/%d failed, retrying in %s: %v", attempt, attempts, wait, err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+	}
+}
+
+// ConnectInBackground keeps retrying the connection at the policy's longest
+// backoff until it succeeds or ctx is done, then calls onConnect. The API
+// serves in a degraded mode meanwhile, with readiness reporting the
+// database as down.
+func (m *Manager) ConnectInBackground(ctx context.Context, pooledURL, directURL string, policy RetryPolicy, onConnect func()) {
+	wait := policy.MaxBackoff
+	if wait <= 0 {
+		wait = policy.backoff(1)
+	}
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(wait):
+			}
+
+			if err := m.Initialize(pooledURL, directURL); err != nil {
+				log.Printf("Warning: database still unavailable, retrying in %s: %v", wait, err)
+				continue
+			}
+			log.Println("Database connection established")
+			if onConnect != nil {
+				onConnect()
+			}
+			return
+		}
+	}()
+}
@@ -0,0 +1,93 @@
+-- Local Schema: user table metadata and the schema change log for
+-- DB_DRIVER=sqlite, mirroring migrations 001, 004, 011, 015, 016, 017, 021, and 022.
+-- Columns added to existing tables must also be listed in addedColumns
+-- (sqlite.go).
+-- Created: 2026-10-16
+
+CREATE TABLE IF NOT EXISTS configurable_tables (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE,
+    table_name TEXT NOT NULL UNIQUE,
+    description TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_by TEXT,
+    deleted_at TIMESTAMP, -- When the table was moved to the trash; NULL for live tables
+    deleted_by TEXT,
+    naming TEXT -- JSON naming strategy of the table's names; NULL for the default
+);
+
+CREATE TABLE IF NOT EXISTS configurable_columns (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    table_id INTEGER NOT NULL REFERENCES configurable_tables(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    column_name TEXT NOT NULL,
+    data_type TEXT NOT NULL,
+    postgres_type TEXT NOT NULL, -- SQLite type of the column
+    is_nullable BOOLEAN NOT NULL DEFAULT 1,
+    is_unique BOOLEAN NOT NULL DEFAULT 0,
+    default_value TEXT,
+    foreign_key_to_table_id INTEGER REFERENCES configurable_tables(id) ON DELETE SET NULL,
+    display_order INTEGER NOT NULL DEFAULT 0,
+    vector_dimensions INTEGER,
+    vector_index_type TEXT,
+    format_timezone TEXT,
+    format_date TEXT,
+    format_number_locale TEXT,
+    format_currency TEXT,
+    format_currency_display TEXT,
+    formula TEXT, -- Expression of formula columns, which have no SQLite column
+    deny_read_roles TEXT, -- Comma-separated roles denied reading the column
+    deny_write_roles TEXT, -- Comma-separated roles denied writing the column
+    description TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (table_id, column_name)
+);
+
+CREATE INDEX IF NOT EXISTS idx_configurable_columns_table_id ON configurable_columns(table_id);
+CREATE INDEX IF NOT EXISTS idx_configurable_columns_fk ON configurable_columns(foreign_key_to_table_id);
+
+CREATE TABLE IF NOT EXISTS schema_change_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    table_id INTEGER REFERENCES configurable_tables(id) ON DELETE SET NULL,
+    change_type TEXT NOT NULL,
+    change_details TEXT NOT NULL, -- JSON
+    executed_sql TEXT,
+    status TEXT NOT NULL,
+    error_message TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_by TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_schema_change_log_table_id ON schema_change_log(table_id);
+
+CREATE TABLE IF NOT EXISTS schema_change_requests (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    change_type TEXT NOT NULL,
+    table_id INTEGER REFERENCES configurable_tables(id) ON DELETE SET NULL,
+    change_details TEXT NOT NULL, -- JSON
+    status TEXT NOT NULL DEFAULT 'pending',
+    requested_by TEXT,
+    reviewed_by TEXT,
+    review_comment TEXT,
+    error_message TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    reviewed_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_schema_change_requests_status ON schema_change_requests(status, id);
+
+CREATE TRIGGER IF NOT EXISTS update_configurable_tables_updated_at
+    AFTER UPDATE ON configurable_tables
+    FOR EACH ROW
+    BEGIN
+        UPDATE configurable_tables SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
+    END;
+
+CREATE TRIGGER IF NOT EXISTS update_configurable_columns_updated_at
+    AFTER UPDATE ON configurable_columns
+    FOR EACH ROW
+    BEGIN
+        UPDATE configurable_columns SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
+    END;
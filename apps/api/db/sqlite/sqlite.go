@@ -0,0 +1,130 @@
+// Package sqlite opens the file database of DB_DRIVER=sqlite, which runs
+// the server locally without Postgres. It holds user tables and their
+// metadata; features that need Postgres report the database as not
+// configured.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	_ "embed"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// schema creates the metadata tables; every statement is idempotent
+//
+//go:embed schema.sql
+var schema string
+
+// openTimeout bounds opening the file and applying the schema
+const openTimeout = 30 * time.Second
+
+// addedColumn is a column added to a metadata table after databases were
+// created with it. SQLite has no ADD COLUMN IF NOT EXISTS, so Open adds
+// the ones a database lacks.
+type addedColumn struct {
+	table      string
+	column     string
+	definition string
+}
+
+// addedColumns lists the columns of schema.sql missing from older files
+var addedColumns = []addedColumn{
+	{"configurable_columns", "format_timezone", "TEXT"},
+	{"configurable_columns", "format_date", "TEXT"},
+	{"configurable_columns", "format_number_locale", "TEXT"},
+	{"configurable_columns", "format_currency", "TEXT"},
+	{"configurable_columns", "format_currency_display", "TEXT"},
+	{"configurable_tables", "deleted_at", "TIMESTAMP"},
+	{"configurable_tables", "deleted_by", "TEXT"},
+	{"configurable_columns", "formula", "TEXT"},
+	{"configurable_columns", "deny_read_roles", "TEXT"},
+	{"configurable_columns", "deny_write_roles", "TEXT"},
+	{"configurable_tables", "naming", "TEXT"},
+	{"configurable_columns", "description", "TEXT"},
+}
+
+// Open opens the database at path, creating it and its directory when
+// missing, and applies the schema. Transactions take the write lock when
+// they begin, so concurrent schema changes wait instead of failing.
+func Open(path string) (*sql.DB, error) {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create database directory: %w", err)
+		}
+	}
+
+	// The pure Go driver keeps cgo out of the build. Times are written in
+	// SQLite's own format, which CURRENT_TIMESTAMP values compare against.
+	params := url.Values{}
+	params.Add("_pragma", "foreign_keys(1)")
+	params.Add("_pragma", "journal_mode(WAL)")
+	params.Add("_pragma", "busy_timeout(5000)")
+	params.Set("_txlock", "immediate")
+	params.Set("_time_format", "sqlite")
+	database, err := sql.Open("sqlite", "file:"+path+"?"+params.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), openTimeout)
+	defer cancel()
+	if _, err := database.ExecContext(ctx, schema); err != nil {
+		database.Close()
+		return nil, fmt.Errorf("failed to apply schema to %s: %w", path, err)
+	}
+	if err := addColumns(ctx, database); err != nil {
+		database.Close()
+		return nil, fmt.Errorf("failed to upgrade schema of %s: %w", path, err)
+	}
+	return database, nil
+}
+
+// addColumns adds the addedColumns a database was created without
+func addColumns(ctx context.Context, database *sql.DB) error {
+	existing := map[string]map[string]bool{}
+	for _, added := range addedColumns {
+		columns, ok := existing[added.table]
+		if !ok {
+			var err error
+			if columns, err = tableColumns(ctx, database, added.table); err != nil {
+				return err
+			}
+			existing[added.table] = columns
+		}
+		if columns[added.column] {
+			continue
+		}
+		stmt := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", added.table, added.column, added.definition)
+		if _, err := database.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to add %s.%s: %w", added.table, added.column, err)
+		}
+		columns[added.column] = true
+	}
+	return nil
+}
+
+// tableColumns returns the names of a table's columns
+func tableColumns(ctx context.Context, database *sql.DB, table string) (map[string]bool, error) {
+	rows, err := database.QueryContext(ctx, "SELECT name FROM pragma_table_info(?)", table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read columns of %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	columns := map[string]bool{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to read columns of %s: %w", table, err)
+		}
+		columns[name] = true
+	}
+	return columns, rows.Err()
+}
@@ -0,0 +1,39 @@
+package db
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultStatementTimeout bounds a single statement when no timeout is
+// configured
+const DefaultStatementTimeout = 30 * time.Second
+
+var statementTimeout atomic.Int64
+
+func init() {
+	statementTimeout.Store(int64(DefaultStatementTimeout))
+}
+
+// SetStatementTimeout sets how long a single statement may run. Zero or a
+// negative duration leaves statements bounded only by their caller's
+// deadline.
+func SetStatementTimeout(d time.Duration) {
+	statementTimeout.Store(int64(d))
+}
+
+// StatementContext derives the context for one statement from a request
+// context. The statement timeout applies only when it ends sooner than the
+// request's own deadline (e.g. a gRPC deadline), so cancelling the request
+// still cancels the statement.
+func StatementContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	timeout := time.Duration(statementTimeout.Load())
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= timeout {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
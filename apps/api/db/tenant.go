@@ -0,0 +1,58 @@
+package db
+
+import (
+	"context"
+	"sync"
+
+	"agentic-template/api/requestctx"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// sharedSearchPath is the search_path of requests without a tenant
+const sharedSearchPath = "public"
+
+// searchPathScoper sets each acquired connection's search_path to the
+// tenant schema of the acquiring context (see requestctx.WithTenant).
+// Connections remember their current path so consecutive requests of a
+// tenant skip the SET.
+type searchPathScoper struct {
+	mu      sync.Mutex
+	current map[*pgx.Conn]string
+}
+
+// newSearchPathScoper creates a scoper with no known connections
+func newSearchPathScoper() *searchPathScoper {
+	return &searchPathScoper{current: map[*pgx.Conn]string{}}
+}
+
+// beforeAcquire sets the search_path; a connection that fails to switch is
+// destroyed and another one acquired
+func (s *searchPathScoper) beforeAcquire(ctx context.Context, conn *pgx.Conn) bool {
+	path := sharedSearchPath
+	if schema := requestctx.TenantSchema(ctx); schema != "" {
+		path = pgx.Identifier{schema}.Sanitize() + ", " + sharedSearchPath
+	}
+
+	s.mu.Lock()
+	current, known := s.current[conn]
+	s.mu.Unlock()
+	if known && current == path {
+		return true
+	}
+
+	if _, err := conn.Exec(ctx, "SELECT set_config('search_path', $1, false)", path); err != nil {
+		return false
+	}
+	s.mu.Lock()
+	s.current[conn] = path
+	s.mu.Unlock()
+	return true
+}
+
+// beforeClose forgets a closed connection
+func (s *searchPathScoper) beforeClose(conn *pgx.Conn) {
+	s.mu.Lock()
+	delete(s.current, conn)
+	s.mu.Unlock()
+}
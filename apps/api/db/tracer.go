@@ -0,0 +1,121 @@
+package db
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"agentic-template/api/logging"
+	"agentic-template/api/metrics"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// DefaultSlowQueryThreshold is how long a query may run before it is logged
+// as slow when no threshold is configured
+const DefaultSlowQueryThreshold = 500 * time.Millisecond
+
+// maxLoggedSQLLength bounds the SQL text written to logs. Arguments are
+// never logged since they may hold user data.
+const maxLoggedSQLLength = 1000
+
+// tracer creates a client span per query, so a request's trace shows the
+// statements it ran
+var tracer = otel.Tracer("agentic-template/api/db")
+
+var (
+	queriesTotal = metrics.NewCounter("db_queries_total",
+		"Database queries run, by outcome (ok or error).", "outcome")
+	slowQueriesTotal = metrics.NewCounter("db_slow_queries_total",
+		"Database queries that ran longer than the slow query threshold.")
+)
+
+// queryTracer logs every query at debug level and slow queries as warnings,
+// and traces each query as a span
+type queryTracer struct {
+	slowThreshold time.Duration // 0 disables slow query warnings
+}
+
+type queryStartKey struct{}
+
+// queryStart is what TraceQueryStart hands to TraceQueryEnd
+type queryStart struct {
+	sql     string
+	started time.Time
+	span    trace.Span
+}
+
+// TraceQueryStart records when a query started and starts its span
+func (t *queryTracer) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	ctx, span := tracer.Start(ctx, "db "+queryOperation(data.SQL), trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.String("db.namespace", conn.Config().Database),
+		attribute.String("db.query.text", loggedSQL(data.SQL)),
+	))
+	return context.WithValue(ctx, queryStartKey{}, queryStart{sql: data.SQL, started: time.Now(), span: span})
+}
+
+// TraceQueryEnd logs the query with its duration and row count
+func (t *queryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	start, ok := ctx.Value(queryStartKey{}).(queryStart)
+	if !ok {
+		return
+	}
+	duration := time.Since(start.started)
+
+	start.span.SetAttributes(attribute.Int64("db.rows_affected", data.CommandTag.RowsAffected()))
+	outcome := "ok"
+	if data.Err != nil {
+		outcome = "error"
+		start.span.RecordError(data.Err)
+		start.span.SetStatus(codes.Error, data.Err.Error())
+	}
+	start.span.End()
+	queriesTotal.Inc(outcome)
+
+	slow := t.slowThreshold > 0 && duration >= t.slowThreshold
+	logger := logging.FromContext(ctx)
+	level := slog.LevelDebug
+	msg := "query"
+	if slow {
+		slowQueriesTotal.Inc()
+		level = slog.LevelWarn
+		msg = "slow query"
+	} else if !logger.Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+
+	attrs := []any{
+		"sql", loggedSQL(start.sql),
+		"duration_ms", duration.Milliseconds(),
+		"rows", data.CommandTag.RowsAffected(),
+	}
+	if data.Err != nil {
+		attrs = append(attrs, "error", data.Err)
+	}
+	logger.Log(ctx, level, msg, attrs...)
+}
+
+// queryOperation returns the leading keyword of a statement, such as
+// SELECT or CREATE, to name its span
+func queryOperation(sql string) string {
+	fields := strings.Fields(sql)
+	if len(fields) == 0 {
+		return "query"
+	}
+	return strings.ToUpper(fields[0])
+}
+
+// loggedSQL collapses whitespace in a statement and truncates it
+func loggedSQL(sql string) string {
+	sql = strings.Join(strings.Fields(sql), " ")
+	if len(sql) > maxLoggedSQLLength {
+		sql = strings.ToValidUTF8(sql[:maxLoggedSQLLength], "") + "..."
+	}
+	return sql
+}
@@ -0,0 +1,129 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrDatabaseNotConfigured is returned by WithTx when the database is not
+// connected
+var ErrDatabaseNotConfigured = errors.New("database not configured - please add DATABASE_URL_POOLED in Environment Settings")
+
+// DefaultTxAttempts is how many times WithTx runs a transaction that keeps
+// failing with serialization failures or deadlocks
+const DefaultTxAttempts = 3
+
+// Backoff between transaction retries, with full jitter
+const (
+	txRetryBaseDelay = 20 * time.Millisecond
+	txRetryMaxDelay  = time.Second
+)
+
+// PostgreSQL error codes of transactions that may succeed when retried
+const (
+	sqlStateSerializationFailure = "40001"
+	sqlStateDeadlockDetected     = "40P01"
+)
+
+// txConfig holds the settings of one WithTx call
+type txConfig struct {
+	options  pgx.TxOptions
+	attempts int
+}
+
+// TxOption configures WithTx
+type TxOption func(*txConfig)
+
+// Isolation runs the transaction at the given isolation level
+func Isolation(level pgx.TxIsoLevel) TxOption {
+	return func(c *txConfig) {
+		c.options.IsoLevel = level
+	}
+}
+
+// ReadOnly runs the transaction in read-only mode
+func ReadOnly() TxOption {
+	return func(c *txConfig) {
+		c.options.AccessMode = pgx.ReadOnly
+	}
+}
+
+// MaxAttempts sets how many times the transaction runs before a retryable
+// error is returned; 1 disables retries
+func MaxAttempts(attempts int) TxOption {
+	return func(c *txConfig) {
+		c.attempts = max(attempts, 1)
+	}
+}
+
+// WithTx runs fn in a transaction, committing when it returns nil and
+// rolling back otherwise. Transactions failing with a serialization
+// failure or deadlock are retried with backoff, so fn may run more than
+// once and must not keep state from an earlier attempt.
+func WithTx(ctx context.Context, pool *pgxpool.Pool, fn func(tx pgx.Tx) error, opts ...TxOption) error {
+	if pool == nil {
+		return ErrDatabaseNotConfigured
+	}
+
+	config := txConfig{attempts: DefaultTxAttempts}
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := runTx(ctx, pool, config.options, fn)
+		if err == nil || !IsRetryable(err) || attempt >= config.attempts {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(txRetryDelay(attempt)):
+		}
+	}
+}
+
+// runTx runs one attempt of a transaction
+func runTx(ctx context.Context, pool *pgxpool.Pool, options pgx.TxOptions, fn func(tx pgx.Tx) error) error {
+	tx, err := pool.BeginTx(ctx, options)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// IsRetryable reports whether err is a serialization failure or deadlock,
+// after which the whole transaction may succeed when run again
+func IsRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	return pgErr.Code == sqlStateSerializationFailure || pgErr.Code == sqlStateDeadlockDetected
+}
+
+// txRetryDelay returns a random wait before retrying after the given
+// failed attempt, up to an exponentially growing bound
+func txRetryDelay(attempt int) time.Duration {
+	bound := txRetryBaseDelay << min(attempt-1, 10)
+	if bound > txRetryMaxDelay {
+		bound = txRetryMaxDelay
+	}
+	return rand.N(bound) + time.Millisecond
+}
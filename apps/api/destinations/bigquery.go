@@ -0,0 +1,151 @@
+package destinations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"google.golang.org/api/bigquery/v2"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+)
+
+// bigQueryDestination streams rows into the tables of a BigQuery dataset
+type bigQueryDestination struct {
+	service   *bigquery.Service
+	projectID string
+	datasetID string
+	columns   map[string]map[string]bool // Known columns by table
+}
+
+// openBigQuery connects to the dataset of a
+// bigquery://project-id/dataset-id[?credentials_file=path] URL. The
+// credentials file holds a service account key; without one, the
+// application default credentials are used.
+func openBigQuery(ctx context.Context, u *url.URL) (Destination, error) {
+	datasetID := strings.Trim(u.Path, "/")
+	if u.Host == "" || datasetID == "" || strings.Contains(datasetID, "/") {
+		return nil, fmt.Errorf("%w: the BigQuery URL must name a project and a dataset, e.g. bigquery://my-project/my_dataset", ErrUnsupportedDestination)
+	}
+	opts := []option.ClientOption{option.WithScopes(bigquery.BigqueryScope)}
+	if file := u.Query().Get("credentials_file"); file != "" {
+		opts = append(opts, option.WithCredentialsFile(file))
+	}
+
+	service, err := bigquery.NewService(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to BigQuery: %w", err)
+	}
+	return &bigQueryDestination{service: service, projectID: u.Host, datasetID: datasetID, columns: map[string]map[string]bool{}}, nil
+}
+
+// Kind returns KindBigQuery
+func (d *bigQueryDestination) Kind() string {
+	return KindBigQuery
+}
+
+// Write streams a batch with insertAll. Rows carry insert IDs derived from
+// the batch key, so BigQuery drops rows of a retried batch it already has
+// on a best-effort basis.
+func (d *bigQueryDestination) Write(ctx context.Context, batch Batch) error {
+	if err := d.ensureTable(ctx, batch.Table, batch.Columns); err != nil {
+		return err
+	}
+
+	req := &bigquery.TableDataInsertAllRequest{Rows: make([]*bigquery.TableDataInsertAllRequestRows, len(batch.Rows))}
+	for i, row := range batch.Rows {
+		values := make(map[string]bigquery.JsonValue, len(row))
+		for j, value := range row {
+			if value != nil {
+				values[batch.Columns[j]] = *value
+			}
+		}
+		req.Rows[i] = &bigquery.TableDataInsertAllRequestRows{InsertId: batch.Key + "-" + strconv.Itoa(i), Json: values}
+	}
+	resp, err := d.service.Tabledata.InsertAll(d.projectID, d.datasetID, batch.Table, req).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("failed to insert rows into %s: %w", batch.Table, err)
+	}
+	if len(resp.InsertErrors) > 0 {
+		first := resp.InsertErrors[0]
+		var reasons []string
+		for _, e := range first.Errors {
+			reasons = append(reasons, e.Reason+": "+e.Message)
+		}
+		return fmt.Errorf("BigQuery rejected %d row(s) of %s, row %d: %s", len(resp.InsertErrors), batch.Table, first.Index, strings.Join(reasons, "; "))
+	}
+	return nil
+}
+
+// Close does nothing; the service holds no connection
+func (d *bigQueryDestination) Close() error {
+	return nil
+}
+
+// ensureTable creates a table of nullable STRING columns, or adds the
+// columns it lacks
+func (d *bigQueryDestination) ensureTable(ctx context.Context, table string, columns []string) error {
+	known, ok := d.columns[table]
+	if !ok {
+		known = map[string]bool{}
+		existing, err := d.service.Tables.Get(d.projectID, d.datasetID, table).Context(ctx).Do()
+		var apiErr *googleapi.Error
+		switch {
+		case errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound:
+			created := &bigquery.Table{
+				TableReference: &bigquery.TableReference{ProjectId: d.projectID, DatasetId: d.datasetID, TableId: table},
+				Schema:         &bigquery.TableSchema{Fields: stringFields(columns)},
+			}
+			if _, err := d.service.Tables.Insert(d.projectID, d.datasetID, created).Context(ctx).Do(); err != nil {
+				return fmt.Errorf("failed to create table %s: %w", table, err)
+			}
+			for _, name := range columns {
+				known[name] = true
+			}
+			d.columns[table] = known
+			return nil
+		case err != nil:
+			return fmt.Errorf("failed to read table %s: %w", table, err)
+		}
+		if existing.Schema != nil {
+			for _, field := range existing.Schema.Fields {
+				known[field.Name] = true
+			}
+		}
+		d.columns[table] = known
+	}
+
+	missing := missingColumns(known, columns)
+	if len(missing) == 0 {
+		return nil
+	}
+	existing, err := d.service.Tables.Get(d.projectID, d.datasetID, table).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("failed to read table %s: %w", table, err)
+	}
+	schema := &bigquery.TableSchema{}
+	if existing.Schema != nil {
+		schema.Fields = existing.Schema.Fields
+	}
+	schema.Fields = append(schema.Fields, stringFields(missing)...)
+	if _, err := d.service.Tables.Patch(d.projectID, d.datasetID, table, &bigquery.Table{Schema: schema}).Context(ctx).Do(); err != nil {
+		return fmt.Errorf("failed to add columns to table %s: %w", table, err)
+	}
+	for _, name := range missing {
+		known[name] = true
+	}
+	return nil
+}
+
+// stringFields returns nullable STRING fields of the given names
+func stringFields(columns []string) []*bigquery.TableFieldSchema {
+	fields := make([]*bigquery.TableFieldSchema, len(columns))
+	for i, name := range columns {
+		fields[i] = &bigquery.TableFieldSchema{Name: name, Type: "STRING", Mode: "NULLABLE"}
+	}
+	return fields
+}
@@ -0,0 +1,92 @@
+// Package destinations writes the changes warehouse syncs replicate to
+// external warehouses: BigQuery datasets, Snowflake schemas, and Parquet
+// files in S3. A destination is named by a URL holding its credentials, so
+// URLs belong in the configuration, never in API calls.
+package destinations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+// Kinds of destination
+const (
+	KindBigQuery  = "bigquery"
+	KindSnowflake = "snowflake"
+	KindS3        = "s3"
+)
+
+// ErrUnsupportedDestination is returned for URLs no destination handles
+var ErrUnsupportedDestination = errors.New("unsupported sync destination")
+
+// Batch is rows of one table written together. Values are text in
+// PostgreSQL's output format, nil for NULL, and are stored as strings;
+// casting them is left to queries in the warehouse.
+type Batch struct {
+	Table   string // Destination table, or the folder of S3 files
+	Key     string // Names the batch across retries, so destinations that can skip or overwrite a rewrite do
+	Columns []string
+	Rows    [][]*string
+}
+
+// Destination appends batches to a warehouse
+type Destination interface {
+	// Kind returns the destination's kind, e.g. KindBigQuery
+	Kind() string
+	// Write appends a batch, creating its table or adding the columns
+	// it lacks first
+	Write(ctx context.Context, batch Batch) error
+	// Close releases the destination's connections
+	Close() error
+}
+
+// DestinationKind returns the kind of destination a URL names
+func DestinationKind(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrUnsupportedDestination, err)
+	}
+	switch u.Scheme {
+	case "bigquery":
+		return KindBigQuery, nil
+	case "snowflake":
+		return KindSnowflake, nil
+	case "s3":
+		return KindS3, nil
+	default:
+		return "", fmt.Errorf("%w: scheme %q, use bigquery://, snowflake://, or s3://", ErrUnsupportedDestination, u.Scheme)
+	}
+}
+
+// Open connects to the destination of a URL
+func Open(ctx context.Context, rawURL string) (Destination, error) {
+	kind, err := DestinationKind(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	switch kind {
+	case KindBigQuery:
+		return openBigQuery(ctx, u)
+	case KindSnowflake:
+		return openSnowflake(u)
+	default:
+		return openS3(u)
+	}
+}
+
+// missingColumns returns the columns not in known
+func missingColumns(known map[string]bool, columns []string) []string {
+	var missing []string
+	for _, name := range columns {
+		if !known[name] {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,145 @@
+package destinations
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"agentic-template/api/awsauth"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// s3Timeout bounds each upload
+const s3Timeout = 2 * time.Minute
+
+// s3Destination uploads each batch as a Parquet file
+type s3Destination struct {
+	client      *http.Client
+	bucket      string
+	prefix      string
+	endpoint    string // Path-style endpoint; empty uses the bucket's AWS endpoint
+	credentials awsauth.Credentials
+}
+
+// openS3 writes to the bucket of an
+// s3://bucket[/prefix][?region=region&endpoint=url] URL with the
+// credentials of the standard AWS environment variables. The endpoint
+// points uploads at an S3-compatible store, such as MinIO.
+func openS3(u *url.URL) (Destination, error) {
+	if u.Host == "" {
+		return nil, fmt.Errorf("%w: the S3 URL must name a bucket, e.g. s3://my-bucket/warehouse", ErrUnsupportedDestination)
+	}
+	creds := awsauth.FromEnv()
+	query := u.Query()
+	if region := query.Get("region"); region != "" {
+		creds.Region = region
+	}
+	if !creds.Complete() {
+		return nil, fmt.Errorf("the S3 destination needs AWS_REGION, AWS_ACCESS_KEY_ID, and AWS_SECRET_ACCESS_KEY")
+	}
+	return &s3Destination{
+		client:      &http.Client{Timeout: s3Timeout},
+		bucket:      u.Host,
+		prefix:      strings.Trim(u.Path, "/"),
+		endpoint:    query.Get("endpoint"),
+		credentials: creds,
+	}, nil
+}
+
+// Kind returns KindS3
+func (d *s3Destination) Kind() string {
+	return KindS3
+}
+
+// Write uploads a batch to table/key.parquet under the prefix, so a
+// retried batch overwrites its file. Columns are optional strings; files
+// of one table gain columns as its user table does.
+func (d *s3Destination) Write(ctx context.Context, batch Batch) error {
+	data, err := encodeParquet(batch)
+	if err != nil {
+		return fmt.Errorf("failed to encode rows of %s: %w", batch.Table, err)
+	}
+
+	key := batch.Table + "/" + batch.Key + ".parquet"
+	if d.prefix != "" {
+		key = d.prefix + "/" + key
+	}
+	objectURL := "https://" + d.bucket + ".s3." + d.credentials.Region + ".amazonaws.com/" + key
+	if d.endpoint != "" {
+		objectURL = strings.TrimSuffix(d.endpoint, "/") + "/" + d.bucket + "/" + key
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, objectURL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/vnd.apache.parquet")
+	req.Header.Set("X-Amz-Content-Sha256", awsauth.SHA256Hex(data))
+	awsauth.Sign(req, data, "s3", d.credentials, time.Now())
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to upload rows of %s to S3: %w", batch.Table, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("S3 returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// Close releases idle connections
+func (d *s3Destination) Close() error {
+	d.client.CloseIdleConnections()
+	return nil
+}
+
+// encodeParquet writes a batch as a Snappy-compressed Parquet file of
+// optional string columns
+func encodeParquet(batch Batch) ([]byte, error) {
+	group := parquet.Group{}
+	for _, name := range batch.Columns {
+		group[name] = parquet.Optional(parquet.String())
+	}
+	schema := parquet.NewSchema(batch.Table, group)
+
+	// The schema orders its columns by name, and row values follow it
+	leaves := schema.Columns()
+	positions := make([]int, len(leaves))
+	for i, path := range leaves {
+		for j, name := range batch.Columns {
+			if path[0] == name {
+				positions[i] = j
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	writer := parquet.NewWriter(&buf, schema, parquet.Compression(&parquet.Snappy))
+	rows := make([]parquet.Row, len(batch.Rows))
+	for r, values := range batch.Rows {
+		row := make(parquet.Row, len(leaves))
+		for i, position := range positions {
+			if value := values[position]; value != nil {
+				row[i] = parquet.ByteArrayValue([]byte(*value)).Level(0, 1, i)
+			} else {
+				row[i] = parquet.NullValue().Level(0, 0, i)
+			}
+		}
+		rows[r] = row
+	}
+	if _, err := writer.WriteRows(rows); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
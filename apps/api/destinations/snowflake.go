@@ -0,0 +1,227 @@
+package destinations
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Snowflake SQL API tuning
+const (
+	snowflakeTimeout      = 60 * time.Second       // Bound on each statement
+	snowflakePollInterval = 500 * time.Millisecond // Between checks of a statement still running
+	snowflakeMaxBinds     = 10000                  // Values bound per INSERT; larger batches are split
+)
+
+// snowflakeDestination inserts rows into the tables of a Snowflake schema
+// through the SQL API
+type snowflakeDestination struct {
+	client    *http.Client
+	endpoint  string // https://account.snowflakecomputing.com
+	token     string
+	tokenType string
+	database  string
+	schema    string
+	warehouse string
+	role      string
+	columns   map[string]map[string]bool // Known columns by table
+}
+
+// openSnowflake connects to the schema of a
+// snowflake://:token@account/database/schema[?warehouse=wh&role=role]
+// URL. The token is a programmatic access token unless token_type names
+// another kind the SQL API accepts, such as OAUTH or KEYPAIR_JWT.
+func openSnowflake(u *url.URL) (Destination, error) {
+	token, ok := u.User.Password()
+	database, schema, _ := strings.Cut(strings.Trim(u.Path, "/"), "/")
+	if !ok || u.Host == "" || database == "" || schema == "" || strings.Contains(schema, "/") {
+		return nil, fmt.Errorf("%w: the Snowflake URL must hold a token, an account, a database, and a schema, e.g. snowflake://:token@myorg-myaccount/ANALYTICS/PUBLIC", ErrUnsupportedDestination)
+	}
+	host := u.Host
+	if !strings.Contains(host, ".") {
+		host += ".snowflakecomputing.com"
+	}
+	query := u.Query()
+	tokenType := query.Get("token_type")
+	if tokenType == "" {
+		tokenType = "PROGRAMMATIC_ACCESS_TOKEN"
+	}
+	return &snowflakeDestination{
+		client:    &http.Client{Timeout: snowflakeTimeout + 10*time.Second},
+		endpoint:  "https://" + host,
+		token:     token,
+		tokenType: tokenType,
+		database:  database,
+		schema:    schema,
+		warehouse: query.Get("warehouse"),
+		role:      query.Get("role"),
+		columns:   map[string]map[string]bool{},
+	}, nil
+}
+
+// Kind returns KindSnowflake
+func (d *snowflakeDestination) Kind() string {
+	return KindSnowflake
+}
+
+// Write inserts a batch with bound values. Snowflake has no insert IDs, so
+// a retried batch is inserted again.
+func (d *snowflakeDestination) Write(ctx context.Context, batch Batch) error {
+	if err := d.ensureTable(ctx, batch.Table, batch.Columns); err != nil {
+		return err
+	}
+
+	names := make([]string, len(batch.Columns))
+	for i, name := range batch.Columns {
+		names[i] = quoteSnowflake(name)
+	}
+	rowsPerStatement := max(1, snowflakeMaxBinds/len(batch.Columns))
+	for start := 0; start < len(batch.Rows); start += rowsPerStatement {
+		chunk := batch.Rows[start:min(start+rowsPerStatement, len(batch.Rows))]
+		bindings := make(map[string]snowflakeBinding, len(chunk)*len(batch.Columns))
+		values := make([]string, len(chunk))
+		for r, row := range chunk {
+			placeholders := make([]string, len(row))
+			for i, value := range row {
+				bindings[strconv.Itoa(len(bindings)+1)] = snowflakeBinding{Type: "TEXT", Value: value}
+				placeholders[i] = "?"
+			}
+			values[r] = "(" + strings.Join(placeholders, ", ") + ")"
+		}
+		statement := fmt.Sprintf("INSERT INTO %s (%s) VALUES %s", quoteSnowflake(batch.Table), strings.Join(names, ", "), strings.Join(values, ", "))
+		if err := d.execute(ctx, statement, bindings); err != nil {
+			return fmt.Errorf("failed to insert rows into %s: %w", batch.Table, err)
+		}
+	}
+	return nil
+}
+
+// Close releases idle connections
+func (d *snowflakeDestination) Close() error {
+	d.client.CloseIdleConnections()
+	return nil
+}
+
+// ensureTable creates a table of VARCHAR columns, or adds the columns it
+// lacks. Both statements are idempotent, so a table is only checked the
+// first time this destination writes to it.
+func (d *snowflakeDestination) ensureTable(ctx context.Context, table string, columns []string) error {
+	known := d.columns[table]
+	if known == nil {
+		known = map[string]bool{}
+	}
+	missing := missingColumns(known, columns)
+	if len(missing) == 0 {
+		return nil
+	}
+
+	defs := make([]string, len(missing))
+	for i, name := range missing {
+		defs[i] = quoteSnowflake(name) + " VARCHAR"
+	}
+	statements := []string{
+		fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", quoteSnowflake(table), strings.Join(defs, ", ")),
+		fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s", quoteSnowflake(table), strings.Join(defs, ", ")),
+	}
+	for _, statement := range statements {
+		if err := d.execute(ctx, statement, nil); err != nil {
+			return fmt.Errorf("failed to prepare table %s: %w", table, err)
+		}
+	}
+	for _, name := range missing {
+		known[name] = true
+	}
+	d.columns[table] = known
+	return nil
+}
+
+// snowflakeBinding is a value bound to a statement; a nil value binds NULL
+type snowflakeBinding struct {
+	Type  string  `json:"type"`
+	Value *string `json:"value"`
+}
+
+// snowflakeResponse is the part of a SQL API response Write reads
+type snowflakeResponse struct {
+	Code               string `json:"code"`
+	Message            string `json:"message"`
+	StatementStatusURL string `json:"statementStatusUrl"`
+}
+
+// execute runs a statement, waiting for it to finish when the API answers
+// before it does
+func (d *snowflakeDestination) execute(ctx context.Context, statement string, bindings map[string]snowflakeBinding) error {
+	body, err := json.Marshal(map[string]any{
+		"statement": statement,
+		"timeout":   int(snowflakeTimeout / time.Second),
+		"database":  d.database,
+		"schema":    d.schema,
+		"warehouse": d.warehouse,
+		"role":      d.role,
+		"bindings":  bindings,
+	})
+	if err != nil {
+		return err
+	}
+	resp, err := d.call(ctx, http.MethodPost, "/api/v2/statements", body)
+	for err == nil && resp.StatementStatusURL != "" {
+		select {
+		case <-time.After(snowflakePollInterval):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		resp, err = d.call(ctx, http.MethodGet, resp.StatementStatusURL, nil)
+	}
+	return err
+}
+
+// call sends a SQL API request. It returns the statement's status URL
+// while the statement runs, and an error when it failed.
+func (d *snowflakeDestination) call(ctx context.Context, method, path string, body []byte) (*snowflakeResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, method, d.endpoint+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+d.token)
+	req.Header.Set("X-Snowflake-Authorization-Token-Type", d.tokenType)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	httpResp, err := d.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+	data, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp snowflakeResponse
+	if json.Unmarshal(data, &resp) != nil {
+		resp.Message = strings.TrimSpace(string(data))
+	}
+	switch httpResp.StatusCode {
+	case http.StatusOK:
+		return &snowflakeResponse{}, nil
+	case http.StatusAccepted:
+		if resp.StatementStatusURL == "" {
+			return nil, fmt.Errorf("snowflake accepted the statement without a status URL")
+		}
+		return &resp, nil
+	default:
+		return nil, fmt.Errorf("snowflake returned %s: %s %s", httpResp.Status, resp.Code, resp.Message)
+	}
+}
+
+// quoteSnowflake quotes an identifier, keeping its case
+func quoteSnowflake(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
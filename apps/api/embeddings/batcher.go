@@ -0,0 +1,455 @@
+package embeddings
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Batcher defaults and tuning
+const (
+	DefaultBatchSize     = 96                    // Texts per provider request
+	DefaultMaxBatchChars = 200000                // Characters per provider request, well under token limits
+	DefaultMaxRetries    = 5                     // Retries of a rate-limited request
+	batchWindow          = 20 * time.Millisecond // How long a partial batch waits for more texts
+	queueSize            = 4096                  // Texts waiting for a batch before submitters block
+	requestTimeout       = 2 * time.Minute       // Bound on each provider request, retries included
+	minBackoff           = time.Second           // First wait after the provider rate limits
+	maxBackoff           = time.Minute           // Longest wait between retries
+	minRatePerMinute     = 6                     // Floor of the adaptive request rate
+)
+
+// ErrBatcherClosed is returned for texts submitted after Close
+var ErrBatcherClosed = errors.New("embedding batcher closed")
+
+// BatchConfig configures a Batcher
+type BatchConfig struct {
+	BatchSize         int // Texts per provider request; defaults to DefaultBatchSize
+	MaxBatchChars     int // Characters per provider request; defaults to DefaultMaxBatchChars
+	RequestsPerMinute int // Provider requests per minute; 0 only backs off when rate limited
+	MaxRetries        int // Retries of a rate-limited request; defaults to DefaultMaxRetries
+}
+
+// Result is the embedding of one text of a batch
+type Result struct {
+	Vector []float32
+	Err    error
+}
+
+// ItemError is the failure of one text of a batch
+type ItemError struct {
+	Index int
+	Err   error
+}
+
+// BatchError reports the texts of an EmbedDocuments call that failed;
+// the others were embedded
+type BatchError struct {
+	Total  int
+	Failed []ItemError
+}
+
+// Error summarizes the failures with the first cause
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("%d of %d text(s) failed to embed, first (text %d): %v",
+		len(e.Failed), e.Total, e.Failed[0].Index, e.Failed[0].Err)
+}
+
+// Unwrap returns the first cause
+func (e *BatchError) Unwrap() error {
+	return e.Failed[0].Err
+}
+
+// pending is a text waiting to be embedded
+type pending struct {
+	ctx    context.Context
+	text   string
+	result chan Result // Buffered so the worker never blocks on an abandoned caller
+}
+
+// Batcher embeds texts from concurrent callers in provider-sized batches
+// instead of one request per text. Requests are throttled to a rate that
+// halves whenever the provider rate limits and recovers as requests
+// succeed. A failing batch is split to find the texts that fail, so the
+// others are still embedded.
+type Batcher struct {
+	embedder   Embedder
+	batchSize  int
+	maxChars   int
+	maxRetries int
+	ratePerMin int
+	limiter    *rate.Limiter // nil when requests aren't throttled
+
+	queue   chan *pending
+	stop    chan struct{}
+	stopped chan struct{} // Closed once the worker has answered every queued text
+	wg      sync.WaitGroup
+
+	mu           sync.Mutex
+	closed       bool
+	backoffUntil time.Time // No request is sent before this
+	rateLimited  int       // Consecutive rate-limited requests
+}
+
+// Statically assert that Batcher implements Embedder
+var _ Embedder = &Batcher{}
+
+// NewBatcher wraps an embedder and starts its batching worker
+func NewBatcher(embedder Embedder, cfg BatchConfig) *Batcher {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = DefaultBatchSize
+	}
+	if cfg.MaxBatchChars <= 0 {
+		cfg.MaxBatchChars = DefaultMaxBatchChars
+	}
+	if cfg.MaxRetries <= 0 {
+		cfg.MaxRetries = DefaultMaxRetries
+	}
+
+	b := &Batcher{
+		embedder:   embedder,
+		batchSize:  cfg.BatchSize,
+		maxChars:   cfg.MaxBatchChars,
+		maxRetries: cfg.MaxRetries,
+		ratePerMin: cfg.RequestsPerMinute,
+		queue:      make(chan *pending, queueSize),
+		stop:       make(chan struct{}),
+		stopped:    make(chan struct{}),
+	}
+	if cfg.RequestsPerMinute > 0 {
+		b.limiter = rate.NewLimiter(perMinute(cfg.RequestsPerMinute), 1)
+	}
+
+	b.wg.Add(1)
+	go b.run()
+	return b
+}
+
+// EmbedDocuments embeds texts in batches shared with other callers. When
+// some texts fail it returns a *BatchError; use EmbedBatch to keep the
+// vectors of the others.
+func (b *Batcher) EmbedDocuments(ctx context.Context, texts []string) ([][]float32, error) {
+	results := b.EmbedBatch(ctx, texts)
+
+	vectors := make([][]float32, len(results))
+	batchErr := &BatchError{Total: len(texts)}
+	for i, result := range results {
+		if result.Err != nil {
+			batchErr.Failed = append(batchErr.Failed, ItemError{Index: i, Err: result.Err})
+			continue
+		}
+		vectors[i] = result.Vector
+	}
+	if len(batchErr.Failed) > 0 {
+		return nil, batchErr
+	}
+	return vectors, nil
+}
+
+// EmbedBatch embeds texts in batches shared with other callers and returns
+// a result per text
+func (b *Batcher) EmbedBatch(ctx context.Context, texts []string) []Result {
+	items := make([]*pending, len(texts))
+	results := make([]Result, len(texts))
+	for i, text := range texts {
+		item := &pending{ctx: ctx, text: text, result: make(chan Result, 1)}
+		if err := b.submit(ctx, item); err != nil {
+			results[i] = Result{Err: err}
+			continue
+		}
+		items[i] = item
+	}
+
+	for i, item := range items {
+		if item == nil {
+			continue
+		}
+		select {
+		case results[i] = <-item.result:
+		case <-ctx.Done():
+			results[i] = Result{Err: ctx.Err()}
+		case <-b.stopped:
+			// Queued as the batcher closed, after the worker drained the queue
+			select {
+			case results[i] = <-item.result:
+			default:
+				results[i] = Result{Err: ErrBatcherClosed}
+			}
+		}
+	}
+	return results
+}
+
+// EmbedQuery embeds a search query right away, bypassing batching so
+// searches don't wait for a batch, but within the rate limit
+func (b *Batcher) EmbedQuery(ctx context.Context, text string) ([]float32, error) {
+	var vector []float32
+	err := b.withRetries(ctx, func(ctx context.Context) error {
+		var err error
+		vector, err = b.embedder.EmbedQuery(ctx, text)
+		return err
+	})
+	return vector, err
+}
+
+// Close stops accepting texts, fails the queued ones, and waits for the
+// batch in flight, or until ctx is done
+func (b *Batcher) Close(ctx context.Context) error {
+	b.mu.Lock()
+	if !b.closed {
+		b.closed = true
+		close(b.stop)
+	}
+	b.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		b.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("embedding batch unfinished: %w", ctx.Err())
+	}
+}
+
+// submit queues a text, blocking while the queue is full
+func (b *Batcher) submit(ctx context.Context, item *pending) error {
+	b.mu.Lock()
+	closed := b.closed
+	b.mu.Unlock()
+	if closed {
+		return ErrBatcherClosed
+	}
+
+	select {
+	case b.queue <- item:
+		return nil
+	case <-b.stop:
+		return ErrBatcherClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run collects queued texts into batches and embeds them until the
+// batcher is closed
+func (b *Batcher) run() {
+	defer b.wg.Done()
+	defer close(b.stopped)
+
+	for {
+		select {
+		case item := <-b.queue:
+			b.process(b.collect(item))
+		case <-b.stop:
+			b.drain()
+			return
+		}
+	}
+}
+
+// collect fills a batch starting with first from the queue, waiting up to
+// batchWindow for more texts
+func (b *Batcher) collect(first *pending) []*pending {
+	batch := []*pending{first}
+	chars := len(first.text)
+
+	timer := time.NewTimer(batchWindow)
+	defer timer.Stop()
+	for len(batch) < b.batchSize {
+		select {
+		case item := <-b.queue:
+			if chars+len(item.text) > b.maxChars {
+				// Send what we have; the text starts the next batch
+				b.process(batch)
+				batch, chars = nil, 0
+			}
+			batch = append(batch, item)
+			chars += len(item.text)
+		case <-timer.C:
+			return batch
+		case <-b.stop:
+			return batch
+		}
+	}
+	return batch
+}
+
+// drain fails the texts still queued after Close
+func (b *Batcher) drain() {
+	for {
+		select {
+		case item := <-b.queue:
+			item.result <- Result{Err: ErrBatcherClosed}
+		default:
+			return
+		}
+	}
+}
+
+// process embeds a batch and delivers each text's result. When the
+// provider rejects the input of a batch, it is split in halves until the
+// failing texts are isolated; other failures fail the whole batch.
+func (b *Batcher) process(batch []*pending) {
+	// Skip texts whose callers gave up
+	live := batch[:0]
+	for _, item := range batch {
+		if err := item.ctx.Err(); err != nil {
+			item.result <- Result{Err: err}
+			continue
+		}
+		live = append(live, item)
+	}
+	if len(live) == 0 {
+		return
+	}
+
+	texts := make([]string, len(live))
+	for i, item := range live {
+		texts[i] = item.text
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	var vectors [][]float32
+	err := b.withRetries(ctx, func(ctx context.Context) error {
+		var err error
+		vectors, err = b.embedder.EmbedDocuments(ctx, texts)
+		if err == nil && len(vectors) != len(texts) {
+			err = fmt.Errorf("embedder returned %d vectors for %d texts", len(vectors), len(texts))
+		}
+		return err
+	})
+	cancel()
+
+	switch {
+	case err == nil:
+		for i, item := range live {
+			item.result <- Result{Vector: vectors[i]}
+		}
+	case len(live) > 1 && isInputRejected(err):
+		mid := len(live) / 2
+		b.process(live[:mid])
+		b.process(live[mid:])
+	default:
+		for _, item := range live {
+			item.result <- Result{Err: err}
+		}
+	}
+}
+
+// withRetries sends a provider request within the rate limit, retrying
+// with exponential backoff while the provider rate limits it
+func (b *Batcher) withRetries(ctx context.Context, request func(ctx context.Context) error) error {
+	var err error
+	for attempt := 0; attempt <= b.maxRetries; attempt++ {
+		if err := b.wait(ctx); err != nil {
+			return err
+		}
+
+		err = request(ctx)
+		if !isRateLimited(err) {
+			if err == nil {
+				b.succeeded()
+			}
+			return err
+		}
+		b.throttled()
+	}
+	return fmt.Errorf("embedding provider still rate limiting after %d retries: %w", b.maxRetries, err)
+}
+
+// wait blocks until a backoff has passed and the limiter allows a request
+func (b *Batcher) wait(ctx context.Context) error {
+	b.mu.Lock()
+	delay := time.Until(b.backoffUntil)
+	b.mu.Unlock()
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	if b.limiter != nil {
+		return b.limiter.Wait(ctx)
+	}
+	return nil
+}
+
+// throttled backs off after the provider rate limited a request, doubling
+// the wait on each consecutive limit, and halves the request rate
+func (b *Batcher) throttled() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	backoff := minBackoff << min(b.rateLimited, 6)
+	backoff = min(backoff, maxBackoff)
+	backoff += rand.N(backoff / 2) // Jitter so concurrent callers don't retry together
+	b.backoffUntil = time.Now().Add(backoff)
+	b.rateLimited++
+
+	if b.limiter != nil {
+		limit := max(b.limiter.Limit()/2, perMinute(minRatePerMinute))
+		b.limiter.SetLimit(limit)
+		log.Printf("Warning: embedding provider rate limited, backing off %s at %.0f requests/minute",
+			backoff.Round(time.Millisecond), float64(limit)*60)
+	} else {
+		log.Printf("Warning: embedding provider rate limited, backing off %s", backoff.Round(time.Millisecond))
+	}
+}
+
+// succeeded resets the backoff and raises the request rate by a tenth
+// toward the configured rate
+func (b *Batcher) succeeded() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.rateLimited = 0
+	if b.limiter != nil {
+		configured := perMinute(b.ratePerMin)
+		if limit := b.limiter.Limit(); limit < configured {
+			b.limiter.SetLimit(min(limit*1.1, configured))
+		}
+	}
+}
+
+// perMinute converts requests per minute to a limiter rate
+func perMinute(n int) rate.Limit {
+	return rate.Limit(float64(n) / 60)
+}
+
+// isRateLimited reports whether a provider error is a rate limit. The
+// provider clients only return HTTP errors as text.
+func isRateLimited(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "429") || strings.Contains(msg, "rate limit") || strings.Contains(msg, "too many requests")
+}
+
+// isInputRejected reports whether a provider error blames the request's
+// texts, such as one exceeding the model's context, rather than the
+// provider or credentials
+func isInputRejected(err error) bool {
+	msg := strings.ToLower(err.Error())
+	for _, marker := range []string{"400", "413", "422", "invalid input", "maximum context length", "too long"} {
+		if strings.Contains(msg, marker) {
+			return true
+		}
+	}
+	return false
+}
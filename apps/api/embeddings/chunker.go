@@ -0,0 +1,79 @@
+package embeddings
+
+import (
+	"strings"
+)
+
+// Default chunking parameters (in characters)
+const (
+	DefaultChunkSize    = 1000
+	DefaultChunkOverlap = 200
+)
+
+// ChunkText splits text into overlapping chunks, preferring paragraph and
+// sentence boundaries so that each chunk stays semantically coherent
+func ChunkText(text string, chunkSize, overlap int) []string {
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+	if overlap < 0 || overlap >= chunkSize {
+		overlap = 0
+	}
+
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+
+	runes := []rune(text)
+	if len(runes) <= chunkSize {
+		return []string{text}
+	}
+
+	var chunks []string
+	start := 0
+	for start < len(runes) {
+		end := start + chunkSize
+		if end >= len(runes) {
+			end = len(runes)
+		} else {
+			end = findBreak(runes, start, end)
+		}
+
+		chunk := strings.TrimSpace(string(runes[start:end]))
+		if chunk != "" {
+			chunks = append(chunks, chunk)
+		}
+
+		if end == len(runes) {
+			break
+		}
+
+		// Step forward, keeping some overlap with the previous chunk
+		next := end - overlap
+		if next <= start {
+			next = end
+		}
+		start = next
+	}
+
+	return chunks
+}
+
+// findBreak looks backwards from end for a natural boundary within the
+// second half of the window, falling back to a hard cut at end
+func findBreak(runes []rune, start, end int) int {
+	minEnd := start + (end-start)/2
+	separators := []string{"\n\n", ". ", "\n", " "}
+
+	for _, sep := range separators {
+		sepRunes := []rune(sep)
+		for i := end - len(sepRunes); i >= minEnd; i-- {
+			if string(runes[i:i+len(sepRunes)]) == sep {
+				return i + len(sepRunes)
+			}
+		}
+	}
+
+	return end
+}
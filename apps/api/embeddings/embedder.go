@@ -0,0 +1,94 @@
+package embeddings
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	lcembeddings "github.com/tmc/langchaingo/embeddings"
+	"github.com/tmc/langchaingo/llms/ollama"
+	"github.com/tmc/langchaingo/llms/openai"
+)
+
+// Embedder creates vector embeddings from text
+type Embedder interface {
+	// EmbedDocuments returns a vector for each text
+	EmbedDocuments(ctx context.Context, texts []string) ([][]float32, error)
+	// EmbedQuery embeds a single search query
+	EmbedQuery(ctx context.Context, text string) ([]float32, error)
+}
+
+// Config holds embedder configuration
+type Config struct {
+	Provider string // "openai" or "ollama" (local)
+	APIKey   string
+	Model    string
+	BaseURL  string // Optional server URL (required for local embedders)
+}
+
+// NewEmbedder creates an embedder for the configured provider
+func NewEmbedder(cfg Config) (Embedder, error) {
+	var client lcembeddings.EmbedderClient
+	var err error
+
+	switch strings.ToLower(cfg.Provider) {
+	case "openai", "":
+		if cfg.APIKey == "" {
+			return nil, fmt.Errorf("OpenAI API key is required for embeddings")
+		}
+		opts := []openai.Option{
+			openai.WithToken(cfg.APIKey),
+			openai.WithEmbeddingModel(getEmbeddingModelName(cfg.Provider, cfg.Model)),
+		}
+		if cfg.BaseURL != "" {
+			opts = append(opts, openai.WithBaseURL(cfg.BaseURL))
+		}
+		client, err = openai.New(opts...)
+	case "ollama":
+		opts := []ollama.Option{
+			ollama.WithModel(getEmbeddingModelName(cfg.Provider, cfg.Model)),
+		}
+		if cfg.BaseURL != "" {
+			opts = append(opts, ollama.WithServerURL(cfg.BaseURL))
+		}
+		client, err = ollama.New(opts...)
+	default:
+		return nil, fmt.Errorf("unsupported embedding provider: %s", cfg.Provider)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to create embedding client: %w", err)
+	}
+
+	return lcembeddings.NewEmbedder(client)
+}
+
+// getEmbeddingModelName returns the embedding model for each provider
+func getEmbeddingModelName(provider, model string) string {
+	if model != "" {
+		return model
+	}
+
+	// Default models produce DefaultDimensions (openai) or 768 (ollama)
+	// dimensions; EMBEDDING_DIMENSIONS must match the model in use
+	switch strings.ToLower(provider) {
+	case "ollama":
+		return "nomic-embed-text"
+	default:
+		return "text-embedding-3-small"
+	}
+}
+
+// FormatVector converts an embedding to pgvector's text representation
+func FormatVector(v []float32) string {
+	var sb strings.Builder
+	sb.WriteByte('[')
+	for i, f := range v {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(fmt.Sprintf("%g", f))
+	}
+	sb.WriteByte(']')
+	return sb.String()
+}
@@ -0,0 +1,80 @@
+package embeddings
+
+import (
+	"context"
+	"fmt"
+)
+
+// DefaultTopK is the number of chunks returned by Retrieve when k is not set
+const DefaultTopK = 4
+
+// Pipeline chunks, embeds, stores, and retrieves knowledge base content
+type Pipeline struct {
+	store    *Store
+	embedder Embedder
+	topK     int
+}
+
+// NewPipeline creates a new embedding pipeline
+func NewPipeline(store *Store, embedder Embedder, topK int) *Pipeline {
+	if topK <= 0 {
+		topK = DefaultTopK
+	}
+	return &Pipeline{
+		store:    store,
+		embedder: embedder,
+		topK:     topK,
+	}
+}
+
+// IngestText chunks the text, embeds each chunk, and stores the document
+func (p *Pipeline) IngestText(ctx context.Context, doc Document, text string) (*Document, int, error) {
+	contents := ChunkText(text, DefaultChunkSize, DefaultChunkOverlap)
+	if len(contents) == 0 {
+		return nil, 0, fmt.Errorf("document '%s' has no content to ingest", doc.Title)
+	}
+
+	vectors, err := p.embedder.EmbedDocuments(ctx, contents)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to embed chunks: %w", err)
+	}
+	if len(vectors) != len(contents) {
+		return nil, 0, fmt.Errorf("embedder returned %d vectors for %d chunks", len(vectors), len(contents))
+	}
+
+	chunks := make([]Chunk, 0, len(contents))
+	for i, content := range contents {
+		chunks = append(chunks, Chunk{
+			ChunkIndex: i,
+			Content:    content,
+			Embedding:  vectors[i],
+		})
+	}
+
+	saved, err := p.store.SaveDocument(ctx, doc, chunks)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return saved, len(chunks), nil
+}
+
+// Retrieve returns the top-k chunks most relevant to the query
+func (p *Pipeline) Retrieve(ctx context.Context, query string, k int) ([]ScoredChunk, error) {
+	return p.Search(ctx, query, k, nil)
+}
+
+// Search returns the top-k chunks most relevant to the query whose document
+// metadata matches all filters
+func (p *Pipeline) Search(ctx context.Context, query string, k int, filters map[string]string) ([]ScoredChunk, error) {
+	if k <= 0 {
+		k = p.topK
+	}
+
+	vector, err := p.embedder.EmbedQuery(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+
+	return p.store.Search(ctx, vector, k, filters)
+}
@@ -0,0 +1,226 @@
+package embeddings
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"agentic-template/api/db"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Store persists documents and chunk embeddings in PostgreSQL (pgvector)
+type Store struct {
+	pool *pgxpool.Pool
+}
+
+// NewStore creates a new vector store
+func NewStore(pool *pgxpool.Pool) *Store {
+	return &Store{
+		pool: pool,
+	}
+}
+
+// SaveDocument inserts a document together with its chunks in one transaction
+func (s *Store) SaveDocument(ctx context.Context, doc Document, chunks []Chunk) (*Document, error) {
+	if s.pool == nil {
+		return nil, fmt.Errorf("database not configured - please add DATABASE_URL_POOLED in Environment Settings")
+	}
+
+	metadataJSON, err := marshalMetadata(doc.Metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	if doc.ContentType == "" {
+		doc.ContentType = "text/plain"
+	}
+
+	err = db.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
+		insertDocQuery := `
+			INSERT INTO rag_documents (title, source, content_type, metadata)
+			VALUES ($1, $2, $3, $4)
+			RETURNING id, created_at, updated_at
+		`
+		err := tx.QueryRow(ctx, insertDocQuery, doc.Title, doc.Source, doc.ContentType, metadataJSON).
+			Scan(&doc.ID, &doc.CreatedAt, &doc.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to insert document: %w", err)
+		}
+
+		return insertChunks(ctx, tx, doc.ID, chunks)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &doc, nil
+}
+
+// insertChunks writes chunk rows for a document
+func insertChunks(ctx context.Context, tx pgx.Tx, documentID int, chunks []Chunk) error {
+	insertChunkQuery := `
+		INSERT INTO rag_chunks (document_id, chunk_index, content, metadata, embedding)
+		VALUES ($1, $2, $3, $4, $5::vector)
+	`
+	dims := CurrentDimensions()
+	for _, chunk := range chunks {
+		if len(chunk.Embedding) != dims {
+			return fmt.Errorf("chunk %d has %d dimensions, expected %d", chunk.ChunkIndex, len(chunk.Embedding), dims)
+		}
+
+		metadataJSON, err := marshalMetadata(chunk.Metadata)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, insertChunkQuery,
+			documentID,
+			chunk.ChunkIndex,
+			chunk.Content,
+			metadataJSON,
+			FormatVector(chunk.Embedding),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert chunk %d: %w", chunk.ChunkIndex, err)
+		}
+	}
+
+	return nil
+}
+
+// Search returns the k chunks closest to the given embedding by cosine distance.
+// Filters restrict results to chunks whose document metadata contains every
+// given key/value pair.
+func (s *Store) Search(ctx context.Context, embedding []float32, k int, filters map[string]string) ([]ScoredChunk, error) {
+	if s.pool == nil {
+		return nil, fmt.Errorf("database not configured - please add DATABASE_URL_POOLED in Environment Settings")
+	}
+	if dims := CurrentDimensions(); len(embedding) != dims {
+		return nil, fmt.Errorf("query embedding has %d dimensions, expected %d", len(embedding), dims)
+	}
+
+	filterJSON, err := marshalMetadata(filters)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT c.id, c.document_id, c.chunk_index, c.content, c.metadata, d.title,
+		       1 - (c.embedding <=> $1::vector) AS similarity
+		FROM rag_chunks c
+		JOIN rag_documents d ON d.id = c.document_id
+		WHERE d.metadata @> $3::jsonb
+		ORDER BY c.embedding <=> $1::vector
+		LIMIT $2
+	`
+	rows, err := s.pool.Query(ctx, query, FormatVector(embedding), k, filterJSON)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search chunks: %w", err)
+	}
+	defer rows.Close()
+
+	results := []ScoredChunk{}
+	for rows.Next() {
+		var sc ScoredChunk
+		var metadataJSON []byte
+		err := rows.Scan(
+			&sc.ID,
+			&sc.DocumentID,
+			&sc.ChunkIndex,
+			&sc.Content,
+			&metadataJSON,
+			&sc.DocumentTitle,
+			&sc.Similarity,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan chunk: %w", err)
+		}
+		if err := json.Unmarshal(metadataJSON, &sc.Metadata); err != nil {
+			return nil, fmt.Errorf("failed to decode chunk metadata: %w", err)
+		}
+		results = append(results, sc)
+	}
+
+	return results, rows.Err()
+}
+
+// EnsureDimensions resizes rag_chunks.embedding to the configured dimensions
+// and rebuilds its index. The column can only be resized while it holds no
+// chunks, since embeddings of different models can't be compared.
+func EnsureDimensions(ctx context.Context, pool *pgxpool.Pool) error {
+	if pool == nil {
+		return nil
+	}
+
+	dims := CurrentDimensions()
+	return db.WithTx(ctx, pool, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `LOCK TABLE rag_chunks IN ACCESS EXCLUSIVE MODE`); err != nil {
+			return fmt.Errorf("failed to lock rag_chunks: %w", err)
+		}
+
+		// pgvector stores a column's dimensions as its type modifier
+		var current int
+		err := tx.QueryRow(ctx, `
+			SELECT atttypmod FROM pg_attribute
+			WHERE attrelid = 'rag_chunks'::regclass AND attname = 'embedding'
+		`).Scan(&current)
+		if err != nil {
+			return fmt.Errorf("failed to read embedding dimensions: %w", err)
+		}
+		if current == dims {
+			return nil
+		}
+
+		var populated bool
+		if err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM rag_chunks)`).Scan(&populated); err != nil {
+			return fmt.Errorf("failed to check for chunks: %w", err)
+		}
+		if populated {
+			return fmt.Errorf("rag_chunks holds %d-dimension embeddings; delete the knowledge base documents before changing EMBEDDING_DIMENSIONS to %d", current, dims)
+		}
+
+		statements := []string{
+			`DROP INDEX IF EXISTS idx_rag_chunks_embedding`,
+			fmt.Sprintf(`ALTER TABLE rag_chunks ALTER COLUMN embedding TYPE VECTOR(%d)`, dims),
+			`CREATE INDEX idx_rag_chunks_embedding ON rag_chunks USING hnsw (embedding vector_cosine_ops)`,
+		}
+		for _, stmt := range statements {
+			if _, err := tx.Exec(ctx, stmt); err != nil {
+				return fmt.Errorf("failed to resize rag_chunks.embedding to %d dimensions: %w", dims, err)
+			}
+		}
+		return nil
+	})
+}
+
+// DeleteDocument removes a document and all of its chunks
+func (s *Store) DeleteDocument(ctx context.Context, documentID int) error {
+	if s.pool == nil {
+		return fmt.Errorf("database not configured - please add DATABASE_URL_POOLED in Environment Settings")
+	}
+
+	tag, err := s.pool.Exec(ctx, `DELETE FROM rag_documents WHERE id = $1`, documentID)
+	if err != nil {
+		return fmt.Errorf("failed to delete document: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("document not found")
+	}
+
+	return nil
+}
+
+// marshalMetadata encodes metadata as JSON, defaulting to an empty object
+func marshalMetadata(metadata map[string]string) (string, error) {
+	if metadata == nil {
+		return "{}", nil
+	}
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+	return string(data), nil
+}
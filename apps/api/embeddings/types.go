@@ -0,0 +1,56 @@
+package embeddings
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// DefaultDimensions is the vector size of rag_chunks.embedding as created by
+// migration 002, matching OpenAI text-embedding-3-small
+const DefaultDimensions = 1536
+
+// dimensions is the configured vector size of the embedding model
+var dimensions atomic.Int64
+
+func init() {
+	dimensions.Store(DefaultDimensions)
+}
+
+// SetDimensions sets the vector size of the embedding model. EnsureDimensions
+// resizes rag_chunks.embedding to match.
+func SetDimensions(n int) {
+	dimensions.Store(int64(n))
+}
+
+// CurrentDimensions returns the configured vector size of the embedding model
+func CurrentDimensions() int {
+	return int(dimensions.Load())
+}
+
+// Document represents a source document stored in the knowledge base
+type Document struct {
+	ID          int               `json:"id,omitempty"`
+	Title       string            `json:"title"`
+	Source      *string           `json:"source,omitempty"` // Optional origin (URL, file name)
+	ContentType string            `json:"content_type"`     // MIME type of the original content
+	Metadata    map[string]string `json:"metadata,omitempty"`
+	CreatedAt   time.Time         `json:"created_at,omitempty"`
+	UpdatedAt   time.Time         `json:"updated_at,omitempty"`
+}
+
+// Chunk represents a piece of a document with its embedding
+type Chunk struct {
+	ID         int               `json:"id,omitempty"`
+	DocumentID int               `json:"document_id"`
+	ChunkIndex int               `json:"chunk_index"`
+	Content    string            `json:"content"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
+	Embedding  []float32         `json:"-"`
+}
+
+// ScoredChunk is a chunk returned from a similarity search
+type ScoredChunk struct {
+	Chunk
+	DocumentTitle string  `json:"document_title"`
+	Similarity    float64 `json:"similarity"` // Cosine similarity, 1.0 is identical
+}
@@ -0,0 +1,297 @@
+package grpc_server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"agentic-template/api/agent/jobs"
+	"agentic-template/api/agent/runs"
+	pb "agentic-template/api/pb/v1"
+	"agentic-template/api/requestctx"
+)
+
+// jobFlushInterval bounds how long streamed output is buffered before a
+// job's progress is persisted
+const jobFlushInterval = time.Second
+
+// SubmitAgentJob queues an agent run for the worker pool
+func (s *AgentServiceServer) SubmitAgentJob(ctx context.Context, req *pb.SubmitAgentJobRequest) (*pb.SubmitAgentJobResponse, error) {
+	pool := s.jobPool()
+	if pool == nil {
+		return &pb.SubmitAgentJobResponse{
+			Success: false,
+			Message: "database not configured - please add DATABASE_URL_POOLED in Environment Settings",
+		}, nil
+	}
+
+	if req.Request == nil || req.Request.Query == "" {
+		return &pb.SubmitAgentJobResponse{
+			Success: false,
+			Message: "Failed to submit agent job: query cannot be empty",
+		}, nil
+	}
+	if _, err := s.runLimits(req.Request, nil); err != nil {
+		return &pb.SubmitAgentJobResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to submit agent job: invalid run limits: %v", err),
+		}, nil
+	}
+	if len(req.Request.Attachments) > 0 {
+		return &pb.SubmitAgentJobResponse{
+			Success: false,
+			Message: "Failed to submit agent job: attachments are only accepted by streaming requests",
+		}, nil
+	}
+	if err := responseStyleFromPb(req.Request.ResponseStyle).Validate(); err != nil {
+		return &pb.SubmitAgentJobResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to submit agent job: invalid response style: %v", err),
+		}, nil
+	}
+
+	job, err := jobs.NewStore(s.dbManager.GetPool()).Enqueue(ctx, jobRequestFromPb(req.Request))
+	if err != nil {
+		return &pb.SubmitAgentJobResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to submit agent job: %v", err),
+		}, nil
+	}
+	pool.Notify()
+
+	return &pb.SubmitAgentJobResponse{
+		Success: true,
+		Message: fmt.Sprintf("Agent job %d queued", job.ID),
+		Job:     convertAgentJobToPb(job),
+	}, nil
+}
+
+// GetAgentJob retrieves the status, progress, and result of an agent job
+func (s *AgentServiceServer) GetAgentJob(ctx context.Context, req *pb.GetAgentJobRequest) (*pb.GetAgentJobResponse, error) {
+	job, err := jobs.NewStore(s.dbManager.GetPool()).Get(ctx, int(req.JobId))
+	if err != nil {
+		return &pb.GetAgentJobResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to get agent job: %v", err),
+		}, nil
+	}
+
+	return &pb.GetAgentJobResponse{
+		Success: true,
+		Message: "Agent job retrieved successfully",
+		Job:     convertAgentJobToPb(job),
+	}, nil
+}
+
+// processJob runs a claimed job through the same pipeline as the streaming
+// RPC, persisting its events as they are produced
+func (s *AgentServiceServer) processJob(ctx context.Context, job *jobs.Job) error {
+	req := jobRequestToPb(job.Request)
+	if req.Metadata == nil {
+		req.Metadata = map[string]string{}
+	}
+	req.Metadata["agent_job_id"] = strconv.Itoa(job.ID)
+
+	// Attribute the run to whoever submitted the job
+	if job.CreatedBy != nil {
+		ctx = requestctx.WithActor(ctx, *job.CreatedBy)
+	}
+
+	sink := &jobSink{store: jobs.NewStore(s.dbManager.GetPool()), jobID: job.ID, lastFlush: time.Now()}
+	runErr := s.runAgent(ctx, req, sink, nil)
+
+	// Persist what is still buffered even when the run was cancelled
+	flushCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := sink.flush(flushCtx); err != nil {
+		log.Printf("Warning: failed to save progress of agent job %d: %v", job.ID, err)
+	}
+
+	if runErr != nil {
+		return runErr
+	}
+	return sink.failure
+}
+
+// jobSink persists the events of a job's run in place of a gRPC stream.
+// Output is buffered and written at most once per jobFlushInterval; other
+// events are written immediately.
+type jobSink struct {
+	store     *jobs.Store
+	jobID     int
+	output    strings.Builder
+	events    []runs.Event
+	lastFlush time.Time
+	failure   error // Error or timeout reported by the run
+}
+
+// Send records a response event and persists progress when due
+func (j *jobSink) Send(resp *pb.AgentResponse) error {
+	event := runs.Event{AgentName: resp.AgentName, Timestamp: time.Unix(resp.Timestamp, 0)}
+
+	switch e := resp.Event.(type) {
+	case *pb.AgentResponse_Chunk:
+		j.output.WriteString(e.Chunk)
+		if time.Since(j.lastFlush) < jobFlushInterval {
+			return nil
+		}
+	case *pb.AgentResponse_Thought:
+		event.Type = runs.EventThought
+		event.Content = e.Thought
+		j.events = append(j.events, event)
+	case *pb.AgentResponse_ToolCall:
+		event.Type = runs.EventToolStart
+		event.Tool = e.ToolCall.ToolName
+		event.Content = e.ToolCall.ToolInput
+		j.events = append(j.events, event)
+	case *pb.AgentResponse_GuardrailTriggered:
+		event.Type = runs.EventGuardrail
+		event.Content = fmt.Sprintf("%s: %s (%s)", e.GuardrailTriggered.Policy, e.GuardrailTriggered.Detail, e.GuardrailTriggered.Action)
+		j.events = append(j.events, event)
+	case *pb.AgentResponse_Citation:
+		event.Type = runs.EventCitation
+		event.Tool = e.Citation.Tool
+		event.Content = citationToAgent(e.Citation).String()
+		j.events = append(j.events, event)
+	case *pb.AgentResponse_Timeout:
+		event.Type = runs.EventTimeout
+		event.Content = e.Timeout.Message
+		j.events = append(j.events, event)
+		j.failure = errors.New(e.Timeout.Message)
+	case *pb.AgentResponse_Error:
+		event.Type = runs.EventAgentError
+		event.Content = e.Error
+		j.events = append(j.events, event)
+		j.failure = errors.New(e.Error)
+	case *pb.AgentResponse_Done, *pb.AgentResponse_Usage:
+		// Usage is saved with the run when it finishes
+		return nil
+	}
+
+	// Progress is best effort; unsaved events are retried on the next flush
+	if err := j.flush(context.Background()); err != nil {
+		log.Printf("Warning: failed to save progress of agent job %d: %v", j.jobID, err)
+	}
+	return nil
+}
+
+// flush appends buffered output and events to the job
+func (j *jobSink) flush(ctx context.Context) error {
+	j.lastFlush = time.Now()
+	if j.output.Len() == 0 && len(j.events) == 0 {
+		return nil
+	}
+
+	if err := j.store.AppendProgress(ctx, j.jobID, j.output.String(), j.events); err != nil {
+		return err
+	}
+
+	j.output.Reset()
+	j.events = nil
+	return nil
+}
+
+// jobRequestFromPb converts an agent request to a job request
+func jobRequestFromPb(req *pb.AgentRequest) jobs.Request {
+	jobReq := jobs.Request{
+		Query:                   req.Query,
+		ConversationID:          req.ConversationId,
+		Metadata:                req.Metadata,
+		MaxIterations:           int(req.GetMaxIterations()),
+		IterationTimeoutSeconds: int(req.GetIterationTimeoutSeconds()),
+		TimeoutSeconds:          int(req.GetTimeoutSeconds()),
+	}
+
+	if style := responseStyleFromPb(req.ResponseStyle); !style.IsZero() {
+		jobReq.ResponseStyle = &style
+	}
+
+	if req.ProfileId != nil {
+		profileID := int(*req.ProfileId)
+		jobReq.ProfileID = &profileID
+	}
+	for _, id := range req.DelegateProfileIds {
+		jobReq.DelegateProfileIDs = append(jobReq.DelegateProfileIDs, int(id))
+	}
+
+	return jobReq
+}
+
+// jobRequestToPb converts a job request back to an agent request
+func jobRequestToPb(jobReq jobs.Request) *pb.AgentRequest {
+	req := &pb.AgentRequest{
+		Query:          jobReq.Query,
+		ConversationId: jobReq.ConversationID,
+		Metadata:       map[string]string{},
+	}
+	for key, value := range jobReq.Metadata {
+		req.Metadata[key] = value
+	}
+
+	if jobReq.ProfileID != nil {
+		profileID := int32(*jobReq.ProfileID)
+		req.ProfileId = &profileID
+	}
+	for _, id := range jobReq.DelegateProfileIDs {
+		req.DelegateProfileIds = append(req.DelegateProfileIds, int32(id))
+	}
+	if jobReq.MaxIterations > 0 {
+		maxIterations := int32(jobReq.MaxIterations)
+		req.MaxIterations = &maxIterations
+	}
+	if jobReq.IterationTimeoutSeconds > 0 {
+		iterationTimeout := int32(jobReq.IterationTimeoutSeconds)
+		req.IterationTimeoutSeconds = &iterationTimeout
+	}
+	if jobReq.TimeoutSeconds > 0 {
+		timeout := int32(jobReq.TimeoutSeconds)
+		req.TimeoutSeconds = &timeout
+	}
+	if jobReq.ResponseStyle != nil {
+		req.ResponseStyle = responseStyleToPb(*jobReq.ResponseStyle)
+	}
+
+	return req
+}
+
+// convertAgentJobToPb converts an agent job to its protobuf message
+func convertAgentJobToPb(job *jobs.Job) *pb.AgentJob {
+	pbJob := &pb.AgentJob{
+		Id:           int32(job.ID),
+		Request:      jobRequestToPb(job.Request),
+		Status:       job.Status,
+		Output:       job.Output,
+		ErrorMessage: job.ErrorMessage,
+		CreatedAt:    job.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		CreatedBy:    job.CreatedBy,
+	}
+
+	for _, event := range job.Events {
+		pbEvent := &pb.AgentRunEvent{
+			Type:      event.Type,
+			AgentName: event.AgentName,
+			Content:   event.Content,
+			Timestamp: event.Timestamp.Format("2006-01-02T15:04:05Z07:00"),
+		}
+		if event.Tool != "" {
+			tool := event.Tool
+			pbEvent.Tool = &tool
+		}
+		pbJob.Events = append(pbJob.Events, pbEvent)
+	}
+
+	if job.StartedAt != nil {
+		startedAt := job.StartedAt.Format("2006-01-02T15:04:05Z07:00")
+		pbJob.StartedAt = &startedAt
+	}
+	if job.CompletedAt != nil {
+		completedAt := job.CompletedAt.Format("2006-01-02T15:04:05Z07:00")
+		pbJob.CompletedAt = &completedAt
+	}
+
+	return pbJob
+}
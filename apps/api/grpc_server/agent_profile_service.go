@@ -0,0 +1,264 @@
+package grpc_server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"agentic-template/api/agent"
+	"agentic-template/api/agent/profiles"
+	"agentic-template/api/db"
+	"agentic-template/api/ingestion"
+	pb "agentic-template/api/pb/v1"
+)
+
+// AgentProfileServiceServer implements the AgentProfileService gRPC service
+type AgentProfileServiceServer struct {
+	pb.UnimplementedAgentProfileServiceServer
+	dbManager        *db.Manager
+	ingestionService *ingestion.Service
+}
+
+// NewAgentProfileServiceServer creates a new agent profile service server
+func NewAgentProfileServiceServer(dbManager *db.Manager, ingestionService *ingestion.Service) *AgentProfileServiceServer {
+	return &AgentProfileServiceServer{
+		dbManager:        dbManager,
+		ingestionService: ingestionService,
+	}
+}
+
+// getStore returns a profile store with the current database pool
+func (s *AgentProfileServiceServer) getStore() *profiles.Store {
+	return profiles.NewStore(s.dbManager.GetPool())
+}
+
+// CreateAgentProfile creates a named agent profile
+func (s *AgentProfileServiceServer) CreateAgentProfile(ctx context.Context, req *pb.CreateAgentProfileRequest) (*pb.AgentProfileResponse, error) {
+	profile := profiles.Profile{
+		Name:            req.Name,
+		Description:     req.Description,
+		SystemPrompt:    req.SystemPrompt,
+		Provider:        req.Provider,
+		Model:           req.Model,
+		Temperature:     req.Temperature,
+		MaxTokens:       int(req.MaxTokens),
+		MemoryStrategy:  req.MemoryStrategy,
+		MemoryMaxTokens: int(req.MemoryMaxTokens),
+	}
+
+	if len(req.AllowedTools) > 0 {
+		if err := validateToolNames(req.AllowedTools); err != nil {
+			return &pb.AgentProfileResponse{
+				Success: false,
+				Message: fmt.Sprintf("Failed to create agent profile: %v", err),
+			}, nil
+		}
+		profile.AllowedTools = req.AllowedTools
+	}
+	if len(req.RoutingRules) > 0 {
+		profile.RoutingRules = req.RoutingRules
+	}
+	if req.MaxIterations != nil {
+		maxIterations := int(*req.MaxIterations)
+		profile.MaxIterations = &maxIterations
+	}
+
+	created, err := s.getStore().Create(ctx, profile)
+	if err != nil {
+		return &pb.AgentProfileResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to create agent profile: %v", err),
+		}, nil
+	}
+
+	return &pb.AgentProfileResponse{
+		Success: true,
+		Message: fmt.Sprintf("Agent profile '%s' created successfully", created.Name),
+		Profile: convertAgentProfileToPb(created),
+	}, nil
+}
+
+// GetAgentProfile retrieves an agent profile
+func (s *AgentProfileServiceServer) GetAgentProfile(ctx context.Context, req *pb.GetAgentProfileRequest) (*pb.AgentProfileResponse, error) {
+	profile, err := s.getStore().Get(ctx, int(req.ProfileId))
+	if err != nil {
+		return &pb.AgentProfileResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to get agent profile: %v", err),
+		}, nil
+	}
+
+	return &pb.AgentProfileResponse{
+		Success: true,
+		Message: "Agent profile retrieved successfully",
+		Profile: convertAgentProfileToPb(profile),
+	}, nil
+}
+
+// ListAgentProfiles returns all agent profiles
+func (s *AgentProfileServiceServer) ListAgentProfiles(ctx context.Context, req *pb.ListAgentProfilesRequest) (*pb.ListAgentProfilesResponse, error) {
+	list, err := s.getStore().List(ctx)
+	if err != nil {
+		return &pb.ListAgentProfilesResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to list agent profiles: %v", err),
+		}, nil
+	}
+
+	pbProfiles := make([]*pb.AgentProfile, 0, len(list))
+	for i := range list {
+		pbProfiles = append(pbProfiles, convertAgentProfileToPb(&list[i]))
+	}
+
+	return &pb.ListAgentProfilesResponse{
+		Success:  true,
+		Message:  fmt.Sprintf("Found %d profile(s)", len(list)),
+		Profiles: pbProfiles,
+	}, nil
+}
+
+// DeleteAgentProfile removes an agent profile
+func (s *AgentProfileServiceServer) DeleteAgentProfile(ctx context.Context, req *pb.DeleteAgentProfileRequest) (*pb.DeleteAgentProfileResponse, error) {
+	if err := s.getStore().Delete(ctx, int(req.ProfileId)); err != nil {
+		return &pb.DeleteAgentProfileResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to delete agent profile: %v", err),
+		}, nil
+	}
+
+	return &pb.DeleteAgentProfileResponse{
+		Success: true,
+		Message: "Agent profile deleted successfully",
+	}, nil
+}
+
+// SetAgentProfileTools replaces the tools an agent profile may use
+func (s *AgentProfileServiceServer) SetAgentProfileTools(ctx context.Context, req *pb.SetAgentProfileToolsRequest) (*pb.AgentProfileResponse, error) {
+	var allowedTools []string
+	if !req.AllowAllTools {
+		if err := validateToolNames(req.AllowedTools); err != nil {
+			return &pb.AgentProfileResponse{
+				Success: false,
+				Message: fmt.Sprintf("Failed to update agent profile tools: %v", err),
+			}, nil
+		}
+		allowedTools = append([]string{}, req.AllowedTools...)
+	}
+
+	profile, err := s.getStore().SetAllowedTools(ctx, int(req.ProfileId), allowedTools)
+	if err != nil {
+		return &pb.AgentProfileResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to update agent profile tools: %v", err),
+		}, nil
+	}
+
+	return &pb.AgentProfileResponse{
+		Success: true,
+		Message: "Agent profile tools updated successfully",
+		Profile: convertAgentProfileToPb(profile),
+	}, nil
+}
+
+// ListTools returns the tools in the tool registry
+func (s *AgentProfileServiceServer) ListTools(ctx context.Context, req *pb.ListToolsRequest) (*pb.ListToolsResponse, error) {
+	deps := agent.ToolDeps{
+		DB:        s.dbManager.GetReadDB(),
+		Retriever: s.ingestionService.Pipeline(),
+	}
+
+	infos := agent.DefaultRegistry.List(deps)
+	pbTools := make([]*pb.ToolInfo, 0, len(infos))
+	for _, info := range infos {
+		pbTool, err := convertToolInfoToPb(info)
+		if err != nil {
+			return &pb.ListToolsResponse{
+				Success: false,
+				Message: fmt.Sprintf("Failed to list tools: %v", err),
+			}, nil
+		}
+		pbTools = append(pbTools, pbTool)
+	}
+
+	return &pb.ListToolsResponse{
+		Success: true,
+		Message: fmt.Sprintf("Found %d tool(s)", len(pbTools)),
+		Tools:   pbTools,
+	}, nil
+}
+
+// SetToolEnabled enables or disables a registered tool for every agent
+func (s *AgentProfileServiceServer) SetToolEnabled(ctx context.Context, req *pb.SetToolEnabledRequest) (*pb.SetToolEnabledResponse, error) {
+	if err := agent.DefaultRegistry.SetEnabled(req.Name, req.Enabled); err != nil {
+		return &pb.SetToolEnabledResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to update tool: %v", err),
+		}, nil
+	}
+
+	state := "disabled"
+	if req.Enabled {
+		state = "enabled"
+	}
+
+	return &pb.SetToolEnabledResponse{
+		Success: true,
+		Message: fmt.Sprintf("Tool '%s' %s", req.Name, state),
+	}, nil
+}
+
+// validateToolNames checks that every tool name is registered
+func validateToolNames(names []string) error {
+	for _, name := range names {
+		if !agent.DefaultRegistry.Has(name) {
+			return fmt.Errorf("unknown tool: %s", name)
+		}
+	}
+	return nil
+}
+
+// Helper function to convert a tool description to protobuf
+func convertToolInfoToPb(info agent.ToolInfo) (*pb.ToolInfo, error) {
+	schemaJSON := []byte("{}")
+	if info.InputSchema != nil {
+		var err error
+		schemaJSON, err = json.Marshal(info.InputSchema)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode input schema for '%s': %w", info.Name, err)
+		}
+	}
+
+	return &pb.ToolInfo{
+		Name:            info.Name,
+		Description:     info.Description,
+		InputSchemaJson: string(schemaJSON),
+		Permissions:     info.Permissions,
+		Enabled:         info.Enabled,
+		Available:       info.Available,
+	}, nil
+}
+
+// Helper function to convert an agent profile to protobuf
+func convertAgentProfileToPb(profile *profiles.Profile) *pb.AgentProfile {
+	pbProfile := &pb.AgentProfile{
+		Id:              int32(profile.ID),
+		Name:            profile.Name,
+		Description:     profile.Description,
+		SystemPrompt:    profile.SystemPrompt,
+		Provider:        profile.Provider,
+		Model:           profile.Model,
+		Temperature:     profile.Temperature,
+		MaxTokens:       int32(profile.MaxTokens),
+		AllowedTools:    profile.AllowedTools,
+		CreatedAt:       profile.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:       profile.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		MemoryStrategy:  profile.MemoryStrategy,
+		MemoryMaxTokens: int32(profile.MemoryMaxTokens),
+		RoutingRules:    profile.RoutingRules,
+	}
+	if profile.MaxIterations != nil {
+		maxIterations := int32(*profile.MaxIterations)
+		pbProfile.MaxIterations = &maxIterations
+	}
+	return pbProfile
+}
@@ -0,0 +1,161 @@
+package grpc_server
+
+import (
+	"context"
+	"fmt"
+
+	"agentic-template/api/agent/conversation"
+	"agentic-template/api/agent/runs"
+	"agentic-template/api/db"
+	pb "agentic-template/api/pb/v1"
+)
+
+// AgentRunServiceServer implements the AgentRunService gRPC service
+type AgentRunServiceServer struct {
+	pb.UnimplementedAgentRunServiceServer
+	dbManager *db.Manager
+}
+
+// NewAgentRunServiceServer creates a new agent run service server
+func NewAgentRunServiceServer(dbManager *db.Manager) *AgentRunServiceServer {
+	return &AgentRunServiceServer{
+		dbManager: dbManager,
+	}
+}
+
+// getStore returns a run store with the current database pool
+func (s *AgentRunServiceServer) getStore() *runs.Store {
+	return runs.NewStore(s.dbManager.GetPool())
+}
+
+// GetAgentRun retrieves a recorded agent run with its trace
+func (s *AgentRunServiceServer) GetAgentRun(ctx context.Context, req *pb.GetAgentRunRequest) (*pb.GetAgentRunResponse, error) {
+	run, err := s.getStore().Get(ctx, int(req.RunId))
+	if err != nil {
+		return &pb.GetAgentRunResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to get agent run: %v", err),
+		}, nil
+	}
+
+	return &pb.GetAgentRunResponse{
+		Success: true,
+		Message: "Agent run retrieved successfully",
+		Run:     convertAgentRunToPb(run),
+	}, nil
+}
+
+// ListAgentRuns returns recorded agent runs, newest first
+func (s *AgentRunServiceServer) ListAgentRuns(ctx context.Context, req *pb.ListAgentRunsRequest) (*pb.ListAgentRunsResponse, error) {
+	opts := runs.ListOptions{
+		ConversationID: req.GetConversationId(),
+		Status:         req.GetStatus(),
+		Limit:          int(req.Limit),
+		Offset:         int(req.Offset),
+	}
+	if req.ProfileId != nil {
+		profileID := int(*req.ProfileId)
+		opts.ProfileID = &profileID
+	}
+
+	list, err := s.getStore().List(ctx, opts)
+	if err != nil {
+		return &pb.ListAgentRunsResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to list agent runs: %v", err),
+		}, nil
+	}
+
+	pbRuns := make([]*pb.AgentRun, 0, len(list))
+	for i := range list {
+		pbRuns = append(pbRuns, convertAgentRunToPb(&list[i]))
+	}
+
+	return &pb.ListAgentRunsResponse{
+		Success: true,
+		Message: fmt.Sprintf("Found %d run(s)", len(list)),
+		Runs:    pbRuns,
+	}, nil
+}
+
+// ListSessions returns conversations with their generated titles and
+// recaps, most recently active first
+func (s *AgentRunServiceServer) ListSessions(ctx context.Context, req *pb.ListSessionsRequest) (*pb.ListSessionsResponse, error) {
+	sessions, err := conversation.NewStore(s.dbManager.GetPool()).ListSessions(ctx, conversation.SessionListOptions{
+		Limit:  int(req.Limit),
+		Offset: int(req.Offset),
+	})
+	if err != nil {
+		return &pb.ListSessionsResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to list sessions: %v", err),
+		}, nil
+	}
+
+	pbSessions := make([]*pb.Session, 0, len(sessions))
+	for _, session := range sessions {
+		pbSessions = append(pbSessions, &pb.Session{
+			ConversationId: session.ConversationID,
+			Title:          session.Title,
+			Recap:          session.Recap,
+			Turns:          int32(session.Turns),
+			CreatedAt:      session.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+			UpdatedAt:      session.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		})
+	}
+
+	return &pb.ListSessionsResponse{
+		Success:  true,
+		Message:  fmt.Sprintf("Found %d session(s)", len(sessions)),
+		Sessions: pbSessions,
+	}, nil
+}
+
+// Helper function to convert an agent run to protobuf
+func convertAgentRunToPb(run *runs.Run) *pb.AgentRun {
+	pbRun := &pb.AgentRun{
+		Id:               int32(run.ID),
+		ConversationId:   run.ConversationID,
+		Input:            run.Input,
+		Metadata:         run.Metadata,
+		Output:           run.Output,
+		Status:           run.Status,
+		ErrorMessage:     run.ErrorMessage,
+		PromptTokens:     int32(run.PromptTokens),
+		CompletionTokens: int32(run.CompletionTokens),
+		TotalTokens:      int32(run.TotalTokens),
+		DurationMs:       run.DurationMs,
+		CreatedAt:        run.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		CreatedBy:        run.CreatedBy,
+	}
+
+	if run.ProfileID != nil {
+		profileID := int32(*run.ProfileID)
+		pbRun.ProfileId = &profileID
+	}
+
+	for _, id := range run.DelegateProfileIDs {
+		pbRun.DelegateProfileIds = append(pbRun.DelegateProfileIds, int32(id))
+	}
+
+	for _, event := range run.Events {
+		pbEvent := &pb.AgentRunEvent{
+			Type:      event.Type,
+			AgentName: event.AgentName,
+			Content:   event.Content,
+			Timestamp: event.Timestamp.Format("2006-01-02T15:04:05Z07:00"),
+		}
+		if event.Tool != "" {
+			tool := event.Tool
+			pbEvent.Tool = &tool
+		}
+		pbRun.Events = append(pbRun.Events, pbEvent)
+	}
+
+	if run.CompletedAt != nil {
+		completedAt := run.CompletedAt.Format("2006-01-02T15:04:05Z07:00")
+		pbRun.CompletedAt = &completedAt
+	}
+
+	return pbRun
+}
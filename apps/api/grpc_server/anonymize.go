@@ -0,0 +1,106 @@
+package grpc_server
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	pb "agentic-template/api/pb/v1"
+	"agentic-template/api/queue"
+	"agentic-template/api/schema_manager"
+)
+
+// AnonymizeTable reports what an anonymization would rewrite, or queues it
+func (s *SchemaServiceServer) AnonymizeTable(ctx context.Context, req *pb.AnonymizeTableRequest) (*pb.AnonymizeTableResponse, error) {
+	columns := make([]schema_manager.AnonymizeColumn, 0, len(req.Columns))
+	for _, col := range req.Columns {
+		columns = append(columns, schema_manager.AnonymizeColumn{
+			ColumnName: col.ColumnName,
+			Strategy:   schema_manager.AnonymizeStrategy(col.Strategy),
+			JitterDays: int(col.JitterDays),
+		})
+	}
+	anonymizeReq := schema_manager.AnonymizeRequest{
+		TableID: int(req.TableId),
+		Columns: columns,
+		DryRun:  req.DryRun,
+	}
+	resourceName := fmt.Sprint(req.TableId)
+
+	sm := s.getSchemaManager()
+	if req.DryRun {
+		report, err := sm.AnonymizeTable(ctx, anonymizeReq)
+		if err != nil {
+			return nil, schemaStatus(err, "anonymize table", resourceName)
+		}
+		return &pb.AnonymizeTableResponse{
+			Success: true,
+			Message: fmt.Sprintf("Dry run: %d record(s) would be anonymized", report.RecordCount),
+			Report:  convertAnonymizeReportToPb(report),
+		}, nil
+	}
+
+	job, err := sm.EnqueueAnonymizeTable(ctx, s.jobQueue, anonymizeReq)
+	if err != nil {
+		return nil, schemaStatus(err, "anonymize table", resourceName)
+	}
+	return &pb.AnonymizeTableResponse{
+		Success: true,
+		Message: fmt.Sprintf("Anonymization queued as job %d", job.ID),
+		Job:     convertBackgroundJobToPb(job),
+	}, nil
+}
+
+// GetAnonymizationJob returns a queued anonymization
+func (s *SchemaServiceServer) GetAnonymizationJob(ctx context.Context, req *pb.GetAnonymizationJobRequest) (*pb.AnonymizationJobResponse, error) {
+	job, err := schema_manager.GetAnonymizeJob(ctx, s.jobQueue, req.JobId)
+	if err != nil {
+		return nil, schemaStatus(err, "get anonymization job", fmt.Sprint(req.JobId))
+	}
+	return &pb.AnonymizationJobResponse{
+		Success: true,
+		Message: "Job retrieved successfully",
+		Job:     convertBackgroundJobToPb(job),
+	}, nil
+}
+
+// convertAnonymizeReportToPb converts an anonymization report
+func convertAnonymizeReportToPb(report *schema_manager.AnonymizeReport) *pb.AnonymizeTableReport {
+	pbReport := &pb.AnonymizeTableReport{
+		TableId:     int32(report.TableID),
+		Name:        report.Name,
+		RecordCount: report.RecordCount,
+	}
+	for _, col := range report.Columns {
+		pbCol := &pb.AnonymizeColumnReport{
+			ColumnName:   col.ColumnName,
+			Strategy:     string(col.Strategy),
+			AffectedRows: col.AffectedRows,
+		}
+		for _, sample := range col.Samples {
+			pbCol.Samples = append(pbCol.Samples, &pb.AnonymizeSample{RecordId: sample.RecordID, Value: sample.Value})
+		}
+		pbReport.Columns = append(pbReport.Columns, pbCol)
+	}
+	return pbReport
+}
+
+// convertBackgroundJobToPb converts a background job, leaving out its
+// payload
+func convertBackgroundJobToPb(job *queue.Job) *pb.BackgroundJob {
+	pbJob := &pb.BackgroundJob{
+		Id:          job.ID,
+		Kind:        job.Kind,
+		Status:      job.Status,
+		Attempts:    int32(job.Attempts),
+		MaxAttempts: int32(job.MaxAttempts),
+		LastError:   job.LastError,
+		CreatedBy:   job.CreatedBy,
+		CreatedAt:   job.CreatedAt.Format(time.RFC3339),
+	}
+	if job.CompletedAt != nil {
+		completedAt := job.CompletedAt.Format(time.RFC3339)
+		pbJob.CompletedAt = &completedAt
+	}
+	return pbJob
+}
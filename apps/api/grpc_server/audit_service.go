@@ -0,0 +1,107 @@
+package grpc_server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"agentic-template/api/audit"
+	"agentic-template/api/db"
+	pb "agentic-template/api/pb/v1"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// AuditServiceServer implements the AuditService gRPC service
+type AuditServiceServer struct {
+	pb.UnimplementedAuditServiceServer
+	dbManager *db.Manager
+}
+
+// NewAuditServiceServer creates a new audit service server
+func NewAuditServiceServer(dbManager *db.Manager) *AuditServiceServer {
+	return &AuditServiceServer{
+		dbManager: dbManager,
+	}
+}
+
+// getStore returns an audit store with the current database pool
+func (s *AuditServiceServer) getStore() *audit.Store {
+	return audit.NewStore(s.dbManager.GetPool())
+}
+
+// ListAuditEntries returns a page of audited API calls, newest first
+func (s *AuditServiceServer) ListAuditEntries(ctx context.Context, req *pb.ListAuditEntriesRequest) (*pb.ListAuditEntriesResponse, error) {
+	filter := audit.Filter{
+		Actor:     req.GetActor(),
+		Method:    req.GetMethod(),
+		PageSize:  int(req.PageSize),
+		PageToken: req.PageToken,
+	}
+	var err error
+	if filter.Since, err = parseTimeFilter("since", req.GetSince()); err != nil {
+		return nil, err
+	}
+	if filter.Until, err = parseTimeFilter("until", req.GetUntil()); err != nil {
+		return nil, err
+	}
+
+	page, err := s.getStore().List(ctx, filter)
+	switch {
+	case errors.Is(err, audit.ErrInvalidPageToken):
+		return nil, invalidArgument("page_token", err.Error())
+	case errors.Is(err, audit.ErrDatabaseNotConfigured):
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	case err != nil:
+		return nil, status.Errorf(codes.Internal, "Failed to list audit entries: %v", err)
+	}
+
+	entries := make([]*pb.AuditEntry, 0, len(page.Entries))
+	for _, entry := range page.Entries {
+		entries = append(entries, &pb.AuditEntry{
+			Id:             entry.ID,
+			OccurredAt:     entry.OccurredAt.Format(time.RFC3339Nano),
+			Actor:          entry.Actor,
+			Transport:      entry.Transport,
+			Method:         entry.Method,
+			Resource:       entry.Resource,
+			RequestSummary: entry.RequestSummary,
+			Status:         entry.Status,
+			LatencyMs:      entry.LatencyMS,
+			RequestId:      entry.RequestID,
+			ClientIp:       entry.ClientIP,
+		})
+	}
+
+	return &pb.ListAuditEntriesResponse{
+		Success:       true,
+		Message:       fmt.Sprintf("Found %d audit entries", len(entries)),
+		Entries:       entries,
+		NextPageToken: page.NextPageToken,
+	}, nil
+}
+
+// parseTimeFilter parses an optional RFC 3339 time filter
+func parseTimeFilter(field, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, invalidArgument(field, "must be an RFC 3339 time")
+	}
+	return t, nil
+}
+
+// invalidArgument creates an InvalidArgument error for a request field
+func invalidArgument(field, description string) error {
+	return withDetails(codes.InvalidArgument, fmt.Sprintf("%s %s", field, description), &errdetails.BadRequest{
+		FieldViolations: []*errdetails.BadRequest_FieldViolation{{
+			Field:       field,
+			Description: description,
+		}},
+	})
+}
@@ -0,0 +1,96 @@
+package grpc_server
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	pb "agentic-template/api/pb/v1"
+	"agentic-template/api/schema_manager"
+)
+
+// ListSchemaChangeRequests returns schema change requests, newest first
+func (s *SchemaServiceServer) ListSchemaChangeRequests(ctx context.Context, req *pb.ListSchemaChangeRequestsRequest) (*pb.ListSchemaChangeRequestsResponse, error) {
+	changes, err := s.getSchemaManager().ListChangeRequests(ctx, schema_manager.ListChangeRequestsOptions{
+		PageSize: int(req.PageSize),
+		BeforeID: req.BeforeId,
+		Status:   req.Status,
+	})
+	if err != nil {
+		return nil, schemaStatus(err, "list schema change requests", "")
+	}
+
+	pbChanges := make([]*pb.SchemaChangeRequest, 0, len(changes))
+	for i := range changes {
+		pbChanges = append(pbChanges, convertChangeRequestToPb(&changes[i]))
+	}
+	return &pb.ListSchemaChangeRequestsResponse{
+		Success:        true,
+		Message:        fmt.Sprintf("Found %d change request(s)", len(pbChanges)),
+		ChangeRequests: pbChanges,
+	}, nil
+}
+
+// GetSchemaChangeRequest returns a schema change request
+func (s *SchemaServiceServer) GetSchemaChangeRequest(ctx context.Context, req *pb.GetSchemaChangeRequestRequest) (*pb.SchemaChangeRequestResponse, error) {
+	change, err := s.getSchemaManager().GetChangeRequest(ctx, req.Id)
+	if err != nil {
+		return nil, schemaStatus(err, "get schema change request", fmt.Sprint(req.Id))
+	}
+	return &pb.SchemaChangeRequestResponse{
+		Success:       true,
+		Message:       "Change request retrieved successfully",
+		ChangeRequest: convertChangeRequestToPb(change),
+	}, nil
+}
+
+// ApproveSchemaChangeRequest approves a pending schema change request and
+// applies it
+func (s *SchemaServiceServer) ApproveSchemaChangeRequest(ctx context.Context, req *pb.ReviewSchemaChangeRequestRequest) (*pb.SchemaChangeRequestResponse, error) {
+	change, err := s.getSchemaManager().ApproveChangeRequest(ctx, req.Id, req.Comment)
+	if err != nil {
+		return nil, schemaStatus(err, "approve schema change request", fmt.Sprint(req.Id))
+	}
+	return &pb.SchemaChangeRequestResponse{
+		Success:       true,
+		Message:       fmt.Sprintf("Change request %d approved and applied", change.ID),
+		ChangeRequest: convertChangeRequestToPb(change),
+	}, nil
+}
+
+// RejectSchemaChangeRequest rejects a pending schema change request
+func (s *SchemaServiceServer) RejectSchemaChangeRequest(ctx context.Context, req *pb.ReviewSchemaChangeRequestRequest) (*pb.SchemaChangeRequestResponse, error) {
+	change, err := s.getSchemaManager().RejectChangeRequest(ctx, req.Id, req.Comment)
+	if err != nil {
+		return nil, schemaStatus(err, "reject schema change request", fmt.Sprint(req.Id))
+	}
+	return &pb.SchemaChangeRequestResponse{
+		Success:       true,
+		Message:       fmt.Sprintf("Change request %d rejected", change.ID),
+		ChangeRequest: convertChangeRequestToPb(change),
+	}, nil
+}
+
+// convertChangeRequestToPb converts a schema change request
+func convertChangeRequestToPb(change *schema_manager.ChangeRequest) *pb.SchemaChangeRequest {
+	pbChange := &pb.SchemaChangeRequest{
+		Id:            change.ID,
+		ChangeType:    change.ChangeType,
+		ChangeDetails: change.ChangeDetails,
+		Status:        change.Status,
+		RequestedBy:   change.RequestedBy,
+		ReviewedBy:    change.ReviewedBy,
+		ReviewComment: change.ReviewComment,
+		ErrorMessage:  change.ErrorMessage,
+		CreatedAt:     change.CreatedAt.Format(time.RFC3339),
+	}
+	if change.TableID != nil {
+		tableID := int32(*change.TableID)
+		pbChange.TableId = &tableID
+	}
+	if change.ReviewedAt != nil {
+		reviewedAt := change.ReviewedAt.Format(time.RFC3339)
+		pbChange.ReviewedAt = &reviewedAt
+	}
+	return pbChange
+}
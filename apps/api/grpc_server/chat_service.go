@@ -0,0 +1,232 @@
+package grpc_server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"agentic-template/api/agent"
+	"agentic-template/api/agent/runs"
+	"agentic-template/api/logging"
+	pb "agentic-template/api/pb/v1"
+
+	"github.com/google/uuid"
+	"github.com/tmc/langchaingo/memory"
+	"github.com/tmc/langchaingo/schema"
+	"google.golang.org/grpc/status"
+)
+
+// maxQueuedTurns is how many user turns a Chat stream buffers while a turn
+// is running. Further turns wait until the queue drains.
+const maxQueuedTurns = 8
+
+// chatSession is the state shared by the turns of a Chat stream
+type chatSession struct {
+	history   schema.ChatMessageHistory // Buffer memory of the conversation
+	approvals *toolApprovals
+}
+
+// Chat implements the bidirectional chat RPC. User turns run one at a time
+// on the same conversation; tool approvals are answered while a turn runs.
+func (s *AgentServiceServer) Chat(stream pb.AgentService_ChatServer) error {
+	ctx := stream.Context()
+	session := &chatSession{
+		history:   memory.NewChatMessageHistory(),
+		approvals: newToolApprovals(),
+	}
+
+	// Receive in the background so approvals arrive while a turn runs
+	turns := make(chan *pb.ChatRequest, maxQueuedTurns)
+	recvErr := make(chan error, 1)
+	go func() {
+		for {
+			msg, err := stream.Recv()
+			if err != nil {
+				recvErr <- err
+				return
+			}
+
+			if approval := msg.GetToolApproval(); approval != nil {
+				if !session.approvals.resolve(approval) {
+					logging.FromContext(ctx).Warn("ignoring approval of unknown tool call", "approval_id", approval.ApprovalId)
+				}
+				continue
+			}
+			if msg.GetMessage() == nil {
+				continue
+			}
+
+			select {
+			case turns <- msg:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	// runTurn runs one user turn on the chat's conversation
+	var conversationID string
+	runTurn := func(msg *pb.ChatRequest) error {
+		req := msg.GetMessage()
+		if req.ConversationId == "" {
+			if conversationID == "" {
+				conversationID = uuid.NewString()
+			}
+			req.ConversationId = conversationID
+		}
+		conversationID = req.ConversationId
+
+		session.approvals.require(msg.ApprovalRequiredTools)
+		err := s.runAgent(ctx, req, stream, session)
+		if err == nil {
+			return nil
+		}
+
+		// A rejected turn ends with an error event; the stream stays open
+		// for the next turn unless it has failed
+		if _, ok := status.FromError(err); !ok || ctx.Err() != nil {
+			return err
+		}
+		if err := s.sendError(stream, status.Convert(err).Message()); err != nil {
+			return err
+		}
+		return s.sendDone(stream)
+	}
+
+	for {
+		select {
+		case msg := <-turns:
+			if err := runTurn(msg); err != nil {
+				return err
+			}
+
+		case err := <-recvErr:
+			if !errors.Is(err, io.EOF) {
+				return err
+			}
+			// The client is done sending; finish the queued turns
+			for len(turns) > 0 {
+				if err := runTurn(<-turns); err != nil {
+					return err
+				}
+			}
+			return nil
+
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// toolApprovals tracks the tool calls of a chat waiting for the client's
+// approval
+type toolApprovals struct {
+	mu       sync.Mutex
+	required map[string]bool                  // Tools that need approval this turn
+	pending  map[string]chan *pb.ToolApproval // Approval ID -> waiting tool call
+}
+
+// newToolApprovals creates an empty approval tracker
+func newToolApprovals() *toolApprovals {
+	return &toolApprovals{
+		required: map[string]bool{},
+		pending:  map[string]chan *pb.ToolApproval{},
+	}
+}
+
+// require sets the tools that need approval for the next turn
+func (a *toolApprovals) require(toolNames []string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.required = make(map[string]bool, len(toolNames))
+	for _, name := range toolNames {
+		a.required[name] = true
+	}
+}
+
+// needsApproval reports whether calls to a tool wait for approval
+func (a *toolApprovals) needsApproval(toolName string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.required[toolName]
+}
+
+// resolve delivers the client's answer to a waiting tool call. It returns
+// false when no call is waiting on the approval ID.
+func (a *toolApprovals) resolve(approval *pb.ToolApproval) bool {
+	a.mu.Lock()
+	waiting, ok := a.pending[approval.ApprovalId]
+	delete(a.pending, approval.ApprovalId)
+	a.mu.Unlock()
+
+	if ok {
+		waiting <- approval
+	}
+	return ok
+}
+
+// approver returns a tool approver that sends an approval request through
+// request and waits for the client's answer, recording the decision
+func (a *toolApprovals) approver(
+	recorder *runs.Recorder,
+	request func(agentName string, req *pb.ToolApprovalRequest) error,
+) agent.ToolApprover {
+	return func(ctx context.Context, agentName, toolName, input string) (bool, string, error) {
+		if !a.needsApproval(toolName) {
+			return true, "", nil
+		}
+		return a.ask(ctx, recorder, request, agentName, toolName, input)
+	}
+}
+
+// ask sends an approval request for a tool call through request and waits
+// for the client's answer, recording the decision
+func (a *toolApprovals) ask(
+	ctx context.Context,
+	recorder *runs.Recorder,
+	request func(agentName string, req *pb.ToolApprovalRequest) error,
+	agentName, toolName, input string,
+) (bool, string, error) {
+	approvalID := uuid.NewString()
+	waiting := make(chan *pb.ToolApproval, 1)
+	a.mu.Lock()
+	a.pending[approvalID] = waiting
+	a.mu.Unlock()
+	defer func() {
+		a.mu.Lock()
+		delete(a.pending, approvalID)
+		a.mu.Unlock()
+	}()
+
+	err := request(agentName, &pb.ToolApprovalRequest{
+		ApprovalId: approvalID,
+		ToolName:   toolName,
+		ToolInput:  input,
+	})
+	if err != nil {
+		return false, "", err
+	}
+
+	select {
+	case approval := <-waiting:
+		reason := approval.GetReason()
+		decision := "approved"
+		if !approval.Approved {
+			decision = "rejected"
+		}
+		if reason != "" {
+			decision = fmt.Sprintf("%s: %s", decision, reason)
+		}
+		recorder.Record(runs.Event{
+			Type:      runs.EventApproval,
+			AgentName: agentName,
+			Tool:      toolName,
+			Content:   decision,
+		})
+		return approval.Approved, reason, nil
+	case <-ctx.Done():
+		return false, "", ctx.Err()
+	}
+}
@@ -0,0 +1,165 @@
+package grpc_server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/encoding/gzip"
+)
+
+// Compressors decompress requests compressed by the client. When a client
+// advertises support (grpc-accept-encoding) but sends uncompressed requests,
+// responses are compressed with the first configured compressor it accepts,
+// which mostly pays off on large streamed payloads.
+type Compressors struct {
+	preferred []string // Compressor names in order of preference
+}
+
+// NewCompressors registers the named compressors ("gzip" or "zstd") in
+// order of preference. "none" negotiates no response compression; gzip
+// requests are still accepted, as the gzip codec is always registered.
+func NewCompressors(names []string) (*Compressors, error) {
+	c := &Compressors{}
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch name {
+		case "", "none":
+			continue
+		case gzip.Name:
+			// Registered by importing the gzip package
+		case zstdName:
+			encoding.RegisterCompressor(newZstdCompressor())
+		default:
+			return nil, fmt.Errorf("unknown gRPC compressor %q (want gzip, zstd, or none)", name)
+		}
+		c.preferred = append(c.preferred, name)
+	}
+	return c, nil
+}
+
+// Names returns the enabled compressors in order of preference
+func (c *Compressors) Names() []string {
+	return c.preferred
+}
+
+// negotiate picks the response compressor of a call from the compressors
+// the client accepts. A call whose request was compressed keeps the
+// client's compressor.
+func (c *Compressors) negotiate(ctx context.Context) {
+	if len(c.preferred) == 0 {
+		return
+	}
+	accepted, err := grpc.ClientSupportedCompressors(ctx)
+	if err != nil {
+		return
+	}
+	for _, name := range c.preferred {
+		for _, candidate := range accepted {
+			if strings.EqualFold(candidate, name) {
+				_ = grpc.SetSendCompressor(ctx, name)
+				return
+			}
+		}
+	}
+}
+
+// UnaryServerInterceptor negotiates the response compressor of unary calls
+func (c *Compressors) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		c.negotiate(ctx)
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor negotiates the response compressor of streams
+func (c *Compressors) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		c.negotiate(stream.Context())
+		return handler(srv, stream)
+	}
+}
+
+// zstdName is the grpc-encoding name of zstd
+const zstdName = "zstd"
+
+// zstdCompressor implements encoding.Compressor with pooled single-threaded
+// zstd encoders and decoders
+type zstdCompressor struct {
+	encoders sync.Pool
+	decoders sync.Pool
+}
+
+// newZstdCompressor creates a zstd compressor with empty pools
+func newZstdCompressor() *zstdCompressor {
+	c := &zstdCompressor{}
+	c.encoders.New = func() any {
+		encoder, _ := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+		return encoder
+	}
+	c.decoders.New = func() any {
+		decoder, _ := zstd.NewReader(nil, zstd.WithDecoderConcurrency(1))
+		return decoder
+	}
+	return c
+}
+
+// Name returns the grpc-encoding name of the compressor
+func (c *zstdCompressor) Name() string {
+	return zstdName
+}
+
+// Compress returns a writer compressing into w
+func (c *zstdCompressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	encoder := c.encoders.Get().(*zstd.Encoder)
+	encoder.Reset(w)
+	return &zstdWriter{Encoder: encoder, pool: &c.encoders}, nil
+}
+
+// Decompress returns a reader decompressing r
+func (c *zstdCompressor) Decompress(r io.Reader) (io.Reader, error) {
+	decoder := c.decoders.Get().(*zstd.Decoder)
+	if err := decoder.Reset(r); err != nil {
+		c.decoders.Put(decoder)
+		return nil, err
+	}
+	return &zstdReader{decoder: decoder, pool: &c.decoders}, nil
+}
+
+// zstdWriter returns its encoder to the pool when closed
+type zstdWriter struct {
+	*zstd.Encoder
+	pool *sync.Pool
+}
+
+// Close flushes the compressed stream and releases the encoder
+func (w *zstdWriter) Close() error {
+	err := w.Encoder.Close()
+	w.pool.Put(w.Encoder)
+	return err
+}
+
+// zstdReader returns its decoder to the pool once fully read. It only
+// exposes Read so callers can't bypass the release through WriteTo.
+type zstdReader struct {
+	decoder *zstd.Decoder
+	pool    *sync.Pool
+}
+
+// Read decompresses into p, releasing the decoder at the end of the stream
+func (r *zstdReader) Read(p []byte) (int, error) {
+	if r.decoder == nil {
+		return 0, io.EOF
+	}
+	n, err := r.decoder.Read(p)
+	if err == io.EOF {
+		r.pool.Put(r.decoder)
+		r.decoder = nil
+	}
+	return n, err
+}
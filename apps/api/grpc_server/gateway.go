@@ -0,0 +1,135 @@
+package grpc_server
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"agentic-template/api/config"
+	"agentic-template/api/logging"
+	"agentic-template/api/ratelimit"
+	"agentic-template/api/requestctx"
+	"agentic-template/api/tenancy"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// gatewayHeaders are the HTTP headers forwarded to the gRPC server as
+// metadata under their own names, so REST calls share the gRPC auth,
+// request ID, locale, and environment handling
+var gatewayHeaders = map[string]bool{
+	"authorization":           true,
+	"x-api-key":               true,
+	logging.RequestIDHeader:   true,
+	requestctx.LocaleHeader:   true,
+	tenancy.EnvironmentHeader: true,
+}
+
+// gatewayHeaderMatcher forwards credentials, the request ID, the locale,
+// and the environment as-is and everything else the gateway's default way
+func gatewayHeaderMatcher(key string) (string, bool) {
+	if lower := strings.ToLower(key); gatewayHeaders[lower] {
+		return lower, true
+	}
+	return runtime.DefaultHeaderMatcher(key)
+}
+
+// gatewayOutgoingHeaderMatcher returns Retry-After to REST callers as a
+// standard header and other response metadata under the gateway's
+// Grpc-Metadata- prefix
+func gatewayOutgoingHeaderMatcher(key string) (string, bool) {
+	if key == ratelimit.RetryAfterHeader {
+		return "Retry-After", true
+	}
+	return runtime.MetadataHeaderPrefix + key, true
+}
+
+// NewGateway creates a grpc-gateway mux that serves every gRPC service as
+// JSON/REST under its version's prefix (see packages/proto/v1/service_http.yaml). It calls the
+// gRPC server over loopback, so REST requests pass through the same
+// interceptors as gRPC calls. The returned function closes the connection.
+func NewGateway(ctx context.Context, cfg *config.Config) (http.Handler, func() error, error) {
+	// A listen address like ":50051" is dialed on localhost
+	host, port, err := net.SplitHostPort(cfg.GRPCPort)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid gRPC address %q: %w", cfg.GRPCPort, err)
+	}
+	if host == "" {
+		host = "localhost"
+	}
+
+	creds, err := loopbackCredentials(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	conn, err := grpc.NewClient(net.JoinHostPort(host, port),
+		grpc.WithTransportCredentials(creds),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+		grpc.WithDefaultCallOptions(clientCallOptions(cfg)...),
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect gateway to gRPC server: %w", err)
+	}
+
+	mux := runtime.NewServeMux(
+		runtime.WithIncomingHeaderMatcher(gatewayHeaderMatcher),
+		runtime.WithOutgoingHeaderMatcher(gatewayOutgoingHeaderMatcher),
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.JSONPb{
+			MarshalOptions: protojson.MarshalOptions{
+				UseProtoNames:   true,
+				EmitUnpopulated: true,
+			},
+			UnmarshalOptions: protojson.UnmarshalOptions{
+				DiscardUnknown: true,
+			},
+		}),
+	)
+
+	// Each version's routes live under its own prefix (/v1, /v2, ...)
+	for _, version := range apiVersions {
+		for _, register := range version.gateway {
+			if err := register(ctx, mux, conn); err != nil {
+				conn.Close()
+				return nil, nil, fmt.Errorf("failed to register %s gateway handler: %w", version.name, err)
+			}
+		}
+	}
+
+	return mux, conn.Close, nil
+}
+
+// loopbackCredentials secures the gateway's connection to its own gRPC
+// server. With TLS configured, the server must present the configured
+// certificate itself, whatever names it covers.
+func loopbackCredentials(cfg *config.Config) (credentials.TransportCredentials, error) {
+	if cfg.TLSCertFile == "" {
+		return insecure.NewCredentials(), nil
+	}
+	cert, err := tls.LoadX509KeyPair(cfg.TLSCertFile, cfg.TLSKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+	leaf := cert.Certificate[0]
+	return credentials.NewTLS(&tls.Config{
+		MinVersion: tls.VersionTLS12,
+		// The certificate is pinned below instead of verified by name
+		InsecureSkipVerify: true,
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			if len(rawCerts) == 0 || !bytes.Equal(rawCerts[0], leaf) {
+				return errors.New("gRPC server presented an unexpected certificate")
+			}
+			return nil
+		},
+	}), nil
+}
@@ -0,0 +1,152 @@
+package grpc_server
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	pb "agentic-template/api/pb/v1"
+	"agentic-template/api/schema_manager"
+)
+
+// ListImportSources returns the configured import sources, without their
+// URLs
+func (s *SchemaServiceServer) ListImportSources(ctx context.Context, req *pb.ListImportSourcesRequest) (*pb.ListImportSourcesResponse, error) {
+	sources := schema_manager.ListImportSources()
+	pbSources := make([]*pb.ImportSource, 0, len(sources))
+	for _, source := range sources {
+		pbSources = append(pbSources, &pb.ImportSource{Name: source.Name, Kind: source.Kind})
+	}
+	return &pb.ListImportSourcesResponse{
+		Success: true,
+		Message: fmt.Sprintf("Found %d import source(s)", len(pbSources)),
+		Sources: pbSources,
+	}, nil
+}
+
+// PreviewImport lists the tables of an import source with the columns
+// importing them creates
+func (s *SchemaServiceServer) PreviewImport(ctx context.Context, req *pb.PreviewImportRequest) (*pb.PreviewImportResponse, error) {
+	previews, err := schema_manager.PreviewImport(ctx, req.Source)
+	if err != nil {
+		return nil, schemaStatus(err, "preview import", req.Source)
+	}
+
+	tables := make([]*pb.ImportTablePreview, 0, len(previews))
+	for _, preview := range previews {
+		pbTable := &pb.ImportTablePreview{SourceTable: preview.SourceTable, RowEstimate: preview.RowEstimate}
+		for _, col := range preview.Columns {
+			pbTable.Columns = append(pbTable.Columns, &pb.ImportColumn{
+				SourceColumn: col.SourceColumn,
+				NativeType:   col.NativeType,
+				Column: &pb.ColumnDefinition{
+					Name:       col.Column.Name,
+					DataType:   string(col.Column.DataType),
+					IsNullable: col.Column.IsNullable,
+				},
+			})
+		}
+		tables = append(tables, pbTable)
+	}
+	return &pb.PreviewImportResponse{
+		Success: true,
+		Message: fmt.Sprintf("Found %d table(s) in %s", len(tables), req.Source),
+		Tables:  tables,
+	}, nil
+}
+
+// StartImport creates a table shaped like a source table and queues the
+// copy of its rows
+func (s *SchemaServiceServer) StartImport(ctx context.Context, req *pb.StartImportRequest) (*pb.DataImportResponse, error) {
+	imp, err := s.getSchemaManager().StartImport(ctx, s.jobQueue, schema_manager.ImportRequest{
+		Source:       req.Source,
+		SourceTable:  req.SourceTable,
+		Name:         req.Name,
+		Description:  req.Description,
+		SyncInterval: time.Duration(req.SyncIntervalMinutes) * time.Minute,
+	})
+	if err != nil {
+		return nil, schemaStatus(err, "start import", req.Name)
+	}
+	return &pb.DataImportResponse{
+		Success:    true,
+		Message:    fmt.Sprintf("Import of %s queued", imp.SourceTable),
+		DataImport: convertDataImportToPb(imp),
+	}, nil
+}
+
+// StopImportSync stops an import from syncing
+func (s *SchemaServiceServer) StopImportSync(ctx context.Context, req *pb.StopImportSyncRequest) (*pb.DataImportResponse, error) {
+	imp, err := s.getSchemaManager().StopImportSync(ctx, req.Id)
+	if err != nil {
+		return nil, schemaStatus(err, "stop import sync", fmt.Sprint(req.Id))
+	}
+	return &pb.DataImportResponse{
+		Success:    true,
+		Message:    fmt.Sprintf("Import %d no longer syncs", imp.ID),
+		DataImport: convertDataImportToPb(imp),
+	}, nil
+}
+
+// GetImport returns a data import with its progress
+func (s *SchemaServiceServer) GetImport(ctx context.Context, req *pb.GetImportRequest) (*pb.DataImportResponse, error) {
+	imp, err := s.getSchemaManager().GetImport(ctx, req.Id)
+	if err != nil {
+		return nil, schemaStatus(err, "get import", fmt.Sprint(req.Id))
+	}
+	return &pb.DataImportResponse{
+		Success:    true,
+		Message:    "Import retrieved successfully",
+		DataImport: convertDataImportToPb(imp),
+	}, nil
+}
+
+// ListImports returns data imports, newest first
+func (s *SchemaServiceServer) ListImports(ctx context.Context, req *pb.ListImportsRequest) (*pb.ListImportsResponse, error) {
+	imports, err := s.getSchemaManager().ListImports(ctx, schema_manager.ListImportsOptions{
+		PageSize: int(req.PageSize),
+		BeforeID: req.BeforeId,
+		Status:   req.Status,
+	})
+	if err != nil {
+		return nil, schemaStatus(err, "list imports", "")
+	}
+
+	pbImports := make([]*pb.DataImport, 0, len(imports))
+	for i := range imports {
+		pbImports = append(pbImports, convertDataImportToPb(&imports[i]))
+	}
+	return &pb.ListImportsResponse{
+		Success: true,
+		Message: fmt.Sprintf("Found %d import(s)", len(pbImports)),
+		Imports: pbImports,
+	}, nil
+}
+
+// convertDataImportToPb converts a data import
+func convertDataImportToPb(imp *schema_manager.DataImport) *pb.DataImport {
+	pbImport := &pb.DataImport{
+		Id:                  imp.ID,
+		Source:              imp.Source,
+		SourceTable:         imp.SourceTable,
+		Status:              imp.Status,
+		JobId:               imp.JobID,
+		RowsTotal:           imp.RowsTotal,
+		RowsCopied:          imp.RowsCopied,
+		RowsFailed:          imp.RowsFailed,
+		ErrorMessage:        imp.ErrorMessage,
+		SyncIntervalMinutes: int32(imp.SyncInterval / time.Minute),
+		CreatedBy:           imp.CreatedBy,
+		CreatedAt:           imp.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:           imp.UpdatedAt.Format(time.RFC3339),
+	}
+	if imp.TableID != nil {
+		tableID := int32(*imp.TableID)
+		pbImport.TableId = &tableID
+	}
+	if imp.CompletedAt != nil {
+		completedAt := imp.CompletedAt.Format(time.RFC3339)
+		pbImport.CompletedAt = &completedAt
+	}
+	return pbImport
+}
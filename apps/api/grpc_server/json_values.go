@@ -0,0 +1,69 @@
+package grpc_server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	pb "agentic-template/api/pb/v1"
+	"agentic-template/api/schema_manager"
+)
+
+// PatchJSONValue sets and removes values at paths inside a record's JSON
+// column
+func (s *SchemaServiceServer) PatchJSONValue(ctx context.Context, req *pb.PatchJSONValueRequest) (*pb.PatchJSONValueResponse, error) {
+	ops := make([]schema_manager.JSONPathOperation, 0, len(req.Operations))
+	for _, op := range req.Operations {
+		operation := schema_manager.JSONPathOperation{
+			Op:   schema_manager.JSONOp(op.Op),
+			Path: op.Path,
+		}
+		if op.Value != "" {
+			operation.Value = json.RawMessage(op.Value)
+		}
+		ops = append(ops, operation)
+	}
+
+	size, err := s.getSchemaManager().PatchJSON(ctx, schema_manager.PatchJSONRequest{
+		TableID:    int(req.TableId),
+		RecordID:   int(req.RecordId),
+		ColumnName: req.ColumnName,
+		Operations: ops,
+	})
+	if err != nil {
+		return nil, schemaStatus(err, "update JSON value", fmt.Sprintf("%d/%d", req.TableId, req.RecordId))
+	}
+
+	return &pb.PatchJSONValueResponse{
+		Success:   true,
+		Message:   fmt.Sprintf("Applied %d operation(s)", len(ops)),
+		SizeBytes: size,
+	}, nil
+}
+
+// StreamJSONValue sends a record's JSON value in chunks
+func (s *SchemaServiceServer) StreamJSONValue(req *pb.StreamJSONValueRequest, stream pb.SchemaService_StreamJSONValueServer) error {
+	var sendErr error
+	err := s.getSchemaManager().StreamJSON(stream.Context(), schema_manager.JSONValueRequest{
+		TableID:    int(req.TableId),
+		RecordID:   int(req.RecordId),
+		ColumnName: req.ColumnName,
+		Path:       req.Path,
+		ChunkSize:  int(req.ChunkSize),
+	}, func(chunk schema_manager.JSONChunk) error {
+		sendErr = stream.Send(&pb.JSONValueChunk{
+			Data:      chunk.Data,
+			Offset:    chunk.Offset,
+			TotalSize: chunk.TotalSize,
+		})
+		return sendErr
+	})
+	if sendErr != nil {
+		// The client went away; its status is already set
+		return sendErr
+	}
+	if err != nil {
+		return schemaStatus(err, "read JSON value", fmt.Sprintf("%d/%d", req.TableId, req.RecordId))
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+package grpc_server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"agentic-template/api/db"
+	"agentic-template/api/embeddings"
+	"agentic-template/api/ingestion"
+	pb "agentic-template/api/pb/v1"
+	"agentic-template/api/schema_manager"
+)
+
+// KnowledgeServiceServer implements the KnowledgeService gRPC service
+type KnowledgeServiceServer struct {
+	pb.UnimplementedKnowledgeServiceServer
+	dbManager        *db.Manager
+	embedder         embeddings.Embedder
+	ingestionService *ingestion.Service
+}
+
+// NewKnowledgeServiceServer creates a new knowledge service server
+// embedder may be nil when no embedding provider is configured
+func NewKnowledgeServiceServer(dbManager *db.Manager, embedder embeddings.Embedder, ingestionService *ingestion.Service) *KnowledgeServiceServer {
+	return &KnowledgeServiceServer{
+		dbManager:        dbManager,
+		embedder:         embedder,
+		ingestionService: ingestionService,
+	}
+}
+
+// IngestDocument submits a document for chunking, embedding, and storage
+func (s *KnowledgeServiceServer) IngestDocument(ctx context.Context, req *pb.IngestDocumentRequest) (*pb.IngestDocumentResponse, error) {
+	ingestReq := ingestion.IngestRequest{
+		Title:      req.Title,
+		SourceType: ingestion.SourceType(req.SourceType),
+		Content:    req.GetContent(),
+		Data:       req.Data,
+		URL:        req.GetUrl(),
+		Metadata:   req.Metadata,
+	}
+
+	job, err := s.ingestionService.Submit(ctx, ingestReq)
+	if err != nil {
+		return &pb.IngestDocumentResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to ingest document: %v", err),
+		}, nil
+	}
+
+	return &pb.IngestDocumentResponse{
+		Success: true,
+		Message: fmt.Sprintf("Ingestion job %d submitted", job.ID),
+		Job:     convertIngestionJobToPb(job),
+	}, nil
+}
+
+// GetIngestionJob returns the status of an ingestion job
+func (s *KnowledgeServiceServer) GetIngestionJob(ctx context.Context, req *pb.GetIngestionJobRequest) (*pb.GetIngestionJobResponse, error) {
+	job, err := s.ingestionService.GetJob(ctx, int(req.JobId))
+	if err != nil {
+		return &pb.GetIngestionJobResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to get ingestion job: %v", err),
+		}, nil
+	}
+
+	return &pb.GetIngestionJobResponse{
+		Success: true,
+		Message: "Ingestion job retrieved successfully",
+		Job:     convertIngestionJobToPb(job),
+	}, nil
+}
+
+// SemanticSearch embeds the query and searches a table's vector column,
+// or the RAG chunk store when no table is given
+func (s *KnowledgeServiceServer) SemanticSearch(ctx context.Context, req *pb.SemanticSearchRequest) (*pb.SemanticSearchResponse, error) {
+	if s.embedder == nil {
+		return &pb.SemanticSearchResponse{
+			Success: false,
+			Message: "Embeddings not configured - please add an embedding provider API key",
+		}, nil
+	}
+
+	if req.Query == "" {
+		return &pb.SemanticSearchResponse{
+			Success: false,
+			Message: "Query cannot be empty",
+		}, nil
+	}
+
+	var results []*pb.SemanticSearchResult
+	var err error
+	if req.TableId != nil {
+		results, err = s.searchTable(ctx, req)
+	} else {
+		results, err = s.searchChunks(ctx, req)
+	}
+
+	if errors.Is(err, schema_manager.ErrColumnAccessDenied) {
+		// Denied columns fail the call so clients get the field violations
+		return nil, schemaStatus(err, "search table", fmt.Sprintf("%d", req.GetTableId()))
+	}
+	if err != nil {
+		return &pb.SemanticSearchResponse{
+			Success: false,
+			Message: fmt.Sprintf("Semantic search failed: %v", err),
+		}, nil
+	}
+
+	return &pb.SemanticSearchResponse{
+		Success: true,
+		Message: fmt.Sprintf("Found %d result(s)", len(results)),
+		Results: results,
+	}, nil
+}
+
+// searchTable runs a nearest-neighbor search over a user table's vector column
+func (s *KnowledgeServiceServer) searchTable(ctx context.Context, req *pb.SemanticSearchRequest) ([]*pb.SemanticSearchResult, error) {
+	if req.GetColumnName() == "" {
+		return nil, fmt.Errorf("column_name is required when table_id is set")
+	}
+
+	vector, err := s.embedder.EmbedQuery(ctx, req.Query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+
+	sm := schema_manager.FromManager(s.dbManager)
+	rows, err := sm.SemanticSearch(ctx, schema_manager.SemanticSearchRequest{
+		TableID:    int(*req.TableId),
+		ColumnName: req.GetColumnName(),
+		Vector:     vector,
+		Limit:      int(req.Limit),
+		Filters:    req.Filters,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*pb.SemanticSearchResult, 0, len(rows))
+	for _, row := range rows {
+		rowJSON, err := json.Marshal(row.Row)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode row: %w", err)
+		}
+		rowStr := string(rowJSON)
+		results = append(results, &pb.SemanticSearchResult{
+			Similarity: row.Similarity,
+			RowJson:    &rowStr,
+		})
+	}
+
+	return results, nil
+}
+
+// searchChunks runs a nearest-neighbor search over the RAG chunk store,
+// limited like table searches rather than by the agents' RAG_TOP_K
+func (s *KnowledgeServiceServer) searchChunks(ctx context.Context, req *pb.SemanticSearchRequest) ([]*pb.SemanticSearchResult, error) {
+	limit := schema_manager.SearchLimit(int(req.Limit))
+	chunks, err := s.ingestionService.Pipeline().Search(ctx, req.Query, limit, req.Filters)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*pb.SemanticSearchResult, 0, len(chunks))
+	for _, chunk := range chunks {
+		docID := int32(chunk.DocumentID)
+		title := chunk.DocumentTitle
+		content := chunk.Content
+		results = append(results, &pb.SemanticSearchResult{
+			Similarity:    chunk.Similarity,
+			DocumentId:    &docID,
+			DocumentTitle: &title,
+			Content:       &content,
+			Metadata:      chunk.Metadata,
+		})
+	}
+
+	return results, nil
+}
+
+// Helper function to convert an ingestion job to protobuf
+func convertIngestionJobToPb(job *ingestion.Job) *pb.IngestionJob {
+	pbJob := &pb.IngestionJob{
+		Id:           int32(job.ID),
+		Title:        job.Title,
+		SourceType:   string(job.SourceType),
+		Source:       job.Source,
+		Status:       job.Status,
+		ChunkCount:   int32(job.ChunkCount),
+		ErrorMessage: job.ErrorMessage,
+		CreatedAt:    job.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:    job.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+
+	if job.DocumentID != nil {
+		docID := int32(*job.DocumentID)
+		pbJob.DocumentId = &docID
+	}
+
+	if job.CompletedAt != nil {
+		completedAt := job.CompletedAt.Format("2006-01-02T15:04:05Z07:00")
+		pbJob.CompletedAt = &completedAt
+	}
+
+	return pbJob
+}
@@ -0,0 +1,64 @@
+package grpc_server
+
+import (
+	"time"
+
+	"agentic-template/api/config"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+)
+
+// megabyte converts configured MB limits to bytes
+const megabyte = 1 << 20
+
+// ServerOptions returns the gRPC server options for the configured message
+// size, stream, and keepalive limits. Unset (0) limits keep the library
+// defaults.
+func ServerOptions(cfg *config.Config) []grpc.ServerOption {
+	var opts []grpc.ServerOption
+
+	if cfg.GRPCMaxRecvMsgSizeMB > 0 {
+		opts = append(opts, grpc.MaxRecvMsgSize(cfg.GRPCMaxRecvMsgSizeMB*megabyte))
+	}
+	if cfg.GRPCMaxSendMsgSizeMB > 0 {
+		opts = append(opts, grpc.MaxSendMsgSize(cfg.GRPCMaxSendMsgSizeMB*megabyte))
+	}
+	if cfg.GRPCMaxConcurrentStreams > 0 {
+		opts = append(opts, grpc.MaxConcurrentStreams(uint32(cfg.GRPCMaxConcurrentStreams)))
+	}
+
+	// Ping idle clients so long agent streams survive proxies that drop
+	// quiet connections
+	params := keepalive.ServerParameters{
+		Time:    time.Duration(cfg.GRPCKeepaliveTimeSeconds) * time.Second,
+		Timeout: time.Duration(cfg.GRPCKeepaliveTimeoutSeconds) * time.Second,
+	}
+	if params.Time > 0 || params.Timeout > 0 {
+		opts = append(opts, grpc.KeepaliveParams(params))
+	}
+
+	// Allow clients to ping while streams are idle, as long as they don't
+	// ping more often than the minimum interval
+	if cfg.GRPCKeepaliveMinTimeSeconds > 0 {
+		opts = append(opts, grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             time.Duration(cfg.GRPCKeepaliveMinTimeSeconds) * time.Second,
+			PermitWithoutStream: true,
+		}))
+	}
+
+	return opts
+}
+
+// clientCallOptions returns call options matching the server's message size
+// limits, for in-process clients such as the REST gateway
+func clientCallOptions(cfg *config.Config) []grpc.CallOption {
+	var opts []grpc.CallOption
+	if cfg.GRPCMaxRecvMsgSizeMB > 0 {
+		opts = append(opts, grpc.MaxCallSendMsgSize(cfg.GRPCMaxRecvMsgSizeMB*megabyte))
+	}
+	if cfg.GRPCMaxSendMsgSizeMB > 0 {
+		opts = append(opts, grpc.MaxCallRecvMsgSize(cfg.GRPCMaxSendMsgSizeMB*megabyte))
+	}
+	return opts
+}
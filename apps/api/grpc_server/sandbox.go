@@ -0,0 +1,130 @@
+package grpc_server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	pb "agentic-template/api/pb/v1"
+	"agentic-template/api/schema_manager"
+	"agentic-template/api/tenancy"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ResetSchemaSandbox replaces the caller's sandbox with a copy of its
+// production tables
+func (s *SchemaServiceServer) ResetSchemaSandbox(ctx context.Context, req *pb.ResetSchemaSandboxRequest) (*pb.ResetSchemaSandboxResponse, error) {
+	sm, err := s.sandboxManager(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sandboxCtx, err := s.tenants.ResetSandbox(ctx)
+	if err != nil {
+		return nil, sandboxStatus(err, "reset schema sandbox")
+	}
+	seed, err := sm.SeedSandbox(ctx, sandboxCtx, int(req.SampleRows))
+	if err != nil {
+		return nil, schemaStatus(err, "seed schema sandbox", "")
+	}
+	return &pb.ResetSchemaSandboxResponse{
+		Success: true,
+		Message: fmt.Sprintf("Sandbox reset with %d table(s) and %d record(s)", seed.Tables, seed.Rows),
+		Tables:  int32(seed.Tables),
+		Rows:    seed.Rows,
+	}, nil
+}
+
+// DiffSchemaSandbox compares the caller's sandbox with production
+func (s *SchemaServiceServer) DiffSchemaSandbox(ctx context.Context, req *pb.DiffSchemaSandboxRequest) (*pb.SchemaSandboxDiffResponse, error) {
+	sm, err := s.sandboxManager(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sandboxCtx, err := s.tenants.Sandbox(ctx)
+	if err != nil {
+		return nil, sandboxStatus(err, "diff schema sandbox")
+	}
+	diff, err := sm.DiffSandbox(ctx, sandboxCtx)
+	if err != nil {
+		return nil, schemaStatus(err, "diff schema sandbox", "")
+	}
+	message := "Sandbox matches production"
+	if !diff.IsEmpty() {
+		message = fmt.Sprintf("Sandbox adds %d, removes %d, and changes %d table(s)",
+			len(diff.AddedTables), len(diff.RemovedTables), len(diff.ChangedTables))
+	}
+	return &pb.SchemaSandboxDiffResponse{Success: true, Message: message, Diff: convertSchemaDiffToPb(diff)}, nil
+}
+
+// PromoteSchemaSandbox applies the caller's sandbox to production
+func (s *SchemaServiceServer) PromoteSchemaSandbox(ctx context.Context, req *pb.PromoteSchemaSandboxRequest) (*pb.SchemaSandboxDiffResponse, error) {
+	sm, err := s.sandboxManager(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sandboxCtx, err := s.tenants.Sandbox(ctx)
+	if err != nil {
+		return nil, sandboxStatus(err, "promote schema sandbox")
+	}
+	diff, err := sm.PromoteSandbox(ctx, sandboxCtx, schema_manager.PromoteOptions{
+		DropRemovedTables: req.DropRemovedTables,
+		CheckIntegrity:    req.CheckIntegrity,
+	})
+	if err != nil {
+		return nil, schemaStatus(err, "promote schema sandbox", "")
+	}
+	dropped := 0
+	if req.DropRemovedTables {
+		dropped = len(diff.RemovedTables)
+	}
+	return &pb.SchemaSandboxDiffResponse{
+		Success: true,
+		Message: fmt.Sprintf("Promoted %d new table(s) and deleted %d", len(diff.AddedTables), dropped),
+		Diff:    convertSchemaDiffToPb(diff),
+	}, nil
+}
+
+// sandboxManager returns a schema manager for sandbox calls, which run in
+// production and reach the sandbox through a second context. It reads
+// from the primary, since a replica may not have the sandbox yet.
+func (s *SchemaServiceServer) sandboxManager(ctx context.Context) (*schema_manager.SchemaManager, error) {
+	if s.tenants == nil {
+		return nil, sandboxStatus(tenancy.ErrSandboxDisabled, "use schema sandbox")
+	}
+	if tenancy.InSandbox(ctx) {
+		return nil, status.Errorf(codes.InvalidArgument, "sandbox calls run in the %s environment; omit %s",
+			tenancy.EnvironmentProduction, tenancy.EnvironmentHeader)
+	}
+	return schema_manager.FromManager(s.dbManager).WithReadPool(nil), nil
+}
+
+// sandboxStatus converts a failure to scope a sandbox to a gRPC status
+func sandboxStatus(err error, action string) error {
+	if errors.Is(err, tenancy.ErrSandboxDisabled) {
+		return status.Errorf(codes.FailedPrecondition, "Failed to %s: %v (set SCHEMA_SANDBOX_ENABLED)", action, err)
+	}
+	return status.Errorf(codes.Unavailable, "Failed to %s: %v", action, err)
+}
+
+// convertSchemaDiffToPb converts a schema diff
+func convertSchemaDiffToPb(diff *schema_manager.SchemaDiff) *pb.SchemaSandboxDiff {
+	pbDiff := &pb.SchemaSandboxDiff{}
+	for i := range diff.AddedTables {
+		pbDiff.AddedTables = append(pbDiff.AddedTables, convertTableDefinitionToPb(&diff.AddedTables[i]))
+	}
+	for i := range diff.RemovedTables {
+		pbDiff.RemovedTables = append(pbDiff.RemovedTables, convertTableDefinitionToPb(&diff.RemovedTables[i]))
+	}
+	for _, change := range diff.ChangedTables {
+		pbDiff.ChangedTables = append(pbDiff.ChangedTables, &pb.SchemaTableChange{
+			TableName:      change.TableName,
+			Renamed:        change.Renamed,
+			AddedColumns:   change.AddedColumns,
+			RemovedColumns: change.RemovedColumns,
+			ChangedColumns: change.ChangedColumns,
+		})
+	}
+	return pbDiff
+}
@@ -0,0 +1,164 @@
+package grpc_server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"agentic-template/api/connectors"
+	"agentic-template/api/db"
+	"agentic-template/api/destinations"
+	"agentic-template/api/queue"
+	"agentic-template/api/schema_manager"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+// schemaStatus converts a schema manager error to a gRPC status error with
+// google.rpc error details. The message is for display; clients should act
+// on the code and details.
+func schemaStatus(err error, action string, resourceName string) error {
+	message := fmt.Sprintf("Failed to %s: %v", action, err)
+
+	var validationErr *schema_manager.ValidationError
+	var quotaErr *schema_manager.QuotaError
+	var accessErr *schema_manager.ColumnAccessError
+	switch {
+	case errors.As(err, &validationErr):
+		return withDetails(codes.InvalidArgument, message, &errdetails.BadRequest{
+			FieldViolations: []*errdetails.BadRequest_FieldViolation{{
+				Field:       validationErr.Field,
+				Description: validationErr.Message,
+			}},
+		})
+	case errors.As(err, &accessErr):
+		violations := make([]*errdetails.BadRequest_FieldViolation, len(accessErr.Fields))
+		for i, field := range accessErr.Fields {
+			violations[i] = &errdetails.BadRequest_FieldViolation{Field: field.Field, Description: field.Message}
+		}
+		return withDetails(codes.PermissionDenied, message, &errdetails.BadRequest{FieldViolations: violations})
+	case errors.Is(err, schema_manager.ErrTableExists):
+		return withDetails(codes.AlreadyExists, message, &errdetails.ResourceInfo{
+			ResourceType: "table",
+			ResourceName: resourceName,
+			Description:  "a table with this name already exists",
+		})
+	case errors.Is(err, schema_manager.ErrTableNotFound):
+		return withDetails(codes.NotFound, message, &errdetails.ResourceInfo{
+			ResourceType: "table",
+			ResourceName: resourceName,
+		})
+	case errors.Is(err, schema_manager.ErrChangeRequestNotFound):
+		return withDetails(codes.NotFound, message, &errdetails.ResourceInfo{
+			ResourceType: "schema_change_request",
+			ResourceName: resourceName,
+		})
+	case errors.Is(err, schema_manager.ErrChangeRequestReviewed):
+		return withDetails(codes.FailedPrecondition, message, &errdetails.PreconditionFailure{
+			Violations: []*errdetails.PreconditionFailure_Violation{{
+				Type:        "STATUS",
+				Subject:     "schema_change_request/" + resourceName,
+				Description: "only pending change requests can be reviewed",
+			}},
+		})
+	case errors.Is(err, schema_manager.ErrSandboxNotPromotable):
+		return withDetails(codes.FailedPrecondition, message, &errdetails.PreconditionFailure{
+			Violations: []*errdetails.PreconditionFailure_Violation{{
+				Type:        "SANDBOX",
+				Subject:     "schema_sandbox",
+				Description: "only new tables without integrity issues can be promoted; reset the sandbox to start over",
+			}},
+		})
+	case errors.Is(err, queue.ErrJobNotFound):
+		return withDetails(codes.NotFound, message, &errdetails.ResourceInfo{
+			ResourceType: "anonymization_job",
+			ResourceName: resourceName,
+		})
+	case errors.Is(err, schema_manager.ErrImportNotFound):
+		return withDetails(codes.NotFound, message, &errdetails.ResourceInfo{
+			ResourceType: "data_import",
+			ResourceName: resourceName,
+		})
+	case errors.Is(err, schema_manager.ErrImportSourceNotFound):
+		return withDetails(codes.NotFound, message, &errdetails.ResourceInfo{
+			ResourceType: "import_source",
+			ResourceName: resourceName,
+			Description:  "sources are configured in IMPORT_SOURCES",
+		})
+	case errors.Is(err, connectors.ErrUnsupportedSource):
+		return withDetails(codes.FailedPrecondition, message, &errdetails.PreconditionFailure{
+			Violations: []*errdetails.PreconditionFailure_Violation{{
+				Type:        "IMPORT_SOURCE",
+				Subject:     "IMPORT_SOURCES",
+				Description: "import sources must be postgres://, mysql://, gsheets://, or airtable:// URLs",
+			}},
+		})
+	case errors.Is(err, schema_manager.ErrSyncNotFound):
+		return withDetails(codes.NotFound, message, &errdetails.ResourceInfo{
+			ResourceType: "warehouse_sync",
+			ResourceName: resourceName,
+		})
+	case errors.Is(err, schema_manager.ErrSyncDestinationNotFound):
+		return withDetails(codes.NotFound, message, &errdetails.ResourceInfo{
+			ResourceType: "sync_destination",
+			ResourceName: resourceName,
+			Description:  "destinations are configured in SYNC_DESTINATIONS",
+		})
+	case errors.Is(err, destinations.ErrUnsupportedDestination):
+		return withDetails(codes.FailedPrecondition, message, &errdetails.PreconditionFailure{
+			Violations: []*errdetails.PreconditionFailure_Violation{{
+				Type:        "SYNC_DESTINATION",
+				Subject:     "SYNC_DESTINATIONS",
+				Description: "sync destinations must be bigquery://, snowflake://, or s3:// URLs",
+			}},
+		})
+	case errors.Is(err, schema_manager.ErrRecordNotFound):
+		return withDetails(codes.NotFound, message, &errdetails.ResourceInfo{
+			ResourceType: "record",
+			ResourceName: resourceName,
+		})
+	case errors.Is(err, schema_manager.ErrTableReferenced):
+		return withDetails(codes.FailedPrecondition, message, &errdetails.PreconditionFailure{
+			Violations: []*errdetails.PreconditionFailure_Violation{{
+				Type:        "REFERENCES",
+				Subject:     "table/" + resourceName,
+				Description: "remove the relation columns pointing at this table first",
+			}},
+		})
+	case errors.As(err, &quotaErr):
+		return withDetails(codes.ResourceExhausted, message, &errdetails.QuotaFailure{
+			Violations: []*errdetails.QuotaFailure_Violation{{
+				Subject:     quotaErr.Limit,
+				Description: fmt.Sprintf("limit is %d, currently %d", quotaErr.Max, quotaErr.Current),
+			}},
+		})
+	case errors.Is(err, schema_manager.ErrQuotaOverrideDenied):
+		return status.Error(codes.PermissionDenied, message)
+	case errors.Is(err, schema_manager.ErrDatabaseNotConfigured), errors.Is(err, db.ErrDatabaseNotConfigured):
+		return withDetails(codes.FailedPrecondition, message, &errdetails.PreconditionFailure{
+			Violations: []*errdetails.PreconditionFailure_Violation{{
+				Type:        "DATABASE",
+				Subject:     "DATABASE_URL_POOLED",
+				Description: "database not configured",
+			}},
+		})
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, message)
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, message)
+	default:
+		return status.Error(codes.Internal, message)
+	}
+}
+
+// withDetails creates a status error carrying the given details
+func withDetails(code codes.Code, message string, details ...protoadapt.MessageV1) error {
+	st := status.New(code, message)
+	if detailed, err := st.WithDetails(details...); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
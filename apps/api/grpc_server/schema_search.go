@@ -0,0 +1,39 @@
+package grpc_server
+
+import (
+	"context"
+	"fmt"
+
+	pb "agentic-template/api/pb/v1"
+	"agentic-template/api/schema_manager"
+)
+
+// SearchSchema finds the tables and columns matching a query, best first
+func (s *SchemaServiceServer) SearchSchema(ctx context.Context, req *pb.SearchSchemaRequest) (*pb.SearchSchemaResponse, error) {
+	matches, err := s.getSchemaManager().SearchSchema(ctx, schema_manager.SchemaSearchRequest{
+		Query: req.Query,
+		Limit: int(req.Limit),
+	})
+	if err != nil {
+		return nil, schemaStatus(err, "search schema", req.Query)
+	}
+
+	pbMatches := make([]*pb.SchemaMatch, 0, len(matches))
+	for _, match := range matches {
+		pbMatches = append(pbMatches, &pb.SchemaMatch{
+			Kind:        string(match.Kind),
+			TableId:     int32(match.TableID),
+			Table:       match.Table,
+			TableName:   match.TableName,
+			Column:      match.Column,
+			ColumnName:  match.ColumnName,
+			Description: match.Description,
+			Rank:        match.Rank,
+		})
+	}
+	return &pb.SearchSchemaResponse{
+		Success: true,
+		Message: fmt.Sprintf("Found %d match(es)", len(pbMatches)),
+		Matches: pbMatches,
+	}, nil
+}
@@ -3,28 +3,41 @@ package grpc_server
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"agentic-template/api/db"
-	"agentic-template/api/pb"
+	pb "agentic-template/api/pb/v1"
+	"agentic-template/api/queue"
 	"agentic-template/api/schema_manager"
+	"agentic-template/api/tenancy"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
-// SchemaServiceServer implements the SchemaService gRPC service
+// SchemaServiceServer implements the SchemaService gRPC service. Failures
+// are returned as gRPC status errors (see schemaStatus); success and message
+// in responses are kept for display.
 type SchemaServiceServer struct {
 	pb.UnimplementedSchemaServiceServer
 	dbManager *db.Manager
+	tenants   *tenancy.Provisioner // nil when neither tenancy nor the sandbox is enabled
+	jobQueue  *queue.Queue         // Runs anonymizations
 }
 
 // NewSchemaServiceServer creates a new schema service server
-func NewSchemaServiceServer(dbManager *db.Manager) *SchemaServiceServer {
+func NewSchemaServiceServer(dbManager *db.Manager, tenants *tenancy.Provisioner, jobQueue *queue.Queue) *SchemaServiceServer {
 	return &SchemaServiceServer{
 		dbManager: dbManager,
+		tenants:   tenants,
+		jobQueue:  jobQueue,
 	}
 }
 
-// getSchemaManager returns a schema manager with the current database pool
+// getSchemaManager returns a schema manager on the current database,
+// reading from a replica when one is configured
 func (s *SchemaServiceServer) getSchemaManager() *schema_manager.SchemaManager {
-	return schema_manager.NewSchemaManager(s.dbManager.GetPool())
+	return schema_manager.FromManager(s.dbManager)
 }
 
 // CreateTable handles table creation requests
@@ -48,6 +61,21 @@ func (s *SchemaServiceServer) CreateTable(ctx context.Context, req *pb.CreateTab
 			colDef.ForeignKeyToTableID = &tableID
 		}
 
+		if col.VectorDimensions != nil {
+			dims := int(*col.VectorDimensions)
+			colDef.VectorDimensions = &dims
+		}
+
+		if col.VectorIndexType != nil {
+			indexType := schema_manager.VectorIndexType(*col.VectorIndexType)
+			colDef.VectorIndexType = &indexType
+		}
+
+		colDef.Format = columnFormatFromPb(col.Format)
+		colDef.Formula = col.Formula
+		colDef.Permissions = columnPermissionsFromPb(col.Permissions)
+		colDef.Description = col.Description
+
 		columns = append(columns, colDef)
 	}
 
@@ -60,13 +88,27 @@ func (s *SchemaServiceServer) CreateTable(ctx context.Context, req *pb.CreateTab
 		createReq.Description = req.Description
 	}
 
+	if req.OverrideQuota {
+		ctx = schema_manager.WithQuotaOverride(ctx)
+	}
+
+	// Non-admins' tables wait for approval when the workflow is on
+	if schema_manager.NeedsApproval(ctx) {
+		change, err := s.getSchemaManager().RequestCreateTable(ctx, createReq)
+		if err != nil {
+			return nil, schemaStatus(err, "request table creation", req.Name)
+		}
+		return &pb.CreateTableResponse{
+			Success:       true,
+			Message:       fmt.Sprintf("Creating table '%s' awaits approval (change request %d)", req.Name, change.ID),
+			ChangeRequest: convertChangeRequestToPb(change),
+		}, nil
+	}
+
 	// Call the schema manager
-	tableDef, err := s.getSchemaManager().CreateTable(ctx, createReq, "system") // TODO: Get actual user ID
+	tableDef, err := s.getSchemaManager().CreateTable(ctx, createReq)
 	if err != nil {
-		return &pb.CreateTableResponse{
-			Success: false,
-			Message: fmt.Sprintf("Failed to create table: %v", err),
-		}, nil // Return error in response, not as gRPC error
+		return nil, schemaStatus(err, "create table", req.Name)
 	}
 
 	// Convert response back to protobuf
@@ -83,10 +125,7 @@ func (s *SchemaServiceServer) CreateTable(ctx context.Context, req *pb.CreateTab
 func (s *SchemaServiceServer) GetTable(ctx context.Context, req *pb.GetTableRequest) (*pb.GetTableResponse, error) {
 	tableDef, err := s.getSchemaManager().GetTable(ctx, int(req.TableId))
 	if err != nil {
-		return &pb.GetTableResponse{
-			Success: false,
-			Message: fmt.Sprintf("Failed to get table: %v", err),
-		}, nil
+		return nil, schemaStatus(err, "get table", fmt.Sprint(req.TableId))
 	}
 
 	pbTableDef := convertTableDefinitionToPb(tableDef)
@@ -98,25 +137,29 @@ func (s *SchemaServiceServer) GetTable(ctx context.Context, req *pb.GetTableRequ
 	}, nil
 }
 
-// ListTables returns all user-defined tables
+// ListTables returns a page of user-defined tables
 func (s *SchemaServiceServer) ListTables(ctx context.Context, req *pb.ListTablesRequest) (*pb.ListTablesResponse, error) {
-	tables, err := s.getSchemaManager().ListTables(ctx)
+	page, err := s.getSchemaManager().ListTables(ctx, schema_manager.ListTablesOptions{
+		PageSize:   int(req.PageSize),
+		PageToken:  req.PageToken,
+		NamePrefix: req.GetNamePrefix(),
+		Sort:       schema_manager.TableSort(req.Sort),
+	})
 	if err != nil {
-		return &pb.ListTablesResponse{
-			Success: false,
-			Message: fmt.Sprintf("Failed to list tables: %v", err),
-		}, nil
+		return nil, schemaStatus(err, "list tables", "")
 	}
 
-	pbTables := make([]*pb.TableDefinition, 0, len(tables))
-	for _, table := range tables {
+	pbTables := make([]*pb.TableDefinition, 0, len(page.Tables))
+	for _, table := range page.Tables {
 		pbTables = append(pbTables, convertTableDefinitionToPb(&table))
 	}
 
 	return &pb.ListTablesResponse{
-		Success: true,
-		Message: fmt.Sprintf("Found %d table(s)", len(tables)),
-		Tables:  pbTables,
+		Success:       true,
+		Message:       fmt.Sprintf("Found %d table(s)", page.TotalSize),
+		Tables:        pbTables,
+		NextPageToken: page.NextPageToken,
+		TotalSize:     int32(page.TotalSize),
 	}, nil
 }
 
@@ -131,21 +174,57 @@ func (s *SchemaServiceServer) GetDataTypes(ctx context.Context, req *pb.GetDataT
 			DisplayName:  info.DisplayName,
 			Description:  info.Description,
 			PostgresType: info.PostgresType,
+			FormatFields: info.FormatFields,
 		})
 	}
 
+	dateFormats := make([]string, 0, len(schema_manager.DateFormats))
+	for _, format := range schema_manager.DateFormats {
+		dateFormats = append(dateFormats, string(format))
+	}
+	currencyDisplays := make([]string, 0, len(schema_manager.CurrencyDisplays))
+	for _, display := range schema_manager.CurrencyDisplays {
+		currencyDisplays = append(currencyDisplays, string(display))
+	}
+
 	return &pb.GetDataTypesResponse{
-		Success:   true,
-		DataTypes: pbDataTypes,
+		Success:          true,
+		DataTypes:        pbDataTypes,
+		DateFormats:      dateFormats,
+		CurrencyDisplays: currencyDisplays,
 	}, nil
 }
 
-// DeleteTable handles table deletion (placeholder for now)
+// DeleteTable moves a table to the trash, or drops it and its metadata
+// when the trash is disabled. Non-admins' deletions wait for approval when
+// the approval workflow is on.
 func (s *SchemaServiceServer) DeleteTable(ctx context.Context, req *pb.DeleteTableRequest) (*pb.DeleteTableResponse, error) {
-	// TODO: Implement table deletion
+	if schema_manager.NeedsApproval(ctx) {
+		change, err := s.getSchemaManager().RequestDeleteTable(ctx, int(req.TableId))
+		if err != nil {
+			return nil, schemaStatus(err, "request table deletion", fmt.Sprint(req.TableId))
+		}
+		return &pb.DeleteTableResponse{
+			Success:       true,
+			Message:       fmt.Sprintf("Deleting the table awaits approval (change request %d)", change.ID),
+			ChangeRequest: convertChangeRequestToPb(change),
+		}, nil
+	}
+
+	if err := s.getSchemaManager().DeleteTable(ctx, int(req.TableId)); err != nil {
+		return nil, schemaStatus(err, "delete table", fmt.Sprint(req.TableId))
+	}
+
+	if schema_manager.CurrentTrashSettings().Enabled {
+		return &pb.DeleteTableResponse{
+			Success: true,
+			Message: "Table moved to the trash",
+			Trashed: true,
+		}, nil
+	}
 	return &pb.DeleteTableResponse{
-		Success: false,
-		Message: "Table deletion not yet implemented",
+		Success: true,
+		Message: "Table deleted successfully",
 	}, nil
 }
 
@@ -153,10 +232,7 @@ func (s *SchemaServiceServer) DeleteTable(ctx context.Context, req *pb.DeleteTab
 func (s *SchemaServiceServer) ReloadDatabase(ctx context.Context, req *pb.ReloadDatabaseRequest) (*pb.ReloadDatabaseResponse, error) {
 	// Reload the database connection
 	if err := s.dbManager.Reload(); err != nil {
-		return &pb.ReloadDatabaseResponse{
-			Success: false,
-			Message: fmt.Sprintf("Failed to reload database: %v", err),
-		}, nil
+		return nil, status.Errorf(codes.Unavailable, "Failed to reload database: %v", err)
 	}
 
 	// Get database info to confirm connection
@@ -184,6 +260,48 @@ func (s *SchemaServiceServer) ReloadDatabase(ctx context.Context, req *pb.Reload
 	}, nil
 }
 
+// ListSchemaChanges returns entries of the schema change log, newest
+// first, or those after req.AfterId oldest first
+func (s *SchemaServiceServer) ListSchemaChanges(ctx context.Context, req *pb.ListSchemaChangesRequest) (*pb.ListSchemaChangesResponse, error) {
+	opts := schema_manager.ListChangesOptions{
+		PageSize: int(req.PageSize),
+		AfterID:  req.AfterId,
+	}
+	if req.TableId != nil {
+		tableID := int(*req.TableId)
+		opts.TableID = &tableID
+	}
+	changes, err := s.getSchemaManager().ListChanges(ctx, opts)
+	if err != nil {
+		return nil, schemaStatus(err, "list schema changes", "")
+	}
+
+	pbChanges := make([]*pb.SchemaChange, 0, len(changes))
+	for _, change := range changes {
+		pbChange := &pb.SchemaChange{
+			Id:            change.ID,
+			ChangeType:    change.ChangeType,
+			ChangeDetails: change.ChangeDetails,
+			ExecutedSql:   change.ExecutedSQL,
+			Status:        change.Status,
+			ErrorMessage:  change.ErrorMessage,
+			CreatedAt:     change.CreatedAt.Format(time.RFC3339),
+			CreatedBy:     change.CreatedBy,
+		}
+		if change.TableID != nil {
+			tableID := int32(*change.TableID)
+			pbChange.TableId = &tableID
+		}
+		pbChanges = append(pbChanges, pbChange)
+	}
+
+	return &pb.ListSchemaChangesResponse{
+		Success: true,
+		Message: fmt.Sprintf("Found %d schema change(s)", len(pbChanges)),
+		Changes: pbChanges,
+	}, nil
+}
+
 // Helper function to convert internal TableDefinition to protobuf
 func convertTableDefinitionToPb(table *schema_manager.TableDefinition) *pb.TableDefinition {
 	columns := make([]*pb.ColumnDetail, 0, len(table.Columns))
@@ -212,6 +330,21 @@ func convertTableDefinitionToPb(table *schema_manager.TableDefinition) *pb.Table
 			pbCol.ForeignKeyToTableName = col.ForeignKeyToTableName
 		}
 
+		if col.VectorDimensions != nil {
+			dims := int32(*col.VectorDimensions)
+			pbCol.VectorDimensions = &dims
+		}
+
+		if col.VectorIndexType != nil {
+			indexType := string(*col.VectorIndexType)
+			pbCol.VectorIndexType = &indexType
+		}
+
+		pbCol.Format = columnFormatToPb(col.Format)
+		pbCol.Formula = col.Formula
+		pbCol.Permissions = columnPermissionsToPb(col.Permissions)
+		pbCol.Description = col.Description
+
 		columns = append(columns, pbCol)
 	}
 
@@ -222,11 +355,100 @@ func convertTableDefinitionToPb(table *schema_manager.TableDefinition) *pb.Table
 		Columns:   columns,
 		CreatedAt: table.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
 		UpdatedAt: table.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		CreatedBy: table.CreatedBy,
 	}
 
 	if table.Description != nil {
 		pbTable.Description = table.Description
 	}
 
+	if table.DeletedAt != nil {
+		deletedAt := table.DeletedAt.Format("2006-01-02T15:04:05Z07:00")
+		pbTable.DeletedAt = &deletedAt
+		pbTable.DeletedBy = table.DeletedBy
+	}
+
+	if table.Naming != nil {
+		pbTable.Naming = &pb.IdentifierNaming{
+			TablePrefix: table.Naming.TablePrefix,
+			Case:        table.Naming.Case,
+			Truncation:  table.Naming.Truncation,
+			MaxLength:   int32(table.Naming.MaxLength),
+		}
+	}
+
+	for _, col := range table.SystemColumns {
+		pbTable.SystemColumns = append(pbTable.SystemColumns, &pb.SystemColumn{
+			Name:        col.Name,
+			DataType:    string(col.DataType),
+			Description: col.Description,
+			Reserved:    col.Reserved,
+		})
+	}
+
 	return pbTable
 }
+
+// columnFormatFromPb converts the presentation settings of a column, nil
+// when none are set
+func columnFormatFromPb(format *pb.ColumnFormat) *schema_manager.ColumnFormat {
+	if format == nil {
+		return nil
+	}
+	converted := &schema_manager.ColumnFormat{
+		Timezone:     format.Timezone,
+		NumberLocale: format.NumberLocale,
+		Currency:     format.Currency,
+	}
+	if format.DateFormat != nil {
+		dateFormat := schema_manager.DateFormat(*format.DateFormat)
+		converted.DateFormat = &dateFormat
+	}
+	if format.CurrencyDisplay != nil {
+		display := schema_manager.CurrencyDisplay(*format.CurrencyDisplay)
+		converted.CurrencyDisplay = &display
+	}
+	if converted.IsZero() {
+		return nil
+	}
+	return converted
+}
+
+// columnFormatToPb converts the presentation settings of a column
+func columnFormatToPb(format *schema_manager.ColumnFormat) *pb.ColumnFormat {
+	if format == nil {
+		return nil
+	}
+	converted := &pb.ColumnFormat{
+		Timezone:     format.Timezone,
+		NumberLocale: format.NumberLocale,
+		Currency:     format.Currency,
+	}
+	if format.DateFormat != nil {
+		dateFormat := string(*format.DateFormat)
+		converted.DateFormat = &dateFormat
+	}
+	if format.CurrencyDisplay != nil {
+		display := string(*format.CurrencyDisplay)
+		converted.CurrencyDisplay = &display
+	}
+	return converted
+}
+
+// columnPermissionsFromPb converts the roles denied access to a column, nil
+// when none are
+func columnPermissionsFromPb(perms *pb.ColumnPermissions) *schema_manager.ColumnPermissions {
+	converted := &schema_manager.ColumnPermissions{DenyRead: perms.GetDenyRead(), DenyWrite: perms.GetDenyWrite()}
+	if converted.IsZero() {
+		return nil
+	}
+	return converted
+}
+
+// columnPermissionsToPb converts the roles denied access to a column
+func columnPermissionsToPb(perms *schema_manager.ColumnPermissions) *pb.ColumnPermissions {
+	if perms.IsZero() {
+		return nil
+	}
+	return &pb.ColumnPermissions{DenyRead: perms.DenyRead, DenyWrite: perms.DenyWrite}
+}
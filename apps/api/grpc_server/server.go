@@ -2,10 +2,16 @@ package grpc_server
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"strings"
 
+	"agentic-template/api/config"
 	"agentic-template/api/db"
-	"agentic-template/api/pb"
+	"agentic-template/api/embeddings"
+	"agentic-template/api/ingestion"
+	"agentic-template/api/queue"
+	"agentic-template/api/tenancy"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -24,13 +30,41 @@ func NewServer(database *db.DB) *Server {
 	}
 }
 
-// RegisterServices registers all gRPC services with the server
-func RegisterServices(grpcServer *grpc.Server, dbManager *db.Manager) {
-	// Register the Schema Management Service
-	schemaService := NewSchemaServiceServer(dbManager)
-	pb.RegisterSchemaServiceServer(grpcServer, schemaService)
+// RegisterServices registers all gRPC services with the server. The returned
+// function stops the services' background workers.
+func RegisterServices(
+	grpcServer *grpc.Server,
+	cfg *config.Config,
+	dbManager *db.Manager,
+	embedder embeddings.Embedder,
+	ingestionService *ingestion.Service,
+	tenants *tenancy.Provisioner,
+	jobQueue *queue.Queue,
+) func(ctx context.Context) error {
+	s := &services{
+		// Streaming Agent Service
+		agent: NewAgentServiceServer(dbManager, ingestionService, cfg),
+		// Schema Management Service
+		schema: NewSchemaServiceServer(dbManager, tenants, jobQueue),
+		// Knowledge (document ingestion) Service
+		knowledge: NewKnowledgeServiceServer(dbManager, embedder, ingestionService),
+		// Agent Profile Service
+		agentProfile: NewAgentProfileServiceServer(dbManager, ingestionService),
+		// Agent Run (trace) Service
+		agentRun: NewAgentRunServiceServer(dbManager),
+		// Audit log review Service
+		audit: NewAuditServiceServer(dbManager),
+	}
+
+	// Serve every API version from the same implementations
+	for _, version := range apiVersions {
+		version.register(grpcServer, s)
+	}
 
-	log.Println("gRPC services registered (SchemaService active)")
+	log.Printf("gRPC services registered (AgentService, SchemaService, KnowledgeService, AgentProfileService, AgentRunService, AuditService active; API versions: %s)",
+		strings.Join(APIVersions(), ", "))
+
+	return s.agent.Shutdown
 }
 
 // Example health check method for gRPC
@@ -40,4 +74,22 @@ func (s *Server) HealthCheck(ctx context.Context, req interface{}) (interface{},
 	}
 
 	return map[string]string{"status": "healthy"}, nil
-}
\ No newline at end of file
+}
+
+// Stop stops the server gracefully, letting in-flight RPCs such as agent
+// streams finish. When ctx is done first the remaining RPCs are cancelled.
+func Stop(ctx context.Context, grpcServer *grpc.Server) error {
+	done := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		grpcServer.Stop()
+		return fmt.Errorf("in-flight RPCs cancelled: %w", ctx.Err())
+	}
+}
@@ -0,0 +1,69 @@
+package grpc_server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"agentic-template/api/analytics"
+	pb "agentic-template/api/pb/v1"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// GetTableAnalytics returns the API usage of user-defined tables over the
+// last req.Days days, most requested first
+func (s *SchemaServiceServer) GetTableAnalytics(ctx context.Context, req *pb.GetTableAnalyticsRequest) (*pb.GetTableAnalyticsResponse, error) {
+	days := int(req.Days)
+	if days == 0 {
+		days = analytics.DefaultDays
+	}
+	if days < 0 || days > analytics.MaxDays {
+		return nil, invalidArgument("days", fmt.Sprintf("must be between 1 and %d", analytics.MaxDays))
+	}
+
+	query := analytics.Query{Since: time.Now().AddDate(0, 0, -days).UTC().Truncate(time.Hour)}
+	if req.TableId != nil {
+		tableID := int(*req.TableId)
+		query.TableID = &tableID
+	}
+	usages, err := analytics.NewStore(s.dbManager.GetReadPool()).Usage(ctx, query)
+	switch {
+	case errors.Is(err, analytics.ErrTableNotFound):
+		return nil, status.Errorf(codes.NotFound, "table %d not found", req.GetTableId())
+	case errors.Is(err, analytics.ErrDatabaseNotConfigured):
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	case err != nil:
+		return nil, status.Errorf(codes.Internal, "Failed to get table analytics: %v", err)
+	}
+
+	tables := make([]*pb.TableAnalytics, 0, len(usages))
+	for _, usage := range usages {
+		table := &pb.TableAnalytics{
+			TableId:      int32(usage.TableID),
+			Name:         usage.Name,
+			RequestCount: usage.Requests(),
+			ReadCount:    usage.Reads,
+			WriteCount:   usage.Writes,
+			ErrorCount:   usage.Errors,
+			ReadRatio:    usage.ReadRatio(),
+			P50LatencyMs: usage.LatencyPercentileMS(0.50),
+			P95LatencyMs: usage.LatencyPercentileMS(0.95),
+			P99LatencyMs: usage.LatencyPercentileMS(0.99),
+		}
+		if usage.LastUsedAt != nil {
+			lastUsed := usage.LastUsedAt.Format(time.RFC3339)
+			table.LastUsedAt = &lastUsed
+		}
+		tables = append(tables, table)
+	}
+
+	return &pb.GetTableAnalyticsResponse{
+		Success: true,
+		Message: fmt.Sprintf("Found usage of %d table(s)", len(tables)),
+		Tables:  tables,
+		Since:   query.Since.Format(time.RFC3339),
+	}, nil
+}
@@ -0,0 +1,53 @@
+package grpc_server
+
+import (
+	"context"
+	"fmt"
+
+	pb "agentic-template/api/pb/v1"
+	"agentic-template/api/schema_manager"
+)
+
+// CheckTableIntegrity scans a table for orphaned relations, invalid values,
+// and constraint violations, applying the requested repairs
+func (s *SchemaServiceServer) CheckTableIntegrity(ctx context.Context, req *pb.CheckTableIntegrityRequest) (*pb.CheckTableIntegrityResponse, error) {
+	fixes := make([]schema_manager.IntegrityFix, len(req.Fixes))
+	for i, fix := range req.Fixes {
+		fixes[i] = schema_manager.IntegrityFix(fix)
+	}
+
+	report, err := s.getSchemaManager().CheckIntegrity(ctx, schema_manager.CheckIntegrityRequest{
+		TableID:    int(req.TableId),
+		SampleSize: int(req.SampleSize),
+		Fixes:      fixes,
+	})
+	if err != nil {
+		return nil, schemaStatus(err, "check table integrity", fmt.Sprint(req.TableId))
+	}
+
+	issues := make([]*pb.IntegrityIssue, 0, len(report.Issues))
+	for _, issue := range report.Issues {
+		issues = append(issues, &pb.IntegrityIssue{
+			Check:           string(issue.Check),
+			ColumnName:      issue.ColumnName,
+			Description:     issue.Description,
+			RecordCount:     issue.RecordCount,
+			SampleRecordIds: issue.SampleRecordIDs,
+			Fix:             string(issue.Fix),
+			FixedCount:      issue.FixedCount,
+			FixError:        issue.FixError,
+		})
+	}
+
+	message := fmt.Sprintf("Found %d issue(s) in table '%s'", len(issues), report.Name)
+	if len(issues) == 0 {
+		message = fmt.Sprintf("Table '%s' has no integrity issues", report.Name)
+	}
+	return &pb.CheckTableIntegrityResponse{
+		Success: true,
+		Message: message,
+		TableId: int32(report.TableID),
+		Issues:  issues,
+		Fixed:   report.Fixed,
+	}, nil
+}
@@ -0,0 +1,57 @@
+package grpc_server
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	pb "agentic-template/api/pb/v1"
+	"agentic-template/api/schema_manager"
+)
+
+// ListTrash returns the tables in the trash, most recently deleted first
+func (s *SchemaServiceServer) ListTrash(ctx context.Context, req *pb.ListTrashRequest) (*pb.ListTrashResponse, error) {
+	tables, err := s.getSchemaManager().ListTrash(ctx)
+	if err != nil {
+		return nil, schemaStatus(err, "list trash", "")
+	}
+
+	pbTables := make([]*pb.TableDefinition, 0, len(tables))
+	for i := range tables {
+		pbTables = append(pbTables, convertTableDefinitionToPb(&tables[i]))
+	}
+	return &pb.ListTrashResponse{
+		Tables:        pbTables,
+		RetentionDays: int32(schema_manager.CurrentTrashSettings().Retention / (24 * time.Hour)),
+	}, nil
+}
+
+// RestoreTable moves a table out of the trash under its original name
+func (s *SchemaServiceServer) RestoreTable(ctx context.Context, req *pb.RestoreTableRequest) (*pb.RestoreTableResponse, error) {
+	sm := s.getSchemaManager()
+	if err := sm.RestoreTable(ctx, int(req.TableId)); err != nil {
+		return nil, schemaStatus(err, "restore table", fmt.Sprint(req.TableId))
+	}
+
+	table, err := sm.GetTable(ctx, int(req.TableId))
+	if err != nil {
+		return nil, schemaStatus(err, "get table", fmt.Sprint(req.TableId))
+	}
+	return &pb.RestoreTableResponse{
+		Success: true,
+		Message: fmt.Sprintf("Table '%s' restored successfully", table.Name),
+		Table:   convertTableDefinitionToPb(table),
+	}, nil
+}
+
+// PurgeTable permanently drops a table in the trash and its metadata
+func (s *SchemaServiceServer) PurgeTable(ctx context.Context, req *pb.PurgeTableRequest) (*pb.PurgeTableResponse, error) {
+	if err := s.getSchemaManager().PurgeTable(ctx, int(req.TableId)); err != nil {
+		return nil, schemaStatus(err, "purge table", fmt.Sprint(req.TableId))
+	}
+
+	return &pb.PurgeTableResponse{
+		Success: true,
+		Message: "Table purged successfully",
+	}, nil
+}
@@ -0,0 +1,117 @@
+package grpc_server
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"agentic-template/api/agent/guardrails"
+	"agentic-template/api/agent/runs"
+	"agentic-template/api/logging"
+	pb "agentic-template/api/pb/v1"
+)
+
+// toolScreen checks a run's tool traffic for instructions injected through
+// the table rows and documents its tools read. Tool inputs are checked once
+// the run has read any; after injected content was found, every later call
+// is treated as derived from it and held or blocked by the tool action.
+type toolScreen struct {
+	guard    *guardrails.Guard
+	recorder *runs.Recorder
+	emit     func(item agentChunk) error
+	// approvals asks the chat client to approve held calls; nil outside a
+	// chat, where held calls are blocked
+	approvals       *toolApprovals
+	requestApproval func(agentName string, req *pb.ToolApprovalRequest) error
+	// classify (optional) is asked when the heuristics find nothing; set
+	// once the main agent's model exists
+	classify guardrails.Classifier
+
+	mu      sync.Mutex
+	read    bool // The run has read rows or documents
+	tainted bool // Injected instructions were found in what it read
+}
+
+// check implements agent.ToolScreen
+func (t *toolScreen) check(ctx context.Context, agentName, toolName, text string, output bool) (string, string, error) {
+	t.mu.Lock()
+	read, tainted := t.read, t.tainted
+	if output {
+		t.read = true
+	}
+	t.mu.Unlock()
+	if !output && !read {
+		return text, "", nil
+	}
+
+	result, err := t.guard.CheckTool(ctx, text, t.classify)
+	if err != nil {
+		// The heuristics still ran; the classifier only adds to them
+		logging.FromContext(ctx).Warn("tool injection classifier failed", "tool", toolName, "error", err)
+	}
+
+	action := t.guard.ToolAction()
+	switch {
+	case result.Triggered() && output:
+		t.mu.Lock()
+		t.tainted = true
+		t.mu.Unlock()
+	case !result.Triggered() && !output && tainted:
+		result.Violations = append(result.Violations, guardrails.Violation{
+			Policy: guardrails.PolicyPromptInjection,
+			Action: action,
+			Detail: "after_injected_content",
+		})
+	}
+	if !result.Triggered() {
+		return text, "", nil
+	}
+	if err := t.report(agentName, toolName, result.Violations); err != nil {
+		return "", "", err
+	}
+
+	switch action {
+	case guardrails.ActionBlock:
+		if output {
+			return "", fmt.Sprintf("The output of %s was withheld because it contains instructions injected into the data it read.", toolName), nil
+		}
+		return "", fmt.Sprintf("This call to %s was blocked because it may follow instructions injected into the data read earlier.", toolName), nil
+	case guardrails.ActionApprove:
+		// The agent reads the content; the calls it makes next wait for approval
+		if output {
+			return result.Text, "", nil
+		}
+		if t.approvals == nil {
+			return "", fmt.Sprintf("This call to %s needs the user's approval because it may follow instructions injected into the data read earlier, and approvals are only available in chat.", toolName), nil
+		}
+		approved, reason, err := t.approvals.ask(ctx, t.recorder, t.requestApproval, agentName, toolName, text)
+		if err != nil {
+			return "", "", err
+		}
+		if !approved {
+			if reason == "" {
+				reason = "no reason given"
+			}
+			return "", fmt.Sprintf("The user rejected this call to %s: %s", toolName, reason), nil
+		}
+		return text, "", nil
+	default:
+		return result.Text, "", nil
+	}
+}
+
+// report records the violations in the trace and streams them
+func (t *toolScreen) report(agentName, toolName string, violations []guardrails.Violation) error {
+	for _, violation := range violations {
+		t.recorder.Record(runs.Event{
+			Type:      runs.EventGuardrail,
+			AgentName: agentName,
+			Tool:      toolName,
+			Content:   fmt.Sprintf("%s: %s (%s)", violation.Policy, violation.Detail, violation.Action),
+		})
+		if err := t.emit(agentChunk{agentName: agentName, guardrail: &violation, stage: guardrails.StageTool}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
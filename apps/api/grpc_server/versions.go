@@ -0,0 +1,69 @@
+package grpc_server
+
+import (
+	"context"
+
+	pb "agentic-template/api/pb/v1"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+)
+
+// services are the service implementations shared by every API version.
+// A new version of a service wraps the same implementation (or the same
+// managers underneath) and converts its own messages.
+type services struct {
+	agent        *AgentServiceServer
+	schema       *SchemaServiceServer
+	knowledge    *KnowledgeServiceServer
+	agentProfile *AgentProfileServiceServer
+	agentRun     *AgentRunServiceServer
+	audit        *AuditServiceServer
+}
+
+// gatewayRegistration registers the REST handlers of one service
+type gatewayRegistration func(context.Context, *runtime.ServeMux, *grpc.ClientConn) error
+
+// apiVersion is one version of the API, served side by side with the
+// others so breaking proto changes don't strand existing clients
+type apiVersion struct {
+	name     string // REST path prefix and Go package suffix, e.g. "v1"
+	register func(grpcServer *grpc.Server, s *services)
+	gateway  []gatewayRegistration
+}
+
+// apiVersions are the served API versions, oldest first. To add a version,
+// generate pb/vN from packages/proto/vN, implement the changed services
+// against the new messages, and append an entry here. Versions are removed
+// only after the deprecation period in packages/proto/README.md.
+var apiVersions = []apiVersion{
+	{
+		name: "v1",
+		register: func(grpcServer *grpc.Server, s *services) {
+			pb.RegisterAgentServiceServer(grpcServer, s.agent)
+			pb.RegisterSchemaServiceServer(grpcServer, s.schema)
+			pb.RegisterKnowledgeServiceServer(grpcServer, s.knowledge)
+			pb.RegisterAgentProfileServiceServer(grpcServer, s.agentProfile)
+			pb.RegisterAgentRunServiceServer(grpcServer, s.agentRun)
+			pb.RegisterAuditServiceServer(grpcServer, s.audit)
+		},
+		gateway: []gatewayRegistration{
+			pb.RegisterAgentServiceHandler,
+			pb.RegisterAgentProfileServiceHandler,
+			pb.RegisterAgentRunServiceHandler,
+			pb.RegisterSchemaServiceHandler,
+			pb.RegisterKnowledgeServiceHandler,
+			pb.RegisterAuditServiceHandler,
+		},
+	},
+}
+
+// APIVersions returns the names of the served API versions, which are also
+// the path prefixes of their REST routes
+func APIVersions() []string {
+	names := make([]string, len(apiVersions))
+	for i, version := range apiVersions {
+		names[i] = version.name
+	}
+	return names
+}
@@ -0,0 +1,147 @@
+package grpc_server
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	pb "agentic-template/api/pb/v1"
+	"agentic-template/api/schema_manager"
+)
+
+// ListSyncDestinations returns the configured sync destinations, without
+// their URLs
+func (s *SchemaServiceServer) ListSyncDestinations(ctx context.Context, req *pb.ListSyncDestinationsRequest) (*pb.ListSyncDestinationsResponse, error) {
+	destinations := schema_manager.ListSyncDestinations()
+	pbDestinations := make([]*pb.SyncDestination, 0, len(destinations))
+	for _, destination := range destinations {
+		pbDestinations = append(pbDestinations, &pb.SyncDestination{Name: destination.Name, Kind: destination.Kind})
+	}
+	return &pb.ListSyncDestinationsResponse{
+		Success:      true,
+		Message:      fmt.Sprintf("Found %d sync destination(s)", len(pbDestinations)),
+		Destinations: pbDestinations,
+	}, nil
+}
+
+// CreateWarehouseSync creates a warehouse sync and queues its first run
+func (s *SchemaServiceServer) CreateWarehouseSync(ctx context.Context, req *pb.CreateWarehouseSyncRequest) (*pb.WarehouseSyncResponse, error) {
+	tableIDs := make([]int, len(req.TableIds))
+	for i, id := range req.TableIds {
+		tableIDs[i] = int(id)
+	}
+	sync, err := s.getSchemaManager().CreateSync(ctx, s.jobQueue, schema_manager.SyncRequest{
+		Destination: req.Destination,
+		TableIDs:    tableIDs,
+		Interval:    time.Duration(req.IntervalMinutes) * time.Minute,
+	})
+	if err != nil {
+		return nil, schemaStatus(err, "create warehouse sync", req.Destination)
+	}
+	return &pb.WarehouseSyncResponse{
+		Success:       true,
+		Message:       fmt.Sprintf("Sync to %s created", sync.Destination),
+		WarehouseSync: convertWarehouseSyncToPb(sync),
+	}, nil
+}
+
+// GetWarehouseSync returns a warehouse sync with its progress
+func (s *SchemaServiceServer) GetWarehouseSync(ctx context.Context, req *pb.GetWarehouseSyncRequest) (*pb.WarehouseSyncResponse, error) {
+	sync, err := s.getSchemaManager().GetSync(ctx, req.Id)
+	if err != nil {
+		return nil, schemaStatus(err, "get warehouse sync", fmt.Sprint(req.Id))
+	}
+	return &pb.WarehouseSyncResponse{
+		Success:       true,
+		Message:       "Warehouse sync retrieved successfully",
+		WarehouseSync: convertWarehouseSyncToPb(sync),
+	}, nil
+}
+
+// ListWarehouseSyncs returns warehouse syncs, newest first
+func (s *SchemaServiceServer) ListWarehouseSyncs(ctx context.Context, req *pb.ListWarehouseSyncsRequest) (*pb.ListWarehouseSyncsResponse, error) {
+	syncs, err := s.getSchemaManager().ListSyncs(ctx, schema_manager.ListSyncsOptions{
+		PageSize: int(req.PageSize),
+		BeforeID: req.BeforeId,
+	})
+	if err != nil {
+		return nil, schemaStatus(err, "list warehouse syncs", "")
+	}
+
+	pbSyncs := make([]*pb.WarehouseSync, 0, len(syncs))
+	for i := range syncs {
+		pbSyncs = append(pbSyncs, convertWarehouseSyncToPb(&syncs[i]))
+	}
+	return &pb.ListWarehouseSyncsResponse{
+		Success:        true,
+		Message:        fmt.Sprintf("Found %d warehouse sync(s)", len(pbSyncs)),
+		WarehouseSyncs: pbSyncs,
+	}, nil
+}
+
+// PauseWarehouseSync stops a warehouse sync from running
+func (s *SchemaServiceServer) PauseWarehouseSync(ctx context.Context, req *pb.PauseWarehouseSyncRequest) (*pb.WarehouseSyncResponse, error) {
+	sync, err := s.getSchemaManager().PauseSync(ctx, req.Id)
+	if err != nil {
+		return nil, schemaStatus(err, "pause warehouse sync", fmt.Sprint(req.Id))
+	}
+	return &pb.WarehouseSyncResponse{
+		Success:       true,
+		Message:       fmt.Sprintf("Warehouse sync %d paused", sync.ID),
+		WarehouseSync: convertWarehouseSyncToPb(sync),
+	}, nil
+}
+
+// ResumeWarehouseSync queues a run of a warehouse sync now
+func (s *SchemaServiceServer) ResumeWarehouseSync(ctx context.Context, req *pb.ResumeWarehouseSyncRequest) (*pb.WarehouseSyncResponse, error) {
+	sync, err := s.getSchemaManager().ResumeSync(ctx, s.jobQueue, req.Id)
+	if err != nil {
+		return nil, schemaStatus(err, "resume warehouse sync", fmt.Sprint(req.Id))
+	}
+	return &pb.WarehouseSyncResponse{
+		Success:       true,
+		Message:       fmt.Sprintf("Warehouse sync %d resumed", sync.ID),
+		WarehouseSync: convertWarehouseSyncToPb(sync),
+	}, nil
+}
+
+// DeleteWarehouseSync deletes a warehouse sync
+func (s *SchemaServiceServer) DeleteWarehouseSync(ctx context.Context, req *pb.DeleteWarehouseSyncRequest) (*pb.DeleteWarehouseSyncResponse, error) {
+	if err := s.getSchemaManager().DeleteSync(ctx, req.Id); err != nil {
+		return nil, schemaStatus(err, "delete warehouse sync", fmt.Sprint(req.Id))
+	}
+	return &pb.DeleteWarehouseSyncResponse{
+		Success: true,
+		Message: fmt.Sprintf("Warehouse sync %d deleted", req.Id),
+	}, nil
+}
+
+// convertWarehouseSyncToPb converts a warehouse sync
+func convertWarehouseSyncToPb(sync *schema_manager.WarehouseSync) *pb.WarehouseSync {
+	pbSync := &pb.WarehouseSync{
+		Id:                  sync.ID,
+		Destination:         sync.Destination,
+		IntervalMinutes:     int32(sync.Interval / time.Minute),
+		Enabled:             sync.Enabled,
+		JobId:               sync.JobID,
+		SnapshotDone:        sync.SnapshotDone,
+		RowsSynced:          sync.RowsSynced,
+		LastError:           sync.LastError,
+		ConsecutiveFailures: int32(sync.ConsecutiveFailures),
+		CreatedBy:           sync.CreatedBy,
+		CreatedAt:           sync.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:           sync.UpdatedAt.Format(time.RFC3339),
+	}
+	for _, id := range sync.TableIDs {
+		pbSync.TableIds = append(pbSync.TableIds, int32(id))
+	}
+	if sync.LastSyncedAt != nil {
+		lastSyncedAt := sync.LastSyncedAt.Format(time.RFC3339)
+		pbSync.LastSyncedAt = &lastSyncedAt
+	}
+	if sync.AlertedAt != nil {
+		alertedAt := sync.AlertedAt.Format(time.RFC3339)
+		pbSync.AlertedAt = &alertedAt
+	}
+	return pbSync
+}
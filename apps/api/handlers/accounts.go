@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"agentic-template/api/accounts"
+	"agentic-template/api/db"
+	"agentic-template/api/requestctx"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CredentialsRequest is the body of signup and login requests
+type CredentialsRequest struct {
+	Email    string `json:"email" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// RefreshRequest is the body of refresh and logout requests
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// AccountsHandler exposes signup, login, and token refresh over HTTP
+type AccountsHandler struct {
+	dbManager *db.Manager
+	config    accounts.Config
+}
+
+// NewAccountsHandler creates a new accounts handler
+func NewAccountsHandler(dbManager *db.Manager, cfg accounts.Config) *AccountsHandler {
+	return &AccountsHandler{
+		dbManager: dbManager,
+		config:    cfg,
+	}
+}
+
+// getService returns an accounts service using the current pool
+func (h *AccountsHandler) getService() *accounts.Service {
+	return accounts.NewService(h.dbManager.GetPool(), h.config)
+}
+
+// Signup handles POST /api/auth/signup
+func (h *AccountsHandler) Signup(c *gin.Context) {
+	var req CredentialsRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	tokens, err := h.getService().Signup(c.Request.Context(), req.Email, req.Password)
+	if err != nil {
+		writeAccountsError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, tokens)
+}
+
+// Login handles POST /api/auth/login
+func (h *AccountsHandler) Login(c *gin.Context) {
+	var req CredentialsRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	tokens, err := h.getService().Login(c.Request.Context(), req.Email, req.Password)
+	if err != nil {
+		writeAccountsError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// Refresh handles POST /api/auth/refresh, rotating the refresh token
+func (h *AccountsHandler) Refresh(c *gin.Context) {
+	var req RefreshRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	tokens, err := h.getService().Refresh(c.Request.Context(), req.RefreshToken)
+	if err != nil {
+		writeAccountsError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// Logout handles POST /api/auth/logout, revoking the refresh token's login
+func (h *AccountsHandler) Logout(c *gin.Context) {
+	var req RefreshRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	if err := h.getService().Logout(c.Request.Context(), req.RefreshToken); err != nil {
+		writeAccountsError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Me handles GET /api/auth/me, returning the caller's principal
+func (h *AccountsHandler) Me(c *gin.Context) {
+	principal, ok := requestctx.PrincipalFrom(c.Request.Context())
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "not signed in"})
+		return
+	}
+
+	c.JSON(http.StatusOK, principal)
+}
+
+// writeAccountsError responds with the HTTP status matching an accounts
+// service error
+func writeAccountsError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, accounts.ErrInvalidEmail):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error(), Field: "email"})
+	case errors.Is(err, accounts.ErrWeakPassword):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error(), Field: "password"})
+	case errors.Is(err, accounts.ErrEmailTaken):
+		c.JSON(http.StatusConflict, ErrorResponse{Error: err.Error()})
+	case errors.Is(err, accounts.ErrInvalidCredentials),
+		errors.Is(err, accounts.ErrInvalidRefreshToken),
+		errors.Is(err, accounts.ErrRefreshTokenReused):
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: err.Error()})
+	case errors.Is(err, accounts.ErrSignupDisabled), errors.Is(err, accounts.ErrUserDisabled):
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: err.Error()})
+	case errors.Is(err, accounts.ErrDatabaseNotConfigured):
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+	}
+}
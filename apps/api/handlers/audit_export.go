@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"net/http"
+
+	"agentic-template/api/audit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuditExportResponse represents the progress of the audit export sinks
+type AuditExportResponse struct {
+	Enabled bool               `json:"enabled"`
+	Sinks   []audit.SinkStatus `json:"sinks"`
+}
+
+// AuditExportHandler reports how far audit events have been exported
+type AuditExportHandler struct {
+	exporter *audit.Exporter
+}
+
+// NewAuditExportHandler creates a new audit export handler; exporter is
+// nil when no sinks are configured
+func NewAuditExportHandler(exporter *audit.Exporter) *AuditExportHandler {
+	return &AuditExportHandler{exporter: exporter}
+}
+
+// Get handles GET /api/admin/audit-export, listing each sink's exported
+// count, last success, and consecutive failures
+func (h *AuditExportHandler) Get(c *gin.Context) {
+	if h.exporter == nil {
+		c.JSON(http.StatusOK, AuditExportResponse{Sinks: []audit.SinkStatus{}})
+		return
+	}
+	c.JSON(http.StatusOK, AuditExportResponse{Enabled: true, Sinks: h.exporter.Status()})
+}
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"net/http"
+
+	"agentic-template/api/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ConfigResponse represents the effective configuration
+type ConfigResponse struct {
+	Environment string           `json:"environment"`
+	Settings    []config.Setting `json:"settings"`
+}
+
+// ConfigHandler reports the configuration the process is running with
+type ConfigHandler struct {
+	config *config.Config
+}
+
+// NewConfigHandler creates a new configuration handler
+func NewConfigHandler(cfg *config.Config) *ConfigHandler {
+	return &ConfigHandler{config: cfg}
+}
+
+// Get handles GET /api/admin/config, listing every setting with its
+// effective value and whether it came from the environment, the .env file,
+// or the default. Secrets are redacted.
+func (h *ConfigHandler) Get(c *gin.Context) {
+	c.JSON(http.StatusOK, ConfigResponse{
+		Environment: h.config.Environment,
+		Settings:    h.config.Settings(),
+	})
+}
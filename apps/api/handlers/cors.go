@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"log"
+	"time"
+
+	"agentic-template/api/config"
+	"agentic-template/api/logging"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// corsExposedHeaders are the response headers browser code may read
+var corsExposedHeaders = []string{
+	logging.RequestIDHeader,
+	"Retry-After",
+}
+
+// CORS returns middleware answering preflight requests and adding CORS
+// headers for the configured origins, methods, and headers
+func CORS(cfg *config.Config) (gin.HandlerFunc, error) {
+	corsConfig := cors.Config{
+		AllowMethods:     cfg.CORSAllowedMethods,
+		AllowHeaders:     cfg.CORSAllowedHeaders,
+		ExposeHeaders:    corsExposedHeaders,
+		AllowCredentials: cfg.CORSAllowCredentials,
+		MaxAge:           time.Duration(cfg.CORSMaxAgeSeconds) * time.Second,
+	}
+
+	for _, origin := range cfg.CORSAllowedOrigins {
+		if origin == "*" {
+			corsConfig.AllowAllOrigins = true
+		}
+	}
+	if corsConfig.AllowAllOrigins {
+		// Browsers reject credentialed responses allowed for any origin
+		if corsConfig.AllowCredentials {
+			log.Println("Warning: CORS_ALLOW_CREDENTIALS is ignored when CORS_ALLOWED_ORIGINS is *")
+			corsConfig.AllowCredentials = false
+		}
+	} else {
+		corsConfig.AllowOrigins = cfg.CORSAllowedOrigins
+	}
+
+	if err := corsConfig.Validate(); err != nil {
+		return nil, err
+	}
+	return cors.New(corsConfig), nil
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"agentic-template/api/db"
+
+	"github.com/gin-gonic/gin"
+)
+
+// poolSaturationThreshold is the share of a pool's connections in use at
+// which it is reported as saturated
+const poolSaturationThreshold = 0.9
+
+// DatabaseHealthResponse represents the deep database health response
+type DatabaseHealthResponse struct {
+	HealthResponse
+	Database DependencyCheck `json:"database"`
+	Pools    []db.PoolStats  `json:"pools"`
+}
+
+// DatabaseHealthHandler reports database reachability and pool usage
+type DatabaseHealthHandler struct {
+	dbManager *db.Manager
+}
+
+// NewDatabaseHealthHandler creates a new database health handler
+func NewDatabaseHealthHandler(dbManager *db.Manager) *DatabaseHealthHandler {
+	return &DatabaseHealthHandler{dbManager: dbManager}
+}
+
+// Check handles GET /health/database. It responds 503 when the database is
+// unreachable and reports "saturated" while a pool has nearly all of its
+// connections in use.
+func (h *DatabaseHealthHandler) Check(c *gin.Context) {
+	response := DatabaseHealthResponse{
+		HealthResponse: HealthResponse{
+			Status:    "healthy",
+			Timestamp: time.Now().UTC(),
+			Service:   "agentic-template-api",
+			Version:   "1.0.0",
+		},
+		Database: runCheck(c.Request.Context(), h.dbManager.Health),
+		Pools:    h.dbManager.PoolStats(),
+	}
+
+	for _, pool := range response.Pools {
+		if pool.Utilization >= poolSaturationThreshold {
+			response.Status = "saturated"
+		}
+	}
+
+	code := http.StatusOK
+	if response.Database.Status != CheckOK {
+		response.Status = "unhealthy"
+		code = http.StatusServiceUnavailable
+	}
+	c.JSON(code, response)
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"expvar"
+	"net/http/pprof"
+	"runtime"
+	"sync"
+	"time"
+
+	"agentic-template/api/db"
+
+	"github.com/gin-gonic/gin"
+)
+
+// publishOnce guards expvar.Publish, which panics on duplicate names
+var publishOnce sync.Once
+
+// GCStats summarizes garbage collector activity for /debug/vars
+type GCStats struct {
+	NumGC          uint32    `json:"num_gc"`
+	PauseTotalNS   uint64    `json:"pause_total_ns"`
+	LastPauseNS    uint64    `json:"last_pause_ns"`
+	LastGC         time.Time `json:"last_gc"`
+	NextGCBytes    uint64    `json:"next_gc_bytes"`
+	HeapAllocBytes uint64    `json:"heap_alloc_bytes"`
+	GCCPUFraction  float64   `json:"gc_cpu_fraction"`
+}
+
+// PoolStats reports database connection pool usage for /debug/vars
+type PoolStats struct {
+	MaxConns                int32 `json:"max_conns"`
+	TotalConns              int32 `json:"total_conns"`
+	AcquiredConns           int32 `json:"acquired_conns"`
+	IdleConns               int32 `json:"idle_conns"`
+	ConstructingConns       int32 `json:"constructing_conns"`
+	AcquireCount            int64 `json:"acquire_count"`
+	AcquireDurationMS       int64 `json:"acquire_duration_ms"`
+	EmptyAcquireCount       int64 `json:"empty_acquire_count"`
+	CanceledAcquireCount    int64 `json:"canceled_acquire_count"`
+	NewConnsCount           int64 `json:"new_conns_count"`
+	MaxLifetimeDestroyCount int64 `json:"max_lifetime_destroy_count"`
+	MaxIdleDestroyCount     int64 `json:"max_idle_destroy_count"`
+}
+
+// RegisterDebugRoutes mounts the net/http/pprof profiles under /pprof and
+// runtime variables (goroutines, GC, and pool stats alongside expvar's
+// memstats and cmdline) under /vars of the given group
+func RegisterDebugRoutes(debug *gin.RouterGroup, dbManager *db.Manager) {
+	publishOnce.Do(func() {
+		expvar.Publish("goroutines", expvar.Func(func() any {
+			return runtime.NumGoroutine()
+		}))
+		expvar.Publish("gc", expvar.Func(func() any {
+			return gcStats()
+		}))
+		expvar.Publish("pgxpool", expvar.Func(func() any {
+			return poolStats(dbManager)
+		}))
+	})
+
+	debug.GET("/vars", gin.WrapH(expvar.Handler()))
+
+	debug.GET("/pprof/", gin.WrapF(pprof.Index))
+	debug.GET("/pprof/cmdline", gin.WrapF(pprof.Cmdline))
+	debug.GET("/pprof/profile", gin.WrapF(pprof.Profile))
+	debug.POST("/pprof/symbol", gin.WrapF(pprof.Symbol))
+	debug.GET("/pprof/symbol", gin.WrapF(pprof.Symbol))
+	debug.GET("/pprof/trace", gin.WrapF(pprof.Trace))
+	for _, profile := range []string{"allocs", "block", "goroutine", "heap", "mutex", "threadcreate"} {
+		debug.GET("/pprof/"+profile, gin.WrapH(pprof.Handler(profile)))
+	}
+}
+
+// gcStats reads the current garbage collector statistics
+func gcStats() GCStats {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	stats := GCStats{
+		NumGC:          mem.NumGC,
+		PauseTotalNS:   mem.PauseTotalNs,
+		NextGCBytes:    mem.NextGC,
+		HeapAllocBytes: mem.HeapAlloc,
+		GCCPUFraction:  mem.GCCPUFraction,
+	}
+	if mem.NumGC > 0 {
+		stats.LastPauseNS = mem.PauseNs[(mem.NumGC+255)%256]
+		stats.LastGC = time.Unix(0, int64(mem.LastGC)).UTC()
+	}
+	return stats
+}
+
+// poolStats reads the current pool's statistics, or nil when the database
+// is not connected
+func poolStats(dbManager *db.Manager) *PoolStats {
+	pool := dbManager.GetPool()
+	if pool == nil {
+		return nil
+	}
+
+	stat := pool.Stat()
+	return &PoolStats{
+		MaxConns:                stat.MaxConns(),
+		TotalConns:              stat.TotalConns(),
+		AcquiredConns:           stat.AcquiredConns(),
+		IdleConns:               stat.IdleConns(),
+		ConstructingConns:       stat.ConstructingConns(),
+		AcquireCount:            stat.AcquireCount(),
+		AcquireDurationMS:       stat.AcquireDuration().Milliseconds(),
+		EmptyAcquireCount:       stat.EmptyAcquireCount(),
+		CanceledAcquireCount:    stat.CanceledAcquireCount(),
+		NewConnsCount:           stat.NewConnsCount(),
+		MaxLifetimeDestroyCount: stat.MaxLifetimeDestroyCount(),
+		MaxIdleDestroyCount:     stat.MaxIdleDestroyCount(),
+	}
+}
@@ -1,12 +1,31 @@
 package handlers
 
 import (
+	"context"
+	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
+	"agentic-template/api/agent"
+	"agentic-template/api/audit"
+	"agentic-template/api/config"
+	"agentic-template/api/db"
+	"agentic-template/api/queue"
+
 	"github.com/gin-gonic/gin"
 )
 
+// Health thresholds
+const (
+	// jobQueueStallThreshold is how long a due job may wait before the job
+	// queue is reported as stalled
+	jobQueueStallThreshold = 15 * time.Minute
+	// webhookFailureThreshold is how many consecutive failed exports mark
+	// a sink as failing
+	webhookFailureThreshold = 3
+)
+
 // HealthResponse represents the health check response
 type HealthResponse struct {
 	Status    string    `json:"status"`
@@ -15,27 +34,161 @@ type HealthResponse struct {
 	Version   string    `json:"version"`
 }
 
-// HealthCheck handles the health check endpoint
-func HealthCheck(c *gin.Context) {
-	response := HealthResponse{
-		Status:    "healthy",
-		Timestamp: time.Now().UTC(),
-		Service:   "agentic-template-api",
-		Version:   "1.0.0",
+// DeepHealthResponse represents the health check response with the status
+// of each component
+type DeepHealthResponse struct {
+	HealthResponse
+	Components map[string]DependencyCheck `json:"components"`
+}
+
+// HealthHandler reports the status of each component the API depends on
+type HealthHandler struct {
+	dbManager *db.Manager
+	config    *config.Config
+	jobQueue  *queue.Queue
+	exporter  *audit.Exporter // Dispatches audit webhooks; nil when no sinks are configured
+	llm       *llmPinger      // nil unless HEALTH_LLM_PING is set
+}
+
+// NewHealthHandler creates a new health handler; exporter is nil when no
+// audit sinks are configured
+func NewHealthHandler(dbManager *db.Manager, cfg *config.Config, jobQueue *queue.Queue, exporter *audit.Exporter) *HealthHandler {
+	h := &HealthHandler{
+		dbManager: dbManager,
+		config:    cfg,
+		jobQueue:  jobQueue,
+		exporter:  exporter,
 	}
+	if cfg.HealthLLMPing {
+		h.llm = newLLMPinger()
+	}
+	return h
+}
+
+// Check handles GET /health. It responds 503 when any component failed;
+// components that aren't configured are reported as disabled.
+func (h *HealthHandler) Check(c *gin.Context) {
+	ctx := c.Request.Context()
+	components := map[string]DependencyCheck{
+		"database":           runCheck(ctx, h.dbManager.Health),
+		"migrations":         runCheck(ctx, h.checkMigrations),
+		"job_queue":          h.checkJobQueue(ctx),
+		"webhook_dispatcher": h.checkWebhooks(),
+	}
+	for provider, check := range h.checkLLMProviders(ctx) {
+		components["llm_"+provider] = check
+	}
+
+	response := DeepHealthResponse{
+		HealthResponse: HealthResponse{
+			Status:    "healthy",
+			Timestamp: time.Now().UTC(),
+			Service:   "agentic-template-api",
+			Version:   "1.0.0",
+		},
+		Components: components,
+	}
+
+	code := http.StatusOK
+	for _, check := range components {
+		if check.Status == CheckFailed {
+			response.Status = "unhealthy"
+			code = http.StatusServiceUnavailable
+		}
+	}
+	c.JSON(code, response)
+}
 
-	c.JSON(http.StatusOK, response)
+// checkMigrations verifies every embedded migration has been applied
+func (h *HealthHandler) checkMigrations(ctx context.Context) error {
+	return checkMigrations(ctx, h.dbManager)
 }
 
-// ReadinessCheck handles the readiness check endpoint
-func ReadinessCheck(c *gin.Context) {
-	// Add database connectivity check or other readiness checks here
-	response := HealthResponse{
-		Status:    "ready",
-		Timestamp: time.Now().UTC(),
-		Service:   "agentic-template-api",
-		Version:   "1.0.0",
+// checkJobQueue counts the background job backlog. It fails when a due job
+// has waited longer than jobQueueStallThreshold, since workers should have
+// claimed it.
+func (h *HealthHandler) checkJobQueue(ctx context.Context) DependencyCheck {
+	if h.dbManager.Local() != nil {
+		return disabledCheck("requires PostgreSQL")
 	}
 
-	c.JSON(http.StatusOK, response)
-}
\ No newline at end of file
+	var stats *queue.Stats
+	result := runCheck(ctx, func(ctx context.Context) error {
+		var err error
+		stats, err = h.jobQueue.Stats(ctx)
+		if err != nil {
+			return err
+		}
+		if stats.OldestDue != nil && time.Since(*stats.OldestDue) > jobQueueStallThreshold {
+			return fmt.Errorf("stalled: %d due job(s), oldest waiting %s", stats.Due, time.Since(*stats.OldestDue).Round(time.Second))
+		}
+		return nil
+	})
+	if result.Status == CheckOK {
+		result.Detail = fmt.Sprintf("%d due, %d running, %d dead", stats.Due, stats.Running, stats.Dead)
+	}
+	return result
+}
+
+// checkWebhooks reports the audit export sinks, failing when any has
+// failed webhookFailureThreshold exports in a row
+func (h *HealthHandler) checkWebhooks() DependencyCheck {
+	if h.exporter == nil {
+		return disabledCheck("no audit export sinks configured")
+	}
+
+	var failing []string
+	statuses := h.exporter.Status()
+	for _, status := range statuses {
+		if status.Failures >= webhookFailureThreshold {
+			failing = append(failing, fmt.Sprintf("%s: %d failed exports, last: %s", status.Sink, status.Failures, status.LastError))
+		}
+	}
+	if len(failing) > 0 {
+		return DependencyCheck{Status: CheckFailed, Detail: strings.Join(failing, "; ")}
+	}
+	return DependencyCheck{Status: CheckOK, Detail: fmt.Sprintf("%d sink(s)", len(statuses))}
+}
+
+// checkLLMProviders reports each LLM provider. Providers without an API key
+// are disabled; the others are pinged when HEALTH_LLM_PING is set, and fail
+// while their circuit breaker is open.
+func (h *HealthHandler) checkLLMProviders(ctx context.Context) map[string]DependencyCheck {
+	keys := map[string]string{
+		llmOpenAI:    h.config.OpenAIAPIKey,
+		llmAnthropic: h.config.AnthropicAPIKey,
+	}
+	breakers := map[string]agent.BreakerState{}
+	for _, state := range agent.BreakerStates() {
+		breakers[state.Provider] = state
+	}
+
+	checks := make(map[string]DependencyCheck, len(keys))
+	for provider, key := range keys {
+		breaker, called := breakers[provider]
+		switch {
+		case key == "":
+			checks[provider] = disabledCheck("no API key set")
+		case called && breaker.State == agent.BreakerOpen:
+			checks[provider] = DependencyCheck{Status: CheckFailed, Detail: fmt.Sprintf(
+				"circuit breaker open after %d failures (last: %s); retry at %s",
+				breaker.Failures, breaker.LastError, breaker.RetryAt.Format(time.RFC3339))}
+		case h.llm == nil:
+			checks[provider] = DependencyCheck{Status: CheckOK, Detail: "API key set; ping disabled"}
+		default:
+			checks[provider] = runCheck(ctx, func(ctx context.Context) error {
+				return h.llm.ping(ctx, provider, key)
+			})
+		}
+		if check := checks[provider]; called && breaker.State == agent.BreakerHalfOpen {
+			check.Detail = strings.TrimPrefix(check.Detail+"; circuit breaker half open", "; ")
+			checks[provider] = check
+		}
+	}
+	return checks
+}
+
+// disabledCheck reports a component that isn't configured
+func disabledCheck(detail string) DependencyCheck {
+	return DependencyCheck{Status: CheckDisabled, Detail: detail}
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"agentic-template/api/agent/tooluse"
+)
+
+// LLM providers reported by /health
+const (
+	llmOpenAI    = "openai"
+	llmAnthropic = "anthropic"
+)
+
+// openAIModelsURL lists OpenAI models, a cheap authenticated request
+const openAIModelsURL = "https://api.openai.com/v1/models"
+
+// llmPingTTL is how long a ping result is reused, so frequent health probes
+// don't call the providers on every request
+const llmPingTTL = time.Minute
+
+// llmPingResult is the cached result of pinging a provider
+type llmPingResult struct {
+	err error
+	at  time.Time
+}
+
+// llmPinger checks LLM providers accept their API keys by listing models
+type llmPinger struct {
+	client *http.Client
+
+	mu      sync.Mutex
+	results map[string]llmPingResult
+}
+
+// newLLMPinger creates a pinger with an empty cache
+func newLLMPinger() *llmPinger {
+	return &llmPinger{
+		client:  &http.Client{},
+		results: map[string]llmPingResult{},
+	}
+}
+
+// ping lists the models of a provider, reusing a result younger than
+// llmPingTTL
+func (p *llmPinger) ping(ctx context.Context, provider, apiKey string) error {
+	p.mu.Lock()
+	cached, ok := p.results[provider]
+	p.mu.Unlock()
+	if ok && time.Since(cached.at) < llmPingTTL {
+		return cached.err
+	}
+
+	err := p.listModels(ctx, provider, apiKey)
+	p.mu.Lock()
+	p.results[provider] = llmPingResult{err: err, at: time.Now()}
+	p.mu.Unlock()
+	return err
+}
+
+// listModels sends an authenticated models request to a provider
+func (p *llmPinger) listModels(ctx context.Context, provider, apiKey string) error {
+	var req *http.Request
+	var err error
+	switch provider {
+	case llmOpenAI:
+		req, err = http.NewRequestWithContext(ctx, http.MethodGet, openAIModelsURL, nil)
+		if err == nil {
+			req.Header.Set("Authorization", "Bearer "+apiKey)
+		}
+	case llmAnthropic:
+		req, err = http.NewRequestWithContext(ctx, http.MethodGet, tooluse.DefaultAnthropicBaseURL+"/models", nil)
+		if err == nil {
+			req.Header.Set("x-api-key", apiKey)
+			req.Header.Set("anthropic-version", tooluse.AnthropicVersion)
+		}
+	default:
+		return fmt.Errorf("unknown provider %s", provider)
+	}
+	if err != nil {
+		return err
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("models request returned %s", resp.Status)
+	}
+	return nil
+}
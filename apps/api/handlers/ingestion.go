@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"agentic-template/api/ingestion"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IngestionHandler exposes document ingestion over HTTP
+type IngestionHandler struct {
+	service *ingestion.Service
+}
+
+// NewIngestionHandler creates a new ingestion handler
+func NewIngestionHandler(service *ingestion.Service) *IngestionHandler {
+	return &IngestionHandler{
+		service: service,
+	}
+}
+
+// ErrorResponse represents an error returned by the HTTP API
+type ErrorResponse struct {
+	Error string `json:"error"`
+	Field string `json:"field,omitempty"` // Request field that failed validation
+}
+
+// IngestDocument handles POST /api/knowledge/documents
+func (h *IngestionHandler) IngestDocument(c *gin.Context) {
+	var req ingestion.IngestRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	job, err := h.service.Submit(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, job)
+}
+
+// GetJob handles GET /api/knowledge/jobs/:id
+func (h *IngestionHandler) GetJob(c *gin.Context) {
+	jobID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid job id"})
+		return
+	}
+
+	job, err := h.service.GetJob(c.Request.Context(), jobID)
+	if errors.Is(err, ingestion.ErrJobNotFound) {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MaxBodySize returns middleware rejecting request bodies larger than limit
+// bytes. Declared lengths over the limit get 413 up front; chunked bodies
+// fail to read past the limit.
+func MaxBodySize(limit int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.ContentLength > limit {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, ErrorResponse{
+				Error: fmt.Sprintf("request body exceeds %d bytes", limit),
+			})
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		c.Next()
+	}
+}
+
+// bindJSON decodes the request body into obj, responding 413 when the body
+// is over MaxBodySize's limit and 400 when it is otherwise invalid
+func bindJSON(c *gin.Context, obj any) bool {
+	err := c.ShouldBindJSON(obj)
+	if err == nil {
+		return true
+	}
+
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		c.JSON(http.StatusRequestEntityTooLarge, ErrorResponse{
+			Error: fmt.Sprintf("request body exceeds %d bytes", tooLarge.Limit),
+		})
+		return false
+	}
+	c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	return false
+}
+
+// WithoutWriteTimeout clears the server's write deadline for requests to
+// the given paths, such as response streams and profiles that outlive the
+// server's WriteTimeout. It must wrap the handler passed to http.Server,
+// where the connection's deadline is still reachable.
+func WithoutWriteTimeout(next http.Handler, paths ...string) http.Handler {
+	exempt := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		exempt[path] = true
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if exempt[r.URL.Path] {
+			_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+		}
+		next.ServeHTTP(w, r)
+	})
+}
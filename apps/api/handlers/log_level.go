@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"agentic-template/api/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetLogLevelRequest changes the log level. Duration is how long the level
+// lasts, such as "30m", before reverting; "0" makes it the new base level.
+// It defaults to logging.DefaultLevelRevert.
+type SetLogLevelRequest struct {
+	Level    string `json:"level" binding:"required"`
+	Duration string `json:"duration"`
+}
+
+// LogLevelHandler reads and changes the log level at runtime
+type LogLevelHandler struct {
+	level *logging.Level
+}
+
+// NewLogLevelHandler creates a new log level handler
+func NewLogLevelHandler(level *logging.Level) *LogLevelHandler {
+	return &LogLevelHandler{level: level}
+}
+
+// Get handles GET /api/admin/log-level
+func (h *LogLevelHandler) Get(c *gin.Context) {
+	c.JSON(http.StatusOK, h.level.Status())
+}
+
+// Set handles PUT /api/admin/log-level
+func (h *LogLevelHandler) Set(c *gin.Context) {
+	var req SetLogLevelRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	level, ok := logging.LookupLevel(req.Level)
+	if !ok {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "level must be debug, info, warn, or error", Field: "level"})
+		return
+	}
+	duration := logging.DefaultLevelRevert
+	if req.Duration != "" {
+		parsed, err := time.ParseDuration(req.Duration)
+		if err != nil || parsed < 0 {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "duration must be a duration such as 30m, or 0", Field: "duration"})
+			return
+		}
+		duration = parsed
+	}
+
+	h.level.Set(level, duration)
+	slog.Warn("log level changed", "level", logging.LevelName(level), "duration", duration.String())
+	c.JSON(http.StatusOK, h.level.Status())
+}
+
+// Reset handles DELETE /api/admin/log-level, reverting to the base level
+func (h *LogLevelHandler) Reset(c *gin.Context) {
+	h.level.Reset()
+	c.JSON(http.StatusOK, h.level.Status())
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"agentic-template/api/config"
+	"agentic-template/api/db"
+	"agentic-template/api/db/migrations"
+
+	"github.com/gin-gonic/gin"
+)
+
+// readinessCheckTimeout bounds each dependency check
+const readinessCheckTimeout = 3 * time.Second
+
+// Dependency check statuses
+const (
+	CheckOK       = "ok"
+	CheckFailed   = "failed"
+	CheckDisabled = "disabled" // Not configured; doesn't fail /health
+)
+
+// DependencyCheck is the result of checking one dependency
+type DependencyCheck struct {
+	Status     string `json:"status"` // ok, failed, or disabled
+	Detail     string `json:"detail,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+}
+
+// ReadinessResponse represents the readiness check response
+type ReadinessResponse struct {
+	HealthResponse
+	Checks map[string]DependencyCheck `json:"checks"`
+}
+
+// ReadinessHandler reports whether the API can serve traffic
+type ReadinessHandler struct {
+	dbManager *db.Manager
+	config    *config.Config
+}
+
+// NewReadinessHandler creates a new readiness handler
+func NewReadinessHandler(dbManager *db.Manager, cfg *config.Config) *ReadinessHandler {
+	return &ReadinessHandler{
+		dbManager: dbManager,
+		config:    cfg,
+	}
+}
+
+// Check handles GET /ready. It responds 503 with the failed checks when the
+// database is unreachable, migrations are pending, or no LLM key is set.
+func (h *ReadinessHandler) Check(c *gin.Context) {
+	ctx := c.Request.Context()
+	checks := map[string]DependencyCheck{
+		"database":   runCheck(ctx, h.checkDatabase),
+		"migrations": runCheck(ctx, h.checkMigrations),
+		"llm":        runCheck(ctx, h.checkLLM),
+	}
+
+	response := ReadinessResponse{
+		HealthResponse: HealthResponse{
+			Status:    "ready",
+			Timestamp: time.Now().UTC(),
+			Service:   "agentic-template-api",
+			Version:   "1.0.0",
+		},
+		Checks: checks,
+	}
+
+	code := http.StatusOK
+	for _, check := range checks {
+		if check.Status != CheckOK {
+			response.Status = "not_ready"
+			code = http.StatusServiceUnavailable
+		}
+	}
+
+	c.JSON(code, response)
+}
+
+// runCheck runs a dependency check with a timeout and records its duration
+func runCheck(ctx context.Context, check func(ctx context.Context) error) DependencyCheck {
+	ctx, cancel := context.WithTimeout(ctx, readinessCheckTimeout)
+	defer cancel()
+
+	started := time.Now()
+	err := check(ctx)
+	result := DependencyCheck{Status: CheckOK, DurationMS: time.Since(started).Milliseconds()}
+	if err != nil {
+		result.Status = CheckFailed
+		result.Detail = err.Error()
+	}
+	return result
+}
+
+// checkDatabase pings the current database connection
+func (h *ReadinessHandler) checkDatabase(ctx context.Context) error {
+	return h.dbManager.Health(ctx)
+}
+
+// checkMigrations verifies every embedded migration has been applied
+func (h *ReadinessHandler) checkMigrations(ctx context.Context) error {
+	return checkMigrations(ctx, h.dbManager)
+}
+
+// checkMigrations verifies every embedded migration has been applied. The
+// local SQLite database has its schema applied when opened instead.
+func checkMigrations(ctx context.Context, dbManager *db.Manager) error {
+	if dbManager.Local() != nil {
+		return nil
+	}
+	pool := dbManager.GetPool()
+	if pool == nil {
+		return fmt.Errorf("database not connected")
+	}
+
+	pending, err := migrations.Pending(ctx, pool)
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		names := make([]string, len(pending))
+		for i, migration := range pending {
+			names[i] = migration.Name
+		}
+		return fmt.Errorf("%d pending migration(s): %s", len(pending), strings.Join(names, ", "))
+	}
+	return nil
+}
+
+// checkLLM verifies an API key is configured for the default LLM provider
+func (h *ReadinessHandler) checkLLM(context.Context) error {
+	if h.config.OpenAIAPIKey == "" {
+		return fmt.Errorf("OPENAI_API_KEY is not set")
+	}
+	return nil
+}
@@ -0,0 +1,238 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"agentic-template/api/db"
+	"agentic-template/api/schema_manager"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SchemaHandler exposes table management over HTTP for clients that don't
+// speak gRPC
+type SchemaHandler struct {
+	dbManager *db.Manager
+}
+
+// NewSchemaHandler creates a new schema handler
+func NewSchemaHandler(dbManager *db.Manager) *SchemaHandler {
+	return &SchemaHandler{
+		dbManager: dbManager,
+	}
+}
+
+// getSchemaManager returns a schema manager on the current database,
+// reading from a replica when one is configured
+func (h *SchemaHandler) getSchemaManager() *schema_manager.SchemaManager {
+	return schema_manager.FromManager(h.dbManager)
+}
+
+// CreateTable handles POST /api/schema/tables. ?override_quota=true lets
+// operators create tables past the quotas. Non-admins' tables wait for
+// approval when the approval workflow is on, answered with 202 and the
+// change request.
+func (h *SchemaHandler) CreateTable(c *gin.Context) {
+	var req schema_manager.CreateTableRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	ctx := c.Request.Context()
+	if c.Query("override_quota") == "true" {
+		ctx = schema_manager.WithQuotaOverride(ctx)
+	}
+	if schema_manager.NeedsApproval(ctx) {
+		change, err := h.getSchemaManager().RequestCreateTable(ctx, req)
+		if err != nil {
+			writeSchemaError(c, err)
+			return
+		}
+		c.JSON(http.StatusAccepted, gin.H{"change_request": change})
+		return
+	}
+	table, err := h.getSchemaManager().CreateTable(ctx, req)
+	if err != nil {
+		writeSchemaError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, table)
+}
+
+// GetTable handles GET /api/schema/tables/:id
+func (h *SchemaHandler) GetTable(c *gin.Context) {
+	tableID, ok := tableIDParam(c)
+	if !ok {
+		return
+	}
+
+	table, err := h.getSchemaManager().GetTable(c.Request.Context(), tableID)
+	if err != nil {
+		writeSchemaError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, table)
+}
+
+// ListTables handles GET /api/schema/tables. It accepts the page_size,
+// page_token, name_prefix, and sort query parameters.
+func (h *SchemaHandler) ListTables(c *gin.Context) {
+	opts := schema_manager.ListTablesOptions{
+		PageToken:  c.Query("page_token"),
+		NamePrefix: c.Query("name_prefix"),
+		Sort:       schema_manager.TableSort(c.Query("sort")),
+	}
+	if pageSize := c.Query("page_size"); pageSize != "" {
+		size, err := strconv.Atoi(pageSize)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "page_size must be an integer", Field: "page_size"})
+			return
+		}
+		opts.PageSize = size
+	}
+
+	page, err := h.getSchemaManager().ListTables(c.Request.Context(), opts)
+	if err != nil {
+		writeSchemaError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// SearchSchema handles GET /api/schema/search. It accepts the query and
+// limit query parameters.
+func (h *SchemaHandler) SearchSchema(c *gin.Context) {
+	req := schema_manager.SchemaSearchRequest{Query: c.Query("query")}
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "limit must be an integer", Field: "limit"})
+			return
+		}
+		req.Limit = n
+	}
+
+	matches, err := h.getSchemaManager().SearchSchema(c.Request.Context(), req)
+	if err != nil {
+		writeSchemaError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"matches": matches})
+}
+
+// DeleteTable handles DELETE /api/schema/tables/:id. Non-admins'
+// deletions wait for approval when the approval workflow is on, answered
+// with 202 and the change request.
+func (h *SchemaHandler) DeleteTable(c *gin.Context) {
+	tableID, ok := tableIDParam(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	if schema_manager.NeedsApproval(ctx) {
+		change, err := h.getSchemaManager().RequestDeleteTable(ctx, tableID)
+		if err != nil {
+			writeSchemaError(c, err)
+			return
+		}
+		c.JSON(http.StatusAccepted, gin.H{"change_request": change})
+		return
+	}
+	if err := h.getSchemaManager().DeleteTable(ctx, tableID); err != nil {
+		writeSchemaError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListTrash handles GET /api/schema/trash
+func (h *SchemaHandler) ListTrash(c *gin.Context) {
+	tables, err := h.getSchemaManager().ListTrash(c.Request.Context())
+	if err != nil {
+		writeSchemaError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tables": tables})
+}
+
+// RestoreTable handles POST /api/schema/trash/:id/restore
+func (h *SchemaHandler) RestoreTable(c *gin.Context) {
+	tableID, ok := tableIDParam(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	sm := h.getSchemaManager()
+	if err := sm.RestoreTable(ctx, tableID); err != nil {
+		writeSchemaError(c, err)
+		return
+	}
+	table, err := sm.GetTable(ctx, tableID)
+	if err != nil {
+		writeSchemaError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, table)
+}
+
+// PurgeTable handles DELETE /api/schema/trash/:id
+func (h *SchemaHandler) PurgeTable(c *gin.Context) {
+	tableID, ok := tableIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.getSchemaManager().PurgeTable(c.Request.Context(), tableID); err != nil {
+		writeSchemaError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// tableIDParam parses the :id path parameter, responding 400 when it isn't
+// a table ID
+func tableIDParam(c *gin.Context) (int, bool) {
+	tableID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || tableID <= 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid table id", Field: "id"})
+		return 0, false
+	}
+	return tableID, true
+}
+
+// writeSchemaError responds with the HTTP status matching a schema manager
+// error
+func writeSchemaError(c *gin.Context, err error) {
+	var validationErr *schema_manager.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error(), Field: validationErr.Field})
+	case errors.Is(err, schema_manager.ErrTableExists), errors.Is(err, schema_manager.ErrTableReferenced):
+		c.JSON(http.StatusConflict, ErrorResponse{Error: err.Error()})
+	case errors.Is(err, schema_manager.ErrTableNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+	case errors.Is(err, schema_manager.ErrQuotaExceeded):
+		c.JSON(http.StatusTooManyRequests, ErrorResponse{Error: err.Error()})
+	case errors.Is(err, schema_manager.ErrQuotaOverrideDenied):
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: err.Error()})
+	case errors.Is(err, schema_manager.ErrDatabaseNotConfigured):
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: err.Error()})
+	case errors.Is(err, context.DeadlineExceeded):
+		c.JSON(http.StatusGatewayTimeout, ErrorResponse{Error: err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+	}
+}
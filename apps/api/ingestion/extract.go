@@ -0,0 +1,232 @@
+package ingestion
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// Limits of fetching a remote URL
+const (
+	maxFetchSize      = 10 << 20 // Largest response body downloaded (10MB)
+	maxFetchRedirects = 5
+)
+
+// errBlockedAddress rejects URLs resolving to loopback, private, or
+// link-local addresses, so ingestion can't reach internal services
+var errBlockedAddress = errors.New("URL resolves to a private or internal address")
+
+// fetchClient downloads URLs for ingestion. Its dialer checks every
+// resolved address, including those of redirects, so DNS can't point a
+// public name at an internal host.
+var fetchClient = &http.Client{
+	Transport: &http.Transport{
+		Proxy: nil, // A proxy would hide the target address from the dialer
+		DialContext: (&net.Dialer{
+			Timeout: 10 * time.Second,
+			Control: func(network, address string, _ syscall.RawConn) error {
+				host, _, err := net.SplitHostPort(address)
+				if err != nil {
+					return err
+				}
+				addr, err := netip.ParseAddr(host)
+				if err != nil || blockedAddress(addr) {
+					return fmt.Errorf("%w: %s", errBlockedAddress, host)
+				}
+				return nil
+			},
+		}).DialContext,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: 15 * time.Second,
+	},
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if len(via) >= maxFetchRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxFetchRedirects)
+		}
+		return checkFetchURL(req.Context(), req.URL)
+	},
+}
+
+// Regex patterns for stripping markup
+var (
+	scriptStylePattern  = regexp.MustCompile(`(?is)<(script|style)[^>]*>.*?</(script|style)>`)
+	htmlTagPattern      = regexp.MustCompile(`(?s)<[^>]+>`)
+	blankLinesPattern   = regexp.MustCompile(`\n{3,}`)
+	markdownLinkPattern = regexp.MustCompile(`!?\[([^\]]*)\]\([^)]*\)`)
+	markdownHeadPattern = regexp.MustCompile(`(?m)^#{1,6}\s+`)
+	markdownEmphPattern = regexp.MustCompile(`(\*\*|__|\*|_|~~|` + "`" + `)`)
+)
+
+// extractText converts the request payload to plain text for chunking
+func extractText(ctx context.Context, req IngestRequest) (string, string, error) {
+	switch req.SourceType {
+	case SourceTypeText:
+		return req.Content, "text/plain", nil
+	case SourceTypeMarkdown:
+		return stripMarkdown(req.Content), "text/markdown", nil
+	case SourceTypePDF:
+		text, err := extractPDF(req.Data)
+		return text, "application/pdf", err
+	case SourceTypeURL:
+		return fetchURL(ctx, req.URL)
+	default:
+		return "", "", fmt.Errorf("unsupported source type: %s", req.SourceType)
+	}
+}
+
+// stripMarkdown removes common markdown syntax while keeping the text
+func stripMarkdown(content string) string {
+	content = markdownLinkPattern.ReplaceAllString(content, "$1")
+	content = markdownHeadPattern.ReplaceAllString(content, "")
+	content = markdownEmphPattern.ReplaceAllString(content, "")
+	return content
+}
+
+// extractPDF reads the plain text of every page of a PDF
+func extractPDF(data []byte) (string, error) {
+	if len(data) == 0 {
+		return "", fmt.Errorf("PDF data is empty")
+	}
+
+	reader, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", fmt.Errorf("failed to open PDF: %w", err)
+	}
+
+	textReader, err := reader.GetPlainText()
+	if err != nil {
+		return "", fmt.Errorf("failed to extract PDF text: %w", err)
+	}
+
+	text, err := io.ReadAll(textReader)
+	if err != nil {
+		return "", fmt.Errorf("failed to read PDF text: %w", err)
+	}
+
+	return string(text), nil
+}
+
+// blockedAddress reports whether an address is internal: loopback,
+// private, link-local, unspecified, or multicast
+func blockedAddress(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() ||
+		addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() ||
+		addr.IsUnspecified() || addr.IsMulticast()
+}
+
+// checkFetchURL rejects URLs that aren't http(s) or whose host resolves to
+// an internal address
+func checkFetchURL(ctx context.Context, u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("invalid URL: %s", u.Redacted())
+	}
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", u.Hostname())
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", u.Hostname(), err)
+	}
+	for _, addr := range addrs {
+		if blockedAddress(addr) {
+			return fmt.Errorf("%w: %s", errBlockedAddress, u.Hostname())
+		}
+	}
+	return nil
+}
+
+// fetchURL downloads a web page and returns its text content
+func fetchURL(ctx context.Context, rawURL string) (string, string, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return "", "", fmt.Errorf("invalid URL: %s", rawURL)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	if err := checkFetchURL(ctx, parsed); err != nil {
+		return "", "", err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, parsed.String(), nil)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to build request: %w", err)
+	}
+
+	resp, err := fetchClient.Do(httpReq)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to fetch URL: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", "", fmt.Errorf("failed to fetch URL: status %d", resp.StatusCode)
+	}
+
+	if resp.ContentLength > maxFetchSize {
+		return "", "", fmt.Errorf("response of %d bytes exceeds the %d byte limit", resp.ContentLength, maxFetchSize)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxFetchSize+1))
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read response body: %w", err)
+	}
+	if len(body) > maxFetchSize {
+		return "", "", fmt.Errorf("response exceeds the %d byte limit", maxFetchSize)
+	}
+
+	return extractContent(body, resp.Header.Get("Content-Type"))
+}
+
+// ExtractFile returns the plain text of a file: PDF, HTML, markdown, or any
+// other UTF-8 text such as CSV or JSON
+func ExtractFile(data []byte, mimeType string) (string, error) {
+	if !strings.Contains(mimeType, "application/pdf") && !utf8.Valid(data) {
+		return "", fmt.Errorf("unsupported file type: %s", mimeType)
+	}
+	text, _, err := extractContent(data, mimeType)
+	return text, err
+}
+
+// extractContent converts a document body to plain text by its content
+// type, returning the type it was read as
+func extractContent(body []byte, contentType string) (string, string, error) {
+	switch {
+	case strings.Contains(contentType, "application/pdf"):
+		text, err := extractPDF(body)
+		return text, "application/pdf", err
+	case strings.Contains(contentType, "text/html"):
+		return stripHTML(string(body)), "text/html", nil
+	case strings.Contains(contentType, "text/markdown"):
+		return stripMarkdown(string(body)), "text/markdown", nil
+	default:
+		return string(body), "text/plain", nil
+	}
+}
+
+// stripHTML removes tags, scripts, and styles from an HTML document
+func stripHTML(content string) string {
+	content = scriptStylePattern.ReplaceAllString(content, "")
+	content = htmlTagPattern.ReplaceAllString(content, "\n")
+	content = html.UnescapeString(content)
+
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	content = strings.Join(lines, "\n")
+
+	return blankLinesPattern.ReplaceAllString(content, "\n\n")
+}
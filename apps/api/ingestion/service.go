@@ -0,0 +1,254 @@
+package ingestion
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"agentic-template/api/db"
+	"agentic-template/api/embeddings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// processTimeout bounds how long a single ingestion job may run
+const processTimeout = 10 * time.Minute
+
+// failTimeout bounds recording a failure, which happens even after the
+// job's context is cancelled
+const failTimeout = 5 * time.Second
+
+// ErrJobNotFound is returned when an ingestion job does not exist
+var ErrJobNotFound = errors.New("ingestion job not found")
+
+// Service ingests documents into the RAG store and tracks ingestion jobs
+type Service struct {
+	dbManager *db.Manager
+	embedder  embeddings.Embedder
+	topK      int
+
+	// Jobs in flight, cancelled by Shutdown once its deadline passes
+	jobs       sync.WaitGroup
+	jobsCtx    context.Context
+	cancelJobs context.CancelFunc
+}
+
+// NewService creates a new ingestion service
+// embedder may be nil when no embedding provider is configured
+func NewService(dbManager *db.Manager, embedder embeddings.Embedder, topK int) *Service {
+	jobsCtx, cancelJobs := context.WithCancel(context.Background())
+	return &Service{
+		dbManager:  dbManager,
+		embedder:   embedder,
+		topK:       topK,
+		jobsCtx:    jobsCtx,
+		cancelJobs: cancelJobs,
+	}
+}
+
+// Shutdown waits for the jobs in flight to finish. When ctx is done first
+// they are cancelled and recorded as failed.
+func (s *Service) Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.jobs.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.cancelJobs()
+		return fmt.Errorf("ingestion jobs cancelled: %w", ctx.Err())
+	}
+}
+
+// Pipeline returns an embedding pipeline bound to the current database pool
+func (s *Service) Pipeline() *embeddings.Pipeline {
+	if s.embedder == nil {
+		return nil
+	}
+	return embeddings.NewPipeline(embeddings.NewStore(s.dbManager.GetPool()), s.embedder, s.topK)
+}
+
+// Submit validates the request, records a pending job, and processes it
+// in the background. The returned job can be polled with GetJob.
+func (s *Service) Submit(ctx context.Context, req IngestRequest) (*Job, error) {
+	if s.embedder == nil {
+		return nil, fmt.Errorf("embeddings not configured - please add an embedding provider API key")
+	}
+	if s.dbManager.GetPool() == nil {
+		return nil, fmt.Errorf("database not configured - please add DATABASE_URL_POOLED in Environment Settings")
+	}
+
+	if err := validateRequest(req); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	var source *string
+	if req.URL != "" {
+		source = &req.URL
+	} else if name, ok := req.Metadata["file_name"]; ok {
+		source = &name
+	}
+
+	job := &Job{
+		Title:      req.Title,
+		SourceType: req.SourceType,
+		Source:     source,
+		Status:     StatusPending,
+	}
+
+	insertQuery := `
+		INSERT INTO ingestion_jobs (title, source_type, source, status)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at, updated_at
+	`
+	err := s.dbManager.GetPool().QueryRow(ctx, insertQuery, job.Title, job.SourceType, job.Source, job.Status).
+		Scan(&job.ID, &job.CreatedAt, &job.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ingestion job: %w", err)
+	}
+
+	// Process outside the request lifecycle so clients can disconnect and poll
+	s.jobs.Add(1)
+	go func() {
+		defer s.jobs.Done()
+		s.process(job.ID, req)
+	}()
+
+	return job, nil
+}
+
+// process extracts, chunks, embeds, and stores the document for a job
+func (s *Service) process(jobID int, req IngestRequest) {
+	ctx, cancel := context.WithTimeout(s.jobsCtx, processTimeout)
+	defer cancel()
+
+	if err := s.updateStatus(ctx, jobID, StatusProcessing); err != nil {
+		log.Printf("Warning: failed to mark ingestion job %d as processing: %v", jobID, err)
+	}
+
+	text, contentType, err := extractText(ctx, req)
+	if err != nil {
+		s.fail(ctx, jobID, err)
+		return
+	}
+
+	doc := embeddings.Document{
+		Title:       req.Title,
+		ContentType: contentType,
+		Metadata:    req.Metadata,
+	}
+	if req.URL != "" {
+		doc.Source = &req.URL
+	}
+
+	saved, chunkCount, err := s.Pipeline().IngestText(ctx, doc, text)
+	if err != nil {
+		s.fail(ctx, jobID, err)
+		return
+	}
+
+	completeQuery := `
+		UPDATE ingestion_jobs
+		SET status = $2, document_id = $3, chunk_count = $4, completed_at = NOW()
+		WHERE id = $1
+	`
+	if _, err := s.dbManager.GetPool().Exec(ctx, completeQuery, jobID, StatusCompleted, saved.ID, chunkCount); err != nil {
+		log.Printf("Warning: failed to mark ingestion job %d as completed: %v", jobID, err)
+		return
+	}
+
+	log.Printf("Ingestion job %d completed: document %d with %d chunk(s)", jobID, saved.ID, chunkCount)
+}
+
+// fail records a job failure with its error message
+func (s *Service) fail(ctx context.Context, jobID int, cause error) {
+	log.Printf("Ingestion job %d failed: %v", jobID, cause)
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), failTimeout)
+	defer cancel()
+
+	failQuery := `
+		UPDATE ingestion_jobs
+		SET status = $2, error_message = $3, completed_at = NOW()
+		WHERE id = $1
+	`
+	if _, err := s.dbManager.GetPool().Exec(ctx, failQuery, jobID, StatusFailed, cause.Error()); err != nil {
+		log.Printf("Warning: failed to mark ingestion job %d as failed: %v", jobID, err)
+	}
+}
+
+// updateStatus sets the status of a job
+func (s *Service) updateStatus(ctx context.Context, jobID int, status string) error {
+	_, err := s.dbManager.GetPool().Exec(ctx, `UPDATE ingestion_jobs SET status = $2 WHERE id = $1`, jobID, status)
+	return err
+}
+
+// GetJob retrieves an ingestion job by ID
+func (s *Service) GetJob(ctx context.Context, jobID int) (*Job, error) {
+	pool := s.dbManager.GetPool()
+	if pool == nil {
+		return nil, fmt.Errorf("database not configured - please add DATABASE_URL_POOLED in Environment Settings")
+	}
+
+	var job Job
+	query := `
+		SELECT id, title, source_type, source, status, document_id, chunk_count,
+		       error_message, created_at, updated_at, completed_at
+		FROM ingestion_jobs
+		WHERE id = $1
+	`
+	err := pool.QueryRow(ctx, query, jobID).Scan(
+		&job.ID,
+		&job.Title,
+		&job.SourceType,
+		&job.Source,
+		&job.Status,
+		&job.DocumentID,
+		&job.ChunkCount,
+		&job.ErrorMessage,
+		&job.CreatedAt,
+		&job.UpdatedAt,
+		&job.CompletedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrJobNotFound
+		}
+		return nil, fmt.Errorf("failed to query ingestion job: %w", err)
+	}
+
+	return &job, nil
+}
+
+// validateRequest checks that the payload matches the source type
+func validateRequest(req IngestRequest) error {
+	if req.Title == "" {
+		return fmt.Errorf("title is required")
+	}
+
+	switch req.SourceType {
+	case SourceTypeText, SourceTypeMarkdown:
+		if req.Content == "" {
+			return fmt.Errorf("content is required for %s documents", req.SourceType)
+		}
+	case SourceTypePDF:
+		if len(req.Data) == 0 {
+			return fmt.Errorf("data is required for pdf documents")
+		}
+	case SourceTypeURL:
+		if req.URL == "" {
+			return fmt.Errorf("url is required for url documents")
+		}
+	default:
+		return fmt.Errorf("unsupported source type: %s", req.SourceType)
+	}
+
+	return nil
+}
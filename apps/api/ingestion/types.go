@@ -0,0 +1,46 @@
+package ingestion
+
+import "time"
+
+// SourceType identifies the kind of content being ingested
+type SourceType string
+
+const (
+	SourceTypeText     SourceType = "text"     // Plain text
+	SourceTypeMarkdown SourceType = "markdown" // Markdown document
+	SourceTypePDF      SourceType = "pdf"      // PDF file (raw bytes)
+	SourceTypeURL      SourceType = "url"      // Web page fetched at ingestion time
+)
+
+// Job statuses
+const (
+	StatusPending    = "PENDING"
+	StatusProcessing = "PROCESSING"
+	StatusCompleted  = "COMPLETED"
+	StatusFailed     = "FAILED"
+)
+
+// IngestRequest is the request payload for ingesting a document
+type IngestRequest struct {
+	Title      string            `json:"title" binding:"required"`
+	SourceType SourceType        `json:"source_type" binding:"required"`
+	Content    string            `json:"content,omitempty"` // Text or markdown body
+	Data       []byte            `json:"data,omitempty"`    // Raw file bytes (PDF), base64 in JSON
+	URL        string            `json:"url,omitempty"`     // Source URL for SourceTypeURL
+	Metadata   map[string]string `json:"metadata,omitempty"`
+}
+
+// Job represents the status of a document ingestion
+type Job struct {
+	ID           int        `json:"id"`
+	Title        string     `json:"title"`
+	SourceType   SourceType `json:"source_type"`
+	Source       *string    `json:"source,omitempty"`
+	Status       string     `json:"status"` // PENDING, PROCESSING, COMPLETED, FAILED
+	DocumentID   *int       `json:"document_id,omitempty"`
+	ChunkCount   int        `json:"chunk_count"`
+	ErrorMessage *string    `json:"error_message,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	CompletedAt  *time.Time `json:"completed_at,omitempty"`
+}
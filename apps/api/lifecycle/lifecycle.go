@@ -0,0 +1,92 @@
+// Package lifecycle coordinates graceful shutdown. Components register a
+// stop function as they start and are stopped in reverse order, so the
+// servers stop taking requests before the workers and writers behind them
+// drain, all within one shutdown deadline.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// StopFunc stops a component, returning once it has drained or ctx is
+// done. It returns an error when work was abandoned.
+type StopFunc func(ctx context.Context) error
+
+// component is a registered component
+type component struct {
+	name string
+	stop StopFunc
+}
+
+// Manager stops registered components on shutdown
+type Manager struct {
+	mu         sync.Mutex
+	components []component
+	stopped    bool
+}
+
+// New creates a lifecycle manager
+func New() *Manager {
+	return &Manager{}
+}
+
+// Register adds a component, to be stopped before every component
+// registered earlier
+func (m *Manager) Register(name string, stop StopFunc) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.components = append(m.components, component{name: name, stop: stop})
+}
+
+// RegisterFunc adds a component whose stop function reports no errors
+func (m *Manager) RegisterFunc(name string, stop func(ctx context.Context)) {
+	m.Register(name, func(ctx context.Context) error {
+		stop(ctx)
+		return nil
+	})
+}
+
+// Shutdown stops the components in reverse registration order, logging
+// each one. Components are still stopped once ctx is done, so they can
+// cancel their work, and the errors of all components are returned.
+// Later calls do nothing.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	if m.stopped {
+		m.mu.Unlock()
+		return nil
+	}
+	m.stopped = true
+	components := m.components
+	m.mu.Unlock()
+
+	var errs []error
+	for i := len(components) - 1; i >= 0; i-- {
+		c := components[i]
+		started := time.Now()
+		log.Printf("Stopping %s...", c.name)
+		if err := stop(ctx, c); err != nil {
+			log.Printf("Warning: %s did not stop cleanly after %s: %v", c.name, time.Since(started).Round(time.Millisecond), err)
+			errs = append(errs, fmt.Errorf("%s: %w", c.name, err))
+			continue
+		}
+		log.Printf("Stopped %s in %s", c.name, time.Since(started).Round(time.Millisecond))
+	}
+	return errors.Join(errs...)
+}
+
+// stop runs a component's stop function, turning a panic into an error so
+// the remaining components still stop
+func stop(ctx context.Context, c component) (err error) {
+	defer func() {
+		if value := recover(); value != nil {
+			err = fmt.Errorf("panic while stopping: %v", value)
+		}
+	}()
+	return c.stop(ctx)
+}
@@ -0,0 +1,48 @@
+package logging
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GinMiddleware tags HTTP requests with a request ID, injects a request
+// logger into the request context, and logs each request
+func GinMiddleware(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		started := time.Now()
+
+		ctx, requestID, requestLogger := startRequest(c.Request.Context(), logger, c.GetHeader(RequestIDHeader))
+		c.Request = c.Request.WithContext(ctx)
+		c.Request.Header.Set(RequestIDHeader, requestID) // Propagated by proxied requests
+		c.Header(RequestIDHeader, requestID)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Duration("duration", time.Since(started)),
+			slog.String("peer", c.ClientIP()),
+		}
+		if route := c.FullPath(); route != "" {
+			attrs = append(attrs, slog.String("route", route))
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("error", c.Errors.String()))
+		}
+
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case status >= 400:
+			level = slog.LevelWarn
+		}
+
+		requestLogger.LogAttrs(ctx, level, "http request", attrs...)
+	}
+}
@@ -0,0 +1,118 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// startRPC reads or generates the request ID of an RPC, returns it to the
+// caller as a response header, and attaches it with the logger to the context
+func startRPC(ctx context.Context, logger *slog.Logger) (context.Context, *slog.Logger) {
+	var incomingID string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(RequestIDHeader); len(values) > 0 {
+			incomingID = values[0]
+		}
+	}
+
+	ctx, requestID, requestLogger := startRequest(ctx, logger, incomingID)
+	_ = grpc.SetHeader(ctx, metadata.Pairs(RequestIDHeader, requestID))
+	return ctx, requestLogger
+}
+
+// ClientIP returns the address of an RPC's caller. Calls proxied by the
+// REST gateway over loopback report the address the gateway forwarded.
+func ClientIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		host = p.Addr.String()
+	}
+
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if forwarded := md.Get("x-forwarded-for"); len(forwarded) > 0 {
+				host = strings.TrimSpace(strings.Split(forwarded[0], ",")[0])
+			}
+		}
+	}
+	return host
+}
+
+// logRPC logs the outcome of an RPC. Server errors log at error level,
+// client errors at warn, and everything else at info.
+func logRPC(ctx context.Context, logger *slog.Logger, method string, started time.Time, err error) {
+	code := status.Code(err)
+
+	attrs := []slog.Attr{
+		slog.String("method", method),
+		slog.String("code", code.String()),
+		slog.Duration("duration", time.Since(started)),
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		attrs = append(attrs, slog.String("peer", p.Addr.String()))
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", status.Convert(err).Message()))
+	}
+
+	level := slog.LevelInfo
+	switch code {
+	case codes.OK, codes.Canceled:
+	case codes.Unknown, codes.Internal, codes.DataLoss, codes.Unavailable, codes.DeadlineExceeded, codes.Unimplemented:
+		level = slog.LevelError
+	default:
+		level = slog.LevelWarn
+	}
+
+	logger.LogAttrs(ctx, level, "grpc request", attrs...)
+}
+
+// UnaryServerInterceptor tags unary calls with a request ID, injects a
+// request logger into the context, and logs each call
+func UnaryServerInterceptor(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		started := time.Now()
+		ctx, requestLogger := startRPC(ctx, logger)
+
+		resp, err := handler(ctx, req)
+		logRPC(ctx, requestLogger, info.FullMethod, started, err)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor tags streams with a request ID, injects a request
+// logger into the stream's context, and logs each stream when it ends
+func StreamServerInterceptor(logger *slog.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		started := time.Now()
+		ctx, requestLogger := startRPC(stream.Context(), logger)
+
+		err := handler(srv, &contextStream{ServerStream: stream, ctx: ctx})
+		logRPC(ctx, requestLogger, info.FullMethod, started, err)
+		return err
+	}
+}
+
+// contextStream overrides the context of a server stream
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns the stream's context with the request logger attached
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,105 @@
+package logging
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// DefaultLevelRevert is how long a level changed at runtime lasts when no
+// duration is given
+const DefaultLevelRevert = 15 * time.Minute
+
+// Level is a log level that can be changed at runtime, for instance to log
+// at debug during an incident. Temporary changes revert to the base level
+// on their own so verbose logging is not left on by accident.
+type Level struct {
+	level slog.LevelVar
+
+	mu       sync.Mutex
+	base     slog.Level
+	revertAt time.Time
+	timer    *time.Timer
+	changes  int // Counts changes so a revert firing late does not undo a newer one
+}
+
+// LevelStatus describes the current level and any pending revert
+type LevelStatus struct {
+	Level    string     `json:"level"`
+	Base     string     `json:"base"`
+	RevertAt *time.Time `json:"revert_at,omitempty"`
+}
+
+// NewLevel creates a level starting at, and reverting to, the named level
+func NewLevel(name string) *Level {
+	l := &Level{base: ParseLevel(name)}
+	l.level.Set(l.base)
+	return l
+}
+
+// Level returns the current level, implementing slog.Leveler
+func (l *Level) Level() slog.Level {
+	return l.level.Level()
+}
+
+// Set changes the level for a while, after which it reverts to the base
+// level. A duration of 0 changes the base level instead, for good.
+func (l *Level) Set(level slog.Level, duration time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.stopTimer()
+	l.level.Set(level)
+	if duration <= 0 {
+		l.base = level
+		return
+	}
+	change := l.changes
+	l.revertAt = time.Now().Add(duration)
+	l.timer = time.AfterFunc(duration, func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if l.changes == change {
+			l.revert()
+		}
+	})
+}
+
+// Reset reverts to the base level at once
+func (l *Level) Reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.revert()
+}
+
+// revert restores the base level; l.mu must be held
+func (l *Level) revert() {
+	l.stopTimer()
+	if l.level.Level() != l.base {
+		slog.Info("log level reverted", "level", LevelName(l.base))
+	}
+	l.level.Set(l.base)
+}
+
+// Status reports the current and base levels and when the current one
+// reverts
+func (l *Level) Status() LevelStatus {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	status := LevelStatus{Level: LevelName(l.level.Level()), Base: LevelName(l.base)}
+	if l.timer != nil {
+		revertAt := l.revertAt
+		status.RevertAt = &revertAt
+	}
+	return status
+}
+
+// stopTimer cancels a pending revert; l.mu must be held
+func (l *Level) stopTimer() {
+	l.changes++
+	if l.timer != nil {
+		l.timer.Stop()
+		l.timer = nil
+	}
+}
@@ -0,0 +1,110 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"strings"
+
+	"agentic-template/api/requestctx"
+
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader carries the request ID in gRPC metadata and HTTP headers
+const RequestIDHeader = "x-request-id"
+
+// New creates a JSON logger at the given level ("debug", "info", "warn", or
+// "error"; anything else logs at info)
+func New(level string) *slog.Logger {
+	return NewWithLevel(ParseLevel(level))
+}
+
+// NewWithLevel creates a JSON logger filtered by a level, such as a *Level
+// that changes at runtime
+func NewWithLevel(level slog.Leveler) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+		Level: level,
+	}))
+}
+
+// ParseLevel converts a level name to a slog level
+func ParseLevel(level string) slog.Level {
+	parsed, ok := LookupLevel(level)
+	if !ok {
+		return slog.LevelInfo
+	}
+	return parsed
+}
+
+// LookupLevel converts a level name to a slog level, reporting whether the
+// name is known
+func LookupLevel(level string) (slog.Level, bool) {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "debug":
+		return slog.LevelDebug, true
+	case "info":
+		return slog.LevelInfo, true
+	case "warn", "warning":
+		return slog.LevelWarn, true
+	case "error":
+		return slog.LevelError, true
+	default:
+		return slog.LevelInfo, false
+	}
+}
+
+// LevelName returns the lowercase name of a level, as accepted by
+// ParseLevel
+func LevelName(level slog.Level) string {
+	return strings.ToLower(level.String())
+}
+
+type loggerKey struct{}
+
+// WithLogger returns a context carrying the logger
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the request's logger, or the default logger outside a
+// request
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok && logger != nil {
+		return logger
+	}
+	return slog.Default()
+}
+
+// NewRequestID generates a request ID
+func NewRequestID() string {
+	return uuid.NewString()
+}
+
+// validRequestID reports whether a caller-supplied request ID is safe to
+// propagate into logs and response headers
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > 128 {
+		return false
+	}
+	for _, r := range requestID {
+		if r < 0x21 || r > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// startRequest attaches the request ID and a logger tagged with it to the
+// context. A valid incoming ID is kept; otherwise a new one is generated.
+func startRequest(ctx context.Context, logger *slog.Logger, incomingID string) (context.Context, string, *slog.Logger) {
+	requestID := incomingID
+	if !validRequestID(requestID) {
+		requestID = NewRequestID()
+	}
+
+	requestLogger := logger.With(slog.String("request_id", requestID))
+	ctx = requestctx.WithRequestID(ctx, requestID)
+	ctx = WithLogger(ctx, requestLogger)
+	return ctx, requestID, requestLogger
+}
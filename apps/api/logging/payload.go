@@ -0,0 +1,155 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"math/rand/v2"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// redactedPayloadValue replaces redacted string fields in logged payloads
+const redactedPayloadValue = "[REDACTED]"
+
+// PayloadLogger logs the request and response messages of a sample of
+// RPCs as JSON. Fields marked [debug_redact = true] in the proto, such as
+// secrets and record values, are redacted: strings read [REDACTED] and
+// other fields are left out.
+type PayloadLogger struct {
+	sampleRate float64
+	maxSize    int
+}
+
+// NewPayloadLogger creates a payload logger logging a share of RPCs, from
+// 0 to 1, with payloads truncated past maxSize bytes (0 logs them whole)
+func NewPayloadLogger(sampleRate float64, maxSize int) *PayloadLogger {
+	return &PayloadLogger{sampleRate: sampleRate, maxSize: maxSize}
+}
+
+// sampled reports whether an RPC's payloads are logged
+func (p *PayloadLogger) sampled() bool {
+	return rand.Float64() < p.sampleRate
+}
+
+// log logs one message of an RPC with the request logger of ctx.
+// direction is "request" for received messages and "response" for sent
+// ones.
+func (p *PayloadLogger) log(ctx context.Context, method, direction string, msg any) {
+	m, ok := msg.(proto.Message)
+	if !ok {
+		return
+	}
+	clone := proto.Clone(m)
+	redactPayload(clone.ProtoReflect())
+	data, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(clone)
+	if err != nil {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("method", method),
+		slog.String("direction", direction),
+		slog.Int("size", len(data)),
+	}
+	if p.maxSize > 0 && len(data) > p.maxSize {
+		attrs = append(attrs,
+			slog.String("payload", strings.ToValidUTF8(string(data[:p.maxSize]), "")),
+			slog.Bool("truncated", true),
+		)
+	} else {
+		attrs = append(attrs, slog.String("payload", string(data)))
+	}
+	FromContext(ctx).LogAttrs(ctx, slog.LevelInfo, "grpc payload", attrs...)
+}
+
+// UnaryServerInterceptor logs the request and response of sampled unary
+// calls. It must run after UnaryServerInterceptor so entries carry the
+// request ID.
+func (p *PayloadLogger) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if !p.sampled() {
+			return handler(ctx, req)
+		}
+
+		p.log(ctx, info.FullMethod, "request", req)
+		resp, err := handler(ctx, req)
+		if err == nil {
+			p.log(ctx, info.FullMethod, "response", resp)
+		}
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor logs every message of sampled streams. It must
+// run after StreamServerInterceptor so entries carry the request ID.
+func (p *PayloadLogger) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if !p.sampled() {
+			return handler(srv, stream)
+		}
+		return handler(srv, &payloadStream{ServerStream: stream, logger: p, method: info.FullMethod})
+	}
+}
+
+// payloadStream logs the messages of a sampled stream
+type payloadStream struct {
+	grpc.ServerStream
+	logger *PayloadLogger
+	method string
+}
+
+// RecvMsg receives a message, logging it
+func (s *payloadStream) RecvMsg(m any) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		s.logger.log(s.Context(), s.method, "request", m)
+	}
+	return err
+}
+
+// SendMsg logs a message and sends it
+func (s *payloadStream) SendMsg(m any) error {
+	s.logger.log(s.Context(), s.method, "response", m)
+	return s.ServerStream.SendMsg(m)
+}
+
+// redactPayload redacts the fields marked debug_redact, recursing into
+// nested messages, lists, and maps
+func redactPayload(msg protoreflect.Message) {
+	msg.Range(func(field protoreflect.FieldDescriptor, value protoreflect.Value) bool {
+		switch {
+		case isDebugRedacted(field):
+			if field.Kind() == protoreflect.StringKind && !field.IsList() && !field.IsMap() {
+				msg.Set(field, protoreflect.ValueOfString(redactedPayloadValue))
+			} else {
+				msg.Clear(field)
+			}
+		case field.IsMap():
+			if field.MapValue().Kind() == protoreflect.MessageKind {
+				value.Map().Range(func(_ protoreflect.MapKey, entry protoreflect.Value) bool {
+					redactPayload(entry.Message())
+					return true
+				})
+			}
+		case field.Kind() == protoreflect.MessageKind && field.IsList():
+			list := value.List()
+			for i := 0; i < list.Len(); i++ {
+				redactPayload(list.Get(i).Message())
+			}
+		case field.Kind() == protoreflect.MessageKind:
+			redactPayload(value.Message())
+		}
+		return true
+	})
+}
+
+// isDebugRedacted reports whether a field is marked [debug_redact = true]
+func isDebugRedacted(field protoreflect.FieldDescriptor) bool {
+	options, ok := field.Options().(*descriptorpb.FieldOptions)
+	return ok && options.GetDebugRedact()
+}
@@ -0,0 +1,253 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	pb "agentic-template/api/pb/v1"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// payloadDescriptor describes a message with redacted fields at every
+// level of nesting the service protos can reach:
+//
+//	message Child {
+//	  string name = 1;
+//	  string secret = 2 [debug_redact = true];
+//	}
+//	message Payload {
+//	  string name = 1;
+//	  string secret = 2 [debug_redact = true];
+//	  bytes blob = 3 [debug_redact = true];
+//	  repeated string tokens = 4 [debug_redact = true];
+//	  Child child = 5;
+//	  repeated Child children = 6;
+//	  map<string, Child> by_name = 7;
+//	  map<string, string> labels = 8 [debug_redact = true];
+//	}
+func payloadDescriptor(t *testing.T) protoreflect.MessageDescriptor {
+	t.Helper()
+	redacted := &descriptorpb.FieldOptions{DebugRedact: proto.Bool(true)}
+	field := func(name string, number int32, kind descriptorpb.FieldDescriptorProto_Type, label descriptorpb.FieldDescriptorProto_Label, typeName string, options *descriptorpb.FieldOptions) *descriptorpb.FieldDescriptorProto {
+		f := &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(name),
+			Number:   proto.Int32(number),
+			Type:     kind.Enum(),
+			Label:    label.Enum(),
+			Options:  options,
+		}
+		if typeName != "" {
+			f.TypeName = proto.String(typeName)
+		}
+		return f
+	}
+	const (
+		optional = descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL
+		repeated = descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+		str      = descriptorpb.FieldDescriptorProto_TYPE_STRING
+		byt      = descriptorpb.FieldDescriptorProto_TYPE_BYTES
+		msg      = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE
+	)
+
+	file := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("logging/payload_test.proto"),
+		Package: proto.String("loggingtest"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("Child"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("name", 1, str, optional, "", nil),
+					field("secret", 2, str, optional, "", redacted),
+				},
+			},
+			{
+				Name: proto.String("Payload"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("name", 1, str, optional, "", nil),
+					field("secret", 2, str, optional, "", redacted),
+					field("blob", 3, byt, optional, "", redacted),
+					field("tokens", 4, str, repeated, "", redacted),
+					field("child", 5, msg, optional, ".loggingtest.Child", nil),
+					field("children", 6, msg, repeated, ".loggingtest.Child", nil),
+					field("by_name", 7, msg, repeated, ".loggingtest.Payload.ByNameEntry", nil),
+					field("labels", 8, msg, repeated, ".loggingtest.Payload.LabelsEntry", redacted),
+				},
+				NestedType: []*descriptorpb.DescriptorProto{
+					{
+						Name: proto.String("ByNameEntry"),
+						Field: []*descriptorpb.FieldDescriptorProto{
+							field("key", 1, str, optional, "", nil),
+							field("value", 2, msg, optional, ".loggingtest.Child", nil),
+						},
+						Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+					},
+					{
+						Name: proto.String("LabelsEntry"),
+						Field: []*descriptorpb.FieldDescriptorProto{
+							field("key", 1, str, optional, "", nil),
+							field("value", 2, str, optional, "", nil),
+						},
+						Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+					},
+				},
+			},
+		},
+	}
+	fd, err := protodesc.NewFile(file, nil)
+	if err != nil {
+		t.Fatalf("building test descriptor: %v", err)
+	}
+	return fd.Messages().ByName("Payload")
+}
+
+// redactedJSON redacts msg and returns its JSON decoded for comparison,
+// since protojson output is deliberately unstable
+func redactedJSON(t *testing.T, msg proto.Message) map[string]any {
+	t.Helper()
+	redactPayload(msg.ProtoReflect())
+	data, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(msg)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unmarshal %s: %v", data, err)
+	}
+	return decoded
+}
+
+func TestRedactPayload(t *testing.T) {
+	desc := payloadDescriptor(t)
+	input := `{
+		"name": "orders",
+		"secret": "hunter2",
+		"blob": "c2VjcmV0",
+		"tokens": ["a", "b"],
+		"child": {"name": "one", "secret": "s1"},
+		"children": [{"name": "two", "secret": "s2"}, {"name": "three"}],
+		"by_name": {"four": {"name": "four", "secret": "s4"}},
+		"labels": {"team": "billing"}
+	}`
+	msg := dynamicpb.NewMessage(desc)
+	if err := protojson.Unmarshal([]byte(input), msg); err != nil {
+		t.Fatalf("unmarshal input: %v", err)
+	}
+
+	got := redactedJSON(t, msg)
+	want := map[string]any{
+		"name":     "orders",
+		"secret":   redactedPayloadValue,
+		"child":    map[string]any{"name": "one", "secret": redactedPayloadValue},
+		"children": []any{map[string]any{"name": "two", "secret": redactedPayloadValue}, map[string]any{"name": "three"}},
+		"by_name":  map[string]any{"four": map[string]any{"name": "four", "secret": redactedPayloadValue}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("redacted payload:\n got %v\nwant %v", got, want)
+	}
+}
+
+func TestRedactPayloadServiceMessages(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  proto.Message
+		want map[string]any
+	}{
+		{
+			name: "ingested document content",
+			msg: &pb.IngestDocumentRequest{
+				Title:      "Handbook",
+				SourceType: "text",
+				Content:    proto.String("salary bands"),
+			},
+			want: map[string]any{"title": "Handbook", "source_type": "text", "content": redactedPayloadValue},
+		},
+		{
+			name: "search result rows and chunks",
+			msg: &pb.SemanticSearchResponse{
+				Success: true,
+				Results: []*pb.SemanticSearchResult{
+					{Similarity: 0.5, RowJson: proto.String(`{"email":"a@example.com"}`)},
+					{Similarity: 0.25, Content: proto.String("chunk text")},
+				},
+			},
+			want: map[string]any{
+				"success": true,
+				"results": []any{
+					map[string]any{"similarity": 0.5, "row_json": redactedPayloadValue},
+					map[string]any{"similarity": 0.25, "content": redactedPayloadValue},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := redactedJSON(t, tt.msg); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("redacted payload:\n got %v\nwant %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPayloadLoggerTruncation(t *testing.T) {
+	msg := &pb.IngestDocumentRequest{Title: strings.Repeat("é", 100), SourceType: "text"}
+	tests := []struct {
+		name          string
+		maxSize       int
+		wantTruncated bool
+	}{
+		{name: "unlimited", maxSize: 0},
+		{name: "under the limit", maxSize: 1 << 20},
+		{name: "over the limit", maxSize: 51, wantTruncated: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			ctx := WithLogger(context.Background(), slog.New(slog.NewJSONHandler(&buf, nil)))
+			NewPayloadLogger(1, tt.maxSize).log(ctx, "/test.Service/Method", "request", msg)
+
+			var entry struct {
+				Method    string `json:"method"`
+				Direction string `json:"direction"`
+				Size      int    `json:"size"`
+				Payload   string `json:"payload"`
+				Truncated bool   `json:"truncated"`
+			}
+			if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+				t.Fatalf("decoding log entry %q: %v", buf.String(), err)
+			}
+			if entry.Method != "/test.Service/Method" || entry.Direction != "request" {
+				t.Errorf("method, direction = %q, %q", entry.Method, entry.Direction)
+			}
+			if entry.Truncated != tt.wantTruncated {
+				t.Errorf("truncated = %v, want %v", entry.Truncated, tt.wantTruncated)
+			}
+			if !utf8.ValidString(entry.Payload) {
+				t.Errorf("payload %q is not valid UTF-8", entry.Payload)
+			}
+			if !tt.wantTruncated {
+				if len(entry.Payload) != entry.Size {
+					t.Errorf("payload is %d bytes, size reports %d", len(entry.Payload), entry.Size)
+				}
+				return
+			}
+			// A rune cut at the limit is dropped rather than split
+			if len(entry.Payload) > tt.maxSize || len(entry.Payload) < tt.maxSize-1 || entry.Size <= tt.maxSize {
+				t.Errorf("payload is %d bytes of %d, want under the %d byte limit", len(entry.Payload), entry.Size, tt.maxSize)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+
+	"agentic-template/api/requestctx"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// recovered logs a recovered panic with its stack and returns the
+// correlation ID to report to the caller: the request ID when there is one
+func recovered(ctx context.Context, value any, attrs ...any) string {
+	correlationID := requestctx.RequestID(ctx)
+	if correlationID == "" {
+		correlationID = NewRequestID()
+	}
+
+	attrs = append(attrs,
+		slog.String("correlation_id", correlationID),
+		slog.String("panic", fmt.Sprint(value)),
+		slog.String("stack", string(debug.Stack())),
+	)
+	FromContext(ctx).Error("recovered from panic", attrs...)
+	return correlationID
+}
+
+// panicError converts a recovered panic to an Internal error that names
+// the correlation ID without leaking the panic value
+func panicError(correlationID string) error {
+	return status.Errorf(codes.Internal, "internal error (correlation ID %s)", correlationID)
+}
+
+// RecoverPanic logs a panic recovered in a goroutine serving ctx and returns
+// an Internal error carrying its correlation ID
+func RecoverPanic(ctx context.Context, value any) error {
+	return panicError(recovered(ctx, value))
+}
+
+// UnaryRecoveryInterceptor converts panics in unary handlers to Internal
+// errors. Chain it after the logging interceptor so the panic is logged with
+// the request ID.
+func UnaryRecoveryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		defer func() {
+			if value := recover(); value != nil {
+				correlationID := recovered(ctx, value, slog.String("method", info.FullMethod))
+				resp, err = nil, panicError(correlationID)
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// StreamRecoveryInterceptor converts panics in stream handlers to Internal
+// errors
+func StreamRecoveryInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if value := recover(); value != nil {
+				correlationID := recovered(stream.Context(), value, slog.String("method", info.FullMethod))
+				err = panicError(correlationID)
+			}
+		}()
+		return handler(srv, stream)
+	}
+}
+
+// GinRecovery converts panics in HTTP handlers to 500 responses carrying the
+// correlation ID. Use it after GinMiddleware.
+func GinRecovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if value := recover(); value != nil {
+				// net/http uses this panic to abort a response on purpose
+				if value == http.ErrAbortHandler {
+					panic(value)
+				}
+				correlationID := recovered(c.Request.Context(), value,
+					slog.String("method", c.Request.Method),
+					slog.String("path", c.Request.URL.Path),
+				)
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+					"error":          "internal error",
+					"correlation_id": correlationID,
+				})
+			}
+		}()
+		c.Next()
+	}
+}
@@ -2,22 +2,48 @@ package main
 
 import (
 	"context"
+	"errors"
 	"log"
+	"log/slog"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"runtime"
+	"slices"
+	"strings"
 	"syscall"
 	"time"
 
+	"agentic-template/api/accounts"
+	"agentic-template/api/adminui"
+	"agentic-template/api/agent"
+	"agentic-template/api/analytics"
+	"agentic-template/api/audit"
+	"agentic-template/api/auth"
+	"agentic-template/api/awsauth"
 	"agentic-template/api/config"
 	"agentic-template/api/db"
 	"agentic-template/api/db/migrations"
+	"agentic-template/api/db/sqlite"
+	"agentic-template/api/embeddings"
 	"agentic-template/api/grpc_server"
 	"agentic-template/api/handlers"
+	"agentic-template/api/ingestion"
+	"agentic-template/api/lifecycle"
+	"agentic-template/api/logging"
+	"agentic-template/api/metrics"
+	"agentic-template/api/queue"
+	"agentic-template/api/ratelimit"
+	"agentic-template/api/requestctx"
+	"agentic-template/api/schema_manager"
+	"agentic-template/api/tenancy"
+	"agentic-template/api/tracing"
 
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/reflection"
 )
 
@@ -27,45 +53,548 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	// Log as JSON at a level admins can raise at runtime; the standard logger
+	// writes through it too
+	logLevel := logging.NewLevel(cfg.LogLevel)
+	logger := logging.NewWithLevel(logLevel)
+	slog.SetDefault(logger)
+
+	// Components register how they stop as they start and are stopped in
+	// reverse order on shutdown
+	components := lifecycle.New()
+
+	// Continue traces started by callers and export spans over OTLP when a
+	// collector is configured
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		Endpoint:       cfg.OTLPEndpoint,
+		Protocol:       cfg.OTLPProtocol,
+		SampleRatio:    cfg.TracingSampleRatio,
+		ServiceName:    cfg.ServiceName,
+		ServiceVersion: "1.0.0",
+		Environment:    cfg.Environment,
+	})
+	if err != nil {
+		log.Printf("Warning: Trace export disabled: %v", err)
+		shutdownTracing = func(context.Context) error { return nil }
+	} else if cfg.OTLPEndpoint != "" {
+		log.Printf("Exporting traces to %s", cfg.OTLPEndpoint)
+	}
+	components.Register("trace export", shutdownTracing)
 
 	// Initialize database manager
 	dbManager := db.GetManager()
+	db.SetStatementTimeout(cfg.DBStatementTimeout)
+	dbManager.SetReplicaURLs(cfg.DatabaseURLReplicas)
+	dbManager.SetPoolConfig(db.PoolConfig{
+		MaxConns:               int32(cfg.DBMaxConns),
+		MinConns:               int32(cfg.DBMinConns),
+		MaxConnLifetime:        time.Duration(cfg.DBMaxConnLifetimeMinutes) * time.Minute,
+		MaxConnIdleTime:        time.Duration(cfg.DBMaxConnIdleMinutes) * time.Minute,
+		HealthCheckPeriod:      time.Duration(cfg.DBHealthCheckPeriodSeconds) * time.Second,
+		ConnectTimeout:         cfg.DBConnectTimeout,
+		StatementTimeout:       cfg.DBStatementTimeout,
+		SlowQueryThreshold:     time.Duration(cfg.DBSlowQueryMS) * time.Millisecond,
+		ScopeSearchPath:        cfg.TenancyEnabled || cfg.SchemaSandboxEnabled,
+		ReloadGracePeriod:      time.Duration(cfg.DBReloadGraceSeconds) * time.Second,
+		StatementCacheCapacity: cfg.DBStatementCacheCapacity,
+	})
 
-	// Try to initialize database connection
-	if err := dbManager.Initialize(cfg.DatabaseURLPooled, cfg.DatabaseURLDirect); err != nil {
-		log.Printf("Warning: Failed to connect to database: %v", err)
-		// Continue without database for now
+	// Connect to the database, retrying with backoff. If it stays down, serve
+	// in a degraded mode and keep retrying in the background; readiness
+	// reports the database until it is up and migrated.
+	components.RegisterFunc("database pool", func(context.Context) { dbManager.Close() })
+	retryPolicy := db.RetryPolicy{
+		Attempts:       cfg.DBConnectAttempts,
+		InitialBackoff: db.DefaultInitialBackoff,
+		MaxBackoff:     time.Duration(cfg.DBConnectMaxBackoffSeconds) * time.Second,
+	}
+	connectCtx, stopConnecting := context.WithCancel(context.Background())
+	dbManager.SetURLResolver(cfg.ResolveSecret)
+	migrations.SetExternalDir(cfg.MigrationsDir)
+	migrations.SetAllowOutOfOrder(cfg.MigrationsAllowOutOfOrder)
+	embeddings.SetDimensions(cfg.EmbeddingDimensions)
+	schema_manager.SetQuotas(schema_manager.Quotas{
+		MaxTables:          cfg.QuotaMaxTables,
+		MaxColumnsPerTable: cfg.QuotaMaxColumnsPerTable,
+		MaxRowsPerTable:    int64(cfg.QuotaMaxRowsPerTable),
+	})
+	schema_manager.SetTrashSettings(schema_manager.TrashSettings{
+		Enabled:   cfg.TableTrashEnabled,
+		Retention: cfg.TableTrashRetention,
+	})
+	if err := schema_manager.SetNamingStrategy(schema_manager.NamingStrategy{
+		TablePrefix: cfg.IdentifierTablePrefix,
+		Case:        cfg.IdentifierCase,
+		Truncation:  cfg.IdentifierTruncation,
+		MaxLength:   cfg.IdentifierMaxLength,
+	}); err != nil {
+		log.Fatalf("Invalid identifier naming: %v", err)
+	}
+	schema_manager.SetChangeApproval(cfg.SchemaChangeApproval)
+	schema_manager.SetImportSources(cfg.ImportSources)
+	schema_manager.SetSyncDestinations(cfg.SyncDestinations)
+	schema_manager.SetSyncAlerts(schema_manager.SyncAlerts{
+		Webhook:  cfg.SyncAlertWebhook,
+		Failures: cfg.SyncAlertFailures,
+	})
+	go cfg.WatchSecrets(connectCtx, func(envVars []string) {
+		// Rotated API keys are picked up per request; database URLs need a new pool
+		for _, envVar := range envVars {
+			if strings.HasPrefix(envVar, "DATABASE_URL_") {
+				if err := dbManager.Reload(); err != nil {
+					log.Printf("Warning: failed to reload database after secret rotation: %v", err)
+				}
+				return
+			}
+		}
+	})
+	if cfg.DBDriver == "sqlite" {
+		// Run locally on a file database; features needing PostgreSQL
+		// report the database as not configured
+		local, err := sqlite.Open(cfg.SQLitePath)
+		if err != nil {
+			log.Fatalf("Failed to open SQLite database: %v", err)
+		}
+		dbManager.SetLocal(local)
+		log.Printf("Using local SQLite database %s; agent profiles, runs, jobs, audit, and the knowledge base need PostgreSQL", cfg.SQLitePath)
 	} else {
-		defer dbManager.Close()
+		err = dbManager.Connect(connectCtx, cfg.DatabaseURLPooled, cfg.DatabaseURLDirect, retryPolicy)
+		switch {
+		case err == nil:
+			runMigrations(dbManager, cfg.DatabaseURLDirect, cfg.MigrationTimeout)
+		case errors.Is(err, db.ErrNotConfigured) || errors.Is(err, db.ErrInvalidConfig) || !cfg.DBConnectInBackground:
+			log.Printf("Warning: Failed to connect to database: %v", err)
+			// Continue without database for now
+		default:
+			log.Printf("Warning: Failed to connect to database, retrying in the background: %v", err)
+			dbManager.ConnectInBackground(connectCtx, cfg.DatabaseURLPooled, cfg.DatabaseURLDirect, retryPolicy, func() {
+				runMigrations(dbManager, cfg.DatabaseURLDirect, cfg.MigrationTimeout)
+			})
+		}
+	}
 
-		// Run database migrations
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-		defer cancel()
+	// Fail over to the next DSN when DATABASE_URL_POOLED lists several
+	if cfg.DBFailoverCheckSeconds > 0 {
+		go dbManager.WatchFailover(connectCtx, time.Duration(cfg.DBFailoverCheckSeconds)*time.Second, cfg.DBFailoverThreshold)
+	}
 
-		if err := migrations.RunMigrations(ctx, dbManager.GetPool()); err != nil {
-			log.Printf("Warning: Failed to run migrations: %v", err)
-			// Continue even if migrations fail (for development)
+	// Initialize the embedding provider for the RAG knowledge base
+	embedder, err := embeddings.NewEmbedder(embeddings.Config{
+		Provider: cfg.EmbeddingProvider,
+		APIKey:   cfg.OpenAIAPIKey,
+		Model:    cfg.EmbeddingModel,
+		BaseURL:  cfg.EmbeddingBaseURL,
+	})
+	if err != nil {
+		log.Printf("Warning: Embeddings disabled: %v", err)
+		embedder = nil
+	} else {
+		// Group texts from concurrent ingestions into provider-sized requests
+		embeddingBatcher := embeddings.NewBatcher(embedder, embeddings.BatchConfig{
+			BatchSize:         cfg.EmbeddingBatchSize,
+			RequestsPerMinute: cfg.EmbeddingRequestsPerMinute,
+			MaxRetries:        cfg.EmbeddingMaxRetries,
+		})
+		components.Register("embedding batches", embeddingBatcher.Close)
+		embedder = embeddingBatcher
+	}
+	ingestionService := ingestion.NewService(dbManager, embedder, cfg.RAGTopK)
+	components.Register("document ingestion", ingestionService.Shutdown)
+
+	// Retry LLM providers and fail over while one is down
+	agent.SetResilience(agent.Resilience{
+		Retry: agent.RetryPolicy{
+			Attempts:       cfg.LLMRetryAttempts,
+			InitialBackoff: cfg.LLMRetryInitialBackoff,
+			MaxBackoff:     cfg.LLMRetryMaxBackoff,
+		},
+		Breaker: agent.BreakerSettings{
+			FailureThreshold: cfg.LLMBreakerFailures,
+			Cooldown:         cfg.LLMBreakerCooldown,
+		},
+		FallbackProviders: cfg.LLMFallbackProviders,
+	})
+
+	// Apply tool registry configuration
+	for _, name := range cfg.DisabledTools {
+		if err := agent.DefaultRegistry.SetEnabled(name, false); err != nil {
+			log.Printf("Warning: Cannot disable tool: %v", err)
+		}
+	}
+
+	// Require an API key or JWT when authentication is enabled, and give
+	// principals without roles the default role. With authentication
+	// disabled, signed-in users are still identified in audit fields.
+	var authenticator *auth.Authenticator
+	if cfg.AuthEnabled || cfg.JWTSecret != "" {
+		authenticator = auth.New(auth.Config{
+			APIKeys:       cfg.APIKeys,
+			APIKeyRoles:   cfg.APIKeyRoles,
+			APIKeyTenants: cfg.APIKeyTenants,
+			JWTSecret:     cfg.JWTSecret,
+			JWTIssuer:     cfg.JWTIssuer,
+			Optional:      !cfg.AuthEnabled,
+		})
+		if cfg.AuthEnabled && !authenticator.Configured() {
+			log.Println("Warning: AUTH_ENABLED is set but no API_KEYS or JWT_SECRET is configured - all requests will be rejected")
+		}
+	}
+	var defaultRole auth.Role
+	if cfg.DefaultRole != "" {
+		role, err := auth.ParseRole(cfg.DefaultRole)
+		if err != nil {
+			log.Printf("Warning: Invalid AUTHZ_DEFAULT_ROLE, principals without roles get no access: %v", err)
+		}
+		defaultRole = role
+	}
+	policy := auth.NewPolicy(defaultRole)
+	if cfg.SchemaChangeApproval {
+		policy.AllowChangeRequests()
+	}
+
+	// Rate limit callers per method class, with writes stricter than reads
+	var limiter *ratelimit.Limiter
+	if cfg.RateLimitEnabled {
+		limiter = ratelimit.New(ratelimit.Config{
+			Limits: map[ratelimit.Class]ratelimit.Limit{
+				ratelimit.ClassRead:  {PerMinute: cfg.RateLimitReadPerMinute, Burst: cfg.RateLimitReadBurst},
+				ratelimit.ClassWrite: {PerMinute: cfg.RateLimitWritePerMinute, Burst: cfg.RateLimitWriteBurst},
+			},
+		})
+	}
+
+	// Record mutating calls with their actor and outcome
+	var auditRecorder *audit.Recorder
+	if cfg.AuditLogEnabled {
+		auditRecorder = audit.New(dbManager, audit.Config{RetentionDays: cfg.AuditRetentionDays})
+		components.Register("audit log writer", auditRecorder.Close)
+	}
+
+	// Count requests to user tables for usage analytics
+	var tableTracker *analytics.Tracker
+	if cfg.TableAnalyticsEnabled {
+		tableTracker = analytics.New(dbManager, analytics.Config{RetentionDays: cfg.TableAnalyticsRetentionDays})
+		components.Register("table analytics", tableTracker.Close)
+	}
+
+	// Drop tables that outstayed their time in the trash
+	if cfg.TableTrashEnabled && cfg.TableTrashRetention > 0 {
+		components.Register("table trash purge", schema_manager.NewTrashPurger(dbManager).Close)
+	}
+
+	// Drop record changes every warehouse sync has replicated
+	components.Register("record change log prune", schema_manager.NewChangeLogPruner(dbManager).Close)
+
+	// Ship audit events to external sinks for archiving
+	var auditExporter *audit.Exporter
+	if len(cfg.AuditExportSinks) > 0 {
+		sinkOpts := audit.SinkOptions{
+			WebhookSecret: cfg.AuditExportWebhookSecret,
+			AWS:           awsauth.FromEnv(),
+			S3Endpoint:    cfg.AuditExportS3Endpoint,
+		}
+		sinks := make([]audit.Sink, 0, len(cfg.AuditExportSinks))
+		for _, spec := range cfg.AuditExportSinks {
+			sink, err := audit.ParseSink(spec, sinkOpts)
+			if err != nil {
+				log.Fatalf("Invalid audit export sink: %v", err)
+			}
+			sinks = append(sinks, sink)
+		}
+		if !cfg.AuditLogEnabled && slices.Contains(cfg.AuditExportSources, audit.SourceAPI) {
+			log.Println("Warning: AUDIT_LOG_ENABLED is false, so no new api events will be exported")
+		}
+		auditExporter = audit.NewExporter(dbManager, audit.ExportConfig{
+			Sinks:     sinks,
+			Sources:   cfg.AuditExportSources,
+			Actions:   cfg.AuditExportActions,
+			Interval:  cfg.AuditExportInterval,
+			BatchSize: cfg.AuditExportBatchSize,
+		})
+		components.Register("audit export", auditExporter.Close)
+		log.Printf("Audit export enabled to %d sink(s)", len(sinks))
+	}
+
+	// Run background jobs shared by every instance through the database
+	jobQueue := queue.New(dbManager)
+	jobWorkers := queue.NewPool(jobQueue, queue.PoolConfig{
+		Workers:      cfg.QueueWorkers,
+		PollInterval: cfg.QueuePollInterval,
+		JobTimeout:   cfg.QueueJobTimeout,
+	})
+	jobWorkers.Register(schema_manager.AnonymizeJobKind, schema_manager.AnonymizeJobHandler(dbManager))
+	jobWorkers.Register(schema_manager.ImportJobKind, schema_manager.ImportJobHandler(dbManager, jobQueue))
+	jobWorkers.Register(schema_manager.SyncJobKind, schema_manager.SyncJobHandler(dbManager, jobQueue))
+	jobWorkers.Start()
+	components.Register("background job workers", jobWorkers.Stop)
+
+	// Scope schema management to the principal's tenant schema and the
+	// selected environment
+	var tenants *tenancy.Provisioner
+	if cfg.TenancyEnabled || cfg.SchemaSandboxEnabled {
+		tenants = tenancy.NewProvisioner(dbManager)
+		if cfg.TenancyEnabled && authenticator == nil {
+			log.Println("Warning: TENANCY_ENABLED is set but no credentials are configured - every request uses the shared schema")
+		}
+		if cfg.SchemaSandboxEnabled {
+			tenants.EnableSandbox()
 		}
 	}
 
 	// Setup Gin router
-	router := gin.Default()
+	gin.SetMode(cfg.GinMode)
+	router := gin.New()
+	// Gin trusts X-Forwarded-For from any peer by default, letting clients
+	// pick the IP the rate limiter keys them by; a nil list trusts none
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Fatalf("Invalid trusted proxies: %v", err)
+	}
+	router.Use(tracing.GinMiddleware(), logging.GinMiddleware(logger), logging.GinRecovery(), requestctx.GinMiddleware())
+	if cfg.HTTPMaxBodySize > 0 {
+		router.Use(handlers.MaxBodySize(cfg.HTTPMaxBodySize))
+	}
+
+	// Let browser frontends on other origins call the HTTP API
+	if cfg.EnableCORS {
+		corsMiddleware, err := handlers.CORS(cfg)
+		if err != nil {
+			log.Printf("Warning: CORS disabled: %v", err)
+		} else {
+			router.Use(corsMiddleware)
+			log.Printf("CORS enabled for origins: %s", strings.Join(cfg.CORSAllowedOrigins, ", "))
+		}
+	}
+
+	// Health check endpoint with the status of each component
+	router.GET("/health", handlers.NewHealthHandler(dbManager, cfg, jobQueue, auditExporter).Check)
+
+	// Database reachability and connection pool usage
+	router.GET("/health/database", handlers.NewDatabaseHealthHandler(dbManager).Check)
+
+	// Readiness endpoint checking the database, migrations, and LLM key
+	router.GET("/ready", handlers.NewReadinessHandler(dbManager, cfg).Check)
+
+	// Prometheus metrics such as query counts, for scrapers on the internal
+	// network
+	if cfg.MetricsEnabled {
+		router.GET("/metrics", gin.WrapH(metrics.Handler()))
+		go dbManager.ExportPoolStats(connectCtx, time.Duration(cfg.DBPoolStatsIntervalSeconds)*time.Second)
+	}
+
+	// Knowledge base ingestion and schema management endpoints. Versioned gateway routes (/v1) are
+	// authenticated and limited by the gRPC interceptors instead.
+	api := router.Group("/api")
+	if authenticator != nil {
+		api.Use(authenticator.GinMiddleware())
+	}
+	if auditRecorder != nil {
+		api.Use(auditRecorder.GinMiddleware())
+	}
+	if limiter != nil {
+		api.Use(limiter.GinMiddleware())
+	}
+	if tenants != nil {
+		api.Use(tenants.GinMiddleware())
+	}
+	if tableTracker != nil {
+		api.Use(tableTracker.GinMiddleware())
+	}
+	ingestionHandler := handlers.NewIngestionHandler(ingestionService)
+	api.POST("/knowledge/documents", policy.Require(auth.RoleEditor), ingestionHandler.IngestDocument)
+	api.GET("/knowledge/jobs/:id", policy.Require(auth.RoleViewer), ingestionHandler.GetJob)
+	schemaHandler := handlers.NewSchemaHandler(dbManager)
+	api.POST("/schema/tables", policy.RequireMethod("SchemaService/CreateTable"), schemaHandler.CreateTable)
+	api.GET("/schema/tables", policy.Require(auth.RoleViewer), schemaHandler.ListTables)
+	api.GET("/schema/tables/:id", policy.Require(auth.RoleViewer), schemaHandler.GetTable)
+	api.GET("/schema/search", policy.Require(auth.RoleViewer), schemaHandler.SearchSchema)
+	api.DELETE("/schema/tables/:id", policy.RequireMethod("SchemaService/DeleteTable"), schemaHandler.DeleteTable)
+	api.GET("/schema/trash", policy.Require(auth.RoleAdmin), schemaHandler.ListTrash)
+	api.POST("/schema/trash/:id/restore", policy.Require(auth.RoleAdmin), schemaHandler.RestoreTable)
+	api.DELETE("/schema/trash/:id", policy.Require(auth.RoleAdmin), schemaHandler.PurgeTable)
+	api.GET("/admin/config", policy.Require(auth.RoleAdmin), handlers.NewConfigHandler(cfg).Get)
+	logLevelHandler := handlers.NewLogLevelHandler(logLevel)
+	api.GET("/admin/log-level", policy.Require(auth.RoleAdmin), logLevelHandler.Get)
+	api.PUT("/admin/log-level", policy.Require(auth.RoleAdmin), logLevelHandler.Set)
+	api.DELETE("/admin/log-level", policy.Require(auth.RoleAdmin), logLevelHandler.Reset)
+	api.GET("/admin/audit-export", policy.Require(auth.RoleAdmin), handlers.NewAuditExportHandler(auditExporter).Get)
+
+	// Profiling and runtime stats for admins, off unless enabled
+	if cfg.DebugEndpoints {
+		runtime.SetMutexProfileFraction(cfg.MutexProfileFraction)
+		runtime.SetBlockProfileRate(cfg.BlockProfileRate)
+
+		debug := router.Group("/debug")
+		if authenticator != nil {
+			debug.Use(authenticator.GinMiddleware())
+		}
+		debug.Use(policy.Require(auth.RoleAdmin))
+		handlers.RegisterDebugRoutes(debug, dbManager)
+		log.Println("Debug endpoints enabled under /debug")
+	}
+
+	// Admin frontend, shipped in the binary unless served from a directory.
+	// It calls the authenticated API, so its static files are public.
+	if cfg.AdminUIEnabled {
+		files, err := adminui.Files(cfg.AdminUIDir)
+		if err != nil {
+			log.Printf("Warning: Admin UI disabled: %v", err)
+		} else {
+			router.GET("/admin/*path", adminui.Handler(files))
+			log.Println("Admin UI enabled under /admin")
+		}
+	}
 
-	// Health check endpoint
-	router.GET("/health", handlers.HealthCheck)
+	// User signup, login, and token refresh, open to anonymous callers
+	if cfg.JWTSecret != "" {
+		accountsHandler := handlers.NewAccountsHandler(dbManager, accounts.Config{
+			Issuer:        auth.NewTokenIssuer(cfg.JWTSecret, cfg.JWTIssuer, time.Duration(cfg.JWTAccessTTLMinutes)*time.Minute),
+			RefreshTTL:    time.Duration(cfg.JWTRefreshTTLHours) * time.Hour,
+			SignupEnabled: cfg.SignupEnabled,
+		})
+		authRoutes := router.Group("/api/auth")
+		if auditRecorder != nil {
+			authRoutes.Use(auditRecorder.GinMiddleware())
+		}
+		if limiter != nil {
+			authRoutes.Use(limiter.GinMiddleware())
+		}
+		authRoutes.POST("/signup", accountsHandler.Signup)
+		authRoutes.POST("/login", accountsHandler.Login)
+		authRoutes.POST("/refresh", accountsHandler.Refresh)
+		authRoutes.POST("/logout", accountsHandler.Logout)
+		api.GET("/auth/me", accountsHandler.Me)
+	} else {
+		log.Println("User accounts disabled: set JWT_SECRET to enable signup and login")
+	}
 
-	// Create HTTP server
+	// REST/JSON gateway for the gRPC services
+	gateway, closeGateway, err := grpc_server.NewGateway(context.Background(), cfg)
+	if err != nil {
+		log.Printf("Warning: REST gateway disabled: %v", err)
+	} else {
+		components.RegisterFunc("REST gateway", func(context.Context) { closeGateway() })
+		for _, version := range grpc_server.APIVersions() {
+			router.Any("/"+version+"/*path", gin.WrapH(gateway))
+		}
+	}
+
+	// Create HTTP server with timeouts against slow clients. Long-lived
+	// responses are exempt from the write timeout.
+	longLivedPaths := []string{"/debug/pprof/profile", "/debug/pprof/trace"}
+	for _, version := range grpc_server.APIVersions() {
+		longLivedPaths = append(longLivedPaths, "/"+version+"/agent:stream")
+	}
 	httpServer := &http.Server{
-		Addr:    cfg.HTTPPort,
-		Handler: router,
+		Addr:              cfg.HTTPPort,
+		Handler:           handlers.WithoutWriteTimeout(router, longLivedPaths...),
+		ReadHeaderTimeout: time.Duration(cfg.HTTPReadHeaderTimeoutSeconds) * time.Second,
+		ReadTimeout:       time.Duration(cfg.HTTPReadTimeoutSeconds) * time.Second,
+		WriteTimeout:      time.Duration(cfg.HTTPWriteTimeoutSeconds) * time.Second,
+		IdleTimeout:       time.Duration(cfg.HTTPIdleTimeoutSeconds) * time.Second,
 	}
 
-	// Create gRPC server
-	grpcServer := grpc.NewServer()
-	grpc_server.RegisterServices(grpcServer, dbManager)
+	// Tag every RPC with a request ID and log it, including rejected calls,
+	// turn handler panics into Internal errors, and attach the caller's locale
+	unaryInterceptors := []grpc.UnaryServerInterceptor{
+		logging.UnaryServerInterceptor(logger),
+		logging.UnaryRecoveryInterceptor(),
+		requestctx.UnaryServerInterceptor(),
+	}
+	streamInterceptors := []grpc.StreamServerInterceptor{
+		logging.StreamServerInterceptor(logger),
+		logging.StreamRecoveryInterceptor(),
+		requestctx.StreamServerInterceptor(),
+	}
+
+	// Log the messages of a sample of RPCs, with sensitive fields redacted
+	if cfg.PayloadLogSampleRate > 0 {
+		payloads := logging.NewPayloadLogger(cfg.PayloadLogSampleRate, int(cfg.PayloadLogMaxSize))
+		unaryInterceptors = append(unaryInterceptors, payloads.UnaryServerInterceptor())
+		streamInterceptors = append(streamInterceptors, payloads.StreamServerInterceptor())
+		log.Printf("gRPC payload logging enabled for %g of calls", cfg.PayloadLogSampleRate)
+	}
+
+	// Accept compressed requests and compress responses for clients that
+	// advertise support
+	compressors, err := grpc_server.NewCompressors(cfg.GRPCCompression)
+	if err != nil {
+		log.Printf("Warning: gRPC response compression disabled: %v", err)
+	} else if len(compressors.Names()) > 0 {
+		unaryInterceptors = append(unaryInterceptors, compressors.UnaryServerInterceptor())
+		streamInterceptors = append(streamInterceptors, compressors.StreamServerInterceptor())
+		log.Printf("gRPC compression enabled: %s", strings.Join(compressors.Names(), ", "))
+	}
+
+	// Require an API key or JWT on every RPC
+	if authenticator != nil {
+		unaryInterceptors = append(unaryInterceptors, authenticator.UnaryServerInterceptor())
+		streamInterceptors = append(streamInterceptors, authenticator.StreamServerInterceptor())
+		log.Println("gRPC authentication enabled")
+	}
+
+	// Audit mutating calls once the actor is known, including calls the
+	// role check or rate limiter reject
+	if auditRecorder != nil {
+		unaryInterceptors = append(unaryInterceptors, auditRecorder.UnaryServerInterceptor())
+		streamInterceptors = append(streamInterceptors, auditRecorder.StreamServerInterceptor())
+	}
+
+	// Check the caller's role
+	if authenticator != nil {
+		unaryInterceptors = append(unaryInterceptors, policy.UnaryServerInterceptor())
+		streamInterceptors = append(streamInterceptors, policy.StreamServerInterceptor())
+	}
+
+	// Limit calls after authentication so they are keyed by principal
+	if limiter != nil {
+		unaryInterceptors = append(unaryInterceptors, limiter.UnaryServerInterceptor())
+		streamInterceptors = append(streamInterceptors, limiter.StreamServerInterceptor())
+	}
+
+	// Scope accepted calls to the principal's tenant and selected
+	// environment, provisioning their schema on first use
+	if tenants != nil {
+		unaryInterceptors = append(unaryInterceptors, tenants.UnaryServerInterceptor())
+		streamInterceptors = append(streamInterceptors, tenants.StreamServerInterceptor())
+		if cfg.TenancyEnabled {
+			log.Println("Multi-tenancy enabled")
+		}
+		if cfg.SchemaSandboxEnabled {
+			log.Println("Schema sandbox enabled")
+		}
+	}
+
+	// Count table RPCs in the tenant's schema
+	if tableTracker != nil {
+		unaryInterceptors = append(unaryInterceptors, tableTracker.UnaryServerInterceptor())
+	}
+
+	// Create gRPC server with a span per RPC, parented to the caller's trace,
+	// and the configured message size and keepalive limits
+	serverOpts := append(grpc_server.ServerOptions(cfg),
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(unaryInterceptors...),
+		grpc.ChainStreamInterceptor(streamInterceptors...),
+	)
+	if cfg.TLSCertFile != "" {
+		creds, err := credentials.NewServerTLSFromFile(cfg.TLSCertFile, cfg.TLSKeyFile)
+		if err != nil {
+			log.Fatalf("Failed to load TLS certificate: %v", err)
+		}
+		serverOpts = append(serverOpts, grpc.Creds(creds))
+	}
+	grpcServer := grpc.NewServer(serverOpts...)
+	components.Register("agent job workers", grpc_server.RegisterServices(grpcServer, cfg, dbManager, embedder, ingestionService, tenants, jobQueue))
 
 	// Register reflection service on gRPC server for grpcurl
-	reflection.Register(grpcServer)
+	if cfg.GRPCReflection {
+		reflection.Register(grpcServer)
+		log.Println("gRPC reflection enabled")
+	}
 
 	// Start gRPC server in a goroutine
 	go func() {
@@ -79,31 +608,72 @@ func main() {
 		}
 	}()
 
+	components.Register("gRPC server", func(ctx context.Context) error {
+		return grpc_server.Stop(ctx, grpcServer)
+	})
+
 	// Start HTTP server in a goroutine
 	go func() {
 		log.Printf("HTTP server starting on port %s", cfg.HTTPPort)
-		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		var err error
+		if cfg.TLSCertFile != "" {
+			err = httpServer.ListenAndServeTLS(cfg.TLSCertFile, cfg.TLSKeyFile)
+		} else {
+			err = httpServer.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			log.Fatalf("Failed to start HTTP server: %v", err)
 		}
 	}()
 
+	components.Register("HTTP server", httpServer.Shutdown)
+
+	// Registered last so it stops first: no new database connections,
+	// failovers, or secret reloads once shutdown begins
+	components.RegisterFunc("database watchers", func(context.Context) { stopConnecting() })
+
 	// Wait for interrupt signal to gracefully shutdown the server
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	log.Println("Shutting down servers...")
 
-	// Give outstanding requests a deadline for completion
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	// Drain every component within one deadline
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
 	defer cancel()
+	if err := components.Shutdown(ctx); err != nil {
+		log.Printf("Warning: shutdown finished with abandoned work: %v", err)
+	}
+
+	log.Println("Servers shutdown complete")
+}
 
-	// Shutdown HTTP server
-	if err := httpServer.Shutdown(ctx); err != nil {
-		log.Printf("HTTP server forced to shutdown: %v", err)
+// runMigrations applies pending migrations to a newly connected database.
+// They run over directURL when set, since the migration lock is a session
+// lock that a transaction pooler does not hold between statements.
+// Failures are logged so the API still starts (for development).
+func runMigrations(dbManager *db.Manager, directURL string, timeout time.Duration) {
+	pool := dbManager.GetPool()
+	if directURL != "" {
+		direct, err := db.NewDirectConnection(directURL)
+		if err != nil {
+			log.Printf("Warning: Failed to run migrations: %v", err)
+			return
+		}
+		defer direct.Close()
+		pool = direct.Pool
 	}
 
-	// Shutdown gRPC server
-	grpcServer.GracefulStop()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
 
-	log.Println("Servers shutdown complete")
-}
\ No newline at end of file
+	if err := migrations.RunMigrations(ctx, pool); err != nil {
+		log.Printf("Warning: Failed to run migrations: %v", err)
+		return
+	}
+
+	// Size the knowledge base's embeddings for the configured model
+	if err := embeddings.EnsureDimensions(ctx, pool); err != nil {
+		log.Printf("Warning: Failed to size knowledge base embeddings: %v", err)
+	}
+}
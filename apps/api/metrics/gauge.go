@@ -0,0 +1,184 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Gauge is a value per label combination that can go up and down
+type Gauge struct {
+	metricName string
+	help       string
+	labelNames []string
+
+	mu     sync.Mutex
+	values map[string]*series
+}
+
+// NewGauge creates a gauge registered with the default registry
+func NewGauge(name, help string, labelNames ...string) *Gauge {
+	g := &Gauge{
+		metricName: name,
+		help:       help,
+		labelNames: labelNames,
+		values:     map[string]*series{},
+	}
+	if len(labelNames) == 0 {
+		g.values[""] = &series{}
+	}
+	Default.register(g)
+	return g
+}
+
+// Set sets the series with the given label values
+func (g *Gauge) Set(value float64, labelValues ...string) {
+	checkLabels(g.metricName, g.labelNames, labelValues)
+	key := strings.Join(labelValues, "\xff")
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	s, ok := g.values[key]
+	if !ok {
+		s = &series{labelValues: append([]string(nil), labelValues...)}
+		g.values[key] = s
+	}
+	s.value = value
+}
+
+// Delete removes the series with the given label values, such as one
+// describing something that no longer exists
+func (g *Gauge) Delete(labelValues ...string) {
+	checkLabels(g.metricName, g.labelNames, labelValues)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.values, strings.Join(labelValues, "\xff"))
+}
+
+// name returns the metric name
+func (g *Gauge) name() string {
+	return g.metricName
+}
+
+// write writes the gauge's series sorted by label values
+func (g *Gauge) write(w io.Writer) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	writeHeader(w, g.metricName, g.help, "gauge")
+	for _, key := range sortedKeys(g.values) {
+		s := g.values[key]
+		writeSample(w, g.metricName, g.labelNames, s.labelValues, s.value)
+	}
+}
+
+// Histogram counts observations in cumulative buckets per label combination
+type Histogram struct {
+	metricName string
+	help       string
+	labelNames []string
+	buckets    []float64 // Upper bounds, ascending; +Inf is implied
+
+	mu     sync.Mutex
+	values map[string]*histogramSeries
+}
+
+// histogramSeries is the state of one label combination
+type histogramSeries struct {
+	labelValues []string
+	counts      []uint64 // Per bucket, not cumulative
+	count       uint64
+	sum         float64
+}
+
+// NewHistogram creates a histogram registered with the default registry
+func NewHistogram(name, help string, buckets []float64, labelNames ...string) *Histogram {
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	h := &Histogram{
+		metricName: name,
+		help:       help,
+		labelNames: labelNames,
+		buckets:    buckets,
+		values:     map[string]*histogramSeries{},
+	}
+	Default.register(h)
+	return h
+}
+
+// Observe records a value in the series with the given label values
+func (h *Histogram) Observe(value float64, labelValues ...string) {
+	checkLabels(h.metricName, h.labelNames, labelValues)
+	key := strings.Join(labelValues, "\xff")
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.values[key]
+	if !ok {
+		s = &histogramSeries{
+			labelValues: append([]string(nil), labelValues...),
+			counts:      make([]uint64, len(h.buckets)),
+		}
+		h.values[key] = s
+	}
+	if i := sort.SearchFloat64s(h.buckets, value); i < len(h.buckets) {
+		s.counts[i]++
+	}
+	s.count++
+	s.sum += value
+}
+
+// name returns the metric name
+func (h *Histogram) name() string {
+	return h.metricName
+}
+
+// write writes the bucket, sum, and count samples of each series
+func (h *Histogram) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	writeHeader(w, h.metricName, h.help, "histogram")
+	bucketLabels := append(append([]string(nil), h.labelNames...), "le")
+	for _, key := range sortedKeys(h.values) {
+		s := h.values[key]
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += s.counts[i]
+			writeSample(w, h.metricName+"_bucket", bucketLabels,
+				append(append([]string(nil), s.labelValues...), formatValue(bound)), float64(cumulative))
+		}
+		writeSample(w, h.metricName+"_bucket", bucketLabels,
+			append(append([]string(nil), s.labelValues...), "+Inf"), float64(s.count))
+		writeSample(w, h.metricName+"_sum", h.labelNames, s.labelValues, s.sum)
+		writeSample(w, h.metricName+"_count", h.labelNames, s.labelValues, float64(s.count))
+	}
+}
+
+// checkLabels panics when a metric is given the wrong number of label values
+func checkLabels(name string, labelNames, labelValues []string) {
+	if len(labelValues) != len(labelNames) {
+		panic(fmt.Sprintf("metrics: %s takes %d label values, got %d", name, len(labelNames), len(labelValues)))
+	}
+}
+
+// sortedKeys returns a series map's keys in order
+func sortedKeys[V any](values map[string]V) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ExponentialBuckets returns count bucket bounds starting at start, each
+// factor times the previous one
+func ExponentialBuckets(start, factor float64, count int) []float64 {
+	buckets := make([]float64, count)
+	for i := range buckets {
+		buckets[i] = start
+		start *= factor
+	}
+	return buckets
+}
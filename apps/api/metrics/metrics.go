@@ -0,0 +1,192 @@
+// Package metrics keeps process metrics and serves them in the Prometheus
+// text exposition format
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// contentType is the Prometheus text exposition format
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// collector is a metric family the registry can write
+type collector interface {
+	name() string
+	write(w io.Writer)
+}
+
+// Registry holds the metrics served by a handler
+type Registry struct {
+	mu         sync.Mutex
+	collectors map[string]collector
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{collectors: map[string]collector{}}
+}
+
+// Default is the registry the package-level constructors register with
+var Default = NewRegistry()
+
+// register adds a metric, panicking on a duplicate name like expvar does
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.collectors[c.name()]; exists {
+		panic("metrics: duplicate metric " + c.name())
+	}
+	r.collectors[c.name()] = c
+}
+
+// Handler serves the registry's metrics sorted by name
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		r.mu.Lock()
+		collectors := make([]collector, 0, len(r.collectors))
+		for _, c := range r.collectors {
+			collectors = append(collectors, c)
+		}
+		r.mu.Unlock()
+
+		sort.Slice(collectors, func(i, j int) bool {
+			return collectors[i].name() < collectors[j].name()
+		})
+		w.Header().Set("Content-Type", contentType)
+		for _, c := range collectors {
+			c.write(w)
+		}
+	})
+}
+
+// Handler serves the default registry
+func Handler() http.Handler {
+	return Default.Handler()
+}
+
+// Counter is a monotonically increasing value per label combination
+type Counter struct {
+	metricName string
+	help       string
+	labelNames []string
+
+	mu     sync.Mutex
+	values map[string]*series
+}
+
+// series is the value of one label combination
+type series struct {
+	labelValues []string
+	value       float64
+}
+
+// NewCounter creates a counter registered with the default registry
+func NewCounter(name, help string, labelNames ...string) *Counter {
+	c := &Counter{
+		metricName: name,
+		help:       help,
+		labelNames: labelNames,
+		values:     map[string]*series{},
+	}
+	if len(labelNames) == 0 {
+		// Expose unlabeled counters from zero rather than omitting them
+		c.values[""] = &series{}
+	}
+	Default.register(c)
+	return c
+}
+
+// Inc adds one to the series with the given label values
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds a non-negative amount to the series with the given label values,
+// given in the order of the counter's label names
+func (c *Counter) Add(delta float64, labelValues ...string) {
+	checkLabels(c.metricName, c.labelNames, labelValues)
+	if delta < 0 {
+		return
+	}
+
+	key := strings.Join(labelValues, "\xff")
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, ok := c.values[key]
+	if !ok {
+		s = &series{labelValues: append([]string(nil), labelValues...)}
+		c.values[key] = s
+	}
+	s.value += delta
+}
+
+// name returns the metric name
+func (c *Counter) name() string {
+	return c.metricName
+}
+
+// write writes the counter's series sorted by label values
+func (c *Counter) write(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	writeHeader(w, c.metricName, c.help, "counter")
+	for _, key := range sortedKeys(c.values) {
+		s := c.values[key]
+		writeSample(w, c.metricName, c.labelNames, s.labelValues, s.value)
+	}
+}
+
+// writeHeader writes the HELP and TYPE lines of a metric family
+func writeHeader(w io.Writer, name, help, metricType string) {
+	help = strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType)
+}
+
+// writeSample writes one sample line
+func writeSample(w io.Writer, name string, labelNames, labelValues []string, value float64) {
+	var sb strings.Builder
+	sb.WriteString(name)
+	if len(labelNames) > 0 {
+		sb.WriteByte('{')
+		for i, labelName := range labelNames {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			sb.WriteString(labelName)
+			sb.WriteString(`="`)
+			sb.WriteString(escapeLabelValue(labelValues[i]))
+			sb.WriteByte('"')
+		}
+		sb.WriteByte('}')
+	}
+	sb.WriteByte(' ')
+	sb.WriteString(formatValue(value))
+	sb.WriteByte('\n')
+	io.WriteString(w, sb.String())
+}
+
+// escapeLabelValue escapes backslashes, quotes, and newlines
+func escapeLabelValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+// formatValue formats a sample value, spelling out infinities
+func formatValue(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
@@ -0,0 +1,331 @@
+// Package queue is a durable job queue in the background_jobs table. Jobs
+// are claimed with FOR UPDATE SKIP LOCKED so any number of workers across
+// instances can share the queue, retried with exponential backoff when
+// they fail, and dead-lettered after their last attempt. A job may run more
+// than once, for instance after a crash, so handlers must be idempotent.
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"agentic-template/api/db"
+	"agentic-template/api/requestctx"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrJobNotFound is returned when a job does not exist
+var ErrJobNotFound = errors.New("background job not found")
+
+// Job statuses
+const (
+	StatusPending   = "PENDING"
+	StatusRunning   = "RUNNING"
+	StatusSucceeded = "SUCCEEDED"
+	StatusDead      = "DEAD" // Failed its last attempt; see Retry
+)
+
+// DefaultMaxAttempts is how often a job runs before it is dead-lettered
+// when enqueued without a limit
+const DefaultMaxAttempts = 5
+
+// Job is a unit of background work
+type Job struct {
+	ID          int64           `json:"id"`
+	Kind        string          `json:"kind"`
+	Payload     json.RawMessage `json:"payload"`
+	Status      string          `json:"status"` // PENDING, RUNNING, SUCCEEDED, DEAD
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	RunAt       time.Time       `json:"run_at"`
+	LastError   *string         `json:"last_error,omitempty"`
+	LockedAt    *time.Time      `json:"locked_at,omitempty"`
+	CreatedBy   *string         `json:"created_by,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	CompletedAt *time.Time      `json:"completed_at,omitempty"`
+}
+
+// Decode unmarshals the job's payload into v
+func (j *Job) Decode(v any) error {
+	if err := json.Unmarshal(j.Payload, v); err != nil {
+		return fmt.Errorf("failed to decode %s job payload: %w", j.Kind, err)
+	}
+	return nil
+}
+
+// EnqueueOptions tune a single job
+type EnqueueOptions struct {
+	MaxAttempts int       // Defaults to DefaultMaxAttempts
+	RunAt       time.Time // Delays the job until then; zero runs it at once
+}
+
+// Queue stores jobs in the managed database, following reloads
+type Queue struct {
+	dbManager *db.Manager
+	wake      chan struct{}
+}
+
+// New creates a queue on the managed database
+func New(dbManager *db.Manager) *Queue {
+	return &Queue{dbManager: dbManager, wake: make(chan struct{}, 1)}
+}
+
+// jobColumns is the column list shared by job queries
+const jobColumns = `id, kind, payload, status, attempts, max_attempts, run_at, last_error, locked_at, created_by, created_at, updated_at, completed_at`
+
+// Enqueue adds a job of the given kind with a JSON-encoded payload and wakes
+// an idle local worker. The job is recorded as created by the actor of ctx.
+func (q *Queue) Enqueue(ctx context.Context, kind string, payload any, opts EnqueueOptions) (*Job, error) {
+	pool := q.dbManager.GetPool()
+	if pool == nil {
+		return nil, db.ErrDatabaseNotConfigured
+	}
+	if kind == "" {
+		return nil, fmt.Errorf("job kind is required")
+	}
+
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s job payload: %w", kind, err)
+	}
+	maxAttempts := opts.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultMaxAttempts
+	}
+	runAt := opts.RunAt
+	if runAt.IsZero() {
+		runAt = time.Now()
+	}
+
+	query := `
+		INSERT INTO background_jobs (kind, payload, max_attempts, run_at, created_by)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING ` + jobColumns
+	job, err := scanJob(pool.QueryRow(ctx, query, kind, payloadJSON, maxAttempts, runAt, requestctx.Actor(ctx)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to enqueue %s job: %w", kind, err)
+	}
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return job, nil
+}
+
+// claim marks the next due job of the given kinds as running, counting the
+// attempt, and returns it, or nil when none is due. Concurrent workers
+// never claim the same job.
+func (q *Queue) claim(ctx context.Context, kinds []string) (*Job, error) {
+	pool := q.dbManager.GetPool()
+	if pool == nil || len(kinds) == 0 {
+		return nil, nil
+	}
+
+	query := `
+		UPDATE background_jobs
+		SET status = $1, attempts = attempts + 1, locked_at = NOW()
+		WHERE id = (
+			SELECT id FROM background_jobs
+			WHERE status = $2 AND run_at <= NOW() AND kind = ANY($3)
+			ORDER BY run_at, id
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + jobColumns
+	job, err := scanJob(pool.QueryRow(ctx, query, StatusRunning, StatusPending, kinds))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to claim background job: %w", err)
+	}
+	return job, nil
+}
+
+// complete marks a running job as succeeded
+func (q *Queue) complete(ctx context.Context, id int64) error {
+	return q.exec(ctx, `
+		UPDATE background_jobs
+		SET status = $2, last_error = NULL, locked_at = NULL, completed_at = NOW()
+		WHERE id = $1`, id, StatusSucceeded)
+}
+
+// fail schedules another attempt of a running job after a backoff, or
+// dead-letters it after its last attempt. It reports whether the job died.
+func (q *Queue) fail(ctx context.Context, job *Job, cause error, backoff time.Duration) (bool, error) {
+	if job.Attempts >= job.MaxAttempts {
+		return true, q.exec(ctx, `
+			UPDATE background_jobs
+			SET status = $2, last_error = $3, locked_at = NULL, completed_at = NOW()
+			WHERE id = $1`, job.ID, StatusDead, cause.Error())
+	}
+	return false, q.exec(ctx, `
+		UPDATE background_jobs
+		SET status = $2, last_error = $3, locked_at = NULL, run_at = NOW() + $4 * INTERVAL '1 millisecond'
+		WHERE id = $1`, job.ID, StatusPending, cause.Error(), backoff.Milliseconds())
+}
+
+// RequeueStale returns jobs whose worker stopped mid-run, claimed before
+// lockedBefore, to the queue, or dead-letters them after their last
+// attempt. It returns how many jobs were recovered.
+func (q *Queue) RequeueStale(ctx context.Context, lockedBefore time.Time) (int64, error) {
+	pool := q.dbManager.GetPool()
+	if pool == nil {
+		return 0, db.ErrDatabaseNotConfigured
+	}
+
+	query := `
+		UPDATE background_jobs
+		SET status = CASE WHEN attempts >= max_attempts THEN $1 ELSE $2 END,
+			last_error = 'worker stopped before the job finished',
+			locked_at = NULL,
+			completed_at = CASE WHEN attempts >= max_attempts THEN NOW() END
+		WHERE status = $3 AND locked_at < $4`
+	tag, err := pool.Exec(ctx, query, StatusDead, StatusPending, StatusRunning, lockedBefore)
+	if err != nil {
+		return 0, fmt.Errorf("failed to requeue stale background jobs: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// Get retrieves a job by ID
+func (q *Queue) Get(ctx context.Context, id int64) (*Job, error) {
+	pool := q.dbManager.GetPool()
+	if pool == nil {
+		return nil, db.ErrDatabaseNotConfigured
+	}
+
+	job, err := scanJob(pool.QueryRow(ctx, `SELECT `+jobColumns+` FROM background_jobs WHERE id = $1`, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrJobNotFound
+		}
+		return nil, fmt.Errorf("failed to query background job: %w", err)
+	}
+	return job, nil
+}
+
+// ListDead returns the most recently dead-lettered jobs
+func (q *Queue) ListDead(ctx context.Context, limit int) ([]Job, error) {
+	pool := q.dbManager.GetPool()
+	if pool == nil {
+		return nil, db.ErrDatabaseNotConfigured
+	}
+	if limit <= 0 {
+		limit = 100
+	}
+
+	query := `SELECT ` + jobColumns + ` FROM background_jobs WHERE status = $1 ORDER BY completed_at DESC, id DESC LIMIT $2`
+	rows, err := pool.Query(ctx, query, StatusDead, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dead background jobs: %w", err)
+	}
+	defer rows.Close()
+
+	jobs := []Job{}
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan background job: %w", err)
+		}
+		jobs = append(jobs, *job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list dead background jobs: %w", err)
+	}
+	return jobs, nil
+}
+
+// Stats summarizes the backlog of a queue
+type Stats struct {
+	Due       int64      // Pending jobs whose run time has come
+	OldestDue *time.Time // Run time of the longest-waiting due job; nil when none is due
+	Running   int64
+	Dead      int64
+}
+
+// Stats counts the due, running, and dead jobs
+func (q *Queue) Stats(ctx context.Context) (*Stats, error) {
+	pool := q.dbManager.GetPool()
+	if pool == nil {
+		return nil, db.ErrDatabaseNotConfigured
+	}
+
+	var stats Stats
+	query := `
+		SELECT
+			COUNT(*) FILTER (WHERE status = $1 AND run_at <= NOW()),
+			MIN(run_at) FILTER (WHERE status = $1 AND run_at <= NOW()),
+			COUNT(*) FILTER (WHERE status = $2),
+			COUNT(*) FILTER (WHERE status = $3)
+		FROM background_jobs
+		WHERE status IN ($1, $2, $3)`
+	err := pool.QueryRow(ctx, query, StatusPending, StatusRunning, StatusDead).Scan(&stats.Due, &stats.OldestDue, &stats.Running, &stats.Dead)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count background jobs: %w", err)
+	}
+	return &stats, nil
+}
+
+// Retry returns a dead job to the queue with a fresh set of attempts. It
+// returns ErrJobNotFound unless the job exists and is dead.
+func (q *Queue) Retry(ctx context.Context, id int64) error {
+	err := q.exec(ctx, `
+		UPDATE background_jobs
+		SET status = $2, attempts = 0, run_at = NOW(), completed_at = NULL
+		WHERE id = $1 AND status = $3`, id, StatusPending, StatusDead)
+	if err == nil {
+		select {
+		case q.wake <- struct{}{}:
+		default:
+		}
+	}
+	return err
+}
+
+// exec runs an update of a single job
+func (q *Queue) exec(ctx context.Context, query string, args ...any) error {
+	pool := q.dbManager.GetPool()
+	if pool == nil {
+		return db.ErrDatabaseNotConfigured
+	}
+
+	tag, err := pool.Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to update background job: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrJobNotFound
+	}
+	return nil
+}
+
+// scanJob scans a job row in jobColumns order
+func scanJob(row pgx.Row) (*Job, error) {
+	var job Job
+	err := row.Scan(
+		&job.ID,
+		&job.Kind,
+		&job.Payload,
+		&job.Status,
+		&job.Attempts,
+		&job.MaxAttempts,
+		&job.RunAt,
+		&job.LastError,
+		&job.LockedAt,
+		&job.CreatedBy,
+		&job.CreatedAt,
+		&job.UpdatedAt,
+		&job.CompletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
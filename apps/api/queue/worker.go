@@ -0,0 +1,228 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"agentic-template/api/logging"
+	"agentic-template/api/metrics"
+)
+
+// Worker defaults
+const (
+	DefaultWorkers        = 4
+	DefaultPollInterval   = 2 * time.Second
+	DefaultJobTimeout     = 10 * time.Minute
+	DefaultInitialBackoff = 10 * time.Second
+	DefaultMaxBackoff     = time.Hour
+
+	// finishTimeout bounds recording a job's outcome, which happens even
+	// while the pool shuts down
+	finishTimeout = 10 * time.Second
+	// staleCheckInterval is how often jobs of crashed workers are recovered
+	staleCheckInterval = time.Minute
+)
+
+var jobsTotal = metrics.NewCounter("background_jobs_total",
+	"Background job attempts, by kind and outcome (succeeded, retried, or dead).", "kind", "outcome")
+
+// Handler runs a job. A returned error schedules a retry, or dead-letters
+// the job after its last attempt.
+type Handler func(ctx context.Context, job *Job) error
+
+// PoolConfig tunes a worker pool
+type PoolConfig struct {
+	Workers        int           // Concurrent jobs; defaults to DefaultWorkers
+	PollInterval   time.Duration // How often idle workers look for due jobs
+	JobTimeout     time.Duration // Longest a job may run; longer runs are presumed crashed
+	InitialBackoff time.Duration // Wait before the first retry, doubled after each failure
+	MaxBackoff     time.Duration // Longest wait between retries
+}
+
+// Pool is a fixed set of workers running the queue's jobs with the
+// registered handlers
+type Pool struct {
+	queue    *Queue
+	config   PoolConfig
+	handlers map[string]Handler
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewPool creates a worker pool for the queue; register handlers, then call
+// Start
+func NewPool(queue *Queue, cfg PoolConfig) *Pool {
+	if cfg.Workers <= 0 {
+		cfg.Workers = DefaultWorkers
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = DefaultPollInterval
+	}
+	if cfg.JobTimeout <= 0 {
+		cfg.JobTimeout = DefaultJobTimeout
+	}
+	if cfg.InitialBackoff <= 0 {
+		cfg.InitialBackoff = DefaultInitialBackoff
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = DefaultMaxBackoff
+	}
+	return &Pool{queue: queue, config: cfg, handlers: map[string]Handler{}}
+}
+
+// Register sets the handler of a job kind. It must be called before Start;
+// workers only claim jobs of registered kinds.
+func (p *Pool) Register(kind string, handler Handler) {
+	p.handlers[kind] = handler
+}
+
+// Start launches the workers and the recovery of jobs left running by
+// crashed workers
+func (p *Pool) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+
+	kinds := make([]string, 0, len(p.handlers))
+	for kind := range p.handlers {
+		kinds = append(kinds, kind)
+	}
+	slices.Sort(kinds)
+
+	p.wg.Add(1)
+	go p.recoverStale(ctx)
+	for i := 0; i < p.config.Workers; i++ {
+		p.wg.Add(1)
+		go p.work(ctx, kinds)
+	}
+	log.Printf("Background job pool started with %d worker(s) for %d kind(s)", p.config.Workers, len(kinds))
+}
+
+// Stop cancels running jobs and waits for the workers to exit or ctx to
+// expire. Cancelled jobs are retried like failed ones.
+func (p *Pool) Stop(ctx context.Context) error {
+	if p.cancel == nil {
+		return nil
+	}
+	p.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("background job workers still running: %w", ctx.Err())
+	}
+}
+
+// work claims and runs jobs until the pool is stopped
+func (p *Pool) work(ctx context.Context, kinds []string) {
+	defer p.wg.Done()
+
+	for {
+		job, err := p.queue.claim(ctx, kinds)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Warning: failed to claim background job: %v", err)
+		}
+
+		if job != nil {
+			p.run(ctx, job)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-p.queue.wake:
+		case <-time.After(p.config.PollInterval):
+		}
+	}
+}
+
+// recoverStale periodically requeues jobs claimed longer ago than the job
+// timeout allows, whose worker must have stopped
+func (p *Pool) recoverStale(ctx context.Context) {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(staleCheckInterval)
+	defer ticker.Stop()
+	for {
+		// Leave running jobs a minute past their timeout to record an outcome
+		lockedBefore := time.Now().Add(-p.config.JobTimeout - time.Minute)
+		if recovered, err := p.queue.RequeueStale(ctx, lockedBefore); err == nil && recovered > 0 {
+			log.Printf("Recovered %d stale background job(s)", recovered)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// handle runs the job's handler within the job timeout, failing the job
+// instead of the worker when the handler panics
+func (p *Pool) handle(ctx context.Context, job *Job) (err error) {
+	handler, ok := p.handlers[job.Kind]
+	if !ok {
+		return fmt.Errorf("no handler for job kind %q", job.Kind)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.config.JobTimeout)
+	defer cancel()
+	defer func() {
+		if value := recover(); value != nil {
+			err = logging.RecoverPanic(ctx, value)
+		}
+	}()
+	return handler(ctx, job)
+}
+
+// run executes a job and records its outcome
+func (p *Pool) run(ctx context.Context, job *Job) {
+	runErr := p.handle(ctx, job)
+
+	// The pool context may be cancelled during shutdown
+	finishCtx, cancel := context.WithTimeout(context.Background(), finishTimeout)
+	defer cancel()
+
+	if runErr == nil {
+		jobsTotal.Inc(job.Kind, "succeeded")
+		if err := p.queue.complete(finishCtx, job.ID); err != nil {
+			log.Printf("Warning: failed to mark background job %d as succeeded: %v", job.ID, err)
+		}
+		return
+	}
+
+	dead, err := p.queue.fail(finishCtx, job, runErr, p.backoff(job.Attempts))
+	if err != nil {
+		log.Printf("Warning: failed to record failure of background job %d: %v", job.ID, err)
+		return
+	}
+	if dead {
+		jobsTotal.Inc(job.Kind, "dead")
+		log.Printf("Background job %d (%s) dead after %d attempt(s): %v", job.ID, job.Kind, job.Attempts, runErr)
+		return
+	}
+	jobsTotal.Inc(job.Kind, "retried")
+	log.Printf("Background job %d (%s) attempt %d/%d failed, retrying: %v", job.ID, job.Kind, job.Attempts, job.MaxAttempts, runErr)
+}
+
+// backoff returns the wait after the given failed attempt (1-based)
+func (p *Pool) backoff(attempt int) time.Duration {
+	wait := p.config.InitialBackoff
+	for i := 1; i < attempt && wait < p.config.MaxBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, p.config.MaxBackoff)
+}
@@ -0,0 +1,42 @@
+package ratelimit
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"agentic-template/api/requestctx"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GinMiddleware rejects HTTP requests over the caller's limit with 429 and
+// a Retry-After header. Callers are keyed by principal when authenticated,
+// otherwise by client address; GET and HEAD requests are reads and
+// everything else is a write.
+func (l *Limiter) GinMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		class := ClassWrite
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			class = ClassRead
+		}
+
+		caller := "ip:" + c.ClientIP()
+		if principal, ok := requestctx.PrincipalFrom(c.Request.Context()); ok {
+			caller = "principal:" + principal.ID
+		}
+
+		allowed, wait := l.Allow(class, caller)
+		if allowed {
+			c.Next()
+			return
+		}
+
+		seconds := retryAfterSeconds(wait)
+		c.Header("Retry-After", strconv.Itoa(seconds))
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+			"error":               fmt.Sprintf("rate limit exceeded for %s requests, retry in %ds", class, seconds),
+			"retry_after_seconds": seconds,
+		})
+	}
+}
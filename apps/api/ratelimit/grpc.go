@@ -0,0 +1,122 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"agentic-template/api/auth"
+	"agentic-template/api/logging"
+	"agentic-template/api/requestctx"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// RetryAfterHeader is the response metadata telling a limited caller how
+// many seconds to wait
+const RetryAfterHeader = "retry-after"
+
+// writeMethods are the RPCs limited as writes, keyed by auth.ServiceMethod
+// so they cover every API version; every other RPC is a read. Agent runs
+// are writes, as their REST routes are, because their tools can change
+// data.
+var writeMethods = map[string]bool{
+	"AgentService/StreamAgentResponse":         true,
+	"AgentService/Chat":                        true,
+	"AgentService/SubmitAgentJob":              true,
+	"SchemaService/CreateTable":                true,
+	"SchemaService/DeleteTable":                true,
+	"SchemaService/RestoreTable":               true,
+	"SchemaService/PurgeTable":                 true,
+	"SchemaService/ReloadDatabase":             true,
+	"SchemaService/PatchJSONValue":             true,
+	"SchemaService/CheckTableIntegrity":        true,
+	"SchemaService/ApproveSchemaChangeRequest": true,
+	"SchemaService/RejectSchemaChangeRequest":  true,
+	"SchemaService/ResetSchemaSandbox":         true,
+	"SchemaService/PromoteSchemaSandbox":       true,
+	"SchemaService/AnonymizeTable":             true,
+	"SchemaService/StartImport":                true,
+	"SchemaService/StopImportSync":             true,
+	"SchemaService/CreateWarehouseSync":        true,
+	"SchemaService/PauseWarehouseSync":         true,
+	"SchemaService/ResumeWarehouseSync":        true,
+	"SchemaService/DeleteWarehouseSync":        true,
+	"AgentProfileService/CreateAgentProfile":   true,
+	"AgentProfileService/DeleteAgentProfile":   true,
+	"AgentProfileService/SetAgentProfileTools": true,
+	"AgentProfileService/SetToolEnabled":       true,
+	"KnowledgeService/IngestDocument":          true,
+}
+
+// methodClass returns the class of a full RPC method name
+func methodClass(fullMethod string) Class {
+	if writeMethods[auth.ServiceMethod(fullMethod)] {
+		return ClassWrite
+	}
+	return ClassRead
+}
+
+// rpcCaller identifies the caller of an RPC: its principal when
+// authenticated, otherwise its address. Calls proxied by the REST gateway
+// over loopback are keyed by the address the gateway forwarded.
+func rpcCaller(ctx context.Context) string {
+	if principal, ok := requestctx.PrincipalFrom(ctx); ok {
+		return "principal:" + principal.ID
+	}
+
+	host := logging.ClientIP(ctx)
+	if host == "" {
+		return "unknown"
+	}
+	return "ip:" + host
+}
+
+// check takes a token for the RPC and returns a ResourceExhausted error
+// with retry details when the caller is over its limit
+func (l *Limiter) check(ctx context.Context, fullMethod string) error {
+	class := methodClass(fullMethod)
+	allowed, wait := l.Allow(class, rpcCaller(ctx))
+	if allowed {
+		return nil
+	}
+
+	seconds := retryAfterSeconds(wait)
+	_ = grpc.SetHeader(ctx, metadata.Pairs(RetryAfterHeader, strconv.Itoa(seconds)))
+
+	st := status.New(codes.ResourceExhausted, fmt.Sprintf("rate limit exceeded for %s requests, retry in %ds", class, seconds))
+	if detailed, err := st.WithDetails(&errdetails.RetryInfo{
+		RetryDelay: durationpb.New(time.Duration(seconds) * time.Second),
+	}); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+// UnaryServerInterceptor rejects unary calls over the caller's limit. It
+// must run after authentication to key calls by principal.
+func (l *Limiter) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := l.check(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor rejects streams over the caller's limit. Each
+// stream takes one token when it opens.
+func (l *Limiter) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := l.check(stream.Context(), info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, stream)
+	}
+}
@@ -0,0 +1,118 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Class groups methods sharing a rate limit
+type Class string
+
+// Method classes. Writes change schema or stored configuration and get a
+// stricter limit than reads.
+const (
+	ClassRead  Class = "read"
+	ClassWrite Class = "write"
+)
+
+// idleTimeout is how long an unused bucket is kept before it is dropped
+const idleTimeout = 10 * time.Minute
+
+// Limit is the token bucket of a method class
+type Limit struct {
+	PerMinute int // Sustained requests per minute; 0 disables the limit
+	Burst     int // Requests allowed at once; defaults to PerMinute
+}
+
+// Config configures the limiter
+type Config struct {
+	Limits map[Class]Limit
+}
+
+// Limiter keeps a token bucket per caller and method class
+type Limiter struct {
+	limits map[Class]Limit
+
+	mu        sync.Mutex
+	buckets   map[bucketKey]*bucket
+	lastSweep time.Time
+}
+
+type bucketKey struct {
+	class  Class
+	caller string
+}
+
+type bucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// New creates a limiter with the given limits
+func New(cfg Config) *Limiter {
+	return &Limiter{
+		limits:    cfg.Limits,
+		buckets:   map[bucketKey]*bucket{},
+		lastSweep: time.Now(),
+	}
+}
+
+// Allow takes a token from the caller's bucket for the class. When the
+// bucket is empty it returns false and how long to wait before retrying.
+func (l *Limiter) Allow(class Class, caller string) (bool, time.Duration) {
+	limit, ok := l.limits[class]
+	if !ok || limit.PerMinute <= 0 {
+		return true, 0
+	}
+
+	now := time.Now()
+	limiter := l.bucket(class, caller, limit, now)
+
+	reservation := limiter.ReserveN(now, 1)
+	if !reservation.OK() {
+		return false, time.Minute
+	}
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		reservation.CancelAt(now)
+		return false, delay
+	}
+	return true, 0
+}
+
+// bucket returns the caller's bucket for the class, creating it on first
+// use, and drops buckets that have been idle for a while
+func (l *Limiter) bucket(class Class, caller string, limit Limit, now time.Time) *rate.Limiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) > idleTimeout {
+		for key, b := range l.buckets {
+			if now.Sub(b.lastSeen) > idleTimeout {
+				delete(l.buckets, key)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	key := bucketKey{class: class, caller: caller}
+	b, ok := l.buckets[key]
+	if !ok {
+		burst := limit.Burst
+		if burst <= 0 {
+			burst = limit.PerMinute
+		}
+		b = &bucket{limiter: rate.NewLimiter(rate.Limit(float64(limit.PerMinute)/60), burst)}
+		l.buckets[key] = b
+	}
+	b.lastSeen = now
+	return b.limiter
+}
+
+// retryAfterSeconds rounds a wait up to whole seconds, as used by the
+// Retry-After header
+func retryAfterSeconds(wait time.Duration) int {
+	return int(math.Max(1, math.Ceil(wait.Seconds())))
+}
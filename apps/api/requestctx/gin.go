@@ -0,0 +1,16 @@
+package requestctx
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// GinMiddleware attaches the locale of the Accept-Language header to the
+// request context
+func GinMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if locale := ParseLocale(c.GetHeader(LocaleHeader)); locale != "" {
+			c.Request = c.Request.WithContext(WithLocale(c.Request.Context(), locale))
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,46 @@
+package requestctx
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// withIncomingLocale attaches the locale of the "accept-language" metadata
+// to the context. The REST gateway forwards the HTTP header under the same
+// name.
+func withIncomingLocale(ctx context.Context) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, value := range md.Get(LocaleHeader) {
+		if locale := ParseLocale(value); locale != "" {
+			return WithLocale(ctx, locale)
+		}
+	}
+	return ctx
+}
+
+// UnaryServerInterceptor attaches the caller's locale to unary calls
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		return handler(withIncomingLocale(ctx), req)
+	}
+}
+
+// StreamServerInterceptor attaches the caller's locale to streams
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &localeStream{ServerStream: stream, ctx: withIncomingLocale(stream.Context())})
+	}
+}
+
+// localeStream overrides the context of a server stream
+type localeStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns the stream's context with the locale attached
+func (s *localeStream) Context() context.Context {
+	return s.ctx
+}
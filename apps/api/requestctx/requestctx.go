@@ -0,0 +1,176 @@
+// Package requestctx carries the values scoped to one request through its
+// context: the request ID, the authenticated principal or the actor of
+// background work, the tenant, and the caller's locale. Interceptors and
+// middleware set them; the schema manager, job stores, and audit log read
+// them here rather than taking them as parameters.
+package requestctx
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// SystemActor is the actor recorded for calls made without a principal
+const SystemActor = "system"
+
+// LocaleHeader is the header (and gRPC metadata key) naming the caller's
+// preferred languages
+const LocaleHeader = "accept-language"
+
+// localePattern accepts BCP 47 language tags such as "en" or "pt-BR"
+var localePattern = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{1,8}){0,3}$`)
+
+// Principal is the authenticated caller of a request
+type Principal struct {
+	ID    string   `json:"id"`
+	Type  string   `json:"type"` // api_key or user
+	Roles []string `json:"roles,omitempty"`
+	// Tenant whose data the principal works on; empty uses the shared
+	// (public) schema
+	Tenant string `json:"tenant,omitempty"`
+}
+
+// HasRole reports whether the principal has the given role
+func (p *Principal) HasRole(role string) bool {
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// tenant is the tenant a request is scoped to
+type tenant struct {
+	name   string
+	schema string
+}
+
+type requestIDKey struct{}
+type principalKey struct{}
+type roleKey struct{}
+type actorKey struct{}
+type tenantKey struct{}
+type localeKey struct{}
+
+// WithRequestID returns a context carrying the request ID
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestID returns the ID of the current request, if any
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// WithPrincipal returns a context carrying the principal
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFrom returns the principal of the request, if authenticated
+func PrincipalFrom(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+	return principal, ok && principal != nil
+}
+
+// WithRole returns a context carrying the role authorization resolved for
+// the principal: its highest role, or the default role when it has none
+func WithRole(ctx context.Context, role string) context.Context {
+	return context.WithValue(ctx, roleKey{}, role)
+}
+
+// Role returns the principal's resolved role, or "" when the request was
+// not authorized by role (no principal, or authentication disabled)
+func Role(ctx context.Context) string {
+	role, _ := ctx.Value(roleKey{}).(string)
+	return role
+}
+
+// WithActor returns a context acting on behalf of an actor without its
+// credentials, for background work such as queued jobs. The actor is only
+// recorded; it grants no roles.
+func WithActor(ctx context.Context, actorID string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actorID)
+}
+
+// Actor returns the ID recorded as the actor of changes made by the
+// request: its principal, the actor of background work, or SystemActor
+func Actor(ctx context.Context) string {
+	if principal, ok := PrincipalFrom(ctx); ok {
+		return principal.ID
+	}
+	if actorID, ok := ctx.Value(actorKey{}).(string); ok && actorID != "" {
+		return actorID
+	}
+	return SystemActor
+}
+
+// WithTenant returns a context scoped to a tenant, whose queries resolve
+// unqualified table names in its schema before public on pools created
+// with db.PoolConfig.ScopeSearchPath. Background work
+// that only knows the schema passes an empty name.
+func WithTenant(ctx context.Context, name, schema string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant{name: name, schema: schema})
+}
+
+// Tenant returns the name of the request's tenant, or "" for the shared
+// schema
+func Tenant(ctx context.Context) string {
+	t, _ := ctx.Value(tenantKey{}).(tenant)
+	return t.name
+}
+
+// TenantSchema returns the schema of the request's tenant, or "" when
+// queries use the shared schema
+func TenantSchema(ctx context.Context) string {
+	t, _ := ctx.Value(tenantKey{}).(tenant)
+	return t.schema
+}
+
+// WithLocale returns a context carrying the caller's locale
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeKey{}, locale)
+}
+
+// Locale returns the caller's preferred language tag, or "" when it sent
+// none
+func Locale(ctx context.Context) string {
+	locale, _ := ctx.Value(localeKey{}).(string)
+	return locale
+}
+
+// ParseLocale returns the most preferred language tag of an
+// Accept-Language value, or "" when it names none. Tags are returned as
+// sent; quality weights are honored but wildcards are skipped.
+func ParseLocale(acceptLanguage string) string {
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.TrimSpace(tag)
+		if !localePattern.MatchString(tag) {
+			continue
+		}
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			q = parseQuality(value)
+		}
+		if q > bestQ {
+			best, bestQ = tag, q
+		}
+	}
+	return best
+}
+
+// parseQuality parses an Accept-Language weight, treating malformed ones
+// as 0 so the tag is ignored
+func parseQuality(value string) float64 {
+	q, err := strconv.ParseFloat(value, 64)
+	if err != nil || q < 0 || q > 1 {
+		return 0
+	}
+	return q
+}
@@ -0,0 +1,405 @@
+package schema_manager
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"agentic-template/api/db"
+	"agentic-template/api/queue"
+	"agentic-template/api/requestctx"
+)
+
+// AnonymizeJobKind is the background job kind applying AnonymizeTable
+const AnonymizeJobKind = "anonymize_table"
+
+// AnonymizeStrategy is how AnonymizeTable rewrites the values of a column.
+// Every strategy is deterministic for a run's salt, so equal values stay
+// equal and can still be joined on.
+type AnonymizeStrategy string
+
+const (
+	AnonymizeFakeName     AnonymizeStrategy = "fake_name"     // Text replaced by a made-up full name
+	AnonymizeShuffleEmail AnonymizeStrategy = "shuffle_email" // Local part scrambled, domain replaced by example.com
+	AnonymizeHash         AnonymizeStrategy = "hash"          // Text replaced by a hex digest, numbers by a derived integer
+	AnonymizeDateJitter   AnonymizeStrategy = "date_jitter"   // Dates moved by up to JitterDays either way
+)
+
+// anonymizeTypes lists the data types each strategy rewrites; the first
+// strategy listed for a type is its default
+var anonymizeTypes = []struct {
+	strategy AnonymizeStrategy
+	types    []DataType
+}{
+	{AnonymizeHash, []DataType{DataTypeText, DataTypeTextLong, DataTypeNumber}},
+	{AnonymizeDateJitter, []DataType{DataTypeDate}},
+	{AnonymizeFakeName, []DataType{DataTypeText, DataTypeTextLong}},
+	{AnonymizeShuffleEmail, []DataType{DataTypeText, DataTypeTextLong}},
+}
+
+// Limits of anonymization
+const (
+	DefaultJitterDays   = 30
+	MaxJitterDays       = 3650
+	AnonymizeSampleSize = 5 // Rewritten records previewed per dry run
+)
+
+// AnonymizeColumn selects a column to rewrite and how
+type AnonymizeColumn struct {
+	ColumnName string            `json:"column_name"`
+	Strategy   AnonymizeStrategy `json:"strategy,omitempty"`    // Defaults by data type: hash, or date_jitter for dates
+	JitterDays int               `json:"jitter_days,omitempty"` // For date_jitter; defaults to DefaultJitterDays
+}
+
+// AnonymizeRequest rewrites columns of a table so its records can be
+// copied to less trusted environments
+type AnonymizeRequest struct {
+	TableID int               `json:"table_id"`
+	Columns []AnonymizeColumn `json:"columns"`
+	DryRun  bool              `json:"dry_run,omitempty"` // Report what would change without changing it
+	Salt    string            `json:"salt,omitempty"`    // Keys the rewrites; random when empty, so runs differ
+}
+
+// AnonymizeReport lists the records a run rewrote, or would rewrite
+type AnonymizeReport struct {
+	TableID     int                     `json:"table_id"`
+	Name        string                  `json:"name"`
+	DryRun      bool                    `json:"dry_run"`
+	RecordCount int64                   `json:"record_count"` // Records in the table
+	Columns     []AnonymizeColumnReport `json:"columns"`
+}
+
+// AnonymizeColumnReport counts the values of a column a run rewrote
+type AnonymizeColumnReport struct {
+	ColumnName   string            `json:"column_name"`
+	Strategy     AnonymizeStrategy `json:"strategy"`
+	AffectedRows int64             `json:"affected_rows"`     // Records with a value; NULLs stay NULL
+	Samples      []AnonymizeSample `json:"samples,omitempty"` // Rewritten values of the first records, on dry runs
+}
+
+// AnonymizeSample is the rewritten value of one record
+type AnonymizeSample struct {
+	RecordID int64   `json:"record_id"`
+	Value    *string `json:"value"`
+}
+
+// RecordValues holds the values of some columns of a record, as text, nil
+// for NULL
+type RecordValues struct {
+	ID     int64
+	Values []*string
+}
+
+// RecordRewrite returns the new values of a record's columns. It must not
+// keep state, since a store may pass a record more than once when it
+// retries its transaction.
+type RecordRewrite func(record RecordValues) ([]*string, error)
+
+// AnonymizeScan reports what a store's AnonymizeRecords saw
+type AnonymizeScan struct {
+	Records  int64          // Records scanned
+	Affected []int64        // Non-NULL values per column
+	Samples  []RecordValues // First records with their rewritten values
+}
+
+// AnonymizeStore is implemented by stores that can rewrite the values of
+// user tables in bulk
+type AnonymizeStore interface {
+	// AnonymizeRecords passes the columns of every record through rewrite
+	// in one transaction, storing the new values unless dryRun, and
+	// returns what it saw with the first sampleSize rewritten records
+	AnonymizeRecords(ctx context.Context, tableName string, columns []ColumnDefinition, rewrite RecordRewrite, dryRun bool, sampleSize int) (*AnonymizeScan, error)
+}
+
+// anonymizeJob is the payload of an AnonymizeJobKind job
+type anonymizeJob struct {
+	Tenant  string           `json:"tenant,omitempty"`
+	Schema  string           `json:"schema,omitempty"`
+	Request AnonymizeRequest `json:"request"`
+}
+
+// AnonymizeTable rewrites the selected columns of every record in one
+// transaction, or with req.DryRun only reports what it would rewrite. The
+// caller's role must be allowed to write the columns.
+func (sm *SchemaManager) AnonymizeTable(ctx context.Context, req AnonymizeRequest) (*AnonymizeReport, error) {
+	ctx, span := startSpan(ctx, "anonymize_table", attrTableID.Int(req.TableID))
+	report, err := sm.anonymizeTable(ctx, req)
+	endSpan(span, err)
+	return report, err
+}
+
+// anonymizeTable runs AnonymizeTable within its span
+func (sm *SchemaManager) anonymizeTable(ctx context.Context, req AnonymizeRequest) (*AnonymizeReport, error) {
+	store, table, columns, err := sm.prepareAnonymize(ctx, &req)
+	if err != nil {
+		return nil, err
+	}
+
+	sampleSize := 0
+	if req.DryRun {
+		sampleSize = AnonymizeSampleSize
+	}
+	defs := make([]ColumnDefinition, len(columns))
+	for i, col := range columns {
+		defs[i] = *table.column(col.ColumnName)
+	}
+	key := []byte(req.Salt)
+	scan, err := store.AnonymizeRecords(ctx, table.TableName, defs, func(record RecordValues) ([]*string, error) {
+		values := make([]*string, len(record.Values))
+		for i, value := range record.Values {
+			if value == nil {
+				continue
+			}
+			rewritten, err := anonymizeValue(key, defs[i], columns[i], record.ID, *value)
+			if err != nil {
+				return nil, fmt.Errorf("record %d, column '%s': %w", record.ID, defs[i].ColumnName, err)
+			}
+			values[i] = &rewritten
+		}
+		return values, nil
+	}, req.DryRun, sampleSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to anonymize records: %w", err)
+	}
+
+	report := &AnonymizeReport{TableID: table.ID, Name: table.Name, DryRun: req.DryRun, RecordCount: scan.Records}
+	for i, col := range columns {
+		colReport := AnonymizeColumnReport{ColumnName: col.ColumnName, Strategy: col.Strategy, AffectedRows: scan.Affected[i]}
+		for _, sample := range scan.Samples {
+			colReport.Samples = append(colReport.Samples, AnonymizeSample{RecordID: sample.ID, Value: sample.Values[i]})
+		}
+		report.Columns = append(report.Columns, colReport)
+	}
+	return report, nil
+}
+
+// EnqueueAnonymizeTable validates an anonymization and queues it as a
+// background job on the caller's tenant. The job reuses the request's
+// salt, random unless set, so its retries rewrite alike.
+func (sm *SchemaManager) EnqueueAnonymizeTable(ctx context.Context, jobs *queue.Queue, req AnonymizeRequest) (*queue.Job, error) {
+	if _, _, _, err := sm.prepareAnonymize(ctx, &req); err != nil {
+		return nil, err
+	}
+	req.DryRun = false
+	return jobs.Enqueue(ctx, AnonymizeJobKind, anonymizeJob{
+		Tenant:  requestctx.Tenant(ctx),
+		Schema:  requestctx.TenantSchema(ctx),
+		Request: req,
+	}, queue.EnqueueOptions{})
+}
+
+// GetAnonymizeJob returns an anonymization job of the caller's tenant, or
+// queue.ErrJobNotFound
+func GetAnonymizeJob(ctx context.Context, jobs *queue.Queue, id int64) (*queue.Job, error) {
+	job, err := jobs.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	var payload anonymizeJob
+	if job.Kind != AnonymizeJobKind || job.Decode(&payload) != nil || payload.Schema != requestctx.TenantSchema(ctx) {
+		return nil, queue.ErrJobNotFound
+	}
+	return job, nil
+}
+
+// AnonymizeJobHandler runs queued anonymizations on the database of a
+// manager, in the schema of the tenant that queued them
+func AnonymizeJobHandler(dbManager *db.Manager) queue.Handler {
+	return func(ctx context.Context, job *queue.Job) error {
+		var payload anonymizeJob
+		if err := job.Decode(&payload); err != nil {
+			return err
+		}
+		if payload.Schema != "" {
+			ctx = requestctx.WithTenant(ctx, payload.Tenant, payload.Schema)
+		}
+		report, err := FromManager(dbManager).AnonymizeTable(ctx, payload.Request)
+		if err != nil {
+			return err
+		}
+		log.Printf("Anonymized %d record(s) of table '%s' (job %d)", report.RecordCount, report.Name, job.ID)
+		return nil
+	}
+}
+
+// prepareAnonymize checks an anonymization against its table, filling in
+// default strategies and the salt
+func (sm *SchemaManager) prepareAnonymize(ctx context.Context, req *AnonymizeRequest) (AnonymizeStore, *TableDefinition, []AnonymizeColumn, error) {
+	if sm.store == nil {
+		return nil, nil, nil, ErrDatabaseNotConfigured
+	}
+	store, ok := sm.store.(AnonymizeStore)
+	if !ok {
+		return nil, nil, nil, fmt.Errorf("anonymization is not supported on %s", sm.store.Dialect().Name())
+	}
+	if len(req.Columns) == 0 {
+		return nil, nil, nil, invalidField("columns", "at least one column is required")
+	}
+
+	table, err := sm.GetTable(ctx, req.TableID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	columns := slices.Clone(req.Columns)
+	names := make([]string, len(columns))
+	for i := range columns {
+		if err := validateAnonymizeColumn(table, i, &columns[i]); err != nil {
+			return nil, nil, nil, err
+		}
+		if slices.Contains(names[:i], columns[i].ColumnName) {
+			return nil, nil, nil, invalidField(columnField(i, "column_name"), "duplicate column: %s", columns[i].ColumnName)
+		}
+		names[i] = columns[i].ColumnName
+	}
+	if err := checkColumnAccess(ctx, table, AccessWrite, names...); err != nil {
+		return nil, nil, nil, err
+	}
+
+	if req.Salt == "" {
+		salt := make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to generate salt: %w", err)
+		}
+		req.Salt = hex.EncodeToString(salt)
+	}
+	req.Columns = columns
+	return store, table, columns, nil
+}
+
+// validateAnonymizeColumn checks the strategy fits the column's data type,
+// defaulting it
+func validateAnonymizeColumn(table *TableDefinition, index int, col *AnonymizeColumn) error {
+	if err := checkSystemColumnWrite(table, columnField(index, "column_name"), col.ColumnName); err != nil {
+		return err
+	}
+	def := table.column(col.ColumnName)
+	if def == nil {
+		return invalidField(columnField(index, "column_name"), "column '%s' does not exist", col.ColumnName)
+	}
+
+	var allowed []string
+	for _, entry := range anonymizeTypes {
+		if !slices.Contains(entry.types, def.DataType) {
+			continue
+		}
+		if col.Strategy == "" {
+			col.Strategy = entry.strategy
+		}
+		allowed = append(allowed, string(entry.strategy))
+	}
+	if len(allowed) == 0 {
+		return invalidField(columnField(index, "column_name"), "%s columns can't be anonymized", def.DataType)
+	}
+	if !slices.Contains(allowed, string(col.Strategy)) {
+		return invalidField(columnField(index, "strategy"), "invalid strategy for %s column '%s': %q (expected %s)",
+			def.DataType, col.ColumnName, col.Strategy, strings.Join(allowed, ", "))
+	}
+
+	switch {
+	case col.Strategy == AnonymizeFakeName && def.IsUnique:
+		return invalidField(columnField(index, "strategy"), "fake names repeat, so they can't fill unique column '%s'", col.ColumnName)
+	case col.Strategy != AnonymizeDateJitter && col.JitterDays != 0:
+		return invalidField(columnField(index, "jitter_days"), "only applies to date_jitter")
+	case col.JitterDays < 0 || col.JitterDays > MaxJitterDays:
+		return invalidField(columnField(index, "jitter_days"), "must be between 1 and %d", MaxJitterDays)
+	case col.Strategy == AnonymizeDateJitter && col.JitterDays == 0:
+		col.JitterDays = DefaultJitterDays
+	}
+	return nil
+}
+
+// column returns the column of a table with a machine name, or nil
+func (t *TableDefinition) column(columnName string) *ColumnDefinition {
+	for i := range t.Columns {
+		if t.Columns[i].ColumnName == columnName {
+			return &t.Columns[i]
+		}
+	}
+	return nil
+}
+
+// Names combined by the fake_name strategy
+var (
+	fakeFirstNames = []string{"Alex", "Blair", "Casey", "Dana", "Eli", "Frankie", "Gray", "Harper", "Indy", "Jordan",
+		"Kai", "Logan", "Morgan", "Noel", "Oakley", "Parker", "Quinn", "Riley", "Sage", "Taylor"}
+	fakeLastNames = []string{"Abbott", "Brooks", "Carter", "Dalton", "Ellis", "Foster", "Garner", "Hayes", "Irwin", "Jensen",
+		"Keller", "Lowe", "Mercer", "Nash", "Olsen", "Pruitt", "Quincy", "Reyes", "Sutton", "Thorne"}
+)
+
+// anonymizeDateLayouts are the layouts date values are read in, and
+// written back in, per engine
+var anonymizeDateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999-07",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02",
+}
+
+// anonymizeValue rewrites one non-NULL value of a column
+func anonymizeValue(key []byte, def ColumnDefinition, col AnonymizeColumn, recordID int64, value string) (string, error) {
+	switch col.Strategy {
+	case AnonymizeFakeName:
+		sum := anonymizeDigest(key, def.ColumnName, value)
+		return fakeFirstNames[int(sum[0])%len(fakeFirstNames)] + " " + fakeLastNames[int(sum[1])%len(fakeLastNames)], nil
+
+	case AnonymizeShuffleEmail:
+		local, _, _ := strings.Cut(value, "@")
+		shuffled := []rune(local)
+		sum := anonymizeDigest(key, def.ColumnName, value)
+		for i := len(shuffled) - 1; i > 0; i-- {
+			j := int(sum[i%len(sum)]) % (i + 1)
+			shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+		}
+		return truncateText(def, string(shuffled)+"@example.com"), nil
+
+	case AnonymizeHash:
+		sum := anonymizeDigest(key, def.ColumnName, value)
+		if def.DataType == DataTypeNumber {
+			// Positive and within a 32-bit INTEGER
+			return strconv.FormatUint(uint64(binary.BigEndian.Uint32(sum)%(1<<31-1))+1, 10), nil
+		}
+		return hex.EncodeToString(sum), nil
+
+	case AnonymizeDateJitter:
+		for _, layout := range anonymizeDateLayouts {
+			t, err := time.Parse(layout, value)
+			if err != nil {
+				continue
+			}
+			// Keyed by record, so equal dates of different records move apart
+			sum := anonymizeDigest(key, def.ColumnName, strconv.FormatInt(recordID, 10))
+			days := int(binary.BigEndian.Uint32(sum)%uint32(2*col.JitterDays+1)) - col.JitterDays
+			return t.AddDate(0, 0, days).Format(layout), nil
+		}
+		return "", fmt.Errorf("unrecognized date: %q", value)
+	}
+	return "", errors.New("unknown strategy " + string(col.Strategy))
+}
+
+// anonymizeDigest keys a value of a column with the run's salt
+func anonymizeDigest(key []byte, columnName, value string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(columnName))
+	mac.Write([]byte{0})
+	mac.Write([]byte(value))
+	return mac.Sum(nil)
+}
+
+// truncateText cuts a rewritten value to fit a short text column
+func truncateText(def ColumnDefinition, value string) string {
+	if runes := []rune(value); def.DataType == DataTypeText && len(runes) > 255 {
+		return string(runes[:255])
+	}
+	return value
+}
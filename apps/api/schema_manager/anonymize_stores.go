@@ -0,0 +1,220 @@
+package schema_manager
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"agentic-template/api/db"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Statically assert that both stores rewrite records for anonymization
+var (
+	_ AnonymizeStore = &PostgresStore{}
+	_ AnonymizeStore = &SQLiteStore{}
+)
+
+// anonymizeBatchSize is how many records a store reads and rewrites at a
+// time
+const anonymizeBatchSize = 500
+
+// tally counts an attempt's records for an AnonymizeScan
+func (scan *AnonymizeScan) tally(record RecordValues, rewritten []*string, sampleSize int) {
+	scan.Records++
+	for i, value := range record.Values {
+		if value != nil {
+			scan.Affected[i]++
+		}
+	}
+	if len(scan.Samples) < sampleSize {
+		scan.Samples = append(scan.Samples, RecordValues{ID: record.ID, Values: rewritten})
+	}
+}
+
+// AnonymizeRecords reads records in ID order and updates them in batches,
+// locking each batch as it is read
+func (s *PostgresStore) AnonymizeRecords(ctx context.Context, tableName string, columns []ColumnDefinition, rewrite RecordRewrite, dryRun bool, sampleSize int) (*AnonymizeScan, error) {
+	query, update := anonymizeStatements(tableName, columns, dryRun)
+
+	var scan *AnonymizeScan
+	err := db.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
+		scan = &AnonymizeScan{Affected: make([]int64, len(columns))}
+		var afterID int64
+		for {
+			records, err := queryRecordValues(ctx, tx, query, len(columns), afterID)
+			if err != nil {
+				return err
+			}
+
+			batch := &pgx.Batch{}
+			for _, record := range records {
+				rewritten, err := rewrite(record)
+				if err != nil {
+					return err
+				}
+				scan.tally(record, rewritten, sampleSize)
+				args := []any{record.ID}
+				for _, value := range rewritten {
+					args = append(args, value)
+				}
+				batch.Queue(update, args...)
+				afterID = record.ID
+			}
+			if !dryRun && batch.Len() > 0 {
+				if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+					return fmt.Errorf("failed to update records: %w", err)
+				}
+			}
+			if len(records) < anonymizeBatchSize {
+				return nil
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return scan, nil
+}
+
+// anonymizeStatements returns the cached statements reading a batch of
+// records, locking it unless dryRun, and rewriting one record
+func anonymizeStatements(tableName string, columns []ColumnDefinition, dryRun bool) (string, string) {
+	names := columnNames(columns)
+	types := make([]string, len(columns))
+	for i, col := range columns {
+		types[i] = col.PostgresType
+	}
+
+	operation := "anonymize_read_locked"
+	if dryRun {
+		operation = "anonymize_read"
+	}
+	query := recordStatements.get(newStatementKey(tableName, operation, names), func() string {
+		selects := make([]string, len(names))
+		for i, name := range names {
+			selects[i] = name + "::text"
+		}
+		lock := " FOR UPDATE"
+		if dryRun {
+			lock = ""
+		}
+		return fmt.Sprintf("SELECT id, %s FROM %s WHERE id > $1 ORDER BY id LIMIT $2%s", strings.Join(selects, ", "), tableName, lock)
+	})
+	update := recordStatements.get(newStatementKey(tableName, "anonymize_write", names, types), func() string {
+		sets := make([]string, len(names))
+		for i, name := range names {
+			sets[i] = fmt.Sprintf("%s = $%d::text::%s", name, i+2, types[i])
+		}
+		return fmt.Sprintf("UPDATE %s SET %s WHERE id = $1", tableName, strings.Join(sets, ", "))
+	})
+	return query, update
+}
+
+// queryRecordValues reads a batch of records after an ID
+func queryRecordValues(ctx context.Context, tx pgx.Tx, query string, columnCount int, afterID int64) ([]RecordValues, error) {
+	queryCtx, cancel := db.StatementContext(ctx)
+	defer cancel()
+	rows, err := tx.Query(queryCtx, query, afterID, anonymizeBatchSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read records: %w", err)
+	}
+	defer rows.Close()
+
+	var records []RecordValues
+	for rows.Next() {
+		record := RecordValues{Values: make([]*string, columnCount)}
+		dest := []any{&record.ID}
+		for i := range record.Values {
+			dest = append(dest, &record.Values[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("failed to read records: %w", err)
+		}
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
+
+// AnonymizeRecords reads records in ID order and updates them one by one;
+// the transaction holds the database's write lock throughout
+func (s *SQLiteStore) AnonymizeRecords(ctx context.Context, tableName string, columns []ColumnDefinition, rewrite RecordRewrite, dryRun bool, sampleSize int) (*AnonymizeScan, error) {
+	names := columnNames(columns)
+	query := recordStatements.get(newStatementKey(tableName, "anonymize_read_sqlite", names), func() string {
+		selects := make([]string, len(names))
+		for i, name := range names {
+			selects[i] = fmt.Sprintf("CAST(%s AS TEXT)", name)
+		}
+		return fmt.Sprintf("SELECT id, %s FROM %s WHERE id > ? ORDER BY id LIMIT ?", strings.Join(selects, ", "), tableName)
+	})
+	update := recordStatements.get(newStatementKey(tableName, "anonymize_write_sqlite", names), func() string {
+		sets := make([]string, len(names))
+		for i, name := range names {
+			sets[i] = name + " = ?"
+		}
+		return fmt.Sprintf("UPDATE %s SET %s WHERE id = ?", tableName, strings.Join(sets, ", "))
+	})
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	scan := &AnonymizeScan{Affected: make([]int64, len(columns))}
+	var afterID int64
+	for {
+		rows, err := tx.QueryContext(ctx, query, afterID, anonymizeBatchSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read records: %w", err)
+		}
+		var records []RecordValues
+		for rows.Next() {
+			record := RecordValues{Values: make([]*string, len(columns))}
+			dest := []any{&record.ID}
+			for i := range record.Values {
+				dest = append(dest, &record.Values[i])
+			}
+			if err := rows.Scan(dest...); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to read records: %w", err)
+			}
+			records = append(records, record)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read records: %w", err)
+		}
+
+		for _, record := range records {
+			rewritten, err := rewrite(record)
+			if err != nil {
+				return nil, err
+			}
+			scan.tally(record, rewritten, sampleSize)
+			afterID = record.ID
+			if dryRun {
+				continue
+			}
+			args := make([]any, 0, len(rewritten)+1)
+			for _, value := range rewritten {
+				args = append(args, value)
+			}
+			if _, err := tx.ExecContext(ctx, update, append(args, record.ID)...); err != nil {
+				return nil, fmt.Errorf("failed to update record %d: %w", record.ID, err)
+			}
+		}
+		if len(records) < anonymizeBatchSize {
+			break
+		}
+	}
+
+	if dryRun {
+		return scan, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return scan, nil
+}
@@ -0,0 +1,219 @@
+package schema_manager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"agentic-template/api/requestctx"
+)
+
+// Statuses of a schema change request
+const (
+	ChangeRequestPending  = "pending"
+	ChangeRequestApproved = "approved" // Replayed successfully
+	ChangeRequestRejected = "rejected"
+	ChangeRequestFailed   = "failed" // Approved, but replaying it failed
+)
+
+// Changes a change request can hold, named like their change log entries
+const (
+	ChangeTypeCreateTable = "CREATE_TABLE"
+	ChangeTypeDropTable   = "DROP_TABLE"
+)
+
+// ChangeRequest is a schema change waiting for, or given, an admin's
+// review
+type ChangeRequest struct {
+	ID            int64      `json:"id"`
+	ChangeType    string     `json:"change_type"`
+	TableID       *int       `json:"table_id,omitempty"` // Table deleted, or created once approved
+	ChangeDetails string     `json:"change_details"`     // JSON of the stored request
+	Status        string     `json:"status"`
+	RequestedBy   *string    `json:"requested_by,omitempty"`
+	ReviewedBy    *string    `json:"reviewed_by,omitempty"`
+	ReviewComment *string    `json:"review_comment,omitempty"`
+	ErrorMessage  *string    `json:"error_message,omitempty"` // Why replaying failed
+	CreatedAt     time.Time  `json:"created_at"`
+	ReviewedAt    *time.Time `json:"reviewed_at,omitempty"`
+}
+
+// ListChangeRequestsOptions filters and pages ListChangeRequests
+type ListChangeRequestsOptions struct {
+	PageSize int    // Defaults to DefaultTablePageSize, max MaxTablePageSize
+	BeforeID int64  // Only requests older than this ID; 0 starts from the newest
+	Status   string // Only requests in this status
+}
+
+var changeApproval atomic.Bool
+
+// SetChangeApproval turns the approval workflow on or off for every tenant
+func SetChangeApproval(enabled bool) {
+	changeApproval.Store(enabled)
+}
+
+// NeedsApproval reports whether the schema changes of ctx wait for an
+// admin's approval: the workflow is on and the caller's role is below
+// admin. Calls without a role (authentication disabled, background work)
+// change the schema directly.
+func NeedsApproval(ctx context.Context) bool {
+	role := requestctx.Role(ctx)
+	return changeApproval.Load() && role != "" && role != "admin"
+}
+
+// RequestCreateTable stores a table creation for an admin to approve. The
+// request is validated now so mistakes surface before review.
+func (sm *SchemaManager) RequestCreateTable(ctx context.Context, req CreateTableRequest) (*ChangeRequest, error) {
+	if sm.store == nil {
+		return nil, ErrDatabaseNotConfigured
+	}
+	if err := sm.validateCreateTableRequest(req); err != nil {
+		return nil, err
+	}
+	return sm.requestChange(ctx, ChangeTypeCreateTable, nil, req)
+}
+
+// RequestDeleteTable stores a table deletion for an admin to approve
+func (sm *SchemaManager) RequestDeleteTable(ctx context.Context, tableID int) (*ChangeRequest, error) {
+	if sm.store == nil {
+		return nil, ErrDatabaseNotConfigured
+	}
+	table, err := sm.store.GetTable(ctx, tableID)
+	if err != nil {
+		return nil, err
+	}
+	details := map[string]interface{}{"table_id": tableID, "name": table.Name, "table_name": table.TableName}
+	return sm.requestChange(ctx, ChangeTypeDropTable, &tableID, details)
+}
+
+// requestChange stores a pending change request by the actor of ctx
+func (sm *SchemaManager) requestChange(ctx context.Context, changeType string, tableID *int, details interface{}) (*ChangeRequest, error) {
+	detailsJSON, err := json.Marshal(details)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal details: %w", err)
+	}
+	actor := requestctx.Actor(ctx)
+	id, err := sm.store.InsertChangeRequest(ctx, ChangeRequest{
+		ChangeType:    changeType,
+		TableID:       tableID,
+		ChangeDetails: string(detailsJSON),
+		RequestedBy:   &actor,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to store change request: %w", err)
+	}
+	return sm.store.GetChangeRequest(ctx, id)
+}
+
+// GetChangeRequest returns a change request, or ErrChangeRequestNotFound
+func (sm *SchemaManager) GetChangeRequest(ctx context.Context, id int64) (*ChangeRequest, error) {
+	if sm.store == nil {
+		return nil, ErrDatabaseNotConfigured
+	}
+	return sm.store.GetChangeRequest(ctx, id)
+}
+
+// ListChangeRequests returns change requests, newest first
+func (sm *SchemaManager) ListChangeRequests(ctx context.Context, opts ListChangeRequestsOptions) ([]ChangeRequest, error) {
+	if sm.store == nil {
+		return nil, ErrDatabaseNotConfigured
+	}
+	switch opts.Status {
+	case "", ChangeRequestPending, ChangeRequestApproved, ChangeRequestRejected, ChangeRequestFailed:
+	default:
+		return nil, invalidField("status", "invalid status: %q (expected pending, approved, rejected, or failed)", opts.Status)
+	}
+
+	pageSize := opts.PageSize
+	if pageSize <= 0 {
+		pageSize = DefaultTablePageSize
+	}
+	if pageSize > MaxTablePageSize {
+		pageSize = MaxTablePageSize
+	}
+	opts.PageSize = pageSize
+	return sm.store.ListChangeRequests(ctx, opts)
+}
+
+// ApproveChangeRequest approves a pending change request and replays it as
+// the approving admin, so the change log records who applied it. A replay
+// that fails leaves the request failed with the error; it is not retried.
+func (sm *SchemaManager) ApproveChangeRequest(ctx context.Context, id int64, comment *string) (*ChangeRequest, error) {
+	if sm.store == nil {
+		return nil, ErrDatabaseNotConfigured
+	}
+	if err := sm.reviewChangeRequest(ctx, id, ChangeRequestApproved, comment); err != nil {
+		return nil, err
+	}
+	change, err := sm.store.GetChangeRequest(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	tableID, replayErr := sm.replayChange(ctx, change)
+	var errMsg *string
+	if replayErr != nil {
+		msg := replayErr.Error()
+		errMsg = &msg
+	}
+	if err := sm.store.CompleteChangeRequest(ctx, id, tableID, errMsg); err != nil {
+		return nil, fmt.Errorf("failed to record the outcome of change request %d: %w", id, err)
+	}
+	if replayErr != nil {
+		return nil, fmt.Errorf("change request %d was approved but failed: %w", id, replayErr)
+	}
+	return sm.store.GetChangeRequest(ctx, id)
+}
+
+// RejectChangeRequest rejects a pending change request
+func (sm *SchemaManager) RejectChangeRequest(ctx context.Context, id int64, comment *string) (*ChangeRequest, error) {
+	if sm.store == nil {
+		return nil, ErrDatabaseNotConfigured
+	}
+	if err := sm.reviewChangeRequest(ctx, id, ChangeRequestRejected, comment); err != nil {
+		return nil, err
+	}
+	return sm.store.GetChangeRequest(ctx, id)
+}
+
+// reviewChangeRequest records the review of a pending request by the actor
+// of ctx. Only one review succeeds; later ones get
+// ErrChangeRequestReviewed.
+func (sm *SchemaManager) reviewChangeRequest(ctx context.Context, id int64, status string, comment *string) error {
+	reviewer := requestctx.Actor(ctx)
+	err := sm.store.ReviewChangeRequest(ctx, id, status, reviewer, comment)
+	if errors.Is(err, ErrChangeRequestReviewed) {
+		// Tell a missing request from one already reviewed
+		if _, getErr := sm.store.GetChangeRequest(ctx, id); getErr != nil {
+			return getErr
+		}
+	}
+	return err
+}
+
+// replayChange applies an approved change request through the schema
+// manager and returns the table it created
+func (sm *SchemaManager) replayChange(ctx context.Context, change *ChangeRequest) (*int, error) {
+	switch change.ChangeType {
+	case ChangeTypeCreateTable:
+		var req CreateTableRequest
+		if err := json.Unmarshal([]byte(change.ChangeDetails), &req); err != nil {
+			return nil, fmt.Errorf("failed to decode stored request: %w", err)
+		}
+		table, err := sm.CreateTable(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		return &table.ID, nil
+	case ChangeTypeDropTable:
+		if change.TableID == nil {
+			return nil, ErrTableNotFound
+		}
+		return nil, sm.DeleteTable(ctx, *change.TableID)
+	default:
+		return nil, fmt.Errorf("unknown change type: %s", change.ChangeType)
+	}
+}
@@ -0,0 +1,186 @@
+package schema_manager
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SchemaBundle is a portable snapshot of the user-defined tables, as
+// exported from one database and turned into a migration for others
+type SchemaBundle struct {
+	ExportedAt time.Time         `json:"exported_at"`
+	Tables     []TableDefinition `json:"tables"`
+}
+
+// ExportBundle snapshots every user-defined table with its columns
+func (sm *SchemaManager) ExportBundle(ctx context.Context) (*SchemaBundle, error) {
+	if sm.store == nil {
+		return nil, ErrDatabaseNotConfigured
+	}
+
+	ids, err := sm.store.TableIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := &SchemaBundle{ExportedAt: time.Now().UTC(), Tables: make([]TableDefinition, 0, len(ids))}
+	for _, id := range ids {
+		table, err := sm.GetTable(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to export table %d: %w", id, err)
+		}
+		bundle.Tables = append(bundle.Tables, *table)
+	}
+	return bundle, nil
+}
+
+// GenerateMigrationSQL renders a bundle as migration SQL that creates the
+// tables and registers them in configurable_tables and configurable_columns.
+// Every statement is idempotent, so the migration also applies to the
+// database the bundle came from. Referenced tables come first.
+func GenerateMigrationSQL(bundle *SchemaBundle) (string, error) {
+	tables, err := orderByReferences(bundle.Tables)
+	if err != nil {
+		return "", err
+	}
+	tableNames := make(map[int]string, len(tables))
+	for _, table := range tables {
+		tableNames[table.ID] = table.TableName
+	}
+
+	var sb strings.Builder
+	for _, table := range tables {
+		if err := ValidateIdentifierSafety(table.TableName); err != nil {
+			return "", fmt.Errorf("table name '%s' failed safety check: %w", table.TableName, err)
+		}
+		ddl, err := createTableSQL(PostgresDialect{}, table.TableName, table.Columns, true, func(i int, col ColumnDefinition) (string, error) {
+			return tableNames[*col.ForeignKeyToTableID], nil
+		})
+		if err != nil {
+			return "", fmt.Errorf("table '%s': %w", table.Name, err)
+		}
+
+		sb.WriteString(fmt.Sprintf("-- %s\n", table.Name))
+		sb.WriteString(ddl)
+		sb.WriteString("\n")
+		sb.WriteString(tableMetadataSQL(table, tableNames))
+		sb.WriteString("\n")
+	}
+	return sb.String(), nil
+}
+
+// tableMetadataSQL registers a table and its columns, leaving existing
+// registrations alone. Relations are resolved by table name since IDs
+// differ between databases.
+func tableMetadataSQL(table TableDefinition, tableNames map[int]string) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf(
+		"INSERT INTO configurable_tables (name, table_name, description, created_by, naming)\nVALUES (%s, %s, %s, %s, %s)\nON CONFLICT (table_name) DO NOTHING;\n",
+		sqlLiteral(&table.Name), sqlLiteral(&table.TableName), sqlLiteral(table.Description), sqlLiteral(table.CreatedBy),
+		sqlLiteral(encodeNaming(table.Naming))))
+
+	for i, col := range table.Columns {
+		foreignTable := "NULL"
+		if col.ForeignKeyToTableID != nil {
+			name := tableNames[*col.ForeignKeyToTableID]
+			foreignTable = fmt.Sprintf("(SELECT id FROM configurable_tables WHERE table_name = %s)", sqlLiteral(&name))
+		}
+		dataType := string(col.DataType)
+		var indexType *string
+		if col.VectorIndexType != nil {
+			value := string(*col.VectorIndexType)
+			indexType = &value
+		}
+		dimensions := "NULL"
+		if col.VectorDimensions != nil {
+			dimensions = strconv.Itoa(*col.VectorDimensions)
+		}
+		format := col.Format
+		if format == nil {
+			format = &ColumnFormat{}
+		}
+		perms := col.Permissions
+		if perms == nil {
+			perms = &ColumnPermissions{}
+		}
+
+		sb.WriteString(fmt.Sprintf(`
+INSERT INTO configurable_columns
+(table_id, name, column_name, data_type, postgres_type, is_nullable, is_unique, default_value, foreign_key_to_table_id, display_order,
+ vector_dimensions, vector_index_type,
+ format_timezone, format_date, format_number_locale, format_currency, format_currency_display, formula,
+ deny_read_roles, deny_write_roles, description)
+SELECT id, %s, %s, %s, %s, %t, %t, %s, %s, %d, %s, %s,
+ %s, %s, %s, %s, %s, %s,
+ %s, %s, %s
+FROM configurable_tables WHERE table_name = %s
+ON CONFLICT (table_id, column_name) DO NOTHING;
+`,
+			sqlLiteral(&col.Name), sqlLiteral(&col.ColumnName), sqlLiteral(&dataType), sqlLiteral(&col.PostgresType),
+			col.IsNullable, col.IsUnique, sqlLiteral(col.DefaultValue), foreignTable, i,
+			dimensions, sqlLiteral(indexType),
+			sqlLiteral(format.Timezone), sqlLiteral((*string)(format.DateFormat)), sqlLiteral(format.NumberLocale),
+			sqlLiteral(format.Currency), sqlLiteral((*string)(format.CurrencyDisplay)), sqlLiteral(col.Formula),
+			sqlLiteral(joinRoles(perms.DenyRead)), sqlLiteral(joinRoles(perms.DenyWrite)), sqlLiteral(col.Description),
+			sqlLiteral(&table.TableName)))
+	}
+	return sb.String()
+}
+
+// orderByReferences orders tables so each comes after the tables its
+// relation columns reference, otherwise in creation order
+func orderByReferences(tables []TableDefinition) ([]TableDefinition, error) {
+	byID := make(map[int]TableDefinition, len(tables))
+	for _, table := range tables {
+		byID[table.ID] = table
+	}
+	sorted := append([]TableDefinition(nil), tables...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+
+	ordered := make([]TableDefinition, 0, len(tables))
+	state := map[int]int{} // 1 while visiting, 2 once ordered
+	var visit func(table TableDefinition) error
+	visit = func(table TableDefinition) error {
+		switch state[table.ID] {
+		case 1:
+			return fmt.Errorf("tables reference each other in a cycle through '%s'", table.Name)
+		case 2:
+			return nil
+		}
+		state[table.ID] = 1
+		for _, col := range table.Columns {
+			if col.ForeignKeyToTableID == nil || *col.ForeignKeyToTableID == table.ID {
+				continue
+			}
+			target, ok := byID[*col.ForeignKeyToTableID]
+			if !ok {
+				return fmt.Errorf("column '%s' of table '%s' references table %d, which is not in the bundle",
+					col.Name, table.Name, *col.ForeignKeyToTableID)
+			}
+			if err := visit(target); err != nil {
+				return err
+			}
+		}
+		state[table.ID] = 2
+		ordered = append(ordered, table)
+		return nil
+	}
+	for _, table := range sorted {
+		if err := visit(table); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
+
+// sqlLiteral quotes a string for SQL, or returns NULL for nil
+func sqlLiteral(value *string) string {
+	if value == nil {
+		return "NULL"
+	}
+	return quoteLiteral(*value)
+}
@@ -0,0 +1,103 @@
+package schema_manager
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"agentic-template/api/db"
+	"agentic-template/api/requestctx"
+)
+
+// Change log pruner tuning
+const (
+	changeLogPruneInterval = time.Hour        // How often replicated changes are pruned
+	changeLogPruneTimeout  = 10 * time.Minute // Bound on each schema's prune
+)
+
+// ChangeLogPruner deletes record changes every warehouse sync of their
+// schema has replicated, in the shared schema and every tenant schema.
+// Syncs prune their own schema after each run; the pruner keeps the log of
+// schemas without syncs from growing.
+type ChangeLogPruner struct {
+	dbManager *db.Manager
+	stop      chan struct{}
+	wg        sync.WaitGroup
+}
+
+// NewChangeLogPruner creates a pruner and starts pruning now and every
+// hour
+func NewChangeLogPruner(dbManager *db.Manager) *ChangeLogPruner {
+	p := &ChangeLogPruner{
+		dbManager: dbManager,
+		stop:      make(chan struct{}),
+	}
+
+	p.wg.Add(1)
+	go p.pruneLoop()
+	return p
+}
+
+// Close stops the pruner, waiting for a running prune to finish until ctx
+// is done
+func (p *ChangeLogPruner) Close(ctx context.Context) error {
+	close(p.stop)
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("change log prune unfinished: %w", ctx.Err())
+	}
+}
+
+// pruneLoop prunes now and every changeLogPruneInterval
+func (p *ChangeLogPruner) pruneLoop() {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(changeLogPruneInterval)
+	defer ticker.Stop()
+
+	for {
+		p.prune()
+		select {
+		case <-ticker.C:
+		case <-p.stop:
+			return
+		}
+	}
+}
+
+// prune deletes the replicated changes of each schema. Failures are logged
+// and retried on the next prune.
+func (p *ChangeLogPruner) prune() {
+	store, err := FromManager(p.dbManager).syncStore()
+	if err != nil {
+		return // SQLite keeps no change log
+	}
+	schemas, err := maintainedSchemas(p.dbManager, changeLogPruneTimeout)
+	if err != nil {
+		log.Printf("Warning: failed to list schemas for change log prune: %v", err)
+		return
+	}
+
+	for _, schema := range schemas {
+		ctx := requestctx.WithTenant(context.Background(), "", schema)
+		ctx, cancel := context.WithTimeout(ctx, changeLogPruneTimeout)
+		pruned, err := store.PruneRecordChanges(ctx)
+		cancel()
+		if err != nil {
+			log.Printf("Warning: failed to prune the record change log: %v", err)
+		}
+		if pruned > 0 {
+			log.Printf("Pruned %d replicated record change(s)", pruned)
+		}
+	}
+}
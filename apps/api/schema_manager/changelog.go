@@ -0,0 +1,45 @@
+package schema_manager
+
+import (
+	"context"
+	"time"
+)
+
+// SchemaChange is an entry of the schema change log
+type SchemaChange struct {
+	ID            int64     `json:"id"`
+	TableID       *int      `json:"table_id,omitempty"` // Nil once the table is deleted
+	ChangeType    string    `json:"change_type"`
+	ChangeDetails string    `json:"change_details"` // JSON
+	ExecutedSQL   *string   `json:"executed_sql,omitempty"`
+	Status        string    `json:"status"`
+	ErrorMessage  *string   `json:"error_message,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	CreatedBy     *string   `json:"created_by,omitempty"`
+}
+
+// ListChangesOptions filters and pages ListChanges
+type ListChangesOptions struct {
+	PageSize int   // Defaults to DefaultTablePageSize, max MaxTablePageSize
+	AfterID  int64 // Only changes after this ID, oldest first; 0 lists the newest first
+	TableID  *int  // Only changes to this table
+}
+
+// ListChanges returns entries of the schema change log. Listing the newest
+// first, then passing the highest ID seen as AfterID, follows new changes.
+func (sm *SchemaManager) ListChanges(ctx context.Context, opts ListChangesOptions) ([]SchemaChange, error) {
+	if sm.store == nil {
+		return nil, ErrDatabaseNotConfigured
+	}
+
+	pageSize := opts.PageSize
+	if pageSize <= 0 {
+		pageSize = DefaultTablePageSize
+	}
+	if pageSize > MaxTablePageSize {
+		pageSize = MaxTablePageSize
+	}
+
+	opts.PageSize = pageSize
+	return sm.store.ListChanges(ctx, opts)
+}
@@ -0,0 +1,95 @@
+package schema_manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// DeleteTable removes a user-defined table, recording the actor of ctx in
+// the change log. When the trash is enabled (see SetTrashSettings) the table
+// is moved there to be restored or purged later; otherwise it is dropped
+// with its metadata. Tables that other tables reference through relation
+// columns are not deleted.
+func (sm *SchemaManager) DeleteTable(ctx context.Context, tableID int) error {
+	if sm.store == nil {
+		return ErrDatabaseNotConfigured
+	}
+
+	ctx, span := startSpan(ctx, "delete_table", attrTableID.Int(tableID))
+	err := sm.store.Tx(ctx, func(tx StoreTx) error {
+		return sm.deleteTable(ctx, tx, tableID)
+	})
+	endSpan(span, err)
+	return err
+}
+
+// deleteTable runs DeleteTable in a transaction
+func (sm *SchemaManager) deleteTable(ctx context.Context, tx StoreTx, tableID int) error {
+	// 1. Lock the table's metadata row
+	name, tableName, err := tx.LockTable(ctx, tableID)
+	if errors.Is(err, ErrTableNotFound) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("failed to query table: %w", err)
+	}
+
+	// 2. Move the table to the trash, or drop it for good
+	if CurrentTrashSettings().Enabled {
+		if err := checkReferences(ctx, tx, tableID, name); err != nil {
+			return err
+		}
+		return sm.trashTable(ctx, tx, tableID, name, tableName)
+	}
+	return sm.dropTable(ctx, tx, tableID, name, tableName)
+}
+
+// checkReferences refuses to break relation columns of other tables,
+// including those in the trash
+func checkReferences(ctx context.Context, tx StoreTx, tableID int, name string) error {
+	referencing, err := tx.ReferencingTables(ctx, tableID)
+	if err != nil {
+		return fmt.Errorf("failed to check table references: %w", err)
+	}
+	if len(referencing) > 0 {
+		return fmt.Errorf("%w: '%s' is referenced by %s", ErrTableReferenced, name, strings.Join(referencing, ", "))
+	}
+	return nil
+}
+
+// dropTable drops a locked table, stored under physicalName, and removes
+// its metadata
+func (sm *SchemaManager) dropTable(ctx context.Context, tx StoreTx, tableID int, name, physicalName string) error {
+	// 1. Refuse to break relation columns of other tables
+	if err := checkReferences(ctx, tx, tableID, name); err != nil {
+		return err
+	}
+
+	// 2. Drop the table
+	if err := ValidateIdentifierSafety(physicalName); err != nil {
+		return fmt.Errorf("table name '%s' failed safety check: %w", physicalName, err)
+	}
+	dropTableSQL := sm.store.Dialect().DropTableSQL(physicalName)
+	details := map[string]interface{}{"table_id": tableID, "name": name, "table_name": physicalName}
+	if err := tx.ExecDDL(ctx, dropTableSQL); err != nil {
+		logSchemaChange(ctx, tx, tableID, "DROP_TABLE", details, &dropTableSQL, "FAILED", err.Error())
+		return fmt.Errorf("failed to execute DROP TABLE: %w", err)
+	}
+
+	// 3. Log the change while the metadata row still exists, then remove it
+	// (columns cascade)
+	if err := logSchemaChange(ctx, tx, tableID, "DROP_TABLE", details, &dropTableSQL, "SUCCESS", ""); err != nil {
+		// Don't fail the transaction, just log the error
+		fmt.Printf("Warning: failed to log schema change: %v\n", err)
+	}
+	if err := tx.DeleteTable(ctx, tableID); err != nil {
+		return fmt.Errorf("failed to delete table metadata: %w", err)
+	}
+
+	// Free the statements of the dropped table; a rollback only costs
+	// rebuilding them
+	recordStatements.forget(physicalName)
+	return nil
+}
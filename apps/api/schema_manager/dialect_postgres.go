@@ -0,0 +1,174 @@
+package schema_manager
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PostgresDialect renders DDL for PostgreSQL with pgvector, the default
+type PostgresDialect struct{}
+
+// Statically assert that PostgresDialect implements DDLDialect
+var _ DDLDialect = PostgresDialect{}
+
+// Name identifies the dialect
+func (PostgresDialect) Name() string {
+	return "postgres"
+}
+
+// ColumnType maps a column to its PostgreSQL type
+func (PostgresDialect) ColumnType(col ColumnDefinition) (string, error) {
+	return MapColumnToPostgresType(col)
+}
+
+// ValidateColumn checks the vector options of a column
+func (PostgresDialect) ValidateColumn(col ColumnDefinition) error {
+	return ValidateVectorColumn(col)
+}
+
+// DropTableSQL renders the DROP TABLE statement of a user table
+func (PostgresDialect) DropTableSQL(tableName string) string {
+	return fmt.Sprintf("DROP TABLE IF EXISTS %s;", tableName)
+}
+
+// RenameTableSQL renders the statement renaming a user table
+func (PostgresDialect) RenameTableSQL(from, to string) string {
+	return fmt.Sprintf("ALTER TABLE %s RENAME TO %s;", from, to)
+}
+
+// DefaultValueSQL formats a default value like GetDefaultValueSQL
+func (PostgresDialect) DefaultValueSQL(dataType DataType, defaultValue *string) (string, error) {
+	return GetDefaultValueSQL(dataType, defaultValue)
+}
+
+// InvalidValueCondition returns "": PostgreSQL enforces every column type
+func (PostgresDialect) InvalidValueCondition(col ColumnDefinition) string {
+	return ""
+}
+
+// CreateTableSQL constructs a safe CREATE TABLE statement with its
+// updated_at and change log triggers and vector indexes. referencedTable resolves the table
+// a relation column references. With ifNotExists the statements are
+// idempotent, for migrations that may meet existing tables.
+func (PostgresDialect) CreateTableSQL(tableName string, columns []ColumnDefinition, ifNotExists bool, referencedTable func(i int, col ColumnDefinition) (string, error)) (string, error) {
+	if err := checkIdentifier(tableName); err != nil {
+		return "", fmt.Errorf("table name failed safety check: %w", err)
+	}
+
+	var sb strings.Builder
+
+	createTable, createTrigger := "CREATE TABLE", "CREATE TRIGGER"
+	if ifNotExists {
+		createTable, createTrigger = "CREATE TABLE IF NOT EXISTS", "CREATE OR REPLACE TRIGGER"
+	}
+
+	// Start the CREATE TABLE statement
+	sb.WriteString(fmt.Sprintf("%s %s (\n", createTable, tableName))
+
+	// Always add an auto-incrementing primary key
+	sb.WriteString("  id SERIAL PRIMARY KEY,\n")
+
+	// Add each column
+	for i, col := range columns {
+		// Validate one more time
+		if err := ValidateIdentifierSafety(col.ColumnName); err != nil {
+			return "", fmt.Errorf("column name '%s' failed safety check: %w", col.ColumnName, err)
+		}
+
+		// Column name and type
+		sb.WriteString(fmt.Sprintf("  %s %s", col.ColumnName, col.PostgresType))
+
+		// NULL constraint
+		if !col.IsNullable {
+			sb.WriteString(" NOT NULL")
+		}
+
+		// UNIQUE constraint
+		if col.IsUnique {
+			sb.WriteString(" UNIQUE")
+		}
+
+		// DEFAULT value
+		if col.DefaultValue != nil {
+			defaultSQL, err := GetDefaultValueSQL(col.DataType, col.DefaultValue)
+			if err != nil {
+				return "", invalidField(columnField(i, "default_value"), "invalid default value for column '%s': %v", col.Name, err)
+			}
+			sb.WriteString(fmt.Sprintf(" DEFAULT %s", defaultSQL))
+		}
+
+		// Foreign key constraint (handled separately below)
+		if col.ForeignKeyToTableID != nil {
+			// We'll add REFERENCES after we query the foreign table name
+			// For now, just note it
+		}
+
+		// Add comma if not the last column
+		if i < len(columns)-1 {
+			sb.WriteString(",\n")
+		}
+	}
+
+	// Add foreign key constraints
+	foreignKeys := []string{}
+	for i, col := range columns {
+		if col.ForeignKeyToTableID != nil {
+			// Get the foreign table name
+			foreignTableName, err := referencedTable(i, col)
+			if err != nil {
+				return "", err
+			}
+			if err := checkIdentifier(foreignTableName); err != nil {
+				return "", fmt.Errorf("referenced table name failed safety check: %w", err)
+			}
+
+			fkConstraint := fmt.Sprintf(
+				"  CONSTRAINT fk_%s_%s FOREIGN KEY (%s) REFERENCES %s(id) ON DELETE SET NULL",
+				tableName, col.ColumnName, col.ColumnName, foreignTableName,
+			)
+			foreignKeys = append(foreignKeys, fkConstraint)
+		}
+	}
+
+	if len(foreignKeys) > 0 {
+		sb.WriteString(",\n")
+		sb.WriteString(strings.Join(foreignKeys, ",\n"))
+	}
+
+	// Add audit columns
+	sb.WriteString(",\n")
+	sb.WriteString("  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),\n")
+	sb.WriteString("  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()\n")
+
+	// Close the CREATE TABLE statement
+	sb.WriteString(");")
+
+	// Add trigger for updated_at
+	sb.WriteString(fmt.Sprintf(`
+
+%s update_%s_updated_at
+    BEFORE UPDATE ON %s
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+`, createTrigger, tableName, tableName))
+
+	// Log record changes for warehouse syncs
+	sb.WriteString(fmt.Sprintf(`
+%s log_%s_changes
+    AFTER INSERT OR UPDATE OR DELETE ON %s
+    FOR EACH ROW
+    EXECUTE FUNCTION log_record_change();
+`, createTrigger, tableName, tableName))
+
+	// Add similarity search indexes for vector columns
+	for _, col := range columns {
+		if indexSQL := BuildVectorIndexSQL(tableName, col); indexSQL != "" {
+			if ifNotExists {
+				indexSQL = strings.Replace(indexSQL, "CREATE INDEX", "CREATE INDEX IF NOT EXISTS", 1)
+			}
+			sb.WriteString("\n" + indexSQL + "\n")
+		}
+	}
+
+	return sb.String(), nil
+}
@@ -0,0 +1,176 @@
+package schema_manager
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SQLiteTypeMapping defines the mapping from user-friendly types to SQLite
+// column types. SQLite stores JSON as text and has no vector type.
+var SQLiteTypeMapping = map[DataType]string{
+	DataTypeText:     "VARCHAR(255)",
+	DataTypeTextLong: "TEXT",
+	DataTypeNumber:   "INTEGER",
+	DataTypeDecimal:  "NUMERIC",
+	DataTypeBoolean:  "BOOLEAN",
+	DataTypeDate:     "TIMESTAMP",
+	DataTypeJSON:     "TEXT",
+	DataTypeRelation: "INTEGER",
+}
+
+// SQLiteDialect renders DDL for SQLite, used by the local file database
+type SQLiteDialect struct{}
+
+// Statically assert that SQLiteDialect implements DDLDialect
+var _ DDLDialect = SQLiteDialect{}
+
+// Name identifies the dialect
+func (SQLiteDialect) Name() string {
+	return "sqlite"
+}
+
+// ColumnType maps a column to its SQLite type
+func (SQLiteDialect) ColumnType(col ColumnDefinition) (string, error) {
+	sqliteType, exists := SQLiteTypeMapping[col.DataType]
+	if !exists {
+		return "", fmt.Errorf("data type %s is not supported on sqlite", col.DataType)
+	}
+	return sqliteType, nil
+}
+
+// ValidateColumn rejects vector columns, which need pgvector
+func (SQLiteDialect) ValidateColumn(col ColumnDefinition) error {
+	if col.DataType == DataTypeVector {
+		return fmt.Errorf("vector columns require PostgreSQL with pgvector")
+	}
+	return ValidateVectorColumn(col)
+}
+
+// DropTableSQL renders the DROP TABLE statement of a user table
+func (SQLiteDialect) DropTableSQL(tableName string) string {
+	return fmt.Sprintf("DROP TABLE IF EXISTS %s;", tableName)
+}
+
+// RenameTableSQL renders the statement renaming a user table
+func (SQLiteDialect) RenameTableSQL(from, to string) string {
+	return fmt.Sprintf("ALTER TABLE %s RENAME TO %s;", from, to)
+}
+
+// DefaultValueSQL formats a default value like sqliteDefaultValueSQL
+func (SQLiteDialect) DefaultValueSQL(dataType DataType, defaultValue *string) (string, error) {
+	return sqliteDefaultValueSQL(dataType, defaultValue)
+}
+
+// sqliteInvalidValues matches values SQLite's type affinity lets into a
+// column of each data type; %s is the column
+var sqliteInvalidValues = map[DataType]string{
+	DataTypeText:     "length(%s) > 255",
+	DataTypeNumber:   "typeof(%s) NOT IN ('integer', 'null')",
+	DataTypeDecimal:  "typeof(%s) NOT IN ('integer', 'real', 'null')",
+	DataTypeBoolean:  "%[1]s IS NOT NULL AND %[1]s NOT IN (0, 1)",
+	DataTypeDate:     "%[1]s IS NOT NULL AND julianday(%[1]s) IS NULL",
+	DataTypeJSON:     "%[1]s IS NOT NULL AND NOT json_valid(%[1]s)",
+	DataTypeRelation: "typeof(%s) NOT IN ('integer', 'null')",
+}
+
+// InvalidValueCondition matches values breaking the column's data type,
+// which SQLite stores as long as they fit the column's affinity
+func (SQLiteDialect) InvalidValueCondition(col ColumnDefinition) string {
+	condition, ok := sqliteInvalidValues[col.DataType]
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf(condition, col.ColumnName)
+}
+
+// CreateTableSQL constructs a safe CREATE TABLE statement with its
+// updated_at trigger. referencedTable resolves the table a relation column
+// references. With ifNotExists the statements are idempotent.
+func (SQLiteDialect) CreateTableSQL(tableName string, columns []ColumnDefinition, ifNotExists bool, referencedTable func(i int, col ColumnDefinition) (string, error)) (string, error) {
+	if err := checkIdentifier(tableName); err != nil {
+		return "", fmt.Errorf("table name failed safety check: %w", err)
+	}
+
+	var sb strings.Builder
+
+	createTable, createTrigger := "CREATE TABLE", "CREATE TRIGGER"
+	if ifNotExists {
+		createTable, createTrigger = "CREATE TABLE IF NOT EXISTS", "CREATE TRIGGER IF NOT EXISTS"
+	}
+
+	sb.WriteString(fmt.Sprintf("%s %s (\n", createTable, tableName))
+	sb.WriteString("  id INTEGER PRIMARY KEY AUTOINCREMENT")
+
+	var foreignKeys []string
+	for i, col := range columns {
+		if err := ValidateIdentifierSafety(col.ColumnName); err != nil {
+			return "", fmt.Errorf("column name '%s' failed safety check: %w", col.ColumnName, err)
+		}
+
+		sb.WriteString(fmt.Sprintf(",\n  %s %s", col.ColumnName, col.PostgresType))
+		if !col.IsNullable {
+			sb.WriteString(" NOT NULL")
+		}
+		if col.IsUnique {
+			sb.WriteString(" UNIQUE")
+		}
+		if col.DefaultValue != nil {
+			defaultSQL, err := sqliteDefaultValueSQL(col.DataType, col.DefaultValue)
+			if err != nil {
+				return "", invalidField(columnField(i, "default_value"), "invalid default value for column '%s': %v", col.Name, err)
+			}
+			sb.WriteString(fmt.Sprintf(" DEFAULT %s", defaultSQL))
+		}
+
+		if col.ForeignKeyToTableID != nil {
+			foreignTableName, err := referencedTable(i, col)
+			if err != nil {
+				return "", err
+			}
+			if err := checkIdentifier(foreignTableName); err != nil {
+				return "", fmt.Errorf("referenced table name failed safety check: %w", err)
+			}
+			foreignKeys = append(foreignKeys, fmt.Sprintf(
+				"  CONSTRAINT fk_%s_%s FOREIGN KEY (%s) REFERENCES %s(id) ON DELETE SET NULL",
+				tableName, col.ColumnName, col.ColumnName, foreignTableName,
+			))
+		}
+	}
+
+	// Audit columns, then the table constraints SQLite expects last
+	sb.WriteString(",\n  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP")
+	sb.WriteString(",\n  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP")
+	for _, fk := range foreignKeys {
+		sb.WriteString(",\n" + fk)
+	}
+	sb.WriteString("\n);")
+
+	// SQLite has no trigger functions, so each table updates its own rows.
+	// Recursive triggers are off, so the UPDATE doesn't fire the trigger again.
+	sb.WriteString(fmt.Sprintf(`
+
+%s update_%s_updated_at
+    AFTER UPDATE ON %s
+    FOR EACH ROW
+    BEGIN
+        UPDATE %s SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
+    END;
+`, createTrigger, tableName, tableName, tableName))
+
+	return sb.String(), nil
+}
+
+// sqliteDefaultValueSQL converts a default value to SQLite syntax. Dates
+// and JSON are stored as text, so they drop the PostgreSQL casts, and text
+// is quoted without the escape string syntax SQLite lacks.
+func sqliteDefaultValueSQL(dataType DataType, defaultValue *string) (string, error) {
+	switch dataType {
+	case DataTypeText, DataTypeTextLong, DataTypeDate, DataTypeJSON:
+		if err := validateDefaultValue(dataType, *defaultValue); err != nil {
+			return "", err
+		}
+		return quoteSQLiteLiteral(*defaultValue), nil
+	default:
+		return GetDefaultValueSQL(dataType, defaultValue)
+	}
+}
@@ -0,0 +1,114 @@
+package schema_manager
+
+import (
+	"reflect"
+)
+
+// SchemaDiff lists how the tables of one bundle differ from another's.
+// Tables match by machine name and columns by column name, so the IDs of
+// the two databases don't matter.
+type SchemaDiff struct {
+	AddedTables   []TableDefinition `json:"added_tables"`   // Only in the target, as defined there
+	RemovedTables []TableDefinition `json:"removed_tables"` // Only in the source, as defined there
+	ChangedTables []TableChange     `json:"changed_tables"` // In both, with different columns or settings
+}
+
+// TableChange lists how a table present in both bundles differs
+type TableChange struct {
+	TableName      string   `json:"table_name"`
+	Renamed        bool     `json:"renamed,omitempty"`         // The display name or description changed
+	AddedColumns   []string `json:"added_columns,omitempty"`   // Column names only in the target
+	RemovedColumns []string `json:"removed_columns,omitempty"` // Column names only in the source
+	ChangedColumns []string `json:"changed_columns,omitempty"` // Column names whose definitions differ
+}
+
+// IsEmpty reports whether the bundles define the same tables
+func (d *SchemaDiff) IsEmpty() bool {
+	return len(d.AddedTables) == 0 && len(d.RemovedTables) == 0 && len(d.ChangedTables) == 0
+}
+
+// DiffBundles compares the tables of two bundles, from is the current
+// schema and to the one it should become. Tables keep the order of their
+// bundle.
+func DiffBundles(from, to *SchemaBundle) *SchemaDiff {
+	fromNames, toNames := bundleTableNames(from), bundleTableNames(to)
+	fromTables := make(map[string]TableDefinition, len(from.Tables))
+	for _, table := range from.Tables {
+		fromTables[table.TableName] = table
+	}
+
+	diff := &SchemaDiff{AddedTables: []TableDefinition{}, RemovedTables: []TableDefinition{}, ChangedTables: []TableChange{}}
+	seen := make(map[string]bool, len(to.Tables))
+	for _, table := range to.Tables {
+		seen[table.TableName] = true
+		current, ok := fromTables[table.TableName]
+		if !ok {
+			diff.AddedTables = append(diff.AddedTables, table)
+			continue
+		}
+		if change, changed := diffTable(current, table, fromNames, toNames); changed {
+			diff.ChangedTables = append(diff.ChangedTables, change)
+		}
+	}
+	for _, table := range from.Tables {
+		if !seen[table.TableName] {
+			diff.RemovedTables = append(diff.RemovedTables, table)
+		}
+	}
+	return diff
+}
+
+// diffTable compares the two definitions of a table
+func diffTable(from, to TableDefinition, fromNames, toNames map[int]string) (TableChange, bool) {
+	change := TableChange{
+		TableName: to.TableName,
+		Renamed:   from.Name != to.Name || !reflect.DeepEqual(from.Description, to.Description),
+	}
+
+	fromColumns := make(map[string]ColumnDefinition, len(from.Columns))
+	for _, col := range from.Columns {
+		fromColumns[col.ColumnName] = col
+	}
+	seen := make(map[string]bool, len(to.Columns))
+	for _, col := range to.Columns {
+		seen[col.ColumnName] = true
+		current, ok := fromColumns[col.ColumnName]
+		switch {
+		case !ok:
+			change.AddedColumns = append(change.AddedColumns, col.ColumnName)
+		case !reflect.DeepEqual(comparableColumn(current, fromNames), comparableColumn(col, toNames)):
+			change.ChangedColumns = append(change.ChangedColumns, col.ColumnName)
+		}
+	}
+	for _, col := range from.Columns {
+		if !seen[col.ColumnName] {
+			change.RemovedColumns = append(change.RemovedColumns, col.ColumnName)
+		}
+	}
+
+	changed := change.Renamed || len(change.AddedColumns) > 0 || len(change.RemovedColumns) > 0 || len(change.ChangedColumns) > 0
+	return change, changed
+}
+
+// comparableColumn strips the parts of a column that differ between
+// databases holding the same definition: its ID, and the ID of the table a
+// relation references, replaced by that table's machine name
+func comparableColumn(col ColumnDefinition, tableNames map[int]string) ColumnDefinition {
+	col.ID = 0
+	col.ForeignKeyToTableName = nil
+	if col.ForeignKeyToTableID != nil {
+		name := tableNames[*col.ForeignKeyToTableID]
+		col.ForeignKeyToTableID = nil
+		col.ForeignKeyToTableName = &name
+	}
+	return col
+}
+
+// bundleTableNames maps the IDs of a bundle's tables to their machine names
+func bundleTableNames(bundle *SchemaBundle) map[int]string {
+	names := make(map[int]string, len(bundle.Tables))
+	for _, table := range bundle.Tables {
+		names[table.ID] = table.TableName
+	}
+	return names
+}
@@ -0,0 +1,43 @@
+package schema_manager
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors returned by the schema manager, for callers that map them to
+// status codes
+var (
+	ErrDatabaseNotConfigured   = errors.New("database not configured - please add DATABASE_URL_POOLED in Environment Settings")
+	ErrTableNotFound           = errors.New("table not found")
+	ErrTableExists             = errors.New("table already exists")
+	ErrTableReferenced         = errors.New("table is referenced by other tables")
+	ErrRecordNotFound          = errors.New("record not found")
+	ErrQuotaExceeded           = errors.New("quota exceeded")
+	ErrQuotaOverrideDenied     = errors.New("only admins not bound to a tenant may override quotas")
+	ErrColumnAccessDenied      = errors.New("column access denied")
+	ErrChangeRequestNotFound   = errors.New("change request not found")
+	ErrChangeRequestReviewed   = errors.New("change request is not pending")
+	ErrSandboxNotPromotable    = errors.New("sandbox cannot be promoted")
+	ErrImportNotFound          = errors.New("data import not found")
+	ErrImportSourceNotFound    = errors.New("import source not found")
+	ErrSyncNotFound            = errors.New("warehouse sync not found")
+	ErrSyncDestinationNotFound = errors.New("sync destination not found")
+)
+
+// Error returns the message prefixed with the field, so a ValidationError
+// can be returned as an error
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// invalidField creates a validation error for a request field path, e.g.
+// "columns[2].data_type"
+func invalidField(field, format string, args ...any) error {
+	return &ValidationError{Field: field, Message: fmt.Sprintf(format, args...)}
+}
+
+// columnField returns the field path of a column attribute
+func columnField(index int, attr string) string {
+	return fmt.Sprintf("columns[%d].%s", index, attr)
+}
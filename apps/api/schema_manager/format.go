@@ -0,0 +1,137 @@
+package schema_manager
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"time"
+	_ "time/tzdata" // Validate time zones on hosts without a zoneinfo database
+)
+
+// DateFormat is a preset date format for displaying date columns
+type DateFormat string
+
+const (
+	DateFormatISO      DateFormat = "iso"      // 2026-01-31T14:05:00+01:00
+	DateFormatDate     DateFormat = "date"     // 2026-01-31
+	DateFormatDateTime DateFormat = "datetime" // 2026-01-31 14:05
+	DateFormatUS       DateFormat = "us"       // 01/31/2026
+	DateFormatEU       DateFormat = "eu"       // 31/01/2026
+	DateFormatLong     DateFormat = "long"     // January 31, 2026
+)
+
+// CurrencyDisplay is how a currency is shown next to an amount
+type CurrencyDisplay string
+
+const (
+	CurrencyDisplaySymbol CurrencyDisplay = "symbol" // €1,234.50
+	CurrencyDisplayCode   CurrencyDisplay = "code"   // EUR 1,234.50
+	CurrencyDisplayName   CurrencyDisplay = "name"   // 1,234.50 euros
+)
+
+// DateFormats lists the date format presets
+var DateFormats = []DateFormat{DateFormatISO, DateFormatDate, DateFormatDateTime, DateFormatUS, DateFormatEU, DateFormatLong}
+
+// CurrencyDisplays lists the currency display options
+var CurrencyDisplays = []CurrencyDisplay{CurrencyDisplaySymbol, CurrencyDisplayCode, CurrencyDisplayName}
+
+// Format fields, as named in the API
+const (
+	FormatFieldTimezone        = "timezone"
+	FormatFieldDateFormat      = "date_format"
+	FormatFieldNumberLocale    = "number_locale"
+	FormatFieldCurrency        = "currency"
+	FormatFieldCurrencyDisplay = "currency_display"
+)
+
+// formatFields lists the format fields that apply to each data type
+var formatFields = map[DataType][]string{
+	DataTypeDate:    {FormatFieldTimezone, FormatFieldDateFormat},
+	DataTypeNumber:  {FormatFieldNumberLocale, FormatFieldCurrency, FormatFieldCurrencyDisplay},
+	DataTypeDecimal: {FormatFieldNumberLocale, FormatFieldCurrency, FormatFieldCurrencyDisplay},
+}
+
+var (
+	// localePattern accepts BCP 47 language tags such as "en" or "pt-BR"
+	localePattern = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{1,8}){0,3}$`)
+	// currencyPattern accepts ISO 4217 currency codes
+	currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+)
+
+// ColumnFormat holds optional presentation settings of a column. They don't
+// change how values are stored; clients and exports use them to render
+// values consistently.
+type ColumnFormat struct {
+	Timezone        *string          `json:"timezone,omitempty"`         // IANA zone dates are shown in (date columns)
+	DateFormat      *DateFormat      `json:"date_format,omitempty"`      // Preset date format (date columns)
+	NumberLocale    *string          `json:"number_locale,omitempty"`    // BCP 47 tag for separators (number and decimal columns)
+	Currency        *string          `json:"currency,omitempty"`         // ISO 4217 code (number and decimal columns)
+	CurrencyDisplay *CurrencyDisplay `json:"currency_display,omitempty"` // Requires Currency
+}
+
+// IsZero reports whether no setting is set
+func (f ColumnFormat) IsZero() bool {
+	return f.Timezone == nil && f.DateFormat == nil && f.NumberLocale == nil && f.Currency == nil && f.CurrencyDisplay == nil
+}
+
+// FormatFields returns the format fields that apply to a data type
+func FormatFields(dataType DataType) []string {
+	return formatFields[dataType]
+}
+
+// ValidateColumnFormat checks a column's format settings apply to its data
+// type and hold valid values. It returns the offending format field.
+func ValidateColumnFormat(col ColumnDefinition) (string, error) {
+	if col.Format == nil {
+		return "", nil
+	}
+	f := col.Format
+
+	for _, field := range f.setFields() {
+		if !slices.Contains(formatFields[col.DataType], field) {
+			return field, fmt.Errorf("%s does not apply to %s columns", field, col.DataType)
+		}
+	}
+
+	if f.Timezone != nil {
+		if _, err := time.LoadLocation(*f.Timezone); err != nil || *f.Timezone == "" || *f.Timezone == "Local" {
+			return FormatFieldTimezone, fmt.Errorf("unknown time zone: %q", *f.Timezone)
+		}
+	}
+	if f.DateFormat != nil && !slices.Contains(DateFormats, *f.DateFormat) {
+		return FormatFieldDateFormat, fmt.Errorf("invalid date format: %s (expected one of %v)", *f.DateFormat, DateFormats)
+	}
+	if f.NumberLocale != nil && !localePattern.MatchString(*f.NumberLocale) {
+		return FormatFieldNumberLocale, fmt.Errorf("invalid locale: %q (expected a language tag such as en-US)", *f.NumberLocale)
+	}
+	if f.Currency != nil && !currencyPattern.MatchString(*f.Currency) {
+		return FormatFieldCurrency, fmt.Errorf("invalid currency: %q (expected an ISO 4217 code such as USD)", *f.Currency)
+	}
+	if f.CurrencyDisplay != nil {
+		if !slices.Contains(CurrencyDisplays, *f.CurrencyDisplay) {
+			return FormatFieldCurrencyDisplay, fmt.Errorf("invalid currency display: %s (expected one of %v)", *f.CurrencyDisplay, CurrencyDisplays)
+		}
+		if f.Currency == nil {
+			return FormatFieldCurrencyDisplay, fmt.Errorf("currency_display requires currency")
+		}
+	}
+	return "", nil
+}
+
+// setFields returns the format fields that are set
+func (f ColumnFormat) setFields() []string {
+	var fields []string
+	for field, set := range map[string]bool{
+		FormatFieldTimezone:        f.Timezone != nil,
+		FormatFieldDateFormat:      f.DateFormat != nil,
+		FormatFieldNumberLocale:    f.NumberLocale != nil,
+		FormatFieldCurrency:        f.Currency != nil,
+		FormatFieldCurrencyDisplay: f.CurrencyDisplay != nil,
+	} {
+		if set {
+			fields = append(fields, field)
+		}
+	}
+	slices.Sort(fields)
+	return fields
+}
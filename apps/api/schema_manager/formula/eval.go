@@ -0,0 +1,343 @@
+package formula
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// dateLayouts are the text forms accepted as dates, for engines that store
+// dates as text
+var dateLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999-07:00", "2006-01-02 15:04:05", "2006-01-02"}
+
+// eval evaluates a node against a record's values
+func eval(n node, values map[string]any) (any, error) {
+	switch n := n.(type) {
+	case literalNode:
+		return n.value, nil
+
+	case refNode:
+		value, ok := values[n.name]
+		if !ok {
+			return nil, fmt.Errorf("unknown column %q", n.name)
+		}
+		return normalize(value)
+
+	case unaryNode:
+		operand, err := eval(n.operand, values)
+		if err != nil || operand == nil {
+			return nil, err
+		}
+		if n.op == "not" {
+			b, err := toBool(operand)
+			return !b, err
+		}
+		f, err := toNumber(operand)
+		return -f, err
+
+	case binaryNode:
+		return evalBinary(n, values)
+
+	case callNode:
+		return evalCall(n, values)
+
+	default:
+		return nil, fmt.Errorf("unknown expression %T", n)
+	}
+}
+
+// evalBinary applies an infix operator. and/or short-circuit and treat
+// null as false; & treats null as empty text; the others are null when an
+// operand is.
+func evalBinary(n binaryNode, values map[string]any) (any, error) {
+	left, err := eval(n.left, values)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "and", "or":
+		l, err := toBool(left)
+		if err != nil {
+			return nil, err
+		}
+		if (n.op == "and" && !l) || (n.op == "or" && l) {
+			return l, nil
+		}
+		right, err := eval(n.right, values)
+		if err != nil {
+			return nil, err
+		}
+		return toBool(right)
+	}
+
+	right, err := eval(n.right, values)
+	if err != nil {
+		return nil, err
+	}
+	if n.op == "&" {
+		return toText(left) + toText(right), nil
+	}
+	if left == nil || right == nil {
+		return nil, nil
+	}
+
+	switch n.op {
+	case "+", "-", "*", "/", "%":
+		l, err := toNumber(left)
+		if err != nil {
+			return nil, err
+		}
+		r, err := toNumber(right)
+		if err != nil {
+			return nil, err
+		}
+		return arithmetic(n.op, l, r)
+	default:
+		c, err := compare(left, right)
+		if err != nil {
+			return nil, err
+		}
+		switch n.op {
+		case "=":
+			return c == 0, nil
+		case "!=", "<>":
+			return c != 0, nil
+		case "<":
+			return c < 0, nil
+		case "<=":
+			return c <= 0, nil
+		case ">":
+			return c > 0, nil
+		default:
+			return c >= 0, nil
+		}
+	}
+}
+
+// arithmetic applies an arithmetic operator to two numbers
+func arithmetic(op string, l, r float64) (any, error) {
+	switch op {
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	case "/":
+		if r == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return l / r, nil
+	default:
+		if r == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return math.Mod(l, r), nil
+	}
+}
+
+// compare orders two non-null values of the same kind
+func compare(left, right any) (int, error) {
+	switch l := left.(type) {
+	case float64:
+		r, err := toNumber(right)
+		if err != nil {
+			return 0, err
+		}
+		return cmpOrdered(l, r), nil
+	case string:
+		if r, ok := right.(string); ok {
+			return cmpOrdered(l, r), nil
+		}
+		// A date compared with text parses the text
+		if r, ok := right.(time.Time); ok {
+			lt, err := toTime(l)
+			if err != nil {
+				return 0, err
+			}
+			return lt.Compare(r), nil
+		}
+	case bool:
+		if r, ok := right.(bool); ok {
+			if l == r {
+				return 0, nil
+			}
+			if !l {
+				return -1, nil
+			}
+			return 1, nil
+		}
+	case time.Time:
+		r, err := toTime(right)
+		if err != nil {
+			return 0, err
+		}
+		return l.Compare(r), nil
+	}
+	return 0, fmt.Errorf("cannot compare %s with %s", kindOf(left), kindOf(right))
+}
+
+func cmpOrdered[T float64 | string](l, r T) int {
+	switch {
+	case l < r:
+		return -1
+	case l > r:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// evalCall evaluates a function call. if evaluates only the branch it
+// takes and coalesce stops at the first non-null argument, so they can
+// guard against errors in the other arguments.
+func evalCall(n callNode, values map[string]any) (any, error) {
+	switch n.fn.name {
+	case "if":
+		cond, err := eval(n.args[0], values)
+		if err != nil {
+			return nil, err
+		}
+		ok, err := toBool(cond)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return eval(n.args[1], values)
+		}
+		return eval(n.args[2], values)
+	case "coalesce":
+		for _, arg := range n.args {
+			value, err := eval(arg, values)
+			if err != nil || value != nil {
+				return value, err
+			}
+		}
+		return nil, nil
+	}
+
+	args := make([]any, len(n.args))
+	for i, arg := range n.args {
+		value, err := eval(arg, values)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = value
+	}
+	value, err := n.fn.call(args)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", n.fn.name, err)
+	}
+	return value, nil
+}
+
+// normalize converts a column value to one of the evaluated kinds
+func normalize(value any) (any, error) {
+	switch v := value.(type) {
+	case nil, float64, string, bool, time.Time:
+		return v, nil
+	case int:
+		return float64(v), nil
+	case int8:
+		return float64(v), nil
+	case int16:
+		return float64(v), nil
+	case int32:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case uint8:
+		return float64(v), nil
+	case uint16:
+		return float64(v), nil
+	case uint32:
+		return float64(v), nil
+	case uint64:
+		return float64(v), nil
+	case float32:
+		return float64(v), nil
+	case []byte:
+		return string(v), nil
+	default:
+		return nil, fmt.Errorf("unsupported value of type %T", value)
+	}
+}
+
+// kindOf names the kind of an evaluated value in errors
+func kindOf(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case float64:
+		return "number"
+	case string:
+		return "text"
+	case bool:
+		return "boolean"
+	case time.Time:
+		return "date"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// toBool reads a condition; null is false
+func toBool(value any) (bool, error) {
+	switch v := value.(type) {
+	case nil:
+		return false, nil
+	case bool:
+		return v, nil
+	default:
+		return false, fmt.Errorf("expected a boolean, got %s", kindOf(value))
+	}
+}
+
+// toNumber reads a number, parsing numeric text
+func toNumber(value any) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return 0, fmt.Errorf("expected a number, got text %q", v)
+		}
+		return f, nil
+	default:
+		return 0, fmt.Errorf("expected a number, got %s", kindOf(value))
+	}
+}
+
+// toText renders a value as text; null is empty
+func toText(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// toTime reads a date, parsing text in the dateLayouts
+func toTime(value any) (time.Time, error) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, nil
+	case string:
+		for _, layout := range dateLayouts {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("expected a date, got text %q", v)
+	default:
+		return time.Time{}, fmt.Errorf("expected a date, got %s", kindOf(value))
+	}
+}
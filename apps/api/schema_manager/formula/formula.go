@@ -0,0 +1,92 @@
+// Package formula parses and evaluates the expressions of formula columns.
+//
+// The language is small and has no side effects: number, string, true,
+// false, and null literals; case-insensitive references to sibling
+// columns by their sanitized names; arithmetic (+ - * / %), string concatenation (&),
+// comparisons (= != <> < <= > >=), and, or, not; and calls of the
+// functions in Functions. Null operands make arithmetic and comparisons
+// null.
+package formula
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Limits of expressions
+const (
+	MaxLength = 1000 // Bytes of source
+	MaxDepth  = 32   // Nesting of operators and calls
+)
+
+// Error reports a problem in an expression with its byte offset
+type Error struct {
+	Pos     int
+	Message string
+}
+
+// Error describes the problem and where it is
+func (e *Error) Error() string {
+	return fmt.Sprintf("at position %d: %s", e.Pos, e.Message)
+}
+
+func errorAt(pos int, format string, args ...any) error {
+	return &Error{Pos: pos, Message: fmt.Sprintf(format, args...)}
+}
+
+// Expr is a parsed expression, safe for concurrent evaluation
+type Expr struct {
+	src  string
+	root node
+}
+
+// Parse parses an expression, checking its syntax, function names, and
+// argument counts. References are checked by the caller against
+// References.
+func Parse(src string) (*Expr, error) {
+	if strings.TrimSpace(src) == "" {
+		return nil, errorAt(0, "expression is empty")
+	}
+	if len(src) > MaxLength {
+		return nil, errorAt(MaxLength, "expression is longer than %d bytes", MaxLength)
+	}
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	root, err := p.parseExpr(0, 0)
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, errorAt(tok.pos, "unexpected %q", tok.text)
+	}
+	return &Expr{src: src, root: root}, nil
+}
+
+// String returns the source of the expression
+func (e *Expr) String() string {
+	return e.src
+}
+
+// References returns the column names the expression reads, sorted and
+// without duplicates
+func (e *Expr) References() []string {
+	var names []string
+	walk(e.root, func(n node) {
+		if ref, ok := n.(refNode); ok {
+			names = append(names, ref.name)
+		}
+	})
+	slices.Sort(names)
+	return slices.Compact(names)
+}
+
+// Eval evaluates the expression against a record's values keyed by column
+// name. Values may be nil, bool, string, time.Time, or any integer or
+// float type; the result is nil, bool, string, time.Time, or float64.
+func (e *Expr) Eval(values map[string]any) (any, error) {
+	return eval(e.root, values)
+}
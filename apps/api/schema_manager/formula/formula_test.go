@@ -0,0 +1,318 @@
+package formula
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func date(year int, month time.Month, day, hour, minute int) time.Time {
+	return time.Date(year, month, day, hour, minute, 0, 0, time.UTC)
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		src     string
+		pos     int
+		message string
+	}{
+		{src: "", pos: 0, message: "expression is empty"},
+		{src: "   ", pos: 0, message: "expression is empty"},
+		{src: "1 +", pos: 3, message: "unexpected end of expression"},
+		{src: "(1 + 2", pos: 6, message: `expected ")"`},
+		{src: ")", pos: 0, message: `unexpected ")"`},
+		{src: "1 2", pos: 2, message: `unexpected "2"`},
+		{src: "'abc", pos: 0, message: "unterminated string"},
+		{src: "1 # 2", pos: 2, message: `unexpected character '#'`},
+		{src: "and 1", pos: 0, message: `expected a value before "and"`},
+		{src: "foo(1)", pos: 0, message: `unknown function "foo"`},
+		{src: "abs(1, 2)", pos: 0, message: "abs takes 1 argument(s)"},
+		{src: "if(true, 1)", pos: 0, message: "if takes 3 argument(s)"},
+		{src: "round()", pos: 0, message: "round takes 1 to 2 arguments"},
+		{src: "min()", pos: 0, message: "min takes at least 1 argument(s)"},
+		{src: "abs(1 2)", pos: 6, message: `expected "," or ")" in call to abs`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			_, err := Parse(tt.src)
+			var parseErr *Error
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("Parse(%q) error = %v, want a *Error", tt.src, err)
+			}
+			if parseErr.Pos != tt.pos || parseErr.Message != tt.message {
+				t.Errorf("Parse(%q) error = %d %q, want %d %q", tt.src, parseErr.Pos, parseErr.Message, tt.pos, tt.message)
+			}
+		})
+	}
+}
+
+func TestLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		wantErr string
+	}{
+		{name: "max length", src: "1" + strings.Repeat(" ", MaxLength-1)},
+		{name: "over max length", src: "1" + strings.Repeat(" ", MaxLength), wantErr: "longer than 1000 bytes"},
+		{name: "max depth of parentheses", src: strings.Repeat("(", MaxDepth) + "1" + strings.Repeat(")", MaxDepth)},
+		{name: "over max depth of parentheses", src: strings.Repeat("(", MaxDepth+1) + "1" + strings.Repeat(")", MaxDepth+1), wantErr: "nested more than 32 levels"},
+		{name: "max depth of prefix operators", src: strings.Repeat("-", MaxDepth) + "1"},
+		{name: "over max depth of prefix operators", src: strings.Repeat("-", MaxDepth+1) + "1", wantErr: "nested more than 32 levels"},
+		{name: "max depth of calls", src: strings.Repeat("abs(", MaxDepth) + "1" + strings.Repeat(")", MaxDepth)},
+		{name: "over max depth of calls", src: strings.Repeat("abs(", MaxDepth+1) + "1" + strings.Repeat(")", MaxDepth+1), wantErr: "nested more than 32 levels"},
+		{name: "long chains are not nested", src: strings.Repeat("1 + ", 200) + "1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.src)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("Parse() error = %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("Parse() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEval(t *testing.T) {
+	record := map[string]any{
+		"price":    19.5,
+		"quantity": int32(4),
+		"zero":     0,
+		"name":     "Widget",
+		"raw":      []byte("bytes"),
+		"missing":  nil,
+		"active":   true,
+		"due":      date(2024, time.March, 15, 10, 0),
+	}
+
+	tests := []struct {
+		name    string
+		src     string
+		want    any
+		wantErr string
+	}{
+		// Literals and references
+		{name: "number", src: "42", want: 42.0},
+		{name: "leading dot number", src: ".5 + 1", want: 1.5},
+		{name: "string with doubled quote", src: "'it''s'", want: "it's"},
+		{name: "double-quoted string", src: `"a""b"`, want: `a"b`},
+		{name: "keywords ignore case", src: "TRUE And NOT False", want: true},
+		{name: "references ignore case", src: "PRICE * Quantity", want: 78.0},
+		{name: "bytes read as text", src: "raw", want: "bytes"},
+		{name: "unknown column", src: "nope + 1", wantErr: `unknown column "nope"`},
+
+		// Arithmetic
+		{name: "add", src: "1 + 2", want: 3.0},
+		{name: "subtract is left-associative", src: "10 - 4 - 3", want: 3.0},
+		{name: "multiply", src: "price * 2", want: 39.0},
+		{name: "divide", src: "7 / 2", want: 3.5},
+		{name: "modulo", src: "7 % 3", want: 1.0},
+		{name: "negate", src: "-price", want: -19.5},
+		{name: "double negation", src: "- -3", want: 3.0},
+		{name: "numeric text", src: "'2' * 3", want: 6.0},
+		{name: "non-numeric text", src: "name + 1", wantErr: `expected a number, got text "Widget"`},
+		{name: "boolean operand", src: "active + 1", wantErr: "expected a number, got boolean"},
+
+		// Division and modulo by zero
+		{name: "divide by zero", src: "1 / 0", wantErr: "division by zero"},
+		{name: "divide by zero column", src: "price / zero", wantErr: "division by zero"},
+		{name: "modulo by zero", src: "5 % 0", wantErr: "division by zero"},
+		{name: "divide by null", src: "1 / missing", want: nil},
+
+		// Precedence
+		{name: "multiply before add", src: "1 + 2 * 3", want: 7.0},
+		{name: "parentheses", src: "(1 + 2) * 3", want: 9.0},
+		{name: "modulo with multiply", src: "2 * 3 % 4", want: 2.0},
+		{name: "negation binds tightest", src: "-2 * 3", want: -6.0},
+		{name: "concat after arithmetic", src: "'n' & 1 + 1", want: "n2"},
+		{name: "compare after concat", src: "'a' & 'b' = 'ab'", want: true},
+		{name: "and before or", src: "true or false and false", want: true},
+		{name: "not binds looser than comparison", src: "not 1 = 2", want: true},
+		{name: "not binds tighter than or", src: "not true or true", want: true},
+		{name: "not binds tighter than and", src: "not false and false", want: false},
+
+		// Comparisons
+		{name: "equal", src: "1 = 1", want: true},
+		{name: "not equal", src: "1 != 2", want: true},
+		{name: "not equal sql", src: "1 <> 1", want: false},
+		{name: "less", src: "1 < 2", want: true},
+		{name: "less or equal", src: "2 <= 2", want: true},
+		{name: "greater", src: "3 > 2", want: true},
+		{name: "greater or equal", src: "2 >= 3", want: false},
+		{name: "text", src: "'apple' < 'banana'", want: true},
+		{name: "booleans", src: "true > false", want: true},
+		{name: "number with numeric text", src: "10 = '10'", want: true},
+		{name: "text with number", src: "'10' = 10", wantErr: "cannot compare text with number"},
+		{name: "number with word", src: "1 < 'a'", wantErr: `expected a number, got text "a"`},
+
+		// Logic
+		{name: "and", src: "1 < 2 and 2 < 1", want: false},
+		{name: "or", src: "1 < 2 or 2 < 1", want: true},
+		{name: "and short-circuits", src: "false and 1 / 0 = 1", want: false},
+		{name: "or short-circuits", src: "true or 1 / 0 = 1", want: true},
+		{name: "and of a number", src: "1 and true", wantErr: "expected a boolean, got number"},
+
+		// Nulls
+		{name: "null arithmetic", src: "missing + 1", want: nil},
+		{name: "null comparison", src: "null = null", want: nil},
+		{name: "null negation", src: "-null", want: nil},
+		{name: "null not", src: "not missing", want: nil},
+		{name: "null concat is empty", src: "missing & 'a'", want: "a"},
+		{name: "null and", src: "null and true", want: false},
+		{name: "null or", src: "null or true", want: true},
+		{name: "null condition", src: "if(missing, 1, 2)", want: 2.0},
+		{name: "null argument", src: "upper(missing)", want: nil},
+		{name: "is_null", src: "is_null(missing)", want: true},
+		{name: "is_null of a value", src: "is_null(name)", want: false},
+		{name: "coalesce", src: "coalesce(missing, null, 'x')", want: "x"},
+		{name: "coalesce of nulls", src: "coalesce(missing, null)", want: nil},
+
+		// Functions
+		{name: "if", src: "if(price > 10, 'high', 'low')", want: "high"},
+		{name: "if is lazy", src: "if(true, 1, 1 / 0)", want: 1.0},
+		{name: "coalesce is lazy", src: "coalesce(1, 1 / 0)", want: 1.0},
+		{name: "if of a number", src: "if(1, 2, 3)", wantErr: "expected a boolean, got number"},
+		{name: "abs", src: "abs(-2)", want: 2.0},
+		{name: "floor", src: "floor(1.7)", want: 1.0},
+		{name: "ceil", src: "ceil(1.2)", want: 2.0},
+		{name: "round", src: "round(2.5)", want: 3.0},
+		{name: "round negative", src: "round(-2.5)", want: -3.0},
+		{name: "round places", src: "round(1.2345, 2)", want: 1.23},
+		{name: "min", src: "min(3, 1, 2)", want: 1.0},
+		{name: "max", src: "max(3, 1, 2)", want: 3.0},
+		{name: "max text", src: "max('a', 'c', 'b')", want: "c"},
+		{name: "min mixed", src: "min(1, true)", wantErr: "min: cannot compare boolean with number"},
+		{name: "concat", src: "concat(name, '-', quantity, missing)", want: "Widget-4"},
+		{name: "upper", src: "upper(name)", want: "WIDGET"},
+		{name: "lower", src: "lower(name)", want: "widget"},
+		{name: "trim", src: "trim('  a b  ')", want: "a b"},
+		{name: "length counts characters", src: "length('héllo')", want: 5.0},
+		{name: "left", src: "left(name, 3)", want: "Wid"},
+		{name: "left past end", src: "left('ab', 5)", want: "ab"},
+		{name: "right", src: "right(name, 3)", want: "get"},
+		{name: "right past start", src: "right('ab', 5)", want: "ab"},
+		{name: "right negative count", src: "right('abc', -5)", want: ""},
+		{name: "right huge count", src: "right('abc', 100000000000000000000000)", want: "abc"},
+		{name: "right NaN count", src: "right('abc', 'NaN')", want: ""},
+		{name: "right of a field count", src: "right(name, quantity)", want: "dget"},
+		{name: "left negative count", src: "left('abc', -5)", want: ""},
+		{name: "left huge count", src: "left('abc', 100000000000000000000000)", want: "abc"},
+		{name: "substr", src: "substr('hello', 2, 3)", want: "ell"},
+		{name: "substr to end", src: "substr('hello', 3)", want: "llo"},
+		{name: "substr past end", src: "substr('hello', 9)", want: ""},
+		{name: "substr negative length", src: "substr('hello', 2, -1)", want: ""},
+		{name: "substr huge length", src: "substr('hello', 2, 100000000000000000000000)", want: "ello"},
+		{name: "replace", src: "replace('a-b-c', '-', '+')", want: "a+b+c"},
+		{name: "contains", src: "contains(name, 'dge')", want: true},
+		{name: "function names ignore case", src: "UPPER('a')", want: "A"},
+
+		// Dates
+		{name: "year", src: "year(due)", want: 2024.0},
+		{name: "month", src: "month(due)", want: 3.0},
+		{name: "day", src: "day(due)", want: 15.0},
+		{name: "date_add minutes", src: "date_add('2024-01-01', 90, 'minutes')", want: date(2024, time.January, 1, 1, 30)},
+		{name: "date_add hours", src: "date_add(due, -3, 'hours')", want: date(2024, time.March, 15, 7, 0)},
+		{name: "date_add days", src: "date_add('2024-02-28', 2, 'days')", want: date(2024, time.March, 1, 0, 0)},
+		{name: "date_add weeks", src: "date_add('2024-01-01', 1, 'weeks')", want: date(2024, time.January, 8, 0, 0)},
+		{name: "date_add months clamps", src: "date_add('2024-01-31', 1, 'months')", want: date(2024, time.February, 29, 0, 0)},
+		{name: "date_add years clamps", src: "date_add('2024-02-29', 1, 'years')", want: date(2025, time.February, 28, 0, 0)},
+		{name: "date_add unknown unit", src: "date_add(due, 1, 'fortnights')", wantErr: `date_add: unknown unit "fortnights"`},
+		{name: "date_diff minutes", src: "date_diff('2024-01-01', '2024-01-01 05:30:00', 'minutes')", want: 330.0},
+		{name: "date_diff hours", src: "date_diff('2024-01-01', '2024-01-01 05:30:00', 'hours')", want: 5.0},
+		{name: "date_diff days", src: "date_diff('2024-01-01', '2024-01-15', 'days')", want: 14.0},
+		{name: "date_diff weeks", src: "date_diff('2024-01-01', '2024-01-15', 'weeks')", want: 2.0},
+		{name: "date_diff whole month", src: "date_diff('2024-01-31', '2024-02-29', 'months')", want: 1.0},
+		{name: "date_diff partial month", src: "date_diff('2024-01-31', '2024-02-28', 'months')", want: 0.0},
+		{name: "date_diff negative months", src: "date_diff('2024-03-15', '2024-01-20', 'months')", want: -1.0},
+		{name: "date_diff years", src: "date_diff('2020-01-01', '2023-06-01', 'years')", want: 3.0},
+		{name: "date_diff unknown unit", src: "date_diff(due, due, 'eons')", wantErr: `date_diff: unknown unit "eons"`},
+
+		// Date parsing
+		{name: "RFC 3339 date", src: "day('2024-03-15T23:30:00Z')", want: 15.0},
+		{name: "RFC 3339 with fraction", src: "day('2024-03-15T23:30:00.123456+02:00')", want: 15.0},
+		{name: "PostgreSQL text", src: "month('2024-03-15 10:00:00.5+02:00')", want: 3.0},
+		{name: "datetime", src: "year('2024-03-15 10:00:00')", want: 2024.0},
+		{name: "date only", src: "day('2024-03-15')", want: 15.0},
+		{name: "unparseable date", src: "year('15/03/2024')", wantErr: `year: expected a date, got text "15/03/2024"`},
+		{name: "number as date", src: "year(5)", wantErr: "year: expected a date, got number"},
+		{name: "date with text", src: "due > '2024-01-01'", want: true},
+		{name: "text with date", src: "'2024-03-15 10:00:00' = due", want: true},
+		{name: "date with bad text", src: "due > 'soon'", wantErr: `expected a date, got text "soon"`},
+		{name: "date concat", src: "'due ' & due", want: "due 2024-03-15T10:00:00Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := Parse(tt.src)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.src, err)
+			}
+			got, err := expr.Eval(record)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Eval(%q) = %v, %v, want error %q", tt.src, got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Eval(%q) error = %v", tt.src, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Eval(%q) = %#v, want %#v", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvalNowAndToday(t *testing.T) {
+	before := time.Now().UTC()
+	for _, src := range []string{"now()", "today()"} {
+		expr, err := Parse(src)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", src, err)
+		}
+		got, err := expr.Eval(nil)
+		if err != nil {
+			t.Fatalf("Eval(%q) error = %v", src, err)
+		}
+		value, ok := got.(time.Time)
+		if !ok {
+			t.Fatalf("Eval(%q) = %#v, want a time.Time", src, got)
+		}
+		if src == "now()" && value.Before(before) {
+			t.Errorf("now() = %v, want at least %v", value, before)
+		}
+		if src == "today()" && !value.Equal(before.Truncate(24*time.Hour)) {
+			t.Errorf("today() = %v, want %v", value, before.Truncate(24*time.Hour))
+		}
+	}
+}
+
+func TestReferences(t *testing.T) {
+	expr, err := Parse("A + b * a + upper(C) & if(is_null(d), 'x', 'y')")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := expr.References(), []string{"a", "b", "c", "d"}; !slices.Equal(got, want) {
+		t.Errorf("References() = %v, want %v", got, want)
+	}
+	if got := expr.String(); got != "A + b * a + upper(C) & if(is_null(d), 'x', 'y')" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestFunctions(t *testing.T) {
+	names := Functions()
+	if !slices.IsSorted(names) {
+		t.Errorf("Functions() = %v, want sorted", names)
+	}
+	for _, name := range []string{"if", "coalesce", "date_diff", "upper"} {
+		if !slices.Contains(names, name) {
+			t.Errorf("Functions() = %v, missing %s", names, name)
+		}
+	}
+}
@@ -0,0 +1,289 @@
+package formula
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// function is a built-in function
+type function struct {
+	name      string
+	minArgs   int
+	maxArgs   int  // -1 for any number
+	takesNull bool // Called with null arguments; otherwise a null argument makes the result null
+	impl      func(args []any) (any, error)
+}
+
+// arity describes the argument count of a function in errors
+func (fn *function) arity() string {
+	switch {
+	case fn.maxArgs < 0:
+		return fmt.Sprintf("at least %d argument(s)", fn.minArgs)
+	case fn.minArgs == fn.maxArgs:
+		return fmt.Sprintf("%d argument(s)", fn.minArgs)
+	default:
+		return fmt.Sprintf("%d to %d arguments", fn.minArgs, fn.maxArgs)
+	}
+}
+
+// call applies the function to evaluated arguments
+func (fn *function) call(args []any) (any, error) {
+	if !fn.takesNull && slices.Contains(args, nil) {
+		return nil, nil
+	}
+	return fn.impl(args)
+}
+
+// functions are the built-in functions by name; if and coalesce are
+// evaluated lazily by evalCall
+var functions = map[string]*function{}
+
+func init() {
+	for _, fn := range []*function{
+		// Logic
+		{name: "if", minArgs: 3, maxArgs: 3, takesNull: true},
+		{name: "coalesce", minArgs: 1, maxArgs: -1, takesNull: true},
+		{name: "is_null", minArgs: 1, maxArgs: 1, takesNull: true, impl: func(args []any) (any, error) {
+			return args[0] == nil, nil
+		}},
+
+		// Numbers
+		{name: "abs", minArgs: 1, maxArgs: 1, impl: numeric(math.Abs)},
+		{name: "floor", minArgs: 1, maxArgs: 1, impl: numeric(math.Floor)},
+		{name: "ceil", minArgs: 1, maxArgs: 1, impl: numeric(math.Ceil)},
+		{name: "round", minArgs: 1, maxArgs: 2, impl: round},
+		{name: "min", minArgs: 1, maxArgs: -1, impl: extreme(-1)},
+		{name: "max", minArgs: 1, maxArgs: -1, impl: extreme(1)},
+
+		// Text
+		{name: "concat", minArgs: 1, maxArgs: -1, takesNull: true, impl: func(args []any) (any, error) {
+			var sb strings.Builder
+			for _, arg := range args {
+				sb.WriteString(toText(arg))
+			}
+			return sb.String(), nil
+		}},
+		{name: "upper", minArgs: 1, maxArgs: 1, impl: textual(strings.ToUpper)},
+		{name: "lower", minArgs: 1, maxArgs: 1, impl: textual(strings.ToLower)},
+		{name: "trim", minArgs: 1, maxArgs: 1, impl: textual(strings.TrimSpace)},
+		{name: "length", minArgs: 1, maxArgs: 1, impl: func(args []any) (any, error) {
+			return float64(utf8.RuneCountInString(toText(args[0]))), nil
+		}},
+		{name: "left", minArgs: 2, maxArgs: 2, impl: func(args []any) (any, error) {
+			return substring(args[0], 1, args[1])
+		}},
+		{name: "right", minArgs: 2, maxArgs: 2, impl: func(args []any) (any, error) {
+			n, err := toNumber(args[1])
+			if err != nil {
+				return nil, err
+			}
+			runes := []rune(toText(args[0]))
+			return string(runes[len(runes)-clampCount(n, len(runes)):]), nil
+		}},
+		{name: "substr", minArgs: 2, maxArgs: 3, impl: func(args []any) (any, error) {
+			start, err := toNumber(args[1])
+			if err != nil {
+				return nil, err
+			}
+			if len(args) == 2 {
+				return substring(args[0], int(start), nil)
+			}
+			return substring(args[0], int(start), args[2])
+		}},
+		{name: "replace", minArgs: 3, maxArgs: 3, impl: func(args []any) (any, error) {
+			return strings.ReplaceAll(toText(args[0]), toText(args[1]), toText(args[2])), nil
+		}},
+		{name: "contains", minArgs: 2, maxArgs: 2, impl: func(args []any) (any, error) {
+			return strings.Contains(toText(args[0]), toText(args[1])), nil
+		}},
+
+		// Dates
+		{name: "now", minArgs: 0, maxArgs: 0, impl: func([]any) (any, error) {
+			return time.Now().UTC(), nil
+		}},
+		{name: "today", minArgs: 0, maxArgs: 0, impl: func([]any) (any, error) {
+			return time.Now().UTC().Truncate(24 * time.Hour), nil
+		}},
+		{name: "year", minArgs: 1, maxArgs: 1, impl: datePart(func(t time.Time) int { return t.Year() })},
+		{name: "month", minArgs: 1, maxArgs: 1, impl: datePart(func(t time.Time) int { return int(t.Month()) })},
+		{name: "day", minArgs: 1, maxArgs: 1, impl: datePart(func(t time.Time) int { return t.Day() })},
+		{name: "date_add", minArgs: 3, maxArgs: 3, impl: dateAdd},
+		{name: "date_diff", minArgs: 3, maxArgs: 3, impl: dateDiff},
+	} {
+		functions[fn.name] = fn
+	}
+}
+
+// Functions returns the names of the built-in functions, sorted
+func Functions() []string {
+	return slices.Sorted(maps.Keys(functions))
+}
+
+// numeric adapts a function of one number
+func numeric(f func(float64) float64) func([]any) (any, error) {
+	return func(args []any) (any, error) {
+		x, err := toNumber(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return f(x), nil
+	}
+}
+
+// textual adapts a function of one text
+func textual(f func(string) string) func([]any) (any, error) {
+	return func(args []any) (any, error) {
+		return f(toText(args[0])), nil
+	}
+}
+
+// datePart adapts a function reading part of a date
+func datePart(f func(time.Time) int) func([]any) (any, error) {
+	return func(args []any) (any, error) {
+		t, err := toTime(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return float64(f(t)), nil
+	}
+}
+
+// round rounds half away from zero to a number of decimal places
+func round(args []any) (any, error) {
+	x, err := toNumber(args[0])
+	if err != nil {
+		return nil, err
+	}
+	places := 0.0
+	if len(args) == 2 {
+		if places, err = toNumber(args[1]); err != nil {
+			return nil, err
+		}
+	}
+	scale := math.Pow(10, math.Trunc(places))
+	return math.Round(x*scale) / scale, nil
+}
+
+// extreme returns the smallest (sign -1) or largest (sign 1) argument
+func extreme(sign int) func([]any) (any, error) {
+	return func(args []any) (any, error) {
+		best := args[0]
+		for _, arg := range args[1:] {
+			c, err := compare(arg, best)
+			if err != nil {
+				return nil, err
+			}
+			if c*sign > 0 {
+				best = arg
+			}
+		}
+		return best, nil
+	}
+}
+
+// substring returns up to length characters of value from a 1-based
+// start; a nil length reads to the end
+func substring(value any, start int, length any) (any, error) {
+	runes := []rune(toText(value))
+	from := min(max(start-1, 0), len(runes))
+	to := len(runes)
+	if length != nil {
+		n, err := toNumber(length)
+		if err != nil {
+			return nil, err
+		}
+		to = from + clampCount(n, len(runes)-from)
+	}
+	return string(runes[from:to]), nil
+}
+
+// clampCount converts a character count to an int between 0 and limit;
+// NaN counts none
+func clampCount(n float64, limit int) int {
+	if !(n > 0) {
+		return 0
+	}
+	return int(min(n, float64(limit)))
+}
+
+// dateUnits are the units of date_add and date_diff
+var dateUnits = []string{"minutes", "hours", "days", "weeks", "months", "years"}
+
+// dateAdd adds a number of units to a date: date_add(date, n, unit)
+func dateAdd(args []any) (any, error) {
+	t, err := toTime(args[0])
+	if err != nil {
+		return nil, err
+	}
+	n, err := toNumber(args[1])
+	if err != nil {
+		return nil, err
+	}
+	switch unit := toText(args[2]); unit {
+	case "minutes":
+		return t.Add(time.Duration(n * float64(time.Minute))), nil
+	case "hours":
+		return t.Add(time.Duration(n * float64(time.Hour))), nil
+	case "days":
+		return t.AddDate(0, 0, int(n)), nil
+	case "weeks":
+		return t.AddDate(0, 0, 7*int(n)), nil
+	case "months":
+		return addMonths(t, int(n)), nil
+	case "years":
+		return addMonths(t, 12*int(n)), nil
+	default:
+		return nil, fmt.Errorf("unknown unit %q (expected %s)", unit, strings.Join(dateUnits, ", "))
+	}
+}
+
+// addMonths adds months to a date, clamping the day to the end of a
+// shorter month as spreadsheets do: January 31 plus a month is February 28
+func addMonths(t time.Time, months int) time.Time {
+	firstOfMonth := time.Date(t.Year(), t.Month()+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	lastDay := firstOfMonth.AddDate(0, 1, -1).Day()
+	return firstOfMonth.AddDate(0, 0, min(t.Day(), lastDay)-1)
+}
+
+// dateDiff counts whole units from a start to an end date:
+// date_diff(start, end, unit), negative when end is earlier
+func dateDiff(args []any) (any, error) {
+	start, err := toTime(args[0])
+	if err != nil {
+		return nil, err
+	}
+	end, err := toTime(args[1])
+	if err != nil {
+		return nil, err
+	}
+	elapsed := end.Sub(start)
+	switch unit := toText(args[2]); unit {
+	case "minutes":
+		return math.Trunc(elapsed.Minutes()), nil
+	case "hours":
+		return math.Trunc(elapsed.Hours()), nil
+	case "days":
+		return math.Trunc(elapsed.Hours() / 24), nil
+	case "weeks":
+		return math.Trunc(elapsed.Hours() / (24 * 7)), nil
+	case "months", "years":
+		months := (end.Year()-start.Year())*12 + int(end.Month()-start.Month())
+		// A month isn't complete until the end reaches the start's day
+		if months > 0 && end.Before(addMonths(start, months)) {
+			months--
+		} else if months < 0 && end.After(addMonths(start, months)) {
+			months++
+		}
+		if unit == "years" {
+			return math.Trunc(float64(months) / 12), nil
+		}
+		return float64(months), nil
+	default:
+		return nil, fmt.Errorf("unknown unit %q (expected %s)", unit, strings.Join(dateUnits, ", "))
+	}
+}
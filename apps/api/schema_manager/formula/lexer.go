@@ -0,0 +1,110 @@
+package formula
+
+import (
+	"strings"
+	"unicode"
+)
+
+// tokenKind classifies a token of an expression
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenString
+	tokenIdent
+	tokenOperator
+)
+
+// token is a lexeme with its byte offset in the expression
+type token struct {
+	kind tokenKind
+	text string // Unquoted for strings
+	pos  int
+}
+
+// operators lists the operators and punctuation, longest first so "<="
+// isn't read as "<"
+var operators = []string{"<=", ">=", "<>", "!=", "+", "-", "*", "/", "%", "&", "=", "<", ">", "(", ")", ","}
+
+// lex splits an expression into tokens
+func lex(src string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+
+		case isDigit(c) || (c == '.' && i+1 < len(src) && isDigit(src[i+1])):
+			start := i
+			for i < len(src) && isDigit(src[i]) {
+				i++
+			}
+			if i < len(src) && src[i] == '.' {
+				i++
+				for i < len(src) && isDigit(src[i]) {
+					i++
+				}
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: src[start:i], pos: start})
+
+		case c == '\'' || c == '"':
+			// Quotes are escaped by doubling them, as in SQL
+			start := i
+			var sb strings.Builder
+			i++
+			for {
+				if i >= len(src) {
+					return nil, errorAt(start, "unterminated string")
+				}
+				if src[i] == c {
+					if i+1 < len(src) && src[i+1] == c {
+						sb.WriteByte(c)
+						i += 2
+						continue
+					}
+					i++
+					break
+				}
+				sb.WriteByte(src[i])
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenString, text: sb.String(), pos: start})
+
+		case isIdentStart(c):
+			start := i
+			for i < len(src) && (isIdentStart(src[i]) || isDigit(src[i])) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: src[start:i], pos: start})
+
+		default:
+			op := ""
+			for _, candidate := range operators {
+				if strings.HasPrefix(src[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				r := []rune(src[i:])[0]
+				if !unicode.IsPrint(r) {
+					return nil, errorAt(i, "unexpected character %U", r)
+				}
+				return nil, errorAt(i, "unexpected character %q", r)
+			}
+			tokens = append(tokens, token{kind: tokenOperator, text: op, pos: i})
+			i += len(op)
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(src)}), nil
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
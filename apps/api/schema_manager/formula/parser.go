@@ -0,0 +1,243 @@
+package formula
+
+import (
+	"strconv"
+	"strings"
+)
+
+// node is a parsed expression
+type node interface{}
+
+type (
+	// literalNode is a constant
+	literalNode struct{ value any }
+	// refNode reads a column of the record
+	refNode struct{ name string }
+	// unaryNode applies - or not
+	unaryNode struct {
+		op      string
+		operand node
+	}
+	// binaryNode applies an infix operator
+	binaryNode struct {
+		op          string
+		left, right node
+		pos         int
+	}
+	// callNode calls a function
+	callNode struct {
+		fn   *function
+		args []node
+		pos  int
+	}
+)
+
+// precedences of the infix operators; higher binds tighter
+var precedences = map[string]int{
+	"or":  1,
+	"and": 2,
+	"=":   3, "!=": 3, "<>": 3, "<": 3, "<=": 3, ">": 3, ">=": 3,
+	"&": 4,
+	"+": 5, "-": 5,
+	"*": 6, "/": 6, "%": 6,
+}
+
+// parser is a precedence-climbing parser over the tokens of an expression
+type parser struct {
+	tokens []token
+	next   int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.next]
+}
+
+func (p *parser) advance() token {
+	tok := p.tokens[p.next]
+	if tok.kind != tokenEOF {
+		p.next++
+	}
+	return tok
+}
+
+// infixOperator returns the operator a token stands for, lowercasing the
+// and/or keywords, or "" when it isn't one
+func infixOperator(tok token) string {
+	switch tok.kind {
+	case tokenOperator:
+		if _, ok := precedences[tok.text]; ok {
+			return tok.text
+		}
+	case tokenIdent:
+		if keyword := strings.ToLower(tok.text); keyword == "and" || keyword == "or" {
+			return keyword
+		}
+	}
+	return ""
+}
+
+// parseExpr parses operators binding tighter than minPrecedence
+func (p *parser) parseExpr(minPrecedence, depth int) (node, error) {
+	if depth > MaxDepth {
+		return nil, errorAt(p.peek().pos, "expression is nested more than %d levels deep", MaxDepth)
+	}
+	left, err := p.parseUnary(depth)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok := p.peek()
+		op := infixOperator(tok)
+		if op == "" || precedences[op] <= minPrecedence {
+			return left, nil
+		}
+		p.advance()
+		// Operators are left-associative
+		right, err := p.parseExpr(precedences[op], depth+1)
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: op, left: left, right: right, pos: tok.pos}
+	}
+}
+
+// parseUnary parses a prefix operator or an operand
+func (p *parser) parseUnary(depth int) (node, error) {
+	tok := p.peek()
+	if (tok.kind == tokenOperator && tok.text == "-") || (tok.kind == tokenIdent && strings.EqualFold(tok.text, "not")) {
+		p.advance()
+		if depth+1 > MaxDepth {
+			return nil, errorAt(tok.pos, "expression is nested more than %d levels deep", MaxDepth)
+		}
+		operand, err := p.parseUnary(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		// not binds looser than comparisons, so "not a = b" negates a = b
+		op := strings.ToLower(tok.text)
+		if op == "not" {
+			operand, err = p.continueInfix(operand, precedences["and"], depth+1)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return unaryNode{op: op, operand: operand}, nil
+	}
+	return p.parseOperand(depth)
+}
+
+// continueInfix extends left with the operators binding tighter than
+// minPrecedence that follow it
+func (p *parser) continueInfix(left node, minPrecedence, depth int) (node, error) {
+	for {
+		tok := p.peek()
+		op := infixOperator(tok)
+		if op == "" || precedences[op] <= minPrecedence {
+			return left, nil
+		}
+		p.advance()
+		right, err := p.parseExpr(precedences[op], depth+1)
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: op, left: left, right: right, pos: tok.pos}
+	}
+}
+
+// parseOperand parses a literal, reference, call, or parenthesized
+// expression
+func (p *parser) parseOperand(depth int) (node, error) {
+	tok := p.advance()
+	switch tok.kind {
+	case tokenNumber:
+		value, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, errorAt(tok.pos, "invalid number %q", tok.text)
+		}
+		return literalNode{value: value}, nil
+
+	case tokenString:
+		return literalNode{value: tok.text}, nil
+
+	case tokenIdent:
+		switch strings.ToLower(tok.text) {
+		case "true":
+			return literalNode{value: true}, nil
+		case "false":
+			return literalNode{value: false}, nil
+		case "null":
+			return literalNode{value: nil}, nil
+		case "and", "or", "not":
+			return nil, errorAt(tok.pos, "expected a value before %q", tok.text)
+		}
+		if next := p.peek(); next.kind == tokenOperator && next.text == "(" {
+			return p.parseCall(tok, depth)
+		}
+		return refNode{name: strings.ToLower(tok.text)}, nil
+
+	case tokenOperator:
+		if tok.text == "(" {
+			inner, err := p.parseExpr(0, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			if closing := p.advance(); closing.text != ")" || closing.kind != tokenOperator {
+				return nil, errorAt(closing.pos, "expected \")\"")
+			}
+			return inner, nil
+		}
+		return nil, errorAt(tok.pos, "unexpected %q", tok.text)
+
+	default:
+		return nil, errorAt(tok.pos, "unexpected end of expression")
+	}
+}
+
+// parseCall parses the arguments of a call to the function named by tok
+func (p *parser) parseCall(tok token, depth int) (node, error) {
+	fn, ok := functions[strings.ToLower(tok.text)]
+	if !ok {
+		return nil, errorAt(tok.pos, "unknown function %q", tok.text)
+	}
+	p.advance() // (
+
+	var args []node
+	if next := p.peek(); !(next.kind == tokenOperator && next.text == ")") {
+		for {
+			arg, err := p.parseExpr(0, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if next := p.peek(); next.kind == tokenOperator && next.text == "," {
+				p.advance()
+				continue
+			}
+			break
+		}
+	}
+	if closing := p.advance(); closing.text != ")" || closing.kind != tokenOperator {
+		return nil, errorAt(closing.pos, "expected \",\" or \")\" in call to %s", fn.name)
+	}
+
+	if len(args) < fn.minArgs || (fn.maxArgs >= 0 && len(args) > fn.maxArgs) {
+		return nil, errorAt(tok.pos, "%s takes %s", fn.name, fn.arity())
+	}
+	return callNode{fn: fn, args: args, pos: tok.pos}, nil
+}
+
+// walk calls visit on n and every node below it
+func walk(n node, visit func(node)) {
+	visit(n)
+	switch n := n.(type) {
+	case unaryNode:
+		walk(n.operand, visit)
+	case binaryNode:
+		walk(n.left, visit)
+		walk(n.right, visit)
+	case callNode:
+		for _, arg := range n.args {
+			walk(arg, visit)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package schema_manager
+
+import (
+	"fmt"
+	"slices"
+
+	"agentic-template/api/schema_manager/formula"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// formulaInputTypes are the data types formulas may reference. Vectors and
+// JSON have no value in the formula language, and formulas can't read
+// other formulas, so evaluation order never matters.
+var formulaInputTypes = []DataType{
+	DataTypeText, DataTypeTextLong, DataTypeNumber, DataTypeDecimal, DataTypeBoolean, DataTypeDate, DataTypeRelation,
+}
+
+// validateFormulaColumns checks the formulas of a table's columns: only
+// formula columns have one, it parses, and it references sibling columns
+// by their sanitized names
+func validateFormulaColumns(columns []ColumnDefinition) error {
+	// Every record also has its stored system columns
+	siblings := map[string]DataType{}
+	for _, col := range systemColumns {
+		if !col.Reserved {
+			siblings[col.Name] = col.DataType
+		}
+	}
+	for _, col := range columns {
+		if name, err := SanitizeIdentifier(col.Name); err == nil {
+			siblings[name] = col.DataType
+		}
+	}
+
+	for i, col := range columns {
+		if col.DataType != DataTypeFormula {
+			if col.Formula != nil {
+				return invalidField(columnField(i, "formula"), "column '%s' is not a formula column", col.Name)
+			}
+			continue
+		}
+
+		if col.Formula == nil {
+			return invalidField(columnField(i, "formula"), "column '%s' is a formula but formula is not set", col.Name)
+		}
+		if col.IsUnique || col.DefaultValue != nil || col.ForeignKeyToTableID != nil {
+			return invalidField(columnField(i, "data_type"), "formula column '%s' can't be unique, have a default, or reference a table", col.Name)
+		}
+		expr, err := formula.Parse(*col.Formula)
+		if err != nil {
+			return invalidField(columnField(i, "formula"), "invalid formula for column '%s': %v", col.Name, err)
+		}
+		for _, ref := range expr.References() {
+			dataType, ok := siblings[ref]
+			switch {
+			case !ok:
+				return invalidField(columnField(i, "formula"), "formula of column '%s' references unknown column '%s'", col.Name, ref)
+			case !slices.Contains(formulaInputTypes, dataType):
+				return invalidField(columnField(i, "formula"), "formula of column '%s' can't reference %s column '%s'", col.Name, dataType, ref)
+			}
+		}
+	}
+	return nil
+}
+
+// storedColumns returns the columns with a database column, leaving out
+// formula columns, and the index of each in columns
+func storedColumns(columns []ColumnDefinition) ([]ColumnDefinition, []int) {
+	stored := make([]ColumnDefinition, 0, len(columns))
+	indexes := make([]int, 0, len(columns))
+	for i, col := range columns {
+		if col.DataType != DataTypeFormula {
+			stored = append(stored, col)
+			indexes = append(indexes, i)
+		}
+	}
+	return stored, indexes
+}
+
+// createTableSQL renders the CREATE TABLE statements of a table's stored
+// columns; referencedTable receives the index of a column in columns
+func createTableSQL(dialect DDLDialect, tableName string, columns []ColumnDefinition, ifNotExists bool, referencedTable func(i int, col ColumnDefinition) (string, error)) (string, error) {
+	stored, indexes := storedColumns(columns)
+	return dialect.CreateTableSQL(tableName, stored, ifNotExists, func(i int, col ColumnDefinition) (string, error) {
+		return referencedTable(indexes[i], col)
+	})
+}
+
+// FormulaSet evaluates the formula columns of a table on the records read
+// from it. Compile it once per read and Apply it to each record.
+type FormulaSet struct {
+	columns []string
+	exprs   []*formula.Expr
+}
+
+// NewFormulaSet compiles the formulas of a table's columns. A table
+// without formula columns gets an empty set.
+func NewFormulaSet(table *TableDefinition) (*FormulaSet, error) {
+	set := &FormulaSet{}
+	for _, col := range table.Columns {
+		if col.DataType != DataTypeFormula || col.Formula == nil {
+			continue
+		}
+		expr, err := formula.Parse(*col.Formula)
+		if err != nil {
+			return nil, fmt.Errorf("invalid formula for column '%s': %w", col.Name, err)
+		}
+		set.columns = append(set.columns, col.ColumnName)
+		set.exprs = append(set.exprs, expr)
+	}
+	return set, nil
+}
+
+// Apply sets the value of each formula column in a record keyed by column
+// name. A formula that fails on the record, such as by dividing by zero,
+// yields null rather than failing the read.
+func (s *FormulaSet) Apply(record map[string]any) {
+	if len(s.exprs) == 0 {
+		return
+	}
+	values := make(map[string]any, len(record))
+	for name, value := range record {
+		values[name] = formulaValue(value)
+	}
+	for i, expr := range s.exprs {
+		value, err := expr.Eval(values)
+		if err != nil {
+			value = nil
+		}
+		record[s.columns[i]] = value
+	}
+}
+
+// References returns the columns each formula column reads, keyed by the
+// formula column's name
+func (s *FormulaSet) References() map[string][]string {
+	refs := make(map[string][]string, len(s.exprs))
+	for i, expr := range s.exprs {
+		refs[s.columns[i]] = expr.References()
+	}
+	return refs
+}
+
+// formulaValue converts driver values the formula language doesn't know,
+// such as PostgreSQL decimals, to ones it does
+func formulaValue(value any) any {
+	if numeric, ok := value.(pgtype.Numeric); ok {
+		f, err := numeric.Float64Value()
+		if err != nil || !f.Valid {
+			return nil
+		}
+		return f.Float64
+	}
+	return value
+}
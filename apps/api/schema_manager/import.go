@@ -0,0 +1,462 @@
+package schema_manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"agentic-template/api/connectors"
+	"agentic-template/api/db"
+	"agentic-template/api/queue"
+	"agentic-template/api/requestctx"
+)
+
+// ImportJobKind is the background job kind copying the rows of a data
+// import
+const ImportJobKind = "import_table"
+
+// Statuses of a data import
+const (
+	ImportPending   = "pending" // Queued, or waiting for a retry after a failed attempt
+	ImportRunning   = "running"
+	ImportSucceeded = "succeeded"
+	ImportFailed    = "failed" // Its job's last attempt failed
+)
+
+// importBatchSize is how many source rows are read and inserted at a time
+const importBatchSize = 500
+
+// MinImportSyncInterval is the shortest interval imports sync at
+const MinImportSyncInterval = 15 * time.Minute
+
+// DataImport tracks the copy of an external table into a user table
+type DataImport struct {
+	ID           int64         `json:"id"`
+	Source       string        `json:"source"`       // Name of the source in IMPORT_SOURCES
+	SourceTable  string        `json:"source_table"` // Schema-qualified
+	TableID      *int          `json:"table_id,omitempty"`
+	Status       string        `json:"status"`
+	JobID        *int64        `json:"job_id,omitempty"`
+	RowsTotal    int64         `json:"rows_total"` // The source's estimate, so progress may pass it
+	RowsCopied   int64         `json:"rows_copied"`
+	RowsFailed   int64         `json:"rows_failed"` // Rows whose values didn't fit their column
+	ErrorMessage *string       `json:"error_message,omitempty"`
+	SyncInterval time.Duration `json:"sync_interval,omitempty"` // Between copies of the source; 0 imports once
+	CreatedBy    *string       `json:"created_by,omitempty"`
+	CreatedAt    time.Time     `json:"created_at"`
+	UpdatedAt    time.Time     `json:"updated_at"`
+	CompletedAt  *time.Time    `json:"completed_at,omitempty"`
+}
+
+// ImportSource is an external database configured for imports. Its URL
+// stays in the configuration, so credentials never reach callers.
+type ImportSource struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"` // A connectors kind, e.g. connectors.KindPostgres
+}
+
+// ImportColumn maps a source column to the column an import creates
+type ImportColumn struct {
+	SourceColumn string           `json:"source_column"`
+	NativeType   string           `json:"native_type"`
+	Column       ColumnDefinition `json:"column"`
+}
+
+// ImportTablePreview is a source table with the columns importing it
+// creates
+type ImportTablePreview struct {
+	SourceTable string         `json:"source_table"` // Schema-qualified
+	RowEstimate int64          `json:"row_estimate"`
+	Columns     []ImportColumn `json:"columns"`
+}
+
+// ImportRequest imports a table of a source into a new user table
+type ImportRequest struct {
+	Source      string  `json:"source"`
+	SourceTable string  `json:"source_table"`   // Qualified by its schema unless only one schema has it
+	Name        string  `json:"name,omitempty"` // Of the new table; defaults to the source table's
+	Description *string `json:"description,omitempty"`
+	// SyncInterval copies the source again this often, replacing the
+	// table's records; 0 imports once
+	SyncInterval time.Duration `json:"sync_interval,omitempty"`
+}
+
+// ListImportsOptions filters and pages ListImports
+type ListImportsOptions struct {
+	PageSize int    // Defaults to DefaultTablePageSize, max MaxTablePageSize
+	BeforeID int64  // Only imports older than this ID; 0 starts from the newest
+	Status   string // Only imports in this status
+}
+
+// ImportStore is implemented by stores that track data imports and insert
+// their rows
+type ImportStore interface {
+	// InsertImport stores a pending import and returns its ID
+	InsertImport(ctx context.Context, imp DataImport) (int64, error)
+	// GetImport returns an import, or ErrImportNotFound
+	GetImport(ctx context.Context, id int64) (*DataImport, error)
+	// ListImports returns imports, newest first
+	ListImports(ctx context.Context, opts ListImportsOptions) ([]DataImport, error)
+	// UpdateImport stores the status, job, progress, and error of an
+	// import, completing it when it succeeded or failed
+	UpdateImport(ctx context.Context, imp DataImport) error
+	// StopImportSync clears the sync interval of an import, or returns
+	// ErrImportNotFound
+	StopImportSync(ctx context.Context, id int64) error
+	// ClearRecords deletes every record of a user table
+	ClearRecords(ctx context.Context, tableName string) error
+	// InsertTextRecords inserts rows of text values, nil for NULL, cast
+	// to the types of the columns, all or none
+	InsertTextRecords(ctx context.Context, tableName string, columns []ColumnDefinition, rows [][]*string) error
+}
+
+// importJob is the payload of an ImportJobKind job
+type importJob struct {
+	ImportID int64                `json:"import_id"`
+	Tenant   string               `json:"tenant,omitempty"`
+	Schema   string               `json:"schema,omitempty"`
+	Columns  []importColumnSource `json:"columns"`
+}
+
+// importColumnSource pairs a column of the imported table with its source
+// column
+type importColumnSource struct {
+	ColumnName   string `json:"column_name"`
+	SourceColumn string `json:"source_column"`
+}
+
+var importSources atomic.Pointer[map[string]string]
+
+// SetImportSources sets the external databases imports may read, by name
+func SetImportSources(sources map[string]string) {
+	importSources.Store(&sources)
+}
+
+// ListImportSources returns the configured sources by name. Sources with
+// URLs no connector supports are left out.
+func ListImportSources() []ImportSource {
+	sources := []ImportSource{}
+	if configured := importSources.Load(); configured != nil {
+		for name, rawURL := range *configured {
+			if kind, err := connectors.SourceKind(rawURL); err == nil {
+				sources = append(sources, ImportSource{Name: name, Kind: kind})
+			}
+		}
+	}
+	sort.Slice(sources, func(i, j int) bool { return sources[i].Name < sources[j].Name })
+	return sources
+}
+
+// openImportSource connects to a configured source
+func openImportSource(ctx context.Context, name string) (connectors.Connector, error) {
+	var rawURL string
+	if configured := importSources.Load(); configured != nil {
+		rawURL = (*configured)[name]
+	}
+	if rawURL == "" {
+		return nil, fmt.Errorf("%w: %q", ErrImportSourceNotFound, name)
+	}
+	return connectors.Open(ctx, rawURL)
+}
+
+// PreviewImport lists the tables of a source with the columns importing
+// each would create
+func PreviewImport(ctx context.Context, source string) ([]ImportTablePreview, error) {
+	conn, err := openImportSource(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	tables, err := conn.ListTables(ctx)
+	if err != nil {
+		return nil, err
+	}
+	previews := make([]ImportTablePreview, len(tables))
+	for i, table := range tables {
+		previews[i] = ImportTablePreview{
+			SourceTable: table.QualifiedName(),
+			RowEstimate: table.RowEstimate,
+			Columns:     importColumns(table),
+		}
+	}
+	return previews, nil
+}
+
+// StartImport creates the table an import fills, as the caller, and
+// queues the job copying the source's rows into it
+func (sm *SchemaManager) StartImport(ctx context.Context, jobs *queue.Queue, req ImportRequest) (*DataImport, error) {
+	store, err := sm.importStore()
+	if err != nil {
+		return nil, err
+	}
+	if req.SourceTable == "" {
+		return nil, invalidField("source_table", "source table is required")
+	}
+	if req.SyncInterval < 0 || (req.SyncInterval > 0 && req.SyncInterval < MinImportSyncInterval) {
+		return nil, invalidField("sync_interval_minutes", "must be 0 or at least %d", int(MinImportSyncInterval/time.Minute))
+	}
+	conn, err := openImportSource(ctx, req.Source)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	source, err := connectors.FindTable(ctx, conn, req.SourceTable)
+	if errors.Is(err, connectors.ErrTableNotFound) {
+		return nil, invalidField("source_table", "%v", err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(source.Columns) == 0 {
+		return nil, invalidField("source_table", "source table %s has no columns", source.QualifiedName())
+	}
+	limits, err := quotasFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if limits.MaxRowsPerTable > 0 && source.RowEstimate > limits.MaxRowsPerTable {
+		return nil, &QuotaError{Limit: "max_rows_per_table", Max: limits.MaxRowsPerTable, Current: source.RowEstimate}
+	}
+
+	columns := importColumns(*source)
+	createReq := CreateTableRequest{Name: req.Name, Description: req.Description}
+	if createReq.Name == "" {
+		createReq.Name = source.Name
+	}
+	for _, col := range columns {
+		createReq.Columns = append(createReq.Columns, col.Column)
+	}
+	table, err := sm.CreateTable(ctx, createReq)
+	if err != nil {
+		return nil, err
+	}
+
+	actor := requestctx.Actor(ctx)
+	id, err := store.InsertImport(ctx, DataImport{
+		Source:       req.Source,
+		SourceTable:  source.QualifiedName(),
+		TableID:      &table.ID,
+		RowsTotal:    source.RowEstimate,
+		SyncInterval: req.SyncInterval,
+		CreatedBy:    &actor,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to store data import: %w", err)
+	}
+	imp, err := store.GetImport(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	payload := importJob{ImportID: id, Tenant: requestctx.Tenant(ctx), Schema: requestctx.TenantSchema(ctx)}
+	for _, col := range columns {
+		// Import column names are already sanitized, so they are the
+		// machine names
+		payload.Columns = append(payload.Columns, importColumnSource{ColumnName: col.Column.Name, SourceColumn: col.SourceColumn})
+	}
+	job, err := jobs.Enqueue(ctx, ImportJobKind, payload, queue.EnqueueOptions{})
+	if err != nil {
+		message := err.Error()
+		imp.Status, imp.ErrorMessage = ImportFailed, &message
+	} else {
+		imp.JobID = &job.ID
+	}
+	if updateErr := store.UpdateImport(ctx, *imp); updateErr != nil {
+		return nil, updateErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return store.GetImport(ctx, id)
+}
+
+// GetImport returns a data import, or ErrImportNotFound
+func (sm *SchemaManager) GetImport(ctx context.Context, id int64) (*DataImport, error) {
+	store, err := sm.importStore()
+	if err != nil {
+		return nil, err
+	}
+	return store.GetImport(ctx, id)
+}
+
+// StopImportSync stops an import from copying its source again; a copy
+// already queued or running finishes
+func (sm *SchemaManager) StopImportSync(ctx context.Context, id int64) (*DataImport, error) {
+	store, err := sm.importStore()
+	if err != nil {
+		return nil, err
+	}
+	if err := store.StopImportSync(ctx, id); err != nil {
+		return nil, err
+	}
+	return store.GetImport(ctx, id)
+}
+
+// ListImports returns data imports, newest first
+func (sm *SchemaManager) ListImports(ctx context.Context, opts ListImportsOptions) ([]DataImport, error) {
+	store, err := sm.importStore()
+	if err != nil {
+		return nil, err
+	}
+	switch opts.Status {
+	case "", ImportPending, ImportRunning, ImportSucceeded, ImportFailed:
+	default:
+		return nil, invalidField("status", "invalid status: %q (expected pending, running, succeeded, or failed)", opts.Status)
+	}
+	if opts.PageSize <= 0 {
+		opts.PageSize = DefaultTablePageSize
+	}
+	if opts.PageSize > MaxTablePageSize {
+		opts.PageSize = MaxTablePageSize
+	}
+	return store.ListImports(ctx, opts)
+}
+
+// ImportJobHandler runs queued imports on the database of a manager, in
+// the schema of the tenant that queued them, queueing the next copy of
+// those that sync
+func ImportJobHandler(dbManager *db.Manager, jobs *queue.Queue) queue.Handler {
+	return func(ctx context.Context, job *queue.Job) error {
+		var payload importJob
+		if err := job.Decode(&payload); err != nil {
+			return err
+		}
+		if payload.Schema != "" {
+			ctx = requestctx.WithTenant(ctx, payload.Tenant, payload.Schema)
+		}
+		return FromManager(dbManager).runImport(ctx, jobs, job, payload)
+	}
+}
+
+// runImport copies the rows of an import, recording its progress. Each
+// attempt empties the table first, so retries and syncs don't duplicate
+// rows.
+func (sm *SchemaManager) runImport(ctx context.Context, jobs *queue.Queue, job *queue.Job, payload importJob) error {
+	store, err := sm.importStore()
+	if err != nil {
+		return err
+	}
+	imp, err := store.GetImport(ctx, payload.ImportID)
+	if err != nil {
+		return err
+	}
+	superseded := imp.JobID != nil && *imp.JobID != job.ID
+	stopped := imp.Status == ImportFailed || (imp.Status == ImportSucceeded && imp.SyncInterval == 0)
+	if superseded || stopped {
+		return nil
+	}
+
+	imp.Status, imp.RowsCopied, imp.RowsFailed, imp.ErrorMessage = ImportRunning, 0, 0, nil
+	if err := store.UpdateImport(ctx, *imp); err != nil {
+		return err
+	}
+	if err := sm.copyImport(ctx, store, imp, payload.Columns); err != nil {
+		message := err.Error()
+		imp.Status, imp.ErrorMessage = ImportPending, &message
+		if job.Attempts >= job.MaxAttempts {
+			imp.Status = ImportFailed
+		}
+		if updateErr := store.UpdateImport(context.WithoutCancel(ctx), *imp); updateErr != nil {
+			log.Printf("Warning: failed to record failure of data import %d: %v", imp.ID, updateErr)
+		}
+		return err
+	}
+
+	if imp.SyncInterval > 0 {
+		next, err := jobs.Enqueue(ctx, ImportJobKind, payload, queue.EnqueueOptions{RunAt: time.Now().Add(imp.SyncInterval)})
+		if err != nil {
+			return fmt.Errorf("failed to schedule the next sync: %w", err)
+		}
+		imp.JobID = &next.ID
+	}
+	imp.Status = ImportSucceeded
+	if err := store.UpdateImport(ctx, *imp); err != nil {
+		return err
+	}
+	log.Printf("Imported %d row(s) of %s from %s, %d failed (job %d)", imp.RowsCopied, imp.SourceTable, imp.Source, imp.RowsFailed, job.ID)
+	return nil
+}
+
+// copyImport empties the import's table and copies the source rows into
+// it a batch at a time
+func (sm *SchemaManager) copyImport(ctx context.Context, store ImportStore, imp *DataImport, columns []importColumnSource) error {
+	if imp.TableID == nil {
+		return ErrTableNotFound
+	}
+	table, err := sm.GetTable(ctx, *imp.TableID)
+	if err != nil {
+		return err
+	}
+	defs := make([]ColumnDefinition, len(columns))
+	sourceColumns := make([]string, len(columns))
+	for i, col := range columns {
+		def := table.column(col.ColumnName)
+		if def == nil {
+			return fmt.Errorf("column '%s' was removed from table '%s'", col.ColumnName, table.Name)
+		}
+		defs[i], sourceColumns[i] = *def, col.SourceColumn
+	}
+
+	conn, err := openImportSource(ctx, imp.Source)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	source, err := connectors.FindTable(ctx, conn, imp.SourceTable)
+	if err != nil {
+		return err
+	}
+
+	if err := store.ClearRecords(ctx, table.TableName); err != nil {
+		return err
+	}
+	return conn.ReadRows(ctx, *source, sourceColumns, importBatchSize, func(rows [][]*string) error {
+		if err := sm.checkRecordWrite(ctx, table, len(rows)); err != nil {
+			return err
+		}
+		failed, err := insertImportRows(ctx, store, table.TableName, defs, rows)
+		if err != nil {
+			return err
+		}
+		imp.RowsCopied += int64(len(rows) - failed)
+		imp.RowsFailed += int64(failed)
+		return store.UpdateImport(ctx, *imp)
+	})
+}
+
+// insertImportRows inserts a batch of rows, falling back to one row at a
+// time when the batch fails so only the rows that don't fit are skipped.
+// It returns how many were skipped.
+func insertImportRows(ctx context.Context, store ImportStore, tableName string, columns []ColumnDefinition, rows [][]*string) (int, error) {
+	if store.InsertTextRecords(ctx, tableName, columns, rows) == nil {
+		return 0, nil
+	}
+	failed := 0
+	for _, row := range rows {
+		if err := store.InsertTextRecords(ctx, tableName, columns, [][]*string{row}); err != nil {
+			if ctx.Err() != nil {
+				return 0, ctx.Err()
+			}
+			failed++
+		}
+	}
+	return failed, nil
+}
+
+// importStore returns the store as an ImportStore
+func (sm *SchemaManager) importStore() (ImportStore, error) {
+	if sm.store == nil {
+		return nil, ErrDatabaseNotConfigured
+	}
+	store, ok := sm.store.(ImportStore)
+	if !ok {
+		return nil, fmt.Errorf("imports are not supported on %s", sm.store.Dialect().Name())
+	}
+	return store, nil
+}
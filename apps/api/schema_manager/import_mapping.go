@@ -0,0 +1,91 @@
+package schema_manager
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"agentic-template/api/connectors"
+)
+
+// importColumns maps the columns of a source table to nullable columns of
+// the closest data type, named so they sanitize to distinct identifiers
+func importColumns(table connectors.Table) []ImportColumn {
+	columns := make([]ImportColumn, len(table.Columns))
+	// Source columns named like system columns get a "source_" prefix
+	var taken []string
+	for _, col := range systemColumns {
+		taken = append(taken, col.Name)
+	}
+	for i, col := range table.Columns {
+		name, err := SanitizeIdentifier(col.Name)
+		if err != nil {
+			name = fmt.Sprintf("column_%d", i+1)
+		}
+		if IsSystemColumn(name) {
+			name = "source_" + name
+		}
+		for base, n := name, 2; slices.Contains(taken, name); n++ {
+			name = fmt.Sprintf("%s_%d", base, n)
+		}
+		taken = append(taken, name)
+
+		columns[i] = ImportColumn{
+			SourceColumn: col.Name,
+			NativeType:   col.NativeType,
+			Column: ColumnDefinition{
+				Name:         name,
+				DataType:     importDataType(col),
+				IsNullable:   col.Nullable,
+				DisplayOrder: i,
+			},
+		}
+	}
+	return columns
+}
+
+// importDataType returns the data type storing the values of a source
+// column, text_long when no other fits. Database types are matched by
+// name, spreadsheet columns by the type inferred for them, and Airtable
+// fields by their field type.
+func importDataType(col connectors.Column) DataType {
+	native := strings.ToLower(strings.TrimSpace(col.NativeType))
+	base, _, _ := strings.Cut(native, "(")
+	base = strings.TrimSpace(strings.TrimSuffix(base, " unsigned"))
+	unsigned := strings.HasSuffix(native, " unsigned")
+
+	switch base {
+	case "boolean", "bool", "checkbox":
+		return DataTypeBoolean
+	case "tinyint":
+		if native == "tinyint(1)" {
+			return DataTypeBoolean // MySQL's BOOLEAN
+		}
+		return DataTypeNumber
+	case "smallint", "mediumint", "int2", "rating", "count", "autonumber":
+		return DataTypeNumber
+	case "integer", "int", "int4":
+		if unsigned {
+			return DataTypeDecimal // Past INTEGER's range
+		}
+		return DataTypeNumber
+	case "bigint", "int8":
+		return DataTypeText // Past the range of INTEGER and DECIMAL(18,8)
+	case "numeric", "decimal", "real", "float", "double", "double precision", "float4", "float8",
+		"number", "currency", "percent", "duration":
+		return DataTypeDecimal
+	case "date", "datetime", "timestamp", "timestamp with time zone", "timestamp without time zone", "timestamptz",
+		"createdtime", "lastmodifiedtime":
+		return DataTypeDate
+	case "json", "jsonb", "multipleselects", "multiplerecordlinks", "multipleattachments", "multiplelookupvalues",
+		"singlecollaborator", "multiplecollaborators", "createdby", "lastmodifiedby", "barcode", "button":
+		return DataTypeJSON // Airtable passes these on as JSON
+	case "email", "phonenumber", "singleselect":
+		return DataTypeText
+	case "character varying", "varchar", "character", "char":
+		if col.MaxLength > 0 && col.MaxLength <= 255 {
+			return DataTypeText
+		}
+	}
+	return DataTypeTextLong
+}
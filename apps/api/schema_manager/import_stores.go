@@ -0,0 +1,189 @@
+package schema_manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"agentic-template/api/db"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Imports run as background jobs, which need PostgreSQL
+var _ ImportStore = &PostgresStore{}
+
+// maxInsertParams is PostgreSQL's limit on the parameters of a statement
+const maxInsertParams = 65535
+
+// dataImportColumns are the columns scanned by scanDataImport
+const dataImportColumns = `id, source, source_table, table_id, status, job_id, rows_total, rows_copied, rows_failed,
+		       error_message, sync_interval_minutes, created_by, created_at, updated_at, completed_at`
+
+// InsertImport stores a pending import
+func (s *PostgresStore) InsertImport(ctx context.Context, imp DataImport) (int64, error) {
+	var id int64
+	query := `
+		INSERT INTO data_imports (source, source_table, table_id, rows_total, sync_interval_minutes, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`
+	minutes := int(imp.SyncInterval / time.Minute)
+	err := queryRow(ctx, s.pool, query, imp.Source, imp.SourceTable, imp.TableID, imp.RowsTotal, minutes, imp.CreatedBy).Scan(&id)
+	return id, err
+}
+
+// GetImport returns an import. It reads from the primary, since imports
+// are read right after they change.
+func (s *PostgresStore) GetImport(ctx context.Context, id int64) (*DataImport, error) {
+	query := `SELECT ` + dataImportColumns + ` FROM data_imports WHERE id = $1`
+	imp, err := scanDataImport(queryRow(ctx, s.pool, query, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrImportNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query data import: %w", err)
+	}
+	return imp, nil
+}
+
+// ListImports returns imports, newest first
+func (s *PostgresStore) ListImports(ctx context.Context, opts ListImportsOptions) ([]DataImport, error) {
+	query := `SELECT ` + dataImportColumns + ` FROM data_imports WHERE true`
+	var args []interface{}
+	if opts.BeforeID > 0 {
+		args = append(args, opts.BeforeID)
+		query += fmt.Sprintf(" AND id < $%d", len(args))
+	}
+	if opts.Status != "" {
+		args = append(args, opts.Status)
+		query += fmt.Sprintf(" AND status = $%d", len(args))
+	}
+	args = append(args, opts.PageSize)
+	query += fmt.Sprintf(" ORDER BY id DESC LIMIT $%d", len(args))
+
+	queryCtx, cancel := db.StatementContext(ctx)
+	defer cancel()
+	rows, err := s.reader().Query(queryCtx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query data imports: %w", err)
+	}
+	defer rows.Close()
+
+	imports := []DataImport{}
+	for rows.Next() {
+		imp, err := scanDataImport(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan data import: %w", err)
+		}
+		imports = append(imports, *imp)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query data imports: %w", err)
+	}
+	return imports, nil
+}
+
+// UpdateImport stores the status, job, progress, and error of an import
+func (s *PostgresStore) UpdateImport(ctx context.Context, imp DataImport) error {
+	query := `
+		UPDATE data_imports
+		SET status = $2, job_id = $3, rows_copied = $4, rows_failed = $5, error_message = $6,
+		    completed_at = CASE WHEN $2 IN ('succeeded', 'failed') THEN NOW() END
+		WHERE id = $1
+	`
+	if err := exec(ctx, s.pool, query, imp.ID, imp.Status, imp.JobID, imp.RowsCopied, imp.RowsFailed, imp.ErrorMessage); err != nil {
+		return fmt.Errorf("failed to update data import: %w", err)
+	}
+	return nil
+}
+
+// StopImportSync clears the sync interval of an import
+func (s *PostgresStore) StopImportSync(ctx context.Context, id int64) error {
+	ctx, cancel := db.StatementContext(ctx)
+	defer cancel()
+	tag, err := s.pool.Exec(ctx, `UPDATE data_imports SET sync_interval_minutes = 0 WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to stop import sync: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrImportNotFound
+	}
+	return nil
+}
+
+// ClearRecords deletes every record of a user table. It isn't bound by
+// the statement timeout, since imported tables may be large.
+func (s *PostgresStore) ClearRecords(ctx context.Context, tableName string) error {
+	if err := ValidateIdentifierSafety(tableName); err != nil {
+		return err
+	}
+	if _, err := s.pool.Exec(ctx, "DELETE FROM "+tableName); err != nil {
+		return fmt.Errorf("failed to clear records: %w", err)
+	}
+	return nil
+}
+
+// InsertTextRecords inserts rows with multi-row INSERTs, as many rows per
+// statement as the parameter limit allows, in one transaction
+func (s *PostgresStore) InsertTextRecords(ctx context.Context, tableName string, columns []ColumnDefinition, rows [][]*string) error {
+	if err := ValidateIdentifierSafety(tableName); err != nil {
+		return err
+	}
+	names := make([]string, len(columns))
+	for i, col := range columns {
+		names[i] = col.ColumnName
+	}
+	rowsPerStatement := maxInsertParams / len(columns)
+
+	return db.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
+		for start := 0; start < len(rows); start += rowsPerStatement {
+			chunk := rows[start:min(start+rowsPerStatement, len(rows))]
+			values := make([]string, len(chunk))
+			args := make([]any, 0, len(chunk)*len(columns))
+			for r, row := range chunk {
+				casts := make([]string, len(columns))
+				for i, col := range columns {
+					args = append(args, row[i])
+					casts[i] = fmt.Sprintf("$%d::text::%s", len(args), col.PostgresType)
+				}
+				values[r] = "(" + strings.Join(casts, ", ") + ")"
+			}
+			query := fmt.Sprintf("INSERT INTO %s (%s) VALUES %s", tableName, strings.Join(names, ", "), strings.Join(values, ", "))
+			if _, err := tx.Exec(ctx, query, args...); err != nil {
+				return fmt.Errorf("failed to insert records: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// scanDataImport reads a row of dataImportColumns
+func scanDataImport(row pgx.Row) (*DataImport, error) {
+	var imp DataImport
+	var syncMinutes int
+	err := row.Scan(
+		&imp.ID,
+		&imp.Source,
+		&imp.SourceTable,
+		&imp.TableID,
+		&imp.Status,
+		&imp.JobID,
+		&imp.RowsTotal,
+		&imp.RowsCopied,
+		&imp.RowsFailed,
+		&imp.ErrorMessage,
+		&syncMinutes,
+		&imp.CreatedBy,
+		&imp.CreatedAt,
+		&imp.UpdatedAt,
+		&imp.CompletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	imp.SyncInterval = time.Duration(syncMinutes) * time.Minute
+	return &imp, nil
+}
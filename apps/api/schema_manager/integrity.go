@@ -0,0 +1,368 @@
+package schema_manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"agentic-template/api/db"
+)
+
+// Statically assert that both stores check the integrity of user tables
+var (
+	_ IntegrityStore = &PostgresStore{}
+	_ IntegrityStore = &SQLiteStore{}
+)
+
+// IntegrityCheck is a kind of problem CheckIntegrity looks for
+type IntegrityCheck string
+
+const (
+	IntegrityOrphanedRelation IntegrityCheck = "orphaned_relation" // Relation value without a record in the referenced table
+	IntegrityInvalidValue     IntegrityCheck = "invalid_value"     // Value breaking the column's data type, stored by a lenient engine
+	IntegrityMissingValue     IntegrityCheck = "missing_value"     // NULL in a column that isn't nullable
+	IntegrityDuplicateValue   IntegrityCheck = "duplicate_value"   // Repeated value in a unique column
+)
+
+// IntegrityFix is an automatic repair of the records an issue affects
+type IntegrityFix string
+
+const (
+	IntegrityFixSetNull       IntegrityFix = "set_null"       // Clear the value
+	IntegrityFixSetDefault    IntegrityFix = "set_default"    // Replace the value with the column's default
+	IntegrityFixDeleteRecords IntegrityFix = "delete_records" // Delete the records
+)
+
+// Limits of integrity reports
+const (
+	DefaultIntegritySampleSize = 10
+	MaxIntegritySampleSize     = 100
+)
+
+// IntegrityFixes lists the automatic repairs
+var IntegrityFixes = []IntegrityFix{IntegrityFixSetNull, IntegrityFixSetDefault, IntegrityFixDeleteRecords}
+
+// CheckIntegrityRequest selects the table to check and the automatic
+// repairs to apply to the issues found
+type CheckIntegrityRequest struct {
+	TableID    int            `json:"table_id"`
+	SampleSize int            `json:"sample_size,omitempty"` // Record IDs listed per issue; defaults to DefaultIntegritySampleSize
+	Fixes      []IntegrityFix `json:"fixes,omitempty"`       // Repairs to apply; none only reports
+}
+
+// IntegrityIssue is one problem found in a column
+type IntegrityIssue struct {
+	Check           IntegrityCheck `json:"check"`
+	ColumnName      string         `json:"column_name"`
+	Description     string         `json:"description"`
+	RecordCount     int64          `json:"record_count"`          // Records affected before any fix
+	SampleRecordIDs []int64        `json:"sample_record_ids"`     // Lowest IDs of the affected records
+	Fix             IntegrityFix   `json:"fix,omitempty"`         // Available repair; empty when it needs a person
+	FixedCount      int64          `json:"fixed_count,omitempty"` // Records repaired when the request asked for Fix
+	FixError        string         `json:"fix_error,omitempty"`   // Why the repair failed
+	condition       string         // Matches the affected records
+	defaultValueSQL string         // Replacement of set_default
+}
+
+// IntegrityReport lists the issues found in a table, clean when empty
+type IntegrityReport struct {
+	TableID int              `json:"table_id"`
+	Name    string           `json:"name"`
+	Issues  []IntegrityIssue `json:"issues"`
+	Fixed   bool             `json:"fixed"` // Some issue was repaired
+}
+
+// IntegrityStore is implemented by stores that can scan user tables for
+// integrity issues
+type IntegrityStore interface {
+	// FindRecords returns how many records of a table match condition and
+	// the IDs of the first limit of them
+	FindRecords(ctx context.Context, tableName, condition string, limit int) (int64, []int64, error)
+	// UpdateRecords sets a column to expr in the records matching
+	// condition and returns how many changed
+	UpdateRecords(ctx context.Context, tableName, columnName, expr, condition string) (int64, error)
+	// DeleteRecords deletes the records matching condition and returns
+	// how many were deleted
+	DeleteRecords(ctx context.Context, tableName, condition string) (int64, error)
+}
+
+// CheckIntegrity scans a table for relation values pointing at missing
+// records, values the engine should have rejected, and constraint
+// violations left by imports that bypassed them. Issues whose fix is in
+// req.Fixes are repaired, each in its own statement; the report still
+// counts the records affected before the fix.
+func (sm *SchemaManager) CheckIntegrity(ctx context.Context, req CheckIntegrityRequest) (*IntegrityReport, error) {
+	ctx, span := startSpan(ctx, "check_integrity", attrTableID.Int(req.TableID))
+	report, err := sm.checkIntegrity(ctx, req)
+	endSpan(span, err)
+	return report, err
+}
+
+// checkIntegrity runs CheckIntegrity within its span
+func (sm *SchemaManager) checkIntegrity(ctx context.Context, req CheckIntegrityRequest) (*IntegrityReport, error) {
+	if sm.store == nil {
+		return nil, ErrDatabaseNotConfigured
+	}
+	store, ok := sm.store.(IntegrityStore)
+	if !ok {
+		return nil, fmt.Errorf("integrity checks are not supported on %s", sm.store.Dialect().Name())
+	}
+	sampleSize := req.SampleSize
+	if sampleSize == 0 {
+		sampleSize = DefaultIntegritySampleSize
+	}
+	if sampleSize < 0 || sampleSize > MaxIntegritySampleSize {
+		return nil, invalidField("sample_size", "must be between 1 and %d", MaxIntegritySampleSize)
+	}
+	for i, fix := range req.Fixes {
+		if !slices.Contains(IntegrityFixes, fix) {
+			return nil, invalidField(fmt.Sprintf("fixes[%d]", i), "invalid fix: %q (expected set_null, set_default, or delete_records)", fix)
+		}
+	}
+
+	table, err := sm.GetTable(ctx, req.TableID)
+	if err != nil {
+		return nil, err
+	}
+	// Repairs other than deletes write records
+	if slices.ContainsFunc(req.Fixes, func(fix IntegrityFix) bool { return fix != IntegrityFixDeleteRecords }) {
+		if err := sm.checkRecordWrite(ctx, table, 0); err != nil {
+			return nil, err
+		}
+	}
+	candidates, err := sm.integrityCandidates(ctx, table)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &IntegrityReport{TableID: table.ID, Name: table.Name, Issues: []IntegrityIssue{}}
+	for _, issue := range candidates {
+		count, ids, err := store.FindRecords(ctx, table.TableName, issue.condition, sampleSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check %s of column '%s': %w", issue.Check, issue.ColumnName, err)
+		}
+		if count == 0 {
+			continue
+		}
+		issue.RecordCount, issue.SampleRecordIDs = count, ids
+		if issue.Fix != "" && slices.Contains(req.Fixes, issue.Fix) {
+			issue.FixedCount, err = applyIntegrityFix(ctx, store, table.TableName, issue)
+			if err != nil {
+				issue.FixError = err.Error()
+			}
+			report.Fixed = report.Fixed || issue.FixedCount > 0
+		}
+		report.Issues = append(report.Issues, issue)
+	}
+	return report, nil
+}
+
+// integrityCandidates lists the issues each column of a table could have,
+// in the order they are checked and fixed: clearing orphaned relations and
+// invalid values comes before looking for missing ones
+func (sm *SchemaManager) integrityCandidates(ctx context.Context, table *TableDefinition) ([]IntegrityIssue, error) {
+	dialect := sm.store.Dialect()
+	var orphaned, invalid, missing, duplicate []IntegrityIssue
+	for _, col := range table.Columns {
+		// Formula columns have no stored values to check
+		if col.DataType == DataTypeFormula {
+			continue
+		}
+		if err := ValidateIdentifierSafety(col.ColumnName); err != nil {
+			return nil, fmt.Errorf("column name '%s' failed safety check: %w", col.ColumnName, err)
+		}
+		// Records breaking a column without a default can only be cleared
+		// when it is nullable, or deleted
+		clear := IntegrityFixSetNull
+		if !col.IsNullable {
+			clear = IntegrityFixDeleteRecords
+		}
+
+		if col.DataType == DataTypeRelation && col.ForeignKeyToTableID != nil {
+			issue, err := sm.orphanedRelations(ctx, table, col)
+			if err != nil {
+				return nil, err
+			}
+			issue.Fix = clear
+			orphaned = append(orphaned, issue)
+		}
+
+		if condition := dialect.InvalidValueCondition(col); condition != "" {
+			invalid = append(invalid, IntegrityIssue{
+				Check:       IntegrityInvalidValue,
+				ColumnName:  col.ColumnName,
+				Description: fmt.Sprintf("'%s' holds values that are not valid %s values", col.Name, col.DataType),
+				Fix:         clear,
+				condition:   condition,
+			})
+		}
+
+		if !col.IsNullable {
+			issue := IntegrityIssue{
+				Check:       IntegrityMissingValue,
+				ColumnName:  col.ColumnName,
+				Description: fmt.Sprintf("'%s' is required but empty", col.Name),
+				condition:   fmt.Sprintf("%s IS NULL", col.ColumnName),
+			}
+			if col.DefaultValue != nil {
+				defaultSQL, err := dialect.DefaultValueSQL(col.DataType, col.DefaultValue)
+				if err != nil {
+					return nil, fmt.Errorf("invalid default value for column '%s': %w", col.Name, err)
+				}
+				issue.Fix, issue.defaultValueSQL = IntegrityFixSetDefault, defaultSQL
+			}
+			missing = append(missing, issue)
+		}
+
+		// Which duplicate to keep is a decision for a person
+		if col.IsUnique {
+			duplicate = append(duplicate, IntegrityIssue{
+				Check:       IntegrityDuplicateValue,
+				ColumnName:  col.ColumnName,
+				Description: fmt.Sprintf("'%s' must be unique but repeats values", col.Name),
+				condition: fmt.Sprintf("%[1]s IN (SELECT %[1]s FROM %[2]s GROUP BY %[1]s HAVING count(*) > 1)",
+					col.ColumnName, table.TableName),
+			})
+		}
+	}
+
+	candidates := append(orphaned, invalid...)
+	candidates = append(candidates, missing...)
+	return append(candidates, duplicate...), nil
+}
+
+// orphanedRelations describes the orphaned values of a relation column.
+// When the referenced table is gone every value is orphaned.
+func (sm *SchemaManager) orphanedRelations(ctx context.Context, table *TableDefinition, col ColumnDefinition) (IntegrityIssue, error) {
+	issue := IntegrityIssue{Check: IntegrityOrphanedRelation, ColumnName: col.ColumnName}
+
+	referenced := table
+	if *col.ForeignKeyToTableID != table.ID {
+		var err error
+		referenced, err = sm.store.GetTable(ctx, *col.ForeignKeyToTableID)
+		if errors.Is(err, ErrTableNotFound) {
+			issue.Description = fmt.Sprintf("'%s' references table %d, which no longer exists", col.Name, *col.ForeignKeyToTableID)
+			issue.condition = fmt.Sprintf("%s IS NOT NULL", col.ColumnName)
+			return issue, nil
+		}
+		if err != nil {
+			return issue, fmt.Errorf("failed to get referenced table: %w", err)
+		}
+	}
+	if err := ValidateIdentifierSafety(referenced.TableName); err != nil {
+		return issue, fmt.Errorf("referenced table name '%s' failed safety check: %w", referenced.TableName, err)
+	}
+
+	issue.Description = fmt.Sprintf("'%s' references records missing from '%s'", col.Name, referenced.Name)
+	// The alias keeps self-references apart from the checked table
+	issue.condition = fmt.Sprintf("%[1]s IS NOT NULL AND NOT EXISTS (SELECT 1 FROM %[2]s AS referenced WHERE referenced.id = %[3]s.%[1]s)",
+		col.ColumnName, referenced.TableName, table.TableName)
+	return issue, nil
+}
+
+// applyIntegrityFix repairs the records of an issue
+func applyIntegrityFix(ctx context.Context, store IntegrityStore, tableName string, issue IntegrityIssue) (int64, error) {
+	switch issue.Fix {
+	case IntegrityFixSetNull:
+		return store.UpdateRecords(ctx, tableName, issue.ColumnName, "NULL", issue.condition)
+	case IntegrityFixSetDefault:
+		return store.UpdateRecords(ctx, tableName, issue.ColumnName, issue.defaultValueSQL, issue.condition)
+	case IntegrityFixDeleteRecords:
+		return store.DeleteRecords(ctx, tableName, issue.condition)
+	default:
+		return 0, fmt.Errorf("unknown fix %q", issue.Fix)
+	}
+}
+
+// FindRecords lists matching records with a window count, so one query
+// returns both
+func (s *PostgresStore) FindRecords(ctx context.Context, tableName, condition string, limit int) (int64, []int64, error) {
+	query := fmt.Sprintf("SELECT id, count(*) OVER () FROM %s WHERE %s ORDER BY id LIMIT $1", tableName, condition)
+	queryCtx, cancel := db.StatementContext(ctx)
+	defer cancel()
+	rows, err := s.reader().Query(queryCtx, query, limit)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer rows.Close()
+
+	var count int64
+	ids := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id, &count); err != nil {
+			return 0, nil, err
+		}
+		ids = append(ids, id)
+	}
+	return count, ids, rows.Err()
+}
+
+// UpdateRecords runs an UPDATE bounded by the statement timeout
+func (s *PostgresStore) UpdateRecords(ctx context.Context, tableName, columnName, expr, condition string) (int64, error) {
+	ctx, cancel := db.StatementContext(ctx)
+	defer cancel()
+	tag, err := s.pool.Exec(ctx, fmt.Sprintf("UPDATE %s SET %s = %s WHERE %s", tableName, columnName, expr, condition))
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// DeleteRecords runs a DELETE bounded by the statement timeout
+func (s *PostgresStore) DeleteRecords(ctx context.Context, tableName, condition string) (int64, error) {
+	ctx, cancel := db.StatementContext(ctx)
+	defer cancel()
+	tag, err := s.pool.Exec(ctx, fmt.Sprintf("DELETE FROM %s WHERE %s", tableName, condition))
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// FindRecords lists matching records with a window count, so one query
+// returns both
+func (s *SQLiteStore) FindRecords(ctx context.Context, tableName, condition string, limit int) (int64, []int64, error) {
+	query := fmt.Sprintf("SELECT id, count(*) OVER () FROM %s WHERE %s ORDER BY id LIMIT ?", tableName, condition)
+	ctx, cancel := db.StatementContext(ctx)
+	defer cancel()
+	rows, err := s.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer rows.Close()
+
+	var count int64
+	ids := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id, &count); err != nil {
+			return 0, nil, err
+		}
+		ids = append(ids, id)
+	}
+	return count, ids, rows.Err()
+}
+
+// UpdateRecords runs an UPDATE bounded by the statement timeout
+func (s *SQLiteStore) UpdateRecords(ctx context.Context, tableName, columnName, expr, condition string) (int64, error) {
+	ctx, cancel := db.StatementContext(ctx)
+	defer cancel()
+	result, err := s.db.ExecContext(ctx, fmt.Sprintf("UPDATE %s SET %s = %s WHERE %s", tableName, columnName, expr, condition))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// DeleteRecords runs a DELETE bounded by the statement timeout
+func (s *SQLiteStore) DeleteRecords(ctx context.Context, tableName, condition string) (int64, error) {
+	ctx, cancel := db.StatementContext(ctx)
+	defer cancel()
+	result, err := s.db.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE %s", tableName, condition))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,215 @@
+package schema_manager
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"agentic-template/api/db"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"modernc.org/sqlite"
+)
+
+// Statically assert that both stores update JSON columns in place
+var (
+	_ JSONStore = &PostgresStore{}
+	_ JSONStore = &SQLiteStore{}
+)
+
+// jsonOpShapes names the operations of a patch as they shape its SQL, for
+// the statement key: setting the root replaces the value outright
+func jsonOpShapes(ops []JSONPathOperation) []string {
+	shapes := make([]string, len(ops))
+	for i, op := range ops {
+		shapes[i] = string(op.Op)
+		if op.Op == JSONOpSet && len(op.Path) == 0 {
+			shapes[i] = "set_root"
+		}
+	}
+	return shapes
+}
+
+// PatchJSON applies the operations with jsonb_set and #- in one UPDATE
+func (s *PostgresStore) PatchJSON(ctx context.Context, table *TableDefinition, req PatchJSONRequest) (int64, error) {
+	query, args := patchJSONStatement(table, req)
+
+	var size int64
+	err := queryRow(ctx, s.pool, query, args...).Scan(&size)
+	var pgErr *pgconn.PgError
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return 0, fmt.Errorf("%w: %d", ErrRecordNotFound, req.RecordID)
+	case errors.As(err, &pgErr) && strings.HasPrefix(pgErr.Code, "22"):
+		// Data exceptions, such as a path through a scalar
+		return 0, invalidField("operations", "%s", pgErr.Message)
+	case err != nil:
+		return 0, fmt.Errorf("failed to update JSON value: %w", err)
+	}
+	return size, nil
+}
+
+// patchJSONStatement returns the cached UPDATE of a JSON patch and its
+// arguments
+func patchJSONStatement(table *TableDefinition, req PatchJSONRequest) (string, []any) {
+	args := []any{req.RecordID}
+	for _, op := range req.Operations {
+		if op.Op == JSONOpSet && len(op.Path) == 0 {
+			args = append(args, string(op.Value))
+			continue
+		}
+		args = append(args, op.Path)
+		if op.Op == JSONOpSet {
+			args = append(args, string(op.Value))
+		}
+	}
+
+	key := newStatementKey(table.TableName, "patch_json", []string{req.ColumnName}, jsonOpShapes(req.Operations))
+	query := recordStatements.get(key, func() string {
+		expr := fmt.Sprintf("COALESCE(%s, '{}'::jsonb)", req.ColumnName)
+		n := 1
+		param := func() int { n++; return n }
+		for _, op := range req.Operations {
+			switch {
+			case op.Op == JSONOpSet && len(op.Path) == 0:
+				expr = fmt.Sprintf("$%d::jsonb", param())
+			case op.Op == JSONOpSet:
+				path := param()
+				expr = fmt.Sprintf("jsonb_set(%s, $%d::text[], $%d::jsonb, true)", expr, path, param())
+			default:
+				expr = fmt.Sprintf("(%s #- $%d::text[])", expr, param())
+			}
+		}
+		return fmt.Sprintf("UPDATE %s SET %s = %s WHERE id = $1 RETURNING octet_length(%s::text)",
+			table.TableName, req.ColumnName, expr, req.ColumnName)
+	})
+	return query, args
+}
+
+// JSONValue reads the value at a path with #>, from the read replica
+func (s *PostgresStore) JSONValue(ctx context.Context, table *TableDefinition, req JSONValueRequest) ([]byte, error) {
+	key := newStatementKey(table.TableName, "json_value", []string{req.ColumnName})
+	query := recordStatements.get(key, func() string {
+		return fmt.Sprintf("SELECT (%s #> $2::text[])::text FROM %s WHERE id = $1", req.ColumnName, table.TableName)
+	})
+
+	path := req.Path
+	if path == nil {
+		path = []string{}
+	}
+	var value []byte
+	err := queryRow(ctx, s.reader(), query, req.RecordID, path).Scan(&value)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %d", ErrRecordNotFound, req.RecordID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JSON value: %w", err)
+	}
+	if value == nil {
+		return []byte("null"), nil
+	}
+	return value, nil
+}
+
+// PatchJSON applies the operations with json_set and json_remove in one
+// UPDATE
+func (s *SQLiteStore) PatchJSON(ctx context.Context, table *TableDefinition, req PatchJSONRequest) (int64, error) {
+	var args []any
+	for i, op := range req.Operations {
+		if op.Op == JSONOpSet && len(op.Path) == 0 {
+			args = append(args, string(op.Value))
+			continue
+		}
+		path, err := sqliteJSONPath(fmt.Sprintf("operations[%d].path", i), op.Path)
+		if err != nil {
+			return 0, err
+		}
+		args = append(args, path)
+		if op.Op == JSONOpSet {
+			args = append(args, string(op.Value))
+		}
+	}
+	args = append(args, req.RecordID)
+
+	key := newStatementKey(table.TableName, "patch_json", []string{req.ColumnName}, jsonOpShapes(req.Operations))
+	query := recordStatements.get(key, func() string {
+		expr := fmt.Sprintf("COALESCE(%s, '{}')", req.ColumnName)
+		for _, op := range req.Operations {
+			switch {
+			case op.Op == JSONOpSet && len(op.Path) == 0:
+				expr = "json(?)"
+			case op.Op == JSONOpSet:
+				expr = fmt.Sprintf("json_set(%s, ?, json(?))", expr)
+			default:
+				expr = fmt.Sprintf("json_remove(%s, ?)", expr)
+			}
+		}
+		return fmt.Sprintf("UPDATE %s SET %s = %s WHERE id = ? RETURNING length(CAST(%s AS BLOB))",
+			table.TableName, req.ColumnName, expr, req.ColumnName)
+	})
+
+	ctx, cancel := db.StatementContext(ctx)
+	defer cancel()
+	var size int64
+	err := s.db.QueryRowContext(ctx, query, args...).Scan(&size)
+	var liteErr *sqlite.Error
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return 0, fmt.Errorf("%w: %d", ErrRecordNotFound, req.RecordID)
+	case errors.As(err, &liteErr) && strings.Contains(liteErr.Error(), "JSON"):
+		// Malformed stored values and paths through scalars
+		return 0, invalidField("operations", "%v", liteErr)
+	case err != nil:
+		return 0, fmt.Errorf("failed to update JSON value: %w", err)
+	}
+	return size, nil
+}
+
+// JSONValue reads the value at a path with the -> operator
+func (s *SQLiteStore) JSONValue(ctx context.Context, table *TableDefinition, req JSONValueRequest) ([]byte, error) {
+	path, err := sqliteJSONPath("path", req.Path)
+	if err != nil {
+		return nil, err
+	}
+	key := newStatementKey(table.TableName, "json_value", []string{req.ColumnName})
+	query := recordStatements.get(key, func() string {
+		return fmt.Sprintf("SELECT %s -> ? FROM %s WHERE id = ?", req.ColumnName, table.TableName)
+	})
+
+	ctx, cancel := db.StatementContext(ctx)
+	defer cancel()
+	var value []byte
+	err = s.db.QueryRowContext(ctx, query, path, req.RecordID).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %d", ErrRecordNotFound, req.RecordID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JSON value: %w", err)
+	}
+	if value == nil {
+		return []byte("null"), nil
+	}
+	return value, nil
+}
+
+// sqliteJSONPath converts path segments to a SQLite JSON path. Segments of
+// digits address array elements; others are quoted object keys, which
+// SQLite can't escape quotes in.
+func sqliteJSONPath(field string, path []string) (string, error) {
+	var sb strings.Builder
+	sb.WriteString("$")
+	for i, segment := range path {
+		switch {
+		case segment != "" && strings.Trim(segment, "0123456789") == "":
+			sb.WriteString("[" + segment + "]")
+		case strings.Contains(segment, `"`):
+			return "", invalidField(fmt.Sprintf("%s[%d]", field, i), "keys with double quotes are not supported on sqlite")
+		default:
+			sb.WriteString(`."` + segment + `"`)
+		}
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,206 @@
+package schema_manager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// JSONOp is a partial update of a JSON value
+type JSONOp string
+
+const (
+	JSONOpSet    JSONOp = "set"    // Set the value at the path, creating its last key
+	JSONOpRemove JSONOp = "remove" // Remove the value at the path
+)
+
+// Limits of JSON requests
+const (
+	MaxJSONOperations    = 100
+	MaxJSONPathDepth     = 32
+	DefaultJSONChunkSize = 256 << 10
+	MaxJSONChunkSize     = 2 << 20
+)
+
+// JSONPathOperation sets or removes the value at a path inside a JSON
+// column. The path lists object keys and array indexes from the root;
+// parents of a set path must exist.
+type JSONPathOperation struct {
+	Op    JSONOp          `json:"op"`
+	Path  []string        `json:"path"`
+	Value json.RawMessage `json:"value,omitempty"` // Set only
+}
+
+// PatchJSONRequest applies operations, in order, to the JSON column of a
+// record without sending the whole value
+type PatchJSONRequest struct {
+	TableID    int                 `json:"table_id"`
+	RecordID   int                 `json:"record_id"`
+	ColumnName string              `json:"column_name"` // Sanitized name of the JSON column
+	Operations []JSONPathOperation `json:"operations"`
+}
+
+// JSONValueRequest selects the JSON value streamed by StreamJSON: a
+// record's column, or the part of it at Path
+type JSONValueRequest struct {
+	TableID    int      `json:"table_id"`
+	RecordID   int      `json:"record_id"`
+	ColumnName string   `json:"column_name"`
+	Path       []string `json:"path,omitempty"`
+	ChunkSize  int      `json:"chunk_size,omitempty"` // Bytes per chunk; defaults to DefaultJSONChunkSize
+}
+
+// JSONChunk is a piece of a streamed JSON value
+type JSONChunk struct {
+	Data      []byte
+	Offset    int64 // Position of Data in the value
+	TotalSize int64 // Size of the whole value
+}
+
+// JSONStore is implemented by stores that can update and read JSON
+// columns in place
+type JSONStore interface {
+	// PatchJSON applies req's operations to a record's column and returns
+	// the size of the new value in bytes, or ErrRecordNotFound
+	PatchJSON(ctx context.Context, table *TableDefinition, req PatchJSONRequest) (int64, error)
+	// JSONValue returns the serialized value at req's path, "null" when
+	// the column or path holds nothing, or ErrRecordNotFound
+	JSONValue(ctx context.Context, table *TableDefinition, req JSONValueRequest) ([]byte, error)
+}
+
+// PatchJSON sets and removes values at paths inside a record's JSON column
+// and returns the size of the new value in bytes
+func (sm *SchemaManager) PatchJSON(ctx context.Context, req PatchJSONRequest) (int64, error) {
+	ctx, span := startSpan(ctx, "patch_json", attrTableID.Int(req.TableID))
+	size, err := sm.patchJSON(ctx, req)
+	endSpan(span, err)
+	return size, err
+}
+
+// patchJSON runs PatchJSON within its span
+func (sm *SchemaManager) patchJSON(ctx context.Context, req PatchJSONRequest) (int64, error) {
+	store, table, err := sm.jsonColumn(ctx, req.TableID, req.ColumnName)
+	if err != nil {
+		return 0, err
+	}
+	if err := checkColumnAccess(ctx, table, AccessWrite, req.ColumnName); err != nil {
+		return 0, err
+	}
+
+	if len(req.Operations) == 0 {
+		return 0, invalidField("operations", "at least one operation is required")
+	}
+	if len(req.Operations) > MaxJSONOperations {
+		return 0, invalidField("operations", "at most %d operations are allowed", MaxJSONOperations)
+	}
+	for i, op := range req.Operations {
+		field := fmt.Sprintf("operations[%d]", i)
+		if err := validateJSONPath(field+".path", op.Path); err != nil {
+			return 0, err
+		}
+		switch op.Op {
+		case JSONOpSet:
+			if !json.Valid(op.Value) {
+				return 0, invalidField(field+".value", "value must be valid JSON")
+			}
+		case JSONOpRemove:
+			if len(op.Path) == 0 {
+				return 0, invalidField(field+".path", "cannot remove the root; set it to null instead")
+			}
+			if len(op.Value) > 0 {
+				return 0, invalidField(field+".value", "remove takes no value")
+			}
+		default:
+			return 0, invalidField(field+".op", "invalid operation: %q (expected set or remove)", op.Op)
+		}
+	}
+
+	if err := sm.checkRecordWrite(ctx, table, 0); err != nil {
+		return 0, err
+	}
+	return store.PatchJSON(ctx, table, req)
+}
+
+// StreamJSON sends a record's JSON value, or the part of it at a path, to
+// emit in chunks, so large values needn't fit one message
+func (sm *SchemaManager) StreamJSON(ctx context.Context, req JSONValueRequest, emit func(JSONChunk) error) error {
+	ctx, span := startSpan(ctx, "stream_json", attrTableID.Int(req.TableID))
+	err := sm.streamJSON(ctx, req, emit)
+	endSpan(span, err)
+	return err
+}
+
+// streamJSON runs StreamJSON within its span
+func (sm *SchemaManager) streamJSON(ctx context.Context, req JSONValueRequest, emit func(JSONChunk) error) error {
+	store, table, err := sm.jsonColumn(ctx, req.TableID, req.ColumnName)
+	if err != nil {
+		return err
+	}
+	if err := checkColumnAccess(ctx, table, AccessRead, req.ColumnName); err != nil {
+		return err
+	}
+	if err := validateJSONPath("path", req.Path); err != nil {
+		return err
+	}
+	chunkSize := req.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = DefaultJSONChunkSize
+	}
+	if chunkSize > MaxJSONChunkSize {
+		return invalidField("chunk_size", "must be at most %d bytes", MaxJSONChunkSize)
+	}
+
+	value, err := store.JSONValue(ctx, table, req)
+	if err != nil {
+		return err
+	}
+	total := int64(len(value))
+	for offset := 0; offset < len(value); offset += chunkSize {
+		end := min(offset+chunkSize, len(value))
+		if err := emit(JSONChunk{Data: value[offset:end], Offset: int64(offset), TotalSize: total}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// jsonColumn resolves a table and checks it has the JSON column
+func (sm *SchemaManager) jsonColumn(ctx context.Context, tableID int, columnName string) (JSONStore, *TableDefinition, error) {
+	if sm.store == nil {
+		return nil, nil, ErrDatabaseNotConfigured
+	}
+	store, ok := sm.store.(JSONStore)
+	if !ok {
+		return nil, nil, fmt.Errorf("JSON updates are not supported on %s", sm.store.Dialect().Name())
+	}
+
+	table, err := sm.GetTable(ctx, tableID)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, col := range table.Columns {
+		if col.ColumnName == columnName {
+			if col.DataType != DataTypeJSON {
+				return nil, nil, invalidField("column_name", "column '%s' is a %s column, not json", columnName, col.DataType)
+			}
+			return store, table, nil
+		}
+	}
+	if IsSystemColumn(columnName) {
+		return nil, nil, invalidField("column_name", "column '%s' is managed by the system, not a json column", columnName)
+	}
+	return nil, nil, invalidField("column_name", "table '%s' has no column '%s'", table.Name, columnName)
+}
+
+// validateJSONPath checks the segments of a path
+func validateJSONPath(field string, path []string) error {
+	if len(path) > MaxJSONPathDepth {
+		return invalidField(field, "paths are at most %d segments deep", MaxJSONPathDepth)
+	}
+	for i, segment := range path {
+		if err := checkLiteralText(segment); err != nil {
+			return invalidField(fmt.Sprintf("%s[%d]", field, i), "%v", err)
+		}
+	}
+	return nil
+}
@@ -3,425 +3,373 @@ package schema_manager
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 
-	"github.com/jackc/pgx/v5"
+	"agentic-template/api/db"
+	"agentic-template/api/requestctx"
+
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 // SchemaManager handles dynamic schema creation and management
 type SchemaManager struct {
-	pool *pgxpool.Pool
+	store SchemaStore // nil when no database is configured
 }
 
-// NewSchemaManager creates a new SchemaManager instance
+// NewSchemaManager creates a SchemaManager on PostgreSQL. A nil pool
+// creates a manager that returns ErrDatabaseNotConfigured.
 func NewSchemaManager(pool *pgxpool.Pool) *SchemaManager {
-	return &SchemaManager{
-		pool: pool,
+	if pool == nil {
+		return &SchemaManager{}
+	}
+	return NewSchemaManagerWithStore(NewPostgresStore(pool))
+}
+
+// NewSchemaManagerWithStore creates a SchemaManager on another store
+func NewSchemaManagerWithStore(store SchemaStore) *SchemaManager {
+	return &SchemaManager{store: store}
+}
+
+// FromManager creates a SchemaManager on the current database of a
+// manager: the local file database when one is set, otherwise PostgreSQL
+// with reads routed to a replica
+func FromManager(dbManager *db.Manager) *SchemaManager {
+	if local := dbManager.Local(); local != nil {
+		return NewSchemaManagerWithStore(NewSQLiteStore(local))
+	}
+	return NewSchemaManager(dbManager.GetWritePool()).WithReadPool(dbManager.GetReadPool())
+}
+
+// WithReadPool routes table and record reads to a read replica pool while
+// DDL and writes stay on the primary. Stores other than PostgreSQL ignore it.
+func (sm *SchemaManager) WithReadPool(readPool *pgxpool.Pool) *SchemaManager {
+	if store, ok := sm.store.(*PostgresStore); ok {
+		store.readPool = readPool
 	}
+	return sm
+}
+
+// Dialect returns the dialect of the manager's store, PostgreSQL when none
+// is configured
+func (sm *SchemaManager) Dialect() DDLDialect {
+	if sm.store == nil {
+		return PostgresDialect{}
+	}
+	return sm.store.Dialect()
+}
+
+// CreateTable creates a new user-defined table based on metadata. The table
+// is recorded as created by the actor of ctx (see requestctx.Actor). Tables
+// over the quotas are rejected unless ctx carries WithQuotaOverride.
+func (sm *SchemaManager) CreateTable(ctx context.Context, req CreateTableRequest) (*TableDefinition, error) {
+	ctx, span := startSpan(ctx, "create_table", attrTableName.String(req.Name))
+	table, err := sm.createTable(ctx, req)
+	endSpan(span, err)
+	return table, err
 }
 
-// CreateTable creates a new user-defined table based on metadata
-func (sm *SchemaManager) CreateTable(ctx context.Context, req CreateTableRequest, createdBy string) (*TableDefinition, error) {
-	if sm.pool == nil {
-		return nil, fmt.Errorf("database not configured - please add DATABASE_URL_POOLED in Environment Settings")
+// createTable runs CreateTable within its span
+func (sm *SchemaManager) createTable(ctx context.Context, req CreateTableRequest) (*TableDefinition, error) {
+	if sm.store == nil {
+		return nil, ErrDatabaseNotConfigured
 	}
+	createdBy := requestctx.Actor(ctx)
 
 	// 1. Validate the request
 	if err := sm.validateCreateTableRequest(req); err != nil {
 		return nil, fmt.Errorf("validation failed: %w", err)
 	}
+	if err := sm.checkTableQuotas(ctx, len(req.Columns)); err != nil {
+		return nil, err
+	}
 
 	// 2. Sanitize table name
-	sanitizedTableName, err := SanitizeTableName(req.Name)
+	naming := CurrentNamingStrategy()
+	sanitizedTableName, err := naming.TableName(req.Name)
 	if err != nil {
-		return nil, fmt.Errorf("failed to sanitize table name: %w", err)
+		return nil, invalidField("name", "failed to sanitize table name: %v", err)
 	}
 
 	// 3. Check if table already exists in metadata
-	exists, err := sm.tableExists(ctx, sanitizedTableName)
+	exists, err := sm.store.TableExists(ctx, sanitizedTableName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to check table existence: %w", err)
 	}
 	if exists {
-		return nil, fmt.Errorf("table with name '%s' already exists", req.Name)
+		return nil, fmt.Errorf("%w: '%s'", ErrTableExists, req.Name)
 	}
 
-	// 4. Start a transaction
-	tx, err := sm.pool.Begin(ctx)
+	// 4. Run the DDL and metadata inserts in a transaction, retried on
+	// serialization failures
+	var tableID int
+	var columns []ColumnDefinition
+	err = sm.store.Tx(ctx, func(tx StoreTx) error {
+		var err error
+		tableID, columns, err = sm.insertTable(ctx, tx, req, sanitizedTableName, naming, createdBy)
+		return err
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+		return nil, err
 	}
-	defer tx.Rollback(ctx)
 
-	// 5. Insert into configurable_tables
-	var tableID int
-	insertTableQuery := `
-		INSERT INTO configurable_tables (name, table_name, description)
-		VALUES ($1, $2, $3)
-		RETURNING id
-	`
-	err = tx.QueryRow(ctx, insertTableQuery, req.Name, sanitizedTableName, req.Description).Scan(&tableID)
+	// 5. Return the created table definition
+	tableDef := &TableDefinition{
+		ID:          tableID,
+		Name:        req.Name,
+		TableName:   sanitizedTableName,
+		Description: req.Description,
+		Columns:     columns,
+		CreatedBy:   &createdBy,
+		Naming:      &naming,
+	}
+
+	return tableDef, nil
+}
+
+// insertTable registers a validated table under its sanitized name, creates
+// it with columns named by the naming strategy, and logs the change,
+// returning its ID and columns
+func (sm *SchemaManager) insertTable(ctx context.Context, tx StoreTx, req CreateTableRequest, tableName string, naming NamingStrategy, createdBy string) (int, []ColumnDefinition, error) {
+	dialect := sm.store.Dialect()
+
+	// 1. Insert into configurable_tables
+	tableID, err := tx.InsertTable(ctx, TableDefinition{
+		Name:        req.Name,
+		TableName:   tableName,
+		Description: req.Description,
+		CreatedBy:   &createdBy,
+		Naming:      &naming,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to insert table metadata: %w", err)
+		return 0, nil, fmt.Errorf("failed to insert table metadata: %w", err)
 	}
 
-	// 6. Process and insert columns
+	// 2. Process and insert columns
 	columns := make([]ColumnDefinition, 0, len(req.Columns))
 	for i, col := range req.Columns {
 		// Sanitize column name
-		sanitizedColName, err := SanitizeIdentifier(col.Name)
+		sanitizedColName, err := naming.Identifier(col.Name)
 		if err != nil {
-			return nil, fmt.Errorf("failed to sanitize column name '%s': %w", col.Name, err)
+			return 0, nil, invalidField(columnField(i, "name"), "failed to sanitize column name '%s': %v", col.Name, err)
 		}
 
-		// Map data type
-		pgType, err := MapToPostgresType(col.DataType)
-		if err != nil {
-			return nil, fmt.Errorf("failed to map data type for column '%s': %w", col.Name, err)
-		}
-
-		// Insert column metadata
-		insertColQuery := `
-			INSERT INTO configurable_columns
-			(table_id, name, column_name, data_type, postgres_type, is_nullable, is_unique, default_value, foreign_key_to_table_id, display_order)
-			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
-			RETURNING id
-		`
-		var colID int
-		err = tx.QueryRow(ctx, insertColQuery,
-			tableID,
-			col.Name,
-			sanitizedColName,
-			col.DataType,
-			pgType,
-			col.IsNullable,
-			col.IsUnique,
-			col.DefaultValue,
-			col.ForeignKeyToTableID,
-			i, // display_order
-		).Scan(&colID)
-
-		if err != nil {
-			return nil, fmt.Errorf("failed to insert column metadata for '%s': %w", col.Name, err)
+		// Map data type; formula columns aren't stored, so they have none
+		columnType := ""
+		if col.DataType != DataTypeFormula {
+			columnType, err = dialect.ColumnType(col)
+			if err != nil {
+				return 0, nil, invalidField(columnField(i, "data_type"), "failed to map data type for column '%s': %v", col.Name, err)
+			}
 		}
 
-		columns = append(columns, ColumnDefinition{
-			ID:                  colID,
+		column := ColumnDefinition{
 			Name:                col.Name,
 			ColumnName:          sanitizedColName,
 			DataType:            col.DataType,
-			PostgresType:        pgType,
-			IsNullable:          col.IsNullable,
+			PostgresType:        columnType,
+			IsNullable:          col.IsNullable || col.DataType == DataTypeFormula,
 			IsUnique:            col.IsUnique,
 			DefaultValue:        col.DefaultValue,
 			ForeignKeyToTableID: col.ForeignKeyToTableID,
 			DisplayOrder:        i,
-		})
+			VectorDimensions:    col.VectorDimensions,
+			VectorIndexType:     col.VectorIndexType,
+			Format:              col.Format,
+			Formula:             col.Formula,
+			Permissions:         col.Permissions,
+			Description:         col.Description,
+		}
+
+		// Insert column metadata
+		column.ID, err = tx.InsertColumn(ctx, tableID, column)
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to insert column metadata for '%s': %w", col.Name, err)
+		}
+		columns = append(columns, column)
 	}
 
-	// 7. Build and execute CREATE TABLE SQL
-	createTableSQL, err := sm.buildCreateTableSQL(sanitizedTableName, columns)
+	// 3. Build and execute CREATE TABLE SQL
+	createTableSQL, err := buildCreateTableSQL(ctx, tx, dialect, tableName, columns)
 	if err != nil {
-		return nil, fmt.Errorf("failed to build CREATE TABLE SQL: %w", err)
+		return 0, nil, fmt.Errorf("failed to build CREATE TABLE SQL: %w", err)
 	}
 
-	_, err = tx.Exec(ctx, createTableSQL)
+	err = tx.ExecDDL(ctx, createTableSQL)
 	if err != nil {
 		// Log the failed SQL for debugging
-		sm.logSchemaChange(ctx, tx, tableID, "CREATE_TABLE", req, &createTableSQL, "FAILED", err.Error(), createdBy)
-		return nil, fmt.Errorf("failed to execute CREATE TABLE: %w", err)
+		logSchemaChange(ctx, tx, tableID, "CREATE_TABLE", req, &createTableSQL, "FAILED", err.Error())
+		return 0, nil, fmt.Errorf("failed to execute CREATE TABLE: %w", err)
 	}
 
-	// 8. Log the successful schema change
-	if err := sm.logSchemaChange(ctx, tx, tableID, "CREATE_TABLE", req, &createTableSQL, "SUCCESS", "", createdBy); err != nil {
+	// 4. Log the successful schema change
+	if err := logSchemaChange(ctx, tx, tableID, "CREATE_TABLE", req, &createTableSQL, "SUCCESS", ""); err != nil {
 		// Don't fail the transaction, just log the error
 		fmt.Printf("Warning: failed to log schema change: %v\n", err)
 	}
 
-	// 9. Commit the transaction
-	if err := tx.Commit(ctx); err != nil {
-		return nil, fmt.Errorf("failed to commit transaction: %w", err)
-	}
-
-	// 10. Return the created table definition
-	tableDef := &TableDefinition{
-		ID:          tableID,
-		Name:        req.Name,
-		TableName:   sanitizedTableName,
-		Description: req.Description,
-		Columns:     columns,
-	}
-
-	return tableDef, nil
+	return tableID, columns, nil
 }
 
-// buildCreateTableSQL constructs a safe CREATE TABLE statement
-func (sm *SchemaManager) buildCreateTableSQL(tableName string, columns []ColumnDefinition) (string, error) {
-	var sb strings.Builder
-
-	// Start the CREATE TABLE statement
-	sb.WriteString(fmt.Sprintf("CREATE TABLE %s (\n", tableName))
-
-	// Always add an auto-incrementing primary key
-	sb.WriteString("  id SERIAL PRIMARY KEY,\n")
-
-	// Add each column
-	for i, col := range columns {
-		// Validate one more time
-		if err := ValidateIdentifierSafety(col.ColumnName); err != nil {
-			return "", fmt.Errorf("column name '%s' failed safety check: %w", col.ColumnName, err)
-		}
-
-		// Column name and type
-		sb.WriteString(fmt.Sprintf("  %s %s", col.ColumnName, col.PostgresType))
-
-		// NULL constraint
-		if !col.IsNullable {
-			sb.WriteString(" NOT NULL")
-		}
-
-		// UNIQUE constraint
-		if col.IsUnique {
-			sb.WriteString(" UNIQUE")
+// buildCreateTableSQL constructs a safe CREATE TABLE statement, looking up
+// the tables referenced by relation columns
+func buildCreateTableSQL(ctx context.Context, tx StoreTx, dialect DDLDialect, tableName string, columns []ColumnDefinition) (string, error) {
+	return createTableSQL(dialect, tableName, columns, false, func(i int, col ColumnDefinition) (string, error) {
+		foreignTableName, err := tx.TableName(ctx, *col.ForeignKeyToTableID)
+		if errors.Is(err, ErrTableNotFound) {
+			return "", invalidField(columnField(i, "foreign_key_to_table_id"), "table %d does not exist", *col.ForeignKeyToTableID)
 		}
-
-		// DEFAULT value
-		if col.DefaultValue != nil {
-			defaultSQL, err := GetDefaultValueSQL(col.DataType, col.DefaultValue)
-			if err != nil {
-				return "", fmt.Errorf("invalid default value for column '%s': %w", col.Name, err)
-			}
-			sb.WriteString(fmt.Sprintf(" DEFAULT %s", defaultSQL))
-		}
-
-		// Foreign key constraint (handled separately below)
-		if col.ForeignKeyToTableID != nil {
-			// We'll add REFERENCES after we query the foreign table name
-			// For now, just note it
+		if err != nil {
+			return "", fmt.Errorf("failed to get foreign table name for column '%s': %w", col.Name, err)
 		}
+		return foreignTableName, nil
+	})
+}
 
-		// Add comma if not the last column
-		if i < len(columns)-1 {
-			sb.WriteString(",\n")
-		}
+// GetTable retrieves a table definition by ID, listing the system columns
+// of its records
+func (sm *SchemaManager) GetTable(ctx context.Context, tableID int) (*TableDefinition, error) {
+	if sm.store == nil {
+		return nil, ErrDatabaseNotConfigured
 	}
-
-	// Add foreign key constraints
-	foreignKeys := []string{}
-	for _, col := range columns {
-		if col.ForeignKeyToTableID != nil {
-			// Get the foreign table name
-			var foreignTableName string
-			query := "SELECT table_name FROM configurable_tables WHERE id = $1"
-			err := sm.pool.QueryRow(context.Background(), query, *col.ForeignKeyToTableID).Scan(&foreignTableName)
-			if err != nil {
-				return "", fmt.Errorf("failed to get foreign table name for column '%s': %w", col.Name, err)
-			}
-
-			fkConstraint := fmt.Sprintf(
-				"  CONSTRAINT fk_%s_%s FOREIGN KEY (%s) REFERENCES %s(id) ON DELETE SET NULL",
-				tableName, col.ColumnName, col.ColumnName, foreignTableName,
-			)
-			foreignKeys = append(foreignKeys, fkConstraint)
-		}
+	table, err := sm.store.GetTable(ctx, tableID)
+	if err != nil {
+		return nil, err
 	}
+	table.SystemColumns = SystemColumns()
+	return table, nil
+}
 
-	if len(foreignKeys) > 0 {
-		sb.WriteString(",\n")
-		sb.WriteString(strings.Join(foreignKeys, ",\n"))
+// ListTables returns a page of user-defined tables, optionally filtered by
+// name prefix. Pass the returned NextPageToken to get the following page.
+func (sm *SchemaManager) ListTables(ctx context.Context, opts ListTablesOptions) (*ListTablesPage, error) {
+	if sm.store == nil {
+		return nil, ErrDatabaseNotConfigured
 	}
 
-	// Add audit columns
-	sb.WriteString(",\n")
-	sb.WriteString("  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),\n")
-	sb.WriteString("  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()\n")
-
-	// Close the CREATE TABLE statement
-	sb.WriteString(");")
-
-	// Add trigger for updated_at
-	sb.WriteString(fmt.Sprintf(`
-
-CREATE TRIGGER update_%s_updated_at
-    BEFORE UPDATE ON %s
-    FOR EACH ROW
-    EXECUTE FUNCTION update_updated_at_column();
-`, tableName, tableName))
-
-	return sb.String(), nil
-}
-
-// GetTable retrieves a table definition by ID
-func (sm *SchemaManager) GetTable(ctx context.Context, tableID int) (*TableDefinition, error) {
-	if sm.pool == nil {
-		return nil, fmt.Errorf("database not configured - please add DATABASE_URL_POOLED in Environment Settings")
-	}
-
-	// Query the table metadata
-	var tableDef TableDefinition
-	query := `
-		SELECT id, name, table_name, description, created_at, updated_at
-		FROM configurable_tables
-		WHERE id = $1
-	`
-	err := sm.pool.QueryRow(ctx, query, tableID).Scan(
-		&tableDef.ID,
-		&tableDef.Name,
-		&tableDef.TableName,
-		&tableDef.Description,
-		&tableDef.CreatedAt,
-		&tableDef.UpdatedAt,
-	)
-	if err != nil {
-		if err == pgx.ErrNoRows {
-			return nil, fmt.Errorf("table not found")
-		}
-		return nil, fmt.Errorf("failed to query table: %w", err)
-	}
-
-	// Query the columns
-	columnsQuery := `
-		SELECT id, name, column_name, data_type, postgres_type, is_nullable, is_unique,
-		       default_value, foreign_key_to_table_id, display_order
-		FROM configurable_columns
-		WHERE table_id = $1
-		ORDER BY display_order
-	`
-	rows, err := sm.pool.Query(ctx, columnsQuery, tableID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query columns: %w", err)
-	}
-	defer rows.Close()
-
-	columns := []ColumnDefinition{}
-	for rows.Next() {
-		var col ColumnDefinition
-		err := rows.Scan(
-			&col.ID,
-			&col.Name,
-			&col.ColumnName,
-			&col.DataType,
-			&col.PostgresType,
-			&col.IsNullable,
-			&col.IsUnique,
-			&col.DefaultValue,
-			&col.ForeignKeyToTableID,
-			&col.DisplayOrder,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan column: %w", err)
-		}
-		columns = append(columns, col)
+	pageSize := opts.PageSize
+	if pageSize <= 0 {
+		pageSize = DefaultTablePageSize
+	}
+	if pageSize > MaxTablePageSize {
+		pageSize = MaxTablePageSize
 	}
 
-	tableDef.Columns = columns
-	return &tableDef, nil
-}
+	sort := opts.Sort
+	if sort == "" {
+		sort = SortCreatedDesc
+	}
+	if _, ok := tableSortClauses[sort]; !ok {
+		return nil, invalidField("sort", "unknown sort order: %s", sort)
+	}
 
-// ListTables returns all user-defined tables
-func (sm *SchemaManager) ListTables(ctx context.Context) ([]TableDefinition, error) {
-	if sm.pool == nil {
-		return nil, fmt.Errorf("database not configured - please add DATABASE_URL_POOLED in Environment Settings")
+	offset, err := decodePageToken(opts.PageToken, opts.NamePrefix, sort)
+	if err != nil {
+		return nil, err
 	}
 
-	query := `
-		SELECT id, name, table_name, description, created_at, updated_at
-		FROM configurable_tables
-		ORDER BY created_at DESC
-	`
-	rows, err := sm.pool.Query(ctx, query)
+	// Fetch one extra row to learn whether another page follows
+	tables, total, err := sm.store.ListTables(ctx, TableQuery{
+		NamePrefix: opts.NamePrefix,
+		Sort:       sort,
+		Limit:      pageSize + 1,
+		Offset:     offset,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to query tables: %w", err)
-	}
-	defer rows.Close()
-
-	tables := []TableDefinition{}
-	for rows.Next() {
-		var table TableDefinition
-		err := rows.Scan(
-			&table.ID,
-			&table.Name,
-			&table.TableName,
-			&table.Description,
-			&table.CreatedAt,
-			&table.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan table: %w", err)
-		}
-		tables = append(tables, table)
+		return nil, err
 	}
 
-	return tables, nil
-}
+	page := &ListTablesPage{Tables: tables, TotalSize: total}
+	if len(page.Tables) > pageSize {
+		page.Tables = page.Tables[:pageSize]
+		page.NextPageToken = encodePageToken(offset+pageSize, opts.NamePrefix, sort)
+	}
 
-// tableExists checks if a table with the given name already exists
-func (sm *SchemaManager) tableExists(ctx context.Context, tableName string) (bool, error) {
-	var exists bool
-	query := `SELECT EXISTS(SELECT 1 FROM configurable_tables WHERE table_name = $1)`
-	err := sm.pool.QueryRow(ctx, query, tableName).Scan(&exists)
-	return exists, err
+	return page, nil
 }
 
-// logSchemaChange records a schema change in the audit log
-func (sm *SchemaManager) logSchemaChange(ctx context.Context, tx pgx.Tx, tableID int, changeType string, details interface{}, sql *string, status, errorMsg, createdBy string) error {
+// logSchemaChange records a schema change by the actor of ctx in the audit
+// log
+func logSchemaChange(ctx context.Context, tx StoreTx, tableID int, changeType string, details interface{}, sql *string, status, errorMsg string) error {
 	detailsJSON, err := json.Marshal(details)
 	if err != nil {
 		return fmt.Errorf("failed to marshal details: %w", err)
 	}
 
-	query := `
-		INSERT INTO schema_change_log (table_id, change_type, change_details, executed_sql, status, error_message, created_by)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-	`
-
+	actor := requestctx.Actor(ctx)
 	var errMsgPtr *string
 	if errorMsg != "" {
 		errMsgPtr = &errorMsg
 	}
 
-	_, err = tx.Exec(ctx, query, tableID, changeType, string(detailsJSON), sql, status, errMsgPtr, createdBy)
-	return err
+	return tx.LogChange(ctx, SchemaChange{
+		TableID:       &tableID,
+		ChangeType:    changeType,
+		ChangeDetails: string(detailsJSON),
+		ExecutedSQL:   sql,
+		Status:        status,
+		ErrorMessage:  errMsgPtr,
+		CreatedBy:     &actor,
+	})
 }
 
 // validateCreateTableRequest validates the table creation request
 func (sm *SchemaManager) validateCreateTableRequest(req CreateTableRequest) error {
 	if req.Name == "" {
-		return fmt.Errorf("table name is required")
+		return invalidField("name", "table name is required")
 	}
 
 	if len(req.Columns) == 0 {
-		return fmt.Errorf("at least one column is required")
+		return invalidField("columns", "at least one column is required")
 	}
 
 	// Check for duplicate column names
 	columnNames := make(map[string]bool)
-	for _, col := range req.Columns {
+	for i, col := range req.Columns {
 		if col.Name == "" {
-			return fmt.Errorf("column name is required")
+			return invalidField(columnField(i, "name"), "column name is required")
 		}
 
 		// Validate data type
 		if err := ValidateDataType(col.DataType); err != nil {
-			return fmt.Errorf("invalid data type for column '%s': %w", col.Name, err)
+			return invalidField(columnField(i, "data_type"), "invalid data type for column '%s': %v", col.Name, err)
+		}
+
+		// Validate vector options and anything else the dialect can't store
+		if err := sm.Dialect().ValidateColumn(col); err != nil {
+			return invalidField(columnField(i, "vector_dimensions"), "invalid vector options for column '%s': %v", col.Name, err)
+		}
+
+		// Validate presentation settings
+		if field, err := ValidateColumnFormat(col); err != nil {
+			return invalidField(columnField(i, "format."+field), "invalid format for column '%s': %v", col.Name, err)
+		}
+
+		// Validate the roles denied access
+		if err := validateColumnPermissions(i, col.Permissions); err != nil {
+			return err
 		}
 
 		// Check for duplicates
 		lowerName := strings.ToLower(col.Name)
 		if columnNames[lowerName] {
-			return fmt.Errorf("duplicate column name: %s", col.Name)
+			return invalidField(columnField(i, "name"), "duplicate column name: %s", col.Name)
 		}
 		columnNames[lowerName] = true
 
+		// Check it isn't a system-managed column
+		if name, err := SanitizeIdentifier(col.Name); err == nil && IsSystemColumn(name) {
+			return invalidField(columnField(i, "name"), "column name '%s' is reserved for the system-managed %s column", col.Name, name)
+		}
+
 		// Validate foreign keys
 		if col.DataType == DataTypeRelation {
 			if col.ForeignKeyToTableID == nil {
-				return fmt.Errorf("column '%s' is a relation but foreign_key_to_table_id is not set", col.Name)
+				return invalidField(columnField(i, "foreign_key_to_table_id"), "column '%s' is a relation but foreign_key_to_table_id is not set", col.Name)
 			}
 		}
 	}
 
-	return nil
+	return validateFormulaColumns(req.Columns)
 }
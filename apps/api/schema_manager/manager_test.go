@@ -0,0 +1,122 @@
+package schema_manager_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"agentic-template/api/requestctx"
+	"agentic-template/api/schema_manager"
+	"agentic-template/api/testsupport"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func TestMain(m *testing.M) { testsupport.Main(m) }
+
+func TestCreateTableRecordRoundTrip(t *testing.T) {
+	sm := testsupport.SchemaManager(t)
+	pool := testsupport.Pool(t)
+	ctx := requestctx.WithActor(context.Background(), testsupport.FixtureUser)
+
+	created := testsupport.CreateTable(t, sm, "Customers",
+		testsupport.Column("Name", schema_manager.DataTypeText),
+		testsupport.Column("Visits", schema_manager.DataTypeNumber),
+		testsupport.Column("Active", schema_manager.DataTypeBoolean),
+	)
+	if created.TableName != "user_table_customers" {
+		t.Errorf("TableName = %q, want user_table_customers", created.TableName)
+	}
+
+	table, err := sm.GetTable(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("GetTable() error = %v", err)
+	}
+	var columns []string
+	for _, col := range table.Columns {
+		columns = append(columns, col.ColumnName)
+	}
+	if want := []string{"name", "visits", "active"}; !slices.Equal(columns, want) {
+		t.Errorf("columns = %v, want %v", columns, want)
+	}
+	if table.CreatedBy == nil || *table.CreatedBy != testsupport.FixtureUser {
+		t.Errorf("CreatedBy = %v, want %s", table.CreatedBy, testsupport.FixtureUser)
+	}
+	if len(table.SystemColumns) == 0 {
+		t.Error("SystemColumns is empty")
+	}
+
+	id := testsupport.InsertRecord(t, pool, table, map[string]any{"name": "Ada", "visits": 3, "active": true})
+	testsupport.InsertRecord(t, pool, table, map[string]any{"name": "Grace"})
+	if count := testsupport.CountRecords(t, pool, table); count != 2 {
+		t.Errorf("CountRecords() = %d, want 2", count)
+	}
+
+	var name string
+	var visits int
+	var active bool
+	query := "SELECT name, visits, active FROM " + pgx.Identifier{table.TableName}.Sanitize() + " WHERE id = $1"
+	if err := pool.QueryRow(ctx, query, id).Scan(&name, &visits, &active); err != nil {
+		t.Fatalf("failed to read record %d: %v", id, err)
+	}
+	if name != "Ada" || visits != 3 || !active {
+		t.Errorf("record = %q, %d, %t, want Ada, 3, true", name, visits, active)
+	}
+
+	if err := sm.DeleteTable(ctx, table.ID); err != nil {
+		t.Fatalf("DeleteTable() error = %v", err)
+	}
+	if _, err := sm.GetTable(ctx, table.ID); !errors.Is(err, schema_manager.ErrTableNotFound) {
+		t.Errorf("GetTable() after delete error = %v, want ErrTableNotFound", err)
+	}
+}
+
+func TestCreateTableRejectsSystemColumns(t *testing.T) {
+	sm := testsupport.SchemaManager(t)
+	ctx := requestctx.WithActor(context.Background(), testsupport.FixtureUser)
+
+	_, err := sm.CreateTable(ctx, schema_manager.CreateTableRequest{
+		Name:    "Orders",
+		Columns: []schema_manager.ColumnDefinition{testsupport.Column("Created At", schema_manager.DataTypeDate)},
+	})
+	var validationErr *schema_manager.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("CreateTable() error = %v, want a ValidationError", err)
+	}
+}
+
+func TestRecordQuota(t *testing.T) {
+	sm := testsupport.SchemaManager(t)
+	pool := testsupport.Pool(t)
+	ctx := requestctx.WithActor(context.Background(), testsupport.FixtureUser)
+	schema_manager.SetQuotas(schema_manager.Quotas{MaxRowsPerTable: 2})
+	t.Cleanup(func() { schema_manager.SetQuotas(schema_manager.Quotas{}) })
+
+	table := testsupport.CreateTable(t, sm, "Events", testsupport.Column("Payload", schema_manager.DataTypeJSON))
+	id := testsupport.InsertRecord(t, pool, table, map[string]any{"payload": `{"n": 1}`})
+	testsupport.InsertRecord(t, pool, table, map[string]any{"payload": `{"n": 2}`})
+
+	var quotaErr *schema_manager.QuotaError
+	if err := sm.CheckRecordQuota(ctx, table.ID, 1); !errors.As(err, &quotaErr) || quotaErr.Current != 2 {
+		t.Errorf("CheckRecordQuota() at the limit error = %v, want a QuotaError with 2 records", err)
+	}
+	patch := schema_manager.PatchJSONRequest{
+		TableID:    table.ID,
+		RecordID:   id,
+		ColumnName: "payload",
+		Operations: []schema_manager.JSONPathOperation{{Op: schema_manager.JSONOpSet, Path: []string{"n"}, Value: []byte("3")}},
+	}
+	if _, err := sm.PatchJSON(ctx, patch); err != nil {
+		t.Errorf("PatchJSON() at the limit error = %v", err)
+	}
+
+	// Rows written outside the API put the table over a lowered limit
+	testsupport.InsertRecord(t, pool, table, map[string]any{"payload": `{"n": 4}`})
+	if _, err := sm.PatchJSON(ctx, patch); !errors.Is(err, schema_manager.ErrQuotaExceeded) {
+		t.Errorf("PatchJSON() over the limit error = %v, want ErrQuotaExceeded", err)
+	}
+	if _, err := sm.PatchJSON(schema_manager.WithQuotaOverride(ctx), patch); err != nil {
+		t.Errorf("PatchJSON() with an override error = %v", err)
+	}
+}
@@ -0,0 +1,127 @@
+package schema_manager
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync/atomic"
+)
+
+// MaxIdentifierLength is PostgreSQL's limit on identifier length; longer
+// names are silently cut, so sanitized names never exceed it
+const MaxIdentifierLength = 63
+
+// Case normalizations of identifiers
+const (
+	NamingCaseLower = "lower" // "FirstName" becomes firstname
+	NamingCaseSnake = "snake" // "FirstName" becomes first_name
+)
+
+// Truncation strategies of identifiers longer than the maximum length
+const (
+	NamingTruncationCut  = "cut"  // Cut at the maximum length
+	NamingTruncationHash = "hash" // Cut and end with a hash of the whole name, so long names stay distinct
+)
+
+// Naming limits
+const (
+	minNameLength = 16 // Shortest maximum length, and what a table prefix must leave for the name
+	hashSuffixLen = 9  // "_" and 8 hex digits
+)
+
+// tablePrefixPattern is the shape of table prefixes: written unquoted
+// before sanitized names, so lowercase letters, digits, and underscores
+var tablePrefixPattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// NamingStrategy derives the database names of user tables and columns
+// from their display names. Tables keep the strategy they were created
+// with; changing it only affects tables created later.
+type NamingStrategy struct {
+	TablePrefix string `json:"table_prefix"` // Starts every table name, separating user tables from metadata tables
+	Case        string `json:"case"`         // A NamingCase normalization
+	Truncation  string `json:"truncation"`   // A NamingTruncation strategy for longer names
+	MaxLength   int    `json:"max_length"`   // Longest name, at most MaxIdentifierLength
+}
+
+// DefaultNamingStrategy is the strategy tables were named with before it
+// was configurable
+func DefaultNamingStrategy() NamingStrategy {
+	return NamingStrategy{
+		TablePrefix: "user_table_",
+		Case:        NamingCaseLower,
+		Truncation:  NamingTruncationCut,
+		MaxLength:   MaxIdentifierLength,
+	}
+}
+
+// Validate checks that the strategy only yields safe, distinct names: the
+// prefix can't be mistaken for the trash's or PostgreSQL's and leaves room
+// for the name
+func (n NamingStrategy) Validate() error {
+	switch {
+	case !tablePrefixPattern.MatchString(n.TablePrefix):
+		return fmt.Errorf("table prefix %q must start with a lowercase letter followed by lowercase letters, digits, or underscores", n.TablePrefix)
+	case strings.HasPrefix(n.TablePrefix, TrashPrefix) || strings.HasPrefix(TrashPrefix, n.TablePrefix):
+		return fmt.Errorf("table prefix %q overlaps the trash prefix %q", n.TablePrefix, TrashPrefix)
+	case strings.HasPrefix(n.TablePrefix, "pg_"):
+		return fmt.Errorf("table prefix %q is reserved by PostgreSQL", n.TablePrefix)
+	case n.Case != NamingCaseLower && n.Case != NamingCaseSnake:
+		return fmt.Errorf("case %q must be %s or %s", n.Case, NamingCaseLower, NamingCaseSnake)
+	case n.Truncation != NamingTruncationCut && n.Truncation != NamingTruncationHash:
+		return fmt.Errorf("truncation %q must be %s or %s", n.Truncation, NamingTruncationCut, NamingTruncationHash)
+	case n.MaxLength < minNameLength || n.MaxLength > MaxIdentifierLength:
+		return fmt.Errorf("max length must be between %d and %d, got %d", minNameLength, MaxIdentifierLength, n.MaxLength)
+	case len(n.TablePrefix) > n.MaxLength-minNameLength:
+		return fmt.Errorf("table prefix %q must leave at least %d of the %d characters for the name", n.TablePrefix, minNameLength, n.MaxLength)
+	}
+	return nil
+}
+
+var namingStrategy atomic.Pointer[NamingStrategy]
+
+func init() {
+	strategy := DefaultNamingStrategy()
+	namingStrategy.Store(&strategy)
+}
+
+// SetNamingStrategy sets the strategy naming the tables and columns
+// created from now on, in every tenant
+func SetNamingStrategy(strategy NamingStrategy) error {
+	if err := strategy.Validate(); err != nil {
+		return err
+	}
+	namingStrategy.Store(&strategy)
+	return nil
+}
+
+// CurrentNamingStrategy returns the strategy naming new tables and columns
+func CurrentNamingStrategy() NamingStrategy {
+	return *namingStrategy.Load()
+}
+
+// decodeNaming reads the naming column of configurable_tables. Tables
+// without one were created with the default strategy.
+func decodeNaming(raw []byte) (*NamingStrategy, error) {
+	strategy := DefaultNamingStrategy()
+	if raw == nil {
+		return &strategy, nil
+	}
+	if err := json.Unmarshal(raw, &strategy); err != nil {
+		return nil, fmt.Errorf("invalid naming strategy: %w", err)
+	}
+	return &strategy, nil
+}
+
+// encodeNaming writes the naming column of configurable_tables
+func encodeNaming(strategy *NamingStrategy) *string {
+	if strategy == nil {
+		return nil
+	}
+	data, err := json.Marshal(strategy)
+	if err != nil {
+		return nil
+	}
+	encoded := string(data)
+	return &encoded
+}
@@ -0,0 +1,89 @@
+package schema_manager
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Page sizes for ListTables
+const (
+	DefaultTablePageSize = 50
+	MaxTablePageSize     = 500
+)
+
+// TableSort orders the results of ListTables
+type TableSort string
+
+// Sort orders supported by ListTables
+const (
+	SortCreatedDesc TableSort = "created_at_desc" // Newest first (default)
+	SortCreatedAsc  TableSort = "created_at_asc"
+	SortUpdatedDesc TableSort = "updated_at_desc"
+	SortNameAsc     TableSort = "name_asc"
+	SortNameDesc    TableSort = "name_desc"
+)
+
+// tableSortClauses maps sort orders to ORDER BY clauses; id breaks ties so
+// pages are stable
+var tableSortClauses = map[TableSort]string{
+	SortCreatedDesc: "created_at DESC, id DESC",
+	SortCreatedAsc:  "created_at ASC, id ASC",
+	SortUpdatedDesc: "updated_at DESC, id DESC",
+	SortNameAsc:     "lower(name) ASC, id ASC",
+	SortNameDesc:    "lower(name) DESC, id DESC",
+}
+
+// ListTablesOptions filters, sorts, and pages ListTables
+type ListTablesOptions struct {
+	PageSize   int       // Defaults to DefaultTablePageSize, max MaxTablePageSize
+	PageToken  string    // NextPageToken of the previous page; empty for the first
+	NamePrefix string    // Case-insensitive prefix of the display name
+	Sort       TableSort // Defaults to SortCreatedDesc
+}
+
+// ListTablesPage is one page of tables
+type ListTablesPage struct {
+	Tables        []TableDefinition `json:"tables"`
+	NextPageToken string            `json:"next_page_token,omitempty"` // Empty on the last page
+	TotalSize     int               `json:"total_size"`                // Tables matching the filter across all pages
+}
+
+// encodePageToken creates an opaque token for the page starting at offset.
+// The filter and sort are included so a token can't be reused with others.
+func encodePageToken(offset int, namePrefix string, sort TableSort) string {
+	raw := fmt.Sprintf("%d|%s|%s", offset, sort, namePrefix)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodePageToken returns the offset of a page token; an empty token is the
+// first page
+func decodePageToken(token, namePrefix string, sort TableSort) (int, error) {
+	if token == "" {
+		return 0, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, invalidField("page_token", "malformed page token")
+	}
+	parts := strings.SplitN(string(raw), "|", 3)
+	if len(parts) != 3 {
+		return 0, invalidField("page_token", "malformed page token")
+	}
+	offset, err := strconv.Atoi(parts[0])
+	if err != nil || offset < 0 {
+		return 0, invalidField("page_token", "malformed page token")
+	}
+	if TableSort(parts[1]) != sort || parts[2] != namePrefix {
+		return 0, invalidField("page_token", "page token was issued for a different filter or sort order")
+	}
+
+	return offset, nil
+}
+
+// escapeLikePattern escapes LIKE wildcards so input matches literally
+func escapeLikePattern(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,169 @@
+package schema_manager
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"agentic-template/api/requestctx"
+)
+
+// ColumnRoles lists the roles column permissions may deny, those of
+// package auth
+var ColumnRoles = []string{"viewer", "editor", "admin"}
+
+// ColumnPermissions denies roles access to a sensitive column. Roles not
+// listed keep the access their RPC role grants.
+type ColumnPermissions struct {
+	DenyRead  []string `json:"deny_read,omitempty"`  // Roles whose reads omit the column
+	DenyWrite []string `json:"deny_write,omitempty"` // Roles whose writes to the column are rejected
+}
+
+// IsZero reports whether the permissions deny nothing
+func (p *ColumnPermissions) IsZero() bool {
+	return p == nil || (len(p.DenyRead) == 0 && len(p.DenyWrite) == 0)
+}
+
+// Column access checked by column permissions
+const (
+	AccessRead  = "read"
+	AccessWrite = "write"
+)
+
+// ColumnAccessError reports columns the caller's role may not read or
+// write, with a field violation per column
+type ColumnAccessError struct {
+	Access string // read or write
+	Role   string
+	Fields []ValidationError
+}
+
+func (e *ColumnAccessError) Error() string {
+	columns := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		columns[i] = field.Field
+	}
+	return fmt.Sprintf("role %s may not %s column(s) %s", e.Role, e.Access, strings.Join(columns, ", "))
+}
+
+// Unwrap lets callers match ErrColumnAccessDenied
+func (e *ColumnAccessError) Unwrap() error {
+	return ErrColumnAccessDenied
+}
+
+// validateColumnPermissions checks the permissions name known roles
+func validateColumnPermissions(index int, perms *ColumnPermissions) error {
+	if perms == nil {
+		return nil
+	}
+	for _, list := range []struct {
+		attr  string
+		roles []string
+	}{{"permissions.deny_read", perms.DenyRead}, {"permissions.deny_write", perms.DenyWrite}} {
+		for _, role := range list.roles {
+			if !slices.Contains(ColumnRoles, role) {
+				return invalidField(columnField(index, list.attr), "unknown role %q (expected one of %s)", role, strings.Join(ColumnRoles, ", "))
+			}
+		}
+	}
+	return nil
+}
+
+// deniedColumns returns the columns of a table the request's role may not
+// access. Requests without a resolved role, such as background work or
+// calls with authentication disabled, are denied nothing.
+func deniedColumns(ctx context.Context, table *TableDefinition, access string) []ColumnDefinition {
+	role := requestctx.Role(ctx)
+	if role == "" {
+		return nil
+	}
+
+	var denied []ColumnDefinition
+	for _, col := range table.Columns {
+		if col.Permissions == nil {
+			continue
+		}
+		roles := col.Permissions.DenyRead
+		if access == AccessWrite {
+			roles = col.Permissions.DenyWrite
+		}
+		if slices.Contains(roles, role) {
+			denied = append(denied, col)
+		}
+	}
+	return denied
+}
+
+// checkColumnAccess rejects the request when its role may not access any
+// of the named columns of a table
+func checkColumnAccess(ctx context.Context, table *TableDefinition, access string, columnNames ...string) error {
+	var fields []ValidationError
+	for _, col := range deniedColumns(ctx, table, access) {
+		if slices.Contains(columnNames, col.ColumnName) {
+			fields = append(fields, ValidationError{
+				Field:   col.ColumnName,
+				Message: fmt.Sprintf("the %s role may not %s this column", requestctx.Role(ctx), access),
+			})
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return &ColumnAccessError{Access: access, Role: requestctx.Role(ctx), Fields: fields}
+}
+
+// unreadableColumns returns the names of the columns a request may not see
+// in records: those its role may not read, and formula columns computed
+// from them
+func unreadableColumns(ctx context.Context, table *TableDefinition, formulas *FormulaSet) map[string]bool {
+	denied := deniedColumns(ctx, table, AccessRead)
+	if len(denied) == 0 {
+		return nil
+	}
+
+	names := make(map[string]bool, len(denied))
+	for _, col := range denied {
+		names[col.ColumnName] = true
+	}
+	for column, refs := range formulas.References() {
+		if slices.ContainsFunc(refs, func(ref string) bool { return names[ref] }) {
+			names[column] = true
+		}
+	}
+	return names
+}
+
+// omitColumns removes the named columns from a record
+func omitColumns(record map[string]any, names map[string]bool) {
+	for name := range names {
+		delete(record, name)
+	}
+}
+
+// joinRoles stores a role list as comma-separated text, NULL when empty
+func joinRoles(roles []string) *string {
+	if len(roles) == 0 {
+		return nil
+	}
+	joined := strings.Join(roles, ",")
+	return &joined
+}
+
+// columnPermissions builds the permissions of stored role lists, nil when
+// they deny nothing
+func columnPermissions(denyRead, denyWrite *string) *ColumnPermissions {
+	perms := &ColumnPermissions{DenyRead: splitRoles(denyRead), DenyWrite: splitRoles(denyWrite)}
+	if perms.IsZero() {
+		return nil
+	}
+	return perms
+}
+
+// splitRoles parses a role list stored by joinRoles
+func splitRoles(roles *string) []string {
+	if roles == nil || *roles == "" {
+		return nil
+	}
+	return strings.Split(*roles, ",")
+}
@@ -0,0 +1,185 @@
+package schema_manager
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"agentic-template/api/db"
+	"agentic-template/api/requestctx"
+)
+
+// Statically assert that both stores count records for row quotas
+var (
+	_ RecordCounter = &PostgresStore{}
+	_ RecordCounter = &SQLiteStore{}
+)
+
+// Quotas are soft limits on what one tenant may create, so a single tenant
+// can't exhaust the database. Each tenant's tables are counted in its own
+// schema; without tenancy the limits apply to the shared schema. Zero
+// disables a limit.
+type Quotas struct {
+	MaxTables          int   // User tables
+	MaxColumnsPerTable int   // Columns of a user table, not counting id and timestamps
+	MaxRowsPerTable    int64 // Records of a user table
+}
+
+// QuotaError reports the limit a change would exceed
+type QuotaError struct {
+	Limit   string // max_tables, max_columns_per_table, or max_rows_per_table
+	Max     int64
+	Current int64 // Usage before the change
+}
+
+// Error describes the exceeded limit
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("%s quota of %d reached (currently %d)", e.Limit, e.Max, e.Current)
+}
+
+// Unwrap makes a QuotaError match ErrQuotaExceeded
+func (e *QuotaError) Unwrap() error {
+	return ErrQuotaExceeded
+}
+
+// RecordCounter is implemented by stores that can count the records of a
+// table for row quotas
+type RecordCounter interface {
+	// CountRecords returns the number of records in a table, counting no
+	// further than limit
+	CountRecords(ctx context.Context, tableName string, limit int64) (int64, error)
+}
+
+var quotas atomic.Pointer[Quotas]
+
+func init() {
+	quotas.Store(&Quotas{})
+}
+
+// SetQuotas sets the limits enforced on every tenant
+func SetQuotas(q Quotas) {
+	quotas.Store(&q)
+}
+
+// CurrentQuotas returns the limits enforced on every tenant
+func CurrentQuotas() Quotas {
+	return *quotas.Load()
+}
+
+type quotaOverrideKey struct{}
+
+// WithQuotaOverride returns a context whose changes skip the quotas, for
+// operators raising a tenant past its limits. Only admins that are not
+// bound to a tenant may override; changes by other principals fail with
+// ErrQuotaOverrideDenied.
+func WithQuotaOverride(ctx context.Context) context.Context {
+	return context.WithValue(ctx, quotaOverrideKey{}, true)
+}
+
+// quotasFor returns the limits applying to the request, none when it
+// carries an override its principal may use
+func quotasFor(ctx context.Context) (Quotas, error) {
+	if override, _ := ctx.Value(quotaOverrideKey{}).(bool); !override {
+		return CurrentQuotas(), nil
+	}
+	// Without authentication every caller is trusted
+	if principal, ok := requestctx.PrincipalFrom(ctx); ok {
+		if !principal.HasRole("admin") || principal.Tenant != "" {
+			return Quotas{}, ErrQuotaOverrideDenied
+		}
+	}
+	return Quotas{}, nil
+}
+
+// checkTableQuotas rejects creating a table with columnCount columns when
+// the tenant is at its table limit or the table is over its column limit
+func (sm *SchemaManager) checkTableQuotas(ctx context.Context, columnCount int) error {
+	limits, err := quotasFor(ctx)
+	if err != nil {
+		return err
+	}
+	if limits.MaxColumnsPerTable > 0 && columnCount > limits.MaxColumnsPerTable {
+		return &QuotaError{Limit: "max_columns_per_table", Max: int64(limits.MaxColumnsPerTable), Current: int64(columnCount)}
+	}
+	if limits.MaxTables > 0 {
+		// Only the total is needed; it counts every table on any page
+		_, total, err := sm.store.ListTables(ctx, TableQuery{Sort: SortCreatedDesc, Limit: 1})
+		if err != nil {
+			return fmt.Errorf("failed to count tables: %w", err)
+		}
+		if total >= limits.MaxTables {
+			return &QuotaError{Limit: "max_tables", Max: int64(limits.MaxTables), Current: int64(total)}
+		}
+	}
+	return nil
+}
+
+// CheckRecordQuota reports whether adding records to a table keeps it
+// within the row limit, returning a QuotaError when it would not
+func (sm *SchemaManager) CheckRecordQuota(ctx context.Context, tableID int, adding int) error {
+	if sm.store == nil {
+		return ErrDatabaseNotConfigured
+	}
+	table, err := sm.GetTable(ctx, tableID)
+	if err != nil {
+		return err
+	}
+	return sm.checkRecordWrite(ctx, table, adding)
+}
+
+// checkRecordWrite enforces the row limit in the record layer; every write
+// to the records of a user table checks it first: imports and their syncs
+// before each batch, JSON patches, and integrity repairs. Adding records
+// must keep the table within the limit. A table already over it, because
+// the limit was lowered or rows were written to the database directly,
+// accepts only writes that remove records.
+func (sm *SchemaManager) checkRecordWrite(ctx context.Context, table *TableDefinition, adding int) error {
+	limits, err := quotasFor(ctx)
+	if err != nil || limits.MaxRowsPerTable <= 0 {
+		return err
+	}
+	counter, ok := sm.store.(RecordCounter)
+	if !ok {
+		return nil
+	}
+
+	// Counting one past the limit tells a full table from one over it
+	count, err := counter.CountRecords(ctx, table.TableName, limits.MaxRowsPerTable+1)
+	if err != nil {
+		return err
+	}
+	if count+int64(adding) > limits.MaxRowsPerTable {
+		return &QuotaError{Limit: "max_rows_per_table", Max: limits.MaxRowsPerTable, Current: count}
+	}
+	return nil
+}
+
+// CountRecords counts up to limit records, so full tables don't scan
+// every row
+func (s *PostgresStore) CountRecords(ctx context.Context, tableName string, limit int64) (int64, error) {
+	if err := ValidateIdentifierSafety(tableName); err != nil {
+		return 0, err
+	}
+	query := fmt.Sprintf("SELECT count(*) FROM (SELECT 1 FROM %s LIMIT $1) AS records", tableName)
+	var count int64
+	if err := queryRow(ctx, s.pool, query, limit).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count records: %w", err)
+	}
+	return count, nil
+}
+
+// CountRecords counts up to limit records, so full tables don't scan
+// every row
+func (s *SQLiteStore) CountRecords(ctx context.Context, tableName string, limit int64) (int64, error) {
+	if err := ValidateIdentifierSafety(tableName); err != nil {
+		return 0, err
+	}
+	ctx, cancel := db.StatementContext(ctx)
+	defer cancel()
+	query := fmt.Sprintf("SELECT count(*) FROM (SELECT 1 FROM %s LIMIT ?)", tableName)
+	var count int64
+	if err := s.db.QueryRowContext(ctx, query, limit).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count records: %w", err)
+	}
+	return count, nil
+}
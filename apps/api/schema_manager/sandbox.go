@@ -0,0 +1,341 @@
+package schema_manager
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"agentic-template/api/db"
+	"agentic-template/api/requestctx"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Statically assert that PostgreSQL copies records into sandboxes
+var _ SandboxStore = &PostgresStore{}
+
+// MaxSandboxSampleRows limits the records copied per table into a sandbox
+const MaxSandboxSampleRows = 1000
+
+// SandboxStore is implemented by stores that keep a sandbox, a shadow
+// schema beside production where schema changes are tried first. The
+// schema manager reaches the sandbox through contexts scoped to it (see
+// package tenancy).
+type SandboxStore interface {
+	// CopySampleRows copies the first limit records of a table from one
+	// schema to the same table in another, skipping records whose relations
+	// point at records missing from the target, and returns how many were
+	// copied
+	CopySampleRows(ctx context.Context, fromSchema, toSchema string, table TableDefinition, tableNames map[int]string, limit int) (int64, error)
+}
+
+// SandboxSeed reports what SeedSandbox copied
+type SandboxSeed struct {
+	Tables int   `json:"tables"`
+	Rows   int64 `json:"rows"`
+}
+
+// PromoteOptions controls PromoteSandbox
+type PromoteOptions struct {
+	DropRemovedTables bool // Delete production tables missing from the sandbox; otherwise they stay
+	CheckIntegrity    bool // Refuse to promote tables whose sample records have integrity issues
+}
+
+// SeedSandbox copies the production tables of prodCtx into the empty
+// sandbox of sandboxCtx, with up to sampleRows records of each
+func (sm *SchemaManager) SeedSandbox(prodCtx, sandboxCtx context.Context, sampleRows int) (*SandboxSeed, error) {
+	store, err := sm.sandboxStore()
+	if err != nil {
+		return nil, err
+	}
+	if sampleRows < 0 || sampleRows > MaxSandboxSampleRows {
+		return nil, invalidField("sample_rows", "must be between 0 and %d", MaxSandboxSampleRows)
+	}
+
+	bundle, err := sm.ExportBundle(prodCtx)
+	if err != nil {
+		return nil, err
+	}
+	seed := &SandboxSeed{Tables: len(bundle.Tables)}
+	if len(bundle.Tables) == 0 {
+		return seed, nil
+	}
+	migrationSQL, err := GenerateMigrationSQL(bundle)
+	if err != nil {
+		return nil, err
+	}
+	if err := sm.store.Tx(sandboxCtx, func(tx StoreTx) error {
+		return tx.ExecDDL(sandboxCtx, migrationSQL)
+	}); err != nil {
+		return nil, fmt.Errorf("failed to copy tables into the sandbox: %w", err)
+	}
+	// Sandbox tables get no more sample records than the row limit allows
+	limits, err := quotasFor(sandboxCtx)
+	if err != nil {
+		return nil, err
+	}
+	if limits.MaxRowsPerTable > 0 {
+		sampleRows = int(min(int64(sampleRows), limits.MaxRowsPerTable))
+	}
+	if sampleRows == 0 {
+		return seed, nil
+	}
+
+	// Referenced tables first, so relations of the sample records resolve
+	tables, err := orderByReferences(bundle.Tables)
+	if err != nil {
+		return nil, err
+	}
+	tableNames := bundleTableNames(bundle)
+	for _, table := range tables {
+		copied, err := store.CopySampleRows(sandboxCtx, schemaOf(prodCtx), schemaOf(sandboxCtx), table, tableNames, sampleRows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to copy records of '%s' into the sandbox: %w", table.Name, err)
+		}
+		seed.Rows += copied
+	}
+	return seed, nil
+}
+
+// DiffSandbox compares the production tables of prodCtx with those of the
+// sandbox of sandboxCtx
+func (sm *SchemaManager) DiffSandbox(prodCtx, sandboxCtx context.Context) (*SchemaDiff, error) {
+	if _, err := sm.sandboxStore(); err != nil {
+		return nil, err
+	}
+	prod, err := sm.ExportBundle(prodCtx)
+	if err != nil {
+		return nil, err
+	}
+	sandbox, err := sm.ExportBundle(sandboxCtx)
+	if err != nil {
+		return nil, err
+	}
+	return DiffBundles(prod, sandbox), nil
+}
+
+// PromoteSandbox applies the differences between the sandbox of sandboxCtx
+// and production to production in one transaction, so either every change
+// lands or none does. Tables added in the sandbox are created empty;
+// tables removed there are deleted only with opts.DropRemovedTables. Tables
+// changed in the sandbox make the promotion fail with
+// ErrSandboxNotPromotable, as existing tables aren't altered. Returns the
+// diff that was applied.
+func (sm *SchemaManager) PromoteSandbox(prodCtx, sandboxCtx context.Context, opts PromoteOptions) (*SchemaDiff, error) {
+	ctx, span := startSpan(prodCtx, "promote_sandbox")
+	diff, err := sm.promoteSandbox(ctx, sandboxCtx, opts)
+	endSpan(span, err)
+	return diff, err
+}
+
+// promoteSandbox runs PromoteSandbox within its span
+func (sm *SchemaManager) promoteSandbox(ctx, sandboxCtx context.Context, opts PromoteOptions) (*SchemaDiff, error) {
+	if _, err := sm.sandboxStore(); err != nil {
+		return nil, err
+	}
+	prod, err := sm.ExportBundle(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sandbox, err := sm.ExportBundle(sandboxCtx)
+	if err != nil {
+		return nil, err
+	}
+	diff := DiffBundles(prod, sandbox)
+
+	// 1. Only additions and removals can be promoted
+	if len(diff.ChangedTables) > 0 {
+		names := make([]string, len(diff.ChangedTables))
+		for i, change := range diff.ChangedTables {
+			names[i] = change.TableName
+		}
+		return nil, fmt.Errorf("%w: existing tables changed in the sandbox: %s", ErrSandboxNotPromotable, strings.Join(names, ", "))
+	}
+
+	// 2. Validate the added tables as their creation requests, with
+	// relations pointing at production tables by name
+	added, err := referenceOrder(sandbox, diff.AddedTables)
+	if err != nil {
+		return nil, err
+	}
+	sandboxNames := bundleTableNames(sandbox)
+	requests := make([]CreateTableRequest, len(added))
+	for i, table := range added {
+		requests[i] = CreateTableRequest{Name: table.Name, Description: table.Description, Columns: table.Columns}
+		if err := sm.validateCreateTableRequest(requests[i]); err != nil {
+			return nil, fmt.Errorf("%w: table '%s': %v", ErrSandboxNotPromotable, table.Name, err)
+		}
+		if opts.CheckIntegrity {
+			report, err := sm.CheckIntegrity(sandboxCtx, CheckIntegrityRequest{TableID: table.ID})
+			if err != nil {
+				return nil, fmt.Errorf("failed to check the integrity of '%s': %w", table.Name, err)
+			}
+			if len(report.Issues) > 0 {
+				return nil, fmt.Errorf("%w: table '%s' has %d integrity issue(s) in the sandbox", ErrSandboxNotPromotable, table.Name, len(report.Issues))
+			}
+		}
+	}
+
+	// 3. Check the quotas against the tables production ends up with
+	if err := checkPromoteQuotas(ctx, len(prod.Tables), diff, opts); err != nil {
+		return nil, err
+	}
+
+	// 4. Apply every change in one transaction
+	prodIDs := make(map[string]int, len(prod.Tables))
+	for _, table := range prod.Tables {
+		prodIDs[table.TableName] = table.ID
+	}
+	createdBy := requestctx.Actor(ctx)
+	err = sm.store.Tx(ctx, func(tx StoreTx) error {
+		// IDs of this attempt's tables; the transaction may be retried
+		ids := make(map[string]int, len(prodIDs)+len(added))
+		for name, id := range prodIDs {
+			ids[name] = id
+		}
+
+		for i, table := range added {
+			req := requests[i]
+			req.Columns = slices.Clone(req.Columns)
+			for j, col := range req.Columns {
+				if col.ForeignKeyToTableID == nil {
+					continue
+				}
+				target := sandboxNames[*col.ForeignKeyToTableID]
+				id, ok := ids[target]
+				if !ok {
+					return fmt.Errorf("%w: column '%s' of table '%s' references '%s', which production doesn't have",
+						ErrSandboxNotPromotable, col.Name, table.Name, target)
+				}
+				req.Columns[j].ForeignKeyToTableID = &id
+			}
+
+			// Keep the sandbox table's names, which may predate the current strategy
+			naming := CurrentNamingStrategy()
+			if table.Naming != nil {
+				naming = *table.Naming
+			}
+			tableID, _, err := sm.insertTable(ctx, tx, req, table.TableName, naming, createdBy)
+			if err != nil {
+				return fmt.Errorf("failed to create table '%s': %w", table.Name, err)
+			}
+			ids[table.TableName] = tableID
+		}
+
+		if !opts.DropRemovedTables {
+			return nil
+		}
+		// Referencing tables first, so relations don't block the drops
+		removed, err := referenceOrder(prod, diff.RemovedTables)
+		if err != nil {
+			return err
+		}
+		slices.Reverse(removed)
+		for _, table := range removed {
+			if err := sm.deleteTable(ctx, tx, table.ID); err != nil {
+				return fmt.Errorf("failed to delete table '%s': %w", table.Name, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return diff, nil
+}
+
+// checkPromoteQuotas rejects a promotion leaving production over its table
+// limit or adding tables over the column limit
+func checkPromoteQuotas(ctx context.Context, tableCount int, diff *SchemaDiff, opts PromoteOptions) error {
+	limits, err := quotasFor(ctx)
+	if err != nil {
+		return err
+	}
+	if limits.MaxColumnsPerTable > 0 {
+		for _, table := range diff.AddedTables {
+			if len(table.Columns) > limits.MaxColumnsPerTable {
+				return &QuotaError{Limit: "max_columns_per_table", Max: int64(limits.MaxColumnsPerTable), Current: int64(len(table.Columns))}
+			}
+		}
+	}
+	total := tableCount + len(diff.AddedTables)
+	if opts.DropRemovedTables {
+		total -= len(diff.RemovedTables)
+	}
+	if limits.MaxTables > 0 && len(diff.AddedTables) > 0 && total > limits.MaxTables {
+		return &QuotaError{Limit: "max_tables", Max: int64(limits.MaxTables), Current: int64(tableCount)}
+	}
+	return nil
+}
+
+// referenceOrder orders some tables of a bundle so each comes after the
+// tables its relation columns reference
+func referenceOrder(bundle *SchemaBundle, tables []TableDefinition) ([]TableDefinition, error) {
+	ordered, err := orderByReferences(bundle.Tables)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(ordered, func(table TableDefinition) bool {
+		return !slices.ContainsFunc(tables, func(t TableDefinition) bool { return t.ID == table.ID })
+	}), nil
+}
+
+// sandboxStore returns the store as a SandboxStore
+func (sm *SchemaManager) sandboxStore() (SandboxStore, error) {
+	if sm.store == nil {
+		return nil, ErrDatabaseNotConfigured
+	}
+	store, ok := sm.store.(SandboxStore)
+	if !ok {
+		return nil, fmt.Errorf("schema sandboxes are not supported on %s", sm.store.Dialect().Name())
+	}
+	return store, nil
+}
+
+// schemaOf returns the schema the queries of ctx use
+func schemaOf(ctx context.Context) string {
+	if schema := requestctx.TenantSchema(ctx); schema != "" {
+		return schema
+	}
+	return "public"
+}
+
+// CopySampleRows copies records with their IDs and timestamps, then moves
+// the target's ID sequence past them
+func (s *PostgresStore) CopySampleRows(ctx context.Context, fromSchema, toSchema string, table TableDefinition, tableNames map[int]string, limit int) (int64, error) {
+	columns := []string{"id"}
+	var conditions []string
+	for _, col := range table.Columns {
+		if col.DataType == DataTypeFormula {
+			continue
+		}
+		name := pgx.Identifier{col.ColumnName}.Sanitize()
+		columns = append(columns, name)
+		if col.ForeignKeyToTableID != nil {
+			target := pgx.Identifier{toSchema, tableNames[*col.ForeignKeyToTableID]}.Sanitize()
+			conditions = append(conditions, fmt.Sprintf("(src.%s IS NULL OR EXISTS (SELECT 1 FROM %s t WHERE t.id = src.%s))", name, target, name))
+		}
+	}
+	columns = append(columns, "created_at", "updated_at")
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	source := pgx.Identifier{fromSchema, table.TableName}.Sanitize()
+	target := pgx.Identifier{toSchema, table.TableName}.Sanitize()
+	list := strings.Join(columns, ", ")
+	query := fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s src %s ORDER BY id LIMIT $1", target, list, "src."+strings.Join(columns, ", src."), source, where)
+
+	queryCtx, cancel := db.StatementContext(ctx)
+	defer cancel()
+	tag, err := s.pool.Exec(queryCtx, query, limit)
+	if err != nil {
+		return 0, err
+	}
+	if tag.RowsAffected() > 0 {
+		err = exec(ctx, s.pool, fmt.Sprintf("SELECT setval(pg_get_serial_sequence($1, 'id'), (SELECT max(id) FROM %s))", target), target)
+	}
+	return tag.RowsAffected(), err
+}
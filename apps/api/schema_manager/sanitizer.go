@@ -2,6 +2,7 @@ package schema_manager
 
 import (
 	"fmt"
+	"hash/fnv"
 	"regexp"
 	"strings"
 	"unicode"
@@ -39,11 +40,48 @@ var (
 	multipleUnderscoresPattern = regexp.MustCompile(`_{2,}`)
 	// Matches non-alphanumeric characters (except underscore)
 	nonAlphanumericPattern = regexp.MustCompile(`[^a-zA-Z0-9_]+`)
+	// Matches a lowercase letter or digit followed by an uppercase letter, as in firstName
+	camelBoundaryPattern = regexp.MustCompile(`([a-z0-9])([A-Z])`)
+	// Matches the end of an acronym followed by a word, as in HTTPServer
+	acronymBoundaryPattern = regexp.MustCompile(`([A-Z]+)([A-Z][a-z])`)
 )
 
 // SanitizeIdentifier takes a user-provided name and converts it to a safe PostgreSQL identifier
+// with the current naming strategy
 // This is the MOST CRITICAL security function - it prevents SQL injection
 func SanitizeIdentifier(input string) (string, error) {
+	return CurrentNamingStrategy().Identifier(input)
+}
+
+// SanitizeTableName creates a safe table name with the current naming strategy's prefix
+func SanitizeTableName(userInput string) (string, error) {
+	return CurrentNamingStrategy().TableName(userInput)
+}
+
+// Identifier converts a user-provided name to a safe PostgreSQL identifier
+func (n NamingStrategy) Identifier(input string) (string, error) {
+	normalized, err := n.normalize(input)
+	if err != nil {
+		return "", err
+	}
+	return n.fit(normalized), nil
+}
+
+// TableName converts a user-provided name to a safe table name starting
+// with the prefix
+func (n NamingStrategy) TableName(userInput string) (string, error) {
+	normalized, err := n.normalize(userInput)
+	if err != nil {
+		return "", fmt.Errorf("failed to sanitize table name: %w", err)
+	}
+
+	// Add prefix to separate user tables from system tables. The prefix
+	// leaves room for the name, so truncation only shortens the name.
+	return n.fit(n.TablePrefix + normalized), nil
+}
+
+// normalize converts a user-provided name to an identifier of any length
+func (n NamingStrategy) normalize(input string) (string, error) {
 	if input == "" {
 		return "", fmt.Errorf("identifier cannot be empty")
 	}
@@ -51,6 +89,12 @@ func SanitizeIdentifier(input string) (string, error) {
 	// Remove leading and trailing whitespace
 	input = strings.TrimSpace(input)
 
+	// Split camelCase words before their case is lost
+	if n.Case == NamingCaseSnake {
+		input = acronymBoundaryPattern.ReplaceAllString(input, "${1}_${2}")
+		input = camelBoundaryPattern.ReplaceAllString(input, "${1}_${2}")
+	}
+
 	// Convert to lowercase for consistency
 	input = strings.ToLower(input)
 
@@ -83,46 +127,38 @@ func SanitizeIdentifier(input string) (string, error) {
 		input = input + "_"
 	}
 
-	// Ensure reasonable length (PostgreSQL has a 63-character limit for identifiers)
-	if len(input) > 63 {
-		input = input[:63]
-		// Ensure we didn't cut in the middle of something important
-		input = strings.TrimRight(input, "_")
-	}
-
-	if input == "" {
-		return "", fmt.Errorf("identifier sanitization resulted in empty string")
-	}
-
 	return input, nil
 }
 
-// SanitizeTableName creates a safe table name with the "user_table_" prefix
-func SanitizeTableName(userInput string) (string, error) {
-	sanitized, err := SanitizeIdentifier(userInput)
-	if err != nil {
-		return "", fmt.Errorf("failed to sanitize table name: %w", err)
+// fit shortens an identifier to the maximum length. Cut names may end up
+// the same; hashed names end with a hash of the whole name instead.
+func (n NamingStrategy) fit(identifier string) string {
+	maxLength := n.MaxLength
+	if maxLength <= 0 || maxLength > MaxIdentifierLength {
+		maxLength = MaxIdentifierLength
 	}
-
-	// Add prefix to separate user tables from system tables
-	tableName := "user_table_" + sanitized
-
-	// Ensure total length is within PostgreSQL limits
-	if len(tableName) > 63 {
-		// Truncate the user portion, keeping the prefix
-		maxUserLength := 63 - len("user_table_")
-		if maxUserLength < 1 {
-			return "", fmt.Errorf("table name too long even after sanitization")
-		}
-		sanitized = sanitized[:maxUserLength]
-		tableName = "user_table_" + sanitized
+	if len(identifier) <= maxLength {
+		return identifier
 	}
 
-	return tableName, nil
+	if n.Truncation == NamingTruncationHash {
+		hash := fnv.New32a()
+		hash.Write([]byte(identifier))
+		// Ensure we didn't cut in the middle of something important
+		cut := strings.TrimRight(identifier[:maxLength-hashSuffixLen], "_")
+		return fmt.Sprintf("%s_%08x", cut, hash.Sum32())
+	}
+	// Ensure we didn't cut in the middle of something important
+	return strings.TrimRight(identifier[:maxLength], "_")
 }
 
 // ValidateIdentifierSafety performs additional security checks
 func ValidateIdentifierSafety(identifier string) error {
+	// Identifiers are written unquoted, so they must keep the sanitized form
+	if err := checkIdentifier(identifier); err != nil {
+		return err
+	}
+
 	// Check for common SQL injection patterns (defense in depth)
 	dangerousPatterns := []string{
 		"--", "/*", "*/", ";", "'", "\"", "\\",
@@ -0,0 +1,93 @@
+package schema_manager
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Limits of SearchSchema
+const (
+	DefaultSchemaSearchLimit = 20
+	MaxSchemaSearchLimit     = 100
+	MaxSchemaSearchTerms     = 16 // Words of a query
+)
+
+// SchemaMatchKind is what a schema search matched
+type SchemaMatchKind string
+
+const (
+	SchemaMatchTable  SchemaMatchKind = "table"
+	SchemaMatchColumn SchemaMatchKind = "column"
+)
+
+// searchTermPattern matches the words of a query; everything else
+// separates them
+var searchTermPattern = regexp.MustCompile(`[\p{L}\p{N}]+`)
+
+// SchemaSearchRequest is the request payload for searching tables and
+// columns
+type SchemaSearchRequest struct {
+	Query string `json:"query"` // Words a table or column must all contain, or start
+	Limit int    `json:"limit"` // Defaults to DefaultSchemaSearchLimit
+}
+
+// SchemaMatch is a table or column whose name or description matched a
+// schema search
+type SchemaMatch struct {
+	Kind        SchemaMatchKind `json:"kind"`
+	TableID     int             `json:"table_id"`
+	Table       string          `json:"table"`                 // User-friendly table name
+	TableName   string          `json:"table_name"`            // Sanitized table name
+	Column      *string         `json:"column,omitempty"`      // User-friendly column name, for column matches
+	ColumnName  *string         `json:"column_name,omitempty"` // Sanitized column name, for column matches
+	Description *string         `json:"description,omitempty"` // Of the table or column
+	Rank        float64         `json:"rank"`                  // Relevance; names weigh more than descriptions
+}
+
+// SchemaSearchStore is implemented by stores that can full-text search
+// their metadata
+type SchemaSearchStore interface {
+	// SearchSchema returns up to limit tables and columns outside the
+	// trash whose names or descriptions contain every term, as a word or
+	// a word's prefix, best matches first
+	SearchSchema(ctx context.Context, terms []string, limit int) ([]SchemaMatch, error)
+}
+
+// SearchSchema finds the tables and columns whose names or descriptions
+// match a query, best matches first
+func (sm *SchemaManager) SearchSchema(ctx context.Context, req SchemaSearchRequest) ([]SchemaMatch, error) {
+	ctx, span := startSpan(ctx, "search_schema")
+	matches, err := sm.searchSchema(ctx, req)
+	endSpan(span, err)
+	return matches, err
+}
+
+// searchSchema runs SearchSchema within its span
+func (sm *SchemaManager) searchSchema(ctx context.Context, req SchemaSearchRequest) ([]SchemaMatch, error) {
+	if sm.store == nil {
+		return nil, ErrDatabaseNotConfigured
+	}
+	store, ok := sm.store.(SchemaSearchStore)
+	if !ok {
+		return nil, fmt.Errorf("schema search is not supported on %s", sm.store.Dialect().Name())
+	}
+
+	terms := searchTermPattern.FindAllString(strings.ToLower(req.Query), -1)
+	if len(terms) == 0 {
+		return nil, invalidField("query", "query must contain a letter or digit")
+	}
+	if len(terms) > MaxSchemaSearchTerms {
+		return nil, invalidField("query", "at most %d words are allowed", MaxSchemaSearchTerms)
+	}
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = DefaultSchemaSearchLimit
+	}
+	if limit > MaxSchemaSearchLimit {
+		limit = MaxSchemaSearchLimit
+	}
+	return store.SearchSchema(ctx, terms, limit)
+}
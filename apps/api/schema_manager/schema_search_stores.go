@@ -0,0 +1,72 @@
+package schema_manager
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"agentic-template/api/db"
+)
+
+// Schema search runs on PostgreSQL's full-text search
+var _ SchemaSearchStore = &PostgresStore{}
+
+// Search vectors of tables and columns, weighting names over descriptions.
+// They must match the expressions of the indexes migration 027 creates.
+const (
+	tableSearchVector  = `setweight(to_tsvector('simple', t.name), 'A') || setweight(to_tsvector('simple', COALESCE(t.description, '')), 'B')`
+	columnSearchVector = `setweight(to_tsvector('simple', c.name || ' ' || c.column_name), 'A') || setweight(to_tsvector('simple', COALESCE(c.description, '')), 'B')`
+)
+
+// SearchSchema matches every term as a word prefix with to_tsquery and
+// ranks matches with ts_rank, tables before their columns on ties
+func (s *PostgresStore) SearchSchema(ctx context.Context, terms []string, limit int) ([]SchemaMatch, error) {
+	// Terms are letters and digits only, so they can't change the query
+	prefixes := make([]string, len(terms))
+	for i, term := range terms {
+		prefixes[i] = term + ":*"
+	}
+	query := `
+		WITH q AS (SELECT to_tsquery('simple', $1) AS query)
+		SELECT kind, table_id, table_display, table_name, column_display, column_name, description, rank
+		FROM (
+			SELECT 'table' AS kind, t.id AS table_id, t.name AS table_display, t.table_name,
+			       NULL::TEXT AS column_display, NULL::TEXT AS column_name, t.description,
+			       ts_rank(` + tableSearchVector + `, q.query) AS rank
+			FROM configurable_tables t, q
+			WHERE t.deleted_at IS NULL AND (` + tableSearchVector + `) @@ q.query
+			UNION ALL
+			SELECT 'column', t.id, t.name, t.table_name, c.name, c.column_name, c.description,
+			       ts_rank(` + columnSearchVector + `, q.query)
+			FROM configurable_columns c
+			JOIN configurable_tables t ON t.id = c.table_id, q
+			WHERE t.deleted_at IS NULL AND (` + columnSearchVector + `) @@ q.query
+		) matches
+		ORDER BY rank DESC, table_display, kind DESC, column_display
+		LIMIT $2
+	`
+	queryCtx, cancel := db.StatementContext(ctx)
+	defer cancel()
+	rows, err := s.reader().Query(queryCtx, query, strings.Join(prefixes, " & "), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search schema: %w", err)
+	}
+	defer rows.Close()
+
+	matches := []SchemaMatch{}
+	for rows.Next() {
+		var match SchemaMatch
+		var rank float32
+		err := rows.Scan(&match.Kind, &match.TableID, &match.Table, &match.TableName,
+			&match.Column, &match.ColumnName, &match.Description, &rank)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan schema match: %w", err)
+		}
+		match.Rank = float64(rank)
+		matches = append(matches, match)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to search schema: %w", err)
+	}
+	return matches, nil
+}
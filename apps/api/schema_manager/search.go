@@ -0,0 +1,186 @@
+package schema_manager
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"agentic-template/api/db"
+	"agentic-template/api/embeddings"
+)
+
+// DefaultSearchLimit is the number of rows returned when no limit is given
+const DefaultSearchLimit = 10
+
+// MaxSearchLimit caps the number of rows returned by a semantic search
+const MaxSearchLimit = 100
+
+// SearchLimit applies DefaultSearchLimit and MaxSearchLimit to a requested
+// number of results
+func SearchLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultSearchLimit
+	}
+	return min(limit, MaxSearchLimit)
+}
+
+// SemanticSearchRequest describes a nearest-neighbor search over a vector column
+type SemanticSearchRequest struct {
+	TableID    int               `json:"table_id"`
+	ColumnName string            `json:"column_name"` // Sanitized name of the vector column
+	Vector     []float32         `json:"-"`           // Embedded query
+	Limit      int               `json:"limit,omitempty"`
+	Filters    map[string]string `json:"filters,omitempty"` // Column name -> exact value
+}
+
+// SearchResult is a row returned from a semantic search
+type SearchResult struct {
+	Row        map[string]interface{} `json:"row"`
+	Similarity float64                `json:"similarity"` // Cosine similarity, 1.0 is identical
+}
+
+// SemanticSearch returns the rows whose vector column is closest to the query
+// vector, ranked by cosine similarity
+func (sm *SchemaManager) SemanticSearch(ctx context.Context, req SemanticSearchRequest) ([]SearchResult, error) {
+	ctx, span := startSpan(ctx, "semantic_search", attrTableID.Int(req.TableID))
+	results, err := sm.semanticSearch(ctx, req)
+	endSpan(span, err)
+	return results, err
+}
+
+// semanticSearch runs SemanticSearch within its span
+func (sm *SchemaManager) semanticSearch(ctx context.Context, req SemanticSearchRequest) ([]SearchResult, error) {
+	if sm.store == nil {
+		return nil, ErrDatabaseNotConfigured
+	}
+	vectors, ok := sm.store.(VectorStore)
+	if !ok {
+		return nil, fmt.Errorf("semantic search is not supported on %s", sm.store.Dialect().Name())
+	}
+
+	tableDef, err := sm.GetTable(ctx, req.TableID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Resolve the vector column and the filtered columns
+	var vectorCol *ColumnDefinition
+	columnsByName := make(map[string]ColumnDefinition, len(tableDef.Columns))
+	for i, col := range tableDef.Columns {
+		columnsByName[col.ColumnName] = col
+		if col.DataType == DataTypeVector && col.ColumnName == req.ColumnName {
+			vectorCol = &tableDef.Columns[i]
+		}
+	}
+
+	if vectorCol == nil {
+		return nil, fmt.Errorf("column '%s' is not a vector column of table '%s'", req.ColumnName, tableDef.Name)
+	}
+	if vectorCol.VectorDimensions != nil && len(req.Vector) != *vectorCol.VectorDimensions {
+		return nil, fmt.Errorf("query vector has %d dimensions, column '%s' expects %d",
+			len(req.Vector), vectorCol.ColumnName, *vectorCol.VectorDimensions)
+	}
+
+	req.Limit = SearchLimit(req.Limit)
+
+	for name := range req.Filters {
+		col, ok := columnsByName[name]
+		if !ok || col.DataType == DataTypeVector || col.DataType == DataTypeFormula {
+			return nil, fmt.Errorf("invalid filter column: %s", name)
+		}
+	}
+
+	// Ranking or filtering by a column reveals its values
+	searched := append([]string{req.ColumnName}, slices.Sorted(maps.Keys(req.Filters))...)
+	if err := checkColumnAccess(ctx, tableDef, AccessRead, searched...); err != nil {
+		return nil, err
+	}
+
+	formulas, err := NewFormulaSet(tableDef)
+	if err != nil {
+		return nil, err
+	}
+	results, err := vectors.SemanticSearch(ctx, tableDef, req)
+	if err != nil {
+		return nil, err
+	}
+	hidden := unreadableColumns(ctx, tableDef, formulas)
+	for _, result := range results {
+		formulas.Apply(result.Row)
+		omitColumns(result.Row, hidden)
+	}
+	return results, nil
+}
+
+// SemanticSearch ranks rows by the cosine distance of a pgvector column
+func (s *PostgresStore) SemanticSearch(ctx context.Context, tableDef *TableDefinition, req SemanticSearchRequest) ([]SearchResult, error) {
+	query, args := semanticSearchStatement(tableDef, req)
+
+	queryCtx, cancel := db.StatementContext(ctx)
+	defer cancel()
+	rows, err := s.reader().Query(queryCtx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute semantic search: %w", err)
+	}
+	defer rows.Close()
+
+	results := []SearchResult{}
+	for rows.Next() {
+		values, err := rows.Values()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get row values: %w", err)
+		}
+
+		result := SearchResult{Row: make(map[string]interface{}, len(values)-1)}
+		for i, field := range rows.FieldDescriptions() {
+			if field.Name == "_similarity" {
+				if similarity, ok := values[i].(float64); ok {
+					result.Similarity = similarity
+				}
+				continue
+			}
+			result.Row[field.Name] = values[i]
+		}
+		results = append(results, result)
+	}
+
+	return results, rows.Err()
+}
+
+// semanticSearchStatement returns the cached query of a semantic search and
+// its arguments
+func semanticSearchStatement(tableDef *TableDefinition, req SemanticSearchRequest) (string, []any) {
+	selectCols := storedSystemColumns()
+	for _, col := range tableDef.Columns {
+		if col.DataType != DataTypeVector && col.DataType != DataTypeFormula {
+			selectCols = append(selectCols, col.ColumnName)
+		}
+	}
+
+	// Identifiers come from metadata, values are parameters. Filters are
+	// sorted so the same filter set reuses the same statement.
+	filterCols := slices.Sorted(maps.Keys(req.Filters))
+	args := []any{embeddings.FormatVector(req.Vector), req.Limit}
+	for _, name := range filterCols {
+		args = append(args, req.Filters[name])
+	}
+
+	key := newStatementKey(tableDef.TableName, "semantic_search", selectCols, []string{req.ColumnName}, filterCols)
+	query := recordStatements.get(key, func() string {
+		conditions := []string{fmt.Sprintf("%s IS NOT NULL", req.ColumnName)}
+		for i, name := range filterCols {
+			conditions = append(conditions, fmt.Sprintf("%s::text = $%d", name, i+3))
+		}
+		return fmt.Sprintf(`
+		SELECT %s, 1 - (%s <=> $1::vector) AS _similarity
+		FROM %s
+		WHERE %s
+		ORDER BY %s <=> $1::vector
+		LIMIT $2
+	`, strings.Join(selectCols, ", "), req.ColumnName, tableDef.TableName,
+			strings.Join(conditions, " AND "), req.ColumnName)
+	})
+	return query, args
+}
@@ -0,0 +1,93 @@
+package schema_manager
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// DDL can't take bind parameters, so the values it embeds are quoted here.
+// Identifiers are written unquoted and must keep the sanitized form;
+// literals are quoted the way the server's quote_literal quotes them.
+
+// maxIdentifierLength is PostgreSQL's identifier limit in bytes
+const maxIdentifierLength = 63
+
+// decimalPattern accepts plain decimal numbers such as -12, 3.5, or .25
+var decimalPattern = regexp.MustCompile(`^[+-]?([0-9]+(\.[0-9]*)?|\.[0-9]+)$`)
+
+// quoteLiteral quotes s as a PostgreSQL string literal like quote_literal:
+// quotes are doubled, and a string with backslashes is written as an
+// escape string (E'...') with doubled backslashes, so it reads the same
+// whether or not standard_conforming_strings is on
+func quoteLiteral(s string) string {
+	quoted := strings.ReplaceAll(s, "'", "''")
+	if !strings.Contains(quoted, `\`) {
+		return "'" + quoted + "'"
+	}
+	return "E'" + strings.ReplaceAll(quoted, `\`, `\\`) + "'"
+}
+
+// quoteSQLiteLiteral quotes s as a SQLite string literal, whose only escape
+// is a doubled quote
+func quoteSQLiteLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// checkLiteralText rejects text that can't be embedded in a statement. A
+// NUL byte ends the statement on the wire, and the server rejects invalid
+// UTF-8.
+func checkLiteralText(s string) error {
+	if strings.ContainsRune(s, 0) {
+		return fmt.Errorf("value contains a NUL character")
+	}
+	if !utf8.ValidString(s) {
+		return fmt.Errorf("value is not valid UTF-8")
+	}
+	return nil
+}
+
+// checkIdentifier checks an identifier is safe to write unquoted: only
+// letters, digits, and underscores, not starting with a digit, and within
+// the length limit
+func checkIdentifier(identifier string) error {
+	if !validIdentifierPattern.MatchString(identifier) {
+		return fmt.Errorf("identifier %q must contain only letters, digits, and underscores", identifier)
+	}
+	if len(identifier) > maxIdentifierLength {
+		return fmt.Errorf("identifier %q is longer than %d characters", identifier, maxIdentifierLength)
+	}
+	return nil
+}
+
+// validateDefaultValue checks a default value can be embedded in DDL for
+// its data type
+func validateDefaultValue(dataType DataType, value string) error {
+	if err := checkLiteralText(value); err != nil {
+		return err
+	}
+
+	switch dataType {
+	case DataTypeNumber:
+		// Columns are INTEGER, so the value must fit 32 bits
+		if _, err := strconv.ParseInt(value, 10, 32); err != nil {
+			return fmt.Errorf("invalid integer value: %s", value)
+		}
+	case DataTypeDecimal:
+		if !decimalPattern.MatchString(value) {
+			return fmt.Errorf("invalid decimal value: %s", value)
+		}
+	case DataTypeJSON:
+		if !json.Valid([]byte(value)) {
+			return fmt.Errorf("invalid JSON value: %s", value)
+		}
+	case DataTypeRelation:
+		return fmt.Errorf("relation columns cannot have default values")
+	case DataTypeVector:
+		return fmt.Errorf("vector columns cannot have default values")
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package schema_manager
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+
+	"agentic-template/api/metrics"
+)
+
+// DefaultStatementCacheSize is how many generated record statements are
+// kept, across tables and tenants
+const DefaultStatementCacheSize = 1024
+
+var statementCacheLookups = metrics.NewCounter("record_statement_cache_lookups_total",
+	"Lookups of generated record statements, by operation and result (hit or miss).", "operation", "result")
+
+// statementKey identifies a generated record statement: the table, the
+// operation, and the columns of each clause in statement order. The
+// SQL is a function of the key alone, so cached statements never go stale;
+// a table whose columns change gets new keys and the old ones age out.
+type statementKey struct {
+	table     string
+	operation string
+	columns   string
+}
+
+// newStatementKey builds the key of a statement from the columns of each
+// of its clauses, such as the selected and the filtered columns
+func newStatementKey(table, operation string, clauses ...[]string) statementKey {
+	columns := make([]string, len(clauses))
+	for i, clause := range clauses {
+		columns[i] = strings.Join(clause, ",")
+	}
+	return statementKey{table: table, operation: operation, columns: strings.Join(columns, ";")}
+}
+
+// statementEntry is a cached statement in the LRU list
+type statementEntry struct {
+	key statementKey
+	sql string
+}
+
+// statementCache keeps generated record statements so similar requests
+// reuse the same SQL text. pgx prepares each text once per connection and
+// caches the prepared statement (see db.PoolConfig.StatementCacheCapacity),
+// so stable text skips parsing and planning on the server as well as
+// rebuilding the SQL here.
+type statementCache struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[statementKey]*list.Element
+	order    *list.List // Most recently used first
+}
+
+// recordStatements caches the statements of all record operations
+var recordStatements = newStatementCache(DefaultStatementCacheSize)
+
+// newStatementCache creates a cache holding up to capacity statements
+func newStatementCache(capacity int) *statementCache {
+	return &statementCache{
+		capacity: capacity,
+		entries:  make(map[statementKey]*list.Element, capacity),
+		order:    list.New(),
+	}
+}
+
+// get returns the statement of key, building and caching it when missing
+// and evicting the least recently used statement when full
+func (c *statementCache) get(key statementKey, build func() string) string {
+	c.mu.Lock()
+	if elem, ok := c.entries[key]; ok {
+		c.order.MoveToFront(elem)
+		sql := elem.Value.(*statementEntry).sql
+		c.mu.Unlock()
+		statementCacheLookups.Inc(key.operation, "hit")
+		return sql
+	}
+	c.mu.Unlock()
+	statementCacheLookups.Inc(key.operation, "miss")
+
+	// Build outside the lock; concurrent misses build the same text
+	sql := build()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		c.order.MoveToFront(elem)
+		return elem.Value.(*statementEntry).sql
+	}
+	c.entries[key] = c.order.PushFront(&statementEntry{key: key, sql: sql})
+	if c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*statementEntry).key)
+	}
+	return sql
+}
+
+// forget drops the statements of a table, such as one just dropped
+func (c *statementCache) forget(table string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, elem := range c.entries {
+		if key.table == table {
+			c.order.Remove(elem)
+			delete(c.entries, key)
+		}
+	}
+}
+
+// columnNames returns the sanitized names of columns, for statement keys
+func columnNames(columns []ColumnDefinition) []string {
+	names := make([]string, len(columns))
+	for i, col := range columns {
+		names[i] = col.ColumnName
+	}
+	return names
+}
@@ -0,0 +1,164 @@
+package schema_manager
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+func TestStatementCache(t *testing.T) {
+	cache := newStatementCache(2)
+	builds := 0
+	build := func(sql string) func() string {
+		return func() string {
+			builds++
+			return sql
+		}
+	}
+
+	a := newStatementKey("t1", "read", []string{"a", "b"})
+	b := newStatementKey("t1", "read", []string{"a"}, []string{"b"})
+	c := newStatementKey("t2", "read", []string{"a"})
+
+	if got := cache.get(a, build("A")); got != "A" || builds != 1 {
+		t.Fatalf("get(a) = %q after %d builds, want A after 1", got, builds)
+	}
+	if got := cache.get(a, build("other")); got != "A" || builds != 1 {
+		t.Fatalf("get(a) again = %q after %d builds, want the cached A", got, builds)
+	}
+	// Clauses are part of the key: a,b in one clause differs from a;b
+	if got := cache.get(b, build("B")); got != "B" || builds != 2 {
+		t.Fatalf("get(b) = %q after %d builds, want B after 2", got, builds)
+	}
+
+	// a was used before b, so adding c evicts it
+	cache.get(c, build("C"))
+	cache.get(a, build("A2"))
+	if builds != 4 {
+		t.Errorf("builds = %d, want a rebuilt after eviction", builds)
+	}
+
+	cache.forget("t1")
+	if _, ok := cache.entries[a]; ok {
+		t.Error("forget(t1) kept a statement of t1")
+	}
+	if _, ok := cache.entries[c]; !ok {
+		t.Error("forget(t1) dropped a statement of t2")
+	}
+	if cache.order.Len() != len(cache.entries) {
+		t.Errorf("order has %d entries, map has %d", cache.order.Len(), len(cache.entries))
+	}
+}
+
+func BenchmarkStatementCacheGet(b *testing.B) {
+	build := func() string { return "SELECT 1" }
+	key := newStatementKey("user_table_orders", "read", []string{"id", "name", "total"})
+
+	b.Run("hit", func(b *testing.B) {
+		cache := newStatementCache(DefaultStatementCacheSize)
+		cache.get(key, build)
+		b.ReportAllocs()
+		for b.Loop() {
+			cache.get(key, build)
+		}
+	})
+
+	b.Run("hit_parallel", func(b *testing.B) {
+		cache := newStatementCache(DefaultStatementCacheSize)
+		cache.get(key, build)
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				cache.get(key, build)
+			}
+		})
+	})
+
+	b.Run("miss_evicting", func(b *testing.B) {
+		keys := make([]statementKey, 4*DefaultStatementCacheSize)
+		for i := range keys {
+			keys[i] = newStatementKey(fmt.Sprintf("user_table_%d", i), "read", []string{"id"})
+		}
+		cache := newStatementCache(DefaultStatementCacheSize)
+		b.ReportAllocs()
+		i := 0
+		for b.Loop() {
+			cache.get(keys[i%len(keys)], build)
+			i++
+		}
+	})
+}
+
+// benchmarkTable is a user table with a representative mix of columns
+func benchmarkTable() *TableDefinition {
+	table := &TableDefinition{TableName: "user_table_orders"}
+	for i := range 12 {
+		table.Columns = append(table.Columns, ColumnDefinition{
+			ColumnName:   fmt.Sprintf("column_%d", i),
+			DataType:     DataTypeText,
+			PostgresType: "VARCHAR(255)",
+		})
+	}
+	dims := 3
+	table.Columns = append(table.Columns,
+		ColumnDefinition{ColumnName: "details", DataType: DataTypeJSON, PostgresType: "JSONB"},
+		ColumnDefinition{ColumnName: "embedding", DataType: DataTypeVector, PostgresType: "VECTOR(3)", VectorDimensions: &dims},
+	)
+	return table
+}
+
+// BenchmarkRecordStatements measures generating the SQL of the record
+// paths with the statement cache and with every lookup missing
+func BenchmarkRecordStatements(b *testing.B) {
+	table := benchmarkTable()
+	search := SemanticSearchRequest{
+		ColumnName: "embedding",
+		Vector:     []float32{0.1, 0.2, 0.3},
+		Limit:      DefaultSearchLimit,
+		Filters:    map[string]string{"column_1": "open", "column_2": "web"},
+	}
+	patch := PatchJSONRequest{
+		RecordID:   1,
+		ColumnName: "details",
+		Operations: []JSONPathOperation{
+			{Op: JSONOpSet, Path: []string{"shipping", "city"}, Value: json.RawMessage(`"Oslo"`)},
+			{Op: JSONOpRemove, Path: []string{"draft"}},
+		},
+	}
+	textColumns := columnNames(table.Columns[:12])
+
+	paths := []struct {
+		name string
+		run  func()
+	}{
+		{"semantic_search", func() { semanticSearchStatement(table, search) }},
+		{"patch_json", func() { patchJSONStatement(table, patch) }},
+		{"anonymize", func() { anonymizeStatements(table.TableName, table.Columns[:12], false) }},
+		{"text_records", func() {
+			if _, err := textRecordsQuery(table.TableName, textColumns, "list_text_records", "id > $1 ORDER BY id LIMIT $2"); err != nil {
+				b.Fatal(err)
+			}
+		}},
+	}
+	for _, path := range paths {
+		for _, cached := range []bool{true, false} {
+			name := path.name + "/cached"
+			if !cached {
+				name = path.name + "/uncached"
+			}
+			b.Run(name, func(b *testing.B) {
+				saved := recordStatements
+				recordStatements = newStatementCache(DefaultStatementCacheSize)
+				if !cached {
+					// A cache of no statements evicts every one it builds
+					recordStatements = newStatementCache(0)
+				}
+				b.Cleanup(func() { recordStatements = saved })
+				b.ReportAllocs()
+				for b.Loop() {
+					path.run()
+				}
+			})
+		}
+	}
+}
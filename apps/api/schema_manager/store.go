@@ -0,0 +1,116 @@
+package schema_manager
+
+import (
+	"context"
+	"time"
+)
+
+// DDLDialect renders the DDL of user tables for one database engine
+type DDLDialect interface {
+	// Name identifies the dialect, e.g. "postgres"
+	Name() string
+	// ColumnType maps a column to the engine's type, stored as the
+	// column's PostgresType
+	ColumnType(col ColumnDefinition) (string, error)
+	// ValidateColumn rejects column options the engine can't store
+	ValidateColumn(col ColumnDefinition) error
+	// CreateTableSQL renders the statements creating a user table with its
+	// id, created_at, and updated_at columns. referencedTable resolves the
+	// table a relation column references. With ifNotExists the statements
+	// are idempotent.
+	CreateTableSQL(tableName string, columns []ColumnDefinition, ifNotExists bool, referencedTable func(i int, col ColumnDefinition) (string, error)) (string, error)
+	// DropTableSQL renders the statement dropping a user table
+	DropTableSQL(tableName string) string
+	// RenameTableSQL renders the statement renaming a user table, such as
+	// moving it to the trash
+	RenameTableSQL(from, to string) string
+	// DefaultValueSQL renders a column's default value as an expression
+	DefaultValueSQL(dataType DataType, defaultValue *string) (string, error)
+	// InvalidValueCondition renders a condition matching values of col
+	// that break its data type, for engines that store them anyway, or ""
+	// when the engine enforces the type
+	InvalidValueCondition(col ColumnDefinition) string
+}
+
+// SchemaStore keeps the metadata of user tables and runs their DDL for one
+// database engine. The SchemaManager validates requests and decides what
+// to change; the store persists it.
+type SchemaStore interface {
+	// Dialect renders the DDL run in the store's transactions
+	Dialect() DDLDialect
+	// Tx runs fn in a transaction, retried on serialization failures
+	Tx(ctx context.Context, fn func(tx StoreTx) error) error
+	// TableExists reports whether a user table has the machine name
+	TableExists(ctx context.Context, tableName string) (bool, error)
+	// GetTable returns a table with its columns, or ErrTableNotFound
+	GetTable(ctx context.Context, tableID int) (*TableDefinition, error)
+	// ListTables returns the tables selected by query, without columns,
+	// and the number of tables matching its filter
+	ListTables(ctx context.Context, query TableQuery) ([]TableDefinition, int, error)
+	// TableIDs returns the ID of every table outside the trash, oldest
+	// first
+	TableIDs(ctx context.Context) ([]int, error)
+	// ListTrash returns the tables in the trash, without columns, most
+	// recently deleted first. A non-zero before only returns tables
+	// deleted earlier.
+	ListTrash(ctx context.Context, before time.Time) ([]TableDefinition, error)
+	// ListChanges returns entries of the schema change log
+	ListChanges(ctx context.Context, opts ListChangesOptions) ([]SchemaChange, error)
+	// InsertChangeRequest stores a pending change request and returns its
+	// ID
+	InsertChangeRequest(ctx context.Context, change ChangeRequest) (int64, error)
+	// GetChangeRequest returns a change request, or
+	// ErrChangeRequestNotFound
+	GetChangeRequest(ctx context.Context, id int64) (*ChangeRequest, error)
+	// ListChangeRequests returns change requests, newest first
+	ListChangeRequests(ctx context.Context, opts ListChangeRequestsOptions) ([]ChangeRequest, error)
+	// ReviewChangeRequest moves a pending change request to status, or
+	// returns ErrChangeRequestReviewed when it is missing or not pending
+	ReviewChangeRequest(ctx context.Context, id int64, status, reviewedBy string, comment *string) error
+	// CompleteChangeRequest records the outcome of replaying an approved
+	// change request: the table it created, or the error failing it
+	CompleteChangeRequest(ctx context.Context, id int64, tableID *int, errorMessage *string) error
+}
+
+// StoreTx changes metadata and runs DDL in one transaction
+type StoreTx interface {
+	// InsertTable registers a table and returns its ID
+	InsertTable(ctx context.Context, table TableDefinition) (int, error)
+	// InsertColumn registers a column of a table and returns its ID
+	InsertColumn(ctx context.Context, tableID int, col ColumnDefinition) (int, error)
+	// TableName returns the machine name of a table outside the trash, or
+	// ErrTableNotFound
+	TableName(ctx context.Context, tableID int) (string, error)
+	// LockTable locks the metadata of a table outside the trash until the
+	// transaction ends and returns its names, or ErrTableNotFound
+	LockTable(ctx context.Context, tableID int) (name, tableName string, err error)
+	// LockTrashedTable is LockTable for tables in the trash
+	LockTrashedTable(ctx context.Context, tableID int) (name, tableName string, err error)
+	// SetTrashed marks a table as deleted by deletedBy, or restores it
+	// when deletedBy is nil
+	SetTrashed(ctx context.Context, tableID int, deletedBy *string) error
+	// ReferencingTables returns the names of other tables with relation
+	// columns pointing at a table
+	ReferencingTables(ctx context.Context, tableID int) ([]string, error)
+	// DeleteTable removes a table's metadata and its columns'
+	DeleteTable(ctx context.Context, tableID int) error
+	// ExecDDL runs statements rendered by the store's dialect
+	ExecDDL(ctx context.Context, sql string) error
+	// LogChange appends an entry to the schema change log
+	LogChange(ctx context.Context, change SchemaChange) error
+}
+
+// TableQuery selects the tables returned by SchemaStore.ListTables
+type TableQuery struct {
+	NamePrefix string    // Case-insensitive prefix of the display name, matched literally
+	Sort       TableSort // One of the sorts in tableSortClauses
+	Limit      int
+	Offset     int
+}
+
+// VectorStore is implemented by stores that can search vector columns
+type VectorStore interface {
+	// SemanticSearch returns the rows of table closest to req.Vector in
+	// the vector column, with filters already checked against the table
+	SemanticSearch(ctx context.Context, table *TableDefinition, req SemanticSearchRequest) ([]SearchResult, error)
+}
@@ -0,0 +1,635 @@
+package schema_manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"agentic-template/api/db"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PostgresStore keeps user tables in PostgreSQL, the default store
+type PostgresStore struct {
+	pool     *pgxpool.Pool
+	readPool *pgxpool.Pool // Replica for reads that tolerate lag; nil reads from pool
+}
+
+// Statically assert that PostgresStore implements the store interfaces
+var (
+	_ SchemaStore = &PostgresStore{}
+	_ VectorStore = &PostgresStore{}
+)
+
+// NewPostgresStore creates a store on a pool
+func NewPostgresStore(pool *pgxpool.Pool) *PostgresStore {
+	return &PostgresStore{pool: pool}
+}
+
+// reader returns the pool for reads that tolerate replication lag
+func (s *PostgresStore) reader() *pgxpool.Pool {
+	if s.readPool != nil {
+		return s.readPool
+	}
+	return s.pool
+}
+
+// Dialect returns the PostgreSQL dialect
+func (s *PostgresStore) Dialect() DDLDialect {
+	return PostgresDialect{}
+}
+
+// Tx runs fn in a transaction, retried on serialization failures
+func (s *PostgresStore) Tx(ctx context.Context, fn func(tx StoreTx) error) error {
+	return db.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
+		return fn(&postgresTx{tx: tx})
+	})
+}
+
+// TableExists checks if a table with the given name already exists
+func (s *PostgresStore) TableExists(ctx context.Context, tableName string) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS(SELECT 1 FROM configurable_tables WHERE table_name = $1)`
+	err := queryRow(ctx, s.pool, query, tableName).Scan(&exists)
+	return exists, err
+}
+
+// GetTable retrieves a table definition by ID
+func (s *PostgresStore) GetTable(ctx context.Context, tableID int) (*TableDefinition, error) {
+	// Query the table metadata
+	var tableDef TableDefinition
+	var naming []byte
+	query := `
+		SELECT id, name, table_name, description, created_at, updated_at, created_by, naming
+		FROM configurable_tables
+		WHERE id = $1 AND deleted_at IS NULL
+	`
+	err := queryRow(ctx, s.reader(), query, tableID).Scan(
+		&tableDef.ID,
+		&tableDef.Name,
+		&tableDef.TableName,
+		&tableDef.Description,
+		&tableDef.CreatedAt,
+		&tableDef.UpdatedAt,
+		&tableDef.CreatedBy,
+		&naming,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrTableNotFound
+		}
+		return nil, fmt.Errorf("failed to query table: %w", err)
+	}
+	if tableDef.Naming, err = decodeNaming(naming); err != nil {
+		return nil, err
+	}
+
+	// Query the columns
+	columnsQuery := `
+		SELECT id, name, column_name, data_type, postgres_type, is_nullable, is_unique,
+		       default_value, foreign_key_to_table_id, display_order, vector_dimensions, vector_index_type,
+		       format_timezone, format_date, format_number_locale, format_currency, format_currency_display, formula,
+		       deny_read_roles, deny_write_roles, description
+		FROM configurable_columns
+		WHERE table_id = $1
+		ORDER BY display_order
+	`
+	queryCtx, cancel := db.StatementContext(ctx)
+	defer cancel()
+	rows, err := s.reader().Query(queryCtx, columnsQuery, tableID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query columns: %w", err)
+	}
+	defer rows.Close()
+
+	columns := []ColumnDefinition{}
+	for rows.Next() {
+		var col ColumnDefinition
+		var format ColumnFormat
+		var denyRead, denyWrite *string
+		err := rows.Scan(
+			&col.ID,
+			&col.Name,
+			&col.ColumnName,
+			&col.DataType,
+			&col.PostgresType,
+			&col.IsNullable,
+			&col.IsUnique,
+			&col.DefaultValue,
+			&col.ForeignKeyToTableID,
+			&col.DisplayOrder,
+			&col.VectorDimensions,
+			&col.VectorIndexType,
+			&format.Timezone,
+			&format.DateFormat,
+			&format.NumberLocale,
+			&format.Currency,
+			&format.CurrencyDisplay,
+			&col.Formula,
+			&denyRead,
+			&denyWrite,
+			&col.Description,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan column: %w", err)
+		}
+		if !format.IsZero() {
+			col.Format = &format
+		}
+		col.Permissions = columnPermissions(denyRead, denyWrite)
+		columns = append(columns, col)
+	}
+
+	tableDef.Columns = columns
+	return &tableDef, nil
+}
+
+// ListTables returns the tables selected by query and the number matching
+// its filter
+func (s *PostgresStore) ListTables(ctx context.Context, q TableQuery) ([]TableDefinition, int, error) {
+	query := `
+		SELECT id, name, table_name, description, created_at, updated_at, created_by, naming, COUNT(*) OVER ()
+		FROM configurable_tables
+		WHERE deleted_at IS NULL`
+	args := []interface{}{}
+	if q.NamePrefix != "" {
+		args = append(args, escapeLikePattern(q.NamePrefix)+"%")
+		query += fmt.Sprintf(" AND name ILIKE $%d", len(args))
+	}
+	args = append(args, q.Limit, q.Offset)
+	query += fmt.Sprintf(" ORDER BY %s LIMIT $%d OFFSET $%d", tableSortClauses[q.Sort], len(args)-1, len(args))
+
+	queryCtx, cancel := db.StatementContext(ctx)
+	defer cancel()
+	rows, err := s.reader().Query(queryCtx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query tables: %w", err)
+	}
+	defer rows.Close()
+
+	tables := []TableDefinition{}
+	var total int
+	for rows.Next() {
+		var table TableDefinition
+		var naming []byte
+		err := rows.Scan(
+			&table.ID,
+			&table.Name,
+			&table.TableName,
+			&table.Description,
+			&table.CreatedAt,
+			&table.UpdatedAt,
+			&table.CreatedBy,
+			&naming,
+			&total,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan table: %w", err)
+		}
+		if table.Naming, err = decodeNaming(naming); err != nil {
+			return nil, 0, err
+		}
+		tables = append(tables, table)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to query tables: %w", err)
+	}
+	return tables, total, nil
+}
+
+// TableIDs returns the ID of every table outside the trash, oldest first
+func (s *PostgresStore) TableIDs(ctx context.Context) ([]int, error) {
+	rows, err := s.reader().Query(ctx, `SELECT id FROM configurable_tables WHERE deleted_at IS NULL ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan table: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+	return ids, nil
+}
+
+// ListTrash returns the tables in the trash, most recently deleted first
+func (s *PostgresStore) ListTrash(ctx context.Context, before time.Time) ([]TableDefinition, error) {
+	query := `
+		SELECT id, name, table_name, description, created_at, updated_at, created_by, deleted_at, deleted_by, naming
+		FROM configurable_tables
+		WHERE deleted_at IS NOT NULL AND ($1::TIMESTAMPTZ IS NULL OR deleted_at < $1)
+		ORDER BY deleted_at DESC
+	`
+	var beforeArg *time.Time
+	if !before.IsZero() {
+		beforeArg = &before
+	}
+
+	queryCtx, cancel := db.StatementContext(ctx)
+	defer cancel()
+	rows, err := s.pool.Query(queryCtx, query, beforeArg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query trash: %w", err)
+	}
+	defer rows.Close()
+
+	tables := []TableDefinition{}
+	for rows.Next() {
+		var table TableDefinition
+		var naming []byte
+		err := rows.Scan(
+			&table.ID,
+			&table.Name,
+			&table.TableName,
+			&table.Description,
+			&table.CreatedAt,
+			&table.UpdatedAt,
+			&table.CreatedBy,
+			&table.DeletedAt,
+			&table.DeletedBy,
+			&naming,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan table: %w", err)
+		}
+		if table.Naming, err = decodeNaming(naming); err != nil {
+			return nil, err
+		}
+		tables = append(tables, table)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query trash: %w", err)
+	}
+	return tables, nil
+}
+
+// ListChanges returns entries of the schema change log
+func (s *PostgresStore) ListChanges(ctx context.Context, opts ListChangesOptions) ([]SchemaChange, error) {
+	query := `
+		SELECT id, table_id, change_type, change_details::TEXT, executed_sql, status, error_message, created_at, created_by
+		FROM schema_change_log
+		WHERE id > $1`
+	args := []interface{}{opts.AfterID}
+	if opts.TableID != nil {
+		args = append(args, *opts.TableID)
+		query += fmt.Sprintf(" AND table_id = $%d", len(args))
+	}
+	order := "DESC"
+	if opts.AfterID > 0 {
+		order = "ASC"
+	}
+	args = append(args, opts.PageSize)
+	query += fmt.Sprintf(" ORDER BY id %s LIMIT $%d", order, len(args))
+
+	queryCtx, cancel := db.StatementContext(ctx)
+	defer cancel()
+	rows, err := s.reader().Query(queryCtx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query schema changes: %w", err)
+	}
+	defer rows.Close()
+
+	changes := []SchemaChange{}
+	for rows.Next() {
+		var change SchemaChange
+		err := rows.Scan(
+			&change.ID,
+			&change.TableID,
+			&change.ChangeType,
+			&change.ChangeDetails,
+			&change.ExecutedSQL,
+			&change.Status,
+			&change.ErrorMessage,
+			&change.CreatedAt,
+			&change.CreatedBy,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan schema change: %w", err)
+		}
+		changes = append(changes, change)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query schema changes: %w", err)
+	}
+	return changes, nil
+}
+
+// changeRequestColumns are the columns scanned by scanChangeRequest
+const changeRequestColumns = `id, change_type, table_id, change_details::TEXT, status, requested_by, reviewed_by,
+		       review_comment, error_message, created_at, reviewed_at`
+
+// InsertChangeRequest stores a pending change request
+func (s *PostgresStore) InsertChangeRequest(ctx context.Context, change ChangeRequest) (int64, error) {
+	var id int64
+	query := `
+		INSERT INTO schema_change_requests (change_type, table_id, change_details, requested_by)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`
+	err := queryRow(ctx, s.pool, query, change.ChangeType, change.TableID, change.ChangeDetails, change.RequestedBy).Scan(&id)
+	return id, err
+}
+
+// GetChangeRequest returns a change request. It reads from the primary,
+// since requests are read right after they change.
+func (s *PostgresStore) GetChangeRequest(ctx context.Context, id int64) (*ChangeRequest, error) {
+	query := `SELECT ` + changeRequestColumns + ` FROM schema_change_requests WHERE id = $1`
+	change, err := scanChangeRequest(queryRow(ctx, s.pool, query, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrChangeRequestNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query change request: %w", err)
+	}
+	return change, nil
+}
+
+// ListChangeRequests returns change requests, newest first
+func (s *PostgresStore) ListChangeRequests(ctx context.Context, opts ListChangeRequestsOptions) ([]ChangeRequest, error) {
+	query := `SELECT ` + changeRequestColumns + ` FROM schema_change_requests WHERE true`
+	var args []interface{}
+	if opts.BeforeID > 0 {
+		args = append(args, opts.BeforeID)
+		query += fmt.Sprintf(" AND id < $%d", len(args))
+	}
+	if opts.Status != "" {
+		args = append(args, opts.Status)
+		query += fmt.Sprintf(" AND status = $%d", len(args))
+	}
+	args = append(args, opts.PageSize)
+	query += fmt.Sprintf(" ORDER BY id DESC LIMIT $%d", len(args))
+
+	queryCtx, cancel := db.StatementContext(ctx)
+	defer cancel()
+	rows, err := s.reader().Query(queryCtx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query change requests: %w", err)
+	}
+	defer rows.Close()
+
+	changes := []ChangeRequest{}
+	for rows.Next() {
+		change, err := scanChangeRequest(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan change request: %w", err)
+		}
+		changes = append(changes, *change)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query change requests: %w", err)
+	}
+	return changes, nil
+}
+
+// ReviewChangeRequest moves a pending change request to status
+func (s *PostgresStore) ReviewChangeRequest(ctx context.Context, id int64, status, reviewedBy string, comment *string) error {
+	query := `
+		UPDATE schema_change_requests
+		SET status = $2, reviewed_by = $3, review_comment = $4, reviewed_at = NOW()
+		WHERE id = $1 AND status = 'pending'
+	`
+	ctx, cancel := db.StatementContext(ctx)
+	defer cancel()
+	tag, err := s.pool.Exec(ctx, query, id, status, reviewedBy, comment)
+	if err != nil {
+		return fmt.Errorf("failed to review change request: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrChangeRequestReviewed
+	}
+	return nil
+}
+
+// CompleteChangeRequest records the outcome of replaying a change request
+func (s *PostgresStore) CompleteChangeRequest(ctx context.Context, id int64, tableID *int, errorMessage *string) error {
+	query := `
+		UPDATE schema_change_requests
+		SET table_id = COALESCE($2, table_id),
+		    error_message = $3,
+		    status = CASE WHEN $3::TEXT IS NULL THEN status ELSE 'failed' END
+		WHERE id = $1
+	`
+	return exec(ctx, s.pool, query, id, tableID, errorMessage)
+}
+
+// scanChangeRequest reads a row of changeRequestColumns
+func scanChangeRequest(row pgx.Row) (*ChangeRequest, error) {
+	var change ChangeRequest
+	err := row.Scan(
+		&change.ID,
+		&change.ChangeType,
+		&change.TableID,
+		&change.ChangeDetails,
+		&change.Status,
+		&change.RequestedBy,
+		&change.ReviewedBy,
+		&change.ReviewComment,
+		&change.ErrorMessage,
+		&change.CreatedAt,
+		&change.ReviewedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &change, nil
+}
+
+// postgresTx is a transaction of a PostgresStore
+type postgresTx struct {
+	tx pgx.Tx
+}
+
+// InsertTable registers a table in configurable_tables
+func (t *postgresTx) InsertTable(ctx context.Context, table TableDefinition) (int, error) {
+	var tableID int
+	query := `
+		INSERT INTO configurable_tables (name, table_name, description, created_by, naming)
+		VALUES ($1, $2, $3, $4, $5::JSONB)
+		RETURNING id
+	`
+	err := queryRow(ctx, t.tx, query, table.Name, table.TableName, table.Description, table.CreatedBy,
+		encodeNaming(table.Naming)).Scan(&tableID)
+	return tableID, err
+}
+
+// InsertColumn registers a column in configurable_columns
+func (t *postgresTx) InsertColumn(ctx context.Context, tableID int, col ColumnDefinition) (int, error) {
+	var colID int
+	format := col.Format
+	if format == nil {
+		format = &ColumnFormat{}
+	}
+	perms := col.Permissions
+	if perms == nil {
+		perms = &ColumnPermissions{}
+	}
+	query := `
+		INSERT INTO configurable_columns
+		(table_id, name, column_name, data_type, postgres_type, is_nullable, is_unique, default_value, foreign_key_to_table_id, display_order,
+		 vector_dimensions, vector_index_type,
+		 format_timezone, format_date, format_number_locale, format_currency, format_currency_display, formula,
+		 deny_read_roles, deny_write_roles, description)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21)
+		RETURNING id
+	`
+	err := queryRow(ctx, t.tx, query,
+		tableID,
+		col.Name,
+		col.ColumnName,
+		col.DataType,
+		col.PostgresType,
+		col.IsNullable,
+		col.IsUnique,
+		col.DefaultValue,
+		col.ForeignKeyToTableID,
+		col.DisplayOrder,
+		col.VectorDimensions,
+		col.VectorIndexType,
+		format.Timezone,
+		format.DateFormat,
+		format.NumberLocale,
+		format.Currency,
+		format.CurrencyDisplay,
+		col.Formula,
+		joinRoles(perms.DenyRead),
+		joinRoles(perms.DenyWrite),
+		col.Description,
+	).Scan(&colID)
+	return colID, err
+}
+
+// TableName returns the machine name of a table
+func (t *postgresTx) TableName(ctx context.Context, tableID int) (string, error) {
+	var tableName string
+	err := queryRow(ctx, t.tx, "SELECT table_name FROM configurable_tables WHERE id = $1 AND deleted_at IS NULL", tableID).Scan(&tableName)
+	if err == pgx.ErrNoRows {
+		return "", ErrTableNotFound
+	}
+	return tableName, err
+}
+
+// LockTable locks a table's metadata row
+func (t *postgresTx) LockTable(ctx context.Context, tableID int) (string, string, error) {
+	var name, tableName string
+	query := `SELECT name, table_name FROM configurable_tables WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`
+	err := queryRow(ctx, t.tx, query, tableID).Scan(&name, &tableName)
+	if err == pgx.ErrNoRows {
+		return "", "", ErrTableNotFound
+	}
+	return name, tableName, err
+}
+
+// LockTrashedTable locks the metadata row of a table in the trash
+func (t *postgresTx) LockTrashedTable(ctx context.Context, tableID int) (string, string, error) {
+	var name, tableName string
+	query := `SELECT name, table_name FROM configurable_tables WHERE id = $1 AND deleted_at IS NOT NULL FOR UPDATE`
+	err := queryRow(ctx, t.tx, query, tableID).Scan(&name, &tableName)
+	if err == pgx.ErrNoRows {
+		return "", "", ErrTableNotFound
+	}
+	return name, tableName, err
+}
+
+// SetTrashed moves a table's metadata to the trash, recording who deleted
+// it, or back out of it when deletedBy is nil
+func (t *postgresTx) SetTrashed(ctx context.Context, tableID int, deletedBy *string) error {
+	query := `
+		UPDATE configurable_tables
+		SET deleted_at = CASE WHEN $2::TEXT IS NULL THEN NULL ELSE NOW() END, deleted_by = $2
+		WHERE id = $1
+	`
+	return exec(ctx, t.tx, query, tableID, deletedBy)
+}
+
+// ReferencingTables returns the names of other tables with relation columns
+// pointing at a table
+func (t *postgresTx) ReferencingTables(ctx context.Context, tableID int) ([]string, error) {
+	query := `
+		SELECT DISTINCT t.name
+		FROM configurable_columns c
+		JOIN configurable_tables t ON t.id = c.table_id
+		WHERE c.foreign_key_to_table_id = $1 AND c.table_id <> $1
+		ORDER BY t.name
+	`
+	queryCtx, cancel := db.StatementContext(ctx)
+	defer cancel()
+	rows, err := t.tx.Query(queryCtx, query, tableID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+// DeleteTable removes a table's metadata; its columns cascade
+func (t *postgresTx) DeleteTable(ctx context.Context, tableID int) error {
+	return exec(ctx, t.tx, `DELETE FROM configurable_tables WHERE id = $1`, tableID)
+}
+
+// ExecDDL runs DDL in the transaction
+func (t *postgresTx) ExecDDL(ctx context.Context, sql string) error {
+	return exec(ctx, t.tx, sql)
+}
+
+// LogChange records a schema change in schema_change_log
+func (t *postgresTx) LogChange(ctx context.Context, change SchemaChange) error {
+	query := `
+		INSERT INTO schema_change_log (table_id, change_type, change_details, executed_sql, status, error_message, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+	return exec(ctx, t.tx, query, change.TableID, change.ChangeType, change.ChangeDetails, change.ExecutedSQL,
+		change.Status, change.ErrorMessage, change.CreatedBy)
+}
+
+// querier is the part of a pool or transaction used to run statements
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
+// exec runs a statement bounded by the statement timeout
+func exec(ctx context.Context, q querier, sql string, args ...interface{}) error {
+	ctx, cancel := db.StatementContext(ctx)
+	defer cancel()
+	_, err := q.Exec(ctx, sql, args...)
+	return err
+}
+
+// queryRow runs a single-row query bounded by the statement timeout. The
+// row is scanned before the statement's context is released.
+func queryRow(ctx context.Context, q querier, sql string, args ...interface{}) pgx.Row {
+	ctx, cancel := db.StatementContext(ctx)
+	return &boundedRow{row: q.QueryRow(ctx, sql, args...), cancel: cancel}
+}
+
+// boundedRow releases its statement context once scanned
+type boundedRow struct {
+	row    pgx.Row
+	cancel context.CancelFunc
+}
+
+// Scan reads the row and releases the statement context
+func (r *boundedRow) Scan(dest ...interface{}) error {
+	defer r.cancel()
+	return r.row.Scan(dest...)
+}
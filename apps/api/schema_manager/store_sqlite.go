@@ -0,0 +1,630 @@
+package schema_manager
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"agentic-template/api/db"
+)
+
+// SQLiteStore keeps user tables in a SQLite file, for running the server
+// locally without PostgreSQL. The database must be opened by db/sqlite,
+// which creates the metadata tables and starts transactions holding the
+// write lock, so concurrent schema changes are serialized.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// Statically assert that SQLiteStore implements SchemaStore
+var _ SchemaStore = &SQLiteStore{}
+
+// NewSQLiteStore creates a store on a SQLite database
+func NewSQLiteStore(database *sql.DB) *SQLiteStore {
+	return &SQLiteStore{db: database}
+}
+
+// Dialect returns the SQLite dialect
+func (s *SQLiteStore) Dialect() DDLDialect {
+	return SQLiteDialect{}
+}
+
+// Tx runs fn in a transaction. SQLite runs DDL transactionally, so a failed
+// change leaves neither the table nor its metadata behind.
+func (s *SQLiteStore) Tx(ctx context.Context, fn func(tx StoreTx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	if err := fn(&sqliteTx{tx: tx}); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// TableExists checks if a table with the given name already exists
+func (s *SQLiteStore) TableExists(ctx context.Context, tableName string) (bool, error) {
+	ctx, cancel := db.StatementContext(ctx)
+	defer cancel()
+	var exists bool
+	query := `SELECT EXISTS(SELECT 1 FROM configurable_tables WHERE table_name = ?)`
+	err := s.db.QueryRowContext(ctx, query, tableName).Scan(&exists)
+	return exists, err
+}
+
+// GetTable retrieves a table definition by ID
+func (s *SQLiteStore) GetTable(ctx context.Context, tableID int) (*TableDefinition, error) {
+	ctx, cancel := db.StatementContext(ctx)
+	defer cancel()
+
+	var tableDef TableDefinition
+	var naming []byte
+	query := `
+		SELECT id, name, table_name, description, created_at, updated_at, created_by, naming
+		FROM configurable_tables
+		WHERE id = ? AND deleted_at IS NULL
+	`
+	err := s.db.QueryRowContext(ctx, query, tableID).Scan(
+		&tableDef.ID,
+		&tableDef.Name,
+		&tableDef.TableName,
+		&tableDef.Description,
+		&tableDef.CreatedAt,
+		&tableDef.UpdatedAt,
+		&tableDef.CreatedBy,
+		&naming,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrTableNotFound
+		}
+		return nil, fmt.Errorf("failed to query table: %w", err)
+	}
+	if tableDef.Naming, err = decodeNaming(naming); err != nil {
+		return nil, err
+	}
+
+	columnsQuery := `
+		SELECT id, name, column_name, data_type, postgres_type, is_nullable, is_unique,
+		       default_value, foreign_key_to_table_id, display_order, vector_dimensions, vector_index_type,
+		       format_timezone, format_date, format_number_locale, format_currency, format_currency_display, formula,
+		       deny_read_roles, deny_write_roles, description
+		FROM configurable_columns
+		WHERE table_id = ?
+		ORDER BY display_order
+	`
+	rows, err := s.db.QueryContext(ctx, columnsQuery, tableID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query columns: %w", err)
+	}
+	defer rows.Close()
+
+	columns := []ColumnDefinition{}
+	for rows.Next() {
+		var col ColumnDefinition
+		var format ColumnFormat
+		var denyRead, denyWrite *string
+		err := rows.Scan(
+			&col.ID,
+			&col.Name,
+			&col.ColumnName,
+			&col.DataType,
+			&col.PostgresType,
+			&col.IsNullable,
+			&col.IsUnique,
+			&col.DefaultValue,
+			&col.ForeignKeyToTableID,
+			&col.DisplayOrder,
+			&col.VectorDimensions,
+			&col.VectorIndexType,
+			&format.Timezone,
+			&format.DateFormat,
+			&format.NumberLocale,
+			&format.Currency,
+			&format.CurrencyDisplay,
+			&col.Formula,
+			&denyRead,
+			&denyWrite,
+			&col.Description,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan column: %w", err)
+		}
+		if !format.IsZero() {
+			col.Format = &format
+		}
+		col.Permissions = columnPermissions(denyRead, denyWrite)
+		columns = append(columns, col)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query columns: %w", err)
+	}
+
+	tableDef.Columns = columns
+	return &tableDef, nil
+}
+
+// ListTables returns the tables selected by query and the number matching
+// its filter. LIKE ignores ASCII case in SQLite, like ILIKE.
+func (s *SQLiteStore) ListTables(ctx context.Context, q TableQuery) ([]TableDefinition, int, error) {
+	query := `
+		SELECT id, name, table_name, description, created_at, updated_at, created_by, naming, COUNT(*) OVER ()
+		FROM configurable_tables
+		WHERE deleted_at IS NULL`
+	args := []interface{}{}
+	if q.NamePrefix != "" {
+		args = append(args, escapeLikePattern(q.NamePrefix)+"%")
+		query += ` AND name LIKE ? ESCAPE '\'`
+	}
+	args = append(args, q.Limit, q.Offset)
+	query += fmt.Sprintf(" ORDER BY %s LIMIT ? OFFSET ?", tableSortClauses[q.Sort])
+
+	ctx, cancel := db.StatementContext(ctx)
+	defer cancel()
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query tables: %w", err)
+	}
+	defer rows.Close()
+
+	tables := []TableDefinition{}
+	var total int
+	for rows.Next() {
+		var table TableDefinition
+		var naming []byte
+		err := rows.Scan(
+			&table.ID,
+			&table.Name,
+			&table.TableName,
+			&table.Description,
+			&table.CreatedAt,
+			&table.UpdatedAt,
+			&table.CreatedBy,
+			&naming,
+			&total,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan table: %w", err)
+		}
+		if table.Naming, err = decodeNaming(naming); err != nil {
+			return nil, 0, err
+		}
+		tables = append(tables, table)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to query tables: %w", err)
+	}
+	return tables, total, nil
+}
+
+// TableIDs returns the ID of every table outside the trash, oldest first
+func (s *SQLiteStore) TableIDs(ctx context.Context) ([]int, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id FROM configurable_tables WHERE deleted_at IS NULL ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan table: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+	return ids, nil
+}
+
+// ListTrash returns the tables in the trash, most recently deleted first
+func (s *SQLiteStore) ListTrash(ctx context.Context, before time.Time) ([]TableDefinition, error) {
+	query := `
+		SELECT id, name, table_name, description, created_at, updated_at, created_by, deleted_at, deleted_by, naming
+		FROM configurable_tables
+		WHERE deleted_at IS NOT NULL AND (? IS NULL OR deleted_at < ?)
+		ORDER BY deleted_at DESC
+	`
+	var beforeArg *time.Time
+	if !before.IsZero() {
+		utc := before.UTC()
+		beforeArg = &utc
+	}
+
+	ctx, cancel := db.StatementContext(ctx)
+	defer cancel()
+	rows, err := s.db.QueryContext(ctx, query, beforeArg, beforeArg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query trash: %w", err)
+	}
+	defer rows.Close()
+
+	tables := []TableDefinition{}
+	for rows.Next() {
+		var table TableDefinition
+		var naming []byte
+		err := rows.Scan(
+			&table.ID,
+			&table.Name,
+			&table.TableName,
+			&table.Description,
+			&table.CreatedAt,
+			&table.UpdatedAt,
+			&table.CreatedBy,
+			&table.DeletedAt,
+			&table.DeletedBy,
+			&naming,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan table: %w", err)
+		}
+		if table.Naming, err = decodeNaming(naming); err != nil {
+			return nil, err
+		}
+		tables = append(tables, table)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query trash: %w", err)
+	}
+	return tables, nil
+}
+
+// ListChanges returns entries of the schema change log
+func (s *SQLiteStore) ListChanges(ctx context.Context, opts ListChangesOptions) ([]SchemaChange, error) {
+	query := `
+		SELECT id, table_id, change_type, change_details, executed_sql, status, error_message, created_at, created_by
+		FROM schema_change_log
+		WHERE id > ?`
+	args := []interface{}{opts.AfterID}
+	if opts.TableID != nil {
+		args = append(args, *opts.TableID)
+		query += " AND table_id = ?"
+	}
+	order := "DESC"
+	if opts.AfterID > 0 {
+		order = "ASC"
+	}
+	args = append(args, opts.PageSize)
+	query += fmt.Sprintf(" ORDER BY id %s LIMIT ?", order)
+
+	ctx, cancel := db.StatementContext(ctx)
+	defer cancel()
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query schema changes: %w", err)
+	}
+	defer rows.Close()
+
+	changes := []SchemaChange{}
+	for rows.Next() {
+		var change SchemaChange
+		err := rows.Scan(
+			&change.ID,
+			&change.TableID,
+			&change.ChangeType,
+			&change.ChangeDetails,
+			&change.ExecutedSQL,
+			&change.Status,
+			&change.ErrorMessage,
+			&change.CreatedAt,
+			&change.CreatedBy,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan schema change: %w", err)
+		}
+		changes = append(changes, change)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query schema changes: %w", err)
+	}
+	return changes, nil
+}
+
+// sqliteChangeRequestColumns are the columns scanned by
+// scanSQLiteChangeRequest
+const sqliteChangeRequestColumns = `id, change_type, table_id, change_details, status, requested_by, reviewed_by,
+		       review_comment, error_message, created_at, reviewed_at`
+
+// InsertChangeRequest stores a pending change request
+func (s *SQLiteStore) InsertChangeRequest(ctx context.Context, change ChangeRequest) (int64, error) {
+	ctx, cancel := db.StatementContext(ctx)
+	defer cancel()
+	var id int64
+	query := `
+		INSERT INTO schema_change_requests (change_type, table_id, change_details, requested_by)
+		VALUES (?, ?, ?, ?)
+		RETURNING id
+	`
+	err := s.db.QueryRowContext(ctx, query, change.ChangeType, change.TableID, change.ChangeDetails, change.RequestedBy).Scan(&id)
+	return id, err
+}
+
+// GetChangeRequest returns a change request
+func (s *SQLiteStore) GetChangeRequest(ctx context.Context, id int64) (*ChangeRequest, error) {
+	ctx, cancel := db.StatementContext(ctx)
+	defer cancel()
+	query := `SELECT ` + sqliteChangeRequestColumns + ` FROM schema_change_requests WHERE id = ?`
+	change, err := scanSQLiteChangeRequest(s.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrChangeRequestNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query change request: %w", err)
+	}
+	return change, nil
+}
+
+// ListChangeRequests returns change requests, newest first
+func (s *SQLiteStore) ListChangeRequests(ctx context.Context, opts ListChangeRequestsOptions) ([]ChangeRequest, error) {
+	query := `SELECT ` + sqliteChangeRequestColumns + ` FROM schema_change_requests WHERE 1 = 1`
+	var args []interface{}
+	if opts.BeforeID > 0 {
+		args = append(args, opts.BeforeID)
+		query += " AND id < ?"
+	}
+	if opts.Status != "" {
+		args = append(args, opts.Status)
+		query += " AND status = ?"
+	}
+	args = append(args, opts.PageSize)
+	query += " ORDER BY id DESC LIMIT ?"
+
+	ctx, cancel := db.StatementContext(ctx)
+	defer cancel()
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query change requests: %w", err)
+	}
+	defer rows.Close()
+
+	changes := []ChangeRequest{}
+	for rows.Next() {
+		change, err := scanSQLiteChangeRequest(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan change request: %w", err)
+		}
+		changes = append(changes, *change)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query change requests: %w", err)
+	}
+	return changes, nil
+}
+
+// ReviewChangeRequest moves a pending change request to status
+func (s *SQLiteStore) ReviewChangeRequest(ctx context.Context, id int64, status, reviewedBy string, comment *string) error {
+	ctx, cancel := db.StatementContext(ctx)
+	defer cancel()
+	query := `
+		UPDATE schema_change_requests
+		SET status = ?, reviewed_by = ?, review_comment = ?, reviewed_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND status = 'pending'
+	`
+	result, err := s.db.ExecContext(ctx, query, status, reviewedBy, comment, id)
+	if err != nil {
+		return fmt.Errorf("failed to review change request: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return ErrChangeRequestReviewed
+	}
+	return nil
+}
+
+// CompleteChangeRequest records the outcome of replaying a change request
+func (s *SQLiteStore) CompleteChangeRequest(ctx context.Context, id int64, tableID *int, errorMessage *string) error {
+	ctx, cancel := db.StatementContext(ctx)
+	defer cancel()
+	query := `
+		UPDATE schema_change_requests
+		SET table_id = COALESCE(?, table_id),
+		    error_message = ?,
+		    status = CASE WHEN ? IS NULL THEN status ELSE 'failed' END
+		WHERE id = ?
+	`
+	_, err := s.db.ExecContext(ctx, query, tableID, errorMessage, errorMessage, id)
+	return err
+}
+
+// scanSQLiteChangeRequest reads a row of sqliteChangeRequestColumns
+func scanSQLiteChangeRequest(row interface{ Scan(dest ...any) error }) (*ChangeRequest, error) {
+	var change ChangeRequest
+	err := row.Scan(
+		&change.ID,
+		&change.ChangeType,
+		&change.TableID,
+		&change.ChangeDetails,
+		&change.Status,
+		&change.RequestedBy,
+		&change.ReviewedBy,
+		&change.ReviewComment,
+		&change.ErrorMessage,
+		&change.CreatedAt,
+		&change.ReviewedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &change, nil
+}
+
+// sqliteTx is a transaction of a SQLiteStore
+type sqliteTx struct {
+	tx *sql.Tx
+}
+
+// exec runs a statement bounded by the statement timeout
+func (t *sqliteTx) exec(ctx context.Context, query string, args ...interface{}) error {
+	ctx, cancel := db.StatementContext(ctx)
+	defer cancel()
+	_, err := t.tx.ExecContext(ctx, query, args...)
+	return err
+}
+
+// queryRow runs a single-row query bounded by the statement timeout and
+// scans it into dest
+func (t *sqliteTx) queryRow(ctx context.Context, query string, args []interface{}, dest ...interface{}) error {
+	ctx, cancel := db.StatementContext(ctx)
+	defer cancel()
+	return t.tx.QueryRowContext(ctx, query, args...).Scan(dest...)
+}
+
+// InsertTable registers a table in configurable_tables
+func (t *sqliteTx) InsertTable(ctx context.Context, table TableDefinition) (int, error) {
+	var tableID int
+	query := `
+		INSERT INTO configurable_tables (name, table_name, description, created_by, naming)
+		VALUES (?, ?, ?, ?, ?)
+		RETURNING id
+	`
+	args := []interface{}{table.Name, table.TableName, table.Description, table.CreatedBy, encodeNaming(table.Naming)}
+	err := t.queryRow(ctx, query, args, &tableID)
+	return tableID, err
+}
+
+// InsertColumn registers a column in configurable_columns
+func (t *sqliteTx) InsertColumn(ctx context.Context, tableID int, col ColumnDefinition) (int, error) {
+	var colID int
+	format := col.Format
+	if format == nil {
+		format = &ColumnFormat{}
+	}
+	perms := col.Permissions
+	if perms == nil {
+		perms = &ColumnPermissions{}
+	}
+	query := `
+		INSERT INTO configurable_columns
+		(table_id, name, column_name, data_type, postgres_type, is_nullable, is_unique, default_value, foreign_key_to_table_id, display_order,
+		 vector_dimensions, vector_index_type,
+		 format_timezone, format_date, format_number_locale, format_currency, format_currency_display, formula,
+		 deny_read_roles, deny_write_roles, description)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id
+	`
+	args := []interface{}{
+		tableID,
+		col.Name,
+		col.ColumnName,
+		string(col.DataType),
+		col.PostgresType,
+		col.IsNullable,
+		col.IsUnique,
+		col.DefaultValue,
+		col.ForeignKeyToTableID,
+		col.DisplayOrder,
+		col.VectorDimensions,
+		col.VectorIndexType,
+		format.Timezone,
+		format.DateFormat,
+		format.NumberLocale,
+		format.Currency,
+		format.CurrencyDisplay,
+		col.Formula,
+		joinRoles(perms.DenyRead),
+		joinRoles(perms.DenyWrite),
+		col.Description,
+	}
+	err := t.queryRow(ctx, query, args, &colID)
+	return colID, err
+}
+
+// TableName returns the machine name of a table
+func (t *sqliteTx) TableName(ctx context.Context, tableID int) (string, error) {
+	var tableName string
+	err := t.queryRow(ctx, "SELECT table_name FROM configurable_tables WHERE id = ? AND deleted_at IS NULL", []interface{}{tableID}, &tableName)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrTableNotFound
+	}
+	return tableName, err
+}
+
+// LockTable returns a table's names. The transaction already holds the
+// database's write lock, so no row lock is needed.
+func (t *sqliteTx) LockTable(ctx context.Context, tableID int) (string, string, error) {
+	var name, tableName string
+	query := `SELECT name, table_name FROM configurable_tables WHERE id = ? AND deleted_at IS NULL`
+	err := t.queryRow(ctx, query, []interface{}{tableID}, &name, &tableName)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", "", ErrTableNotFound
+	}
+	return name, tableName, err
+}
+
+// LockTrashedTable returns the names of a table in the trash
+func (t *sqliteTx) LockTrashedTable(ctx context.Context, tableID int) (string, string, error) {
+	var name, tableName string
+	query := `SELECT name, table_name FROM configurable_tables WHERE id = ? AND deleted_at IS NOT NULL`
+	err := t.queryRow(ctx, query, []interface{}{tableID}, &name, &tableName)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", "", ErrTableNotFound
+	}
+	return name, tableName, err
+}
+
+// SetTrashed moves a table's metadata to the trash, recording who deleted
+// it, or back out of it when deletedBy is nil
+func (t *sqliteTx) SetTrashed(ctx context.Context, tableID int, deletedBy *string) error {
+	query := `
+		UPDATE configurable_tables
+		SET deleted_at = CASE WHEN ? IS NULL THEN NULL ELSE CURRENT_TIMESTAMP END, deleted_by = ?
+		WHERE id = ?
+	`
+	return t.exec(ctx, query, deletedBy, deletedBy, tableID)
+}
+
+// ReferencingTables returns the names of other tables with relation columns
+// pointing at a table
+func (t *sqliteTx) ReferencingTables(ctx context.Context, tableID int) ([]string, error) {
+	query := `
+		SELECT DISTINCT t.name
+		FROM configurable_columns c
+		JOIN configurable_tables t ON t.id = c.table_id
+		WHERE c.foreign_key_to_table_id = ? AND c.table_id <> ?
+		ORDER BY t.name
+	`
+	queryCtx, cancel := db.StatementContext(ctx)
+	defer cancel()
+	rows, err := t.tx.QueryContext(queryCtx, query, tableID, tableID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+// DeleteTable removes a table's metadata; its columns cascade
+func (t *sqliteTx) DeleteTable(ctx context.Context, tableID int) error {
+	return t.exec(ctx, `DELETE FROM configurable_tables WHERE id = ?`, tableID)
+}
+
+// ExecDDL runs DDL in the transaction
+func (t *sqliteTx) ExecDDL(ctx context.Context, sql string) error {
+	return t.exec(ctx, sql)
+}
+
+// LogChange records a schema change in schema_change_log
+func (t *sqliteTx) LogChange(ctx context.Context, change SchemaChange) error {
+	query := `
+		INSERT INTO schema_change_log (table_id, change_type, change_details, executed_sql, status, error_message, created_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+	return t.exec(ctx, query, change.TableID, change.ChangeType, change.ChangeDetails, change.ExecutedSQL,
+		change.Status, change.ErrorMessage, change.CreatedBy)
+}
@@ -0,0 +1,54 @@
+package schema_manager
+
+import "slices"
+
+// SystemColumn is a column of every record that the system manages. Users
+// can't define columns of these names or write to them.
+type SystemColumn struct {
+	Name        string   `json:"name"`
+	DataType    DataType `json:"data_type"`
+	Description string   `json:"description"`
+	Reserved    bool     `json:"reserved,omitempty"` // Reserved for a future feature, not yet stored
+}
+
+// systemColumns are the system-managed columns of user tables. Reserved
+// ones aren't created yet, so records don't have them.
+var systemColumns = []SystemColumn{
+	{Name: "id", DataType: DataTypeNumber, Description: "Record ID, assigned on insert"},
+	{Name: "created_at", DataType: DataTypeDate, Description: "When the record was created"},
+	{Name: "updated_at", DataType: DataTypeDate, Description: "When the record last changed, set on every update"},
+	{Name: "deleted_at", DataType: DataTypeDate, Description: "When the record was soft deleted", Reserved: true},
+	{Name: "version", DataType: DataTypeNumber, Description: "Revision for optimistic locking", Reserved: true},
+}
+
+// SystemColumns returns the system-managed columns of user tables
+func SystemColumns() []SystemColumn {
+	return slices.Clone(systemColumns)
+}
+
+// IsSystemColumn reports whether a sanitized column name is system-managed
+func IsSystemColumn(name string) bool {
+	return slices.ContainsFunc(systemColumns, func(col SystemColumn) bool { return col.Name == name })
+}
+
+// storedSystemColumns returns the names of the system columns every record
+// has
+func storedSystemColumns() []string {
+	var names []string
+	for _, col := range systemColumns {
+		if !col.Reserved {
+			names = append(names, col.Name)
+		}
+	}
+	return names
+}
+
+// checkSystemColumnWrite rejects writes to a system-managed column. Tables
+// created before deleted_at and version were reserved may have columns of
+// those names, which stay writable.
+func checkSystemColumnWrite(table *TableDefinition, field, name string) error {
+	if IsSystemColumn(name) && table.column(name) == nil {
+		return invalidField(field, "column '%s' is managed by the system and can't be written", name)
+	}
+	return nil
+}
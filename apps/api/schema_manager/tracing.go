@@ -0,0 +1,34 @@
+package schema_manager
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer creates a span per schema operation, parenting the spans of the
+// queries and DDL it runs
+var tracer = otel.Tracer("agentic-template/api/schema_manager")
+
+// Span attributes of schema operations
+const (
+	attrTableName = attribute.Key("schema.table.name")
+	attrTableID   = attribute.Key("schema.table.id")
+)
+
+// startSpan starts the span of a schema operation
+func startSpan(ctx context.Context, operation string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "schema."+operation, trace.WithAttributes(attrs...))
+}
+
+// endSpan records the outcome of a schema operation and ends its span
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
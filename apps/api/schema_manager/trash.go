@@ -0,0 +1,183 @@
+package schema_manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"agentic-template/api/requestctx"
+)
+
+// TrashPrefix prefixes the physical name of tables in the trash. User
+// tables start with "user_table_" or another naming strategy prefix, which
+// can't overlap it, so trashed names never collide with live ones.
+const TrashPrefix = "trash_"
+
+// TrashSettings configure the trash
+type TrashSettings struct {
+	Enabled   bool          // DeleteTable moves tables to the trash instead of dropping them
+	Retention time.Duration // How long tables stay in the trash before TrashPurger drops them; 0 keeps them
+}
+
+var trashSettings atomic.Pointer[TrashSettings]
+
+func init() {
+	trashSettings.Store(&TrashSettings{})
+}
+
+// SetTrashSettings configures the trash of every tenant
+func SetTrashSettings(settings TrashSettings) {
+	trashSettings.Store(&settings)
+}
+
+// CurrentTrashSettings returns the configuration of the trash
+func CurrentTrashSettings() TrashSettings {
+	return *trashSettings.Load()
+}
+
+// trashTableName returns the physical name of a table while it is in the
+// trash. It only depends on the table's name, never on the current naming
+// strategy, so a table restored after the strategy changed is found.
+func trashTableName(tableName string) string {
+	// Tables named with another prefix keep it, and may not fit
+	naming := NamingStrategy{Truncation: NamingTruncationHash, MaxLength: MaxIdentifierLength}
+	return naming.fit(TrashPrefix + ExtractUserTableName(tableName))
+}
+
+// ListTrash returns the tables in the trash, most recently deleted first
+func (sm *SchemaManager) ListTrash(ctx context.Context) ([]TableDefinition, error) {
+	if sm.store == nil {
+		return nil, ErrDatabaseNotConfigured
+	}
+	return sm.store.ListTrash(ctx, time.Time{})
+}
+
+// RestoreTable moves a table out of the trash under its original name,
+// recording the actor of ctx in the change log. The restored table counts
+// against the table quota again.
+func (sm *SchemaManager) RestoreTable(ctx context.Context, tableID int) error {
+	if sm.store == nil {
+		return ErrDatabaseNotConfigured
+	}
+
+	ctx, span := startSpan(ctx, "restore_table", attrTableID.Int(tableID))
+	err := sm.checkTableQuotas(ctx, 0)
+	if err == nil {
+		err = sm.store.Tx(ctx, func(tx StoreTx) error {
+			return sm.restoreTable(ctx, tx, tableID)
+		})
+	}
+	endSpan(span, err)
+	return err
+}
+
+// restoreTable runs RestoreTable in a transaction
+func (sm *SchemaManager) restoreTable(ctx context.Context, tx StoreTx, tableID int) error {
+	name, tableName, err := tx.LockTrashedTable(ctx, tableID)
+	if errors.Is(err, ErrTableNotFound) {
+		return fmt.Errorf("%w: %d is not in the trash", ErrTableNotFound, tableID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to query table: %w", err)
+	}
+
+	trashName := trashTableName(tableName)
+	if err := ValidateIdentifierSafety(trashName); err != nil {
+		return fmt.Errorf("table name '%s' failed safety check: %w", trashName, err)
+	}
+	renameSQL := sm.store.Dialect().RenameTableSQL(trashName, tableName)
+	details := map[string]interface{}{"table_id": tableID, "name": name, "table_name": tableName}
+	if err := tx.ExecDDL(ctx, renameSQL); err != nil {
+		logSchemaChange(ctx, tx, tableID, "RESTORE_TABLE", details, &renameSQL, "FAILED", err.Error())
+		return fmt.Errorf("failed to restore table: %w", err)
+	}
+	if err := tx.SetTrashed(ctx, tableID, nil); err != nil {
+		return fmt.Errorf("failed to update table metadata: %w", err)
+	}
+
+	if err := logSchemaChange(ctx, tx, tableID, "RESTORE_TABLE", details, &renameSQL, "SUCCESS", ""); err != nil {
+		// Don't fail the transaction, just log the error
+		fmt.Printf("Warning: failed to log schema change: %v\n", err)
+	}
+	return nil
+}
+
+// PurgeTable permanently drops a table in the trash and its metadata
+func (sm *SchemaManager) PurgeTable(ctx context.Context, tableID int) error {
+	if sm.store == nil {
+		return ErrDatabaseNotConfigured
+	}
+
+	ctx, span := startSpan(ctx, "purge_table", attrTableID.Int(tableID))
+	err := sm.store.Tx(ctx, func(tx StoreTx) error {
+		name, tableName, err := tx.LockTrashedTable(ctx, tableID)
+		if errors.Is(err, ErrTableNotFound) {
+			return fmt.Errorf("%w: %d is not in the trash", ErrTableNotFound, tableID)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to query table: %w", err)
+		}
+		return sm.dropTable(ctx, tx, tableID, name, trashTableName(tableName))
+	})
+	endSpan(span, err)
+	return err
+}
+
+// PurgeTrash permanently drops the tables deleted before a time and
+// returns how many were purged. Tables that fail to purge, such as ones
+// still referenced by other trashed tables, are skipped until the next
+// purge.
+func (sm *SchemaManager) PurgeTrash(ctx context.Context, before time.Time) (int, error) {
+	if sm.store == nil {
+		return 0, ErrDatabaseNotConfigured
+	}
+
+	tables, err := sm.store.ListTrash(ctx, before)
+	if err != nil {
+		return 0, err
+	}
+	purged := 0
+	var failures []string
+	for _, table := range tables {
+		if err := sm.PurgeTable(ctx, table.ID); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", table.Name, err))
+			continue
+		}
+		purged++
+	}
+	if len(failures) > 0 {
+		return purged, fmt.Errorf("failed to purge %d table(s): %s", len(failures), strings.Join(failures, "; "))
+	}
+	return purged, nil
+}
+
+// trashTable moves a locked table to the trash by renaming it and marking
+// its metadata as deleted by the actor of ctx
+func (sm *SchemaManager) trashTable(ctx context.Context, tx StoreTx, tableID int, name, tableName string) error {
+	trashName := trashTableName(tableName)
+	if err := ValidateIdentifierSafety(trashName); err != nil {
+		return fmt.Errorf("table name '%s' failed safety check: %w", trashName, err)
+	}
+	renameSQL := sm.store.Dialect().RenameTableSQL(tableName, trashName)
+	details := map[string]interface{}{"table_id": tableID, "name": name, "table_name": tableName, "trash_table_name": trashName}
+	if err := tx.ExecDDL(ctx, renameSQL); err != nil {
+		logSchemaChange(ctx, tx, tableID, "TRASH_TABLE", details, &renameSQL, "FAILED", err.Error())
+		return fmt.Errorf("failed to move table to the trash: %w", err)
+	}
+	actor := requestctx.Actor(ctx)
+	if err := tx.SetTrashed(ctx, tableID, &actor); err != nil {
+		return fmt.Errorf("failed to update table metadata: %w", err)
+	}
+
+	if err := logSchemaChange(ctx, tx, tableID, "TRASH_TABLE", details, &renameSQL, "SUCCESS", ""); err != nil {
+		// Don't fail the transaction, just log the error
+		fmt.Printf("Warning: failed to log schema change: %v\n", err)
+	}
+
+	// Statements of the old name fail from now on
+	recordStatements.forget(tableName)
+	return nil
+}
@@ -0,0 +1,133 @@
+package schema_manager
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"agentic-template/api/db"
+	"agentic-template/api/requestctx"
+	"agentic-template/api/tenancy"
+)
+
+// Trash purger tuning
+const (
+	trashPurgeInterval = time.Hour         // How often expired tables are purged
+	trashPurgeTimeout  = 5 * time.Minute   // Bound on each schema's purge
+	trashPurgeActor    = "trash-retention" // Actor recorded for purged tables
+)
+
+// TrashPurger permanently drops tables that have been in the trash longer
+// than the retention period of TrashSettings, in the shared schema and
+// every tenant schema
+type TrashPurger struct {
+	dbManager *db.Manager
+	stop      chan struct{}
+	wg        sync.WaitGroup
+}
+
+// NewTrashPurger creates a purger and starts purging now and every hour
+func NewTrashPurger(dbManager *db.Manager) *TrashPurger {
+	p := &TrashPurger{
+		dbManager: dbManager,
+		stop:      make(chan struct{}),
+	}
+
+	p.wg.Add(1)
+	go p.purgeLoop()
+	return p
+}
+
+// Close stops the purger, waiting for a running purge to finish until ctx
+// is done
+func (p *TrashPurger) Close(ctx context.Context) error {
+	close(p.stop)
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("trash purge unfinished: %w", ctx.Err())
+	}
+}
+
+// purgeLoop purges expired tables now and every trashPurgeInterval
+func (p *TrashPurger) purgeLoop() {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(trashPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		p.purge()
+		select {
+		case <-ticker.C:
+		case <-p.stop:
+			return
+		}
+	}
+}
+
+// purge drops the tables deleted before the retention period in each
+// schema. Failures are logged and retried on the next purge.
+func (p *TrashPurger) purge() {
+	retention := CurrentTrashSettings().Retention
+	if retention <= 0 {
+		return
+	}
+	schemas, err := maintainedSchemas(p.dbManager, trashPurgeTimeout)
+	if err != nil {
+		log.Printf("Warning: failed to list schemas for trash purge: %v", err)
+		return
+	}
+
+	before := time.Now().Add(-retention)
+	for _, schema := range schemas {
+		ctx := requestctx.WithActor(requestctx.WithTenant(context.Background(), "", schema), trashPurgeActor)
+		ctx, cancel := context.WithTimeout(ctx, trashPurgeTimeout)
+		purged, err := FromManager(p.dbManager).PurgeTrash(ctx, before)
+		cancel()
+		if err != nil {
+			log.Printf("Warning: failed to purge trash: %v", err)
+		}
+		if purged > 0 {
+			log.Printf("Purged %d table(s) deleted before %s", purged, before.Format(time.RFC3339))
+		}
+	}
+}
+
+// maintainedSchemas returns the shared schema ("") and, on PostgreSQL,
+// every tenant schema, for background maintenance
+func maintainedSchemas(dbManager *db.Manager, timeout time.Duration) ([]string, error) {
+	schemas := []string{""}
+	if dbManager.Local() != nil {
+		return schemas, nil
+	}
+	pool := dbManager.GetWritePool()
+	if pool == nil {
+		return nil, ErrDatabaseNotConfigured
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	rows, err := pool.Query(ctx, `SELECT nspname FROM pg_namespace WHERE starts_with(nspname, $1) ORDER BY nspname`, tenancy.SchemaPrefix)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var schema string
+		if err := rows.Scan(&schema); err != nil {
+			return nil, err
+		}
+		schemas = append(schemas, schema)
+	}
+	return schemas, rows.Err()
+}
@@ -16,6 +16,12 @@ var PostgresTypeMapping = map[DataType]string{
 	// DataTypeRelation is handled specially (becomes INTEGER with FK constraint)
 }
 
+// pgvector limits: columns support up to 16000 dimensions, indexes up to 2000
+const (
+	MaxVectorDimensions        = 16000
+	MaxIndexedVectorDimensions = 2000
+)
+
 // MapToPostgresType converts a user-friendly data type to a PostgreSQL type
 func MapToPostgresType(dataType DataType) (string, error) {
 	// Special handling for relations
@@ -23,6 +29,11 @@ func MapToPostgresType(dataType DataType) (string, error) {
 		return "INTEGER", nil
 	}
 
+	// Vectors need a dimension, see MapColumnToPostgresType
+	if dataType == DataTypeVector {
+		return "VECTOR", nil
+	}
+
 	pgType, exists := PostgresTypeMapping[dataType]
 	if !exists {
 		return "", fmt.Errorf("unknown data type: %s", dataType)
@@ -31,6 +42,79 @@ func MapToPostgresType(dataType DataType) (string, error) {
 	return pgType, nil
 }
 
+// MapColumnToPostgresType converts a column definition to a PostgreSQL type,
+// taking type parameters such as vector dimensions into account
+func MapColumnToPostgresType(col ColumnDefinition) (string, error) {
+	if col.DataType != DataTypeVector {
+		return MapToPostgresType(col.DataType)
+	}
+
+	if col.VectorDimensions == nil {
+		return "", fmt.Errorf("vector columns require vector_dimensions")
+	}
+
+	return fmt.Sprintf("VECTOR(%d)", *col.VectorDimensions), nil
+}
+
+// ValidateVectorColumn checks the dimension and index options of a vector column
+func ValidateVectorColumn(col ColumnDefinition) error {
+	if col.DataType != DataTypeVector {
+		if col.VectorDimensions != nil || col.VectorIndexType != nil {
+			return fmt.Errorf("vector options are only allowed on vector columns")
+		}
+		return nil
+	}
+
+	if col.VectorDimensions == nil {
+		return fmt.Errorf("vector_dimensions is required for vector columns")
+	}
+
+	dims := *col.VectorDimensions
+	if dims < 1 || dims > MaxVectorDimensions {
+		return fmt.Errorf("vector_dimensions must be between 1 and %d", MaxVectorDimensions)
+	}
+
+	if col.VectorIndexType != nil {
+		switch *col.VectorIndexType {
+		case VectorIndexHNSW, VectorIndexIVFFlat:
+		default:
+			return fmt.Errorf("invalid vector index type: %s", *col.VectorIndexType)
+		}
+
+		if dims > MaxIndexedVectorDimensions {
+			return fmt.Errorf("vector indexes support at most %d dimensions", MaxIndexedVectorDimensions)
+		}
+	}
+
+	if col.DefaultValue != nil {
+		return fmt.Errorf("vector columns cannot have default values")
+	}
+
+	return nil
+}
+
+// BuildVectorIndexSQL returns the CREATE INDEX statement for a vector column,
+// or an empty string when no index is requested
+func BuildVectorIndexSQL(tableName string, col ColumnDefinition) string {
+	if col.DataType != DataTypeVector || col.VectorIndexType == nil {
+		return ""
+	}
+
+	indexName := fmt.Sprintf("idx_%s_%s_vec", tableName, col.ColumnName)
+	if len(indexName) > 63 {
+		indexName = indexName[:63]
+	}
+
+	switch *col.VectorIndexType {
+	case VectorIndexIVFFlat:
+		return fmt.Sprintf("CREATE INDEX %s ON %s USING ivfflat (%s vector_cosine_ops) WITH (lists = 100);",
+			indexName, tableName, col.ColumnName)
+	default:
+		return fmt.Sprintf("CREATE INDEX %s ON %s USING hnsw (%s vector_cosine_ops);",
+			indexName, tableName, col.ColumnName)
+	}
+}
+
 // ValidateDataType checks if a data type is valid
 func ValidateDataType(dataType DataType) error {
 	validTypes := map[DataType]bool{
@@ -42,6 +126,8 @@ func ValidateDataType(dataType DataType) error {
 		DataTypeDate:     true,
 		DataTypeJSON:     true,
 		DataTypeRelation: true,
+		DataTypeVector:   true,
+		DataTypeFormula:  true,
 	}
 
 	if !validTypes[dataType] {
@@ -51,28 +137,24 @@ func ValidateDataType(dataType DataType) error {
 	return nil
 }
 
-// GetDefaultValueSQL formats a default value for SQL
-// This ensures proper escaping and type conversion
+// GetDefaultValueSQL formats a default value for PostgreSQL DDL. The value
+// is validated for its data type and text is quoted like quote_literal.
 func GetDefaultValueSQL(dataType DataType, defaultValue *string) (string, error) {
 	if defaultValue == nil {
 		return "", nil
 	}
 
 	value := *defaultValue
+	if err := validateDefaultValue(dataType, value); err != nil {
+		return "", err
+	}
 
 	switch dataType {
 	case DataTypeText, DataTypeTextLong:
-		// Text values need to be quoted
-		// We use PostgreSQL's quote_literal-like behavior
-		// For simplicity, we'll just ensure single quotes are escaped
-		return fmt.Sprintf("'%s'", escapeString(value)), nil
-
-	case DataTypeNumber:
-		// Numbers should be validated but don't need quotes
-		return value, nil
+		return quoteLiteral(value), nil
 
-	case DataTypeDecimal:
-		// Decimals should be validated but don't need quotes
+	case DataTypeNumber, DataTypeDecimal:
+		// Validated as plain numbers, so they don't need quotes
 		return value, nil
 
 	case DataTypeBoolean:
@@ -88,40 +170,16 @@ func GetDefaultValueSQL(dataType DataType, defaultValue *string) (string, error)
 
 	case DataTypeDate:
 		// For dates, we'll accept ISO format strings
-		return fmt.Sprintf("'%s'::TIMESTAMPTZ", escapeString(value)), nil
+		return quoteLiteral(value) + "::TIMESTAMPTZ", nil
 
 	case DataTypeJSON:
-		// JSON needs to be a valid JSON string
-		return fmt.Sprintf("'%s'::JSONB", escapeString(value)), nil
-
-	case DataTypeRelation:
-		// Relations shouldn't have default values
-		return "", fmt.Errorf("relation columns cannot have default values")
+		return quoteLiteral(value) + "::JSONB", nil
 
 	default:
 		return "", fmt.Errorf("unsupported data type for default value: %s", dataType)
 	}
 }
 
-// escapeString escapes single quotes in a string for SQL
-func escapeString(s string) string {
-	// In PostgreSQL, single quotes are escaped by doubling them
-	return escapeStringSingleQuotes(s)
-}
-
-// escapeStringSingleQuotes doubles single quotes for SQL escaping
-func escapeStringSingleQuotes(s string) string {
-	result := ""
-	for _, char := range s {
-		if char == '\'' {
-			result += "''"
-		} else {
-			result += string(char)
-		}
-	}
-	return result
-}
-
 // GetDataTypeDisplayName returns a human-readable name for a data type
 func GetDataTypeDisplayName(dataType DataType) string {
 	names := map[DataType]string{
@@ -133,6 +191,8 @@ func GetDataTypeDisplayName(dataType DataType) string {
 		DataTypeDate:     "Date & Time",
 		DataTypeJSON:     "JSON Data",
 		DataTypeRelation: "Relationship",
+		DataTypeVector:   "Vector (Embedding)",
+		DataTypeFormula:  "Formula",
 	}
 
 	if name, exists := names[dataType]; exists {
@@ -153,6 +213,8 @@ func GetDataTypeDescription(dataType DataType) string {
 		DataTypeDate:     "Dates and times with timezone support",
 		DataTypeJSON:     "Flexible structured data in JSON format",
 		DataTypeRelation: "Link to another table (foreign key relationship)",
+		DataTypeVector:   "Embedding vector with a configurable dimension for similarity search",
+		DataTypeFormula:  "Value computed from other columns when records are read (totals, labels, date math)",
 	}
 
 	if desc, exists := descriptions[dataType]; exists {
@@ -173,15 +235,18 @@ func AllDataTypes() []DataType {
 		DataTypeDate,
 		DataTypeJSON,
 		DataTypeRelation,
+		DataTypeVector,
+		DataTypeFormula,
 	}
 }
 
 // DataTypeInfo contains display information for a data type
 type DataTypeInfo struct {
-	Type        DataType `json:"type"`
-	DisplayName string   `json:"display_name"`
-	Description string   `json:"description"`
-	PostgresType string  `json:"postgres_type"`
+	Type         DataType `json:"type"`
+	DisplayName  string   `json:"display_name"`
+	Description  string   `json:"description"`
+	PostgresType string   `json:"postgres_type"`
+	FormatFields []string `json:"format_fields,omitempty"` // Column format settings that apply
 }
 
 // GetAllDataTypeInfo returns information about all data types
@@ -196,6 +261,7 @@ func GetAllDataTypeInfo() []DataTypeInfo {
 			DisplayName:  GetDataTypeDisplayName(dt),
 			Description:  GetDataTypeDescription(dt),
 			PostgresType: pgType,
+			FormatFields: FormatFields(dt),
 		})
 	}
 
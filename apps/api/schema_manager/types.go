@@ -6,40 +6,61 @@ import "time"
 type DataType string
 
 const (
-	DataTypeText       DataType = "text"        // Short text (VARCHAR(255))
-	DataTypeTextLong   DataType = "text_long"   // Long text (TEXT)
-	DataTypeNumber     DataType = "number"      // Integer
-	DataTypeDecimal    DataType = "decimal"     // Decimal numbers with precision
-	DataTypeBoolean    DataType = "boolean"     // True/False
-	DataTypeDate       DataType = "date"        // Date with time and timezone
-	DataTypeJSON       DataType = "json"        // JSON data (stored as JSONB)
-	DataTypeRelation   DataType = "relation"    // Foreign key to another table
+	DataTypeText     DataType = "text"      // Short text (VARCHAR(255))
+	DataTypeTextLong DataType = "text_long" // Long text (TEXT)
+	DataTypeNumber   DataType = "number"    // Integer
+	DataTypeDecimal  DataType = "decimal"   // Decimal numbers with precision
+	DataTypeBoolean  DataType = "boolean"   // True/False
+	DataTypeDate     DataType = "date"      // Date with time and timezone
+	DataTypeJSON     DataType = "json"      // JSON data (stored as JSONB)
+	DataTypeRelation DataType = "relation"  // Foreign key to another table
+	DataTypeVector   DataType = "vector"    // Embedding vector (pgvector VECTOR(n))
+	DataTypeFormula  DataType = "formula"   // Computed from sibling columns on read, not stored
+)
+
+// VectorIndexType represents the pgvector index method for a vector column
+type VectorIndexType string
+
+const (
+	VectorIndexHNSW    VectorIndexType = "hnsw"    // Hierarchical navigable small world graph
+	VectorIndexIVFFlat VectorIndexType = "ivfflat" // Inverted file with flat compression
 )
 
 // ColumnDefinition represents a column in a user-defined table
 type ColumnDefinition struct {
-	ID                    int       `json:"id,omitempty"`
-	Name                  string    `json:"name"`                      // User-friendly name
-	ColumnName            string    `json:"column_name"`               // Sanitized machine name
-	DataType              DataType  `json:"data_type"`                 // User-friendly type
-	PostgresType          string    `json:"postgres_type,omitempty"`   // Actual PostgreSQL type
-	IsNullable            bool      `json:"is_nullable"`
-	IsUnique              bool      `json:"is_unique"`
-	DefaultValue          *string   `json:"default_value,omitempty"`
-	ForeignKeyToTableID   *int      `json:"foreign_key_to_table_id,omitempty"`
-	ForeignKeyToTableName *string   `json:"foreign_key_to_table_name,omitempty"`
-	DisplayOrder          int       `json:"display_order"`
+	ID                    int                `json:"id,omitempty"`
+	Name                  string             `json:"name"`                    // User-friendly name
+	ColumnName            string             `json:"column_name"`             // Sanitized machine name
+	DataType              DataType           `json:"data_type"`               // User-friendly type
+	PostgresType          string             `json:"postgres_type,omitempty"` // Database type in the store's dialect
+	IsNullable            bool               `json:"is_nullable"`
+	IsUnique              bool               `json:"is_unique"`
+	DefaultValue          *string            `json:"default_value,omitempty"`
+	ForeignKeyToTableID   *int               `json:"foreign_key_to_table_id,omitempty"`
+	ForeignKeyToTableName *string            `json:"foreign_key_to_table_name,omitempty"`
+	DisplayOrder          int                `json:"display_order"`
+	VectorDimensions      *int               `json:"vector_dimensions,omitempty"` // Required for vector columns
+	VectorIndexType       *VectorIndexType   `json:"vector_index_type,omitempty"` // Optional index for vector columns
+	Format                *ColumnFormat      `json:"format,omitempty"`            // Optional presentation settings
+	Formula               *string            `json:"formula,omitempty"`           // Expression of formula columns, see package formula
+	Permissions           *ColumnPermissions `json:"permissions,omitempty"`       // Roles denied reading or writing the column
+	Description           *string            `json:"description,omitempty"`       // What the column holds, matched by SearchSchema
 }
 
 // TableDefinition represents a user-defined table
 type TableDefinition struct {
-	ID          int                 `json:"id,omitempty"`
-	Name        string              `json:"name"`        // User-friendly name
-	TableName   string              `json:"table_name"`  // Sanitized machine name
-	Description *string             `json:"description,omitempty"`
-	Columns     []ColumnDefinition  `json:"columns"`
-	CreatedAt   time.Time           `json:"created_at,omitempty"`
-	UpdatedAt   time.Time           `json:"updated_at,omitempty"`
+	ID            int                `json:"id,omitempty"`
+	Name          string             `json:"name"`       // User-friendly name
+	TableName     string             `json:"table_name"` // Sanitized machine name
+	Description   *string            `json:"description,omitempty"`
+	Columns       []ColumnDefinition `json:"columns"`
+	CreatedAt     time.Time          `json:"created_at,omitempty"`
+	UpdatedAt     time.Time          `json:"updated_at,omitempty"`
+	CreatedBy     *string            `json:"created_by,omitempty"`     // Principal that created the table
+	DeletedAt     *time.Time         `json:"deleted_at,omitempty"`     // When the table was moved to the trash
+	DeletedBy     *string            `json:"deleted_by,omitempty"`     // Principal that moved it to the trash
+	Naming        *NamingStrategy    `json:"naming,omitempty"`         // Strategy its table and column names were derived with
+	SystemColumns []SystemColumn     `json:"system_columns,omitempty"` // Columns every record has besides Columns, which can't be written
 }
 
 // SchemaChangeLog represents an audit entry for schema changes
@@ -57,16 +78,16 @@ type SchemaChangeLog struct {
 
 // CreateTableRequest is the request payload for creating a new table
 type CreateTableRequest struct {
-	Name        string              `json:"name" binding:"required"`
-	Description *string             `json:"description,omitempty"`
-	Columns     []ColumnDefinition  `json:"columns" binding:"required,min=1"`
+	Name        string             `json:"name" binding:"required"`
+	Description *string            `json:"description,omitempty"`
+	Columns     []ColumnDefinition `json:"columns" binding:"required,min=1"`
 }
 
 // UpdateTableRequest is the request payload for updating an existing table
 type UpdateTableRequest struct {
-	Name        *string             `json:"name,omitempty"`
-	Description *string             `json:"description,omitempty"`
-	Columns     []ColumnDefinition  `json:"columns,omitempty"`
+	Name        *string            `json:"name,omitempty"`
+	Description *string            `json:"description,omitempty"`
+	Columns     []ColumnDefinition `json:"columns,omitempty"`
 }
 
 // ValidationError represents a validation error
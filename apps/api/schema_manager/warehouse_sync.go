@@ -0,0 +1,302 @@
+package schema_manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"agentic-template/api/db"
+	"agentic-template/api/destinations"
+	"agentic-template/api/queue"
+	"agentic-template/api/requestctx"
+)
+
+// SyncJobKind is the background job kind running a warehouse sync
+const SyncJobKind = "warehouse_sync"
+
+// MinSyncInterval is the shortest interval warehouse syncs run at
+const MinSyncInterval = 5 * time.Minute
+
+// WarehouseSync replicates the record changes of user tables to an
+// external warehouse on a schedule. Each run first copies the records
+// present when the sync started, then appends the changes logged since the
+// previous run, so the warehouse holds a change history: a row per change
+// with its _operation, upsert or delete, and _changed_at. Runs deliver
+// changes at least once; a failed run is retried whole by the next one.
+type WarehouseSync struct {
+	ID                  int64         `json:"id"`
+	Destination         string        `json:"destination"`      // Name of the destination in SYNC_DESTINATIONS
+	TableIDs            []int         `json:"table_ids"`        // Empty replicates every table
+	Interval            time.Duration `json:"interval"`         // Between runs
+	Enabled             bool          `json:"enabled"`          // False while paused
+	JobID               *int64        `json:"job_id,omitempty"` // Of the next run
+	SnapshotDone        bool          `json:"snapshot_done"`    // Whether the records present at the start were copied
+	RowsSynced          int64         `json:"rows_synced"`      // Written across all runs
+	LastSyncedAt        *time.Time    `json:"last_synced_at,omitempty"`
+	LastError           *string       `json:"last_error,omitempty"` // Of the last run, cleared when a run succeeds
+	ConsecutiveFailures int           `json:"consecutive_failures"`
+	AlertedAt           *time.Time    `json:"alerted_at,omitempty"` // When the failures raised an alert
+	CreatedBy           *string       `json:"created_by,omitempty"`
+	CreatedAt           time.Time     `json:"created_at"`
+	UpdatedAt           time.Time     `json:"updated_at"`
+
+	// cursor is the oldest transaction whose changes weren't replicated
+	cursor int64
+}
+
+// SyncDestination is a warehouse configured for syncs. Its URL stays in
+// the configuration, so credentials never reach callers.
+type SyncDestination struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"` // A destinations kind, e.g. destinations.KindBigQuery
+}
+
+// SyncRequest creates a warehouse sync
+type SyncRequest struct {
+	Destination string        `json:"destination"`
+	TableIDs    []int         `json:"table_ids,omitempty"` // Empty replicates every table, including later ones
+	Interval    time.Duration `json:"interval"`
+}
+
+// ListSyncsOptions pages ListSyncs
+type ListSyncsOptions struct {
+	PageSize int   // Defaults to DefaultTablePageSize, max MaxTablePageSize
+	BeforeID int64 // Only syncs older than this ID; 0 starts from the newest
+}
+
+// RecordChange is an entry of the record change log, written by a
+// trigger on every user table
+type RecordChange struct {
+	ID        int64     `json:"id"`
+	TableName string    `json:"table_name"`
+	RecordID  int       `json:"record_id"`
+	Operation string    `json:"operation"` // insert, update, or delete
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+// SyncStore is implemented by stores that keep the record change log and
+// track warehouse syncs
+type SyncStore interface {
+	// InsertSync stores a sync, starting its cursor at the change log's
+	// horizon, and returns its ID
+	InsertSync(ctx context.Context, sync WarehouseSync) (int64, error)
+	// GetSync returns a sync, or ErrSyncNotFound
+	GetSync(ctx context.Context, id int64) (*WarehouseSync, error)
+	// ListSyncs returns syncs, newest first
+	ListSyncs(ctx context.Context, opts ListSyncsOptions) ([]WarehouseSync, error)
+	// SetSyncEnabled pauses or resumes a sync and sets its next run's job,
+	// or returns ErrSyncNotFound
+	SetSyncEnabled(ctx context.Context, id int64, enabled bool, jobID *int64) error
+	// UpdateSyncRun stores the job, cursor, progress, and failures of a
+	// sync's run
+	UpdateSyncRun(ctx context.Context, sync WarehouseSync) error
+	// DeleteSync deletes a sync, or returns ErrSyncNotFound
+	DeleteSync(ctx context.Context, id int64) error
+	// ChangeLogHorizon returns the oldest transaction still running; every
+	// change of an older transaction is committed or rolled back
+	ChangeLogHorizon(ctx context.Context) (int64, error)
+	// ListRecordChanges returns changes of the tables by transactions in
+	// [fromXact, toXact) with IDs after afterID, in ID order
+	ListRecordChanges(ctx context.Context, tableNames []string, fromXact, toXact, afterID int64, limit int) ([]RecordChange, error)
+	// PruneRecordChanges deletes the changes every sync has replicated
+	PruneRecordChanges(ctx context.Context) (int64, error)
+	// ListTextRecords returns records with IDs after afterID in ID order,
+	// their columns as text
+	ListTextRecords(ctx context.Context, tableName string, columns []string, afterID, limit int) ([][]*string, error)
+	// GetTextRecords returns the records of the IDs that still exist, their
+	// columns as text
+	GetTextRecords(ctx context.Context, tableName string, columns []string, ids []int) ([][]*string, error)
+}
+
+// syncJob is the payload of a SyncJobKind job
+type syncJob struct {
+	SyncID int64  `json:"sync_id"`
+	Tenant string `json:"tenant,omitempty"`
+	Schema string `json:"schema,omitempty"`
+}
+
+var syncDestinations atomic.Pointer[map[string]string]
+
+// SetSyncDestinations sets the warehouses syncs may write to, by name
+func SetSyncDestinations(configured map[string]string) {
+	syncDestinations.Store(&configured)
+}
+
+// ListSyncDestinations returns the configured destinations by name.
+// Destinations with URLs no writer supports are left out.
+func ListSyncDestinations() []SyncDestination {
+	list := []SyncDestination{}
+	if configured := syncDestinations.Load(); configured != nil {
+		for name, rawURL := range *configured {
+			if kind, err := destinations.DestinationKind(rawURL); err == nil {
+				list = append(list, SyncDestination{Name: name, Kind: kind})
+			}
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// openSyncDestination connects to a configured destination
+func openSyncDestination(ctx context.Context, name string) (destinations.Destination, error) {
+	rawURL := syncDestinationURL(name)
+	if rawURL == "" {
+		return nil, fmt.Errorf("%w: %q", ErrSyncDestinationNotFound, name)
+	}
+	return destinations.Open(ctx, rawURL)
+}
+
+// CreateSync stores a warehouse sync and queues its first run
+func (sm *SchemaManager) CreateSync(ctx context.Context, jobs *queue.Queue, req SyncRequest) (*WarehouseSync, error) {
+	store, err := sm.syncStore()
+	if err != nil {
+		return nil, err
+	}
+	if req.Interval < MinSyncInterval {
+		return nil, invalidField("interval_minutes", "must be at least %d", int(MinSyncInterval/time.Minute))
+	}
+	rawURL := syncDestinationURL(req.Destination)
+	if rawURL == "" {
+		return nil, fmt.Errorf("%w: %q", ErrSyncDestinationNotFound, req.Destination)
+	}
+	if _, err := destinations.DestinationKind(rawURL); err != nil {
+		return nil, err
+	}
+	for i, id := range req.TableIDs {
+		if _, err := sm.GetTable(ctx, id); errors.Is(err, ErrTableNotFound) {
+			return nil, invalidField(fmt.Sprintf("table_ids[%d]", i), "table %d not found", id)
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	tableIDs := slices.Compact(slices.Sorted(slices.Values(req.TableIDs)))
+
+	actor := requestctx.Actor(ctx)
+	id, err := store.InsertSync(ctx, WarehouseSync{
+		Destination: req.Destination,
+		TableIDs:    tableIDs,
+		Interval:    req.Interval,
+		Enabled:     true,
+		CreatedBy:   &actor,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to store warehouse sync: %w", err)
+	}
+	return sm.scheduleSync(ctx, store, jobs, id)
+}
+
+// GetSync returns a warehouse sync, or ErrSyncNotFound
+func (sm *SchemaManager) GetSync(ctx context.Context, id int64) (*WarehouseSync, error) {
+	store, err := sm.syncStore()
+	if err != nil {
+		return nil, err
+	}
+	return store.GetSync(ctx, id)
+}
+
+// ListSyncs returns warehouse syncs, newest first
+func (sm *SchemaManager) ListSyncs(ctx context.Context, opts ListSyncsOptions) ([]WarehouseSync, error) {
+	store, err := sm.syncStore()
+	if err != nil {
+		return nil, err
+	}
+	if opts.PageSize <= 0 {
+		opts.PageSize = DefaultTablePageSize
+	}
+	if opts.PageSize > MaxTablePageSize {
+		opts.PageSize = MaxTablePageSize
+	}
+	return store.ListSyncs(ctx, opts)
+}
+
+// PauseSync stops a sync from running; a run already in progress
+// finishes. Paused syncs keep the changes they haven't replicated in the
+// change log.
+func (sm *SchemaManager) PauseSync(ctx context.Context, id int64) (*WarehouseSync, error) {
+	store, err := sm.syncStore()
+	if err != nil {
+		return nil, err
+	}
+	if err := store.SetSyncEnabled(ctx, id, false, nil); err != nil {
+		return nil, err
+	}
+	return store.GetSync(ctx, id)
+}
+
+// ResumeSync queues a run of a paused sync now, which catches up on the
+// changes logged while it was paused
+func (sm *SchemaManager) ResumeSync(ctx context.Context, jobs *queue.Queue, id int64) (*WarehouseSync, error) {
+	store, err := sm.syncStore()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := store.GetSync(ctx, id); err != nil {
+		return nil, err
+	}
+	return sm.scheduleSync(ctx, store, jobs, id)
+}
+
+// DeleteSync deletes a sync, releasing the changes it hadn't replicated.
+// Its queued run finds it gone and stops.
+func (sm *SchemaManager) DeleteSync(ctx context.Context, id int64) error {
+	store, err := sm.syncStore()
+	if err != nil {
+		return err
+	}
+	return store.DeleteSync(ctx, id)
+}
+
+// scheduleSync queues a run of a sync now, enabling it. Queueing a run
+// supersedes the sync's previous one.
+func (sm *SchemaManager) scheduleSync(ctx context.Context, store SyncStore, jobs *queue.Queue, id int64) (*WarehouseSync, error) {
+	payload := syncJob{SyncID: id, Tenant: requestctx.Tenant(ctx), Schema: requestctx.TenantSchema(ctx)}
+	job, err := jobs.Enqueue(ctx, SyncJobKind, payload, queue.EnqueueOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to queue warehouse sync: %w", err)
+	}
+	if err := store.SetSyncEnabled(ctx, id, true, &job.ID); err != nil {
+		return nil, err
+	}
+	return store.GetSync(ctx, id)
+}
+
+// SyncJobHandler runs queued warehouse syncs on the database of a manager,
+// in the schema of the tenant that created them, queueing each sync's
+// next run
+func SyncJobHandler(dbManager *db.Manager, jobs *queue.Queue) queue.Handler {
+	return func(ctx context.Context, job *queue.Job) error {
+		var payload syncJob
+		if err := job.Decode(&payload); err != nil {
+			return err
+		}
+		if payload.Schema != "" {
+			ctx = requestctx.WithTenant(ctx, payload.Tenant, payload.Schema)
+		}
+		return FromManager(dbManager).runSync(ctx, jobs, job, payload)
+	}
+}
+
+// syncDestinationURL returns the URL of a configured destination, empty
+// when there is none of that name
+func syncDestinationURL(name string) string {
+	if configured := syncDestinations.Load(); configured != nil {
+		return (*configured)[name]
+	}
+	return ""
+}
+
+// syncStore returns the store as a SyncStore
+func (sm *SchemaManager) syncStore() (SyncStore, error) {
+	if sm.store == nil {
+		return nil, ErrDatabaseNotConfigured
+	}
+	store, ok := sm.store.(SyncStore)
+	if !ok {
+		return nil, fmt.Errorf("warehouse syncs are not supported on %s", sm.store.Dialect().Name())
+	}
+	return store, nil
+}
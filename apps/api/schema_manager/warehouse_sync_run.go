@@ -0,0 +1,403 @@
+package schema_manager
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"agentic-template/api/destinations"
+	"agentic-template/api/metrics"
+	"agentic-template/api/queue"
+	"agentic-template/api/requestctx"
+)
+
+// Warehouse sync tuning
+const (
+	syncBatchSize    = 500              // Records read and written at a time
+	syncAlertTimeout = 10 * time.Second // Bound on each alert webhook call
+)
+
+// Operations of replicated rows, in their _operation column
+const (
+	syncUpsert = "upsert"
+	syncDelete = "delete"
+)
+
+// syncMetaColumns follow the record columns of every replicated row
+var syncMetaColumns = []string{"_operation", "_changed_at"}
+
+var (
+	syncRuns = metrics.NewCounter("warehouse_sync_runs_total",
+		"Warehouse sync runs by destination and outcome", "destination", "outcome")
+	syncRows = metrics.NewCounter("warehouse_sync_rows_total",
+		"Rows warehouse syncs wrote by destination", "destination")
+)
+
+// SyncAlerts configures the alerts raised when warehouse syncs keep
+// failing
+type SyncAlerts struct {
+	Webhook  string // Receives alerts as JSON POSTs; empty only logs them
+	Failures int    // Failed runs in a row that raise an alert; 0 disables alerts
+}
+
+var syncAlerts atomic.Pointer[SyncAlerts]
+
+// SetSyncAlerts sets when and where failing syncs raise alerts
+func SetSyncAlerts(alerts SyncAlerts) {
+	syncAlerts.Store(&alerts)
+}
+
+// Events of sync alerts
+const (
+	syncAlertFailing   = "warehouse_sync.failing"
+	syncAlertRecovered = "warehouse_sync.recovered"
+)
+
+// syncAlert is the body of an alert webhook call
+type syncAlert struct {
+	Event               string    `json:"event"`
+	SyncID              int64     `json:"sync_id"`
+	Tenant              string    `json:"tenant,omitempty"`
+	Destination         string    `json:"destination"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	Error               string    `json:"error,omitempty"`
+	At                  time.Time `json:"at"`
+}
+
+// runSync runs a sync, records its outcome, and queues its next run. A
+// failed run counts toward the sync's alert instead of failing the job, so
+// the sync keeps its schedule; the next run retries the same changes.
+func (sm *SchemaManager) runSync(ctx context.Context, jobs *queue.Queue, job *queue.Job, payload syncJob) error {
+	store, err := sm.syncStore()
+	if err != nil {
+		return err
+	}
+	sync, err := store.GetSync(ctx, payload.SyncID)
+	if errors.Is(err, ErrSyncNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if (sync.JobID != nil && *sync.JobID != job.ID) || !sync.Enabled {
+		return nil
+	}
+
+	started := time.Now()
+	runErr := sm.replicate(ctx, store, sync)
+	if runErr != nil && ctx.Err() != nil {
+		return runErr // Shutting down; the queue retries the job
+	}
+
+	outcome := "succeeded"
+	if runErr != nil {
+		outcome = "failed"
+		message := runErr.Error()
+		sync.LastError = &message
+		sync.ConsecutiveFailures++
+		log.Printf("Warning: warehouse sync %d to %s failed (%d in a row): %v", sync.ID, sync.Destination, sync.ConsecutiveFailures, runErr)
+		if alerts := currentSyncAlerts(); alerts.Failures > 0 && sync.ConsecutiveFailures >= alerts.Failures && sync.AlertedAt == nil {
+			raiseSyncAlert(ctx, alerts, syncAlertFailing, sync)
+			now := time.Now()
+			sync.AlertedAt = &now
+		}
+	} else {
+		if sync.AlertedAt != nil {
+			raiseSyncAlert(ctx, currentSyncAlerts(), syncAlertRecovered, sync)
+		}
+		now := time.Now()
+		sync.LastSyncedAt, sync.LastError, sync.ConsecutiveFailures, sync.AlertedAt = &now, nil, 0, nil
+	}
+	syncRuns.Inc(sync.Destination, outcome)
+
+	next, err := jobs.Enqueue(ctx, SyncJobKind, payload, queue.EnqueueOptions{RunAt: started.Add(sync.Interval)})
+	if err != nil {
+		return fmt.Errorf("failed to schedule the next run: %w", err)
+	}
+	sync.JobID = &next.ID
+	if err := store.UpdateSyncRun(context.WithoutCancel(ctx), *sync); err != nil {
+		return err
+	}
+	if runErr == nil {
+		if _, err := store.PruneRecordChanges(ctx); err != nil {
+			log.Printf("Warning: failed to prune the record change log: %v", err)
+		}
+	}
+	return nil
+}
+
+// replicate writes the changes of a sync's tables since its cursor to its
+// destination, up to the change log's horizon, and moves the cursor
+// there. The first run copies every record instead.
+func (sm *SchemaManager) replicate(ctx context.Context, store SyncStore, sync *WarehouseSync) error {
+	tables, err := sm.syncTables(ctx, sync.TableIDs)
+	if err != nil {
+		return err
+	}
+	dest, err := openSyncDestination(ctx, sync.Destination)
+	if err != nil {
+		return err
+	}
+	defer dest.Close()
+	horizon, err := store.ChangeLogHorizon(ctx)
+	if err != nil {
+		return err
+	}
+	w := &syncWriter{dest: dest, sync: sync, key: fmt.Sprintf("%d-%d", sync.ID, horizon)}
+
+	if !sync.SnapshotDone {
+		// Changes of transactions from the horizon on may be missing from
+		// the copy, so the change log keeps them for the next run
+		sync.cursor = horizon
+		if err := store.UpdateSyncRun(ctx, *sync); err != nil {
+			return err
+		}
+		for _, table := range tables {
+			if err := w.snapshot(ctx, store, table); err != nil {
+				return err
+			}
+		}
+		sync.SnapshotDone = true
+		return nil
+	}
+
+	if err := w.changes(ctx, store, tables, sync.cursor, horizon); err != nil {
+		return err
+	}
+	sync.cursor = horizon
+	return nil
+}
+
+// syncTables returns the tables a sync replicates, leaving out those
+// deleted or in the trash since it was created
+func (sm *SchemaManager) syncTables(ctx context.Context, tableIDs []int) ([]*TableDefinition, error) {
+	if len(tableIDs) == 0 {
+		for offset := 0; ; offset += MaxTablePageSize {
+			page, _, err := sm.store.ListTables(ctx, TableQuery{Sort: SortCreatedDesc, Limit: MaxTablePageSize, Offset: offset})
+			if err != nil {
+				return nil, err
+			}
+			for _, table := range page {
+				tableIDs = append(tableIDs, table.ID)
+			}
+			if len(page) < MaxTablePageSize {
+				break
+			}
+		}
+	}
+
+	tables := make([]*TableDefinition, 0, len(tableIDs))
+	for _, id := range tableIDs {
+		table, err := sm.GetTable(ctx, id)
+		if errors.Is(err, ErrTableNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, table)
+	}
+	return tables, nil
+}
+
+// syncWriter writes the batches of one run to a destination
+type syncWriter struct {
+	dest    destinations.Destination
+	sync    *WarehouseSync
+	key     string // Of the run; batches add their position
+	batches int
+}
+
+// syncColumns returns the stored columns of a table, id first. Formula
+// columns are left out; the warehouse has the columns they compute from.
+func syncColumns(table *TableDefinition) []string {
+	columns := []string{"id"}
+	stored, _ := storedColumns(table.Columns)
+	for _, col := range stored {
+		columns = append(columns, col.ColumnName)
+	}
+	return append(columns, "created_at", "updated_at")
+}
+
+// snapshot copies every record of a table as an upsert changed at its
+// updated_at
+func (w *syncWriter) snapshot(ctx context.Context, store SyncStore, table *TableDefinition) error {
+	columns := syncColumns(table)
+	updatedAt := len(columns) - 1
+	for afterID := 0; ; {
+		records, err := store.ListTextRecords(ctx, table.TableName, columns, afterID, syncBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(records) == 0 {
+			return nil
+		}
+		upsert := syncUpsert
+		rows := make([][]*string, len(records))
+		for i, record := range records {
+			rows[i] = append(record, &upsert, record[updatedAt])
+		}
+		if err := w.write(ctx, table.TableName, columns, rows); err != nil {
+			return err
+		}
+		if afterID, err = strconv.Atoi(*records[len(records)-1][0]); err != nil {
+			return fmt.Errorf("failed to read record ID: %w", err)
+		}
+	}
+}
+
+// changes writes the latest state of each record the tables' changes in
+// [fromXact, toXact) touched: an upsert of the record, or a delete when it
+// no longer exists
+func (w *syncWriter) changes(ctx context.Context, store SyncStore, tables []*TableDefinition, fromXact, toXact int64) error {
+	byName := make(map[string]*TableDefinition, len(tables))
+	names := make([]string, 0, len(tables))
+	for _, table := range tables {
+		byName[table.TableName] = table
+		names = append(names, table.TableName)
+	}
+
+	for afterID := int64(0); ; {
+		changes, err := store.ListRecordChanges(ctx, names, fromXact, toXact, afterID, syncBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(changes) == 0 {
+			return nil
+		}
+		afterID = changes[len(changes)-1].ID
+
+		// Only the latest change of each record matters, since its
+		// current state is written
+		latest := map[string]map[int]RecordChange{}
+		for _, change := range changes {
+			if latest[change.TableName] == nil {
+				latest[change.TableName] = map[int]RecordChange{}
+			}
+			latest[change.TableName][change.RecordID] = change
+		}
+		for _, name := range names {
+			if records := latest[name]; len(records) > 0 {
+				if err := w.writeChanges(ctx, store, byName[name], records); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// writeChanges writes a batch of changed records of a table
+func (w *syncWriter) writeChanges(ctx context.Context, store SyncStore, table *TableDefinition, changes map[int]RecordChange) error {
+	columns := syncColumns(table)
+	ids := make([]int, 0, len(changes))
+	for id := range changes {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	records, err := store.GetTextRecords(ctx, table.TableName, columns, ids)
+	if err != nil {
+		return err
+	}
+	current := make(map[string][]*string, len(records))
+	for _, record := range records {
+		current[*record[0]] = record
+	}
+
+	upsert, remove := syncUpsert, syncDelete
+	rows := make([][]*string, 0, len(ids))
+	for _, id := range ids {
+		changedAt := changes[id].ChangedAt.UTC().Format(time.RFC3339Nano)
+		idText := strconv.Itoa(id)
+		if record, ok := current[idText]; ok {
+			rows = append(rows, append(record, &upsert, &changedAt))
+			continue
+		}
+		row := make([]*string, len(columns), len(columns)+len(syncMetaColumns))
+		row[0] = &idText
+		rows = append(rows, append(row, &remove, &changedAt))
+	}
+	return w.write(ctx, table.TableName, columns, rows)
+}
+
+// write sends rows of a table to the destination with the meta columns
+func (w *syncWriter) write(ctx context.Context, tableName string, columns []string, rows [][]*string) error {
+	w.batches++
+	batch := destinations.Batch{
+		Table:   tableName,
+		Key:     fmt.Sprintf("%s-%d", w.key, w.batches),
+		Columns: append(slices.Clone(columns), syncMetaColumns...),
+		Rows:    rows,
+	}
+	if err := w.dest.Write(ctx, batch); err != nil {
+		return err
+	}
+	w.sync.RowsSynced += int64(len(rows))
+	syncRows.Add(float64(len(rows)), w.sync.Destination)
+	return nil
+}
+
+// currentSyncAlerts returns the alert settings, disabled until set
+func currentSyncAlerts() SyncAlerts {
+	if alerts := syncAlerts.Load(); alerts != nil {
+		return *alerts
+	}
+	return SyncAlerts{}
+}
+
+// raiseSyncAlert logs an alert about a sync and posts it to the alert
+// webhook. A webhook failure is only logged, so it can't fail the run.
+func raiseSyncAlert(ctx context.Context, alerts SyncAlerts, event string, sync *WarehouseSync) {
+	alert := syncAlert{
+		Event:               event,
+		SyncID:              sync.ID,
+		Tenant:              requestctx.Tenant(ctx),
+		Destination:         sync.Destination,
+		ConsecutiveFailures: sync.ConsecutiveFailures,
+		At:                  time.Now().UTC(),
+	}
+	if sync.LastError != nil {
+		alert.Error = *sync.LastError
+	}
+	if event == syncAlertFailing {
+		log.Printf("Error: warehouse sync %d to %s has failed %d times in a row: %s", sync.ID, sync.Destination, sync.ConsecutiveFailures, alert.Error)
+	} else {
+		log.Printf("Warehouse sync %d to %s recovered", sync.ID, sync.Destination)
+	}
+	if alerts.Webhook == "" {
+		return
+	}
+
+	body, err := json.Marshal(alert)
+	if err != nil {
+		log.Printf("Warning: failed to encode warehouse sync alert: %v", err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), syncAlertTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, alerts.Webhook, bytes.NewReader(body))
+	if err != nil {
+		log.Printf("Warning: failed to send warehouse sync alert: %v", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		log.Printf("Warning: failed to send warehouse sync alert: %v", err)
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		log.Printf("Warning: warehouse sync alert webhook returned %s: %s", resp.Status, strings.TrimSpace(string(detail)))
+	}
+}
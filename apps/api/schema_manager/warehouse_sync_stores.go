@@ -0,0 +1,287 @@
+package schema_manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"agentic-template/api/db"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Warehouse syncs run as background jobs and read the change log its
+// triggers write, which need PostgreSQL
+var _ SyncStore = &PostgresStore{}
+
+// changeLogHorizonSQL is the oldest transaction still running, as a
+// number comparable to record_changes.xact_id
+const changeLogHorizonSQL = `pg_snapshot_xmin(pg_current_snapshot())::text::bigint`
+
+// warehouseSyncColumns are the columns scanned by scanWarehouseSync
+const warehouseSyncColumns = `id, destination, table_ids, interval_minutes, enabled, job_id, cursor_xact_id, snapshot_done,
+		       rows_synced, last_synced_at, last_error, consecutive_failures, alerted_at, created_by, created_at, updated_at`
+
+// InsertSync stores a sync whose cursor starts at the change log's horizon
+func (s *PostgresStore) InsertSync(ctx context.Context, sync WarehouseSync) (int64, error) {
+	var id int64
+	query := `
+		INSERT INTO warehouse_syncs (destination, table_ids, interval_minutes, enabled, cursor_xact_id, created_by)
+		VALUES ($1, $2, $3, $4, ` + changeLogHorizonSQL + `, $5)
+		RETURNING id
+	`
+	tableIDs := sync.TableIDs
+	if tableIDs == nil {
+		tableIDs = []int{}
+	}
+	minutes := int(sync.Interval / time.Minute)
+	err := queryRow(ctx, s.pool, query, sync.Destination, tableIDs, minutes, sync.Enabled, sync.CreatedBy).Scan(&id)
+	return id, err
+}
+
+// GetSync returns a sync. It reads from the primary, since syncs are read
+// right after they change.
+func (s *PostgresStore) GetSync(ctx context.Context, id int64) (*WarehouseSync, error) {
+	query := `SELECT ` + warehouseSyncColumns + ` FROM warehouse_syncs WHERE id = $1`
+	sync, err := scanWarehouseSync(queryRow(ctx, s.pool, query, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrSyncNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query warehouse sync: %w", err)
+	}
+	return sync, nil
+}
+
+// ListSyncs returns syncs, newest first
+func (s *PostgresStore) ListSyncs(ctx context.Context, opts ListSyncsOptions) ([]WarehouseSync, error) {
+	query := `SELECT ` + warehouseSyncColumns + ` FROM warehouse_syncs WHERE true`
+	var args []interface{}
+	if opts.BeforeID > 0 {
+		args = append(args, opts.BeforeID)
+		query += fmt.Sprintf(" AND id < $%d", len(args))
+	}
+	args = append(args, opts.PageSize)
+	query += fmt.Sprintf(" ORDER BY id DESC LIMIT $%d", len(args))
+
+	queryCtx, cancel := db.StatementContext(ctx)
+	defer cancel()
+	rows, err := s.reader().Query(queryCtx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query warehouse syncs: %w", err)
+	}
+	defer rows.Close()
+
+	syncs := []WarehouseSync{}
+	for rows.Next() {
+		sync, err := scanWarehouseSync(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan warehouse sync: %w", err)
+		}
+		syncs = append(syncs, *sync)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query warehouse syncs: %w", err)
+	}
+	return syncs, nil
+}
+
+// SetSyncEnabled pauses or resumes a sync, keeping its job when jobID is
+// nil
+func (s *PostgresStore) SetSyncEnabled(ctx context.Context, id int64, enabled bool, jobID *int64) error {
+	ctx, cancel := db.StatementContext(ctx)
+	defer cancel()
+	tag, err := s.pool.Exec(ctx, `UPDATE warehouse_syncs SET enabled = $2, job_id = COALESCE($3, job_id) WHERE id = $1`, id, enabled, jobID)
+	if err != nil {
+		return fmt.Errorf("failed to update warehouse sync: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrSyncNotFound
+	}
+	return nil
+}
+
+// UpdateSyncRun stores the job, cursor, progress, and failures of a run.
+// A sync deleted while it ran stays deleted.
+func (s *PostgresStore) UpdateSyncRun(ctx context.Context, sync WarehouseSync) error {
+	query := `
+		UPDATE warehouse_syncs
+		SET job_id = $2, cursor_xact_id = $3, snapshot_done = $4, rows_synced = $5, last_synced_at = $6,
+		    last_error = $7, consecutive_failures = $8, alerted_at = $9
+		WHERE id = $1
+	`
+	err := exec(ctx, s.pool, query, sync.ID, sync.JobID, sync.cursor, sync.SnapshotDone, sync.RowsSynced, sync.LastSyncedAt,
+		sync.LastError, sync.ConsecutiveFailures, sync.AlertedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update warehouse sync: %w", err)
+	}
+	return nil
+}
+
+// DeleteSync deletes a sync
+func (s *PostgresStore) DeleteSync(ctx context.Context, id int64) error {
+	ctx, cancel := db.StatementContext(ctx)
+	defer cancel()
+	tag, err := s.pool.Exec(ctx, `DELETE FROM warehouse_syncs WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete warehouse sync: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrSyncNotFound
+	}
+	return nil
+}
+
+// ChangeLogHorizon returns the oldest transaction still running
+func (s *PostgresStore) ChangeLogHorizon(ctx context.Context) (int64, error) {
+	var horizon int64
+	if err := queryRow(ctx, s.pool, `SELECT `+changeLogHorizonSQL).Scan(&horizon); err != nil {
+		return 0, fmt.Errorf("failed to read the change log horizon: %w", err)
+	}
+	return horizon, nil
+}
+
+// ListRecordChanges returns changes of the tables by transactions in
+// [fromXact, toXact), in ID order
+func (s *PostgresStore) ListRecordChanges(ctx context.Context, tableNames []string, fromXact, toXact, afterID int64, limit int) ([]RecordChange, error) {
+	query := `
+		SELECT id, table_name, record_id, operation, changed_at
+		FROM record_changes
+		WHERE xact_id >= $1 AND xact_id < $2 AND id > $3 AND table_name = ANY($4)
+		ORDER BY id
+		LIMIT $5
+	`
+	queryCtx, cancel := db.StatementContext(ctx)
+	defer cancel()
+	rows, err := s.pool.Query(queryCtx, query, fromXact, toXact, afterID, tableNames, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query record changes: %w", err)
+	}
+	defer rows.Close()
+
+	changes := []RecordChange{}
+	for rows.Next() {
+		var change RecordChange
+		if err := rows.Scan(&change.ID, &change.TableName, &change.RecordID, &change.Operation, &change.ChangedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan record change: %w", err)
+		}
+		changes = append(changes, change)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query record changes: %w", err)
+	}
+	return changes, nil
+}
+
+// PruneRecordChanges deletes the changes of transactions before every
+// sync's cursor, or before the horizon when there are no syncs. It isn't
+// bound by the statement timeout, since the log may have grown large.
+func (s *PostgresStore) PruneRecordChanges(ctx context.Context) (int64, error) {
+	query := `
+		DELETE FROM record_changes
+		WHERE xact_id < COALESCE((SELECT MIN(cursor_xact_id) FROM warehouse_syncs), ` + changeLogHorizonSQL + `)
+	`
+	tag, err := s.pool.Exec(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune record changes: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// ListTextRecords returns records with IDs after afterID in ID order
+func (s *PostgresStore) ListTextRecords(ctx context.Context, tableName string, columns []string, afterID, limit int) ([][]*string, error) {
+	query, err := textRecordsQuery(tableName, columns, "list_text_records", "id > $1 ORDER BY id LIMIT $2")
+	if err != nil {
+		return nil, err
+	}
+	return s.queryTextRecords(ctx, query, afterID, limit)
+}
+
+// GetTextRecords returns the records of the IDs that still exist
+func (s *PostgresStore) GetTextRecords(ctx context.Context, tableName string, columns []string, ids []int) ([][]*string, error) {
+	query, err := textRecordsQuery(tableName, columns, "get_text_records", "id = ANY($1)")
+	if err != nil {
+		return nil, err
+	}
+	return s.queryTextRecords(ctx, query, ids)
+}
+
+// textRecordsQuery returns the cached statement of an operation selecting
+// columns of a user table as text
+func textRecordsQuery(tableName string, columns []string, operation, where string) (string, error) {
+	if err := ValidateIdentifierSafety(tableName); err != nil {
+		return "", err
+	}
+	for _, name := range columns {
+		if err := ValidateIdentifierSafety(name); err != nil {
+			return "", err
+		}
+	}
+	return recordStatements.get(newStatementKey(tableName, operation, columns), func() string {
+		selects := make([]string, len(columns))
+		for i, name := range columns {
+			selects[i] = name + "::text"
+		}
+		return fmt.Sprintf("SELECT %s FROM %s WHERE %s", strings.Join(selects, ", "), tableName, where)
+	}), nil
+}
+
+// queryTextRecords reads rows of text columns from the primary, so syncs
+// see the changes the change log holds
+func (s *PostgresStore) queryTextRecords(ctx context.Context, query string, args ...any) ([][]*string, error) {
+	queryCtx, cancel := db.StatementContext(ctx)
+	defer cancel()
+	rows, err := s.pool.Query(queryCtx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query records: %w", err)
+	}
+	defer rows.Close()
+
+	var records [][]*string
+	for rows.Next() {
+		values := make([]*string, len(rows.FieldDescriptions()))
+		dest := make([]any, len(values))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("failed to scan record: %w", err)
+		}
+		records = append(records, values)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query records: %w", err)
+	}
+	return records, nil
+}
+
+// scanWarehouseSync reads a row of warehouseSyncColumns
+func scanWarehouseSync(row pgx.Row) (*WarehouseSync, error) {
+	var sync WarehouseSync
+	var minutes int
+	err := row.Scan(
+		&sync.ID,
+		&sync.Destination,
+		&sync.TableIDs,
+		&minutes,
+		&sync.Enabled,
+		&sync.JobID,
+		&sync.cursor,
+		&sync.SnapshotDone,
+		&sync.RowsSynced,
+		&sync.LastSyncedAt,
+		&sync.LastError,
+		&sync.ConsecutiveFailures,
+		&sync.AlertedAt,
+		&sync.CreatedBy,
+		&sync.CreatedAt,
+		&sync.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	sync.Interval = time.Duration(minutes) * time.Minute
+	return &sync, nil
+}
@@ -0,0 +1,36 @@
+package tenancy
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GinMiddleware scopes HTTP requests to their principal's tenant and the
+// environment selected by EnvironmentHeader. It must run after
+// authentication.
+func (p *Provisioner) GinMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scoped, err := p.Scope(c.Request.Context())
+		if err == nil {
+			scoped, err = p.scopeEnvironment(scoped, c.GetHeader(EnvironmentHeader))
+		}
+		if err != nil {
+			code := http.StatusServiceUnavailable
+			switch {
+			case errors.Is(err, ErrInvalidTenant):
+				code = http.StatusForbidden
+			case errors.Is(err, ErrInvalidEnvironment):
+				code = http.StatusBadRequest
+			case errors.Is(err, ErrSandboxDisabled):
+				code = http.StatusPreconditionFailed
+			}
+			c.AbortWithStatusJSON(code, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.Request = c.Request.WithContext(scoped)
+		c.Next()
+	}
+}
@@ -0,0 +1,74 @@
+package tenancy
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// scopeError converts a scoping failure to a gRPC status
+func scopeError(err error) error {
+	switch {
+	case errors.Is(err, ErrInvalidTenant):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, ErrInvalidEnvironment):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, ErrSandboxDisabled):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	default:
+		return status.Error(codes.Unavailable, err.Error())
+	}
+}
+
+// scopeRPC scopes a call to its principal's tenant and the environment
+// selected by its metadata
+func (p *Provisioner) scopeRPC(ctx context.Context) (context.Context, error) {
+	scoped, err := p.Scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var environment string
+	if values := metadata.ValueFromIncomingContext(ctx, EnvironmentHeader); len(values) > 0 {
+		environment = values[0]
+	}
+	return p.scopeEnvironment(scoped, environment)
+}
+
+// UnaryServerInterceptor scopes unary calls to their principal's tenant
+// and selected environment. It must run after authentication.
+func (p *Provisioner) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		scoped, err := p.scopeRPC(ctx)
+		if err != nil {
+			return nil, scopeError(err)
+		}
+		return handler(scoped, req)
+	}
+}
+
+// StreamServerInterceptor scopes streams to their principal's tenant and
+// selected environment. It must run after authentication.
+func (p *Provisioner) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		scoped, err := p.scopeRPC(stream.Context())
+		if err != nil {
+			return scopeError(err)
+		}
+		return handler(srv, &scopedStream{ServerStream: stream, ctx: scoped})
+	}
+}
+
+// scopedStream overrides the context of a server stream
+type scopedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns the stream's context scoped to its tenant
+func (s *scopedStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,98 @@
+package tenancy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"agentic-template/api/requestctx"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// EnvironmentHeader selects the schema environment of a request, as gRPC
+// metadata or an HTTP header. Requests without it use production.
+const EnvironmentHeader = "x-schema-environment"
+
+// Schema environments
+const (
+	EnvironmentProduction = "production"
+	EnvironmentSandbox    = "sandbox" // Shadow copy where schema changes are tried before promotion
+)
+
+// SandboxPrefix prefixes the shadow schema of a sandbox, so the sandbox of
+// tenant_acme is sandbox_tenant_acme and that of the shared schema is
+// sandbox_public. Tenant schemas start with SchemaPrefix, so the two never
+// collide.
+const SandboxPrefix = "sandbox_"
+
+// Errors of environment selection
+var (
+	ErrInvalidEnvironment = errors.New("invalid schema environment")
+	ErrSandboxDisabled    = errors.New("schema sandbox is not enabled")
+)
+
+// SandboxSchema returns the shadow schema of a schema's sandbox; "" is
+// the shared schema
+func SandboxSchema(schema string) string {
+	if schema == "" {
+		schema = "public"
+	}
+	return SandboxPrefix + schema
+}
+
+// InSandbox reports whether a context is scoped to a sandbox
+func InSandbox(ctx context.Context) bool {
+	return strings.HasPrefix(requestctx.TenantSchema(ctx), SandboxPrefix)
+}
+
+// EnableSandbox lets requests select the sandbox environment
+func (p *Provisioner) EnableSandbox() {
+	p.sandbox = true
+}
+
+// Sandbox returns a context, already scoped to its tenant, whose queries
+// use the tenant's sandbox, provisioning its metadata tables on first use
+func (p *Provisioner) Sandbox(ctx context.Context) (context.Context, error) {
+	if !p.sandbox {
+		return nil, ErrSandboxDisabled
+	}
+	schema := SandboxSchema(requestctx.TenantSchema(ctx))
+	if err := p.ensure(ctx, schema); err != nil {
+		return nil, fmt.Errorf("failed to provision sandbox %s: %w", schema, err)
+	}
+	return requestctx.WithTenant(ctx, requestctx.Tenant(ctx), schema), nil
+}
+
+// ResetSandbox drops the sandbox of a context's tenant with its tables and
+// returns a context scoped to a new, empty one
+func (p *Provisioner) ResetSandbox(ctx context.Context) (context.Context, error) {
+	if !p.sandbox {
+		return nil, ErrSandboxDisabled
+	}
+	pool := p.dbManager.GetWritePool()
+	if pool == nil {
+		return nil, errors.New("database not connected")
+	}
+
+	schema := SandboxSchema(requestctx.TenantSchema(ctx))
+	if _, err := pool.Exec(ctx, "DROP SCHEMA IF EXISTS "+pgx.Identifier{schema}.Sanitize()+" CASCADE"); err != nil {
+		return nil, fmt.Errorf("failed to drop sandbox %s: %w", schema, err)
+	}
+	p.provisioned.Delete(schema)
+	return p.Sandbox(ctx)
+}
+
+// scopeEnvironment scopes a context, already scoped to its tenant, to the
+// selected environment
+func (p *Provisioner) scopeEnvironment(ctx context.Context, environment string) (context.Context, error) {
+	switch environment {
+	case "", EnvironmentProduction:
+		return ctx, nil
+	case EnvironmentSandbox:
+		return p.Sandbox(ctx)
+	default:
+		return nil, fmt.Errorf("%w: %q (expected %s or %s)", ErrInvalidEnvironment, environment, EnvironmentProduction, EnvironmentSandbox)
+	}
+}
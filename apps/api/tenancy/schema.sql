@@ -0,0 +1,218 @@
+-- Tenant schema template
+-- Metadata tables of one tenant, created in its schema with search_path set
+-- to it. Keep in step with the metadata migrations (001, 004, 011, 015, 016, 017, 021, 022, 023,
+-- 024, 025, 026, 027) and table usage (014); every statement must be idempotent since provisioning
+-- reruns it.
+
+CREATE TABLE IF NOT EXISTS configurable_tables (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    table_name TEXT NOT NULL UNIQUE,
+    description TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_by TEXT,
+    deleted_at TIMESTAMPTZ,
+    deleted_by TEXT
+);
+
+-- Columns added after tenants were first provisioned (016)
+ALTER TABLE configurable_tables
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS deleted_by TEXT;
+
+-- Columns added after tenants were first provisioned (026)
+ALTER TABLE configurable_tables
+    ADD COLUMN IF NOT EXISTS naming JSONB;
+
+CREATE INDEX IF NOT EXISTS idx_configurable_tables_deleted_at ON configurable_tables(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_configurable_tables_table_name ON configurable_tables(table_name);
+
+CREATE TABLE IF NOT EXISTS configurable_columns (
+    id SERIAL PRIMARY KEY,
+    table_id INTEGER NOT NULL REFERENCES configurable_tables(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    column_name TEXT NOT NULL,
+    data_type TEXT NOT NULL,
+    postgres_type TEXT NOT NULL,
+    is_nullable BOOLEAN NOT NULL DEFAULT true,
+    is_unique BOOLEAN NOT NULL DEFAULT false,
+    default_value TEXT,
+    foreign_key_to_table_id INTEGER REFERENCES configurable_tables(id) ON DELETE SET NULL,
+    display_order INTEGER NOT NULL DEFAULT 0,
+    vector_dimensions INTEGER,
+    vector_index_type TEXT,
+    format_timezone TEXT,
+    format_date TEXT,
+    format_number_locale TEXT,
+    format_currency TEXT,
+    format_currency_display TEXT,
+    formula TEXT,
+    deny_read_roles TEXT,
+    deny_write_roles TEXT,
+    description TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (table_id, column_name)
+);
+
+-- Columns added after tenants were first provisioned (015)
+ALTER TABLE configurable_columns
+    ADD COLUMN IF NOT EXISTS format_timezone TEXT,
+    ADD COLUMN IF NOT EXISTS format_date TEXT,
+    ADD COLUMN IF NOT EXISTS format_number_locale TEXT,
+    ADD COLUMN IF NOT EXISTS format_currency TEXT,
+    ADD COLUMN IF NOT EXISTS format_currency_display TEXT;
+
+-- Columns added after tenants were first provisioned (017)
+ALTER TABLE configurable_columns
+    ADD COLUMN IF NOT EXISTS formula TEXT;
+
+-- Columns added after tenants were first provisioned (021)
+ALTER TABLE configurable_columns
+    ADD COLUMN IF NOT EXISTS deny_read_roles TEXT,
+    ADD COLUMN IF NOT EXISTS deny_write_roles TEXT;
+
+-- Columns added after tenants were first provisioned (027)
+ALTER TABLE configurable_columns
+    ADD COLUMN IF NOT EXISTS description TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_configurable_columns_table_id ON configurable_columns(table_id);
+CREATE INDEX IF NOT EXISTS idx_configurable_columns_fk ON configurable_columns(foreign_key_to_table_id);
+CREATE INDEX IF NOT EXISTS idx_configurable_tables_search ON configurable_tables USING GIN (
+    (setweight(to_tsvector('simple', name), 'A') || setweight(to_tsvector('simple', COALESCE(description, '')), 'B'))
+);
+CREATE INDEX IF NOT EXISTS idx_configurable_columns_search ON configurable_columns USING GIN (
+    (setweight(to_tsvector('simple', name || ' ' || column_name), 'A') || setweight(to_tsvector('simple', COALESCE(description, '')), 'B'))
+);
+
+CREATE TABLE IF NOT EXISTS schema_change_log (
+    id SERIAL PRIMARY KEY,
+    table_id INTEGER REFERENCES configurable_tables(id) ON DELETE SET NULL,
+    change_type TEXT NOT NULL,
+    change_details JSONB NOT NULL,
+    executed_sql TEXT,
+    status TEXT NOT NULL,
+    error_message TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_by TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_schema_change_log_table_id ON schema_change_log(table_id);
+CREATE INDEX IF NOT EXISTS idx_schema_change_log_created_at ON schema_change_log(created_at DESC);
+
+CREATE TABLE IF NOT EXISTS schema_change_requests (
+    id SERIAL PRIMARY KEY,
+    change_type TEXT NOT NULL,
+    table_id INTEGER REFERENCES configurable_tables(id) ON DELETE SET NULL,
+    change_details JSONB NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    requested_by TEXT,
+    reviewed_by TEXT,
+    review_comment TEXT,
+    error_message TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    reviewed_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_schema_change_requests_status ON schema_change_requests(status, id);
+
+CREATE TABLE IF NOT EXISTS data_imports (
+    id SERIAL PRIMARY KEY,
+    source TEXT NOT NULL,
+    source_table TEXT NOT NULL,
+    table_id INTEGER REFERENCES configurable_tables(id) ON DELETE SET NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    job_id BIGINT,
+    rows_total BIGINT NOT NULL DEFAULT 0,
+    rows_copied BIGINT NOT NULL DEFAULT 0,
+    rows_failed BIGINT NOT NULL DEFAULT 0,
+    error_message TEXT,
+    created_by TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMPTZ
+);
+
+-- Columns added after tenants were first provisioned (024)
+ALTER TABLE data_imports
+    ADD COLUMN IF NOT EXISTS sync_interval_minutes INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_data_imports_status ON data_imports(status, id);
+
+CREATE TABLE IF NOT EXISTS record_changes (
+    id BIGSERIAL PRIMARY KEY,
+    table_name TEXT NOT NULL,
+    record_id INTEGER NOT NULL,
+    operation TEXT NOT NULL,
+    xact_id BIGINT NOT NULL,
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_record_changes_xact_id ON record_changes(xact_id);
+
+CREATE TABLE IF NOT EXISTS warehouse_syncs (
+    id SERIAL PRIMARY KEY,
+    destination TEXT NOT NULL,
+    table_ids INTEGER[] NOT NULL DEFAULT '{}',
+    interval_minutes INTEGER NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT true,
+    job_id BIGINT,
+    cursor_xact_id BIGINT NOT NULL DEFAULT 0,
+    snapshot_done BOOLEAN NOT NULL DEFAULT false,
+    rows_synced BIGINT NOT NULL DEFAULT 0,
+    last_synced_at TIMESTAMPTZ,
+    last_error TEXT,
+    consecutive_failures INTEGER NOT NULL DEFAULT 0,
+    alerted_at TIMESTAMPTZ,
+    created_by TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS table_usage (
+    table_id INTEGER NOT NULL,
+    bucket TIMESTAMPTZ NOT NULL,
+    reads BIGINT NOT NULL DEFAULT 0,
+    writes BIGINT NOT NULL DEFAULT 0,
+    errors BIGINT NOT NULL DEFAULT 0,
+    latency_counts BIGINT[] NOT NULL,
+    PRIMARY KEY (table_id, bucket)
+);
+
+CREATE INDEX IF NOT EXISTS idx_table_usage_bucket ON table_usage(bucket);
+
+-- The trigger function is shared from the public schema (migration 001)
+CREATE OR REPLACE TRIGGER update_configurable_tables_updated_at
+    BEFORE UPDATE ON configurable_tables
+    FOR EACH ROW
+    EXECUTE FUNCTION public.update_updated_at_column();
+
+CREATE OR REPLACE TRIGGER update_configurable_columns_updated_at
+    BEFORE UPDATE ON configurable_columns
+    FOR EACH ROW
+    EXECUTE FUNCTION public.update_updated_at_column();
+
+CREATE OR REPLACE TRIGGER update_data_imports_updated_at
+    BEFORE UPDATE ON data_imports
+    FOR EACH ROW
+    EXECUTE FUNCTION public.update_updated_at_column();
+
+CREATE OR REPLACE TRIGGER update_warehouse_syncs_updated_at
+    BEFORE UPDATE ON warehouse_syncs
+    FOR EACH ROW
+    EXECUTE FUNCTION public.update_updated_at_column();
+
+-- Log the changes of user tables created before the change log (025)
+DO $$
+DECLARE
+    user_table TEXT;
+BEGIN
+    FOR user_table IN
+        SELECT t.table_name FROM configurable_tables t
+        JOIN pg_tables p ON p.schemaname = current_schema() AND p.tablename = t.table_name
+    LOOP
+        EXECUTE format('CREATE OR REPLACE TRIGGER %I AFTER INSERT OR UPDATE OR DELETE ON %I.%I FOR EACH ROW EXECUTE FUNCTION public.log_record_change()',
+            'log_' || user_table || '_changes', current_schema(), user_table);
+    END LOOP;
+END $$;
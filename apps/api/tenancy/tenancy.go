@@ -0,0 +1,124 @@
+// Package tenancy isolates tenants in PostgreSQL schemas. Each tenant's
+// user tables and their metadata live in a tenant_<name> schema; requests
+// are scoped to their principal's tenant through the connection's
+// search_path.
+package tenancy
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+
+	"agentic-template/api/db"
+	"agentic-template/api/requestctx"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// SchemaPrefix prefixes the schema of every tenant
+const SchemaPrefix = "tenant_"
+
+// ErrInvalidTenant is returned for tenant names that can't name a schema
+var ErrInvalidTenant = errors.New("invalid tenant")
+
+// tenantPattern limits tenant names to lowercase identifiers that fit in a
+// schema name with the prefix
+var tenantPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_]{0,39}$`)
+
+//go:embed schema.sql
+var schemaSQL string
+
+// SchemaName returns the schema holding a tenant's tables
+func SchemaName(tenant string) (string, error) {
+	if !tenantPattern.MatchString(tenant) {
+		return "", fmt.Errorf("%w: %q must be 1-40 lowercase letters, digits, or underscores", ErrInvalidTenant, tenant)
+	}
+	return SchemaPrefix + tenant, nil
+}
+
+// Provisioner creates tenant schemas on first use
+type Provisioner struct {
+	dbManager   *db.Manager
+	provisioned sync.Map // schema -> struct{}
+	sandbox     bool     // Requests may select the sandbox environment
+}
+
+// NewProvisioner creates a provisioner for the managed database. Schemas
+// are checked again after a reload since the new URL may name another
+// database.
+func NewProvisioner(dbManager *db.Manager) *Provisioner {
+	p := &Provisioner{dbManager: dbManager}
+	dbManager.OnReload(func(*db.DB) { p.provisioned.Clear() })
+	return p
+}
+
+// Ensure creates a tenant's schema and metadata tables unless they were
+// already created by this process, and returns the schema name
+func (p *Provisioner) Ensure(ctx context.Context, tenant string) (string, error) {
+	schema, err := SchemaName(tenant)
+	if err != nil {
+		return "", err
+	}
+	if err := p.ensure(ctx, schema); err != nil {
+		return "", fmt.Errorf("failed to provision tenant %s: %w", tenant, err)
+	}
+	return schema, nil
+}
+
+// ensure creates a schema and its metadata tables unless this process
+// already did
+func (p *Provisioner) ensure(ctx context.Context, schema string) error {
+	if _, done := p.provisioned.Load(schema); done {
+		return nil
+	}
+
+	pool := p.dbManager.GetWritePool()
+	if pool == nil {
+		// Queries fail on their own until the database connects
+		return nil
+	}
+
+	err := db.WithTx(ctx, pool, func(tx pgx.Tx) error {
+		// Serialize concurrent first requests of the same schema
+		if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock(hashtext($1))", schema); err != nil {
+			return fmt.Errorf("failed to lock schema: %w", err)
+		}
+
+		ident := pgx.Identifier{schema}.Sanitize()
+		if _, err := tx.Exec(ctx, "CREATE SCHEMA IF NOT EXISTS "+ident); err != nil {
+			return fmt.Errorf("failed to create schema: %w", err)
+		}
+		if _, err := tx.Exec(ctx, "SET LOCAL search_path TO "+ident+", public"); err != nil {
+			return fmt.Errorf("failed to scope provisioning: %w", err)
+		}
+		if _, err := tx.Exec(ctx, schemaSQL); err != nil {
+			return fmt.Errorf("failed to create metadata tables: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	p.provisioned.Store(schema, struct{}{})
+	return nil
+}
+
+// Scope returns a context whose queries use the tenant schema of its
+// principal, provisioning the schema on first use. Requests without a
+// principal or tenant keep the shared schema.
+func (p *Provisioner) Scope(ctx context.Context) (context.Context, error) {
+	principal, ok := requestctx.PrincipalFrom(ctx)
+	if !ok || principal.Tenant == "" {
+		return ctx, nil
+	}
+
+	schema, err := p.Ensure(ctx, principal.Tenant)
+	if err != nil {
+		return nil, err
+	}
+	return requestctx.WithTenant(ctx, principal.Tenant, schema), nil
+}
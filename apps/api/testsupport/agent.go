@@ -0,0 +1,55 @@
+package testsupport
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"agentic-template/api/agent"
+	"agentic-template/api/agent/mockllm"
+
+	"github.com/tmc/langchaingo/tools"
+)
+
+// Respond returns a fixture step giving a final answer
+func Respond(response string) mockllm.Step {
+	return mockllm.Step{Response: response}
+}
+
+// CallTool returns a fixture step calling a tool with the given input
+func CallTool(tool, input string) mockllm.Step {
+	return mockllm.Step{Tool: tool, ToolInput: input}
+}
+
+// MockFixture writes a fixture replaying steps in order to a temporary file
+// and returns its path, for configs taking a MOCK_LLM_FIXTURE path
+func MockFixture(t testing.TB, steps ...mockllm.Step) string {
+	t.Helper()
+	data, err := json.Marshal(mockllm.Fixture{Steps: steps})
+	if err != nil {
+		t.Fatalf("testsupport: failed to encode fixture: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "fixture.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("testsupport: failed to write fixture: %v", err)
+	}
+	return path
+}
+
+// MockAgent returns an initialized agent on the mock LLM, which replays
+// steps in order, with the given tools. Agents need at least one tool.
+func MockAgent(t testing.TB, steps []mockllm.Step, agentTools ...tools.Tool) *agent.Agent {
+	t.Helper()
+	a, err := agent.NewAgent(agent.Config{Provider: "mock", MockFixture: MockFixture(t, steps...)})
+	if err != nil {
+		t.Fatalf("testsupport: failed to create agent: %v", err)
+	}
+	for _, tool := range agentTools {
+		a.AddTool(tool)
+	}
+	if err := a.Initialize(); err != nil {
+		t.Fatalf("testsupport: failed to initialize agent: %v", err)
+	}
+	return a
+}
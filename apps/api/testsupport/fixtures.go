@@ -0,0 +1,78 @@
+package testsupport
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+
+	"agentic-template/api/requestctx"
+	"agentic-template/api/schema_manager"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// FixtureUser is the principal recorded as the creator of fixture tables
+const FixtureUser = "testsupport"
+
+// SchemaManager returns a schema manager on a fresh database
+func SchemaManager(t testing.TB) *schema_manager.SchemaManager {
+	t.Helper()
+	return schema_manager.NewSchemaManager(Pool(t))
+}
+
+// Column returns a nullable column definition of the given type
+func Column(name string, dataType schema_manager.DataType) schema_manager.ColumnDefinition {
+	return schema_manager.ColumnDefinition{Name: name, DataType: dataType, IsNullable: true}
+}
+
+// CreateTable creates a table through the schema manager, failing the test
+// on error
+func CreateTable(t testing.TB, sm *schema_manager.SchemaManager, name string, columns ...schema_manager.ColumnDefinition) *schema_manager.TableDefinition {
+	t.Helper()
+	table, err := sm.CreateTable(requestctx.WithActor(context.Background(), FixtureUser), schema_manager.CreateTableRequest{Name: name, Columns: columns})
+	if err != nil {
+		t.Fatalf("testsupport: failed to create table %q: %v", name, err)
+	}
+	return table
+}
+
+// InsertRecord inserts a row into a user table and returns its ID. values
+// are keyed by column name, e.g. "name" for a column created as "Name".
+func InsertRecord(t testing.TB, pool *pgxpool.Pool, table *schema_manager.TableDefinition, values map[string]any) int {
+	t.Helper()
+	columns := slices.Sorted(maps.Keys(values))
+	names := make([]string, len(columns))
+	placeholders := make([]string, len(columns))
+	args := make([]any, len(columns))
+	for i, column := range columns {
+		names[i] = pgx.Identifier{column}.Sanitize()
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		args[i] = values[column]
+	}
+
+	query := "INSERT INTO " + pgx.Identifier{table.TableName}.Sanitize() + " DEFAULT VALUES RETURNING id"
+	if len(columns) > 0 {
+		query = fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) RETURNING id",
+			pgx.Identifier{table.TableName}.Sanitize(), strings.Join(names, ", "), strings.Join(placeholders, ", "))
+	}
+	var id int
+	if err := pool.QueryRow(context.Background(), query, args...).Scan(&id); err != nil {
+		t.Fatalf("testsupport: failed to insert into %s: %v", table.TableName, err)
+	}
+	return id
+}
+
+// CountRecords returns the number of rows in a user table
+func CountRecords(t testing.TB, pool *pgxpool.Pool, table *schema_manager.TableDefinition) int {
+	t.Helper()
+	var count int
+	query := "SELECT COUNT(*) FROM " + pgx.Identifier{table.TableName}.Sanitize()
+	if err := pool.QueryRow(context.Background(), query).Scan(&count); err != nil {
+		t.Fatalf("testsupport: failed to count %s: %v", table.TableName, err)
+	}
+	return count
+}
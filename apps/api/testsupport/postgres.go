@@ -0,0 +1,275 @@
+// Package testsupport sets up end-to-end tests: a migrated Postgres
+// database per test, fixtures for tables and records, and agents backed by
+// the mock LLM.
+//
+// Postgres comes from TEST_DATABASE_URL when it is set, for example a CI
+// service container, and otherwise from a pgvector container started with
+// the docker CLI on first use. Tests that need it are skipped when neither is
+// available. Every package using the database helpers must run its tests
+// through Main, which removes the container and databases afterwards:
+//
+//	func TestMain(m *testing.M) { testsupport.Main(m) }
+//
+//	func TestCreateTable(t *testing.T) {
+//		sm := testsupport.SchemaManager(t)
+//		table := testsupport.CreateTable(t, sm, "Customers", testsupport.Column("Name", schema_manager.DataTypeText))
+//		testsupport.InsertRecord(t, testsupport.Pool(t), table, map[string]any{"name": "Ada"})
+//	}
+package testsupport
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	osexec "os/exec"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"agentic-template/api/db"
+	"agentic-template/api/db/migrations"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Image is the container image started when TEST_DATABASE_URL is unset. It
+// ships pgvector, which the migrations require.
+const Image = "pgvector/pgvector:pg16"
+
+// startTimeout bounds starting the container and waiting for Postgres
+const startTimeout = 2 * time.Minute
+
+var (
+	mainRunning bool
+
+	serverOnce sync.Once
+	serverURL  string // Maintenance database of the server
+	serverErr  error
+	container  string // ID of the started container, if any
+	template   string // Migrated database new test databases are copied from
+
+	createMu sync.Mutex // CREATE DATABASE ... TEMPLATE allows one copy at a time
+
+	pools sync.Map // Pool of each running test, by testing.TB
+)
+
+// Main runs the tests, then removes the databases and the container they
+// used, and exits with the result
+func Main(m *testing.M) {
+	mainRunning = true
+	code := m.Run()
+	if err := teardown(); err != nil {
+		fmt.Fprintf(os.Stderr, "testsupport: %v\n", err)
+	}
+	os.Exit(code)
+}
+
+// URL creates a fresh database with every migration applied and returns
+// its connection string. The database is dropped when the test ends.
+func URL(t testing.TB) string {
+	t.Helper()
+	if !mainRunning {
+		t.Fatal("testsupport: run the package's tests through testsupport.Main in TestMain")
+	}
+	if testing.Short() {
+		t.Skip("testsupport: skipping database test in short mode")
+	}
+	serverOnce.Do(func() {
+		serverErr = setup()
+	})
+	if serverErr != nil {
+		t.Skipf("testsupport: no database: %v", serverErr)
+	}
+
+	name := "test_" + randomSuffix()
+	ctx, cancel := context.WithTimeout(context.Background(), startTimeout)
+	defer cancel()
+	createMu.Lock()
+	err := exec(ctx, serverURL, "CREATE DATABASE "+pgx.Identifier{name}.Sanitize()+" TEMPLATE "+pgx.Identifier{template}.Sanitize())
+	createMu.Unlock()
+	if err != nil {
+		t.Fatalf("testsupport: failed to create database: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := dropDatabase(name); err != nil {
+			t.Errorf("testsupport: %v", err)
+		}
+	})
+	return databaseURL(serverURL, name)
+}
+
+// Pool returns a pool connected to the test's database, created fresh on
+// first use and closed when the test ends. Later calls in the same test,
+// including through SchemaManager, share it.
+func Pool(t testing.TB) *pgxpool.Pool {
+	t.Helper()
+	if pool, ok := pools.Load(t); ok {
+		return pool.(*pgxpool.Pool)
+	}
+	pool, err := pgxpool.New(context.Background(), URL(t))
+	if err != nil {
+		t.Fatalf("testsupport: failed to connect: %v", err)
+	}
+	pools.Store(t, pool)
+	t.Cleanup(func() {
+		pools.Delete(t)
+		pool.Close()
+	})
+	return pool
+}
+
+// Manager returns a database manager connected to a fresh database, for
+// subsystems that take one, closed when the test ends
+func Manager(t testing.TB) *db.Manager {
+	t.Helper()
+	manager := db.NewManager()
+	poolConfig := db.DefaultPoolConfig()
+	poolConfig.MinConns = 0
+	manager.SetPoolConfig(poolConfig)
+	if err := manager.Initialize(URL(t), ""); err != nil {
+		t.Fatalf("testsupport: failed to connect: %v", err)
+	}
+	t.Cleanup(manager.Close)
+	return manager
+}
+
+// setup connects to the server, starting a container when needed, and
+// migrates the template database
+func setup() error {
+	ctx, cancel := context.WithTimeout(context.Background(), startTimeout)
+	defer cancel()
+
+	serverURL = os.Getenv("TEST_DATABASE_URL")
+	if serverURL == "" {
+		var err error
+		if serverURL, err = startContainer(ctx); err != nil {
+			return err
+		}
+	}
+	if err := waitReady(ctx, serverURL); err != nil {
+		return err
+	}
+
+	template = fmt.Sprintf("testsupport_template_%d_%s", os.Getpid(), randomSuffix())
+	if err := exec(ctx, serverURL, "CREATE DATABASE "+pgx.Identifier{template}.Sanitize()); err != nil {
+		return fmt.Errorf("failed to create template database: %w", err)
+	}
+	pool, err := pgxpool.New(ctx, databaseURL(serverURL, template))
+	if err != nil {
+		return err
+	}
+	// Copies fail while the template has connections, so close it first
+	defer pool.Close()
+	if err := migrations.RunMigrations(ctx, pool); err != nil {
+		return fmt.Errorf("failed to migrate template database: %w", err)
+	}
+	return nil
+}
+
+// startContainer runs Postgres in a container on a free local port and
+// returns its URL
+func startContainer(ctx context.Context) (string, error) {
+	if _, err := osexec.LookPath("docker"); err != nil {
+		return "", errors.New("set TEST_DATABASE_URL or install docker")
+	}
+	out, err := osexec.CommandContext(ctx, "docker", "run", "--detach", "--rm",
+		"--label", "agentic-template.testsupport=true",
+		"--env", "POSTGRES_PASSWORD=test",
+		"--publish", "127.0.0.1::5432",
+		Image,
+	).Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to start %s: %w", Image, commandError(err))
+	}
+	container = strings.TrimSpace(string(out))
+
+	out, err = osexec.CommandContext(ctx, "docker", "port", container, "5432/tcp").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to find the container's port: %w", commandError(err))
+	}
+	address := strings.TrimSpace(strings.SplitN(string(out), "\n", 2)[0])
+	return "postgres://postgres:test@" + address + "/postgres?sslmode=disable", nil
+}
+
+// waitReady polls until the server accepts connections
+func waitReady(ctx context.Context, serverURL string) error {
+	for {
+		err := exec(ctx, serverURL, "SELECT 1")
+		if err == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("database did not become ready: %w", err)
+		case <-time.After(250 * time.Millisecond):
+		}
+	}
+}
+
+// teardown drops the template database and removes the container
+func teardown() error {
+	if container != "" {
+		if err := osexec.Command("docker", "rm", "--force", container).Run(); err != nil {
+			return fmt.Errorf("failed to remove container %s: %w", container, err)
+		}
+		return nil
+	}
+	if template != "" {
+		return dropDatabase(template)
+	}
+	return nil
+}
+
+// dropDatabase drops a database, disconnecting its remaining sessions
+func dropDatabase(name string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := exec(ctx, serverURL, "DROP DATABASE IF EXISTS "+pgx.Identifier{name}.Sanitize()+" WITH (FORCE)"); err != nil {
+		return fmt.Errorf("failed to drop database %s: %w", name, err)
+	}
+	return nil
+}
+
+// exec runs a statement on a connection of its own
+func exec(ctx context.Context, connURL, sql string) error {
+	conn, err := pgx.Connect(ctx, connURL)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+	_, err = conn.Exec(ctx, sql)
+	return err
+}
+
+// databaseURL returns serverURL pointing at another database
+func databaseURL(serverURL, name string) string {
+	u, err := url.Parse(serverURL)
+	if err != nil || u.Scheme == "" {
+		// Keyword/value connection strings; the last dbname wins
+		return serverURL + " dbname=" + name
+	}
+	u.Path = "/" + name
+	return u.String()
+}
+
+// randomSuffix returns a short random identifier
+func randomSuffix() string {
+	b := make([]byte, 6)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// commandError includes a failed command's stderr in its error
+func commandError(err error) error {
+	var exitErr *osexec.ExitError
+	if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	return err
+}
@@ -0,0 +1,52 @@
+package tracing
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ginTracer creates the server span of each HTTP request
+var ginTracer = otel.Tracer("agentic-template/api/http")
+
+// GinMiddleware starts a server span per request, continuing the caller's
+// trace from its traceparent header. The span is named after the matched
+// route so requests for different IDs group together.
+func GinMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		ctx, span := ginTracer.Start(ctx, c.Request.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", c.Request.Method),
+				attribute.String("http.route", route),
+				attribute.String("url.path", c.Request.URL.Path),
+				attribute.String("client.address", c.ClientIP()),
+				attribute.String("user_agent.original", c.Request.UserAgent()),
+			),
+		)
+		defer span.End()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if status >= 500 {
+			span.SetStatus(codes.Error, fmt.Sprintf("HTTP %d", status))
+		}
+		if len(c.Errors) > 0 {
+			span.RecordError(c.Errors.Last())
+		}
+	}
+}
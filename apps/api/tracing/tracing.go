@@ -0,0 +1,105 @@
+// Package tracing sets up OpenTelemetry tracing: the OTLP exporter, the
+// resource describing this service, sampling, and W3C context propagation.
+// Spans come from the Gin middleware here, the gRPC stats handlers, the
+// database query tracer, the schema manager, and the agent.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// Config configures trace export
+type Config struct {
+	// Endpoint is the collector's OTLP URL, such as http://collector:4317;
+	// empty disables export. An http:// URL is dialed without TLS.
+	Endpoint string
+	// Protocol is "grpc" or "http/protobuf"
+	Protocol string
+	// SampleRatio is the share of new traces recorded; calls continuing a
+	// caller's trace follow the caller's decision
+	SampleRatio float64
+
+	ServiceName    string
+	ServiceVersion string
+	Environment    string
+}
+
+// Setup installs the W3C trace context and baggage propagators and, when an
+// endpoint is configured, a tracer provider exporting spans over OTLP. The
+// returned function flushes pending spans and stops export.
+func Setup(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	// Continue traces started by callers even when not exporting, so
+	// downstream services still see them
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+	if cfg.Endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := newExporter(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// OTEL_RESOURCE_ATTRIBUTES and OTEL_SERVICE_NAME override these
+	res, err := resource.New(ctx,
+		resource.WithAttributes(
+			attribute.String("service.name", cfg.ServiceName),
+			attribute.String("service.version", cfg.ServiceVersion),
+			attribute.String("deployment.environment", cfg.Environment),
+		),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+		resource.WithHost(),
+		resource.WithProcessPID(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe service resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// newExporter creates the OTLP exporter for the configured protocol.
+// Headers such as credentials are read from OTEL_EXPORTER_OTLP_HEADERS.
+func newExporter(ctx context.Context, cfg Config) (sdktrace.SpanExporter, error) {
+	switch strings.ToLower(cfg.Protocol) {
+	case "", "grpc":
+		exporter, err := otlptracegrpc.New(ctx, otlptracegrpc.WithEndpointURL(cfg.Endpoint))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create OTLP gRPC exporter: %w", err)
+		}
+		return exporter, nil
+	case "http/protobuf", "http":
+		// Like OTEL_EXPORTER_OTLP_ENDPOINT, the URL is the base of the signal paths
+		endpoint := cfg.Endpoint
+		if !strings.HasSuffix(endpoint, "/v1/traces") {
+			endpoint = strings.TrimSuffix(endpoint, "/") + "/v1/traces"
+		}
+		exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(endpoint))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create OTLP HTTP exporter: %w", err)
+		}
+		return exporter, nil
+	default:
+		return nil, fmt.Errorf("unsupported OTLP protocol %q, use grpc or http/protobuf", cfg.Protocol)
+	}
+}
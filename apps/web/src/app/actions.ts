@@ -38,7 +38,7 @@ interface ToolCall {
 }
 
 // Load the protobuf definition
-const PROTO_PATH = path.join(process.cwd(), '../../packages/proto/service.proto')
+const PROTO_PATH = path.join(process.cwd(), '../../packages/proto/v1/service.proto')
 
 let client: AgentService | null = null
 
@@ -10,7 +10,8 @@
 import { schemaService } from '@/lib/grpc/schema-client'
 import type {
   CreateTableRequestProto,
-  ColumnDefinitionProto
+  ColumnDefinitionProto,
+  TableDefinitionProto
 } from '@/lib/grpc/schema-client'
 
 // Type definitions matching the backend schema
@@ -300,17 +301,25 @@ export async function getTable(tableId: number): Promise<ApiResponse<TableDefini
  */
 export async function listTables(): Promise<ApiResponse<TableDefinition[]>> {
   try {
-    const response = await schemaService.listTables({})
+    // Collect every page of tables
+    const tableProtos: TableDefinitionProto[] = []
+    let pageToken = ''
+    do {
+      const response = await schemaService.listTables({ page_size: 500, page_token: pageToken })
 
-    if (!response.success) {
-      return {
-        success: false,
-        error: response.message || 'Failed to list tables'
+      if (!response.success) {
+        return {
+          success: false,
+          error: response.message || 'Failed to list tables'
+        }
       }
-    }
+
+      tableProtos.push(...response.tables)
+      pageToken = response.next_page_token
+    } while (pageToken)
 
     // Map protobuf response to our type
-    const tables: TableDefinition[] = response.tables.map((table) => ({
+    const tables: TableDefinition[] = tableProtos.map((table) => ({
       id: table.id,
       name: table.name,
       table_name: table.table_name,
@@ -335,7 +344,7 @@ export async function listTables(): Promise<ApiResponse<TableDefinition[]>> {
     return {
       success: true,
       data: tables,
-      message: response.message
+      message: `Found ${tables.length} table(s)`
     }
   } catch (error) {
     console.error('Failed to list tables:', error)
@@ -5,7 +5,7 @@ import * as protoLoader from '@grpc/proto-loader'
 import path from 'path'
 
 // Load the protobuf definition
-const PROTO_PATH = path.join(process.cwd(), '../../packages/proto/service.proto')
+const PROTO_PATH = path.join(process.cwd(), '../../packages/proto/v1/service.proto')
 
 const packageDefinition = protoLoader.loadSync(PROTO_PATH, {
   keepCase: true,
@@ -59,10 +59,19 @@ export interface CreateTableResponseProto {
   table?: TableDefinitionProto
 }
 
+export interface ListTablesRequestProto {
+  page_size?: number
+  page_token?: string
+  name_prefix?: string
+  sort?: string
+}
+
 export interface ListTablesResponseProto {
   success: boolean
   message?: string
   tables: TableDefinitionProto[]
+  next_page_token: string
+  total_size: number
 }
 
 export interface GetTableResponseProto {
@@ -101,7 +110,7 @@ interface SchemaServiceClient {
   ): void
 
   ListTables(
-    request: Record<string, never>,
+    request: ListTablesRequestProto,
     callback: (error: grpc.ServiceError | null, response: ListTablesResponseProto) => void
   ): void
 
@@ -128,7 +137,7 @@ function getSchemaClient(): SchemaServiceClient {
   }
 
   // Locate the proto file
-  const PROTO_PATH = path.join(process.cwd(), '..', '..', 'packages', 'proto', 'service.proto')
+  const PROTO_PATH = path.join(process.cwd(), '..', '..', 'packages', 'proto', 'v1', 'service.proto')
 
   // Load the proto file
   const packageDefinition = protoLoader.loadSync(PROTO_PATH, {
@@ -162,7 +171,8 @@ function promisify<TRequest, TResponse>(
     return new Promise((resolve, reject) => {
       fn.call(getSchemaClient(), request, (error, response) => {
         if (error) {
-          reject(error)
+          // Show the server's message; the status code stays on error.code
+          reject(Object.assign(error, { message: error.details || error.message }))
         } else {
           resolve(response)
         }
@@ -192,7 +202,7 @@ export const schemaService = {
   /**
    * List all user-defined tables
    */
-  listTables: promisify<Record<string, never>, ListTablesResponseProto>(
+  listTables: promisify<ListTablesRequestProto, ListTablesResponseProto>(
     getSchemaClient().ListTables
   ),
 
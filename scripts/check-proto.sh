@@ -0,0 +1,20 @@
+#!/bin/bash
+
+# Fails when the committed TypeScript client is out of date with the proto
+# files, or when a proto change breaks compatibility with main
+
+set -e
+cd "$(dirname "$0")/../packages/proto"
+
+pnpm exec buf generate
+if [ -n "$(git status --porcelain -- gen)" ]; then
+    echo "Generated client is out of date; run pnpm proto:gen and commit packages/proto/gen:"
+    git status --short -- gen
+    exit 1
+fi
+
+if git rev-parse --verify --quiet main > /dev/null; then
+    pnpm exec buf breaking --against '../../.git#branch=main,subdir=packages/proto'
+fi
+
+echo "Proto client is up to date"
@@ -25,6 +25,7 @@ if command -v go &> /dev/null; then
     echo "Installing Go protobuf plugins..."
     go install google.golang.org/protobuf/cmd/protoc-gen-go@latest
     go install google.golang.org/grpc/cmd/protoc-gen-go-grpc@latest
+    go install github.com/grpc-ecosystem/grpc-gateway/v2/protoc-gen-grpc-gateway@latest
     echo "Go plugins installed"
 else
     echo "Go not found, skipping Go plugin installation"